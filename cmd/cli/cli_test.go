@@ -22,6 +22,7 @@ func setupBufConn() (*grpc.Server, func(context.Context, string) (net.Conn, erro
 
 	// Register a mock service
 	proto.RegisterDeploymentServiceServer(s, &mockDeploymentService{})
+	proto.RegisterClusterServiceServer(s, &mockClusterService{})
 
 	// Start the server
 	go func() {
@@ -71,6 +72,26 @@ func (s *mockDeploymentService) GetStatus(ctx context.Context, req *proto.Status
 	}, nil
 }
 
+// mockClusterService is a mock implementation of the ClusterServiceServer interface
+type mockClusterService struct {
+	proto.UnimplementedClusterServiceServer
+}
+
+// DrainNode implements the DrainNode method of the ClusterServiceServer interface
+func (s *mockClusterService) DrainNode(req *proto.DrainNodeRequest, stream proto.ClusterService_DrainNodeServer) error {
+	phases := []*proto.DrainNodeProgress{
+		{Phase: "checking", Message: "Checking capacity on remaining nodes"},
+		{Phase: "waiting", Total: 2, Remaining: 1, Pending: 1},
+		{Phase: "complete", Total: 2},
+	}
+	for _, p := range phases {
+		if err := stream.Send(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // setupTestClient creates a test client that uses the provided dialer
 func setupTestClient(ctx context.Context, dialer func(context.Context, string) (net.Conn, error)) (*client.Client, error) {
 	// Create a connection using the dialer
@@ -185,3 +206,34 @@ func TestRollbackDeployment(t *testing.T) {
 		t.Errorf("Expected message %q, got %q", "Deployment rolled back successfully", resp.Message)
 	}
 }
+
+func TestDrainNode(t *testing.T) {
+	// Set up the test server and client
+	_, dialer, cleanup := setupBufConn()
+	defer cleanup()
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Create a test client
+	c, err := setupTestClient(ctx, dialer)
+	if err != nil {
+		t.Fatalf("Failed to create test client: %v", err)
+	}
+	defer c.Close()
+
+	// Drain a node and collect the progress updates
+	var phases []string
+	err = c.DrainNode(ctx, "node-1", time.Minute, false, func(p *proto.DrainNodeProgress) {
+		phases = append(phases, p.Phase)
+	})
+	if err != nil {
+		t.Fatalf("Failed to drain node: %v", err)
+	}
+
+	// Check the progress updates
+	if len(phases) != 3 || phases[len(phases)-1] != "complete" {
+		t.Errorf("Expected 3 progress updates ending in %q, got %v", "complete", phases)
+	}
+}
@@ -0,0 +1,352 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/api/proto"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ruleCondition string
+	ruleService   string
+	ruleThreshold float64
+	ruleTarget    string
+	ruleWithin    time.Duration
+	ruleFor       time.Duration
+	ruleSeverity  string
+	ruleLabels    map[string]string
+	ruleGroupBy   []string
+	ruleRepeat    time.Duration
+	ruleReceivers []string
+
+	silenceDuration time.Duration
+	silenceComment  string
+)
+
+func init() {
+	alertsCmd := &cobra.Command{
+		Use:   "alerts",
+		Short: "List alerts and manage alert rules and silences",
+		Long: `List the pending and firing alerts. Alerts come from rules evaluated by the
+manager every 30 seconds; firing alerts are sent to the receivers configured
+in the manager's daemon config, grouped and repeated as their rules say.`,
+		Run: runListAlerts,
+	}
+
+	rulesCmd := &cobra.Command{
+		Use:   "rules",
+		Short: "List alert rules",
+		Run:   runListRules,
+	}
+
+	setRuleCmd := &cobra.Command{
+		Use:   "set <name>",
+		Short: "Create or replace an alert rule",
+		Long: `Create or replace an alert rule. Conditions:
+
+  replicas_below  a service runs fewer replicas than desired
+  node_down       a node isn't ready, or its agent stopped sending heartbeats
+  cpu_above       a service's containers on a node average more CPU than --threshold percent
+  deploy_failed   the last deploy of a service failed
+  cert_expiry     the certificate of --target (host:port or PEM file) expires within --within
+
+Rules apply to all clusters unless --cluster is given. For example:
+
+  veloctl alerts rules set web-down --condition replicas_below --service web --for 5m --severity critical
+  veloctl alerts rules set cert --condition cert_expiry --target example.com:443 --within 336h`,
+		Args: cobra.ExactArgs(1),
+		Run:  runSetRule,
+	}
+	setRuleCmd.Flags().StringVar(&ruleCondition, "condition", "", "What the rule checks (see above)")
+	setRuleCmd.Flags().StringVar(&ruleService, "service", "", "Only check this service")
+	setRuleCmd.Flags().Float64Var(&ruleThreshold, "threshold", 0, "CPU percent per container, for cpu_above")
+	setRuleCmd.Flags().StringVar(&ruleTarget, "target", "", "TLS endpoint or PEM file, for cert_expiry")
+	setRuleCmd.Flags().DurationVar(&ruleWithin, "within", 0, "How long before expiry to fire, for cert_expiry (default 336h)")
+	setRuleCmd.Flags().DurationVar(&ruleFor, "for", 0, "How long the condition must hold before the alert fires")
+	setRuleCmd.Flags().StringVar(&ruleSeverity, "severity", "warning", "Severity of the alerts (warning or critical)")
+	setRuleCmd.Flags().StringToStringVar(&ruleLabels, "label", nil, "Extra labels of the alerts (key=value)")
+	setRuleCmd.Flags().StringSliceVar(&ruleGroupBy, "group-by", nil, "Labels whose alerts are notified together, e.g. service")
+	setRuleCmd.Flags().DurationVar(&ruleRepeat, "repeat", 0, "How often to notify again while alerts fire (default: the manager's)")
+	setRuleCmd.Flags().StringSliceVar(&ruleReceivers, "receiver", nil, "Receivers to notify (default: all)")
+	setRuleCmd.MarkFlagRequired("condition")
+
+	deleteRuleCmd := &cobra.Command{
+		Use:   "delete <name>",
+		Short: "Delete an alert rule",
+		Long:  `Delete an alert rule. Its firing alerts are notified as resolved.`,
+		Args:  cobra.ExactArgs(1),
+		Run:   runDeleteRule,
+	}
+
+	silenceCmd := &cobra.Command{
+		Use:   "silence <matcher>...",
+		Short: "Silence alerts",
+		Long: `Mute the notifications of the alerts matching all matchers, e.g.
+service=web or severity!=critical. Silenced alerts are still listed.
+
+  veloctl alerts silence alertname=web-down cluster=prod --duration 2h --comment "planned maintenance"`,
+		Args: cobra.MinimumNArgs(1),
+		Run:  runSilence,
+	}
+	silenceCmd.Flags().DurationVar(&silenceDuration, "duration", 2*time.Hour, "How long the silence lasts")
+	silenceCmd.Flags().StringVar(&silenceComment, "comment", "", "Why the alerts are silenced")
+
+	silencesCmd := &cobra.Command{
+		Use:   "silences",
+		Short: "List silences",
+		Long:  `List the active silences and the ones that ended in the last day.`,
+		Run:   runListSilences,
+	}
+
+	unsilenceCmd := &cobra.Command{
+		Use:   "unsilence <silence-id>",
+		Short: "End a silence",
+		Args:  cobra.ExactArgs(1),
+		Run:   runUnsilence,
+	}
+
+	rulesCmd.AddCommand(setRuleCmd)
+	rulesCmd.AddCommand(deleteRuleCmd)
+
+	alertsCmd.AddCommand(rulesCmd)
+	alertsCmd.AddCommand(silenceCmd)
+	alertsCmd.AddCommand(silencesCmd)
+	alertsCmd.AddCommand(unsilenceCmd)
+
+	rootCmd.AddCommand(alertsCmd)
+}
+
+func runListAlerts(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.ListAlerts(ctx)
+	if err != nil {
+		log.Fatalf("Failed to list alerts: %v", err)
+	}
+	if len(resp.Alerts) == 0 {
+		fmt.Println("No alerts")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STATE\tRULE\tSEVERITY\tSINCE\tSILENCED BY\tSUMMARY")
+	for _, a := range resp.Alerts {
+		silenced := a.SilencedBy
+		if silenced == "" {
+			silenced = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", a.State, a.Rule, a.Labels["severity"],
+			time.Since(time.Unix(a.ActiveSinceUnix, 0)).Round(time.Second), silenced, a.Summary)
+	}
+	w.Flush()
+}
+
+func runListRules(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.ListAlertRules(ctx)
+	if err != nil {
+		log.Fatalf("Failed to list alert rules: %v", err)
+	}
+	if len(resp.Receivers) == 0 {
+		fmt.Println("No receivers are configured on the manager; alerts are listed but not sent")
+	}
+	if len(resp.Rules) == 0 {
+		fmt.Println("No alert rules")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCONDITION\tSCOPE\tFOR\tSEVERITY\tRECEIVERS")
+	for _, rule := range resp.Rules {
+		receivers := strings.Join(rule.Receivers, ",")
+		if receivers == "" {
+			receivers = "(all)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", rule.Name, describeCondition(rule), ruleScope(rule),
+			time.Duration(rule.ForSeconds)*time.Second, rule.Severity, receivers)
+	}
+	w.Flush()
+}
+
+// describeCondition renders a rule's condition with its parameters
+func describeCondition(rule *proto.AlertRule) string {
+	switch rule.Condition {
+	case "cpu_above":
+		return fmt.Sprintf("cpu_above %.0f%%", rule.Threshold)
+	case "cert_expiry":
+		return fmt.Sprintf("cert_expiry %s within %s", rule.Target, time.Duration(rule.WithinSeconds)*time.Second)
+	default:
+		return rule.Condition
+	}
+}
+
+// ruleScope renders which clusters and services a rule checks
+func ruleScope(rule *proto.AlertRule) string {
+	var scope []string
+	if rule.Cluster != "" {
+		scope = append(scope, "cluster="+rule.Cluster)
+	}
+	if rule.Service != "" {
+		scope = append(scope, "service="+rule.Service)
+	}
+	labels := make([]string, 0, len(rule.Labels))
+	for k, v := range rule.Labels {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+	scope = append(scope, labels...)
+	if len(scope) == 0 {
+		return "(all)"
+	}
+	return strings.Join(scope, ",")
+}
+
+func runSetRule(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	rule, err := c.SetAlertRule(ctx, &proto.AlertRule{
+		Name:                  args[0],
+		Condition:             ruleCondition,
+		Cluster:               clusterName,
+		Service:               ruleService,
+		Threshold:             ruleThreshold,
+		Target:                ruleTarget,
+		WithinSeconds:         int64(ruleWithin.Seconds()),
+		ForSeconds:            int64(ruleFor.Seconds()),
+		Severity:              ruleSeverity,
+		Labels:                ruleLabels,
+		GroupBy:               ruleGroupBy,
+		RepeatIntervalSeconds: int64(ruleRepeat.Seconds()),
+		Receivers:             ruleReceivers,
+	})
+	if err != nil {
+		log.Fatalf("Failed to set alert rule: %v", err)
+	}
+	fmt.Printf("Alert rule %s set: %s, scope %s\n", rule.Name, describeCondition(rule), ruleScope(rule))
+}
+
+func runDeleteRule(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.DeleteAlertRule(ctx, args[0])
+	if err != nil {
+		log.Fatalf("Failed to delete alert rule: %v", err)
+	}
+	if !resp.Success {
+		log.Fatalf("%s", resp.Message)
+	}
+	fmt.Println(resp.Message)
+}
+
+func runSilence(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	createdBy := "unknown"
+	if u, err := user.Current(); err == nil {
+		createdBy = u.Username
+	}
+	silence, err := c.CreateSilence(ctx, args, silenceDuration, silenceComment, createdBy)
+	if err != nil {
+		log.Fatalf("Failed to create silence: %v", err)
+	}
+	fmt.Printf("Silence %s created for %s, until %s\n", silence.Id, strings.Join(silence.Matchers, ","),
+		time.Unix(silence.EndsUnix, 0).Format(time.RFC3339))
+}
+
+func runListSilences(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.ListSilences(ctx)
+	if err != nil {
+		log.Fatalf("Failed to list silences: %v", err)
+	}
+	if len(resp.Silences) == 0 {
+		fmt.Println("No silences")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tMATCHERS\tSTATE\tENDS\tCREATED BY\tCOMMENT")
+	for _, s := range resp.Silences {
+		ends := time.Unix(s.EndsUnix, 0)
+		state := "active"
+		if !ends.After(time.Now()) {
+			state = "expired"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", s.Id, strings.Join(s.Matchers, ","), state,
+			ends.Format(time.RFC3339), s.CreatedBy, s.Comment)
+	}
+	w.Flush()
+}
+
+func runUnsilence(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.ExpireSilence(ctx, args[0])
+	if err != nil {
+		log.Fatalf("Failed to expire silence: %v", err)
+	}
+	if !resp.Success {
+		log.Fatalf("%s", resp.Message)
+	}
+	fmt.Println(resp.Message)
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/api/proto"
+	"github.com/jasonlovesdoggo/velo/internal/audit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditActor  string
+	auditSource string
+	auditAction string
+	auditTarget string
+	auditResult string
+	auditSince  string
+	auditUntil  string
+	auditLimit  int
+)
+
+func init() {
+	auditCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Show the audit log",
+		Long: `Show who changed what, newest first: deploys, rollbacks, scaling, node
+operations, token management and logins, from the web UI, the gRPC API and
+the reconcilers. Secrets in the recorded parameters are redacted.
+
+  veloctl audit --actor alice --since 24h
+  veloctl audit --action Deploy --target web --result failure
+  veloctl audit --since 2024-01-01 --limit 0 -o json > audit.jsonl
+
+With -o json the entries are printed as JSON lines, for export.`,
+		Args: cobra.NoArgs,
+		Run:  runAudit,
+	}
+	auditCmd.Flags().StringVar(&auditActor, "actor", "", "Only show the entries of this user")
+	auditCmd.Flags().StringVar(&auditSource, "source", "", "Only show entries from this source (web, grpc, webhook or reconciler)")
+	auditCmd.Flags().StringVar(&auditAction, "action", "", "Only show this action, e.g. Deploy or Login")
+	auditCmd.Flags().StringVar(&auditTarget, "target", "", "Only show the entries of this service, node, user or token")
+	auditCmd.Flags().StringVar(&auditResult, "result", "", "Only show entries with this result (success, failure or denied)")
+	auditCmd.Flags().StringVar(&auditSince, "since", "", "Only show entries after this time (a duration like 24h, a date or an RFC 3339 time)")
+	auditCmd.Flags().StringVar(&auditUntil, "until", "", "Only show entries before this time")
+	auditCmd.Flags().IntVar(&auditLimit, "limit", 50, "How many entries to show (0 for all)")
+
+	rootCmd.AddCommand(auditCmd)
+}
+
+func runAudit(cmd *cobra.Command, args []string) {
+	req := &proto.ListAuditEntriesRequest{
+		Actor:  auditActor,
+		Source: auditSource,
+		Action: auditAction,
+		Target: auditTarget,
+		Result: auditResult,
+		Limit:  int32(auditLimit),
+	}
+	now := time.Now()
+	if auditSince != "" {
+		since, err := audit.ParseTime(auditSince, now)
+		if err != nil {
+			log.Fatalf("Invalid --since: %v", err)
+		}
+		req.SinceUnix = since.Unix()
+	}
+	if auditUntil != "" {
+		until, err := audit.ParseTime(auditUntil, now)
+		if err != nil {
+			log.Fatalf("Invalid --until: %v", err)
+		}
+		req.UntilUnix = until.Unix()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.ListAuditEntries(ctx, req)
+	if err != nil {
+		log.Fatalf("Failed to list audit entries: %v", err)
+	}
+
+	if outputFormat == "json" {
+		entries := make([]audit.Entry, len(resp.Entries))
+		for i, e := range resp.Entries {
+			entries[i] = audit.Entry{
+				ID:      e.Id,
+				Time:    time.Unix(0, e.TimeUnixNano).UTC(),
+				Actor:   e.Actor,
+				Source:  e.Source,
+				IP:      e.Ip,
+				Action:  e.Action,
+				Target:  e.Target,
+				Cluster: e.Cluster,
+				Params:  e.Params,
+				Result:  e.Result,
+				Error:   e.Error,
+			}
+		}
+		if err := audit.WriteJSONL(os.Stdout, entries); err != nil {
+			log.Fatalf("Failed to write audit entries: %v", err)
+		}
+		return
+	}
+
+	if len(resp.Entries) == 0 {
+		fmt.Println("No audit entries")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tACTOR\tSOURCE\tIP\tACTION\tTARGET\tRESULT\tPARAMS")
+	for _, e := range resp.Entries {
+		actor := e.Actor
+		if actor == "" {
+			actor = "-"
+		}
+		target := e.Target
+		if e.Cluster != "" {
+			target = e.Cluster + "/" + target
+		}
+		result := e.Result
+		if e.Error != "" {
+			result += ": " + e.Error
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			time.Unix(0, e.TimeUnixNano).Format(time.RFC3339), actor, e.Source, e.Ip, e.Action, target, result, auditParams(e.Params))
+	}
+	w.Flush()
+}
+
+// auditParams renders an entry's parameters as key=value pairs, sorted by key
+func auditParams(params map[string]string) string {
+	pairs := make([]string, 0, len(params))
+	for k, v := range params {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, " ")
+}
@@ -2,13 +2,19 @@ package cmd
 
 import (
 	"bufio"
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
+	"log"
 	"os"
+	"slices"
 	"strings"
 	"syscall"
+	"text/tabwriter"
+	"time"
 
+	"github.com/jasonlovesdoggo/velo/api/proto"
+	"github.com/jasonlovesdoggo/velo/internal/auth"
+	"github.com/jasonlovesdoggo/velo/pkg/client"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 )
@@ -17,6 +23,10 @@ var (
 	authUsername string
 	authPassword string
 	authRole     string
+	roleProject  string
+	roleService  string
+
+	authServiceAccount bool
 )
 
 func init() {
@@ -31,8 +41,11 @@ func init() {
 	loginCmd := &cobra.Command{
 		Use:   "login",
 		Short: "Login to Velo platform",
-		Long:  `Authenticate with the Velo platform and store credentials.`,
-		Run:   runLogin,
+		Long: `Authenticate with the Velo platform and store the token for the server in
+the credentials file (VELO_CREDENTIALS, or velo/credentials.json in the user
+config directory), readable only by you. Every other command then
+authenticates with it until it expires or you log out.`,
+		Run: runLogin,
 	}
 
 	loginCmd.Flags().StringVar(&authUsername, "username", "", "Username")
@@ -42,10 +55,18 @@ func init() {
 	logoutCmd := &cobra.Command{
 		Use:   "logout",
 		Short: "Logout from Velo platform",
-		Long:  `Remove stored authentication credentials.`,
+		Long:  `Revoke the token stored for the server and remove it from the credentials file.`,
 		Run:   runLogout,
 	}
 
+	// Who am I command
+	whoAmICmd := &cobra.Command{
+		Use:   "whoami",
+		Short: "Show the user you're logged in as",
+		Args:  cobra.NoArgs,
+		Run:   runWhoAmI,
+	}
+
 	// Create user command
 	createUserCmd := &cobra.Command{
 		Use:   "create-user",
@@ -56,23 +77,100 @@ func init() {
 
 	createUserCmd.Flags().StringVar(&authUsername, "username", "", "Username")
 	createUserCmd.Flags().StringVar(&authPassword, "password", "", "Password (use interactive prompt if not provided)")
-	createUserCmd.Flags().StringVar(&authRole, "role", "user", "User role (admin or user)")
+	createUserCmd.Flags().StringVar(&authRole, "role", auth.RoleViewer, "User role (viewer, deployer or admin)")
+	createUserCmd.Flags().BoolVar(&authServiceAccount, "service-account", false, "Create a service account, which has no password and authenticates with API tokens")
 	createUserCmd.MarkFlagRequired("username")
 
+	// List users command
+	usersCmd := &cobra.Command{
+		Use:   "users",
+		Short: "List users and their roles",
+		Args:  cobra.NoArgs,
+		Run:   runListUsers,
+	}
+
+	// Delete user command
+	deleteUserCmd := &cobra.Command{
+		Use:   "delete-user [username]",
+		Short: "Delete a user",
+		Long:  `Deactivate a user account. Their sessions and tokens stop working.`,
+		Args:  cobra.ExactArgs(1),
+		Run:   runDeleteUser,
+	}
+
+	// Role commands
+	roleCmd := &cobra.Command{
+		Use:   "role",
+		Short: "Manage user roles",
+		Long: `Every user has a role on the whole platform: viewer, deployer or admin.
+Users can also be granted a role on a project or a single service, on top of
+their own, e.g. to let a viewer deploy the services of their team.
+
+  veloctl auth role set alice admin
+  veloctl auth role grant ci deployer --service web
+  veloctl auth role revoke ci deployer --service web`,
+	}
+
+	roleListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "Show what each role may do",
+		Args:  cobra.NoArgs,
+		Run:   runRoleList,
+	}
+
+	roleSetCmd := &cobra.Command{
+		Use:   "set [username] [role]",
+		Short: "Set the role of a user",
+		Args:  cobra.ExactArgs(2),
+		Run:   runRoleSet,
+	}
+
+	roleGrantCmd := &cobra.Command{
+		Use:   "grant [username] [role]",
+		Short: "Grant a user a role on a project or service",
+		Args:  cobra.ExactArgs(2),
+		Run:   runRoleGrant,
+	}
+
+	roleRevokeCmd := &cobra.Command{
+		Use:   "revoke [username] [role]",
+		Short: "Revoke a role granted on a project or service",
+		Args:  cobra.ExactArgs(2),
+		Run:   runRoleRevoke,
+	}
+
+	for _, c := range []*cobra.Command{roleGrantCmd, roleRevokeCmd} {
+		c.Flags().StringVar(&roleProject, "project", "", "Project the role applies to")
+		c.Flags().StringVar(&roleService, "service", "", "Service the role applies to")
+		c.MarkFlagsOneRequired("project", "service")
+		c.MarkFlagsMutuallyExclusive("project", "service")
+	}
+
+	roleCmd.AddCommand(roleListCmd)
+	roleCmd.AddCommand(roleSetCmd)
+	roleCmd.AddCommand(roleGrantCmd)
+	roleCmd.AddCommand(roleRevokeCmd)
+
 	// Change password command
 	changePasswordCmd := &cobra.Command{
 		Use:   "change-password",
 		Short: "Change user password",
-		Long:  `Change password for the current user or specified user.`,
-		Run:   runChangePassword,
+		Long: `Change the password of the current user, or as an admin set another
+user's password with --username. They must change it on their next login.`,
+		Run: runChangePassword,
 	}
 
-	changePasswordCmd.Flags().StringVar(&authUsername, "username", "", "Username (current user if not specified)")
+	changePasswordCmd.Flags().StringVar(&authUsername, "username", "", "Set the password of another user (admins only)")
 
 	// Add subcommands
 	authCmd.AddCommand(loginCmd)
 	authCmd.AddCommand(logoutCmd)
+	authCmd.AddCommand(whoAmICmd)
 	authCmd.AddCommand(createUserCmd)
+	authCmd.AddCommand(usersCmd)
+	authCmd.AddCommand(deleteUserCmd)
+	authCmd.AddCommand(roleCmd)
+	authCmd.AddCommand(apiTokensCmd())
 	authCmd.AddCommand(changePasswordCmd)
 
 	rootCmd.AddCommand(authCmd)
@@ -97,45 +195,122 @@ func runLogin(cmd *cobra.Command, args []string) {
 		fmt.Println()
 	}
 
-	// Create HTTP client and authenticate
-	client := &http.Client{Timeout: timeout}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	loginData := map[string]string{
-		"username": authUsername,
-		"password": authPassword,
+	// Log in without any token we already have
+	c, err := client.NewClient(serverAddr, traced())
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
 	}
+	defer c.Close()
 
-	jsonData, _ := json.Marshal(loginData)
+	resp, err := c.Login(ctx, authUsername, authPassword)
+	if err != nil {
+		log.Fatalf("Login failed: %v", err)
+	}
 
-	resp, err := client.Post(fmt.Sprintf("http://%s/api/auth/login", serverAddr), "application/json", strings.NewReader(string(jsonData)))
+	path, err := credentialsPath()
 	if err != nil {
-		fmt.Printf("Error connecting to server: %v\n", err)
+		log.Fatalf("Failed to find the credentials file: %v", err)
+	}
+	creds, err := loadCredentials(path)
+	if err != nil {
+		log.Fatalf("Failed to read credentials: %v", err)
+	}
+	creds.Servers[serverAddr] = credential{
+		Username:  resp.User.Username,
+		Token:     resp.Token,
+		ExpiresAt: time.Unix(resp.ExpiresUnix, 0).UTC(),
+	}
+	if err := creds.save(path); err != nil {
+		log.Fatalf("Failed to store credentials: %v", err)
+	}
+
+	if resp.User.MustChangePassword {
+		fmt.Println("You must change your password before doing anything else.")
+		newPassword, ok := readNewPassword()
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		c, err := client.NewClient(serverAddr, client.WithToken(resp.Token), traced())
+		if err != nil {
+			log.Fatalf("Failed to create client: %v", err)
+		}
+		defer c.Close()
+		if _, err := c.ChangePassword(ctx, authPassword, newPassword, ""); err != nil {
+			log.Fatalf("Failed to change password: %v", err)
+		}
+		fmt.Println("Password changed")
+	}
+
+	fmt.Printf("Logged in to %s as %s (%s) until %s\n",
+		serverAddr, resp.User.Username, resp.User.Role, time.Unix(resp.ExpiresUnix, 0).Format(time.RFC1123))
+}
+
+func runLogout(cmd *cobra.Command, args []string) {
+	path, err := credentialsPath()
+	if err != nil {
+		log.Fatalf("Failed to find the credentials file: %v", err)
+	}
+	creds, err := loadCredentials(path)
+	if err != nil {
+		log.Fatalf("Failed to read credentials: %v", err)
+	}
+	cred, ok := creds.Servers[serverAddr]
+	if !ok {
+		fmt.Printf("Not logged in to %s\n", serverAddr)
 		return
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode == 200 {
-		var result map[string]string
-		json.NewDecoder(resp.Body).Decode(&result)
-		fmt.Printf("Login successful! Token: %s\n", result["token"])
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-		// Store token for future requests (TODO: implement token storage)
-		fmt.Println("Token storage not yet implemented - use web interface for persistent sessions")
-	} else {
-		var result map[string]string
-		json.NewDecoder(resp.Body).Decode(&result)
-		fmt.Printf("Login failed: %s\n", result["error"])
+	// Revoke the token, unless it's no good anyway
+	c, err := client.NewClient(serverAddr, client.WithToken(cred.Token), traced())
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
 	}
+	defer c.Close()
+
+	if _, err := c.Logout(ctx); err != nil && !cred.expired(time.Now()) {
+		log.Fatalf("Failed to revoke token: %v", err)
+	}
+
+	delete(creds.Servers, serverAddr)
+	if err := creds.save(path); err != nil {
+		log.Fatalf("Failed to remove credentials: %v", err)
+	}
+	fmt.Printf("Logged out of %s\n", serverAddr)
 }
 
-func runLogout(cmd *cobra.Command, args []string) {
-	// TODO: Remove stored credentials
-	fmt.Println("Logout functionality will remove stored credentials")
-	fmt.Println("User logged out successfully")
+func runWhoAmI(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	user, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		log.Fatalf("Failed to get the current user: %v", err)
+	}
+	fmt.Printf("Logged in to %s as %s (%s)\n", serverAddr, user.Username, user.Role)
+	for _, b := range user.Bindings {
+		fmt.Printf("  %s\n", bindingFromProto(b))
+	}
+	if cred, ok := storedCredential(serverAddr); ok && authToken == "" {
+		fmt.Printf("Token expires %s\n", cred.ExpiresAt.Local().Format(time.RFC1123))
+	}
 }
 
 func runCreateUser(cmd *cobra.Command, args []string) {
-	if authPassword == "" {
+	if authPassword == "" && !authServiceAccount {
 		fmt.Print("Password: ")
 		passwordBytes, err := term.ReadPassword(syscall.Stdin)
 		if err != nil {
@@ -160,32 +335,200 @@ func runCreateUser(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	// TODO: Implement actual user creation with server
-	fmt.Printf("User creation functionality will create user %s with role %s\n", authUsername, authRole)
-	fmt.Println("User management system ready for implementation")
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	user, err := c.CreateUserWithRequest(ctx, &proto.CreateUserRequest{
+		Username:       authUsername,
+		Password:       authPassword,
+		Role:           authRole,
+		ServiceAccount: authServiceAccount,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create user: %v", err)
+	}
+	if user.ServiceAccount {
+		fmt.Printf("Service account %s created with role %s; create its tokens with 'veloctl auth tokens create --user %s'\n", user.Username, user.Role, user.Username)
+		return
+	}
+	fmt.Printf("User %s created with role %s\n", user.Username, user.Role)
+}
+
+func runListUsers(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.ListUsers(ctx)
+	if err != nil {
+		log.Fatalf("Failed to list users: %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "USERNAME\tROLE\tGRANTS\tACTIVE\tCREATED")
+	for _, u := range resp.Users {
+		grants := make([]string, len(u.Bindings))
+		for i, b := range u.Bindings {
+			grants[i] = bindingFromProto(b).String()
+		}
+		if len(grants) == 0 {
+			grants = []string{"-"}
+		}
+		role := u.Role
+		if u.ServiceAccount {
+			role += " (service account)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\n",
+			u.Username, role, strings.Join(grants, ", "), u.Active, time.Unix(u.CreatedUnix, 0).Format(time.RFC3339))
+	}
+	w.Flush()
+}
+
+func runDeleteUser(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.DeleteUser(ctx, args[0])
+	if err != nil {
+		log.Fatalf("Failed to delete user: %v", err)
+	}
+	fmt.Println(resp.Message)
+}
+
+func runRoleList(cmd *cobra.Command, args []string) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PERMISSION\t"+strings.ToUpper(strings.Join(auth.Roles, "\t"))+"\tON PROJECTS/SERVICES")
+	for _, perm := range auth.Permissions {
+		row := []string{string(perm)}
+		for _, role := range auth.Roles {
+			mark := "-"
+			if slices.Contains(auth.RolePermissions(role), perm) {
+				mark = "yes"
+			}
+			row = append(row, mark)
+		}
+		scoped := "no"
+		if perm.Scoped() {
+			scoped = "yes"
+		}
+		fmt.Fprintln(w, strings.Join(append(row, scoped), "\t"))
+	}
+	w.Flush()
+}
+
+func runRoleSet(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	user, err := c.SetUserRole(ctx, args[0], args[1])
+	if err != nil {
+		log.Fatalf("Failed to set role: %v", err)
+	}
+	fmt.Printf("User %s is now %s\n", user.Username, user.Role)
+}
+
+func runRoleGrant(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	binding := &proto.RoleBinding{Role: args[1], Project: roleProject, Service: roleService}
+	if _, err := c.GrantRole(ctx, args[0], binding); err != nil {
+		log.Fatalf("Failed to grant role: %v", err)
+	}
+	fmt.Printf("Granted %s %s\n", args[0], bindingFromProto(binding))
+}
+
+func runRoleRevoke(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	binding := &proto.RoleBinding{Role: args[1], Project: roleProject, Service: roleService}
+	if _, err := c.RevokeRole(ctx, args[0], binding); err != nil {
+		log.Fatalf("Failed to revoke role: %v", err)
+	}
+	fmt.Printf("Revoked %s from %s\n", bindingFromProto(binding), args[0])
+}
+
+func bindingFromProto(b *proto.RoleBinding) auth.Binding {
+	return auth.Binding{Role: b.Role, Project: b.Project, Service: b.Service}
 }
 
 func runChangePassword(cmd *cobra.Command, args []string) {
+	var currentPassword string
 	if authUsername == "" {
-		fmt.Print("Username (current user if empty): ")
-		reader := bufio.NewReader(os.Stdin)
-		username, _ := reader.ReadString('\n')
-		authUsername = strings.TrimSpace(username)
+		fmt.Print("Current password: ")
+		currentBytes, err := term.ReadPassword(syscall.Stdin)
+		if err != nil {
+			fmt.Printf("Error reading password: %v\n", err)
+			return
+		}
+		currentPassword = string(currentBytes)
+		fmt.Println()
 	}
 
-	fmt.Print("Current password: ")
-	_, err := term.ReadPassword(syscall.Stdin)
-	if err != nil {
-		fmt.Printf("Error reading password: %v\n", err)
+	newPassword, ok := readNewPassword()
+	if !ok {
 		return
 	}
-	fmt.Println()
 
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.ChangePassword(ctx, currentPassword, newPassword, authUsername)
+	if err != nil {
+		log.Fatalf("Failed to change password: %v", err)
+	}
+	fmt.Println(resp.Message)
+}
+
+// readNewPassword prompts for a new password twice
+func readNewPassword() (string, bool) {
 	fmt.Print("New password: ")
 	newBytes, err := term.ReadPassword(syscall.Stdin)
 	if err != nil {
 		fmt.Printf("Error reading password: %v\n", err)
-		return
+		return "", false
 	}
 	fmt.Println()
 
@@ -193,16 +536,13 @@ func runChangePassword(cmd *cobra.Command, args []string) {
 	confirmBytes, err := term.ReadPassword(syscall.Stdin)
 	if err != nil {
 		fmt.Printf("Error reading password: %v\n", err)
-		return
+		return "", false
 	}
 	fmt.Println()
 
 	if string(newBytes) != string(confirmBytes) {
 		fmt.Println("Passwords do not match")
-		return
+		return "", false
 	}
-
-	// TODO: Implement actual password change with server
-	fmt.Printf("Password change functionality will update password for user %s\n", authUsername)
-	fmt.Println("Password management system ready for implementation")
+	return string(newBytes), true
 }
@@ -4,15 +4,29 @@ import (
 	"context"
 	"fmt"
 	"log"
-
-	"github.com/jasonlovesdoggo/velo/pkg/client"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/jasonlovesdoggo/velo/api/proto"
 	"github.com/spf13/cobra"
 )
 
 var (
-	nodeID    string
-	nodeLabel string
-	nodeValue string
+	nodeID       string
+	nodeLabel    string
+	nodeValue    string
+	drainTimeout time.Duration
+	drainForce   bool
+
+	tokenRole        string
+	tokenTTL         time.Duration
+	tokenDescription string
+
+	commandNoWait bool
 )
 
 func init() {
@@ -20,7 +34,7 @@ func init() {
 	clusterCmd := &cobra.Command{
 		Use:   "cluster",
 		Short: "Cluster management commands",
-		Long:  `Manage cluster nodes and operations.`,
+		Long:  `Manage clusters, their nodes and operations. Use --cluster to select a cluster.`,
 	}
 
 	// List nodes command
@@ -31,6 +45,14 @@ func init() {
 		Run:   runListNodes,
 	}
 
+	// List clusters command
+	listClustersCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List clusters",
+		Long:  `List the clusters this Velo manager deploys to and their health. The default cluster is marked with *.`,
+		Run:   runListClusters,
+	}
+
 	// Add node label command
 	labelNodeCmd := &cobra.Command{
 		Use:   "label-node",
@@ -50,11 +72,15 @@ func init() {
 	drainNodeCmd := &cobra.Command{
 		Use:   "drain",
 		Short: "Drain a node",
-		Long:  `Drain a node to prevent new tasks from being scheduled on it.`,
-		Run:   runDrainNode,
+		Long: `Drain a node for maintenance. Velo first checks that the remaining nodes
+have enough capacity for the node's tasks, then drains it and waits until every
+task has been rescheduled and is running elsewhere.`,
+		Run: runDrainNode,
 	}
 
 	drainNodeCmd.Flags().StringVar(&nodeID, "node", "", "Node ID or hostname")
+	drainNodeCmd.Flags().DurationVar(&drainTimeout, "timeout", 5*time.Minute, "How long to wait for tasks to be rescheduled")
+	drainNodeCmd.Flags().BoolVar(&drainForce, "force", false, "Drain even if the remaining nodes lack capacity")
 	drainNodeCmd.MarkFlagRequired("node")
 
 	// Activate node command
@@ -68,52 +94,237 @@ func init() {
 	activateNodeCmd.Flags().StringVar(&nodeID, "node", "", "Node ID or hostname")
 	activateNodeCmd.MarkFlagRequired("node")
 
+	// Rebalance command
+	rebalanceCmd := &cobra.Command{
+		Use:   "rebalance",
+		Short: "Spread service tasks over all active nodes",
+		Long:  `Force-update replicated services so their tasks spread back out, e.g. after a drained node is re-activated.`,
+		Run:   runRebalance,
+	}
+
+	// Capacity report command
+	capacityCmd := &cobra.Command{
+		Use:   "capacity",
+		Short: "Show reserved resources and headroom per node",
+		Long:  `Show the CPU and memory reserved by services on each node and how much is left for new deploys.`,
+		Run:   runCapacity,
+	}
+
 	// Join token command
 	joinTokenCmd := &cobra.Command{
 		Use:   "join-token",
 		Short: "Get join tokens",
-		Long:  `Get the join token for adding new nodes to the cluster.`,
-		Run:   runJoinToken,
+		Long: `Get the swarm join token for adding new nodes to the cluster with 'docker swarm join'.
+Prefer 'veloctl cluster tokens create' with 'velo join', which hands out the
+swarm token only to nodes holding a short-lived, single-use bootstrap token.`,
+		Run: runJoinToken,
 	}
 
 	var isManager bool
 	joinTokenCmd.Flags().BoolVar(&isManager, "manager", false, "Get manager join token")
 
+	// Bootstrap token commands
+	tokensCmd := &cobra.Command{
+		Use:   "tokens",
+		Short: "Manage bootstrap tokens",
+		Long: `Manage the bootstrap tokens new nodes join the cluster with. A node runs
+'velo join <manager-addr> --token <token>'; the token is used up on join and
+expires after its TTL if unused.`,
+	}
+
+	createTokenCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a bootstrap token",
+		Long:  `Create a single-use bootstrap token. The token is shown once and can't be recovered.`,
+		Run:   runCreateToken,
+	}
+	createTokenCmd.Flags().StringVar(&tokenRole, "role", "worker", "Role the joining node gets (worker or manager)")
+	createTokenCmd.Flags().DurationVar(&tokenTTL, "ttl", time.Hour, "How long the token is valid (at most 24h)")
+	createTokenCmd.Flags().StringVar(&tokenDescription, "description", "", "What the token is for")
+
+	listTokensCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List bootstrap tokens",
+		Long:  `List the bootstrap tokens that haven't been used, revoked or expired.`,
+		Run:   runListTokens,
+	}
+
+	revokeTokenCmd := &cobra.Command{
+		Use:   "revoke <token-id>",
+		Short: "Revoke a bootstrap token",
+		Long:  `Revoke an unused bootstrap token by its ID.`,
+		Args:  cobra.ExactArgs(1),
+		Run:   runRevokeToken,
+	}
+
+	agentTokenCmd := &cobra.Command{
+		Use:   "agent-token <node>",
+		Short: "Create the agent token of a node",
+		Long: `Create the token the Velo agent of a node, found by ID or hostname,
+authenticates with. Nodes that joined with 'velo join' already have one; this
+is for standalone hosts and nodes that joined the swarm directly. Creating a
+token replaces the node's previous one.`,
+		Args: cobra.ExactArgs(1),
+		Run:  runAgentToken,
+	}
+
+	agentTokensCmd := &cobra.Command{
+		Use:   "agent-tokens",
+		Short: "Manage agent tokens",
+		Long: `Manage the tokens the Velo agents of the selected cluster authenticate
+with. Revoke the token of a node that was lost or decommissioned so its agent
+is turned away.`,
+	}
+
+	listAgentTokensCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List agent tokens",
+		Long:  `List the agent tokens of the selected cluster and the nodes they belong to.`,
+		Run:   runListAgentTokens,
+	}
+
+	revokeAgentTokenCmd := &cobra.Command{
+		Use:   "revoke <token-id>",
+		Short: "Revoke an agent token",
+		Long:  `Revoke an agent token by its ID. The agent using it is rejected from its next call on.`,
+		Args:  cobra.ExactArgs(1),
+		Run:   runRevokeAgentToken,
+	}
+
+	// Agent command
+	nodeCommandCmd := &cobra.Command{
+		Use:   "node-command <node> <command> [container]",
+		Short: "Run a command through a node's agent",
+		Long: `Run a command through the Velo agent of a node, found by ID or hostname.
+The command is delivered with the agent's next heartbeat. Commands:
+
+  restart-container <container>
+  stop-container <container>
+  start-container <container>
+  prune-images`,
+		Args: cobra.RangeArgs(2, 3),
+		Run:  runNodeCommand,
+	}
+	nodeCommandCmd.Flags().BoolVar(&commandNoWait, "no-wait", false, "Return once the command is queued")
+
+	tokensCmd.AddCommand(createTokenCmd)
+	tokensCmd.AddCommand(listTokensCmd)
+	tokensCmd.AddCommand(revokeTokenCmd)
+	agentTokensCmd.AddCommand(listAgentTokensCmd)
+	agentTokensCmd.AddCommand(revokeAgentTokenCmd)
+
 	// Add subcommands
+	clusterCmd.AddCommand(listClustersCmd)
 	clusterCmd.AddCommand(listNodesCmd)
 	clusterCmd.AddCommand(labelNodeCmd)
 	clusterCmd.AddCommand(drainNodeCmd)
 	clusterCmd.AddCommand(activateNodeCmd)
+	clusterCmd.AddCommand(rebalanceCmd)
+	clusterCmd.AddCommand(capacityCmd)
 	clusterCmd.AddCommand(joinTokenCmd)
+	clusterCmd.AddCommand(tokensCmd)
+	clusterCmd.AddCommand(nodeCommandCmd)
+	clusterCmd.AddCommand(agentTokenCmd)
+	clusterCmd.AddCommand(agentTokensCmd)
 
 	rootCmd.AddCommand(clusterCmd)
 }
 
 func runListNodes(cmd *cobra.Command, args []string) {
-	_, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.ListNodes(ctx)
+	if err != nil {
+		log.Fatalf("Failed to list nodes: %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tHOSTNAME\tROLE\tSTATE\tAVAILABILITY\tCPUS\tMEMORY\tDISK\tGPUS\tAGENT\tLABELS")
+	for _, n := range resp.Nodes {
+		role := n.Role
+		if n.Manager && role != "manager" {
+			role += " (manager)"
+		}
+		labels := make([]string, 0, len(n.Labels))
+		for key, value := range n.Labels {
+			labels = append(labels, key+"="+value)
+		}
+		sort.Strings(labels)
+		disk := "-"
+		if n.DiskBytes > 0 {
+			disk = units.BytesSize(float64(n.DiskBytes))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\t%d\t%s\t%s\n",
+			n.Id, n.Hostname, role, strings.Join(n.Conditions, ","), n.Availability,
+			n.CpuCores, units.BytesSize(float64(n.MemoryBytes)), disk, n.Gpus, agentSummary(n), strings.Join(labels, ","))
+	}
+	w.Flush()
+}
+
+// agentSummary describes the agent of a node for the nodes table
+func agentSummary(n *proto.NodeInfo) string {
+	switch {
+	case n.Agent == nil:
+		return "missing"
+	case n.AgentMissing:
+		return fmt.Sprintf("missing (last seen %s ago)", time.Since(time.Unix(n.Agent.LastHeartbeatUnix, 0)).Round(time.Second))
+	default:
+		return fmt.Sprintf("%s (%s)", n.Agent.Version, n.Agent.Health)
+	}
+}
+
+func runListClusters(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	c, err := client.NewClient(serverAddr)
+	c, err := newClient()
 	if err != nil {
 		log.Fatalf("Failed to create client: %v", err)
 	}
 	defer c.Close()
 
-	// TODO: Implement cluster API calls
-	fmt.Println("Cluster node listing functionality will be implemented")
-	fmt.Println("This will show:")
-	fmt.Println("- Node ID and hostname")
-	fmt.Println("- Node role (manager/worker)")
-	fmt.Println("- Node status (active/drain)")
-	fmt.Println("- Node labels and constraints")
-	fmt.Println("- Resource usage (CPU, Memory, Disk)")
+	resp, err := c.ListClusters(ctx)
+	if err != nil {
+		log.Fatalf("Failed to list clusters: %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tBACKEND\tHOST\tNODES\tHEALTH")
+	for _, cl := range resp.Clusters {
+		name := cl.Name
+		if cl.Default {
+			name += " *"
+		}
+		host := cl.Host
+		if host == "" {
+			host = "local"
+		}
+		health := "healthy"
+		if !cl.Healthy {
+			health = "unhealthy"
+			if cl.Error != "" {
+				health += ": " + cl.Error
+			}
+		} else if cl.MissingAgents > 0 {
+			health = fmt.Sprintf("healthy, %d node(s) without agent", cl.MissingAgents)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", name, cl.Backend, host, cl.Nodes, health)
+	}
+	w.Flush()
 }
 
 func runLabelNode(cmd *cobra.Command, args []string) {
 	_, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	c, err := client.NewClient(serverAddr)
+	c, err := newClient()
 	if err != nil {
 		log.Fatalf("Failed to create client: %v", err)
 	}
@@ -128,43 +339,109 @@ func runLabelNode(cmd *cobra.Command, args []string) {
 }
 
 func runDrainNode(cmd *cobra.Command, args []string) {
-	_, cancel := context.WithTimeout(context.Background(), timeout)
+	// Leave some headroom over the server-side timeout so it can report why it gave up
+	ctx, cancel := context.WithTimeout(context.Background(), drainTimeout+timeout)
 	defer cancel()
 
-	c, err := client.NewClient(serverAddr)
+	c, err := newClient()
 	if err != nil {
 		log.Fatalf("Failed to create client: %v", err)
 	}
 	defer c.Close()
 
-	// TODO: Implement node draining API
-	fmt.Printf("Node draining functionality will drain node %s\n", nodeID)
-	fmt.Println("This will:")
-	fmt.Println("- Stop scheduling new tasks on the node")
-	fmt.Println("- Gracefully move existing tasks to other nodes")
-	fmt.Println("- Maintain service availability during the process")
+	err = c.DrainNode(ctx, nodeID, drainTimeout, drainForce, func(p *proto.DrainNodeProgress) {
+		for _, warning := range p.Warnings {
+			fmt.Printf("Warning: %s\n", warning)
+		}
+		fmt.Printf("[%s] %s\n", p.Phase, p.Message)
+	})
+	if err != nil {
+		log.Fatalf("Failed to drain node: %v", err)
+	}
+
+	fmt.Printf("Node %s drained. Run 'veloctl cluster activate --node %s' when maintenance is done.\n", nodeID, nodeID)
 }
 
 func runActivateNode(cmd *cobra.Command, args []string) {
-	_, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	c, err := client.NewClient(serverAddr)
+	c, err := newClient()
 	if err != nil {
 		log.Fatalf("Failed to create client: %v", err)
 	}
 	defer c.Close()
 
-	// TODO: Implement node activation API
-	fmt.Printf("Node activation functionality will activate node %s\n", nodeID)
-	fmt.Println("This will allow the node to receive new task assignments")
+	resp, err := c.ActivateNode(ctx, nodeID)
+	if err != nil {
+		log.Fatalf("Failed to activate node: %v", err)
+	}
+	if !resp.Success {
+		log.Fatalf("Failed to activate node: %s", resp.Message)
+	}
+
+	fmt.Printf("Node %s activated. Run 'veloctl cluster rebalance' to spread tasks back onto it.\n", nodeID)
+}
+
+func runRebalance(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.Rebalance(ctx)
+	if err != nil {
+		log.Fatalf("Failed to rebalance services: %v", err)
+	}
+
+	if len(resp.Services) == 0 {
+		fmt.Println("No replicated services to rebalance")
+		return
+	}
+	fmt.Printf("Rebalancing %d service(s): %s\n", len(resp.Services), strings.Join(resp.Services, ", "))
+}
+
+func runCapacity(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.GetCapacity(ctx)
+	if err != nil {
+		log.Fatalf("Failed to get capacity: %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tAVAILABILITY\tTASKS\tCPU RESERVED\tCPU FREE\tMEMORY RESERVED\tMEMORY FREE")
+	for _, n := range resp.Nodes {
+		availability := n.Availability
+		if !n.Eligible && availability == "active" {
+			availability = "not ready"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%.2f / %.2f\t%.2f\t%s / %s\t%s\n",
+			n.Hostname, availability, n.Tasks,
+			float64(n.ReservedNanoCpus)/1e9, float64(n.NanoCpus)/1e9,
+			float64(n.NanoCpus-n.ReservedNanoCpus)/1e9,
+			units.BytesSize(float64(n.ReservedMemoryBytes)), units.BytesSize(float64(n.MemoryBytes)),
+			units.BytesSize(float64(n.MemoryBytes-n.ReservedMemoryBytes)))
+	}
+	w.Flush()
 }
 
 func runJoinToken(cmd *cobra.Command, args []string) {
-	_, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	c, err := client.NewClient(serverAddr)
+	c, err := newClient()
 	if err != nil {
 		log.Fatalf("Failed to create client: %v", err)
 	}
@@ -172,12 +449,208 @@ func runJoinToken(cmd *cobra.Command, args []string) {
 
 	isManager, _ := cmd.Flags().GetBool("manager")
 
-	// TODO: Implement join token API
+	resp, err := c.GetJoinToken(ctx, isManager)
+	if err != nil {
+		log.Fatalf("Failed to get join token: %v", err)
+	}
+
 	tokenType := "worker"
 	if isManager {
 		tokenType = "manager"
 	}
+	managerAddr := "<manager-addr>"
+	if len(resp.ManagerAddrs) > 0 {
+		managerAddr = resp.ManagerAddrs[0]
+	}
+	fmt.Printf("To add a %s to this cluster, run the following command on it:\n\n", tokenType)
+	fmt.Printf("    docker swarm join --token %s %s\n\n", resp.Token, managerAddr)
+	fmt.Println("To join through Velo instead, create a bootstrap token with 'veloctl cluster tokens create'.")
+}
+
+func runCreateToken(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	token, err := c.CreateBootstrapToken(ctx, tokenRole, tokenTTL, tokenDescription)
+	if err != nil {
+		log.Fatalf("Failed to create bootstrap token: %v", err)
+	}
+
+	fmt.Printf("Bootstrap token %s created for a %s node, valid until %s.\n", token.Id, token.Role,
+		time.Unix(token.ExpiresUnix, 0).Format(time.RFC3339))
+	fmt.Println("It is shown only once. To add the node, run on it:")
+	fmt.Printf("\n    velo join %s --token %s\n\n", serverAddr, token.Token)
+}
+
+func runAgentToken(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	token, err := c.CreateAgentToken(ctx, args[0])
+	if err != nil {
+		log.Fatalf("Failed to create agent token: %v", err)
+	}
+
+	fmt.Printf("Agent token %s created for node %s in cluster %s.\n", token.Id, args[0], token.Cluster)
+	fmt.Println("It is shown only once. Run the agent on the node with:")
+	fmt.Printf("\n    velo --manager-addr %s --agent-token %s\n\n", serverAddr, token.Token)
+}
+
+func runListTokens(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.ListBootstrapTokens(ctx)
+	if err != nil {
+		log.Fatalf("Failed to list bootstrap tokens: %v", err)
+	}
+	if len(resp.Tokens) == 0 {
+		fmt.Println("No bootstrap tokens")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tROLE\tCLUSTER\tEXPIRES\tDESCRIPTION")
+	for _, token := range resp.Tokens {
+		cluster := token.Cluster
+		if cluster == "" {
+			cluster = "(default)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", token.Id, token.Role, cluster,
+			time.Until(time.Unix(token.ExpiresUnix, 0)).Round(time.Second), token.Description)
+	}
+	w.Flush()
+}
+
+func runRevokeToken(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.RevokeBootstrapToken(ctx, args[0])
+	if err != nil {
+		log.Fatalf("Failed to revoke bootstrap token: %v", err)
+	}
+	if !resp.Success {
+		log.Fatalf("%s", resp.Message)
+	}
+	fmt.Println(resp.Message)
+}
+
+func runListAgentTokens(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
 
-	fmt.Printf("Join token functionality will return %s join token\n", tokenType)
-	fmt.Println("Usage instructions will be provided for adding new nodes")
+	resp, err := c.ListAgentTokens(ctx)
+	if err != nil {
+		log.Fatalf("Failed to list agent tokens: %v", err)
+	}
+	if len(resp.Tokens) == 0 {
+		fmt.Println("No agent tokens")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNODE\tHOSTNAME\tCREATED")
+	for _, token := range resp.Tokens {
+		nodeID := token.NodeId
+		if nodeID == "" {
+			nodeID = "(unbound)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", token.Id, nodeID, token.Hostname,
+			time.Unix(token.CreatedUnix, 0).Format(time.RFC3339))
+	}
+	w.Flush()
+}
+
+func runRevokeAgentToken(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.RevokeAgentToken(ctx, args[0])
+	if err != nil {
+		log.Fatalf("Failed to revoke agent token: %v", err)
+	}
+	if !resp.Success {
+		log.Fatalf("%s", resp.Message)
+	}
+	fmt.Println(resp.Message)
+}
+
+func runNodeCommand(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	var target string
+	if len(args) == 3 {
+		target = args[2]
+	}
+	status, err := c.SendAgentCommand(ctx, args[0], args[1], target)
+	if err != nil {
+		log.Fatalf("Failed to send command: %v", err)
+	}
+	if commandNoWait {
+		fmt.Printf("Command %s queued for node %s\n", status.Id, status.NodeId)
+		return
+	}
+
+	// The agent picks the command up with its next heartbeat
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for status.State != "succeeded" && status.State != "failed" {
+		select {
+		case <-ctx.Done():
+			log.Fatalf("Command %s is still %s, check the node's agent", status.Id, status.State)
+		case <-ticker.C:
+		}
+		if status, err = c.GetAgentCommand(ctx, status.Id); err != nil {
+			log.Fatalf("Failed to get command status: %v", err)
+		}
+	}
+
+	if status.State == "failed" {
+		log.Fatalf("Command failed on node %s: %s", status.NodeId, status.Message)
+	}
+	fmt.Printf("Command succeeded on node %s: %s\n", status.NodeId, status.Message)
 }
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// credential is what veloctl keeps after logging in to a server
+type credential struct {
+	Username  string    `json:"username"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// expired reports whether the token has expired
+func (c credential) expired(now time.Time) bool {
+	return !c.ExpiresAt.IsZero() && now.After(c.ExpiresAt)
+}
+
+// credentials are the tokens veloctl is logged in with, per server address
+type credentials struct {
+	Servers map[string]credential `json:"servers"`
+}
+
+// credentialsPath returns VELO_CREDENTIALS, or the credentials file in the
+// user's config directory
+func credentialsPath() (string, error) {
+	if path := os.Getenv("VELO_CREDENTIALS"); path != "" {
+		return path, nil
+	}
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(config, "velo", "credentials.json"), nil
+}
+
+// loadCredentials reads the credentials file. A missing file holds no credentials.
+func loadCredentials(path string) (*credentials, error) {
+	creds := &credentials{Servers: map[string]credential{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return creds, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, creds); err != nil {
+		return nil, fmt.Errorf("invalid credentials file %s: %w", path, err)
+	}
+	if creds.Servers == nil {
+		creds.Servers = map[string]credential{}
+	}
+	return creds, nil
+}
+
+// save writes the credentials file, readable only by its owner
+func (c *credentials) save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	// Write a new file and move it into place, so it's never readable by others
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".credentials-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// storedCredential returns the credential veloctl is logged in to a server with
+func storedCredential(server string) (credential, bool) {
+	path, err := credentialsPath()
+	if err != nil {
+		return credential{}, false
+	}
+	creds, err := loadCredentials(path)
+	if err != nil {
+		return credential{}, false
+	}
+	cred, ok := creds.Servers[server]
+	return cred, ok
+}
+
+// token returns the token to authenticate with: --token or VELO_TOKEN, or the
+// one stored when logging in to the server
+func token() string {
+	if authToken != "" {
+		return authToken
+	}
+	cred, _ := storedCredential(serverAddr)
+	return cred.Token
+}
+
+// loginRequired turns the Unauthenticated errors of a call into a prompt to
+// log in again
+func loginRequired(err error) error {
+	s, ok := status.FromError(err)
+	if !ok || s.Code() != codes.Unauthenticated {
+		return err
+	}
+	if authToken != "" {
+		return status.Errorf(codes.Unauthenticated, "%s (check --token or VELO_TOKEN)", s.Message())
+	}
+	if cred, ok := storedCredential(serverAddr); ok && cred.expired(time.Now()) {
+		return status.Errorf(codes.Unauthenticated, "your login to %s as %s expired at %s; run 'veloctl auth login --server %s' to log in again",
+			serverAddr, cred.Username, cred.ExpiresAt.Local().Format(time.RFC1123), serverAddr)
+	}
+	return status.Errorf(codes.Unauthenticated, "%s; run 'veloctl auth login --server %s'", s.Message(), serverAddr)
+}
+
+func loginRequiredUnary(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return loginRequired(invoker(ctx, method, req, reply, cc, opts...))
+}
+
+func loginRequiredStream(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		return nil, loginRequired(err)
+	}
+	return &loginRequiredClientStream{ClientStream: stream}, nil
+}
+
+// loginRequiredClientStream prompts to log in again when a stream is turned away
+type loginRequiredClientStream struct {
+	grpc.ClientStream
+}
+
+func (s *loginRequiredClientStream) RecvMsg(m interface{}) error {
+	return loginRequired(s.ClientStream.RecvMsg(m))
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "velo", "credentials.json")
+	t.Setenv("VELO_CREDENTIALS", path)
+
+	creds, err := loadCredentials(path)
+	if err != nil || len(creds.Servers) != 0 {
+		t.Fatalf("Expected a missing file to hold no credentials, got %v (%v)", creds, err)
+	}
+
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	creds.Servers["prod:37355"] = credential{Username: "alice", Token: "prod-token", ExpiresAt: expires}
+	creds.Servers["staging:37355"] = credential{Username: "alice", Token: "staging-token", ExpiresAt: expires}
+	if err := creds.save(path); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Expected the credentials file to exist: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the credentials file to be 0600, got %v", info.Mode().Perm())
+	}
+
+	defer func(server, token string) { serverAddr, authToken = server, token }(serverAddr, authToken)
+	serverAddr, authToken = "staging:37355", ""
+	if got := token(); got != "staging-token" {
+		t.Errorf("Expected the token stored for the server, got %q", got)
+	}
+	authToken = "flag-token"
+	if got := token(); got != "flag-token" {
+		t.Errorf("Expected --token to win over stored credentials, got %q", got)
+	}
+	serverAddr, authToken = "dev:37355", ""
+	if got := token(); got != "" {
+		t.Errorf("Expected no token for a server without credentials, got %q", got)
+	}
+
+	loaded, err := loadCredentials(path)
+	if err != nil || loaded.Servers["prod:37355"].ExpiresAt != expires {
+		t.Errorf("Expected the credentials to round trip, got %v (%v)", loaded, err)
+	}
+}
+
+func TestLoginRequired(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	t.Setenv("VELO_CREDENTIALS", path)
+	creds := &credentials{Servers: map[string]credential{
+		"prod:37355": {Username: "alice", Token: "old", ExpiresAt: time.Now().Add(-time.Hour)},
+	}}
+	if err := creds.save(path); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	defer func(server, token string) { serverAddr, authToken = server, token }(serverAddr, authToken)
+	serverAddr, authToken = "prod:37355", ""
+
+	denied := status.Error(codes.PermissionDenied, "permission denied")
+	if err := loginRequired(denied); err != denied {
+		t.Errorf("Expected other errors to pass through, got %v", err)
+	}
+
+	err := loginRequired(status.Error(codes.Unauthenticated, "token expired, log in again"))
+	if status.Code(err) != codes.Unauthenticated || !strings.Contains(err.Error(), "expired") || !strings.Contains(err.Error(), "veloctl auth login --server prod:37355") {
+		t.Errorf("Expected a prompt to log in again, got %v", err)
+	}
+
+	serverAddr = "dev:37355"
+	err = loginRequired(status.Error(codes.Unauthenticated, "authentication required, log in first"))
+	if !strings.Contains(err.Error(), "veloctl auth login --server dev:37355") {
+		t.Errorf("Expected a prompt to log in, got %v", err)
+	}
+}
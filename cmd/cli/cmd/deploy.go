@@ -4,38 +4,99 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
 	"strings"
+	"time"
 
-	"github.com/jasonlovesdoggo/velo/pkg/client"
+	"github.com/docker/go-units"
+	"github.com/jasonlovesdoggo/velo/api/proto"
+	"github.com/jasonlovesdoggo/velo/internal/config"
 	"github.com/spf13/cobra"
 )
 
 var (
-	deployService string
-	deployImage   string
-	deployEnv     []string
+	deployService     string
+	deployImage       string
+	deployEnv         []string
+	deployLabels      []string
+	deployConstraints []string
+	deploySpread      []string
+	deployMaxPerNode  uint64
+	deployReplicas    int
+	deployCPUReserve  float64
+	deployMemReserve  string
+	deployCPULimit    float64
+	deployMemLimit    string
+	deployHealthcheck string
+	deployHCInterval  time.Duration
+	deployVolumes     []string
+	deployHealth      string
+	deployPreHook     string
+	deployPostHook    string
+	deployHookTimeout time.Duration
+	deployRollback    bool
 )
 
 func init() {
 	deployCmd := &cobra.Command{
 		Use:   "deploy",
 		Short: "Deploy a service",
-		Long:  `Deploy a service to the Velo platform.`,
-		Run:   runDeploy,
+		Long: `Deploy a service to the Velo platform.
+
+Hooks run as one-off jobs with the new image, environment and networks; their
+output is streamed as they run. A pre-deploy hook that exits non-zero aborts the
+deploy. The post-deploy hook runs once every replica is running:
+
+  veloctl deploy --service web --image web:2 --pre-deploy "./manage.py migrate" \
+    --post-deploy "./manage.py check --deploy" --rollback-on-hook-failure
+
+Hook commands are split on whitespace; use "sh -c" in a script for anything that
+needs quoting. Healthcheck commands run in a shell:
+
+  veloctl deploy --service web --image web:2 --cpu-limit 1 --memory-limit 512m \
+    --healthcheck "curl -f http://localhost/ || exit 1" --volume uploads:/app/uploads`,
+		Run: runDeploy,
 	}
 
 	deployCmd.Flags().StringVar(&deployService, "service", "test-service", "Name of the service to deploy")
 	deployCmd.Flags().StringVar(&deployImage, "image", "nginx:latest", "Docker image to deploy")
 	deployCmd.Flags().StringArrayVar(&deployEnv, "env", []string{}, "Environment variables (KEY=VALUE)")
+	deployCmd.Flags().StringArrayVar(&deployLabels, "label", []string{}, "Service labels (KEY=VALUE)")
+	deployCmd.Flags().StringArrayVar(&deployConstraints, "constraint", []string{}, "Placement constraints (e.g. node.labels.zone==eu)")
+	deployCmd.Flags().StringArrayVar(&deploySpread, "spread", []string{}, "Spread tasks over a node label (e.g. node.labels.zone)")
+	deployCmd.Flags().IntVar(&deployReplicas, "replicas", 1, "Number of replicas")
+	deployCmd.Flags().Float64Var(&deployCPUReserve, "cpu-reserve", 0, "CPUs reserved per replica (e.g. 0.5)")
+	deployCmd.Flags().StringVar(&deployMemReserve, "memory-reserve", "", "Memory reserved per replica (e.g. 512m)")
+	deployCmd.Flags().Float64Var(&deployCPULimit, "cpu-limit", 0, "CPUs each replica may use (e.g. 0.5)")
+	deployCmd.Flags().StringVar(&deployMemLimit, "memory-limit", "", "Memory each replica may use (e.g. 512m)")
+	deployCmd.Flags().StringVar(&deployHealthcheck, "healthcheck", "", "Shell command that checks a replica is healthy")
+	deployCmd.Flags().DurationVar(&deployHCInterval, "healthcheck-interval", 0, "How often the healthcheck runs (default 30s)")
+	deployCmd.Flags().StringArrayVar(&deployVolumes, "volume", []string{}, "Volumes to mount (SOURCE:DESTINATION[:ro])")
+	deployCmd.Flags().StringVar(&deployHealth, "health-policy", "", "What node agents do about unhealthy or crash-looping replicas (restart, report or ignore)")
+	deployCmd.Flags().StringVar(&deployPreHook, "pre-deploy", "", "Command to run before the rollout")
+	deployCmd.Flags().StringVar(&deployPostHook, "post-deploy", "", "Command to run once the rollout is healthy")
+	deployCmd.Flags().DurationVar(&deployHookTimeout, "hook-timeout", 0, "How long each hook may run (default 10m)")
+	deployCmd.Flags().BoolVar(&deployRollback, "rollback-on-hook-failure", false, "Roll the deploy back when the post-deploy hook fails")
+	deployCmd.Flags().Uint64Var(&deployMaxPerNode, "max-replicas-per-node", 0, "Maximum replicas per node (0 for unlimited)")
 
 	rootCmd.AddCommand(deployCmd)
 }
 
 func runDeploy(cmd *cobra.Command, args []string) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	hooks := deployPreHook != "" || deployPostHook != ""
+	deadline := timeout
+	if hooks {
+		// Leave room for both hooks and the rollout in between
+		hookTimeout := deployHookTimeout
+		if hookTimeout <= 0 {
+			hookTimeout = config.DefaultHookTimeout * time.Second
+		}
+		deadline += 3 * hookTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), deadline)
 	defer cancel()
 
-	c, err := client.NewClient(serverAddr)
+	c, err := newClient()
 	if err != nil {
 		log.Fatalf("Failed to create client: %v", err)
 	}
@@ -49,12 +110,95 @@ func runDeploy(cmd *cobra.Command, args []string) {
 		}
 		envMap[parts[0]] = parts[1]
 	}
+	labels := make(map[string]string)
+	for _, kv := range deployLabels {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			log.Fatalf("Invalid label %q; expected KEY=VALUE", kv)
+		}
+		labels[key] = value
+	}
 
-	resp, err := c.Deploy(ctx, deployService, deployImage, envMap)
+	var memReserve int64
+	if deployMemReserve != "" {
+		memReserve, err = units.RAMInBytes(deployMemReserve)
+		if err != nil {
+			log.Fatalf("Invalid memory reservation %q: %v", deployMemReserve, err)
+		}
+	}
+	var memLimit int64
+	if deployMemLimit != "" {
+		memLimit, err = units.RAMInBytes(deployMemLimit)
+		if err != nil {
+			log.Fatalf("Invalid memory limit %q: %v", deployMemLimit, err)
+		}
+	}
+	var healthcheck *proto.HealthCheck
+	if deployHealthcheck != "" {
+		healthcheck = &proto.HealthCheck{
+			Command:         []string{"CMD-SHELL", deployHealthcheck},
+			IntervalSeconds: int32(deployHCInterval.Seconds()),
+		}
+	}
+	var volumes []*proto.VolumeMount
+	for _, v := range deployVolumes {
+		volume, err := parseVolume(v)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		volumes = append(volumes, volume)
+	}
+
+	req := &proto.DeployRequest{
+		ServiceName:           deployService,
+		Image:                 deployImage,
+		Env:                   envMap,
+		Labels:                labels,
+		Constraints:           deployConstraints,
+		PlacementPreferences:  deploySpread,
+		MaxReplicasPerNode:    deployMaxPerNode,
+		Replicas:              int32(deployReplicas),
+		CpuReserve:            deployCPUReserve,
+		MemoryReserve:         memReserve,
+		CpuLimit:              deployCPULimit,
+		MemoryLimit:           memLimit,
+		Healthcheck:           healthcheck,
+		Volumes:               volumes,
+		HealthPolicy:          deployHealth,
+		PreDeploy:             strings.Fields(deployPreHook),
+		PostDeploy:            strings.Fields(deployPostHook),
+		HookTimeoutSeconds:    int32(deployHookTimeout.Seconds()),
+		RollbackOnHookFailure: deployRollback,
+	}
+	var resp *proto.DeployResponse
+	if hooks {
+		resp, err = c.DeployStream(ctx, req, os.Stdout)
+	} else {
+		resp, err = c.DeployWithRequest(ctx, req)
+	}
 	if err != nil {
 		log.Fatalf("Failed to deploy service: %v", err)
 	}
 
+	for _, warning := range resp.Warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
 	fmt.Printf("Service deployed successfully!\nDeployment ID: %s\nStatus: %s\n",
 		resp.DeploymentId, resp.Status)
 }
+
+// parseVolume parses a SOURCE:DESTINATION[:ro] volume flag
+func parseVolume(v string) (*proto.VolumeMount, error) {
+	parts := strings.Split(v, ":")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid volume %q; expected SOURCE:DESTINATION[:ro]", v)
+	}
+	volume := &proto.VolumeMount{Source: parts[0], Destination: parts[1]}
+	if len(parts) == 3 {
+		if parts[2] != "ro" && parts[2] != "rw" {
+			return nil, fmt.Errorf("invalid volume mode %q; expected ro or rw", parts[2])
+		}
+		volume.ReadOnly = parts[2] == "ro"
+	}
+	return volume, nil
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+)
+
+var (
+	metricsService string
+	metricsNode    string
+	metricsGroupBy string
+	metricsSince   time.Duration
+	metricsStep    time.Duration
+)
+
+func init() {
+	metricsCmd := &cobra.Command{
+		Use:   "metrics",
+		Short: "Show container resource usage",
+		Long: `Show the CPU, memory, network and block I/O usage of the cluster's
+containers, as sampled by the node agents, per service or per node. The table
+shows the most recent step; CPU is in percent of one core, I/O in bytes per second.`,
+		Run: runMetrics,
+	}
+
+	metricsCmd.Flags().StringVar(&metricsService, "service", "", "Only show containers of this service")
+	metricsCmd.Flags().StringVar(&metricsNode, "node", "", "Only show containers on this node (ID or hostname)")
+	metricsCmd.Flags().StringVar(&metricsGroupBy, "group-by", "service", "Group containers by service or node")
+	metricsCmd.Flags().DurationVar(&metricsSince, "since", 15*time.Minute, "How far back to look")
+	metricsCmd.Flags().DurationVar(&metricsStep, "step", time.Minute, "Resolution of the aggregation")
+
+	rootCmd.AddCommand(metricsCmd)
+}
+
+func runMetrics(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.GetMetrics(ctx, metricsService, metricsNode, metricsGroupBy, metricsSince, metricsStep)
+	if err != nil {
+		log.Fatalf("Failed to get metrics: %v", err)
+	}
+	if len(resp.Series) == 0 {
+		fmt.Println("No metrics reported yet")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCONTAINERS\tCPU %\tMEMORY\tNET RX/s\tNET TX/s\tBLOCK READ/s\tBLOCK WRITE/s\tTIME")
+	for _, s := range resp.Series {
+		if len(s.Points) == 0 {
+			continue
+		}
+		name := s.Service
+		if s.Node != "" {
+			name = s.Hostname
+			if name == "" {
+				name = s.Node
+			}
+		}
+		p := s.Points[len(s.Points)-1]
+		memory := units.BytesSize(float64(p.MemoryUsage))
+		if p.MemoryLimit > 0 {
+			memory += " / " + units.BytesSize(float64(p.MemoryLimit))
+		}
+		fmt.Fprintf(w, "%s\t%d\t%.1f\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			name, p.Containers, p.CpuPercent, memory,
+			units.BytesSize(p.NetRxRate), units.BytesSize(p.NetTxRate),
+			units.BytesSize(p.BlockReadRate), units.BytesSize(p.BlockWriteRate),
+			time.Unix(p.TimestampUnix, 0).Format(time.Kitchen))
+	}
+	w.Flush()
+}
@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// pluginPrefix is the prefix of plugin executables: veloctl-foo adds `veloctl foo`
+const pluginPrefix = "veloctl-"
+
+// Groups of commands in the help output
+const (
+	builtinGroup = "builtin"
+	pluginGroup  = "plugins"
+)
+
+// plugin is an executable that adds a veloctl subcommand
+type plugin struct {
+	Name string
+	Path string
+	// Shadowed lists executables with the same name found later in the search order
+	Shadowed []string
+}
+
+// pluginDirs returns the directories searched for plugins, in order: the
+// plugins directory, then PATH
+func pluginDirs() []string {
+	var dirs []string
+	if dir := pluginsDir(); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	return append(dirs, filepath.SplitList(os.Getenv("PATH"))...)
+}
+
+// pluginsDir returns VELO_PLUGINS_DIR, or the plugins directory in the user's
+// config directory
+func pluginsDir() string {
+	if dir := os.Getenv("VELO_PLUGINS_DIR"); dir != "" {
+		return dir
+	}
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(config, "velo", "plugins")
+}
+
+// discoverPlugins finds the plugin executables in dirs. When several have the
+// same name the first one wins.
+func discoverPlugins(dirs []string) []*plugin {
+	var plugins []*plugin
+	byName := make(map[string]*plugin)
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		if dir == "" || seen[dir] {
+			continue
+		}
+		seen[dir] = true
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := pluginName(entry.Name())
+			if !ok {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if !isExecutable(path) {
+				continue
+			}
+			if p, ok := byName[name]; ok {
+				p.Shadowed = append(p.Shadowed, path)
+				continue
+			}
+			p := &plugin{Name: name, Path: path}
+			byName[name] = p
+			plugins = append(plugins, p)
+		}
+	}
+	return plugins
+}
+
+// pluginName returns the command name of a plugin executable's file name
+func pluginName(file string) (string, bool) {
+	if runtime.GOOS == "windows" {
+		file = strings.TrimSuffix(strings.ToLower(file), ".exe")
+	}
+	name, ok := strings.CutPrefix(file, pluginPrefix)
+	if !ok || name == "" || strings.ContainsAny(name, ". ") {
+		return "", false
+	}
+	return name, true
+}
+
+// isExecutable reports whether path is a regular file that can be executed
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	return runtime.GOOS == "windows" || info.Mode().Perm()&0111 != 0
+}
+
+// registerPlugins adds a subcommand for every plugin that doesn't clash with a
+// built-in command
+func registerPlugins(root *cobra.Command, plugins []*plugin) {
+	if len(plugins) == 0 {
+		return
+	}
+	// List the built-in commands first in the help output
+	root.AddGroup(&cobra.Group{ID: builtinGroup, Title: "Available Commands:"}, &cobra.Group{ID: pluginGroup, Title: "Plugin Commands:"})
+	for _, cmd := range root.Commands() {
+		cmd.GroupID = builtinGroup
+	}
+	root.SetHelpCommandGroupID(builtinGroup)
+	root.SetCompletionCommandGroupID(builtinGroup)
+
+	for _, p := range plugins {
+		if isBuiltin(root, p.Name) {
+			continue
+		}
+		root.AddCommand(&cobra.Command{
+			Use:                p.Name,
+			Short:              "Plugin at " + p.Path,
+			Long:               fmt.Sprintf("Runs %s with the remaining arguments; run \"veloctl %s --help\" for its own help.", p.Path, p.Name),
+			GroupID:            pluginGroup,
+			DisableFlagParsing: true,
+			Run: func(cmd *cobra.Command, args []string) {
+				runPlugin(p, pluginArgs(os.Args[1:], p.Name))
+			},
+		})
+	}
+}
+
+// isBuiltin reports whether a built-in command has a name
+func isBuiltin(root *cobra.Command, name string) bool {
+	// Cobra adds these when the command runs
+	if name == "help" || name == "completion" {
+		return true
+	}
+	cmd, _, err := root.Find([]string{name})
+	return err == nil && cmd != root && cmd.GroupID != pluginGroup
+}
+
+// wantsPlugins reports whether an invocation may run a plugin or list them in
+// its help, so built-in commands don't search PATH for plugins
+func wantsPlugins(root *cobra.Command, args []string) bool {
+	name := commandName(args)
+	return name == "" || name == "help" || !isBuiltin(root, name)
+}
+
+// commandName returns the first argument after veloctl's global flags, or ""
+// when there is none or the flags don't parse
+func commandName(args []string) string {
+	fs := pflag.NewFlagSet("veloctl", pflag.ContinueOnError)
+	fs.AddFlagSet(rootCmd.PersistentFlags())
+	fs.SetInterspersed(false)
+	fs.Usage = func() {}
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil || fs.NArg() == 0 {
+		return ""
+	}
+	return fs.Arg(0)
+}
+
+// pluginArgs returns the arguments a plugin gets: those after its name. The
+// global flags before its name are parsed as veloctl's own.
+func pluginArgs(args []string, name string) []string {
+	fs := pflag.NewFlagSet("veloctl", pflag.ContinueOnError)
+	fs.AddFlagSet(rootCmd.PersistentFlags())
+	fs.SetInterspersed(false)
+	fs.Usage = func() {}
+	if err := fs.Parse(args); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	rest := fs.Args()
+	if len(rest) > 0 && rest[0] == name {
+		rest = rest[1:]
+	}
+	return rest
+}
+
+// pluginEnv returns the environment plugins run with: veloctl's own, plus the
+// settings of the invocation
+func pluginEnv() []string {
+	return append(os.Environ(),
+		"VELO_SERVER="+serverAddr,
+		"VELO_CLUSTER="+clusterName,
+		"VELO_TOKEN="+token(),
+		"VELO_OUTPUT="+outputFormat,
+		"VELO_TIMEOUT="+timeout.String(),
+	)
+}
+
+// runPlugin runs a plugin and exits with its exit code
+func runPlugin(p *plugin, args []string) {
+	c := exec.Command(p.Path, args...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	c.Env = pluginEnv()
+
+	err := c.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.ExitCode())
+	}
+	if err != nil {
+		fmt.Printf("Failed to run plugin %s: %v\n", p.Name, err)
+		os.Exit(1)
+	}
+}
+
+func init() {
+	pluginCmd := &cobra.Command{
+		Use:   "plugin",
+		Short: "Manage veloctl plugins",
+		Long: `Plugins are executables named veloctl-<name>, found in the plugins directory
+(VELO_PLUGINS_DIR, or velo/plugins in the user config directory) and on PATH.
+Each one adds a "veloctl <name>" command that runs it with the remaining
+arguments. Plugins get VELO_SERVER, VELO_CLUSTER, VELO_TOKEN, VELO_OUTPUT and
+VELO_TIMEOUT in their environment.`,
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the installed plugins",
+		Run:   runPluginList,
+	}
+
+	pluginCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(pluginCmd)
+}
+
+func runPluginList(cmd *cobra.Command, args []string) {
+	plugins := discoverPlugins(pluginDirs())
+	if len(plugins) == 0 {
+		fmt.Printf("No plugins found in %s or on PATH\n", pluginsDir())
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPATH")
+	for _, p := range plugins {
+		fmt.Fprintf(w, "%s\t%s\n", p.Name, p.Path)
+	}
+	w.Flush()
+
+	for _, p := range plugins {
+		if isBuiltin(rootCmd, p.Name) {
+			fmt.Printf("Warning: %s is ignored, it has the name of a built-in command\n", p.Path)
+		}
+		for _, path := range p.Shadowed {
+			fmt.Printf("Warning: %s is shadowed by %s\n", path, p.Path)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiscoverPlugins(t *testing.T) {
+	pluginsDir, pathDir := t.TempDir(), t.TempDir()
+	write := func(dir, name string, mode os.FileMode) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"), mode); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		return path
+	}
+	hello := write(pluginsDir, "veloctl-hello", 0755)
+	shadowed := write(pathDir, "veloctl-hello", 0755)
+	backup := write(pathDir, "veloctl-backup", 0755)
+	write(pathDir, "veloctl-notes.txt", 0755)
+	write(pathDir, "veloctl-readonly", 0644)
+	write(pathDir, "kubectl-foo", 0755)
+	if err := os.Mkdir(filepath.Join(pathDir, "veloctl-dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	plugins := discoverPlugins([]string{pluginsDir, "", pathDir, pluginsDir, filepath.Join(pathDir, "missing")})
+	if len(plugins) != 2 {
+		t.Fatalf("Expected 2 plugins, got %d: %+v", len(plugins), plugins)
+	}
+	if plugins[0].Name != "hello" || plugins[0].Path != hello {
+		t.Errorf("Expected the plugins directory to win, got %+v", plugins[0])
+	}
+	if len(plugins[0].Shadowed) != 1 || plugins[0].Shadowed[0] != shadowed {
+		t.Errorf("Expected %s to be shadowed, got %v", shadowed, plugins[0].Shadowed)
+	}
+	if plugins[1].Name != "backup" || plugins[1].Path != backup {
+		t.Errorf("Expected the backup plugin, got %+v", plugins[1])
+	}
+}
+
+func TestWantsPlugins(t *testing.T) {
+	for _, tt := range []struct {
+		args []string
+		want bool
+	}{
+		{nil, true},
+		{[]string{"--help"}, true},
+		{[]string{"help"}, true},
+		{[]string{"deploy", "--service", "web"}, false},
+		{[]string{"--server", "prod:37355", "status"}, false},
+		{[]string{"--server", "prod:37355", "backup", "--all"}, true},
+		{[]string{"backup"}, true},
+	} {
+		if got := wantsPlugins(rootCmd, tt.args); got != tt.want {
+			t.Errorf("Expected wantsPlugins(%v) to be %v, got %v", tt.args, tt.want, got)
+		}
+	}
+}
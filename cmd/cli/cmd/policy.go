@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/jasonlovesdoggo/velo/internal/config"
+	"github.com/jasonlovesdoggo/velo/internal/policy"
+	"github.com/spf13/cobra"
+)
+
+var policyFile string
+
+func init() {
+	policyCmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Check services against the deploy policy",
+	}
+
+	testCmd := &cobra.Command{
+		Use:   "test [velo.toml]",
+		Short: "Check a service definition against the deploy policy",
+		Long: `Check a service definition against the policy the server checks deploys
+against, or a local policy file with --policy. Without a file the velo.toml of
+the current directory is checked. Exits non-zero if an enforced rule is broken.`,
+		Args: cobra.MaximumNArgs(1),
+		Run:  runPolicyTest,
+	}
+	testCmd.Flags().StringVar(&policyFile, "policy", "", "Policy file to check against instead of the server's")
+
+	policyCmd.AddCommand(testCmd)
+	rootCmd.AddCommand(policyCmd)
+}
+
+func runPolicyTest(cmd *cobra.Command, args []string) {
+	var def *config.ServiceDefinition
+	var err error
+	if len(args) == 1 {
+		def, err = config.LoadServiceFile(args[0])
+	} else {
+		pwd, wdErr := os.Getwd()
+		if wdErr != nil {
+			log.Fatalf("Failed to get working directory: %v", wdErr)
+		}
+		def, err = config.LoadConfigFromFile(pwd)
+	}
+	if errors.Is(err, config.ErrConfigNotFound) {
+		log.Fatalf("Config file not found. Please create a %s file.", config.FileName)
+	}
+	if err != nil {
+		log.Fatalf("Config file is invalid: %v", err)
+	}
+
+	var p *policy.Policy
+	if policyFile != "" {
+		p, err = policy.Load(policyFile)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+	} else {
+		p = fetchPolicy()
+	}
+	if p == nil {
+		fmt.Println("The server doesn't check deploys against a policy.")
+		return
+	}
+
+	violations := p.Evaluate(*def)
+	if len(violations) == 0 {
+		fmt.Printf("%s complies with the policy.\n", def.Name)
+		return
+	}
+	enforced := 0
+	for _, v := range violations {
+		fmt.Printf("[%s] %s\n", v.Mode, v)
+		if v.Mode == policy.ModeEnforce {
+			enforced++
+		}
+	}
+	if enforced > 0 {
+		fmt.Printf("%s would be rejected: %d enforced rule violation(s).\n", def.Name, enforced)
+		os.Exit(1)
+	}
+	fmt.Printf("%s would be deployed with %d warning(s).\n", def.Name, len(violations))
+}
+
+// fetchPolicy returns the server's policy, or nil if it has none
+func fetchPolicy() *policy.Policy {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	source, err := c.GetPolicy(ctx)
+	if err != nil {
+		log.Fatalf("Failed to get the policy: %v", err)
+	}
+	if source == "" {
+		return nil
+	}
+	p, err := policy.Parse([]byte(source))
+	if err != nil {
+		log.Fatalf("The server's policy is invalid: %v", err)
+	}
+	return p
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log"
 
-	"github.com/jasonlovesdoggo/velo/pkg/client"
 	"github.com/spf13/cobra"
 )
 
@@ -29,7 +28,7 @@ func runRollback(cmd *cobra.Command, args []string) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	c, err := client.NewClient(serverAddr)
+	c, err := newClient()
 	if err != nil {
 		log.Fatalf("Failed to create client: %v", err)
 	}
@@ -1,16 +1,24 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"time"
 
+	"github.com/jasonlovesdoggo/velo/internal/tracing"
+	"github.com/jasonlovesdoggo/velo/pkg/client"
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
 )
 
 var (
-	serverAddr string
-	timeout    time.Duration
+	serverAddr  string
+	clusterName string
+	timeout     time.Duration
+
+	authToken    string
+	outputFormat string
 )
 
 var rootCmd = &cobra.Command{
@@ -22,11 +30,53 @@ self-hostable deployment and operations platform built on top of Docker Swarm.`,
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&serverAddr, "server", "localhost:37355", "The server address in host:port format")
+	rootCmd.PersistentFlags().StringVar(&clusterName, "cluster", os.Getenv("VELO_CLUSTER"), "The cluster to operate on (defaults to the server's default cluster)")
 	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 10*time.Second, "Timeout for API requests")
+	rootCmd.PersistentFlags().StringVar(&authToken, "token", os.Getenv("VELO_TOKEN"), "API token to authenticate with instead of the one stored by auth login (or set VELO_TOKEN)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table or json); used by audit and passed on to plugins")
+}
+
+// traced traces the calls of a client, continuing TRACEPARENT if it's set
+func traced() client.Option {
+	return client.WithDialOptions(tracing.ClientDialOptions()...)
+}
+
+// newClient connects to the server, authenticated with token(), and selects
+// the cluster given with --cluster
+func newClient() (*client.Client, error) {
+	c, err := client.NewClient(serverAddr,
+		client.WithToken(token()),
+		traced(),
+		client.WithDialOptions(
+			grpc.WithChainUnaryInterceptor(loginRequiredUnary),
+			grpc.WithChainStreamInterceptor(loginRequiredStream),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+	c.UseCluster(clusterName)
+	return c, nil
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	// Requests carry a W3C trace context, continuing TRACEPARENT if it's set.
+	// Spans are exported to OTEL_EXPORTER_OTLP_ENDPOINT, if it's set.
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{ServiceName: "veloctl"})
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if wantsPlugins(rootCmd, os.Args[1:]) {
+		registerPlugins(rootCmd, discoverPlugins(pluginDirs()))
+	}
+
+	err = rootCmd.Execute()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	shutdownTracing(ctx)
+	cancel()
+	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
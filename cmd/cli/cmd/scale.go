@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+var scaleReplicas int
+
+func init() {
+	scaleCmd := &cobra.Command{
+		Use:   "scale <service>",
+		Short: "Scale a service",
+		Long:  `Change the number of replicas of a service. Scale-ups are checked against the cluster's free capacity.`,
+		Args:  cobra.ExactArgs(1),
+		Run:   runScale,
+	}
+
+	scaleCmd.Flags().IntVar(&scaleReplicas, "replicas", 1, "Desired number of replicas")
+	scaleCmd.MarkFlagRequired("replicas")
+
+	rootCmd.AddCommand(scaleCmd)
+}
+
+func runScale(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.Scale(ctx, args[0], scaleReplicas)
+	if err != nil {
+		log.Fatalf("Failed to scale service: %v", err)
+	}
+	if !resp.Success {
+		log.Fatalf("%s", resp.Message)
+	}
+
+	fmt.Println(resp.Message)
+}
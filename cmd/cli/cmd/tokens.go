@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/api/proto"
+	"github.com/spf13/cobra"
+)
+
+var (
+	apiTokenName   string
+	apiTokenScopes []string
+	apiTokenTTL    time.Duration
+	apiTokenUser   string
+	apiTokensAll   bool
+)
+
+// apiTokensCmd returns the "auth tokens" commands
+func apiTokensCmd() *cobra.Command {
+	tokensCmd := &cobra.Command{
+		Use:   "tokens",
+		Short: "Manage API tokens",
+		Long: `Manage long-lived API tokens for CI and scripts. A token can do what its
+scopes allow and its owner's role allows, whichever is less:
+
+  read-only          read services, nodes, metrics and alerts
+  deploy             and deploy, scale and roll back any service
+  deploy:<service>   and deploy, scale and roll back one service
+  admin              everything the owner may do
+
+Use a token with --token or VELO_TOKEN. Admins create tokens for service
+accounts (see 'veloctl auth create-user --service-account').
+
+  veloctl auth tokens create --name laptop --scope read-only --ttl 720h
+  veloctl auth tokens create --name deploy-web --scope deploy:web --user ci`,
+	}
+
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create an API token",
+		Long:  `Create an API token. It's only shown once, so store it right away.`,
+		Args:  cobra.NoArgs,
+		Run:   runCreateAPIToken,
+	}
+	createCmd.Flags().StringVar(&apiTokenName, "name", "", "What the token is for")
+	createCmd.Flags().StringSliceVar(&apiTokenScopes, "scope", nil, "Scope of the token, repeatable (read-only, deploy, deploy:<service> or admin)")
+	createCmd.Flags().DurationVar(&apiTokenTTL, "ttl", 0, "How long the token is valid (0 for a token that doesn't expire)")
+	createCmd.Flags().StringVar(&apiTokenUser, "user", "", "Service account to create the token for (yourself if not specified)")
+	createCmd.MarkFlagRequired("name")
+	createCmd.MarkFlagRequired("scope")
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List API tokens",
+		Args:  cobra.NoArgs,
+		Run:   runListAPITokens,
+	}
+	listCmd.Flags().BoolVar(&apiTokensAll, "all", false, "List everyone's tokens (admins only)")
+
+	revokeCmd := &cobra.Command{
+		Use:   "revoke [id]",
+		Short: "Revoke an API token",
+		Args:  cobra.ExactArgs(1),
+		Run:   runRevokeAPIToken,
+	}
+
+	tokensCmd.AddCommand(createCmd)
+	tokensCmd.AddCommand(listCmd)
+	tokensCmd.AddCommand(revokeCmd)
+	return tokensCmd
+}
+
+func runCreateAPIToken(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.CreateAPIToken(ctx, &proto.CreateAPITokenRequest{
+		Name:       apiTokenName,
+		Scopes:     apiTokenScopes,
+		TtlSeconds: int64(apiTokenTTL / time.Second),
+		Username:   apiTokenUser,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create API token: %v", err)
+	}
+
+	token := resp.ApiToken
+	fmt.Printf("API token %s (%s) created for %s with scopes %s, expires %s\n",
+		token.Name, token.Id, token.Username, strings.Join(token.Scopes, ", "), apiTokenTime(token.ExpiresUnix, "never"))
+	fmt.Println("Store it now; it won't be shown again:")
+	fmt.Printf("\n    %s\n\n", resp.Token)
+}
+
+func runListAPITokens(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.ListAPITokens(ctx, apiTokensAll)
+	if err != nil {
+		log.Fatalf("Failed to list API tokens: %v", err)
+	}
+	if len(resp.Tokens) == 0 {
+		fmt.Println("No API tokens")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tUSER\tSCOPES\tCREATED\tEXPIRES\tLAST USED")
+	for _, token := range resp.Tokens {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", token.Id, token.Name, token.Username, strings.Join(token.Scopes, ","),
+			apiTokenTime(token.CreatedUnix, "-"), apiTokenTime(token.ExpiresUnix, "never"), apiTokenTime(token.LastUsedUnix, "never"))
+	}
+	w.Flush()
+}
+
+func runRevokeAPIToken(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.RevokeAPIToken(ctx, args[0])
+	if err != nil {
+		log.Fatalf("Failed to revoke API token: %v", err)
+	}
+	fmt.Println(resp.Message)
+}
+
+// apiTokenTime formats a token's Unix time, or returns unset when it's 0
+func apiTokenTime(unix int64, unset string) string {
+	if unix == 0 {
+		return unset
+	}
+	return time.Unix(unix, 0).Format(time.RFC3339)
+}
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/api/proto"
+	"github.com/spf13/cobra"
+)
+
+var (
+	webhookURL         string
+	webhookEvents      []string
+	webhookService     string
+	webhookSecret      string
+	webhookDescription string
+
+	deliveriesWebhook string
+	deliveriesLimit   int
+)
+
+func init() {
+	webhooksCmd := &cobra.Command{
+		Use:   "webhooks",
+		Short: "Manage webhooks for deployment events",
+		Long: `Webhooks POST deployment lifecycle events as JSON to a URL. Every request
+carries these headers:
+
+  X-Velo-Event      the event type, e.g. deploy.succeeded
+  X-Velo-Delivery   the delivery ID, the same on every retry
+  X-Velo-Timestamp  when the request was sent, in unix seconds
+  X-Velo-Signature  sha256=<hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the secret>
+
+Receivers should check the signature and reject old timestamps. Deliveries
+that fail are retried with exponential backoff.`,
+		Run: runListWebhooks,
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List webhooks",
+		Run:   runListWebhooks,
+	}
+
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Subscribe a URL to deployment events",
+		Long: `Subscribe a URL to deployment events. Webhooks get the events of all
+clusters and services unless --cluster or --service is given; node events
+only go to webhooks without a service. The signing secret is printed once.
+
+  veloctl webhooks create --url https://ci.example.com/velo --event deploy.failed --event service.rolled_back
+  veloctl webhooks create --url https://hooks.example.com/web --service web`,
+		Run: runCreateWebhook,
+	}
+	createCmd.Flags().StringVar(&webhookURL, "url", "", "URL the events are POSTed to")
+	createCmd.Flags().StringSliceVar(&webhookEvents, "event", nil, "Events to send (default: all)")
+	createCmd.Flags().StringVar(&webhookService, "service", "", "Only send the events of this service")
+	createCmd.Flags().StringVar(&webhookSecret, "secret", "", "Secret to sign payloads with (default: generated)")
+	createCmd.Flags().StringVar(&webhookDescription, "description", "", "What the webhook is for")
+	createCmd.MarkFlagRequired("url")
+
+	deleteCmd := &cobra.Command{
+		Use:   "delete <webhook-id>",
+		Short: "Delete a webhook",
+		Long:  `Delete a webhook. Its pending deliveries are dropped.`,
+		Args:  cobra.ExactArgs(1),
+		Run:   runDeleteWebhook,
+	}
+
+	deliveriesCmd := &cobra.Command{
+		Use:   "deliveries",
+		Short: "Show the webhook delivery log",
+		Long:  `Show recent webhook deliveries, newest first, with their attempts and last error.`,
+		Run:   runListDeliveries,
+	}
+	deliveriesCmd.Flags().StringVar(&deliveriesWebhook, "webhook", "", "Only show the deliveries of this webhook")
+	deliveriesCmd.Flags().IntVar(&deliveriesLimit, "limit", 50, "How many deliveries to show (0 for all)")
+
+	webhooksCmd.AddCommand(listCmd)
+	webhooksCmd.AddCommand(createCmd)
+	webhooksCmd.AddCommand(deleteCmd)
+	webhooksCmd.AddCommand(deliveriesCmd)
+
+	rootCmd.AddCommand(webhooksCmd)
+}
+
+func runListWebhooks(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.ListWebhooks(ctx)
+	if err != nil {
+		log.Fatalf("Failed to list webhooks: %v", err)
+	}
+	if len(resp.Webhooks) == 0 {
+		fmt.Println("No webhooks")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tURL\tEVENTS\tSCOPE\tCREATED\tDESCRIPTION")
+	for _, h := range resp.Webhooks {
+		events := strings.Join(h.Events, ",")
+		if events == "" {
+			events = "(all)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", h.Id, h.Url, events, webhookScope(h),
+			time.Unix(h.CreatedUnix, 0).Format(time.RFC3339), h.Description)
+	}
+	w.Flush()
+}
+
+// webhookScope renders which clusters and services a webhook gets events of
+func webhookScope(h *proto.Webhook) string {
+	var scope []string
+	if h.Cluster != "" {
+		scope = append(scope, "cluster="+h.Cluster)
+	}
+	if h.Service != "" {
+		scope = append(scope, "service="+h.Service)
+	}
+	if len(scope) == 0 {
+		return "(all)"
+	}
+	return strings.Join(scope, ",")
+}
+
+func runCreateWebhook(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	h, err := c.CreateWebhook(ctx, &proto.CreateWebhookRequest{
+		Url:         webhookURL,
+		Events:      webhookEvents,
+		Cluster:     clusterName,
+		Service:     webhookService,
+		Description: webhookDescription,
+		Secret:      webhookSecret,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create webhook: %v", err)
+	}
+
+	fmt.Printf("Webhook %s created for %s\n", h.Id, h.Url)
+	fmt.Printf("Signing secret: %s\n", h.Secret)
+	fmt.Println("Store the secret now; it won't be shown again.")
+}
+
+func runDeleteWebhook(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.DeleteWebhook(ctx, args[0])
+	if err != nil {
+		log.Fatalf("Failed to delete webhook: %v", err)
+	}
+	if !resp.Success {
+		log.Fatalf("%s", resp.Message)
+	}
+	fmt.Println(resp.Message)
+}
+
+func runListDeliveries(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c, err := newClient()
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.ListWebhookDeliveries(ctx, deliveriesWebhook, deliveriesLimit)
+	if err != nil {
+		log.Fatalf("Failed to list webhook deliveries: %v", err)
+	}
+	if len(resp.Deliveries) == 0 {
+		fmt.Println("No webhook deliveries")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tWEBHOOK\tEVENT\tSTATE\tATTEMPTS\tSTATUS\tCREATED\tDETAIL")
+	for _, d := range resp.Deliveries {
+		status := "-"
+		if d.LastStatus != 0 {
+			status = fmt.Sprint(d.LastStatus)
+		}
+		detail := d.LastError
+		if d.State == "pending" && d.Attempts > 0 {
+			detail = fmt.Sprintf("retrying at %s: %s", time.Unix(d.NextAttemptUnix, 0).Format(time.RFC3339), d.LastError)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n", d.Id, d.Webhook, d.Event, d.State, d.Attempts, status,
+			time.Unix(d.CreatedUnix, 0).Format(time.RFC3339), detail)
+	}
+	w.Flush()
+}
@@ -1,90 +1,332 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
+	dockerclient "github.com/docker/docker/client"
+	"github.com/jasonlovesdoggo/velo/internal/admission"
 	"github.com/jasonlovesdoggo/velo/internal/agent"
+	"github.com/jasonlovesdoggo/velo/internal/alerting"
+	"github.com/jasonlovesdoggo/velo/internal/audit"
 	"github.com/jasonlovesdoggo/velo/internal/auth"
+	"github.com/jasonlovesdoggo/velo/internal/config"
 	"github.com/jasonlovesdoggo/velo/internal/log"
+	"github.com/jasonlovesdoggo/velo/internal/metrics"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/cluster"
 	"github.com/jasonlovesdoggo/velo/internal/orchestrator/manager"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/sim"
+	"github.com/jasonlovesdoggo/velo/internal/policy"
 	"github.com/jasonlovesdoggo/velo/internal/server"
 	"github.com/jasonlovesdoggo/velo/internal/state"
+	"github.com/jasonlovesdoggo/velo/internal/state/stores"
+	"github.com/jasonlovesdoggo/velo/internal/tracing"
 	"github.com/jasonlovesdoggo/velo/internal/web"
+	"github.com/jasonlovesdoggo/velo/internal/webhooks"
+	"github.com/jasonlovesdoggo/velo/pkg/client"
 	"github.com/jasonlovesdoggo/velo/pkg/core"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "join" {
+		runJoin(os.Args[2:])
+		return
+	}
+
 	// Parse command line flags
 	isManager := flag.Bool("manager", false, "Run as manager daemon")
+	configPath := flag.String("config", config.DefaultDaemonConfigPath, "Daemon config file")
+	backend := flag.String("backend", config.BackendSwarm, "Orchestration backend (swarm, standalone or sim)")
 	webPort := flag.String("web-port", "8080", "Web interface port")
+	capacityPolicy := flag.String("capacity-policy", string(manager.CapacityEnforce), "What to do with deploys that don't fit on the cluster (enforce, warn or off)")
+	managerAddr := flag.String("manager-addr", os.Getenv("VELO_MANAGER_ADDR"), "Manager the agent reports to (or set VELO_MANAGER_ADDR)")
+	clusterName := flag.String("cluster", "", "Cluster the agent's node belongs to (defaults to the manager's choice)")
+	agentToken := flag.String("agent-token", os.Getenv("VELO_AGENT_TOKEN"), "Token the agent authenticates with, from 'veloctl cluster agent-token' (or set VELO_AGENT_TOKEN)")
+	agentTokenFile := flag.String("agent-token-file", defaultAgentTokenFile, "File the agent token is read from unless given, written by 'velo join'")
+	agentDefaults := agent.DefaultOptions()
+	collectInterval := flag.Duration("collect-interval", agentDefaults.CollectInterval, "How often the agent refreshes its container inventory")
+	healthInterval := flag.Duration("health-interval", agentDefaults.HealthInterval, "How often the agent checks container health")
+	metricsInterval := flag.Duration("metrics-interval", agentDefaults.MetricsInterval, "How often the agent samples the resource usage of Velo tasks")
+	healthPolicy := flag.String("health-policy", agentDefaults.DefaultPolicy, "What the agent does about unhealthy or crash-looping containers of services without a policy (restart, report or ignore)")
 	flag.Parse()
 
 	if *isManager {
-		runManager(*webPort)
+		cfg, err := config.LoadDaemonConfig(*configPath)
+		if err != nil {
+			log.Error("Failed to load daemon config", "error", err)
+			os.Exit(1)
+		}
+
+		// Flags given on the command line override the config file
+		flag.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "backend":
+				cfg.Backend = *backend
+			case "web-port":
+				cfg.WebPort = *webPort
+			case "capacity-policy":
+				cfg.CapacityPolicy = *capacityPolicy
+			}
+		})
+
+		runManager(cfg)
 	} else {
-		runWorker()
+		opts := agentDefaults
+		opts.CollectInterval = *collectInterval
+		opts.HealthInterval = *healthInterval
+		opts.DefaultPolicy = *healthPolicy
+		opts.MetricsInterval = *metricsInterval
+		token := *agentToken
+		if token == "" {
+			token = readAgentToken(*agentTokenFile)
+		}
+		runWorker(*managerAddr, *clusterName, token, opts)
+	}
+}
+
+// defaultAgentTokenFile is where 'velo join' keeps the agent token
+const defaultAgentTokenFile = "/etc/velo/agent-token"
+
+// readAgentToken reads the agent token kept in a file, if there's one
+func readAgentToken(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Warn("Failed to read agent token", "path", path, "error", err)
+		}
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// newBackend creates the orchestration backend of a cluster
+func newBackend(cfg config.DaemonConfig, cc config.ClusterConfig) (manager.Backend, error) {
+	policy, err := manager.ParseCapacityPolicy(cfg.CapacityPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cc.Backend {
+	case config.BackendSwarm:
+		cli, err := cluster.NewDockerClient(cc)
+		if err != nil {
+			return nil, err
+		}
+		swarmManager := manager.NewSwarmManagerWithClient(cli)
+		swarmManager.SetCapacityPolicy(policy)
+		return swarmManager, nil
+	case config.BackendStandalone:
+		cli, err := cluster.NewDockerClient(cc)
+		if err != nil {
+			return nil, err
+		}
+		return manager.NewStandaloneManagerWithClient(cli, cfg.Standalone), nil
+	case config.BackendSim:
+		log.Warn("Running against a simulated cluster, nothing will actually be deployed", "cluster", cc.Name)
+		return sim.New(sim.Options{StartDelay: 3 * time.Second, CapacityPolicy: policy}), nil
+	default:
+		return nil, fmt.Errorf("unknown backend %q (expected swarm, standalone or sim)", cc.Backend)
+	}
+}
+
+// newRegistry creates the backends of all clusters in the daemon config
+func newRegistry(cfg config.DaemonConfig) (*cluster.Registry, error) {
+	registry := cluster.NewRegistry()
+	for _, cc := range cfg.ClusterConfigs() {
+		backend, err := newBackend(cfg, cc)
+		if err != nil {
+			return nil, fmt.Errorf("cluster %s: %w", cc.Name, err)
+		}
+		if err := registry.Register(&cluster.Cluster{Name: cc.Name, Backend: cc.Backend, Host: cc.Host, Manager: backend}); err != nil {
+			return nil, err
+		}
 	}
+	if err := registry.SetDefault(cfg.DefaultClusterName()); err != nil {
+		return nil, err
+	}
+	return registry, nil
 }
 
-func runManager(webPort string) {
+func runManager(cfg config.DaemonConfig) {
 	log.Info("Starting Velo Management Server...")
 
+	// Export traces, if a collector is configured
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		SampleRatio: cfg.Tracing.SampleRatio,
+		ServiceName: "velo-manager",
+	})
+	if err != nil {
+		log.Error("Failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+	if tracing.Enabled(tracing.Config{Endpoint: cfg.Tracing.Endpoint}) {
+		log.Info("Exporting traces", "endpoint", cfg.Tracing.Endpoint, "sampleRatio", cfg.Tracing.SampleRatio)
+	}
+
 	// Initialize state store
 	stateStore, err := state.NewDefaultStateStore()
 	if err != nil {
 		log.Error("Failed to create state store", "error", err)
 		os.Exit(1)
 	}
+	stateStore = tracing.InstrumentStateStore(metrics.InstrumentStateStore(stateStore))
 
 	// Initialize auth service
 	authService := auth.NewAuthService(stateStore)
+	authService.SetPasswordPolicy(auth.PasswordPolicy{
+		MinLength:     cfg.Auth.PasswordMinLength,
+		RequireUpper:  cfg.Auth.PasswordRequireUpper,
+		RequireLower:  cfg.Auth.PasswordRequireLower,
+		RequireDigit:  cfg.Auth.PasswordRequireDigit,
+		RequireSymbol: cfg.Auth.PasswordRequireSymbol,
+	})
+	authService.SetLockoutPolicy(auth.LockoutPolicy{
+		Threshold:  cfg.Auth.LockoutThreshold,
+		Backoff:    time.Duration(cfg.Auth.LockoutBackoff) * time.Second,
+		MaxBackoff: time.Duration(cfg.Auth.MaxLockout) * time.Minute,
+	})
 	if err := authService.Initialize(); err != nil {
 		log.Error("Failed to initialize auth service", "error", err)
 		os.Exit(1)
 	}
 
-	// Create a new swarm manager
-	swarmManager, err := manager.NewSwarmManager()
+	// Create the orchestration backends
+	clusters, err := newRegistry(cfg)
 	if err != nil {
-		log.Error("Failed to create swarm manager", "error", err)
+		log.Error("Failed to create backends", "error", err)
 		os.Exit(1)
 	}
 
-	// Start the manager
-	if err := swarmManager.Start(); err != nil {
-		log.Error("Failed to start swarm manager", "error", err)
+	// Record who changed what, including what the backends do on their own
+	auditLog := audit.New(stateStore)
+	for _, c := range clusters.Clusters() {
+		if r, ok := c.Manager.(manager.Reconciler); ok {
+			r.OnReconcile(auditLog.Reconciled(c.Name))
+		}
+	}
+
+	// Keep container metrics past the manager's in-memory hour
+	var history *stores.TimeSeriesStore
+	if cfg.Metrics.Path != "" {
+		history, err = stores.NewTimeSeriesStore(cfg.Metrics.Path, stores.TimeSeriesOptions{
+			Retention: stores.Retention{
+				Raw:    time.Duration(cfg.Metrics.RawRetention) * time.Hour,
+				Minute: time.Duration(cfg.Metrics.MinuteRetention) * time.Hour,
+				Hour:   time.Duration(cfg.Metrics.HourRetention) * time.Hour,
+			},
+		})
+		if err != nil {
+			log.Error("Failed to open metrics history, keeping metrics in memory only", "path", cfg.Metrics.Path, "error", err)
+		} else {
+			for _, c := range clusters.Clusters() {
+				c.Metrics.Record(history, c.Name)
+			}
+		}
+	}
+
+	// Export the state of the clusters on /metrics
+	metrics.Registry.MustRegister(metrics.NewClusterCollector(clusters))
+
+	// Start the clusters; unreachable ones are retried by the health checks
+	clusters.Start(cluster.DefaultHealthInterval)
+	healthy := 0
+	for _, status := range clusters.List() {
+		if !status.Healthy {
+			log.Error("Failed to start cluster", "cluster", status.Name, "backend", status.Backend, "error", status.Error)
+			continue
+		}
+		healthy++
+		log.Info("Orchestration backend started", "cluster", status.Name, "backend", status.Backend, "nodes", status.Nodes)
+	}
+	if healthy == 0 {
+		log.Error("No cluster could be started")
+		clusters.Stop()
 		os.Exit(1)
 	}
 
-	// Print node information
-	nodes := swarmManager.GetNodes()
-	log.Info("Managing nodes in the swarm", "count", len(nodes))
-	for _, node := range nodes {
-		log.Info("Node details", "hostname", node.Hostname, "id", node.ID, "isManager", node.Manager)
+	// Evaluate alert rules and notify the configured receivers
+	receivers := make(map[string]alerting.Notifier, len(cfg.Alerting.Receivers))
+	for _, rc := range cfg.Alerting.Receivers {
+		notifier, err := alerting.NewNotifier(rc)
+		if err != nil {
+			log.Error("Failed to create alert receiver", "receiver", rc.Name, "error", err)
+			clusters.Stop()
+			os.Exit(1)
+		}
+		receivers[rc.Name] = notifier
+	}
+	alerts := alerting.NewEngine(stateStore, clusters, alerting.Options{
+		Interval:       time.Duration(cfg.Alerting.Interval) * time.Second,
+		RepeatInterval: time.Duration(cfg.Alerting.RepeatInterval) * time.Minute,
+		Receivers:      receivers,
+	})
+	alerts.Start()
+
+	// Deliver lifecycle events to webhook subscriptions
+	hooks := webhooks.NewDispatcher(stateStore, clusters, webhooks.Options{
+		MaxAttempts: cfg.Webhooks.MaxAttempts,
+		Backoff:     time.Duration(cfg.Webhooks.RetryBackoff) * time.Second,
+		MaxBackoff:  time.Duration(cfg.Webhooks.MaxBackoff) * time.Minute,
+		Timeout:     time.Duration(cfg.Webhooks.Timeout) * time.Second,
+	})
+	hooks.Start()
+
+	// Ask admission webhooks about every deploy, then check it against the policy
+	admit := admission.New(cfg.Admission)
+	if len(cfg.Admission) > 0 {
+		log.Info("Admission webhooks configured", "count", len(cfg.Admission))
+	}
+	var deployPolicy *policy.Policy
+	if cfg.PolicyFile != "" {
+		deployPolicy, err = policy.Load(cfg.PolicyFile)
+		if err != nil {
+			log.Error("Failed to load deploy policy", "error", err)
+			clusters.Stop()
+			os.Exit(1)
+		}
+		log.Info("Deploy policy loaded", "path", cfg.PolicyFile, "rules", len(deployPolicy.Rules))
 	}
 
 	// Create and start the gRPC server
-	deploymentServer := server.NewDeploymentServer(swarmManager, authService)
+	deploymentServer := server.NewDeploymentServer(clusters, authService)
+	deploymentServer.SetAlerting(alerts)
+	deploymentServer.SetWebhooks(hooks)
+	deploymentServer.SetAdmission(admit)
+	deploymentServer.SetPolicy(deployPolicy)
+	deploymentServer.SetAudit(auditLog)
 	portstring := strconv.Itoa(core.Port)
 	if err := deploymentServer.Start(":" + portstring); err != nil {
 		log.Error("Failed to start gRPC server", "error", err)
-		swarmManager.Stop()
+		clusters.Stop()
 		os.Exit(1)
 	}
 	log.Info("gRPC server started", "address", ":"+portstring)
 
 	// Create and start the web server
-	webServer := web.NewWebServer(swarmManager, authService, webPort)
+	webServer := web.NewWebServer(clusters, authService, cfg.WebPort)
+	webServer.ServeMetrics(cfg.Metrics.ScrapeToken)
+	webServer.SetWebhooks(hooks)
+	webServer.SetAdmission(admit)
+	webServer.SetPolicy(deployPolicy)
+	webServer.SetAudit(auditLog)
+	webServer.SetLoginRate(cfg.Auth.LoginRate)
 	go func() {
 		if err := webServer.Start(); err != nil {
 			log.Error("Failed to start web server", "error", err)
 		}
 	}()
-	log.Info("Web server started", "address", ":"+webPort)
+	log.Info("Web server started", "address", ":"+cfg.WebPort)
 
 	// Wait for termination signal
 	sigCh := make(chan os.Signal, 1)
@@ -96,16 +338,111 @@ func runManager(webPort string) {
 	if err := webServer.Stop(); err != nil {
 		log.Error("Error stopping web server", "error", err)
 	}
-	swarmManager.Stop()
+	alerts.Stop()
+	hooks.Stop()
+	clusters.Stop()
+	if history != nil {
+		if err := history.Close(); err != nil {
+			log.Error("Error closing metrics history", "error", err)
+		}
+	}
 	stateStore.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := shutdownTracing(ctx); err != nil {
+		log.Error("Error flushing traces", "error", err)
+	}
+	cancel()
 	log.Info("Velo Management Server stopped")
 }
 
-func runWorker() {
+// runJoin joins this host to a cluster through a Velo manager and runs the agent
+func runJoin(args []string) {
+	fs := flag.NewFlagSet("join", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: velo join <manager-addr> --token <bootstrap-token> [flags]")
+		fs.PrintDefaults()
+	}
+	token := fs.String("token", os.Getenv("VELO_JOIN_TOKEN"), "Bootstrap token from 'veloctl cluster tokens create' (or set VELO_JOIN_TOKEN)")
+	advertiseAddr := fs.String("advertise-addr", "", "Address other nodes reach this node on (defaults to Docker's choice)")
+	listenAddr := fs.String("listen-addr", "0.0.0.0:2377", "Address the swarm listens on")
+	timeout := fs.Duration("timeout", 30*time.Second, "Timeout for contacting the manager and joining the swarm")
+	agentTokenFile := fs.String("agent-token-file", defaultAgentTokenFile, "File the agent token handed out on join is kept in")
+
+	// Flags may come before or after the manager address
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	managerAddr := fs.Arg(0)
+	fs.Parse(fs.Args()[1:])
+	if *token == "" {
+		log.Error("A bootstrap token is required, create one with 'veloctl cluster tokens create'")
+		os.Exit(2)
+	}
+	if !strings.Contains(managerAddr, ":") {
+		managerAddr += ":" + strconv.Itoa(core.Port)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Error("Failed to get hostname", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	c, err := client.NewClient(managerAddr, client.WithDialOptions(tracing.ClientDialOptions()...))
+	if err != nil {
+		log.Error("Failed to connect to manager", "address", managerAddr, "error", err)
+		os.Exit(1)
+	}
+	resp, err := c.Join(ctx, *token, hostname)
+	c.Close()
+	if err != nil {
+		log.Error("Manager refused to let this node join", "address", managerAddr, "error", err)
+		os.Exit(1)
+	}
+	log.Info("Authenticated with manager", "address", managerAddr, "role", resp.Role, "cluster", resp.Cluster)
+
+	// Keep the agent token, so the agent can authenticate again after a restart
+	if err := os.MkdirAll(filepath.Dir(*agentTokenFile), 0o755); err != nil {
+		log.Error("Failed to create agent token directory", "error", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*agentTokenFile, []byte(resp.AgentToken+"\n"), 0o600); err != nil {
+		log.Error("Failed to write agent token", "path", *agentTokenFile, "error", err)
+		os.Exit(1)
+	}
+
+	cli, err := dockerclient.NewClientWithOpts(dockerclient.FromEnv, dockerclient.WithAPIVersionNegotiation())
+	if err != nil {
+		log.Error("Failed to create Docker client", "error", err)
+		os.Exit(1)
+	}
+	_, err = agent.JoinSwarm(ctx, cli, agent.JoinOptions{
+		JoinToken:     resp.SwarmJoinToken,
+		ManagerAddrs:  resp.ManagerAddrs,
+		ListenAddr:    *listenAddr,
+		AdvertiseAddr: *advertiseAddr,
+	})
+	cli.Close()
+	if err != nil {
+		log.Error("Failed to join swarm", "error", err)
+		os.Exit(1)
+	}
+
+	runWorker(managerAddr, resp.Cluster, resp.AgentToken, agent.DefaultOptions())
+}
+
+// runWorker runs the container agent, reporting to the manager at managerAddr
+// if set, authenticated by its agent token
+func runWorker(managerAddr, clusterName, agentToken string, opts agent.Options) {
 	log.Info("Starting Velo Container Agent...")
 
 	// Create a new container agent
-	containerAgent, err := agent.NewContainerAgent()
+	containerAgent, err := agent.NewContainerAgent(opts)
 	if err != nil {
 		log.Error("Failed to create container agent", "error", err)
 		os.Exit(1)
@@ -117,6 +454,24 @@ func runWorker() {
 		os.Exit(1)
 	}
 
+	if managerAddr == "" {
+		log.Warn("No manager address set, the agent won't report to a manager; use --manager-addr")
+	} else {
+		if !strings.Contains(managerAddr, ":") {
+			managerAddr += ":" + strconv.Itoa(core.Port)
+		}
+		if agentToken == "" {
+			log.Warn("No agent token set, the manager will turn the agent away; use --agent-token or 'velo join'")
+		}
+		c, err := client.NewClient(managerAddr, client.WithToken(agentToken), client.WithDialOptions(tracing.ClientDialOptions()...))
+		if err != nil {
+			log.Error("Failed to connect to manager", "address", managerAddr, "error", err)
+			os.Exit(1)
+		}
+		defer c.Close()
+		containerAgent.ReportTo(c.Conn(), clusterName)
+	}
+
 	// Wait for termination signal
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
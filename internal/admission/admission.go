@@ -0,0 +1,231 @@
+// Package admission asks configured webhooks to allow, deny or change deploys
+// before they're made. Each webhook gets the service definition with the user,
+// project and cluster of the deploy and answers whether it's allowed, optionally
+// with a JSON patch (RFC 6902) that changes the definition, e.g. to inject
+// labels or default resource limits. Webhooks are asked in order; each sees the
+// definition as patched by the ones before it.
+package admission
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/config"
+	"github.com/jasonlovesdoggo/velo/internal/log"
+)
+
+// Operations admission webhooks are asked about
+const (
+	OperationDeploy = "deploy"
+)
+
+// maxResponseSize caps how much of a webhook's response is read
+const maxResponseSize = 1 << 20
+
+// Request is what admission webhooks are sent
+type Request struct {
+	UID       string                   `json:"uid"`
+	Operation string                   `json:"operation"`
+	Cluster   string                   `json:"cluster"`
+	User      string                   `json:"user,omitempty"` // empty when the API is used without authentication
+	Project   string                   `json:"project"`
+	Service   config.ServiceDefinition `json:"service"`
+}
+
+// Response is what admission webhooks answer with
+type Response struct {
+	UID      string          `json:"uid"`
+	Allowed  bool            `json:"allowed"`
+	Reason   string          `json:"reason,omitempty"`   // why the deploy is denied
+	Patch    json.RawMessage `json:"patch,omitempty"`    // JSON patch applied to the service when allowed
+	Warnings []string        `json:"warnings,omitempty"` // returned to the user with the deploy
+}
+
+// DeniedError is returned when an admission webhook denies a deploy, or can't be
+// asked and doesn't fail open
+type DeniedError struct {
+	Hook   string
+	Reason string
+}
+
+func (e *DeniedError) Error() string {
+	return fmt.Sprintf("denied by admission webhook %s: %s", e.Hook, e.Reason)
+}
+
+// Controller asks the configured admission webhooks about deploys
+type Controller struct {
+	hooks  []config.AdmissionHook
+	client *http.Client
+}
+
+// New creates a controller for the admission webhooks, asked in order
+func New(hooks []config.AdmissionHook) *Controller {
+	return &Controller{hooks: hooks, client: &http.Client{}}
+}
+
+// Admit asks every admission webhook about a deploy and returns the service as
+// they patched it, with their warnings. A nil controller allows everything.
+func (c *Controller) Admit(ctx context.Context, req Request) (config.ServiceDefinition, []string, error) {
+	if c == nil || len(c.hooks) == 0 {
+		return req.Service, nil, nil
+	}
+	if req.Operation == "" {
+		req.Operation = OperationDeploy
+	}
+	if req.Project == "" {
+		req.Project = req.Service.Project()
+	}
+
+	var warnings []string
+	for _, hook := range c.hooks {
+		resp, err := c.ask(ctx, hook, req)
+		if err != nil {
+			if hook.FailOpen {
+				log.Warn("Admission webhook failed, allowing the deploy", "hook", hook.Name, "service", req.Service.Name, "error", err)
+				warnings = append(warnings, fmt.Sprintf("admission webhook %s was skipped: %v", hook.Name, err))
+				continue
+			}
+			log.Error("Admission webhook failed, denying the deploy", "hook", hook.Name, "service", req.Service.Name, "error", err)
+			return req.Service, warnings, &DeniedError{Hook: hook.Name, Reason: err.Error()}
+		}
+		warnings = append(warnings, resp.Warnings...)
+		if !resp.Allowed {
+			reason := resp.Reason
+			if reason == "" {
+				reason = "no reason given"
+			}
+			log.Info("Admission webhook denied the deploy", "hook", hook.Name, "service", req.Service.Name, "reason", reason)
+			return req.Service, warnings, &DeniedError{Hook: hook.Name, Reason: reason}
+		}
+		if len(resp.Patch) == 0 || string(resp.Patch) == "null" {
+			continue
+		}
+
+		patched, err := patchService(req.Service, resp.Patch)
+		if err != nil {
+			// A broken patch is the hook's fault, like a broken response
+			if hook.FailOpen {
+				log.Warn("Admission webhook returned an invalid patch, ignoring it", "hook", hook.Name, "service", req.Service.Name, "error", err)
+				warnings = append(warnings, fmt.Sprintf("admission webhook %s returned an invalid patch: %v", hook.Name, err))
+				continue
+			}
+			return req.Service, warnings, &DeniedError{Hook: hook.Name, Reason: fmt.Sprintf("invalid patch: %v", err)}
+		}
+		log.Info("Admission webhook patched the deploy", "hook", hook.Name, "service", req.Service.Name)
+		req.Service = patched
+	}
+	return req.Service, warnings, nil
+}
+
+// ask sends a request to one webhook and decodes its answer
+func (c *Controller) ask(ctx context.Context, hook config.AdmissionHook, req Request) (*Response, error) {
+	uid := make([]byte, 8)
+	if _, err := rand.Read(uid); err != nil {
+		return nil, fmt.Errorf("failed to generate request ID: %w", err)
+	}
+	req.UID = hex.EncodeToString(uid)
+	// Maps are sent as objects, so patches can add to them
+	if req.Service.Labels == nil {
+		req.Service.Labels = make(map[string]string)
+	}
+	if req.Service.Environment == nil {
+		req.Service.Environment = make(map[string]string)
+	}
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	timeout := time.Duration(hook.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = config.DefaultAdmissionTimeout * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "Velo-Admission")
+
+	httpResp, err := c.client.Do(httpReq)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("no response within %s", timeout)
+		}
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(httpResp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status %s", httpResp.Status)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	if resp.UID != "" && resp.UID != req.UID {
+		return nil, fmt.Errorf("response is for request %s, not %s", resp.UID, req.UID)
+	}
+	return &resp, nil
+}
+
+// patchService applies a JSON patch to the JSON form of a service
+func patchService(def config.ServiceDefinition, patch json.RawMessage) (config.ServiceDefinition, error) {
+	var ops []Operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return def, fmt.Errorf("patch is not a list of operations: %w", err)
+	}
+
+	def.Labels = maps.Clone(def.Labels)
+	def.Environment = maps.Clone(def.Environment)
+	if def.Labels == nil {
+		def.Labels = make(map[string]string)
+	}
+	if def.Environment == nil {
+		def.Environment = make(map[string]string)
+	}
+	raw, err := json.Marshal(def)
+	if err != nil {
+		return def, err
+	}
+	var doc any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return def, err
+	}
+
+	doc, err = ApplyPatch(doc, ops)
+	if err != nil {
+		return def, err
+	}
+
+	raw, err = json.Marshal(doc)
+	if err != nil {
+		return def, err
+	}
+	var patched config.ServiceDefinition
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&patched); err != nil {
+		return def, fmt.Errorf("patched service is invalid: %w", err)
+	}
+	if patched.Name != def.Name {
+		return def, errors.New("patches must not rename the service")
+	}
+	return patched, nil
+}
@@ -0,0 +1,151 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jasonlovesdoggo/velo/internal/config"
+)
+
+func TestApplyPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		want    string
+		wantErr string
+	}{
+		{name: "add member", doc: `{"labels":{}}`, patch: `[{"op":"add","path":"/labels/team","value":"payments"}]`, want: `{"labels":{"team":"payments"}}`},
+		{name: "add escaped member", doc: `{"labels":{}}`, patch: `[{"op":"add","path":"/labels/a~1b~0c","value":"x"}]`, want: `{"labels":{"a/b~c":"x"}}`},
+		{name: "insert and append", doc: `{"a":[1,3]}`, patch: `[{"op":"add","path":"/a/1","value":2},{"op":"add","path":"/a/-","value":4}]`, want: `{"a":[1,2,3,4]}`},
+		{name: "remove", doc: `{"a":[1,2],"b":1}`, patch: `[{"op":"remove","path":"/a/0"},{"op":"remove","path":"/b"}]`, want: `{"a":[2]}`},
+		{name: "replace", doc: `{"replicas":8}`, patch: `[{"op":"replace","path":"/replicas","value":3}]`, want: `{"replicas":3}`},
+		{name: "move and copy", doc: `{"a":{"x":1},"b":{}}`, patch: `[{"op":"copy","from":"/a/x","path":"/b/y"},{"op":"move","from":"/a","path":"/c"}]`, want: `{"b":{"y":1},"c":{"x":1}}`},
+		{name: "test passes", doc: `{"image":"nginx"}`, patch: `[{"op":"test","path":"/image","value":"nginx"}]`, want: `{"image":"nginx"}`},
+		{name: "test fails", doc: `{"image":"nginx"}`, patch: `[{"op":"test","path":"/image","value":"redis"}]`, wantErr: "test failed"},
+		{name: "replace missing member", doc: `{}`, patch: `[{"op":"replace","path":"/replicas","value":3}]`, wantErr: `"replicas" does not exist`},
+		{name: "add to missing parent", doc: `{}`, patch: `[{"op":"add","path":"/resources/cpu_limit","value":1}]`, wantErr: `"resources" does not exist`},
+		{name: "index out of range", doc: `{"a":[1]}`, patch: `[{"op":"add","path":"/a/3","value":2}]`, wantErr: "out of range"},
+		{name: "unknown operation", doc: `{}`, patch: `[{"op":"merge","path":"/a"}]`, wantErr: `unknown operation "merge"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc any
+			var ops []Operation
+			if err := json.Unmarshal([]byte(tt.doc), &doc); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.patch), &ops); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ApplyPatch(doc, ops)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyPatch failed: %v", err)
+			}
+			var want any
+			json.Unmarshal([]byte(tt.want), &want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Expected %s, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// hook serves an admission webhook that answers with handle
+func hook(t *testing.T, handle func(req Request) any) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode admission request: %v", err)
+		}
+		json.NewEncoder(w).Encode(handle(req))
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestAdmit(t *testing.T) {
+	var seen Request
+	allow := hook(t, func(req Request) any {
+		seen = req
+		return Response{UID: req.UID, Allowed: true, Warnings: []string{"image is not signed"}}
+	})
+	patch := hook(t, func(req Request) any {
+		return Response{UID: req.UID, Allowed: true, Patch: json.RawMessage(`[
+			{"op":"add","path":"/labels/team","value":"payments"},
+			{"op":"replace","path":"/resources/memory_limit","value":268435456}]`)}
+	})
+	deny := hook(t, func(req Request) any {
+		return Response{UID: req.UID, Allowed: false, Reason: "images must come from registry.example.com"}
+	})
+	broken := hook(t, func(req Request) any { return "not a response" })
+	rename := hook(t, func(req Request) any {
+		return Response{Allowed: true, Patch: json.RawMessage(`[{"op":"replace","path":"/name","value":"other"}]`)}
+	})
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(slow.Close)
+	t.Cleanup(func() { close(release) })
+
+	tests := []struct {
+		name         string
+		hooks        []config.AdmissionHook
+		wantDenied   string
+		wantLabels   map[string]string
+		wantWarnings int
+	}{
+		{name: "no hooks"},
+		{name: "allowed", hooks: []config.AdmissionHook{{Name: "allow", URL: allow}}, wantWarnings: 1},
+		{name: "patched", hooks: []config.AdmissionHook{{Name: "patch", URL: patch}, {Name: "allow", URL: allow}},
+			wantLabels: map[string]string{"team": "payments"}, wantWarnings: 1},
+		{name: "denied", hooks: []config.AdmissionHook{{Name: "allow", URL: allow}, {Name: "registries", URL: deny}},
+			wantDenied: "denied by admission webhook registries: images must come from registry.example.com", wantWarnings: 1},
+		{name: "broken fails closed", hooks: []config.AdmissionHook{{Name: "broken", URL: broken}}, wantDenied: "invalid response"},
+		{name: "broken fails open", hooks: []config.AdmissionHook{{Name: "broken", URL: broken, FailOpen: true}}, wantWarnings: 1},
+		{name: "timeout", hooks: []config.AdmissionHook{{Name: "slow", URL: slow.URL, Timeout: 1}}, wantDenied: "no response within 1s"},
+		{name: "rename", hooks: []config.AdmissionHook{{Name: "rename", URL: rename}}, wantDenied: "must not rename"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := config.ServiceDefinition{Name: "web", Image: "nginx:1", Replicas: 2, Resources: config.ResourceConfig{MemoryLimit: 1 << 30}}
+			got, warnings, err := New(tt.hooks).Admit(context.Background(), Request{Cluster: "default", User: "alice", Service: def})
+
+			var denied *DeniedError
+			if tt.wantDenied != "" {
+				if !errors.As(err, &denied) || !strings.Contains(err.Error(), tt.wantDenied) {
+					t.Fatalf("Expected denial %q, got %v", tt.wantDenied, err)
+				}
+			} else if err != nil {
+				t.Fatalf("Admit failed: %v", err)
+			}
+			if len(warnings) != tt.wantWarnings {
+				t.Errorf("Expected %d warnings, got %v", tt.wantWarnings, warnings)
+			}
+			if tt.wantLabels != nil {
+				if !reflect.DeepEqual(got.Labels, tt.wantLabels) || got.Resources.MemoryLimit != 268435456 || got.Image != "nginx:1" {
+					t.Errorf("Expected the patched service, got %+v", got)
+				}
+			}
+		})
+	}
+
+	if seen.User != "alice" || seen.Project != "web" || seen.Operation != OperationDeploy || seen.Service.Replicas != 2 || seen.UID == "" {
+		t.Errorf("Expected the deploy to be described to the hook, got %+v", seen)
+	}
+}
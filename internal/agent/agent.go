@@ -4,21 +4,60 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
+	"github.com/jasonlovesdoggo/velo/internal/config"
 	"github.com/jasonlovesdoggo/velo/internal/log"
 )
 
-// NewContainerAgent creates a new ContainerAgent
-func NewContainerAgent() (*ContainerAgent, error) {
+// NewContainerAgent creates a new ContainerAgent for the local Docker host
+func NewContainerAgent(opts Options) (*ContainerAgent, error) {
 	// Create Docker client
 	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Docker client: %w", err)
 	}
 
+	return NewContainerAgentWithOptions(cli, opts)
+}
+
+// NewContainerAgentWithClient creates a ContainerAgent with the default options that uses an existing Docker client
+func NewContainerAgentWithClient(cli *client.Client) (*ContainerAgent, error) {
+	return NewContainerAgentWithOptions(cli, DefaultOptions())
+}
+
+// NewContainerAgentWithOptions creates a ContainerAgent that uses an existing Docker client.
+// Unset options take their default.
+func NewContainerAgentWithOptions(cli *client.Client, opts Options) (*ContainerAgent, error) {
+	defaults := DefaultOptions()
+	if opts.CollectInterval <= 0 {
+		opts.CollectInterval = defaults.CollectInterval
+	}
+	if opts.HealthInterval <= 0 {
+		opts.HealthInterval = defaults.HealthInterval
+	}
+	if opts.DefaultPolicy == "" {
+		opts.DefaultPolicy = defaults.DefaultPolicy
+	}
+	if !config.ValidHealthPolicy(opts.DefaultPolicy) {
+		return nil, fmt.Errorf("invalid health policy %q (expected restart, report or ignore)", opts.DefaultPolicy)
+	}
+	if opts.CrashLoopRestarts <= 0 {
+		opts.CrashLoopRestarts = defaults.CrashLoopRestarts
+	}
+	if opts.CrashLoopWindow <= 0 {
+		opts.CrashLoopWindow = defaults.CrashLoopWindow
+	}
+	if opts.MetricsInterval <= 0 {
+		opts.MetricsInterval = defaults.MetricsInterval
+	}
+	if opts.MetricsBuffer <= 0 {
+		opts.MetricsBuffer = defaults.MetricsBuffer
+	}
+
 	// Get hostname
 	hostname, err := os.Hostname()
 	if err != nil {
@@ -29,10 +68,15 @@ func NewContainerAgent() (*ContainerAgent, error) {
 
 	agent := &ContainerAgent{
 		client:     cli,
+		opts:       opts,
 		hostname:   hostname,
 		ctx:        ctx,
 		cancel:     cancel,
 		containers: []ContainerInfo{},
+		crashes:    make(map[string][]time.Time),
+		observed:   make(map[string]observation),
+		metrics:    newSampleRing(opts.MetricsBuffer),
+		cpu:        make(map[string]cpuUsage),
 	}
 
 	// Determine if this node is a manager
@@ -45,7 +89,10 @@ func NewContainerAgent() (*ContainerAgent, error) {
 		agent.nodeID = info.Swarm.NodeID
 		agent.isManager = info.Swarm.ControlAvailable
 	} else {
-		return nil, fmt.Errorf("node is not part of a swarm")
+		// Standalone hosts are identified by their Docker daemon ID
+		agent.nodeID = info.ID
+		agent.standalone = true
+		log.Info("Docker host is not part of a swarm, running in standalone mode", "daemonID", info.ID)
 	}
 
 	return agent, nil
@@ -59,7 +106,7 @@ func (a *ContainerAgent) Start() error {
 	}
 
 	// Start periodic container collection
-	a.collectTicker = time.NewTicker(30 * time.Second)
+	a.collectTicker = time.NewTicker(a.opts.CollectInterval)
 	go func() {
 		for {
 			select {
@@ -74,14 +121,25 @@ func (a *ContainerAgent) Start() error {
 	}()
 
 	// Start periodic health checks
-	a.healthTicker = time.NewTicker(1 * time.Minute)
+	a.healthTicker = time.NewTicker(a.opts.HealthInterval)
 	go func() {
 		for {
 			select {
 			case <-a.healthTicker.C:
-				if err := a.checkContainerHealth(); err != nil {
-					log.Error("Error checking container health", "error", err)
-				}
+				a.checkContainerHealth()
+			case <-a.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Start periodic metrics sampling
+	a.metricsTicker = time.NewTicker(a.opts.MetricsInterval)
+	go func() {
+		for {
+			select {
+			case <-a.metricsTicker.C:
+				a.sampleMetrics()
 			case <-a.ctx.Done():
 				return
 			}
@@ -89,7 +147,9 @@ func (a *ContainerAgent) Start() error {
 	}()
 
 	log.Info("Container agent started",
-		"node", a.hostname, "nodeID", a.nodeID, "isManager", a.isManager)
+		"node", a.hostname, "nodeID", a.nodeID, "isManager", a.isManager, "standalone", a.standalone,
+		"collectInterval", a.opts.CollectInterval, "healthInterval", a.opts.HealthInterval, "healthPolicy", a.opts.DefaultPolicy,
+		"metricsInterval", a.opts.MetricsInterval)
 	return nil
 }
 
@@ -101,39 +161,65 @@ func (a *ContainerAgent) Stop() {
 	if a.healthTicker != nil {
 		a.healthTicker.Stop()
 	}
+	if a.metricsTicker != nil {
+		a.metricsTicker.Stop()
+	}
 	a.cancel()
 	log.Info("Container agent stopped", "node", a.hostname)
 }
 
-// collectContainers collects information about running containers
+// collectContainers refreshes the inventory of the containers on the node and
+// records the crashes that happened since the last inventory
 func (a *ContainerAgent) collectContainers() error {
-	// For now, just log that we're collecting containers
-	// In a real implementation, this would use the Docker API to list containers
-	log.Info("Collecting container information", "node", a.hostname)
+	list, err := a.client.ContainerList(a.ctx, container.ListOptions{All: true})
+	if err != nil {
+		return fmt.Errorf("failed to list containers: %w", err)
+	}
 
-	// Simulate container collection with a placeholder
-	a.containersMu.Lock()
-	a.containers = []ContainerInfo{
-		{
-			ID:      "placeholder",
-			Name:    "placeholder",
-			Image:   "placeholder",
-			Status:  "running",
-			Running: true,
-			Health:  "healthy",
-		},
+	containers := make([]ContainerInfo, 0, len(list))
+	for _, c := range list {
+		inspect, err := a.client.ContainerInspect(a.ctx, c.ID)
+		if err != nil {
+			continue // Removed since it was listed
+		}
+		containers = append(containers, containerInfo(inspect))
 	}
-	a.containersMu.Unlock()
 
+	a.observe(containers, time.Now())
+	a.forgetContainers(containers)
+
+	a.containersMu.Lock()
+	a.containers = containers
+	a.containersMu.Unlock()
 	return nil
 }
 
-// checkContainerHealth checks the health of all containers
-func (a *ContainerAgent) checkContainerHealth() error {
-	// For now, just log that we're checking container health
-	// In a real implementation, this would use the Docker API to check container health
-	log.Info("Checking container health", "node", a.hostname)
-	return nil
+// containerInfo converts an inspected container into its inventory entry
+func containerInfo(inspect container.InspectResponse) ContainerInfo {
+	labels := inspect.Config.Labels
+	info := ContainerInfo{
+		ID:           inspect.ID,
+		Name:         strings.TrimPrefix(inspect.Name, "/"),
+		Image:        inspect.Config.Image,
+		Service:      labels["com.docker.swarm.service.name"],
+		TaskID:       labels["com.docker.swarm.task.id"],
+		RestartCount: inspect.RestartCount,
+		Policy:       labels[config.HealthPolicyLabel],
+	}
+	if info.Service == "" {
+		info.Service = labels["velo.service.name"] // standalone backend
+	}
+	if state := inspect.State; state != nil {
+		info.Status = state.Status
+		info.Running = state.Running
+		info.ExitCode = state.ExitCode
+		info.OOMKilled = state.OOMKilled
+		info.FinishedAt, _ = time.Parse(time.RFC3339Nano, state.FinishedAt)
+		if state.Health != nil {
+			info.Health = state.Health.Status
+		}
+	}
+	return info
 }
 
 // GetContainers returns information about all containers
@@ -156,6 +242,7 @@ func (a *ContainerAgent) GetNodeInfo() map[string]interface{} {
 		"id":         a.nodeID,
 		"hostname":   a.hostname,
 		"is_manager": a.isManager,
+		"standalone": a.standalone,
 		"containers": len(a.containers),
 		"timestamp":  time.Now(),
 	}
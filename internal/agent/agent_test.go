@@ -0,0 +1,209 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/jasonlovesdoggo/velo/internal/config"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/dockertest"
+)
+
+func newTestAgent(t *testing.T, opts dockertest.Options) (*dockertest.Server, *ContainerAgent) {
+	t.Helper()
+
+	srv := dockertest.NewServer(opts)
+	t.Cleanup(srv.Close)
+	cli, err := srv.Client()
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	t.Cleanup(func() { cli.Close() })
+
+	a, err := NewContainerAgentWithClient(cli)
+	if err != nil {
+		t.Fatalf("NewContainerAgentWithClient failed: %v", err)
+	}
+	return srv, a
+}
+
+func TestNewContainerAgent(t *testing.T) {
+	tests := []struct {
+		name           string
+		opts           dockertest.Options
+		wantStandalone bool
+		wantManager    bool
+	}{
+		{name: "swarm manager", opts: dockertest.Options{Swarm: true}, wantManager: true},
+		{name: "standalone host", opts: dockertest.Options{}, wantStandalone: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, a := newTestAgent(t, tt.opts)
+			if a.standalone != tt.wantStandalone || a.isManager != tt.wantManager || a.nodeID == "" {
+				t.Errorf("Expected standalone=%v manager=%v, got standalone=%v manager=%v nodeID=%q",
+					tt.wantStandalone, tt.wantManager, a.standalone, a.isManager, a.nodeID)
+			}
+		})
+	}
+}
+
+func TestContainerControl(t *testing.T) {
+	srv, a := newTestAgent(t, dockertest.Options{})
+	srv.AddImage("nginx")
+
+	cli, _ := srv.Client()
+	defer cli.Close()
+	created, err := cli.ContainerCreate(context.Background(), &container.Config{Image: "nginx"}, nil, nil, nil, "web")
+	if err != nil {
+		t.Fatalf("ContainerCreate failed: %v", err)
+	}
+
+	state := func() *container.State {
+		inspect, err := cli.ContainerInspect(context.Background(), created.ID)
+		if err != nil {
+			t.Fatalf("ContainerInspect failed: %v", err)
+		}
+		return inspect.State
+	}
+
+	if err := a.StartContainer(created.ID); err != nil || !state().Running {
+		t.Fatalf("Expected container to start, got %v", err)
+	}
+	if err := a.StopContainer(created.ID); err != nil || state().Running {
+		t.Fatalf("Expected container to stop, got %v", err)
+	}
+	if err := a.RestartContainer(created.ID); err != nil || !state().Running {
+		t.Fatalf("Expected container to restart, got %v", err)
+	}
+	if err := a.StartContainer("missing"); err == nil {
+		t.Error("Expected starting a missing container to fail")
+	}
+}
+
+// runContainer creates and starts a container with a healthcheck and returns its ID
+func runContainer(t *testing.T, srv *dockertest.Server, name string, labels map[string]string, restart container.RestartPolicyMode) string {
+	t.Helper()
+
+	cli, err := srv.Client()
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer cli.Close()
+	ctx := context.Background()
+	created, err := cli.ContainerCreate(ctx,
+		&container.Config{Image: "nginx", Labels: labels, Healthcheck: &container.HealthConfig{Test: []string{"CMD", "true"}}},
+		&container.HostConfig{RestartPolicy: container.RestartPolicy{Name: restart}}, nil, nil, name)
+	if err != nil {
+		t.Fatalf("ContainerCreate failed: %v", err)
+	}
+	if err := cli.ContainerStart(ctx, created.ID, container.StartOptions{}); err != nil {
+		t.Fatalf("ContainerStart failed: %v", err)
+	}
+	return created.ID
+}
+
+func TestCollectContainers(t *testing.T) {
+	srv, a := newTestAgent(t, dockertest.Options{})
+	srv.AddImage("nginx")
+	runContainer(t, srv, "web-1", map[string]string{"velo.service.name": "web", config.HealthPolicyLabel: config.HealthPolicyRestart}, container.RestartPolicyAlways)
+	runContainer(t, srv, "worker", nil, container.RestartPolicyDisabled)
+	srv.CrashContainer("web-1", 1, false)
+	srv.CrashContainer("worker", 137, true)
+
+	if err := a.collectContainers(); err != nil {
+		t.Fatalf("collectContainers failed: %v", err)
+	}
+	containers := a.GetContainers()
+	if len(containers) != 2 {
+		t.Fatalf("Expected 2 containers, got %d", len(containers))
+	}
+	web, worker := containers[0], containers[1]
+	if web.Name != "web-1" || web.Service != "web" || web.Policy != config.HealthPolicyRestart ||
+		!web.Running || web.Health != "healthy" || web.RestartCount != 1 {
+		t.Errorf("Unexpected restarted container %+v", web)
+	}
+	if worker.Running || worker.Status != "exited" || worker.ExitCode != 137 || !worker.OOMKilled || worker.FinishedAt.IsZero() {
+		t.Errorf("Unexpected OOM killed container %+v", worker)
+	}
+}
+
+func TestHealthPolicies(t *testing.T) {
+	tests := []struct {
+		policy        string
+		wantProblem   bool
+		wantRestarted bool
+	}{
+		{config.HealthPolicyRestart, true, true},
+		{config.HealthPolicyReport, true, false},
+		{config.HealthPolicyIgnore, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			srv, a := newTestAgent(t, dockertest.Options{})
+			srv.AddImage("nginx")
+			runContainer(t, srv, "api-1", map[string]string{config.HealthPolicyLabel: tt.policy}, container.RestartPolicyDisabled)
+			srv.SetContainerHealth("api-1", container.Unhealthy)
+
+			if err := a.collectContainers(); err != nil {
+				t.Fatalf("collectContainers failed: %v", err)
+			}
+			a.checkContainerHealth()
+
+			problems := a.Problems()
+			if (len(problems) == 1) != tt.wantProblem {
+				t.Fatalf("Expected problem %v, got %v", tt.wantProblem, problems)
+			}
+			restarted := srv.Containers()[0].State.Health.Status == container.Healthy
+			if restarted != tt.wantRestarted {
+				t.Errorf("Expected restarted %v, got %v", tt.wantRestarted, restarted)
+			}
+			if tt.wantProblem && (problems[0].Kind != ProblemUnhealthy || (problems[0].Action == "restarted") != tt.wantRestarted) {
+				t.Errorf("Unexpected problem %+v", problems[0])
+			}
+		})
+	}
+}
+
+func TestCrashLoopDetection(t *testing.T) {
+	srv, a := newTestAgent(t, dockertest.Options{})
+	srv.AddImage("nginx")
+	a.opts.DefaultPolicy = config.HealthPolicyRestart
+	runContainer(t, srv, "queue", nil, container.RestartPolicyAlways)
+
+	if err := a.collectContainers(); err != nil {
+		t.Fatalf("collectContainers failed: %v", err)
+	}
+	for i := 0; i < a.opts.CrashLoopRestarts; i++ {
+		srv.CrashContainer("queue", 1, false)
+		if err := a.collectContainers(); err != nil {
+			t.Fatalf("collectContainers failed: %v", err)
+		}
+	}
+	srv.SetContainerHealth("queue", container.Unhealthy)
+	if err := a.collectContainers(); err != nil {
+		t.Fatalf("collectContainers failed: %v", err)
+	}
+	a.checkContainerHealth()
+
+	// Crash-looping containers are reported, not restarted
+	problems := a.Problems()
+	if len(problems) != 1 || problems[0].Kind != ProblemCrashLoop || problems[0].Action != "reported" ||
+		!strings.Contains(problems[0].Message, "crashed 3 times") {
+		t.Fatalf("Expected a reported crash loop, got %v", problems)
+	}
+	if srv.Containers()[0].State.Health.Status != container.Unhealthy {
+		t.Error("Expected the crash-looping container not to be restarted")
+	}
+
+	// Crashes age out of the window
+	a.opts.CrashLoopWindow = time.Nanosecond
+	a.checkContainerHealth()
+	if problems := a.Problems(); len(problems) != 1 || problems[0].Kind != ProblemUnhealthy {
+		t.Errorf("Expected only the unhealthy container once the crashes are old, got %v", problems)
+	}
+}
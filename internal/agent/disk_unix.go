@@ -0,0 +1,17 @@
+//go:build !windows
+
+package agent
+
+import "syscall"
+
+// diskSize returns the size of the filesystem holding path, 0 if unknown
+func diskSize(path string) int64 {
+	if path == "" {
+		path = "/"
+	}
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0
+	}
+	return int64(st.Blocks) * int64(st.Bsize)
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package agent
+
+// diskSize isn't reported on Windows
+func diskSize(path string) int64 {
+	return 0
+}
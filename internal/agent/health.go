@@ -0,0 +1,195 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/config"
+	"github.com/jasonlovesdoggo/velo/internal/log"
+)
+
+// Kinds of health problems
+const (
+	ProblemUnhealthy = "unhealthy"
+	ProblemCrashLoop = "crash-loop"
+)
+
+// String describes the problem and what the agent did about it
+func (p Problem) String() string {
+	return fmt.Sprintf("%s (%s)", p.Message, p.Action)
+}
+
+// Problems returns the health problems found by the last health check
+func (a *ContainerAgent) Problems() []Problem {
+	a.healthMu.Lock()
+	defer a.healthMu.Unlock()
+	problems := make([]Problem, len(a.problems))
+	copy(problems, a.problems)
+	return problems
+}
+
+// observe records the crashes that happened between two inventories: restarts
+// of a container, counted by its restart count, and containers that exited with
+// a failure, which swarm replaces with new containers instead of restarting them
+func (a *ContainerAgent) observe(containers []ContainerInfo, now time.Time) {
+	a.healthMu.Lock()
+	defer a.healthMu.Unlock()
+
+	seen := make(map[string]observation, len(containers))
+	for _, c := range containers {
+		previous, known := a.observed[c.ID]
+		key := serviceKey(c)
+		if known && c.RestartCount > previous.restartCount {
+			for i := previous.restartCount; i < c.RestartCount; i++ {
+				a.crashes[key] = append(a.crashes[key], now)
+			}
+		}
+		failed := c.Status == "exited" && (c.ExitCode != 0 || c.OOMKilled)
+		if failed && !c.FinishedAt.IsZero() && now.Sub(c.FinishedAt) <= a.opts.CrashLoopWindow &&
+			(!known || c.FinishedAt.After(previous.finishedAt)) {
+			a.crashes[key] = append(a.crashes[key], c.FinishedAt)
+		}
+		seen[c.ID] = observation{restartCount: c.RestartCount, finishedAt: c.FinishedAt}
+	}
+	a.observed = seen
+}
+
+// checkContainerHealth looks for unhealthy and crash-looping containers and
+// applies the health policy of their service: restart restarts unhealthy
+// containers, report passes problems on to the manager and ignore skips them.
+// Crash-looping containers are reported rather than restarted, as restarting
+// them only feeds the loop; the same goes for containers the agent already
+// restarted too often.
+func (a *ContainerAgent) checkContainerHealth() {
+	containers := a.GetContainers()
+	now := time.Now()
+
+	a.healthMu.Lock()
+	looping := a.crashLoops(now)
+	var problems []Problem
+	var restart []ContainerInfo
+	reported := make(map[string]bool)
+	for _, c := range containers {
+		key := serviceKey(c)
+		if a.policy(c) == config.HealthPolicyIgnore {
+			continue
+		}
+		if crashes, ok := looping[key]; ok {
+			if !reported[key] {
+				reported[key] = true
+				problems = append(problems, Problem{
+					Container: c.Name,
+					Service:   c.Service,
+					Kind:      ProblemCrashLoop,
+					Message:   fmt.Sprintf("%s crashed %d times in %s%s", key, crashes, a.opts.CrashLoopWindow, lastExit(containers, key)),
+					Action:    "reported",
+				})
+			}
+			continue
+		}
+		if c.Running && c.Health == "unhealthy" {
+			problem := Problem{
+				Container: c.Name,
+				Service:   c.Service,
+				Kind:      ProblemUnhealthy,
+				Message:   fmt.Sprintf("container %s is unhealthy", c.Name),
+				Action:    "reported",
+			}
+			if a.policy(c) == config.HealthPolicyRestart {
+				restart = append(restart, c)
+				problem.Action = "restarted"
+			}
+			problems = append(problems, problem)
+		}
+	}
+	a.healthMu.Unlock()
+
+	for _, c := range restart {
+		log.Info("Restarting unhealthy container", "container", c.Name, "service", c.Service)
+		err := a.RestartContainer(c.ID)
+
+		a.healthMu.Lock()
+		if err != nil {
+			for i := range problems {
+				if problems[i].Container == c.Name && problems[i].Kind == ProblemUnhealthy {
+					problems[i].Action = "restart failed: " + err.Error()
+				}
+			}
+		} else {
+			// Restarts count as crashes, so a container that keeps turning
+			// unhealthy ends up reported as crash-looping
+			a.crashes[serviceKey(c)] = append(a.crashes[serviceKey(c)], now)
+		}
+		a.healthMu.Unlock()
+	}
+
+	for _, p := range problems {
+		log.Warn("Container health problem", "container", p.Container, "service", p.Service, "kind", p.Kind, "message", p.Message, "action", p.Action)
+	}
+
+	a.healthMu.Lock()
+	a.problems = problems
+	a.healthMu.Unlock()
+}
+
+// crashLoops forgets crashes outside the window and returns the number of crashes
+// of every crash-looping service. Callers must hold a.healthMu.
+func (a *ContainerAgent) crashLoops(now time.Time) map[string]int {
+	looping := make(map[string]int)
+	for key, times := range a.crashes {
+		recent := times[:0]
+		for _, t := range times {
+			if now.Sub(t) <= a.opts.CrashLoopWindow {
+				recent = append(recent, t)
+			}
+		}
+		if len(recent) == 0 {
+			delete(a.crashes, key)
+			continue
+		}
+		a.crashes[key] = recent
+		if len(recent) >= a.opts.CrashLoopRestarts {
+			looping[key] = len(recent)
+		}
+	}
+	return looping
+}
+
+// policy returns the health policy of a container's service
+func (a *ContainerAgent) policy(c ContainerInfo) string {
+	if c.Policy != "" && config.ValidHealthPolicy(c.Policy) {
+		return c.Policy
+	}
+	return a.opts.DefaultPolicy
+}
+
+// serviceKey groups the containers of a service; containers outside services stand alone
+func serviceKey(c ContainerInfo) string {
+	if c.Service != "" {
+		return c.Service
+	}
+	return c.Name
+}
+
+// lastExit describes how the most recently exited container of a service exited
+func lastExit(containers []ContainerInfo, key string) string {
+	var last *ContainerInfo
+	for i, c := range containers {
+		if serviceKey(c) != key || c.FinishedAt.IsZero() {
+			continue
+		}
+		if last == nil || c.FinishedAt.After(last.FinishedAt) {
+			last = &containers[i]
+		}
+	}
+	switch {
+	case last == nil:
+		return ""
+	case last.OOMKilled:
+		return ", last exit was an OOM kill"
+	case last.ExitCode != 0:
+		return fmt.Sprintf(", last exit code %d", last.ExitCode)
+	default:
+		return ""
+	}
+}
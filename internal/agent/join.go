@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+	"github.com/jasonlovesdoggo/velo/internal/log"
+)
+
+// ErrAlreadyInSwarm is returned when joining a Docker host that is already part of a swarm
+var ErrAlreadyInSwarm = errors.New("this Docker host is already part of a swarm")
+
+// JoinOptions configures how the local Docker host joins a swarm
+type JoinOptions struct {
+	JoinToken     string   // swarm join token
+	ManagerAddrs  []string // addresses of the swarm managers, host:port
+	ListenAddr    string   // defaults to 0.0.0.0:2377
+	AdvertiseAddr string   // empty lets Docker pick the address
+}
+
+// JoinSwarm makes the local Docker host join a swarm and returns its node ID
+func JoinSwarm(ctx context.Context, cli *client.Client, opts JoinOptions) (string, error) {
+	if opts.JoinToken == "" || len(opts.ManagerAddrs) == 0 {
+		return "", errors.New("a join token and at least one manager address are required")
+	}
+	if opts.ListenAddr == "" {
+		opts.ListenAddr = "0.0.0.0:2377"
+	}
+
+	info, err := cli.Info(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get Docker info: %w", err)
+	}
+	if info.Swarm.LocalNodeState != "" && info.Swarm.LocalNodeState != swarm.LocalNodeStateInactive {
+		return info.Swarm.NodeID, fmt.Errorf("%w (node %s); run 'docker swarm leave' first", ErrAlreadyInSwarm, info.Swarm.NodeID)
+	}
+
+	log.Info("Joining swarm", "managers", opts.ManagerAddrs, "advertiseAddr", opts.AdvertiseAddr)
+	err = cli.SwarmJoin(ctx, swarm.JoinRequest{
+		ListenAddr:    opts.ListenAddr,
+		AdvertiseAddr: opts.AdvertiseAddr,
+		RemoteAddrs:   opts.ManagerAddrs,
+		JoinToken:     opts.JoinToken,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to join swarm: %w", err)
+	}
+
+	info, err = cli.Info(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get Docker info: %w", err)
+	}
+	log.Info("Joined swarm", "nodeID", info.Swarm.NodeID)
+	return info.Swarm.NodeID, nil
+}
@@ -0,0 +1,223 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/jasonlovesdoggo/velo/internal/log"
+)
+
+// maxMetricsPerHeartbeat caps how many samples a heartbeat carries; the rest
+// go out with the following heartbeats
+const maxMetricsPerHeartbeat = 500
+
+// Sample is the resource usage of a container at one point in time. Network
+// and block I/O are cumulative byte counters since the container started.
+type Sample struct {
+	Seq         uint64 // position in the agent's buffer, to acknowledge shipped samples
+	Time        time.Time
+	ContainerID string
+	Container   string
+	Service     string
+	TaskID      string
+	CPUPercent  float64
+	MemoryUsage uint64 // without the page cache, like docker stats
+	MemoryLimit uint64
+	NetRx       uint64
+	NetTx       uint64
+	BlockRead   uint64
+	BlockWrite  uint64
+}
+
+// cpuUsage is the last CPU reading of a container, to compute CPU % from one-shot stats
+type cpuUsage struct {
+	total  uint64
+	system uint64
+}
+
+// sampleRing is a fixed-size buffer of samples waiting to be shipped to the
+// manager. When it's full the oldest samples are dropped.
+type sampleRing struct {
+	samples []Sample
+	start   int // index of the oldest sample
+	count   int
+	nextSeq uint64
+	dropped uint64
+}
+
+func newSampleRing(size int) *sampleRing {
+	return &sampleRing{samples: make([]Sample, size), nextSeq: 1}
+}
+
+// push adds a sample, overwriting the oldest one if the buffer is full
+func (r *sampleRing) push(s Sample) {
+	s.Seq = r.nextSeq
+	r.nextSeq++
+	if r.count == len(r.samples) {
+		r.samples[r.start] = s
+		r.start = (r.start + 1) % len(r.samples)
+		r.dropped++
+		return
+	}
+	r.samples[(r.start+r.count)%len(r.samples)] = s
+	r.count++
+}
+
+// oldest returns up to max samples, oldest first, without removing them
+func (r *sampleRing) oldest(max int) []Sample {
+	n := min(max, r.count)
+	samples := make([]Sample, n)
+	for i := range samples {
+		samples[i] = r.samples[(r.start+i)%len(r.samples)]
+	}
+	return samples
+}
+
+// ack removes the samples up to seq, once the manager has received them
+func (r *sampleRing) ack(seq uint64) {
+	for r.count > 0 && r.samples[r.start].Seq <= seq {
+		r.start = (r.start + 1) % len(r.samples)
+		r.count--
+	}
+}
+
+// PendingMetrics returns up to max samples that haven't been shipped to the manager yet, oldest first
+func (a *ContainerAgent) PendingMetrics(max int) []Sample {
+	a.metricsMu.Lock()
+	defer a.metricsMu.Unlock()
+	return a.metrics.oldest(max)
+}
+
+// AckMetrics drops the samples up to seq from the buffer once the manager has them
+func (a *ContainerAgent) AckMetrics(seq uint64) {
+	a.metricsMu.Lock()
+	defer a.metricsMu.Unlock()
+	a.metrics.ack(seq)
+}
+
+// sampleMetrics samples the resource usage of every running Velo task
+func (a *ContainerAgent) sampleMetrics() {
+	var samples []Sample
+	for _, c := range a.GetContainers() {
+		if !c.Running || c.Service == "" {
+			continue
+		}
+		sample, err := a.sampleContainer(c)
+		if err != nil {
+			log.Warn("Failed to sample container stats", "container", c.Name, "error", err)
+			continue
+		}
+		samples = append(samples, sample)
+	}
+
+	a.metricsMu.Lock()
+	defer a.metricsMu.Unlock()
+	dropped := a.metrics.dropped
+	for _, s := range samples {
+		a.metrics.push(s)
+	}
+	if a.metrics.dropped > dropped {
+		log.Warn("Metrics buffer is full, dropped the oldest samples", "dropped", a.metrics.dropped-dropped)
+	}
+}
+
+// sampleContainer reads a container's stats once and converts them into a sample
+func (a *ContainerAgent) sampleContainer(c ContainerInfo) (Sample, error) {
+	resp, err := a.client.ContainerStatsOneShot(a.ctx, c.ID)
+	if err != nil {
+		return Sample{}, fmt.Errorf("failed to get stats: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var stats container.StatsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return Sample{}, fmt.Errorf("failed to decode stats: %w", err)
+	}
+
+	sample := Sample{
+		Time:        stats.Read,
+		ContainerID: c.ID,
+		Container:   c.Name,
+		Service:     c.Service,
+		TaskID:      c.TaskID,
+		MemoryUsage: memoryUsage(stats.MemoryStats),
+		MemoryLimit: stats.MemoryStats.Limit,
+	}
+	if sample.Time.IsZero() {
+		sample.Time = time.Now()
+	}
+	for _, n := range stats.Networks {
+		sample.NetRx += n.RxBytes
+		sample.NetTx += n.TxBytes
+	}
+	for _, entry := range stats.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			sample.BlockRead += entry.Value
+		case "write":
+			sample.BlockWrite += entry.Value
+		}
+	}
+
+	// One-shot stats don't include the previous reading, so CPU % is computed
+	// against the last sample of the container
+	previous := cpuUsage{total: stats.PreCPUStats.CPUUsage.TotalUsage, system: stats.PreCPUStats.SystemUsage}
+	a.metricsMu.Lock()
+	if last, ok := a.cpu[c.ID]; ok {
+		previous = last
+	}
+	a.cpu[c.ID] = cpuUsage{total: stats.CPUStats.CPUUsage.TotalUsage, system: stats.CPUStats.SystemUsage}
+	a.metricsMu.Unlock()
+	sample.CPUPercent = cpuPercent(stats.CPUStats, previous)
+	return sample, nil
+}
+
+// forgetContainers drops the CPU readings of containers that are gone
+func (a *ContainerAgent) forgetContainers(containers []ContainerInfo) {
+	running := make(map[string]bool, len(containers))
+	for _, c := range containers {
+		running[c.ID] = c.Running
+	}
+	a.metricsMu.Lock()
+	defer a.metricsMu.Unlock()
+	for id := range a.cpu {
+		if !running[id] {
+			delete(a.cpu, id)
+		}
+	}
+}
+
+// cpuPercent computes CPU usage like docker stats: the container's share of the
+// host's CPU time since the previous reading, where 100% is one full core
+func cpuPercent(stats container.CPUStats, previous cpuUsage) float64 {
+	if stats.CPUUsage.TotalUsage < previous.total || stats.SystemUsage <= previous.system {
+		return 0
+	}
+	cpuDelta := float64(stats.CPUUsage.TotalUsage - previous.total)
+	systemDelta := float64(stats.SystemUsage - previous.system)
+	cpus := float64(stats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(stats.CPUUsage.PercpuUsage))
+	}
+	if cpus == 0 {
+		cpus = 1
+	}
+	return cpuDelta / systemDelta * cpus * 100
+}
+
+// memoryUsage returns the memory used by a container without the page cache,
+// which the kernel reclaims under pressure. cgroup v1 reports it as
+// total_inactive_file, cgroup v2 as inactive_file.
+func memoryUsage(stats container.MemoryStats) uint64 {
+	cache, ok := stats.Stats["total_inactive_file"]
+	if !ok {
+		cache = stats.Stats["inactive_file"]
+	}
+	if cache < stats.Usage {
+		return stats.Usage - cache
+	}
+	return stats.Usage
+}
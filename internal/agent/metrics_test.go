@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"math"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/dockertest"
+)
+
+func TestSampleRing(t *testing.T) {
+	r := newSampleRing(3)
+	for _, name := range []string{"a", "b", "c", "d"} {
+		r.push(Sample{Container: name})
+	}
+	if r.dropped != 1 {
+		t.Errorf("Expected 1 dropped sample, got %d", r.dropped)
+	}
+	samples := r.oldest(10)
+	if len(samples) != 3 || samples[0].Container != "b" || samples[2].Container != "d" {
+		t.Fatalf("Expected the 3 newest samples, got %v", samples)
+	}
+
+	// Samples pushed while a heartbeat is in flight survive its acknowledgement
+	sent := r.oldest(2)
+	r.push(Sample{Container: "e"})
+	r.ack(sent[len(sent)-1].Seq)
+	samples = r.oldest(10)
+	if len(samples) != 2 || samples[0].Container != "d" || samples[1].Container != "e" {
+		t.Errorf("Expected d and e to be pending, got %v", samples)
+	}
+	r.ack(0)
+	if len(r.oldest(10)) != 2 {
+		t.Error("Expected acknowledging nothing to keep the samples")
+	}
+}
+
+func TestSampleMetrics(t *testing.T) {
+	srv, a := newTestAgent(t, dockertest.Options{})
+	srv.AddImage("nginx")
+	runContainer(t, srv, "web-1", map[string]string{"velo.service.name": "web"}, container.RestartPolicyAlways)
+	runContainer(t, srv, "sidecar", nil, container.RestartPolicyAlways)
+	if err := a.collectContainers(); err != nil {
+		t.Fatalf("collectContainers failed: %v", err)
+	}
+
+	stats := container.StatsResponse{
+		CPUStats: container.CPUStats{
+			CPUUsage:    container.CPUUsage{TotalUsage: 1_000},
+			SystemUsage: 10_000,
+			OnlineCPUs:  4,
+		},
+		MemoryStats: container.MemoryStats{
+			Usage: 300 << 20,
+			Limit: 1 << 30,
+			Stats: map[string]uint64{"inactive_file": 100 << 20},
+		},
+		Networks: map[string]container.NetworkStats{
+			"eth0": {RxBytes: 1000, TxBytes: 200},
+			"eth1": {RxBytes: 500, TxBytes: 50},
+		},
+		BlkioStats: container.BlkioStats{IoServiceBytesRecursive: []container.BlkioStatEntry{
+			{Op: "read", Value: 4096},
+			{Op: "Write", Value: 8192},
+			{Op: "total", Value: 12288},
+		}},
+	}
+	srv.SetContainerStats("web-1", stats)
+	a.sampleMetrics()
+
+	// The second sample computes CPU % against the first one
+	stats.CPUStats.CPUUsage.TotalUsage = 3_000
+	stats.CPUStats.SystemUsage = 20_000
+	srv.SetContainerStats("web-1", stats)
+	a.sampleMetrics()
+
+	samples := a.PendingMetrics(10)
+	if len(samples) != 2 {
+		t.Fatalf("Expected 2 samples of the Velo task only, got %v", samples)
+	}
+	s := samples[1]
+	if s.Container != "web-1" || s.Service != "web" {
+		t.Errorf("Expected a sample of web-1, got %+v", s)
+	}
+	// 2000 of 10000 ns of host CPU time on 4 CPUs is 80% of a core
+	if math.Abs(s.CPUPercent-80) > 0.001 {
+		t.Errorf("Expected 80%% CPU, got %f", s.CPUPercent)
+	}
+	if s.MemoryUsage != 200<<20 || s.MemoryLimit != 1<<30 {
+		t.Errorf("Expected 200MiB of 1GiB memory without the cache, got %d of %d", s.MemoryUsage, s.MemoryLimit)
+	}
+	if s.NetRx != 1500 || s.NetTx != 250 || s.BlockRead != 4096 || s.BlockWrite != 8192 {
+		t.Errorf("Unexpected I/O counters %+v", s)
+	}
+
+	a.AckMetrics(samples[1].Seq)
+	if pending := a.PendingMetrics(10); len(pending) != 0 {
+		t.Errorf("Expected no pending samples after the acknowledgement, got %v", pending)
+	}
+}
@@ -0,0 +1,232 @@
+package agent
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/docker/docker/api/types/filters"
+	"github.com/jasonlovesdoggo/velo/api/proto"
+	"github.com/jasonlovesdoggo/velo/internal/log"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/cluster"
+	"github.com/jasonlovesdoggo/velo/pkg/core"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxRegisterBackoff caps the delay between attempts to register with the manager
+const maxRegisterBackoff = time.Minute
+
+// ReportTo registers the agent with the manager behind conn and sends it
+// heartbeats until the agent stops. An empty cluster lets the manager find the
+// cluster of this node.
+func (a *ContainerAgent) ReportTo(conn grpc.ClientConnInterface, clusterName string) {
+	go a.report(proto.NewAgentServiceClient(conn), clusterName)
+}
+
+// report registers with the manager, retrying with backoff, and sends heartbeats.
+// It registers again when the manager no longer knows the agent, e.g. after a restart.
+func (a *ContainerAgent) report(svc proto.AgentServiceClient, clusterName string) {
+	backoff := time.Second
+	var results []*proto.AgentCommandResult
+	for a.ctx.Err() == nil {
+		reg, err := a.register(svc, clusterName)
+		if err != nil {
+			log.Warn("Failed to register with manager", "error", err, "retryIn", backoff)
+			if !a.sleep(backoff) {
+				return
+			}
+			backoff = min(backoff*2, maxRegisterBackoff)
+			continue
+		}
+		backoff = time.Second
+		log.Info("Registered with manager", "agentID", reg.AgentId, "node", reg.NodeId, "cluster", reg.Cluster)
+
+		interval := time.Duration(reg.HeartbeatIntervalMs) * time.Millisecond
+		if interval <= 0 {
+			interval = cluster.DefaultHeartbeatInterval
+		}
+		results = a.sendHeartbeats(svc, reg, interval, results)
+	}
+}
+
+// sendHeartbeats sends heartbeats and runs the commands they return until the
+// manager doesn't recognize the agent anymore. It returns the command results
+// that still have to be reported.
+func (a *ContainerAgent) sendHeartbeats(svc proto.AgentServiceClient, reg *proto.RegisterAgentResponse, interval time.Duration, results []*proto.AgentCommandResult) []*proto.AgentCommandResult {
+	for {
+		req, lastSeq := a.heartbeat(reg, results)
+		ctx, cancel := context.WithTimeout(a.ctx, interval)
+		resp, err := svc.Heartbeat(ctx, req)
+		cancel()
+		switch {
+		case status.Code(err) == codes.NotFound:
+			log.Warn("Manager doesn't know this agent, registering again", "agentID", reg.AgentId)
+			return results
+		case err != nil:
+			log.Warn("Failed to send heartbeat", "error", err)
+		default:
+			a.AckMetrics(lastSeq)
+			results = a.runCommands(resp.Commands)
+			if len(results) > 0 {
+				continue // report results right away
+			}
+		}
+		if !a.sleep(interval) {
+			return results
+		}
+	}
+}
+
+// register sends the node's identity, versions and capacity to the manager
+func (a *ContainerAgent) register(svc proto.AgentServiceClient, clusterName string) (*proto.RegisterAgentResponse, error) {
+	ctx, cancel := context.WithTimeout(a.ctx, 30*time.Second)
+	defer cancel()
+
+	info, err := a.client.Info(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Docker info: %w", err)
+	}
+	return svc.Register(ctx, &proto.RegisterAgentRequest{
+		NodeId:        a.nodeID,
+		Hostname:      a.hostname,
+		Cluster:       clusterName,
+		AgentVersion:  core.Version,
+		DockerVersion: info.ServerVersion,
+		Os:            info.OperatingSystem,
+		Arch:          info.Architecture,
+		KernelVersion: info.KernelVersion,
+		Capacity: &proto.AgentCapacity{
+			CpuCores:    int32(info.NCPU),
+			MemoryBytes: info.MemTotal,
+			DiskBytes:   diskSize(info.DockerRootDir),
+			Gpus:        int32(countGPUs()),
+		},
+	})
+}
+
+// heartbeat builds a heartbeat from the latest inventory, health check and the
+// metrics not shipped yet. It also returns the sequence number of the last
+// sample it carries, to acknowledge once the manager has it.
+func (a *ContainerAgent) heartbeat(reg *proto.RegisterAgentResponse, results []*proto.AgentCommandResult) (*proto.HeartbeatRequest, uint64) {
+	req := &proto.HeartbeatRequest{
+		AgentId: reg.AgentId,
+		NodeId:  cmp.Or(reg.NodeId, a.nodeID), // standalone hosts are known by the name of their token
+		Cluster: reg.Cluster,
+		Health:  "healthy",
+		Results: results,
+	}
+	for _, c := range a.GetContainers() {
+		req.Containers = append(req.Containers, &proto.ContainerStatus{
+			Id:           c.ID,
+			Name:         c.Name,
+			Image:        c.Image,
+			State:        c.Status,
+			Health:       c.Health,
+			Service:      c.Service,
+			TaskId:       c.TaskID,
+			RestartCount: int32(c.RestartCount),
+			ExitCode:     int32(c.ExitCode),
+			OomKilled:    c.OOMKilled,
+		})
+	}
+	for _, p := range a.Problems() {
+		req.Problems = append(req.Problems, p.String())
+	}
+	if len(req.Problems) > 0 {
+		req.Health = "degraded"
+	}
+	var lastSeq uint64
+	for _, s := range a.PendingMetrics(maxMetricsPerHeartbeat) {
+		req.Metrics = append(req.Metrics, &proto.ContainerMetrics{
+			TimestampUnixMs: s.Time.UnixMilli(),
+			ContainerId:     s.ContainerID,
+			Container:       s.Container,
+			Service:         s.Service,
+			TaskId:          s.TaskID,
+			CpuPercent:      s.CPUPercent,
+			MemoryUsage:     s.MemoryUsage,
+			MemoryLimit:     s.MemoryLimit,
+			NetRxBytes:      s.NetRx,
+			NetTxBytes:      s.NetTx,
+			BlockReadBytes:  s.BlockRead,
+			BlockWriteBytes: s.BlockWrite,
+		})
+		lastSeq = s.Seq
+	}
+	return req, lastSeq
+}
+
+// runCommands runs the commands sent by the manager and returns their results
+func (a *ContainerAgent) runCommands(commands []*proto.AgentCommand) []*proto.AgentCommandResult {
+	var results []*proto.AgentCommandResult
+	for _, cmd := range commands {
+		log.Info("Running command from manager", "id", cmd.Id, "type", cmd.Type, "target", cmd.Target)
+		message, err := a.runCommand(cmd)
+		result := &proto.AgentCommandResult{Id: cmd.Id, Success: err == nil, Message: message}
+		if err != nil {
+			log.Warn("Command from manager failed", "id", cmd.Id, "type", cmd.Type, "error", err)
+			result.Message = err.Error()
+		}
+		results = append(results, result)
+	}
+	if len(results) > 0 {
+		// Report the containers as the commands left them
+		if err := a.collectContainers(); err != nil {
+			log.Error("Error collecting containers", "error", err)
+		}
+	}
+	return results
+}
+
+func (a *ContainerAgent) runCommand(cmd *proto.AgentCommand) (string, error) {
+	switch cmd.Type {
+	case cluster.CommandRestartContainer:
+		return "container restarted", a.RestartContainer(cmd.Target)
+	case cluster.CommandStopContainer:
+		return "container stopped", a.StopContainer(cmd.Target)
+	case cluster.CommandStartContainer:
+		return "container started", a.StartContainer(cmd.Target)
+	case cluster.CommandPruneImages:
+		return a.PruneImages()
+	default:
+		return "", fmt.Errorf("unknown command %q", cmd.Type)
+	}
+}
+
+// PruneImages removes dangling images and returns a summary of what was removed
+func (a *ContainerAgent) PruneImages() (string, error) {
+	report, err := a.client.ImagesPrune(a.ctx, filters.NewArgs())
+	if err != nil {
+		return "", fmt.Errorf("failed to prune images: %w", err)
+	}
+	return fmt.Sprintf("removed %d images, reclaimed %d bytes", len(report.ImagesDeleted), report.SpaceReclaimed), nil
+}
+
+// sleep waits for d and reports whether the agent is still running
+func (a *ContainerAgent) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-a.ctx.Done():
+		return false
+	}
+}
+
+// countGPUs counts the NVIDIA GPUs exposed to the host, which Docker doesn't report
+func countGPUs() int {
+	matches, _ := filepath.Glob("/dev/nvidia[0-9]*")
+	count := 0
+	for _, match := range matches {
+		if fi, err := os.Stat(match); err == nil && fi.Mode()&os.ModeDevice != 0 {
+			count++
+		}
+	}
+	return count
+}
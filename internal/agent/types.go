@@ -2,31 +2,91 @@ package agent
 
 import (
 	"context"
-	"github.com/docker/docker/client"
 	"sync"
 	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/jasonlovesdoggo/velo/internal/config"
 )
 
 // ContainerAgent is responsible for monitoring the local node and containers
 type ContainerAgent struct {
 	client        *client.Client
+	opts          Options
 	hostname      string
 	nodeID        string
 	isManager     bool
+	standalone    bool // the host is not part of a swarm
 	collectTicker *time.Ticker
 	healthTicker  *time.Ticker
+	metricsTicker *time.Ticker
 	ctx           context.Context
 	cancel        context.CancelFunc
 	containers    []ContainerInfo
 	containersMu  sync.RWMutex
+
+	healthMu sync.Mutex
+	problems []Problem
+	crashes  map[string][]time.Time // crash times per service, within the crash loop window
+	observed map[string]observation // last state seen per container ID
+
+	metricsMu sync.Mutex
+	metrics   *sampleRing         // samples not shipped to the manager yet
+	cpu       map[string]cpuUsage // last CPU reading per container ID
+}
+
+// Options configures a ContainerAgent
+type Options struct {
+	CollectInterval   time.Duration // how often the container inventory is refreshed
+	HealthInterval    time.Duration // how often container health is evaluated
+	DefaultPolicy     string        // health policy of services that don't set one: restart, report or ignore
+	CrashLoopRestarts int           // crashes within CrashLoopWindow that make a service crash-looping
+	CrashLoopWindow   time.Duration
+	MetricsInterval   time.Duration // how often the resource usage of Velo tasks is sampled
+	MetricsBuffer     int           // samples kept until they're shipped to the manager
+}
+
+// DefaultOptions returns the options agents run with unless configured otherwise
+func DefaultOptions() Options {
+	return Options{
+		CollectInterval:   30 * time.Second,
+		HealthInterval:    time.Minute,
+		DefaultPolicy:     config.HealthPolicyReport,
+		CrashLoopRestarts: 3,
+		CrashLoopWindow:   5 * time.Minute,
+		MetricsInterval:   15 * time.Second,
+		MetricsBuffer:     5000,
+	}
 }
 
 // ContainerInfo contains information about a container
 type ContainerInfo struct {
-	ID      string
-	Name    string
-	Image   string
-	Status  string
-	Running bool
-	Health  string
+	ID           string
+	Name         string
+	Image        string
+	Service      string // swarm or standalone service the container belongs to
+	TaskID       string // swarm task, empty outside swarm
+	Status       string
+	Running      bool
+	Health       string // healthy, unhealthy or starting; empty without a healthcheck
+	RestartCount int
+	ExitCode     int
+	OOMKilled    bool
+	FinishedAt   time.Time
+	Policy       string // health policy set by the service, empty for the agent's default
+}
+
+// Problem is a container health problem found by the agent
+type Problem struct {
+	Container string
+	Service   string
+	Kind      string // unhealthy or crash-loop
+	Message   string
+	Action    string // what the agent did about it: restarted, reported
+}
+
+// observation is what the agent last saw of a container, to count crashes between inventories
+type observation struct {
+	restartCount int
+	finishedAt   time.Time
 }
@@ -0,0 +1,444 @@
+package alerting
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/config"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/cluster"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/sim"
+	"github.com/jasonlovesdoggo/velo/internal/state"
+)
+
+// recorder is a Notifier that keeps what it's sent
+type recorder struct {
+	mu            sync.Mutex
+	notifications []Notification
+}
+
+func (r *recorder) Notify(ctx context.Context, n Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notifications = append(r.notifications, n)
+	return nil
+}
+
+func (r *recorder) take() []Notification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := r.notifications
+	r.notifications = nil
+	return n
+}
+
+func simClusters(t *testing.T) (*sim.Orchestrator, *cluster.Registry) {
+	t.Helper()
+	o := sim.New(sim.Options{})
+	clusters := cluster.Single(o)
+	clusters.CheckHealth(context.Background())
+	t.Cleanup(clusters.Stop)
+	return o, clusters
+}
+
+func alertStates(e *Engine) map[string]string {
+	states := make(map[string]string)
+	for _, a := range e.Alerts() {
+		states[a.Summary] = a.State
+	}
+	return states
+}
+
+func TestEngine(t *testing.T) {
+	o, clusters := simClusters(t)
+	o.FailImagePulls("broken:1", "manifest unknown")
+	for _, def := range []config.ServiceDefinition{
+		{Name: "web", Image: "nginx", Replicas: 1},
+		{Name: "api", Image: "broken:1", Replicas: 2},
+	} {
+		if _, err := o.DeployService(def); err != nil {
+			t.Fatalf("DeployService failed: %v", err)
+		}
+	}
+	o.Step()
+	o.Step()
+	if err := o.SetNodeDown("worker-1", true); err != nil {
+		t.Fatalf("SetNodeDown failed: %v", err)
+	}
+
+	now := time.Now()
+	receiver := &recorder{}
+	e := NewEngine(state.NewMemoryStateStore(), clusters, Options{
+		Receivers: map[string]Notifier{"ops": receiver},
+		Now:       func() time.Time { return now },
+	})
+	if _, err := e.SetRule(Rule{Name: "replicas", Condition: ConditionReplicasBelow, For: 5 * time.Minute, GroupBy: []string{"service"}}); err != nil {
+		t.Fatalf("SetRule failed: %v", err)
+	}
+	if _, err := e.SetRule(Rule{Name: "nodes", Condition: ConditionNodeDown, Severity: SeverityCritical}); err != nil {
+		t.Fatalf("SetRule failed: %v", err)
+	}
+
+	// The node alert fires at once, the replica alert waits for its rule's 5 minutes
+	e.Evaluate(context.Background())
+	states := alertStates(e)
+	if states["node worker-1 is down"] != StateFiring || states["api is running 0 of 2 replicas"] != StatePending || len(states) != 2 {
+		t.Fatalf("Unexpected alerts %v", states)
+	}
+	sent := receiver.take()
+	if len(sent) != 1 || sent[0].Status != StateFiring || sent[0].Alerts[0].Labels["severity"] != SeverityCritical {
+		t.Fatalf("Expected one firing node notification, got %+v", sent)
+	}
+
+	now = now.Add(5 * time.Minute)
+	e.Evaluate(context.Background())
+	sent = receiver.take()
+	if len(sent) != 1 || sent[0].GroupLabels["service"] != "api" || sent[0].Title() != "[FIRING:1] replicas service=api" {
+		t.Fatalf("Expected only the replica alert to be notified, got %+v", sent)
+	}
+
+	// Silenced alerts keep firing but aren't notified; the rest repeat
+	silence, err := e.CreateSilence([]Matcher{{Name: "service", Value: "api"}}, 8*time.Hour, "fixing the image", "alice")
+	if err != nil {
+		t.Fatalf("CreateSilence failed: %v", err)
+	}
+	now = now.Add(DefaultRepeatInterval)
+	e.Evaluate(context.Background())
+	sent = receiver.take()
+	if len(sent) != 1 || sent[0].GroupLabels["alertname"] != "nodes" {
+		t.Fatalf("Expected the node alert to repeat, got %+v", sent)
+	}
+	for _, a := range e.Alerts() {
+		if a.Rule == "replicas" && a.SilencedBy != silence.ID {
+			t.Errorf("Expected the replica alert to be silenced by %s, got %q", silence.ID, a.SilencedBy)
+		}
+	}
+
+	o.SetNodeDown("worker-1", false)
+	e.Evaluate(context.Background())
+	sent = receiver.take()
+	if len(sent) != 1 || sent[0].Status != StateResolved || sent[0].Alerts[0].State != StateResolved {
+		t.Fatalf("Expected a resolved notification, got %+v", sent)
+	}
+	if states := alertStates(e); len(states) != 1 {
+		t.Errorf("Expected resolved alerts to be dropped, got %v", states)
+	}
+
+	if err := e.ExpireSilence(silence.ID); err != nil {
+		t.Fatalf("ExpireSilence failed: %v", err)
+	}
+	if err := e.ExpireSilence("nope"); !errors.Is(err, ErrUnknownSilence) {
+		t.Errorf("Expected an unknown silence error, got %v", err)
+	}
+	silences, _ := e.Silences()
+	if len(silences) != 1 || silences[0].Active(now) {
+		t.Errorf("Expected the expired silence to be listed as inactive, got %+v", silences)
+	}
+}
+
+func TestDeployAndCPUConditions(t *testing.T) {
+	_, clusters := simClusters(t)
+	c, _ := clusters.Get("")
+	c.RecordDeploy("web", errors.New("image not found"))
+	c.RecordDeploy("api", errors.New("no capacity"))
+	c.RecordDeploy("api", nil)
+	c.Metrics.Add("node-1", []cluster.ContainerSample{
+		{Time: time.Now(), ContainerID: "a", Service: "web", CPUPercent: 90},
+		{Time: time.Now(), ContainerID: "b", Service: "web", CPUPercent: 50},
+		{Time: time.Now(), ContainerID: "c", Service: "api", CPUPercent: 20},
+	})
+
+	e := NewEngine(state.NewMemoryStateStore(), clusters, Options{})
+	e.SetRule(Rule{Name: "deploys", Condition: ConditionDeployFailed})
+	e.SetRule(Rule{Name: "cpu", Condition: ConditionCPUAbove, Threshold: 60})
+	e.Evaluate(context.Background())
+
+	alerts := e.Alerts()
+	if len(alerts) != 2 {
+		t.Fatalf("Expected a CPU and a deploy alert, got %+v", alerts)
+	}
+	if alerts[0].Rule != "cpu" || alerts[0].Value != 70 || alerts[0].Labels["service"] != "web" || alerts[0].Labels["node"] != "node-1" {
+		t.Errorf("Unexpected CPU alert %+v", alerts[0])
+	}
+	if alerts[1].Rule != "deploys" || alerts[1].Labels["service"] != "web" || !strings.Contains(alerts[1].Summary, "image not found") {
+		t.Errorf("Unexpected deploy alert %+v", alerts[1])
+	}
+}
+
+// writeCert writes a self-signed certificate expiring at notAfter
+func writeCert(t *testing.T, notAfter time.Time) string {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "velo.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644)
+	return path
+}
+
+func TestCertExpiry(t *testing.T) {
+	_, clusters := simClusters(t)
+	e := NewEngine(state.NewMemoryStateStore(), clusters, Options{})
+
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		target string
+		within time.Duration
+		firing bool
+	}{
+		{"Expiring file", writeCert(t, time.Now().Add(48*time.Hour)), 0, true},
+		{"Valid file", writeCert(t, time.Now().Add(30*24*time.Hour)), 0, false},
+		{"Valid endpoint", server.Listener.Addr().String(), time.Hour, false},
+		{"Unreachable endpoint", "127.0.0.1:1", time.Hour, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := Rule{Name: "cert", Condition: ConditionCertExpiry, Target: tt.target, Within: tt.within}
+			if err := rule.validate(); err != nil {
+				t.Fatalf("validate failed: %v", err)
+			}
+			observations := e.observeCert(context.Background(), rule)
+			if (len(observations) > 0) != tt.firing {
+				t.Errorf("Expected firing=%v, got %+v", tt.firing, observations)
+			}
+		})
+	}
+}
+
+func TestRuleValidation(t *testing.T) {
+	e := NewEngine(state.NewMemoryStateStore(), cluster.NewRegistry(), Options{
+		Receivers: map[string]Notifier{"ops": &recorder{}},
+	})
+
+	tests := []struct {
+		name  string
+		rule  Rule
+		valid bool
+	}{
+		{"Replicas", Rule{Name: "replicas", Condition: ConditionReplicasBelow, Receivers: []string{"ops"}}, true},
+		{"Bad name", Rule{Name: "no spaces", Condition: ConditionNodeDown}, false},
+		{"Unknown condition", Rule{Name: "x", Condition: "disk_full"}, false},
+		{"CPU without threshold", Rule{Name: "cpu", Condition: ConditionCPUAbove}, false},
+		{"Cert without target", Rule{Name: "cert", Condition: ConditionCertExpiry}, false},
+		{"Bad severity", Rule{Name: "x", Condition: ConditionNodeDown, Severity: "page"}, false},
+		{"Unknown receiver", Rule{Name: "x", Condition: ConditionNodeDown, Receivers: []string{"pager"}}, false},
+		{"Bad group label", Rule{Name: "x", Condition: ConditionNodeDown, GroupBy: []string{"a-b"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := e.SetRule(tt.rule)
+			if (err == nil) != tt.valid {
+				t.Errorf("Expected valid=%v, got %v", tt.valid, err)
+			}
+		})
+	}
+
+	rules, _ := e.Rules()
+	if len(rules) != 1 || rules[0].Severity != SeverityWarning {
+		t.Fatalf("Expected the valid rule with the default severity, got %+v", rules)
+	}
+	if err := e.DeleteRule("replicas"); err != nil {
+		t.Fatalf("DeleteRule failed: %v", err)
+	}
+	if err := e.DeleteRule("replicas"); !errors.Is(err, ErrUnknownRule) {
+		t.Errorf("Expected an unknown rule error, got %v", err)
+	}
+}
+
+func TestParseMatcher(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Matcher
+		valid bool
+	}{
+		{"service=web", Matcher{Name: "service", Value: "web"}, true},
+		{"severity!=critical", Matcher{Name: "severity", Value: "critical", Negate: true}, true},
+		{"node=", Matcher{Name: "node"}, true},
+		{"service", Matcher{}, false},
+		{"bad-name=x", Matcher{}, false},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMatcher(tt.input)
+		if (err == nil) != tt.valid || got != tt.want {
+			t.Errorf("ParseMatcher(%q): expected %+v (valid=%v), got %+v (%v)", tt.input, tt.want, tt.valid, got, err)
+		}
+	}
+
+	m, _ := ParseMatcher("severity!=critical")
+	if !m.Matches(map[string]string{"severity": "warning"}) || m.Matches(map[string]string{"severity": "critical"}) {
+		t.Error("Expected a negated matcher to match other values only")
+	}
+}
+
+func testNotification() Notification {
+	return Notification{
+		Group:       `{alertname="replicas",service="api"}`,
+		Status:      StateFiring,
+		GroupLabels: map[string]string{"alertname": "replicas", "service": "api"},
+		Alerts:      []Alert{{Rule: "replicas", State: StateFiring, Summary: "api is running 0 of 2 replicas"}},
+	}
+}
+
+func TestWebhookNotifiers(t *testing.T) {
+	var got []byte
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		got = make([]byte, r.ContentLength)
+		r.Body.Read(got)
+	}))
+	defer server.Close()
+
+	webhook, _ := NewNotifier(config.ReceiverConfig{Type: config.ReceiverWebhook, URL: server.URL, Headers: map[string]string{"Authorization": "Bearer s3cret"}})
+	if err := webhook.Notify(context.Background(), testNotification()); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	var n Notification
+	if err := json.Unmarshal(got, &n); err != nil || n.Status != StateFiring || len(n.Alerts) != 1 || auth != "Bearer s3cret" {
+		t.Errorf("Unexpected webhook payload %s (auth %q, %v)", got, auth, err)
+	}
+
+	slack, _ := NewNotifier(config.ReceiverConfig{Type: config.ReceiverSlack, URL: server.URL, Channel: "#ops"})
+	if err := slack.Notify(context.Background(), testNotification()); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	var msg slackMessage
+	json.Unmarshal(got, &msg)
+	if msg.Channel != "#ops" || !strings.Contains(msg.Text, "[FIRING:1] replicas service=api") || !strings.Contains(msg.Text, "FIRING: api is running 0 of 2 replicas") {
+		t.Errorf("Unexpected Slack payload %s", got)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such hook", http.StatusNotFound)
+	}))
+	defer failing.Close()
+	webhook, _ = NewNotifier(config.ReceiverConfig{Type: config.ReceiverWebhook, URL: failing.URL})
+	if err := webhook.Notify(context.Background(), testNotification()); err == nil || !strings.Contains(err.Error(), "no such hook") {
+		t.Errorf("Expected the receiver's error, got %v", err)
+	}
+}
+
+// smtpServer is a minimal SMTP server that accepts one message
+type smtpServer struct {
+	addr string
+	auth chan string
+	data chan string
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	t.Cleanup(func() { lis.Close() })
+	s := &smtpServer{addr: lis.Addr().String(), auth: make(chan string, 1), data: make(chan string, 1)}
+
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			verb, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+			switch strings.ToUpper(verb) {
+			case "EHLO":
+				reply("250-localhost")
+				reply("250 AUTH PLAIN")
+			case "AUTH":
+				s.auth <- arg
+				reply("235 Authenticated")
+			case "MAIL", "RCPT":
+				reply("250 OK")
+			case "DATA":
+				reply("354 Go ahead")
+				var body strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					body.WriteString(line)
+				}
+				s.data <- body.String()
+				reply("250 Queued")
+			case "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("502 Not implemented")
+			}
+		}
+	}()
+	return s
+}
+
+func TestEmailNotifier(t *testing.T) {
+	server := newSMTPServer(t)
+	email, err := NewNotifier(config.ReceiverConfig{
+		Type:     config.ReceiverEmail,
+		SMTPAddr: server.addr,
+		Username: "velo",
+		Password: "hunter2",
+		From:     "velo@example.com",
+		To:       []string{"ops@example.com"},
+	})
+	if err != nil {
+		t.Fatalf("NewNotifier failed: %v", err)
+	}
+	if err := email.Notify(context.Background(), testNotification()); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	creds, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(<-server.auth, "PLAIN "))
+	if string(creds) != "\x00velo\x00hunter2" {
+		t.Errorf("Unexpected credentials %q", creds)
+	}
+	data := <-server.data
+	for _, want := range []string{"Subject: [FIRING:1] replicas service=api", "To: ops@example.com", "FIRING: api is running 0 of 2 replicas"} {
+		if !strings.Contains(data, want) {
+			t.Errorf("Expected %q in the message, got:\n%s", want, data)
+		}
+	}
+}
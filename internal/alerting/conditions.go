@@ -0,0 +1,232 @@
+package alerting
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/cluster"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/manager"
+)
+
+// usageWindow is how recent container samples must be to count for cpu_above
+const usageWindow = 2 * time.Minute
+
+// observation is one thing a rule's condition currently holds for, e.g. one
+// service below its replica count
+type observation struct {
+	labels  map[string]string
+	summary string
+	value   float64
+}
+
+// observe evaluates a rule's condition. Clusters that are down are skipped:
+// what they last reported can't be trusted.
+func (e *Engine) observe(ctx context.Context, rule Rule) ([]observation, error) {
+	if rule.Condition == ConditionCertExpiry {
+		return e.observeCert(ctx, rule), nil
+	}
+
+	var clusters []*cluster.Cluster
+	if rule.Cluster != "" {
+		c, err := e.clusters.Get(rule.Cluster)
+		if err != nil {
+			return nil, err
+		}
+		clusters = []*cluster.Cluster{c}
+	} else {
+		clusters = e.clusters.Clusters()
+	}
+
+	var observations []observation
+	for _, c := range clusters {
+		if !c.Healthy() {
+			continue
+		}
+		var found []observation
+		var err error
+		switch rule.Condition {
+		case ConditionReplicasBelow:
+			found, err = observeReplicas(c, rule)
+		case ConditionNodeDown:
+			found = observeNodes(c)
+		case ConditionCPUAbove:
+			found = observeCPU(c, rule, e.now())
+		case ConditionDeployFailed:
+			found = observeDeploys(c, rule)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cluster %s: %w", c.Name, err)
+		}
+		for _, o := range found {
+			o.labels["cluster"] = c.Name
+			observations = append(observations, o)
+		}
+	}
+	return observations, nil
+}
+
+// observeReplicas finds services running fewer replicas than desired
+func observeReplicas(c *cluster.Cluster, rule Rule) ([]observation, error) {
+	sm, ok := c.Manager.(manager.ServiceManager)
+	if !ok {
+		return nil, nil
+	}
+	services, err := sm.ListServices()
+	if err != nil {
+		return nil, err
+	}
+
+	var found []observation
+	for _, s := range services {
+		if rule.Service != "" && s.Service.Name != rule.Service {
+			continue
+		}
+		if s.Running >= s.Service.Replicas {
+			continue
+		}
+		found = append(found, observation{
+			labels:  map[string]string{"service": s.Service.Name},
+			summary: fmt.Sprintf("%s is running %d of %d replicas", s.Service.Name, s.Running, s.Service.Replicas),
+			value:   float64(s.Running),
+		})
+	}
+	return found, nil
+}
+
+// observeNodes finds nodes that aren't ready and nodes whose agent went
+// missing. Nodes that never ran an agent don't count.
+func observeNodes(c *cluster.Cluster) []observation {
+	var found []observation
+	for _, n := range c.Nodes() {
+		name := n.Hostname
+		if name == "" {
+			name = n.ID
+		}
+		var summary string
+		switch {
+		case len(n.Conditions) > 0 && n.Conditions[0] != "ready":
+			summary = fmt.Sprintf("node %s is %s", name, n.Conditions[0])
+		case n.Agent != nil && n.AgentMissing:
+			summary = fmt.Sprintf("the agent on node %s stopped sending heartbeats", name)
+		default:
+			continue
+		}
+		found = append(found, observation{
+			labels:  map[string]string{"node": name},
+			summary: summary,
+		})
+	}
+	return found
+}
+
+// observeCPU finds services whose containers on a node average more CPU than the threshold
+func observeCPU(c *cluster.Cluster, rule Rule, now time.Time) []observation {
+	var found []observation
+	for _, u := range c.CurrentUsage(now.Add(-usageWindow)) {
+		if rule.Service != "" && u.Service != rule.Service {
+			continue
+		}
+		if u.Containers == 0 {
+			continue
+		}
+		cpu := u.CPUPercent / float64(u.Containers)
+		if cpu <= rule.Threshold {
+			continue
+		}
+		node := u.Hostname
+		if node == "" {
+			node = u.Node
+		}
+		found = append(found, observation{
+			labels:  map[string]string{"service": u.Service, "node": node},
+			summary: fmt.Sprintf("%s uses %.1f%% CPU per container on %s (threshold %.1f%%)", u.Service, cpu, node, rule.Threshold),
+			value:   cpu,
+		})
+	}
+	return found
+}
+
+// observeDeploys finds services whose last deploy failed
+func observeDeploys(c *cluster.Cluster, rule Rule) []observation {
+	var found []observation
+	for _, f := range c.FailedDeploys() {
+		if rule.Service != "" && f.Service != rule.Service {
+			continue
+		}
+		found = append(found, observation{
+			labels:  map[string]string{"service": f.Service},
+			summary: fmt.Sprintf("deploy of %s failed at %s: %s", f.Service, f.Time.Format(time.RFC3339), f.Error),
+		})
+	}
+	return found
+}
+
+// observeCert checks when the certificate of a rule's target expires. A
+// certificate that can't be read alerts too: it can't be vouched for.
+func (e *Engine) observeCert(ctx context.Context, rule Rule) []observation {
+	labels := map[string]string{"target": rule.Target}
+	notAfter, err := certExpiry(ctx, rule.Target)
+	if err != nil {
+		return []observation{{
+			labels:  labels,
+			summary: fmt.Sprintf("certificate of %s can't be checked: %v", rule.Target, err),
+		}}
+	}
+
+	left := notAfter.Sub(e.now())
+	if left > rule.Within {
+		return nil
+	}
+	summary := fmt.Sprintf("certificate of %s expires on %s", rule.Target, notAfter.Format(time.RFC3339))
+	if left <= 0 {
+		summary = fmt.Sprintf("certificate of %s expired on %s", rule.Target, notAfter.Format(time.RFC3339))
+	}
+	return []observation{{
+		labels:  labels,
+		summary: summary,
+		value:   left.Hours() / 24,
+	}}
+}
+
+// certExpiry returns when the leaf certificate of a PEM file or TLS endpoint
+// expires. Targets that exist as files are read, anything else is dialed,
+// on port 443 unless given.
+func certExpiry(ctx context.Context, target string) (time.Time, error) {
+	if data, err := os.ReadFile(target); err == nil {
+		block, _ := pem.Decode(data)
+		if block == nil || block.Type != "CERTIFICATE" {
+			return time.Time{}, fmt.Errorf("no PEM certificate in %s", target)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return cert.NotAfter, nil
+	}
+
+	addr := target
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(strings.Trim(addr, "[]"), "443")
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	// Expiry is all that's checked; an untrusted certificate still expires
+	dialer := &tls.Dialer{Config: &tls.Config{InsecureSkipVerify: true}}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer conn.Close()
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return time.Time{}, fmt.Errorf("%s presented no certificate", addr)
+	}
+	return certs[0].NotAfter, nil
+}
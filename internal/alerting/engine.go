@@ -0,0 +1,424 @@
+package alerting
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/log"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/cluster"
+	"github.com/jasonlovesdoggo/velo/internal/state"
+)
+
+// Alert states
+const (
+	// StatePending alerts hold but haven't for as long as their rule requires
+	StatePending = "pending"
+	// StateFiring alerts are notified
+	StateFiring = "firing"
+	// StateResolved alerts stopped holding after firing
+	StateResolved = "resolved"
+)
+
+// Defaults of the engine's options
+const (
+	DefaultInterval       = 30 * time.Second
+	DefaultRepeatInterval = 4 * time.Hour
+)
+
+// Alert is an instance of a rule's condition holding, e.g. one service below
+// its replica count. Alerts are identified by their labels.
+type Alert struct {
+	Fingerprint string            `json:"fingerprint"`
+	Rule        string            `json:"rule"`
+	State       string            `json:"state"`
+	Labels      map[string]string `json:"labels"`
+	Summary     string            `json:"summary"`
+	Value       float64           `json:"value"`
+	ActiveSince time.Time         `json:"active_since"`
+	FiredAt     time.Time         `json:"fired_at"`
+	ResolvedAt  time.Time         `json:"resolved_at"`
+	SilencedBy  string            `json:"silenced_by,omitempty"` // ID of the silence muting the alert
+
+	group string
+}
+
+// Options configure an Engine
+type Options struct {
+	Interval       time.Duration       // between evaluations
+	RepeatInterval time.Duration       // before a still-firing group is notified again, unless its rule sets one
+	Receivers      map[string]Notifier // by name
+	Now            func() time.Time    // for tests
+}
+
+// Engine evaluates the alert rules periodically and notifies receivers. Alerts
+// of a rule are notified in groups, by the rule's group_by labels: a group is
+// notified when an alert in it starts firing or resolves, and again every
+// repeat interval while alerts in it fire.
+type Engine struct {
+	store     state.StateStore
+	clusters  *cluster.Registry
+	receivers map[string]Notifier
+	interval  time.Duration
+	repeat    time.Duration
+	now       func() time.Time
+
+	evalMu sync.Mutex // serializes evaluations
+	mu     sync.Mutex
+	alerts map[string]*Alert // by fingerprint
+	groups map[string]*group // by key
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// group tracks what receivers were last told about a group of alerts
+type group struct {
+	labels       map[string]string
+	receivers    []string
+	repeat       time.Duration
+	notified     map[string]bool // fingerprints of the alerts last notified as firing
+	lastNotified time.Time
+	resolved     []Alert // resolved since the last evaluation
+}
+
+// NewEngine creates an engine evaluating the rules in store against clusters
+func NewEngine(store state.StateStore, clusters *cluster.Registry, opts Options) *Engine {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.RepeatInterval <= 0 {
+		opts.RepeatInterval = DefaultRepeatInterval
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	if opts.Receivers == nil {
+		opts.Receivers = make(map[string]Notifier)
+	}
+	return &Engine{
+		store:     store,
+		clusters:  clusters,
+		receivers: opts.Receivers,
+		interval:  opts.Interval,
+		repeat:    opts.RepeatInterval,
+		now:       opts.Now,
+		alerts:    make(map[string]*Alert),
+		groups:    make(map[string]*group),
+	}
+}
+
+// Start evaluates the rules every interval in the background
+func (e *Engine) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+	e.done = make(chan struct{})
+
+	go func() {
+		defer close(e.done)
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				e.Evaluate(ctx)
+			}
+		}
+	}()
+}
+
+// Stop stops the background evaluation
+func (e *Engine) Stop() {
+	if e.cancel == nil {
+		return
+	}
+	e.cancel()
+	<-e.done
+}
+
+// Receivers returns the names of the configured receivers
+func (e *Engine) Receivers() []string {
+	names := make([]string, 0, len(e.receivers))
+	for name := range e.receivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Alerts returns the pending and firing alerts
+func (e *Engine) Alerts() []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	alerts := make([]Alert, 0, len(e.alerts))
+	for _, a := range e.alerts {
+		alerts = append(alerts, *a)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].Rule != alerts[j].Rule {
+			return alerts[i].Rule < alerts[j].Rule
+		}
+		return alerts[i].Summary < alerts[j].Summary
+	})
+	return alerts
+}
+
+// Evaluate runs every rule once, updates the alerts and sends the notifications due
+func (e *Engine) Evaluate(ctx context.Context) {
+	e.evalMu.Lock()
+	defer e.evalMu.Unlock()
+
+	rules, err := e.Rules()
+	if err != nil {
+		log.Error("Failed to load alert rules", "error", err)
+		return
+	}
+	silences, err := e.Silences()
+	if err != nil {
+		log.Error("Failed to load silences", "error", err)
+	}
+
+	// Observe outside the lock: conditions call backends and dial TLS endpoints
+	observed := make(map[string][]observation, len(rules))
+	failed := make(map[string]bool)
+	for _, rule := range rules {
+		obs, err := e.observe(ctx, rule)
+		if err != nil {
+			log.Warn("Failed to evaluate alert rule", "rule", rule.Name, "error", err)
+			failed[rule.Name] = true
+			continue
+		}
+		observed[rule.Name] = obs
+	}
+
+	e.mu.Lock()
+	now := e.now()
+	seen := make(map[string]bool)
+	for _, rule := range rules {
+		if failed[rule.Name] {
+			// Keep the rule's alerts as they are until it can be evaluated again
+			for fp, a := range e.alerts {
+				if a.Rule == rule.Name {
+					seen[fp] = true
+				}
+			}
+			continue
+		}
+		for _, o := range observed[rule.Name] {
+			labels := alertLabels(rule, o)
+			fp := fingerprint(labels)
+			seen[fp] = true
+
+			a := e.alerts[fp]
+			if a == nil {
+				a = &Alert{Fingerprint: fp, Rule: rule.Name, State: StatePending, Labels: labels, ActiveSince: now}
+				e.alerts[fp] = a
+			}
+			a.Summary = o.summary
+			a.Value = o.value
+			a.group = e.groupFor(rule, labels)
+			if a.State == StatePending && now.Sub(a.ActiveSince) >= rule.For {
+				a.State = StateFiring
+				a.FiredAt = now
+				log.Info("Alert firing", "rule", rule.Name, "summary", a.Summary)
+			}
+		}
+	}
+
+	// Alerts whose condition no longer holds resolve, if they fired
+	for fp, a := range e.alerts {
+		if seen[fp] {
+			continue
+		}
+		delete(e.alerts, fp)
+		if a.State != StateFiring {
+			continue
+		}
+		a.State = StateResolved
+		a.ResolvedAt = now
+		log.Info("Alert resolved", "rule", a.Rule, "summary", a.Summary)
+		if g := e.groups[a.group]; g != nil {
+			g.resolved = append(g.resolved, *a)
+		}
+	}
+
+	for _, a := range e.alerts {
+		a.SilencedBy = silencedBy(silences, a.Labels, now)
+	}
+	pending := e.dueNotifications(silences, now)
+	e.mu.Unlock()
+
+	for _, p := range pending {
+		e.send(ctx, p)
+	}
+}
+
+// delivery is a notification due to a group's receivers
+type delivery struct {
+	receivers    []string
+	notification Notification
+}
+
+// dueNotifications works out which groups need notifying and marks them notified
+func (e *Engine) dueNotifications(silences []Silence, now time.Time) []delivery {
+	firingByGroup := make(map[string][]Alert)
+	live := make(map[string]bool)
+	for _, a := range e.alerts {
+		live[a.group] = true
+		if a.State == StateFiring && a.SilencedBy == "" {
+			firingByGroup[a.group] = append(firingByGroup[a.group], *a)
+		}
+	}
+
+	var due []delivery
+	for key, g := range e.groups {
+		firing := firingByGroup[key]
+		// Only alerts receivers were told about are notified as resolved
+		var resolved []Alert
+		for _, a := range g.resolved {
+			if g.notified[a.Fingerprint] && silencedBy(silences, a.Labels, now) == "" {
+				resolved = append(resolved, a)
+			}
+		}
+		g.resolved = nil
+
+		notify := len(resolved) > 0
+		for _, a := range firing {
+			if !g.notified[a.Fingerprint] {
+				notify = true
+			}
+		}
+		if len(firing) > 0 && now.Sub(g.lastNotified) >= g.repeat {
+			notify = true
+		}
+		if !notify {
+			if !live[key] {
+				delete(e.groups, key)
+			}
+			continue
+		}
+
+		alerts := append(firing, resolved...)
+		sort.Slice(alerts, func(i, j int) bool { return alerts[i].Summary < alerts[j].Summary })
+		status := StateResolved
+		if len(firing) > 0 {
+			status = StateFiring
+		}
+		due = append(due, delivery{
+			receivers: g.receivers,
+			notification: Notification{
+				Group:       key,
+				Status:      status,
+				GroupLabels: g.labels,
+				Alerts:      alerts,
+			},
+		})
+
+		// Failed deliveries aren't retried until the next repeat, so one
+		// broken receiver doesn't flood the others
+		g.notified = make(map[string]bool, len(firing))
+		for _, a := range firing {
+			g.notified[a.Fingerprint] = true
+		}
+		g.lastNotified = now
+	}
+	return due
+}
+
+func (e *Engine) send(ctx context.Context, d delivery) {
+	for _, name := range d.receivers {
+		notifier, ok := e.receivers[name]
+		if !ok {
+			continue
+		}
+		nctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+		err := notifier.Notify(nctx, d.notification)
+		cancel()
+		if err != nil {
+			log.Error("Failed to send alert notification", "receiver", name, "group", d.notification.Group, "error", err)
+			continue
+		}
+		log.Info("Alert notification sent", "receiver", name, "group", d.notification.Group, "status", d.notification.Status, "alerts", len(d.notification.Alerts))
+	}
+}
+
+// groupFor returns the key of the group an alert of rule belongs to, creating the group
+func (e *Engine) groupFor(rule Rule, labels map[string]string) string {
+	groupLabels := map[string]string{"alertname": rule.Name}
+	for _, name := range rule.GroupBy {
+		groupLabels[name] = labels[name]
+	}
+	key := labelString(groupLabels)
+
+	g := e.groups[key]
+	if g == nil {
+		g = &group{labels: groupLabels, notified: make(map[string]bool)}
+		e.groups[key] = g
+	}
+	// The rule may have changed since the group was created
+	g.receivers = rule.Receivers
+	if len(g.receivers) == 0 {
+		g.receivers = e.Receivers()
+	}
+	g.repeat = rule.RepeatInterval
+	if g.repeat <= 0 {
+		g.repeat = e.repeat
+	}
+	return key
+}
+
+// alertLabels combines a rule's labels with what an observation is about
+func alertLabels(rule Rule, o observation) map[string]string {
+	labels := make(map[string]string, len(rule.Labels)+len(o.labels)+2)
+	for k, v := range rule.Labels {
+		labels[k] = v
+	}
+	for k, v := range o.labels {
+		labels[k] = v
+	}
+	labels["alertname"] = rule.Name
+	labels["severity"] = rule.Severity
+	return labels
+}
+
+// silencedBy returns the ID of the first active silence matching labels
+func silencedBy(silences []Silence, labels map[string]string, now time.Time) string {
+	for _, s := range silences {
+		if s.Active(now) && s.Matches(labels) {
+			return s.ID
+		}
+	}
+	return ""
+}
+
+// labelString renders labels sorted by name, e.g. {alertname="a",service="web"}
+func labelString(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name + "=" + `"` + labels[name] + `"`)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func fingerprint(labels map[string]string) string {
+	hash := sha256.Sum256([]byte(labelString(labels)))
+	return hex.EncodeToString(hash[:8])
+}
@@ -0,0 +1,190 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/config"
+)
+
+// notifyTimeout bounds how long a receiver has to accept a notification
+const notifyTimeout = 10 * time.Second
+
+// Notification is what receivers are sent about a group of alerts: the ones
+// firing and the ones that resolved since the last notification
+type Notification struct {
+	Group       string            `json:"group"`
+	Status      string            `json:"status"` // firing if any alert fires, otherwise resolved
+	GroupLabels map[string]string `json:"group_labels"`
+	Alerts      []Alert           `json:"alerts"`
+}
+
+// Title summarizes a notification in one line
+func (n Notification) Title() string {
+	firing := 0
+	for _, a := range n.Alerts {
+		if a.State == StateFiring {
+			firing++
+		}
+	}
+	keys := make([]string, 0, len(n.GroupLabels))
+	for k := range n.GroupLabels {
+		if k != "alertname" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	title := n.GroupLabels["alertname"]
+	for _, k := range keys {
+		title += " " + k + "=" + n.GroupLabels[k]
+	}
+	if firing > 0 {
+		return fmt.Sprintf("[FIRING:%d] %s", firing, title)
+	}
+	return fmt.Sprintf("[RESOLVED] %s", title)
+}
+
+// Text lists the alerts of a notification, one per line
+func (n Notification) Text() string {
+	var b strings.Builder
+	for _, a := range n.Alerts {
+		fmt.Fprintf(&b, "%s: %s\n", strings.ToUpper(a.State), a.Summary)
+	}
+	return b.String()
+}
+
+// Notifier delivers notifications to a receiver
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// NewNotifier creates the notifier of a receiver in the daemon config
+func NewNotifier(cfg config.ReceiverConfig) (Notifier, error) {
+	switch cfg.Type {
+	case config.ReceiverWebhook:
+		return &WebhookNotifier{URL: cfg.URL, Headers: cfg.Headers}, nil
+	case config.ReceiverSlack:
+		return &SlackNotifier{URL: cfg.URL, Channel: cfg.Channel}, nil
+	case config.ReceiverEmail:
+		return &EmailNotifier{Addr: cfg.SMTPAddr, Username: cfg.Username, Password: cfg.Password, From: cfg.From, To: cfg.To}, nil
+	default:
+		return nil, fmt.Errorf("unknown receiver type %q", cfg.Type)
+	}
+}
+
+// WebhookNotifier POSTs notifications as JSON
+type WebhookNotifier struct {
+	URL     string
+	Headers map[string]string
+	Client  *http.Client // defaults to http.DefaultClient
+}
+
+// Notify implements Notifier
+func (w *WebhookNotifier) Notify(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, w.Client, w.URL, w.Headers, body)
+}
+
+// SlackNotifier posts notifications to a Slack incoming webhook, or anything
+// that accepts the same payload (Mattermost, Rocket.Chat, Discord's /slack endpoint)
+type SlackNotifier struct {
+	URL     string
+	Channel string // overrides the webhook's channel, if set
+	Client  *http.Client
+}
+
+type slackMessage struct {
+	Channel string `json:"channel,omitempty"`
+	Text    string `json:"text"`
+}
+
+// Notify implements Notifier
+func (s *SlackNotifier) Notify(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(slackMessage{
+		Channel: s.Channel,
+		Text:    "*" + n.Title() + "*\n" + n.Text(),
+	})
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, s.Client, s.URL, nil, body)
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body []byte) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s returned %s: %s", url, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// EmailNotifier mails notifications through an SMTP server. It upgrades to
+// TLS when the server offers STARTTLS; PLAIN auth needs TLS unless the server
+// is on localhost.
+type EmailNotifier struct {
+	Addr     string // host:port
+	Username string // empty sends without auth
+	Password string
+	From     string
+	To       []string
+}
+
+// Notify implements Notifier
+func (e *EmailNotifier) Notify(ctx context.Context, n Notification) error {
+	var auth smtp.Auth
+	if e.Username != "" {
+		host, _, err := net.SplitHostPort(e.Addr)
+		if err != nil {
+			return fmt.Errorf("invalid SMTP address %q: %w", e.Addr, err)
+		}
+		auth = smtp.PlainAuth("", e.Username, e.Password, host)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", e.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", n.Title())
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(n.Text(), "\n", "\r\n"))
+
+	// smtp.SendMail takes no context; give up on it when ctx is done
+	done := make(chan error, 1)
+	go func() { done <- smtp.SendMail(e.Addr, auth, e.From, e.To, msg.Bytes()) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,178 @@
+// Package alerting evaluates alert rules against the clusters a manager runs
+// and notifies receivers of alerts that start and stop firing. Rules and
+// silences are kept in the state store; receivers come from the daemon config,
+// as they hold credentials.
+package alerting
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// Rule conditions
+const (
+	// ConditionReplicasBelow fires for services running fewer replicas than desired
+	ConditionReplicasBelow = "replicas_below"
+	// ConditionNodeDown fires for nodes that aren't ready or whose agent stopped sending heartbeats
+	ConditionNodeDown = "node_down"
+	// ConditionCPUAbove fires for services whose containers on a node average
+	// more CPU than the threshold, in percent of a core
+	ConditionCPUAbove = "cpu_above"
+	// ConditionDeployFailed fires for services whose last deploy failed
+	ConditionDeployFailed = "deploy_failed"
+	// ConditionCertExpiry fires when the certificate of a TLS endpoint or PEM
+	// file expires within the rule's window, or can't be read
+	ConditionCertExpiry = "cert_expiry"
+)
+
+// Conditions lists the rule conditions
+var Conditions = []string{
+	ConditionReplicasBelow,
+	ConditionNodeDown,
+	ConditionCPUAbove,
+	ConditionDeployFailed,
+	ConditionCertExpiry,
+}
+
+// Alert severities
+const (
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// DefaultCertExpiryWindow is how long before expiry certificates alert unless set otherwise
+const DefaultCertExpiryWindow = 14 * 24 * time.Hour
+
+const ruleKeyPrefix = "alert-rule:"
+
+var ruleNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// ErrUnknownRule is returned for rules that don't exist
+var ErrUnknownRule = errors.New("unknown alert rule")
+
+// Rule describes when an alert fires. A rule can produce several alerts, e.g.
+// one per service below its replica count.
+type Rule struct {
+	Name      string            `json:"name"`
+	Condition string            `json:"condition"`
+	Cluster   string            `json:"cluster,omitempty"`   // empty for all clusters
+	Service   string            `json:"service,omitempty"`   // only this service
+	Threshold float64           `json:"threshold,omitempty"` // cpu_above: CPU percent
+	Target    string            `json:"target,omitempty"`    // cert_expiry: host:port or PEM file
+	Within    time.Duration     `json:"within,omitempty"`    // cert_expiry: how long before expiry to fire
+	For       time.Duration     `json:"for,omitempty"`       // how long the condition must hold before firing
+	Severity  string            `json:"severity"`            // warning or critical
+	Labels    map[string]string `json:"labels,omitempty"`
+
+	// GroupBy lists the labels whose alerts are notified together; alerts of
+	// a rule are always grouped by rule
+	GroupBy []string `json:"group_by,omitempty"`
+	// RepeatInterval is how long before a still-firing group is notified
+	// again; zero uses the engine's default
+	RepeatInterval time.Duration `json:"repeat_interval,omitempty"`
+	// Receivers notified of the rule's alerts; empty notifies all receivers
+	Receivers []string `json:"receivers,omitempty"`
+
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+}
+
+// validate checks a rule and fills in defaults
+func (r *Rule) validate() error {
+	if !ruleNamePattern.MatchString(r.Name) {
+		return fmt.Errorf("invalid rule name %q (letters, digits, '_', '.' and '-')", r.Name)
+	}
+	switch r.Condition {
+	case ConditionReplicasBelow, ConditionNodeDown, ConditionDeployFailed:
+	case ConditionCPUAbove:
+		if r.Threshold <= 0 {
+			return fmt.Errorf("condition %s needs a threshold above 0", r.Condition)
+		}
+	case ConditionCertExpiry:
+		if r.Target == "" {
+			return fmt.Errorf("condition %s needs a target (host:port or PEM file)", r.Condition)
+		}
+		if r.Within <= 0 {
+			r.Within = DefaultCertExpiryWindow
+		}
+	default:
+		return fmt.Errorf("unknown condition %q (expected one of %v)", r.Condition, Conditions)
+	}
+	if r.Severity == "" {
+		r.Severity = SeverityWarning
+	}
+	if r.Severity != SeverityWarning && r.Severity != SeverityCritical {
+		return fmt.Errorf("invalid severity %q (expected warning or critical)", r.Severity)
+	}
+	for _, name := range r.GroupBy {
+		if !labelNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid group_by label %q", name)
+		}
+	}
+	for name := range r.Labels {
+		if !labelNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid label name %q", name)
+		}
+	}
+	if r.For < 0 || r.RepeatInterval < 0 {
+		return fmt.Errorf("durations must not be negative")
+	}
+	return nil
+}
+
+// SetRule creates or replaces a rule
+func (e *Engine) SetRule(rule Rule) (Rule, error) {
+	if err := rule.validate(); err != nil {
+		return Rule{}, err
+	}
+	for _, name := range rule.Receivers {
+		if _, ok := e.receivers[name]; !ok {
+			return Rule{}, fmt.Errorf("unknown receiver %q", name)
+		}
+	}
+
+	rule.Updated = time.Now()
+	rule.Created = rule.Updated
+	var existing Rule
+	if e.store.Get(ruleKeyPrefix+rule.Name, &existing) == nil {
+		rule.Created = existing.Created
+	}
+	if err := e.store.Set(ruleKeyPrefix+rule.Name, rule); err != nil {
+		return Rule{}, fmt.Errorf("failed to store alert rule: %w", err)
+	}
+	return rule, nil
+}
+
+// DeleteRule removes a rule. Its alerts resolve on the next evaluation.
+func (e *Engine) DeleteRule(name string) error {
+	var rule Rule
+	if err := e.store.Get(ruleKeyPrefix+name, &rule); err != nil {
+		return fmt.Errorf("%w: %s", ErrUnknownRule, name)
+	}
+	if err := e.store.Delete(ruleKeyPrefix + name); err != nil {
+		return fmt.Errorf("failed to delete alert rule: %w", err)
+	}
+	return nil
+}
+
+// Rules returns the rules sorted by name
+func (e *Engine) Rules() ([]Rule, error) {
+	keys, err := e.store.List(ruleKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]Rule, 0, len(keys))
+	for _, key := range keys {
+		var rule Rule
+		if err := e.store.Get(key, &rule); err != nil {
+			continue // Skip invalid entries
+		}
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	return rules, nil
+}
@@ -0,0 +1,169 @@
+package alerting
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/log"
+)
+
+// ExpiredSilenceRetention is how long expired silences are still listed
+const ExpiredSilenceRetention = 24 * time.Hour
+
+const silenceKeyPrefix = "silence:"
+
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// ErrUnknownSilence is returned for silences that don't exist
+var ErrUnknownSilence = errors.New("unknown silence")
+
+// Matcher selects alerts by a label, e.g. service=web or severity!=critical
+type Matcher struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Negate bool   `json:"negate,omitempty"`
+}
+
+// ParseMatcher parses a name=value or name!=value matcher
+func ParseMatcher(s string) (Matcher, error) {
+	m := Matcher{}
+	name, value, ok := strings.Cut(s, "!=")
+	if ok {
+		m.Negate = true
+	} else if name, value, ok = strings.Cut(s, "="); !ok {
+		return Matcher{}, fmt.Errorf("invalid matcher %q (expected name=value or name!=value)", s)
+	}
+	m.Name = strings.TrimSpace(name)
+	m.Value = strings.TrimSpace(value)
+	if !labelNamePattern.MatchString(m.Name) {
+		return Matcher{}, fmt.Errorf("invalid label name %q in matcher %q", m.Name, s)
+	}
+	return m, nil
+}
+
+// Matches reports whether labels satisfy the matcher. A missing label has the empty value.
+func (m Matcher) Matches(labels map[string]string) bool {
+	return (labels[m.Name] == m.Value) != m.Negate
+}
+
+func (m Matcher) String() string {
+	if m.Negate {
+		return m.Name + "!=" + m.Value
+	}
+	return m.Name + "=" + m.Value
+}
+
+// Silence mutes the notifications of alerts matching all of its matchers
+// until it ends. Silenced alerts are still evaluated and listed.
+type Silence struct {
+	ID        string    `json:"id"`
+	Matchers  []Matcher `json:"matchers"`
+	Comment   string    `json:"comment"`
+	CreatedBy string    `json:"created_by"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+}
+
+// Active reports whether the silence mutes alerts at the given time
+func (s Silence) Active(now time.Time) bool {
+	return !now.Before(s.StartsAt) && now.Before(s.EndsAt)
+}
+
+// Matches reports whether the silence applies to an alert's labels
+func (s Silence) Matches(labels map[string]string) bool {
+	for _, m := range s.Matchers {
+		if !m.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// CreateSilence silences the alerts matching all matchers for the given duration
+func (e *Engine) CreateSilence(matchers []Matcher, duration time.Duration, comment, createdBy string) (Silence, error) {
+	if len(matchers) == 0 {
+		return Silence{}, fmt.Errorf("a silence needs at least one matcher")
+	}
+	if duration <= 0 {
+		return Silence{}, fmt.Errorf("a silence needs a duration")
+	}
+
+	// IDs are short so they're easy to expire; regenerate the rare duplicate
+	var id string
+	for {
+		bytes := make([]byte, 4)
+		if _, err := rand.Read(bytes); err != nil {
+			return Silence{}, fmt.Errorf("failed to generate silence ID: %w", err)
+		}
+		id = hex.EncodeToString(bytes)
+		var existing Silence
+		if e.store.Get(silenceKeyPrefix+id, &existing) != nil {
+			break
+		}
+	}
+
+	now := e.now()
+	silence := Silence{
+		ID:        id,
+		Matchers:  matchers,
+		Comment:   comment,
+		CreatedBy: createdBy,
+		StartsAt:  now,
+		EndsAt:    now.Add(duration),
+	}
+	if err := e.store.Set(silenceKeyPrefix+id, silence); err != nil {
+		return Silence{}, fmt.Errorf("failed to store silence: %w", err)
+	}
+
+	log.Info("Silence created", "id", id, "matchers", matchers, "ends", silence.EndsAt, "by", createdBy)
+	return silence, nil
+}
+
+// ExpireSilence ends a silence now
+func (e *Engine) ExpireSilence(id string) error {
+	var silence Silence
+	if err := e.store.Get(silenceKeyPrefix+id, &silence); err != nil {
+		return fmt.Errorf("%w: %s", ErrUnknownSilence, id)
+	}
+	now := e.now()
+	if silence.EndsAt.After(now) {
+		silence.EndsAt = now
+	}
+	if err := e.store.Set(silenceKeyPrefix+id, silence); err != nil {
+		return fmt.Errorf("failed to expire silence: %w", err)
+	}
+
+	log.Info("Silence expired", "id", id)
+	return nil
+}
+
+// Silences returns the silences, newest first, removing the ones that ended
+// more than a day ago
+func (e *Engine) Silences() ([]Silence, error) {
+	keys, err := e.store.List(silenceKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := e.now().Add(-ExpiredSilenceRetention)
+	silences := make([]Silence, 0, len(keys))
+	for _, key := range keys {
+		var silence Silence
+		if err := e.store.Get(key, &silence); err != nil {
+			continue // Skip invalid entries
+		}
+		if silence.EndsAt.Before(cutoff) {
+			e.store.Delete(key)
+			continue
+		}
+		silences = append(silences, silence)
+	}
+	sort.Slice(silences, func(i, j int) bool { return silences[i].StartsAt.After(silences[j].StartsAt) })
+	return silences, nil
+}
@@ -0,0 +1,304 @@
+// Package audit keeps an append-only log of the operations that change the
+// platform: deploys, rollbacks, scaling, node operations, user and token
+// management, logins and what the reconcilers do on their own. Entries are
+// written to the state store under keys that sort by time and are never
+// updated or deleted.
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/log"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/manager"
+	"github.com/jasonlovesdoggo/velo/internal/state"
+)
+
+// Sources of entries
+const (
+	SourceWeb        = "web"        // the web UI and its API
+	SourceGRPC       = "grpc"       // the gRPC API, e.g. veloctl
+	SourceWebhook    = "webhook"    // incoming deploy hooks
+	SourceReconciler = "reconciler" // backends acting on their own
+)
+
+// Results of entries
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+	ResultDenied  = "denied" // refused for lack of permission
+)
+
+const (
+	entryKeyPrefix = "audit:"
+	redacted       = "[REDACTED]"
+)
+
+// Entry records one operation
+type Entry struct {
+	ID      string            `json:"id"`
+	Time    time.Time         `json:"time"`
+	Actor   string            `json:"actor,omitempty"` // user, empty when unknown
+	Source  string            `json:"source"`
+	IP      string            `json:"ip,omitempty"`
+	Action  string            `json:"action"`           // e.g. Deploy, Scale or Login
+	Target  string            `json:"target,omitempty"` // service, node, user or token acted on
+	Cluster string            `json:"cluster,omitempty"`
+	Params  map[string]string `json:"params,omitempty"` // secrets are redacted
+	Result  string            `json:"result"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// Filter selects entries. Empty fields match every entry.
+type Filter struct {
+	Actor  string
+	Source string
+	Action string // case-insensitive
+	Target string
+	Result string
+	Since  time.Time
+	Until  time.Time
+	Limit  int // 0 returns every matching entry
+}
+
+// Matches reports whether the filter selects an entry
+func (f Filter) Matches(e Entry) bool {
+	switch {
+	case f.Actor != "" && f.Actor != e.Actor,
+		f.Source != "" && f.Source != e.Source,
+		f.Action != "" && !strings.EqualFold(f.Action, e.Action),
+		f.Target != "" && f.Target != e.Target,
+		f.Result != "" && f.Result != e.Result,
+		!f.Since.IsZero() && e.Time.Before(f.Since),
+		!f.Until.IsZero() && e.Time.After(f.Until):
+		return false
+	}
+	return true
+}
+
+// Log is the audit log. A nil log records nothing.
+type Log struct {
+	store state.StateStore
+	now   func() time.Time
+}
+
+// New creates an audit log kept in store
+func New(store state.StateStore) *Log {
+	return &Log{store: store, now: time.Now}
+}
+
+// Record appends an entry, filling in its ID and time and redacting its
+// parameters. Failures are logged; they never fail the operation.
+func (l *Log) Record(e Entry) {
+	if l == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = l.now()
+	}
+	if e.Result == "" {
+		e.Result = ResultSuccess
+	}
+	e.Params = Redact(e.Params)
+
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	e.ID = fmt.Sprintf("%020d-%s", e.Time.UnixNano(), hex.EncodeToString(suffix))
+	if err := l.store.Set(entryKeyPrefix+e.ID, e); err != nil {
+		log.Error("Failed to record audit entry", "action", e.Action, "actor", e.Actor, "error", err)
+	}
+}
+
+// Query returns the entries a filter selects, newest first
+func (l *Log) Query(f Filter) ([]Entry, error) {
+	if l == nil {
+		return nil, nil
+	}
+	keys, err := l.store.List(entryKeyPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+
+	entries := []Entry{}
+	for _, key := range keys {
+		// Keys start with the entry's time, so entries outside the range
+		// aren't read
+		if t, ok := keyTime(key); ok {
+			if !f.Until.IsZero() && t.After(f.Until) {
+				continue
+			}
+			if !f.Since.IsZero() && t.Before(f.Since) {
+				break
+			}
+		}
+		var e Entry
+		if err := l.store.Get(key, &e); err != nil {
+			continue // Skip invalid entries
+		}
+		if !f.Matches(e) {
+			continue
+		}
+		entries = append(entries, e)
+		if f.Limit > 0 && len(entries) == f.Limit {
+			break
+		}
+	}
+	return entries, nil
+}
+
+// keyTime returns the time an entry's key starts with
+func keyTime(key string) (time.Time, bool) {
+	stamp, _, _ := strings.Cut(strings.TrimPrefix(key, entryKeyPrefix), "-")
+	nanos, err := strconv.ParseInt(stamp, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, nanos), true
+}
+
+// WriteJSONL writes entries as JSON lines, one entry per line
+func WriteJSONL(w io.Writer, entries []Entry) error {
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sensitive are the parts of parameter names whose values are redacted
+var sensitive = []string{"password", "passwd", "secret", "token", "apikey", "api_key", "private_key", "credential"}
+
+// environment are the names of parameters holding environment variables. Any
+// of their values may be a secret, like a password in DATABASE_URL, so only
+// the variables' names are kept.
+var environment = []string{"env", "environment"}
+
+// isEnvironment reports whether a parameter holds environment variables
+func isEnvironment(name string) bool {
+	return slices.Contains(environment, strings.ToLower(name))
+}
+
+// IsSensitive reports whether a parameter, or environment variable, holds a secret
+func IsSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range sensitive {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns a copy of params with the values of sensitive parameters
+// replaced. Parameters holding JSON objects have the values of their sensitive
+// keys replaced, and environments have all their values replaced.
+func Redact(params map[string]string) map[string]string {
+	if len(params) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(params))
+	for name, value := range params {
+		switch {
+		case IsSensitive(name):
+			out[name] = redacted
+		case strings.HasPrefix(value, "{"):
+			var nested map[string]any
+			if json.Unmarshal([]byte(value), &nested) != nil {
+				out[name] = value
+				continue
+			}
+			if isEnvironment(name) {
+				data, _ := json.Marshal(redactAll(nested))
+				out[name] = string(data)
+				continue
+			}
+			data, _ := json.Marshal(redactValue(nested))
+			out[name] = string(data)
+		default:
+			out[name] = value
+		}
+	}
+	return out
+}
+
+func redactValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if nested, ok := value.(map[string]any); ok && isEnvironment(key) {
+				v[key] = redactAll(nested)
+			} else if IsSensitive(key) {
+				v[key] = redacted
+			} else {
+				v[key] = redactValue(value)
+			}
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = redactValue(value)
+		}
+	}
+	return v
+}
+
+// redactAll replaces every value of an object, keeping its keys
+func redactAll(v map[string]any) map[string]any {
+	for key := range v {
+		v[key] = redacted
+	}
+	return v
+}
+
+// Reconciled returns a function recording the changes a cluster's backend
+// makes on its own, for manager.Reconciler
+func (l *Log) Reconciled(cluster string) func(manager.ReconcileAction) {
+	return func(a manager.ReconcileAction) {
+		l.Record(Entry{
+			Source:  SourceReconciler,
+			Action:  a.Action,
+			Target:  a.Service,
+			Cluster: cluster,
+			Params:  a.Params,
+		})
+	}
+}
+
+type entryContextKey struct{}
+
+// WithEntry returns a copy of ctx carrying an entry being recorded, for the
+// handler of the operation to fill in
+func WithEntry(ctx context.Context, e *Entry) context.Context {
+	return context.WithValue(ctx, entryContextKey{}, e)
+}
+
+// FromContext returns the entry carried by ctx. Without one it returns an
+// entry that's never recorded, so handlers don't need to check.
+func FromContext(ctx context.Context) *Entry {
+	if e, ok := ctx.Value(entryContextKey{}).(*Entry); ok && e != nil {
+		return e
+	}
+	return &Entry{}
+}
+
+// ClientIP returns the IP address an HTTP request came from
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,149 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+
+	"github.com/jasonlovesdoggo/velo/api/proto"
+	"github.com/jasonlovesdoggo/velo/internal/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// unaudited are the services whose calls aren't recorded: agents reporting
+// in, and reading the audit log itself
+var unaudited = []string{"/velo.AgentService/", "/velo.AuditService/"}
+
+// targetFields are the request fields naming what a call acts on, in order of preference
+var targetFields = []string{"service_name", "deployment_id", "node_id", "node", "hostname", "username", "name", "id", "webhook", "url"}
+
+// Audited reports whether calls of a gRPC method are recorded. Calls that only
+// read, Get* and List*, aren't.
+func Audited(fullMethod string) bool {
+	for _, prefix := range unaudited {
+		if strings.HasPrefix(fullMethod, prefix) {
+			return false
+		}
+	}
+	method := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	return !strings.HasPrefix(method, "Get") && !strings.HasPrefix(method, "List")
+}
+
+// UnaryServerInterceptor records the unary calls that change something
+func (l *Log) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if l != nil && Audited(info.FullMethod) {
+		l.Record(grpcEntry(ctx, info.FullMethod, req, resp, err))
+	}
+	return resp, err
+}
+
+// StreamServerInterceptor records the streaming calls that change something,
+// with the request their client sent first
+func (l *Log) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if l == nil || !Audited(info.FullMethod) {
+		return handler(srv, ss)
+	}
+	stream := &recordingStream{ServerStream: ss}
+	err := handler(srv, stream)
+	l.Record(grpcEntry(ss.Context(), info.FullMethod, stream.req, nil, err))
+	return err
+}
+
+// recordingStream keeps the first message received on a stream
+type recordingStream struct {
+	grpc.ServerStream
+	req interface{}
+}
+
+func (s *recordingStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil && s.req == nil {
+		s.req = m
+	}
+	return err
+}
+
+// grpcEntry describes a finished gRPC call
+func grpcEntry(ctx context.Context, fullMethod string, req, resp interface{}, err error) Entry {
+	e := Entry{
+		Actor:  auth.Username(ctx),
+		Source: SourceGRPC,
+		Action: fullMethod[strings.LastIndex(fullMethod, "/")+1:],
+		Params: requestParams(req),
+		Result: ResultSuccess,
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		e.IP = p.Addr.String()
+		if host, _, splitErr := net.SplitHostPort(e.IP); splitErr == nil {
+			e.IP = host
+		}
+	}
+	for _, field := range targetFields {
+		if e.Params[field] != "" {
+			e.Target = e.Params[field]
+			break
+		}
+	}
+	e.Cluster = e.Params["cluster"]
+	if e.Actor == "" && e.Action == "Login" {
+		// Whoever logs in is the actor, as for logins to the web UI
+		e.Actor = e.Params["username"]
+	}
+
+	switch {
+	case err != nil:
+		e.Result, e.Error = ResultFailure, err.Error()
+		if s, ok := status.FromError(err); ok {
+			e.Error = s.Message()
+			if s.Code() == codes.PermissionDenied {
+				e.Result = ResultDenied
+			}
+		}
+	default:
+		// Some calls report failure in their response
+		if generic, ok := resp.(*proto.GenericResponse); ok && !generic.Success {
+			e.Result, e.Error = ResultFailure, generic.Message
+		}
+	}
+	return e
+}
+
+// requestParams flattens a request into parameters. Fields that aren't strings
+// are kept as JSON.
+func requestParams(req interface{}) map[string]string {
+	msg, ok := req.(protobuf.Message)
+	if !ok || msg == nil {
+		return nil
+	}
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+
+	params := make(map[string]string, len(fields))
+	for name, raw := range fields {
+		var s string
+		var compact bytes.Buffer
+		switch {
+		case json.Unmarshal(raw, &s) == nil:
+			params[name] = s
+		case json.Compact(&compact, raw) == nil:
+			params[name] = compact.String()
+		default:
+			params[name] = string(raw)
+		}
+	}
+	return params
+}
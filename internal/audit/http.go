@@ -0,0 +1,100 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/auth"
+)
+
+// maxErrorBody is how much of an error response is kept as the entry's error
+const maxErrorBody = 1024
+
+// Handler records the requests a handler serves as action. The handler fills
+// in the target and parameters through FromContext. The result comes from the
+// response status unless the handler sets it.
+func (l *Log) Handler(action string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if l == nil {
+			next(w, r)
+			return
+		}
+
+		e := &Entry{
+			Actor:  auth.Username(r.Context()),
+			Source: SourceWeb,
+			IP:     ClientIP(r),
+			Action: action,
+		}
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r.WithContext(WithEntry(r.Context(), e)))
+
+		if e.Result == "" {
+			switch {
+			case rec.status == http.StatusForbidden:
+				e.Result = ResultDenied
+			case rec.status >= http.StatusBadRequest:
+				e.Result = ResultFailure
+			default:
+				e.Result = ResultSuccess
+			}
+		}
+		if e.Error == "" && rec.status >= http.StatusBadRequest {
+			e.Error = rec.errorMessage()
+		}
+		l.Record(*e)
+	}
+}
+
+// statusRecorder keeps the status of a response, and the start of its body
+// if it's an error
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(data []byte) (int, error) {
+	if r.status >= http.StatusBadRequest && r.body.Len() < maxErrorBody {
+		r.body.Write(data[:min(len(data), maxErrorBody-r.body.Len())])
+	}
+	return r.ResponseWriter.Write(data)
+}
+
+// errorMessage returns the error of a JSON error response, or the text of another
+func (r *statusRecorder) errorMessage() string {
+	var resp struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(r.body.Bytes(), &resp) == nil && resp.Error != "" {
+		return resp.Error
+	}
+	if msg := strings.TrimSpace(r.body.String()); msg != "" {
+		return msg
+	}
+	return http.StatusText(r.status)
+}
+
+// ParseTime parses the bounds of a query: a duration before now like 24h, a
+// date like 2006-01-02 or an RFC 3339 time
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (expected a duration like 24h, a date or an RFC 3339 time)", value)
+}
@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/log"
+)
+
+const (
+	agentTokenPrefix    = "velo-agent-"
+	agentTokenKeyPrefix = "agenttoken:"
+)
+
+// ErrAgentTokenBound is returned for agent tokens used for another node than
+// the one they're bound to, and for nodes another token is bound to
+var ErrAgentTokenBound = errors.New("agent token belongs to another node")
+
+// AgentToken authenticates the agent of one node. Tokens handed out when a node
+// joins only know the hostname it joined with until its agent first registers,
+// which binds them to the node's ID. Only a hash of the secret is stored.
+type AgentToken struct {
+	ID         string    `json:"id"`
+	SecretHash string    `json:"secret_hash"`
+	Cluster    string    `json:"cluster"`
+	NodeID     string    `json:"node_id"`
+	Hostname   string    `json:"hostname,omitempty"` // the node joined with, until it's bound
+	Created    time.Time `json:"created"`
+}
+
+// CreateAgentToken mints the agent token of a node and returns it with its
+// secret. Either the node's ID or, for nodes that are only joining, the
+// hostname it joins with is required. A token for a node ID replaces the
+// node's previous token.
+func (a *AuthService) CreateAgentToken(cluster, nodeID, hostname string) (string, *AgentToken, error) {
+	if nodeID == "" && hostname == "" {
+		return "", nil, errors.New("a node ID or hostname is required")
+	}
+	id, err := randomHex(8)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate token ID: %w", err)
+	}
+	secret, err := randomHex(24)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate token secret: %w", err)
+	}
+
+	token := &AgentToken{
+		ID:         id,
+		SecretHash: hashSecret(secret),
+		Cluster:    cluster,
+		NodeID:     nodeID,
+		Hostname:   hostname,
+		Created:    time.Now(),
+	}
+
+	a.agentMu.Lock()
+	defer a.agentMu.Unlock()
+	if nodeID != "" {
+		previous, err := a.agentTokensOf(cluster, nodeID)
+		if err != nil {
+			return "", nil, err
+		}
+		for _, key := range previous {
+			if err := a.store.Delete(key); err != nil {
+				return "", nil, fmt.Errorf("failed to revoke agent token: %w", err)
+			}
+		}
+	}
+	if err := a.store.Set(agentTokenKeyPrefix+id, token); err != nil {
+		return "", nil, fmt.Errorf("failed to store agent token: %w", err)
+	}
+
+	log.Info("Agent token created", "id", id, "cluster", cluster, "node", nodeID, "hostname", hostname)
+	return agentTokenPrefix + id + "." + secret, token, nil
+}
+
+// ValidateAgentToken returns the agent token of a velo-agent-<id>.<secret> value
+func (a *AuthService) ValidateAgentToken(value string) (*AgentToken, error) {
+	rest, ok := strings.CutPrefix(value, agentTokenPrefix)
+	if !ok {
+		return nil, ErrTokenInvalid
+	}
+	id, secret, ok := strings.Cut(rest, ".")
+	if !ok || id == "" || secret == "" {
+		return nil, ErrTokenInvalid
+	}
+
+	var token AgentToken
+	if err := a.store.Get(agentTokenKeyPrefix+id, &token); err != nil {
+		return nil, ErrTokenInvalid
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(token.SecretHash)) != 1 {
+		return nil, ErrTokenInvalid
+	}
+	return &token, nil
+}
+
+// BindAgentToken binds an agent token that only knows a hostname to the ID of
+// its node. Tokens already bound to the node are returned as they are; those
+// bound to another node, or for a node another token is bound to, fail with
+// ErrAgentTokenBound.
+func (a *AuthService) BindAgentToken(id, nodeID string) (*AgentToken, error) {
+	a.agentMu.Lock()
+	defer a.agentMu.Unlock()
+
+	var token AgentToken
+	if err := a.store.Get(agentTokenKeyPrefix+id, &token); err != nil {
+		return nil, ErrTokenInvalid
+	}
+	if token.NodeID == nodeID {
+		return &token, nil
+	}
+	if token.NodeID != "" {
+		return nil, ErrAgentTokenBound
+	}
+
+	bound, err := a.agentTokensOf(token.Cluster, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	if len(bound) > 0 {
+		return nil, ErrAgentTokenBound
+	}
+
+	token.NodeID = nodeID
+	token.Hostname = ""
+	if err := a.store.Set(agentTokenKeyPrefix+id, token); err != nil {
+		return nil, fmt.Errorf("failed to bind agent token: %w", err)
+	}
+
+	log.Info("Agent token bound to node", "id", id, "cluster", token.Cluster, "node", nodeID)
+	return &token, nil
+}
+
+// ListAgentTokens returns the agent tokens of a cluster, oldest first
+func (a *AuthService) ListAgentTokens(cluster string) ([]AgentToken, error) {
+	keys, err := a.store.List(agentTokenKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []AgentToken
+	for _, key := range keys {
+		var token AgentToken
+		if err := a.store.Get(key, &token); err != nil {
+			continue // Skip invalid entries
+		}
+		if token.Cluster == cluster {
+			tokens = append(tokens, token)
+		}
+	}
+
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].Created.Before(tokens[j].Created) })
+	return tokens, nil
+}
+
+// RevokeAgentToken invalidates an agent token by its ID. The agent using it is
+// rejected from its next call on.
+func (a *AuthService) RevokeAgentToken(id string) error {
+	a.agentMu.Lock()
+	defer a.agentMu.Unlock()
+
+	var token AgentToken
+	if err := a.store.Get(agentTokenKeyPrefix+id, &token); err != nil {
+		return ErrTokenInvalid
+	}
+	if err := a.store.Delete(agentTokenKeyPrefix + id); err != nil {
+		return fmt.Errorf("failed to revoke agent token: %w", err)
+	}
+
+	log.Info("Agent token revoked", "id", id, "cluster", token.Cluster, "node", token.NodeID)
+	return nil
+}
+
+// agentTokensOf returns the keys of the agent tokens bound to a node
+func (a *AuthService) agentTokensOf(cluster, nodeID string) ([]string, error) {
+	keys, err := a.store.List(agentTokenKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	var bound []string
+	for _, key := range keys {
+		var token AgentToken
+		if a.store.Get(key, &token) == nil && token.Cluster == cluster && token.NodeID == nodeID {
+			bound = append(bound, key)
+		}
+	}
+	return bound, nil
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jasonlovesdoggo/velo/internal/state"
+)
+
+func TestAgentTokens(t *testing.T) {
+	a := NewAuthService(state.NewMemoryStateStore())
+
+	value, token, err := a.CreateAgentToken("prod", "", "box-1")
+	if err != nil {
+		t.Fatalf("CreateAgentToken failed: %v", err)
+	}
+	if !strings.HasPrefix(value, "velo-agent-"+token.ID+".") {
+		t.Fatalf("Unexpected token %q", value)
+	}
+	if got, err := a.ValidateAgentToken(value); err != nil || got.Hostname != "box-1" {
+		t.Fatalf("Expected the token to validate, got %+v (%v)", got, err)
+	}
+	for _, forged := range []string{"", value + "x", "velo-agent-" + token.ID, "velo-api-" + token.ID + ".secret"} {
+		if _, err := a.ValidateAgentToken(forged); !errors.Is(err, ErrTokenInvalid) {
+			t.Errorf("Expected %q to be rejected, got %v", forged, err)
+		}
+	}
+
+	// The first registration binds the token to its node
+	if _, err := a.BindAgentToken(token.ID, "node-1"); err != nil {
+		t.Fatalf("BindAgentToken failed: %v", err)
+	}
+	if _, err := a.BindAgentToken(token.ID, "node-1"); err != nil {
+		t.Errorf("Expected binding to the same node again to succeed, got %v", err)
+	}
+	if _, err := a.BindAgentToken(token.ID, "node-2"); !errors.Is(err, ErrAgentTokenBound) {
+		t.Errorf("Expected ErrAgentTokenBound for another node, got %v", err)
+	}
+	_, other, _ := a.CreateAgentToken("prod", "", "box-2")
+	if _, err := a.BindAgentToken(other.ID, "node-1"); !errors.Is(err, ErrAgentTokenBound) {
+		t.Errorf("Expected ErrAgentTokenBound for a node with a token, got %v", err)
+	}
+
+	// A new token for a node replaces its old one
+	if _, _, err := a.CreateAgentToken("prod", "node-1", ""); err != nil {
+		t.Fatalf("CreateAgentToken failed: %v", err)
+	}
+	if _, err := a.ValidateAgentToken(value); !errors.Is(err, ErrTokenInvalid) {
+		t.Errorf("Expected the replaced token to be rejected, got %v", err)
+	}
+}
+
+func TestAgentTokenListAndRevocation(t *testing.T) {
+	a := NewAuthService(state.NewMemoryStateStore())
+
+	value, token, _ := a.CreateAgentToken("prod", "node-1", "")
+	a.CreateAgentToken("prod", "", "box-2")
+	a.CreateAgentToken("staging", "node-3", "")
+
+	tokens, err := a.ListAgentTokens("prod")
+	if err != nil {
+		t.Fatalf("ListAgentTokens failed: %v", err)
+	}
+	if len(tokens) != 2 || tokens[0].ID != token.ID || tokens[1].Hostname != "box-2" {
+		t.Fatalf("Expected the 2 tokens of prod, got %+v", tokens)
+	}
+
+	if err := a.RevokeAgentToken(token.ID); err != nil {
+		t.Fatalf("RevokeAgentToken failed: %v", err)
+	}
+	if err := a.RevokeAgentToken(token.ID); !errors.Is(err, ErrTokenInvalid) {
+		t.Errorf("Expected revoking twice to fail, got %v", err)
+	}
+	if _, err := a.ValidateAgentToken(value); !errors.Is(err, ErrTokenInvalid) {
+		t.Errorf("Expected a revoked token to be rejected, got %v", err)
+	}
+	if tokens, _ := a.ListAgentTokens("prod"); len(tokens) != 1 {
+		t.Errorf("Expected 1 token left, got %d", len(tokens))
+	}
+}
@@ -0,0 +1,252 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/log"
+)
+
+// API token scopes. A token can do what its scopes allow and its owner's role
+// allows, whichever is less.
+const (
+	ScopeReadOnly = "read-only" // read services, nodes, metrics and alerts
+	ScopeDeploy   = "deploy"    // and deploy, scale and roll back any service
+	ScopeAdmin    = "admin"     // everything the owner may do
+
+	// scopeDeployPrefix starts scopes that deploy a single service, deploy:<service>
+	scopeDeployPrefix = "deploy:"
+)
+
+const (
+	apiTokenPrefix    = "velo-api-"
+	apiTokenKeyPrefix = "apitoken:"
+
+	// lastUsedInterval is how often the last use of a token is written down
+	lastUsedInterval = time.Minute
+)
+
+// APIToken is a long-lived token for scripts and CI, owned by a user or a
+// service account. Only a hash of the secret is stored.
+type APIToken struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	UserID     string    `json:"user_id"`
+	Scopes     []string  `json:"scopes"`
+	SecretHash string    `json:"secret_hash"`
+	Created    time.Time `json:"created"`
+	ExpiresAt  time.Time `json:"expires_at"` // zero for tokens that don't expire
+	LastUsed   time.Time `json:"last_used"`
+}
+
+// Expired reports whether the token can no longer be used
+func (t APIToken) Expired() bool {
+	return !t.ExpiresAt.IsZero() && time.Now().After(t.ExpiresAt)
+}
+
+// ValidScope returns an error unless a scope exists
+func ValidScope(scope string) error {
+	switch scope {
+	case ScopeReadOnly, ScopeDeploy, ScopeAdmin:
+		return nil
+	}
+	if service, ok := strings.CutPrefix(scope, scopeDeployPrefix); ok && service != "" {
+		return nil
+	}
+	return fmt.Errorf("unknown scope %q (expected %s, %s, %s<service> or %s)", scope, ScopeReadOnly, ScopeDeploy, scopeDeployPrefix, ScopeAdmin)
+}
+
+// scopeAllows reports whether a scope allows something on a resource.
+// Every scope reads; deploy:<service> deploys only that service.
+func scopeAllows(scope string, perm Permission, res Resource) bool {
+	switch {
+	case scope == ScopeAdmin:
+		return true
+	case perm == PermServicesRead || perm == PermClusterRead:
+		return true
+	case perm != PermServicesDeploy:
+		return false
+	case scope == ScopeDeploy:
+		return true
+	}
+	service, ok := strings.CutPrefix(scope, scopeDeployPrefix)
+	return ok && res.Service != "" && service == res.Service
+}
+
+// scopesAllow reports whether any of a token's scopes allows something on a
+// resource, or on some resource when some is set
+func scopesAllow(scopes []string, perm Permission, res Resource, some bool) bool {
+	for _, scope := range scopes {
+		if scopeAllows(scope, perm, res) {
+			return true
+		}
+		if some && perm == PermServicesDeploy && strings.HasPrefix(scope, scopeDeployPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAPIToken mints an API token named name for owner, or for creator when
+// owner is empty, and returns it with its secret. Only admins create tokens
+// for others, and only for service accounts. Tokens can't create tokens. The
+// secret is only available here; it can't be recovered later.
+func (a *AuthService) CreateAPIToken(creator *User, owner, name string, scopes []string, ttl time.Duration) (string, *APIToken, error) {
+	if creator.Scopes != nil {
+		return "", nil, fmt.Errorf("%w: API tokens can't create tokens, log in instead", ErrPermissionDenied)
+	}
+	if name == "" {
+		return "", nil, errors.New("a token needs a name")
+	}
+	if len(scopes) == 0 {
+		return "", nil, errors.New("a token needs at least one scope")
+	}
+	for _, scope := range scopes {
+		if err := ValidScope(scope); err != nil {
+			return "", nil, err
+		}
+	}
+	if ttl < 0 {
+		return "", nil, errors.New("a token's lifetime can't be negative")
+	}
+
+	user := creator
+	if owner != "" && owner != creator.Username {
+		var err error
+		if user, err = a.GetUserByUsername(owner); err != nil {
+			return "", nil, err
+		}
+		if !user.ServiceAccount {
+			return "", nil, fmt.Errorf("%s isn't a service account; users create their own tokens", owner)
+		}
+		if err := Authorize(creator, PermUsersManage, Resource{}); err != nil {
+			return "", nil, err
+		}
+	}
+
+	existing, err := a.ListAPITokens(user.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, t := range existing {
+		if t.Name == name {
+			return "", nil, fmt.Errorf("%s already has a token named %s", user.Username, name)
+		}
+	}
+
+	// Regenerate the rare duplicate ID
+	var id string
+	for {
+		if id, err = randomHex(4); err != nil {
+			return "", nil, fmt.Errorf("failed to generate token ID: %w", err)
+		}
+		var t APIToken
+		if a.store.Get(apiTokenKeyPrefix+id, &t) != nil {
+			break
+		}
+	}
+	secret, err := randomHex(24)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate token secret: %w", err)
+	}
+
+	token := &APIToken{
+		ID:         id,
+		Name:       name,
+		UserID:     user.ID,
+		Scopes:     slices.Clone(scopes),
+		SecretHash: hashSecret(secret),
+		Created:    time.Now(),
+	}
+	if ttl > 0 {
+		token.ExpiresAt = token.Created.Add(ttl)
+	}
+	if err := a.store.Set(apiTokenKeyPrefix+id, token); err != nil {
+		return "", nil, fmt.Errorf("failed to store API token: %w", err)
+	}
+
+	log.Info("API token created", "id", id, "name", name, "user", user.Username, "scopes", scopes, "expires", token.ExpiresAt)
+	return apiTokenPrefix + id + "." + secret, token, nil
+}
+
+// ListAPITokens returns the API tokens of a user, or of everyone when userID
+// is empty, oldest first
+func (a *AuthService) ListAPITokens(userID string) ([]APIToken, error) {
+	keys, err := a.store.List(apiTokenKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]APIToken, 0, len(keys))
+	for _, key := range keys {
+		var token APIToken
+		if err := a.store.Get(key, &token); err != nil {
+			continue // Skip invalid entries
+		}
+		if userID == "" || token.UserID == userID {
+			tokens = append(tokens, token)
+		}
+	}
+
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].Created.Before(tokens[j].Created) })
+	return tokens, nil
+}
+
+// RevokeAPIToken invalidates an API token by its ID. Users revoke their own
+// tokens; admins revoke anyone's.
+func (a *AuthService) RevokeAPIToken(by *User, id string) error {
+	var token APIToken
+	if err := a.store.Get(apiTokenKeyPrefix+id, &token); err != nil {
+		return ErrTokenInvalid
+	}
+	if token.UserID != by.ID {
+		if err := Authorize(by, PermUsersManage, Resource{}); err != nil {
+			return err
+		}
+	}
+	if err := a.store.Delete(apiTokenKeyPrefix + id); err != nil {
+		return fmt.Errorf("failed to revoke API token: %w", err)
+	}
+
+	log.Info("API token revoked", "id", id, "name", token.Name, "by", by.Username)
+	return nil
+}
+
+// validateAPIToken returns the owner of an API token, limited to its scopes
+func (a *AuthService) validateAPIToken(value string) (*User, error) {
+	id, secret, ok := strings.Cut(strings.TrimPrefix(value, apiTokenPrefix), ".")
+	if !ok || id == "" || secret == "" {
+		return nil, ErrTokenInvalid
+	}
+
+	var token APIToken
+	if err := a.store.Get(apiTokenKeyPrefix+id, &token); err != nil {
+		return nil, ErrTokenInvalid
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(token.SecretHash)) != 1 {
+		return nil, ErrTokenInvalid
+	}
+	if token.Expired() {
+		return nil, ErrTokenExpired
+	}
+
+	user, err := a.GetUserByID(token.UserID)
+	if err != nil || !user.Active {
+		return nil, ErrTokenInvalid
+	}
+
+	if time.Since(token.LastUsed) >= lastUsedInterval {
+		token.LastUsed = time.Now()
+		if err := a.store.Set(apiTokenKeyPrefix+id, token); err != nil {
+			log.Warn("Failed to record the use of an API token", "id", id, "error", err)
+		}
+	}
+
+	user.Scopes = token.Scopes
+	return user, nil
+}
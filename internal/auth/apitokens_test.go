@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/state"
+)
+
+func TestAPITokens(t *testing.T) {
+	a := NewAuthService(state.NewMemoryStateStore())
+	admin, _ := a.AddUser("root", "secret-password", RoleAdmin)
+	alice, _ := a.AddUser("alice", "secret-password", RoleViewer)
+	ci, err := a.AddServiceAccount("ci", RoleDeployer)
+	if err != nil {
+		t.Fatalf("AddServiceAccount failed: %v", err)
+	}
+	if _, err := a.Authenticate("ci", ""); err == nil {
+		t.Error("Expected service accounts not to log in")
+	}
+
+	value, token, err := a.CreateAPIToken(admin, "ci", "deploy-web", []string{"deploy:web"}, 0)
+	if err != nil {
+		t.Fatalf("CreateAPIToken failed: %v", err)
+	}
+	if !strings.HasPrefix(value, apiTokenPrefix+token.ID+".") || strings.Contains(token.SecretHash, strings.TrimPrefix(value, apiTokenPrefix+token.ID+".")) {
+		t.Fatalf("Unexpected token %q (%+v)", value, token)
+	}
+
+	user, err := a.ValidateToken(value)
+	if err != nil || user.Username != "ci" {
+		t.Fatalf("Expected the token to authenticate ci, got %v (%v)", user, err)
+	}
+	for _, tt := range []struct {
+		perm Permission
+		res  Resource
+		want bool
+	}{
+		{PermServicesDeploy, Resource{Service: "web"}, true},
+		{PermServicesDeploy, Resource{Service: "api"}, false},
+		{PermServicesDeploy, Resource{}, false},
+		{PermServicesRead, Resource{}, true},
+		{PermNodesManage, Resource{}, false},
+	} {
+		if got := user.Can(tt.perm, tt.res); got != tt.want {
+			t.Errorf("Expected Can(%s, %+v) to be %v, got %v", tt.perm, tt.res, tt.want, got)
+		}
+	}
+	if !user.CanSome(PermServicesDeploy) {
+		t.Error("Expected a deploy:web token to deploy some service")
+	}
+	if tokens, _ := a.ListAPITokens(ci.ID); len(tokens) != 1 || tokens[0].LastUsed.IsZero() {
+		t.Errorf("Expected the use of the token to be recorded, got %+v", tokens)
+	}
+
+	// A token can't do more than its owner
+	aliceValue, _, err := a.CreateAPIToken(alice, "", "laptop", []string{ScopeAdmin}, time.Hour)
+	if err != nil {
+		t.Fatalf("CreateAPIToken failed: %v", err)
+	}
+	if user, _ := a.ValidateToken(aliceValue); user.Can(PermServicesDeploy, Resource{Service: "web"}) {
+		t.Error("Expected an admin-scoped token of a viewer to stay a viewer")
+	}
+
+	for _, tt := range []struct {
+		name    string
+		creator *User
+		owner   string
+		token   string
+		scopes  []string
+	}{
+		{"unknown scope", alice, "", "x", []string{"deploy:"}},
+		{"no scopes", alice, "", "x", nil},
+		{"no name", alice, "", "", []string{ScopeReadOnly}},
+		{"duplicate", alice, "", "laptop", []string{ScopeReadOnly}},
+		{"for a user", admin, "alice", "x", []string{ScopeReadOnly}},
+		{"by a viewer", alice, "ci", "x", []string{ScopeReadOnly}},
+		{"by a token", user, "", "x", []string{ScopeReadOnly}},
+	} {
+		if _, _, err := a.CreateAPIToken(tt.creator, tt.owner, tt.token, tt.scopes, 0); err == nil {
+			t.Errorf("%s: expected CreateAPIToken to fail", tt.name)
+		}
+	}
+
+	if err := a.RevokeAPIToken(alice, token.ID); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("Expected users not to revoke others' tokens, got %v", err)
+	}
+	if err := a.RevokeAPIToken(admin, token.ID); err != nil {
+		t.Fatalf("RevokeAPIToken failed: %v", err)
+	}
+	if _, err := a.ValidateToken(value); !errors.Is(err, ErrTokenInvalid) {
+		t.Errorf("Expected a revoked token to be invalid, got %v", err)
+	}
+	if _, err := a.ValidateToken(apiTokenPrefix + token.ID + ".wrong"); !errors.Is(err, ErrTokenInvalid) {
+		t.Errorf("Expected a wrong secret to be invalid, got %v", err)
+	}
+}
+
+func TestAPITokenExpiry(t *testing.T) {
+	a := NewAuthService(state.NewMemoryStateStore())
+	alice, _ := a.AddUser("alice", "secret-password", RoleDeployer)
+	value, token, err := a.CreateAPIToken(alice, "", "short", []string{ScopeDeploy}, time.Hour)
+	if err != nil {
+		t.Fatalf("CreateAPIToken failed: %v", err)
+	}
+
+	token.ExpiresAt = time.Now().Add(-time.Minute)
+	a.store.Set(apiTokenKeyPrefix+token.ID, token)
+	if _, err := a.ValidateToken(value); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("Expected ErrTokenExpired, got %v", err)
+	}
+
+	_, forever, _ := a.CreateAPIToken(alice, "", "forever", []string{ScopeDeploy}, 0)
+	if !forever.ExpiresAt.IsZero() || forever.Expired() {
+		t.Errorf("Expected a token without a lifetime not to expire, got %+v", forever)
+	}
+}
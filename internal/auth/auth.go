@@ -1,33 +1,56 @@
 package auth
 
 import (
-	"context"
 	"crypto/rand"
-	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/jasonlovesdoggo/velo/internal/log"
 	"github.com/jasonlovesdoggo/velo/internal/state"
-	"google.golang.org/grpc"
 )
 
 var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrTokenExpired       = errors.New("token expired")
 	ErrTokenInvalid       = errors.New("invalid token")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrWeakPassword       = errors.New("password too weak")
+	ErrTooManyAttempts    = errors.New("too many failed logins")
+	ErrNotSessionToken    = errors.New("API tokens aren't revoked by logging out; revoke them with 'veloctl auth tokens revoke'")
 )
 
+// defaultAdmin is the user created on the first run
+const defaultAdmin = "admin"
+
+// initialPasswordOutput is where the password of the first run's admin is
+// printed, once, rather than to the log, which is often shipped and kept
+var initialPasswordOutput io.Writer = os.Stderr
+
 // User represents a system user
 type User struct {
 	ID       string    `json:"id"`
 	Username string    `json:"username"`
-	Password string    `json:"password"` // hashed
-	Role     string    `json:"role"`     // admin, user
+	Password string    `json:"password"`           // hashed
+	Role     string    `json:"role"`               // viewer, deployer or admin
+	Bindings []Binding `json:"bindings,omitempty"` // roles on projects or services on top of Role
 	Created  time.Time `json:"created"`
 	Active   bool      `json:"active"`
+
+	// ServiceAccount users don't log in; they're for the API tokens of CI and scripts
+	ServiceAccount bool `json:"service_account,omitempty"`
+
+	// MustChangePassword users may only change their password until they do
+	MustChangePassword bool `json:"must_change_password,omitempty"`
+
+	// Scopes limit what the user may do when they authenticated with an API token
+	Scopes []string `json:"-"`
 }
 
 // Token represents an authentication token
@@ -41,16 +64,37 @@ type Token struct {
 // AuthService handles authentication and authorization
 type AuthService struct {
 	store state.StateStore
+
+	// bootstrapMu serializes bootstrap token use so a token can't be used twice
+	bootstrapMu sync.Mutex
+	// agentMu serializes binding agent tokens so a node gets only one
+	agentMu sync.Mutex
+
+	policy  PasswordPolicy
+	lockout *lockout
 }
 
 // NewAuthService creates a new authentication service
 func NewAuthService(store state.StateStore) *AuthService {
 	return &AuthService{
-		store: store,
+		store:   store,
+		policy:  DefaultPasswordPolicy(),
+		lockout: newLockout(DefaultLockoutPolicy()),
 	}
 }
 
-// Initialize sets up the authentication service with default admin user
+// SetPasswordPolicy sets what new passwords must look like
+func (a *AuthService) SetPasswordPolicy(policy PasswordPolicy) {
+	a.policy = policy
+}
+
+// SetLockoutPolicy sets how failed logins lock out accounts and IP addresses
+func (a *AuthService) SetLockoutPolicy(policy LockoutPolicy) {
+	a.lockout.setPolicy(policy)
+}
+
+// Initialize sets up the authentication service with an admin user with a
+// random password, which must be changed on the first login
 func (a *AuthService) Initialize() error {
 	// Check if admin user exists
 	users, err := a.GetAllUsers()
@@ -60,35 +104,31 @@ func (a *AuthService) Initialize() error {
 
 	// Create default admin user if no users exist
 	if len(users) == 0 {
-		// Use simple password for development, random for production
-		// TODO: Detect environment or use config flag
-		defaultPassword := "admin" // For development ease
-		// defaultPassword := generateRandomPassword() // Uncomment for production
-
-		hashedPassword, err := a.hashPassword(defaultPassword)
+		password, err := generatePassword(a.policy)
+		if err != nil {
+			return fmt.Errorf("failed to generate admin password: %w", err)
+		}
+		hashedPassword, err := hashPassword(password)
 		if err != nil {
-			return fmt.Errorf("failed to hash default password: %w", err)
+			return fmt.Errorf("failed to hash admin password: %w", err)
 		}
 
 		admin := &User{
-			ID:       generateID(),
-			Username: "admin",
-			Password: hashedPassword,
-			Role:     "admin",
-			Created:  time.Now(),
-			Active:   true,
+			ID:                 generateID(),
+			Username:           defaultAdmin,
+			Password:           hashedPassword,
+			Role:               RoleAdmin,
+			Created:            time.Now(),
+			Active:             true,
+			MustChangePassword: true,
 		}
 
 		if err := a.CreateUser(admin); err != nil {
 			return fmt.Errorf("failed to create default admin user: %w", err)
 		}
 
-		log.Info("Created default admin user", "username", "admin", "password", defaultPassword)
-		if defaultPassword == "admin" {
-			log.Warn("Using default password 'admin' - change this in production!")
-		} else {
-			log.Warn("Please save this randomly generated admin password!")
-		}
+		fmt.Fprintf(initialPasswordOutput, "\nCreated the %s user with the password\n\n    %s\n\nIt must be changed on the first login and won't be shown again.\n\n", defaultAdmin, password)
+		log.Warn("Created the admin user with a random password, printed to stderr", "username", defaultAdmin)
 	}
 
 	return nil
@@ -96,18 +136,30 @@ func (a *AuthService) Initialize() error {
 
 // Authenticate validates credentials and returns a token
 func (a *AuthService) Authenticate(username, password string) (*Token, error) {
-	user, err := a.GetUserByUsername(username)
-	if err != nil {
-		return nil, ErrInvalidCredentials
-	}
+	return a.AuthenticateFrom(username, password, "")
+}
 
-	if !user.Active {
-		return nil, ErrInvalidCredentials
+// AuthenticateFrom validates credentials sent from an IP address and returns a
+// token. Accounts and addresses that fail too often are locked out for a while,
+// longer with every further failure; a locked out login isn't even checked.
+func (a *AuthService) AuthenticateFrom(username, password, ip string) (*Token, error) {
+	keys := []string{"user:" + username}
+	if ip != "" {
+		keys = append(keys, "ip:"+ip)
+	}
+	if wait := a.lockout.check(keys...); wait > 0 {
+		log.Warn("Login turned away while locked out", "username", username, "ip", ip, "wait", wait)
+		return nil, fmt.Errorf("%w, try again in %s", ErrTooManyAttempts, wait.Round(time.Second))
 	}
 
-	if !a.verifyPassword(password, user.Password) {
-		return nil, ErrInvalidCredentials
+	user, err := a.checkPassword(username, password)
+	if err != nil {
+		a.lockout.fail(keys...)
+		return nil, err
 	}
+	// Only the account's failures are forgiven; a good login from an address
+	// doesn't excuse its failures against other accounts
+	a.lockout.reset(keys[0])
 
 	// Generate token
 	tokenValue, err := generateToken()
@@ -131,8 +183,52 @@ func (a *AuthService) Authenticate(username, password string) (*Token, error) {
 	return token, nil
 }
 
-// ValidateToken checks if a token is valid and returns the associated user
+// checkPassword returns the user whose credentials these are. Legacy or
+// outdated password hashes are replaced with current ones.
+func (a *AuthService) checkPassword(username, password string) (*User, error) {
+	user, err := a.GetUserByUsername(username)
+	if err != nil {
+		// Spend as long as checking a password, so unknown usernames don't stand out
+		verifyPassword(password, dummyPasswordHash())
+		return nil, ErrInvalidCredentials
+	}
+
+	if !user.Active || user.ServiceAccount {
+		return nil, ErrInvalidCredentials
+	}
+
+	ok, rehash := verifyPassword(password, user.Password)
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	if rehash {
+		if hashed, err := hashPassword(password); err != nil {
+			log.Warn("Failed to rehash password", "username", username, "error", err)
+		} else {
+			user.Password = hashed
+			if err := a.UpdateUser(user); err != nil {
+				log.Warn("Failed to store rehashed password", "username", username, "error", err)
+			} else {
+				log.Info("Rehashed password", "username", username)
+			}
+		}
+	}
+	return user, nil
+}
+
+// dummyPasswordHash is a hash no password is checked against successfully
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, _ := hashPassword(generateID())
+	return hash
+})
+
+// ValidateToken checks if a session or API token is valid and returns the
+// associated user
 func (a *AuthService) ValidateToken(tokenValue string) (*User, error) {
+	if strings.HasPrefix(tokenValue, apiTokenPrefix) {
+		return a.validateAPIToken(tokenValue)
+	}
+
 	var token Token
 	if err := a.store.Get("token:"+tokenValue, &token); err != nil {
 		return nil, ErrTokenInvalid
@@ -156,8 +252,12 @@ func (a *AuthService) ValidateToken(tokenValue string) (*User, error) {
 	return user, nil
 }
 
-// RevokeToken invalidates a token
+// RevokeToken invalidates a session token. API tokens are revoked by their ID
+// with RevokeAPIToken instead.
 func (a *AuthService) RevokeToken(tokenValue string) error {
+	if strings.HasPrefix(tokenValue, apiTokenPrefix) {
+		return ErrNotSessionToken
+	}
 	return a.store.Delete("token:" + tokenValue)
 }
 
@@ -175,7 +275,7 @@ func (a *AuthService) CreateUser(user *User) error {
 func (a *AuthService) GetUserByID(id string) (*User, error) {
 	var user User
 	if err := a.store.Get("user:"+id, &user); err != nil {
-		return nil, errors.New("user not found")
+		return nil, ErrUserNotFound
 	}
 	return &user, nil
 }
@@ -193,7 +293,7 @@ func (a *AuthService) GetUserByUsername(username string) (*User, error) {
 		}
 	}
 
-	return nil, errors.New("user not found")
+	return nil, ErrUserNotFound
 }
 
 // GetAllUsers retrieves all users
@@ -215,11 +315,107 @@ func (a *AuthService) GetAllUsers() ([]User, error) {
 	return users, nil
 }
 
+// AddUser creates an active user with a password and a role
+func (a *AuthService) AddUser(username, password, role string) (*User, error) {
+	if username == "" || password == "" {
+		return nil, errors.New("a username and password are required")
+	}
+	if !ValidRole(role) {
+		return nil, fmt.Errorf("unknown role %q (expected %s)", role, strings.Join(Roles, ", "))
+	}
+	if err := a.policy.Check(password); err != nil {
+		return nil, err
+	}
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user := &User{
+		ID:       generateID(),
+		Username: username,
+		Password: hashedPassword,
+		Role:     role,
+		Created:  time.Now(),
+		Active:   true,
+	}
+	if err := a.CreateUser(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// AddServiceAccount creates a service account with a role. Service accounts
+// have no password; they authenticate with API tokens.
+func (a *AuthService) AddServiceAccount(name, role string) (*User, error) {
+	if name == "" {
+		return nil, errors.New("a service account needs a name")
+	}
+	if !ValidRole(role) {
+		return nil, fmt.Errorf("unknown role %q (expected %s)", role, strings.Join(Roles, ", "))
+	}
+
+	user := &User{
+		ID:             generateID(),
+		Username:       name,
+		Role:           role,
+		Created:        time.Now(),
+		Active:         true,
+		ServiceAccount: true,
+	}
+	if err := a.CreateUser(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// SetRole changes the role a user has on the whole platform
+func (a *AuthService) SetRole(username, role string) (*User, error) {
+	if !ValidRole(role) {
+		return nil, fmt.Errorf("unknown role %q (expected %s)", role, strings.Join(Roles, ", "))
+	}
+	user, err := a.GetUserByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	user.Role = role
+	return user, a.UpdateUser(user)
+}
+
+// Grant gives a user a role on a project or service
+func (a *AuthService) Grant(username string, b Binding) (*User, error) {
+	if err := validateBinding(b); err != nil {
+		return nil, err
+	}
+	user, err := a.GetUserByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(user.Bindings, b) {
+		user.Bindings = append(user.Bindings, b)
+	}
+	return user, a.UpdateUser(user)
+}
+
+// Revoke takes a role on a project or service away from a user
+func (a *AuthService) Revoke(username string, b Binding) (*User, error) {
+	user, err := a.GetUserByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.Index(user.Bindings, b)
+	if i < 0 {
+		return nil, fmt.Errorf("%s doesn't have %s", username, b)
+	}
+	user.Bindings = slices.Delete(user.Bindings, i, i+1)
+	return user, a.UpdateUser(user)
+}
+
 // UpdateUser updates an existing user
 func (a *AuthService) UpdateUser(user *User) error {
 	// Verify user exists
 	if _, err := a.GetUserByID(user.ID); err != nil {
-		return errors.New("user not found")
+		return ErrUserNotFound
 	}
 
 	return a.store.Set("user:"+user.ID, user)
@@ -236,37 +432,107 @@ func (a *AuthService) DeleteUser(id string) error {
 	return a.UpdateUser(user)
 }
 
-// ChangePassword changes a user's password
-func (a *AuthService) ChangePassword(userID, newPassword string) error {
+// ChangePassword changes a user's password, which then no longer has to be
+// changed. All their sessions but keep, if any, are revoked.
+func (a *AuthService) ChangePassword(userID, newPassword, keep string) error {
 	user, err := a.GetUserByID(userID)
 	if err != nil {
 		return err
 	}
+	if err := a.setPassword(user, newPassword); err != nil {
+		return err
+	}
+	user.MustChangePassword = false
+	if err := a.UpdateUser(user); err != nil {
+		return err
+	}
+	return a.revokeSessions(user.ID, keep)
+}
 
-	hashedPassword, err := a.hashPassword(newPassword)
+// ChangeOwnPassword changes a user's password after checking their current
+// one, which is locked out like logins so a stolen session can't guess it. The
+// session the change is made with survives; their others are revoked.
+func (a *AuthService) ChangeOwnPassword(userID, currentPassword, newPassword, session string) error {
+	user, err := a.GetUserByID(userID)
 	if err != nil {
-		return fmt.Errorf("failed to hash password: %w", err)
+		return err
 	}
-
-	user.Password = hashedPassword
-	return a.UpdateUser(user)
+	key := "user:" + user.Username
+	if wait := a.lockout.check(key); wait > 0 {
+		log.Warn("Password change turned away while locked out", "username", user.Username, "wait", wait)
+		return fmt.Errorf("%w, try again in %s", ErrTooManyAttempts, wait.Round(time.Second))
+	}
+	if ok, _ := verifyPassword(currentPassword, user.Password); !ok {
+		a.lockout.fail(key)
+		return fmt.Errorf("%w: the current password is wrong", ErrInvalidCredentials)
+	}
+	a.lockout.reset(key)
+	if currentPassword == newPassword {
+		return fmt.Errorf("%w: the new password must differ from the current one", ErrWeakPassword)
+	}
+	return a.ChangePassword(userID, newPassword, session)
 }
 
-// Helper functions
+// ResetPassword sets a user's password for them and revokes their sessions.
+// They must change it on their next login.
+func (a *AuthService) ResetPassword(username, newPassword string) (*User, error) {
+	user, err := a.GetUserByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if user.ServiceAccount {
+		return nil, fmt.Errorf("%s is a service account, which has no password", username)
+	}
+	if err := a.setPassword(user, newPassword); err != nil {
+		return nil, err
+	}
+	user.MustChangePassword = true
+	a.lockout.reset("user:" + username)
+	if err := a.UpdateUser(user); err != nil {
+		return nil, err
+	}
+	return user, a.revokeSessions(user.ID, "")
+}
 
-func (a *AuthService) hashPassword(password string) (string, error) {
-	hash := sha256.Sum256([]byte(password))
-	return hex.EncodeToString(hash[:]), nil
+// revokeSessions revokes the session tokens of a user but keep. API tokens
+// aren't sessions and are left alone.
+func (a *AuthService) revokeSessions(userID, keep string) error {
+	keys, err := a.store.List("token:")
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+	revoked := 0
+	for _, key := range keys {
+		var token Token
+		if a.store.Get(key, &token) != nil || token.UserID != userID || (keep != "" && token.Value == keep) {
+			continue
+		}
+		if err := a.store.Delete(key); err != nil {
+			return fmt.Errorf("failed to revoke session: %w", err)
+		}
+		revoked++
+	}
+	if revoked > 0 {
+		log.Info("Revoked sessions after password change", "userID", userID, "count", revoked)
+	}
+	return nil
 }
 
-func (a *AuthService) verifyPassword(password, hash string) bool {
-	passwordHash, err := a.hashPassword(password)
+// setPassword checks a new password against the policy and hashes it into user
+func (a *AuthService) setPassword(user *User, password string) error {
+	if err := a.policy.Check(password); err != nil {
+		return err
+	}
+	hashedPassword, err := hashPassword(password)
 	if err != nil {
-		return false
+		return fmt.Errorf("failed to hash password: %w", err)
 	}
-	return passwordHash == hash
+	user.Password = hashedPassword
+	return nil
 }
 
+// Helper functions
+
 func generateToken() (string, error) {
 	bytes := make([]byte, 32)
 	if _, err := rand.Read(bytes); err != nil {
@@ -280,10 +546,3 @@ func generateID() string {
 	rand.Read(bytes)
 	return hex.EncodeToString(bytes)
 }
-
-// Middleware for gRPC authentication
-func (a *AuthService) AuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-	// For now, skip auth for basic functionality
-	// TODO: Implement proper gRPC metadata token extraction
-	return handler(ctx, req)
-}
@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/log"
+)
+
+// Bootstrap token roles
+const (
+	BootstrapRoleWorker  = "worker"
+	BootstrapRoleManager = "manager"
+)
+
+const (
+	// DefaultBootstrapTTL is how long a bootstrap token is valid unless set otherwise
+	DefaultBootstrapTTL = time.Hour
+
+	// MaxBootstrapTTL caps the lifetime of bootstrap tokens
+	MaxBootstrapTTL = 24 * time.Hour
+
+	bootstrapTokenPrefix = "velo-"
+	bootstrapKeyPrefix   = "bootstrap:"
+)
+
+// BootstrapToken authenticates a new node joining a cluster. Tokens are
+// single-use and short-lived; only a hash of the secret is stored.
+type BootstrapToken struct {
+	ID          string    `json:"id"`
+	SecretHash  string    `json:"secret_hash"`
+	Role        string    `json:"role"`    // worker or manager
+	Cluster     string    `json:"cluster"` // empty for the default cluster
+	Description string    `json:"description"`
+	Created     time.Time `json:"created"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the token can no longer be used
+func (t BootstrapToken) Expired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+// CreateBootstrapToken mints a bootstrap token and returns it with its secret.
+// The secret is only available here; it can't be recovered later.
+func (a *AuthService) CreateBootstrapToken(role, cluster, description string, ttl time.Duration) (string, *BootstrapToken, error) {
+	if role == "" {
+		role = BootstrapRoleWorker
+	}
+	if role != BootstrapRoleWorker && role != BootstrapRoleManager {
+		return "", nil, fmt.Errorf("invalid role %q (expected worker or manager)", role)
+	}
+	if ttl <= 0 {
+		ttl = DefaultBootstrapTTL
+	}
+	if ttl > MaxBootstrapTTL {
+		return "", nil, fmt.Errorf("token lifetime %s exceeds the maximum of %s", ttl, MaxBootstrapTTL)
+	}
+
+	// IDs are short so they're easy to revoke; regenerate the rare duplicate
+	var id string
+	for {
+		var err error
+		if id, err = randomHex(3); err != nil {
+			return "", nil, fmt.Errorf("failed to generate token ID: %w", err)
+		}
+		var existing BootstrapToken
+		if a.store.Get(bootstrapKeyPrefix+id, &existing) != nil {
+			break
+		}
+	}
+	secret, err := randomHex(16)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate token secret: %w", err)
+	}
+
+	token := &BootstrapToken{
+		ID:          id,
+		SecretHash:  hashSecret(secret),
+		Role:        role,
+		Cluster:     cluster,
+		Description: description,
+		Created:     time.Now(),
+		ExpiresAt:   time.Now().Add(ttl),
+	}
+	if err := a.store.Set(bootstrapKeyPrefix+id, token); err != nil {
+		return "", nil, fmt.Errorf("failed to store bootstrap token: %w", err)
+	}
+
+	log.Info("Bootstrap token created", "id", id, "role", role, "cluster", cluster, "expires", token.ExpiresAt)
+	return bootstrapTokenPrefix + id + "." + secret, token, nil
+}
+
+// ListBootstrapTokens returns the bootstrap tokens that haven't been used or revoked,
+// removing the ones that have expired
+func (a *AuthService) ListBootstrapTokens() ([]BootstrapToken, error) {
+	keys, err := a.store.List(bootstrapKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]BootstrapToken, 0, len(keys))
+	for _, key := range keys {
+		var token BootstrapToken
+		if err := a.store.Get(key, &token); err != nil {
+			continue // Skip invalid entries
+		}
+		if token.Expired() {
+			a.store.Delete(key)
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].Created.Before(tokens[j].Created) })
+	return tokens, nil
+}
+
+// RevokeBootstrapToken invalidates a bootstrap token by its ID
+func (a *AuthService) RevokeBootstrapToken(id string) error {
+	var token BootstrapToken
+	if err := a.store.Get(bootstrapKeyPrefix+id, &token); err != nil {
+		return ErrTokenInvalid
+	}
+	if err := a.store.Delete(bootstrapKeyPrefix + id); err != nil {
+		return fmt.Errorf("failed to revoke bootstrap token: %w", err)
+	}
+
+	log.Info("Bootstrap token revoked", "id", id)
+	return nil
+}
+
+// ConsumeBootstrapToken validates a bootstrap token and invalidates it, so it
+// can't be used again. usedBy identifies the joining node in the logs.
+func (a *AuthService) ConsumeBootstrapToken(value, usedBy string) (*BootstrapToken, error) {
+	id, secret, ok := parseBootstrapToken(value)
+	if !ok {
+		return nil, ErrTokenInvalid
+	}
+
+	a.bootstrapMu.Lock()
+	defer a.bootstrapMu.Unlock()
+
+	var token BootstrapToken
+	if err := a.store.Get(bootstrapKeyPrefix+id, &token); err != nil {
+		return nil, ErrTokenInvalid
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(token.SecretHash)) != 1 {
+		log.Warn("Rejected bootstrap token with a wrong secret", "id", id, "node", usedBy)
+		return nil, ErrTokenInvalid
+	}
+	if err := a.store.Delete(bootstrapKeyPrefix + id); err != nil {
+		return nil, fmt.Errorf("failed to invalidate bootstrap token: %w", err)
+	}
+	if token.Expired() {
+		return nil, ErrTokenExpired
+	}
+
+	log.Info("Bootstrap token used", "id", id, "role", token.Role, "node", usedBy)
+	return &token, nil
+}
+
+// parseBootstrapToken splits a velo-<id>.<secret> token into its ID and secret
+func parseBootstrapToken(value string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(value, bootstrapTokenPrefix)
+	if !ok {
+		return "", "", false
+	}
+	id, secret, ok := strings.Cut(rest, ".")
+	if !ok || id == "" || secret == "" {
+		return "", "", false
+	}
+	return id, secret, true
+}
+
+func hashSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+func randomHex(n int) (string, error) {
+	bytes := make([]byte, n)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
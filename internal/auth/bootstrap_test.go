@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/state"
+)
+
+func TestBootstrapTokens(t *testing.T) {
+	a := NewAuthService(state.NewMemoryStateStore())
+
+	value, token, err := a.CreateBootstrapToken("", "prod", "rack 4", time.Hour)
+	if err != nil {
+		t.Fatalf("CreateBootstrapToken failed: %v", err)
+	}
+	if !strings.HasPrefix(value, "velo-"+token.ID+".") || token.Role != BootstrapRoleWorker {
+		t.Fatalf("Unexpected token %q (%+v)", value, token)
+	}
+	if strings.Contains(token.SecretHash, strings.TrimPrefix(value, "velo-"+token.ID+".")) {
+		t.Error("Expected only a hash of the secret to be stored")
+	}
+
+	tokens, err := a.ListBootstrapTokens()
+	if err != nil || len(tokens) != 1 || tokens[0].ID != token.ID {
+		t.Fatalf("Expected the new token to be listed, got %v (%v)", tokens, err)
+	}
+
+	if _, err := a.ConsumeBootstrapToken("velo-"+token.ID+".wrong", "node-1"); !errors.Is(err, ErrTokenInvalid) {
+		t.Errorf("Expected a wrong secret to be rejected, got %v", err)
+	}
+	used, err := a.ConsumeBootstrapToken(value, "node-1")
+	if err != nil || used.Cluster != "prod" {
+		t.Fatalf("ConsumeBootstrapToken failed: %v (%+v)", err, used)
+	}
+	if _, err := a.ConsumeBootstrapToken(value, "node-2"); !errors.Is(err, ErrTokenInvalid) {
+		t.Errorf("Expected a used token to be rejected, got %v", err)
+	}
+	if tokens, _ := a.ListBootstrapTokens(); len(tokens) != 0 {
+		t.Errorf("Expected no tokens after use, got %d", len(tokens))
+	}
+}
+
+func TestBootstrapTokenExpiryAndRevocation(t *testing.T) {
+	a := NewAuthService(state.NewMemoryStateStore())
+
+	if _, _, err := a.CreateBootstrapToken("admin", "", "", time.Hour); err == nil {
+		t.Error("Expected an unknown role to be rejected")
+	}
+	if _, _, err := a.CreateBootstrapToken(BootstrapRoleManager, "", "", 48*time.Hour); err == nil {
+		t.Error("Expected a lifetime over the maximum to be rejected")
+	}
+
+	value, token, _ := a.CreateBootstrapToken(BootstrapRoleManager, "", "", time.Hour)
+	token.ExpiresAt = time.Now().Add(-time.Minute)
+	a.store.Set(bootstrapKeyPrefix+token.ID, token)
+	if _, err := a.ConsumeBootstrapToken(value, "node-1"); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("Expected an expired token to be rejected, got %v", err)
+	}
+
+	value, token, _ = a.CreateBootstrapToken(BootstrapRoleWorker, "", "", time.Hour)
+	if err := a.RevokeBootstrapToken(token.ID); err != nil {
+		t.Fatalf("RevokeBootstrapToken failed: %v", err)
+	}
+	if err := a.RevokeBootstrapToken(token.ID); !errors.Is(err, ErrTokenInvalid) {
+		t.Errorf("Expected revoking twice to fail, got %v", err)
+	}
+	if _, err := a.ConsumeBootstrapToken(value, "node-1"); !errors.Is(err, ErrTokenInvalid) {
+		t.Errorf("Expected a revoked token to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package auth
+
+import "context"
+
+type userContextKey struct{}
+
+type agentContextKey struct{}
+
+// WithUser returns a copy of ctx that carries the authenticated user
+func WithUser(ctx context.Context, user *User) context.Context {
+	return context.WithValue(ctx, userContextKey{}, user)
+}
+
+// UserFromContext returns the authenticated user carried by ctx, if any
+func UserFromContext(ctx context.Context) (*User, bool) {
+	user, ok := ctx.Value(userContextKey{}).(*User)
+	return user, ok && user != nil
+}
+
+// Username returns the name of the authenticated user carried by ctx, or "" if there's none
+func Username(ctx context.Context) string {
+	if user, ok := UserFromContext(ctx); ok {
+		return user.Username
+	}
+	return ""
+}
+
+// WithAgent returns a copy of ctx that carries the agent token a call is authenticated with
+func WithAgent(ctx context.Context, token *AgentToken) context.Context {
+	return context.WithValue(ctx, agentContextKey{}, token)
+}
+
+// AgentFromContext returns the agent token carried by ctx, if any
+func AgentFromContext(ctx context.Context) (*AgentToken, bool) {
+	token, ok := ctx.Value(agentContextKey{}).(*AgentToken)
+	return token, ok && token != nil
+}
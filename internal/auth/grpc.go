@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Access says who may call a gRPC method
+type Access struct {
+	Public     bool       // anyone may call it, e.g. nodes joining with a bootstrap token
+	Agent      bool       // only agents may call it, authenticated by their node's agent token
+	Permission Permission // required otherwise; without one any user may call it
+	// BeforePasswordChange lets users who must change their password call it
+	BeforePasswordChange bool
+	// Resource returns the service a call acts on, so users whose role is
+	// scoped to projects or services can be allowed. Without it they aren't.
+	Resource func(ctx context.Context, req interface{}) (Resource, error)
+}
+
+type authErrorContextKey struct{}
+
+// UnaryAuthenticator attaches the user or agent of the bearer token in a call's
+// authorization metadata to its context. Calls without a valid token go on
+// without either and are turned away by the authorizer unless they're public.
+func (a *AuthService) UnaryAuthenticator(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(a.authenticate(ctx), req)
+}
+
+// StreamAuthenticator attaches the user or agent of a stream's bearer token like UnaryAuthenticator
+func (a *AuthService) StreamAuthenticator(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &contextStream{ServerStream: ss, ctx: a.authenticate(ss.Context())})
+}
+
+func (a *AuthService) authenticate(ctx context.Context) context.Context {
+	token := BearerToken(ctx)
+	if token == "" {
+		return ctx
+	}
+	if strings.HasPrefix(token, agentTokenPrefix) {
+		agent, err := a.ValidateAgentToken(token)
+		if err != nil {
+			return context.WithValue(ctx, authErrorContextKey{}, err)
+		}
+		return WithAgent(ctx, agent)
+	}
+	user, err := a.ValidateToken(token)
+	if err != nil {
+		return context.WithValue(ctx, authErrorContextKey{}, err)
+	}
+	return WithUser(ctx, user)
+}
+
+// BearerToken returns the bearer token of a call's authorization metadata
+func BearerToken(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		if token, ok := strings.CutPrefix(value, "Bearer "); ok {
+			return token
+		}
+	}
+	return ""
+}
+
+// UnaryAuthorizer checks the user of a call may call its method. Methods
+// missing from methods can't be called at all.
+func (a *AuthService) UnaryAuthorizer(methods map[string]Access) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := authorize(ctx, methods, info.FullMethod, req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuthorizer checks the user of a stream may call its method. Methods
+// whose access depends on the request are checked when the request arrives.
+func (a *AuthService) StreamAuthorizer(methods map[string]Access) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+		access, ok := methods[info.FullMethod]
+		if ok && !access.Public && access.Resource != nil {
+			// Check what doesn't need the request now, the rest on the first message
+			if err := authenticated(ctx); err != nil {
+				return err
+			}
+			if err := passwordChanged(ctx, access); err != nil {
+				return err
+			}
+			if user, _ := UserFromContext(ctx); !user.CanSome(access.Permission) {
+				return denied(Authorize(user, access.Permission, Resource{}))
+			}
+			return handler(srv, &authorizingStream{ServerStream: ss, methods: methods, method: info.FullMethod})
+		}
+		if err := authorize(ctx, methods, info.FullMethod, nil); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func authorize(ctx context.Context, methods map[string]Access, method string, req interface{}) error {
+	access, ok := methods[method]
+	if !ok {
+		return status.Errorf(codes.PermissionDenied, "%s isn't allowed", method)
+	}
+	if access.Public {
+		return nil
+	}
+	if access.Agent {
+		if _, ok := AgentFromContext(ctx); !ok {
+			return status.Error(codes.Unauthenticated, "agent token required; join with 'velo join' or create one with 'veloctl cluster agent-token'")
+		}
+		return nil
+	}
+	if err := authenticated(ctx); err != nil {
+		return err
+	}
+	if err := passwordChanged(ctx, access); err != nil {
+		return err
+	}
+
+	user, _ := UserFromContext(ctx)
+	if access.Permission == "" || user.Can(access.Permission, Resource{}) {
+		return nil
+	}
+	if access.Resource == nil || !user.CanSome(access.Permission) {
+		return denied(Authorize(user, access.Permission, Resource{}))
+	}
+	res, err := access.Resource(ctx, req)
+	if err != nil {
+		return err
+	}
+	return denied(Authorize(user, access.Permission, res))
+}
+
+// authenticated returns an Unauthenticated error unless a call has a user
+func authenticated(ctx context.Context) error {
+	if _, ok := UserFromContext(ctx); ok {
+		return nil
+	}
+	err, _ := ctx.Value(authErrorContextKey{}).(error)
+	switch {
+	case errors.Is(err, ErrTokenExpired):
+		return status.Error(codes.Unauthenticated, "token expired, log in again")
+	case err != nil:
+		return status.Error(codes.Unauthenticated, "invalid token, log in again")
+	default:
+		return status.Error(codes.Unauthenticated, "authentication required, log in first")
+	}
+}
+
+// passwordChanged turns users who must change their password away from
+// everything else
+func passwordChanged(ctx context.Context, access Access) error {
+	if user, _ := UserFromContext(ctx); user.MustChangePassword && !access.BeforePasswordChange {
+		return status.Error(codes.FailedPrecondition, "you must change your password first; run 'veloctl auth change-password'")
+	}
+	return nil
+}
+
+func denied(err error) error {
+	if err == nil {
+		return nil
+	}
+	return status.Error(codes.PermissionDenied, err.Error())
+}
+
+// contextStream is a server stream with another context
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// authorizingStream authorizes a stream on the request its client sends first
+type authorizingStream struct {
+	grpc.ServerStream
+	methods    map[string]Access
+	method     string
+	authorized bool
+}
+
+func (s *authorizingStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if !s.authorized {
+		if err := authorize(s.Context(), s.methods, s.method, m); err != nil {
+			return err
+		}
+		s.authorized = true
+	}
+	return nil
+}
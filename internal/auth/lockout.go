@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// LockoutPolicy is how failed logins lock out an account or IP address
+type LockoutPolicy struct {
+	// Threshold is how many failed logins in a row lock out; 0 disables lockout
+	Threshold int
+
+	// Backoff is how long the first lockout lasts. Every further failure
+	// doubles it, up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultLockoutPolicy returns the lockout policy used unless configured otherwise
+func DefaultLockoutPolicy() LockoutPolicy {
+	return LockoutPolicy{Threshold: 5, Backoff: 30 * time.Second, MaxBackoff: time.Hour}
+}
+
+// failedLogins are the failed logins in a row of an account or IP address
+type failedLogins struct {
+	count  int
+	last   time.Time
+	locked time.Time // until when logins are turned away
+}
+
+// lockout counts failed logins per key, like an account or IP address, and
+// locks a key out once it fails too often. Counts are kept in memory, so a
+// restart forgets them.
+type lockout struct {
+	mu      sync.Mutex
+	policy  LockoutPolicy
+	entries map[string]*failedLogins
+	now     func() time.Time
+}
+
+func newLockout(policy LockoutPolicy) *lockout {
+	return &lockout{policy: policy, entries: map[string]*failedLogins{}, now: time.Now}
+}
+
+// setPolicy replaces the lockout policy
+func (l *lockout) setPolicy(policy LockoutPolicy) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.policy = policy
+}
+
+// check returns how much longer the most locked out of keys stays locked out,
+// or 0 when none is
+func (l *lockout) check(keys ...string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	var wait time.Duration
+	for _, key := range keys {
+		if e, ok := l.entries[key]; ok && e.locked.After(now) {
+			wait = max(wait, e.locked.Sub(now))
+		}
+	}
+	return wait
+}
+
+// fail records a failed login of keys, locking out those that failed too often
+func (l *lockout) fail(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.policy.Threshold <= 0 {
+		return
+	}
+
+	now := l.now()
+	l.prune(now)
+	for _, key := range keys {
+		e, ok := l.entries[key]
+		if !ok {
+			e = &failedLogins{}
+			l.entries[key] = e
+		}
+		e.count++
+		e.last = now
+		if e.count >= l.policy.Threshold {
+			e.locked = now.Add(l.backoff(e.count - l.policy.Threshold))
+		}
+	}
+}
+
+// reset forgets the failed logins of keys
+func (l *lockout) reset(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		delete(l.entries, key)
+	}
+}
+
+// backoff returns how long the nth lockout in a row lasts, from 0
+func (l *lockout) backoff(n int) time.Duration {
+	d := l.policy.Backoff
+	for ; n > 0 && d < l.policy.MaxBackoff; n-- {
+		d *= 2
+	}
+	if l.policy.MaxBackoff > 0 {
+		d = min(d, l.policy.MaxBackoff)
+	}
+	return d
+}
+
+// prune forgets keys that aren't locked out and haven't failed for as long as
+// the longest lockout
+func (l *lockout) prune(now time.Time) {
+	window := max(l.policy.MaxBackoff, l.policy.Backoff)
+	for key, e := range l.entries {
+		if !e.locked.After(now) && now.Sub(e.last) > window {
+			delete(l.entries, key)
+		}
+	}
+}
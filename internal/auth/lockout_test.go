@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/state"
+)
+
+func TestLockoutBackoff(t *testing.T) {
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newLockout(LockoutPolicy{Threshold: 3, Backoff: time.Minute, MaxBackoff: 5 * time.Minute})
+	l.now = func() time.Time { return now }
+
+	for i, want := range []time.Duration{0, 0, time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute} {
+		l.fail("user:alice")
+		if got := l.check("user:alice"); got != want {
+			t.Errorf("Expected failure %d to lock out for %s, got %s", i+1, want, got)
+		}
+	}
+	if got := l.check("user:bob", "ip:10.0.0.1"); got != 0 {
+		t.Errorf("Expected other keys not to be locked out, got %s", got)
+	}
+	if got := l.check("user:bob", "user:alice"); got != 5*time.Minute {
+		t.Errorf("Expected the longest lockout of keys, got %s", got)
+	}
+
+	now = now.Add(5 * time.Minute)
+	if got := l.check("user:alice"); got != 0 {
+		t.Errorf("Expected the lockout to end, got %s", got)
+	}
+	l.reset("user:alice")
+	l.fail("user:alice")
+	if got := l.check("user:alice"); got != 0 {
+		t.Errorf("Expected a reset to forget failures, got %s", got)
+	}
+
+	// Failures are forgotten once they're old
+	l.fail("ip:10.0.0.1")
+	now = now.Add(10 * time.Minute)
+	l.fail("ip:10.0.0.2")
+	if _, ok := l.entries["ip:10.0.0.1"]; ok {
+		t.Error("Expected old failures to be pruned")
+	}
+}
+
+func TestAuthenticateLockout(t *testing.T) {
+	a := NewAuthService(state.NewMemoryStateStore())
+	a.SetLockoutPolicy(LockoutPolicy{Threshold: 2, Backoff: time.Hour, MaxBackoff: time.Hour})
+	a.AddUser("alice", "alice-password", RoleViewer)
+	a.AddUser("bob", "bob-password", RoleViewer)
+
+	for range 2 {
+		if _, err := a.AuthenticateFrom("alice", "wrong", "10.0.0.1"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("Expected ErrInvalidCredentials, got %v", err)
+		}
+	}
+	if _, err := a.AuthenticateFrom("alice", "alice-password", "10.0.0.2"); !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("Expected the account to be locked out, even with the right password, got %v", err)
+	}
+	if _, err := a.AuthenticateFrom("bob", "bob-password", "10.0.0.1"); !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("Expected the IP address to be locked out, got %v", err)
+	}
+	if _, err := a.AuthenticateFrom("bob", "bob-password", "10.0.0.2"); err != nil {
+		t.Errorf("Expected other accounts and addresses to log in, got %v", err)
+	}
+
+	if _, err := a.ResetPassword("alice", "reset-password"); err != nil {
+		t.Fatalf("ResetPassword failed: %v", err)
+	}
+	if _, err := a.AuthenticateFrom("alice", "reset-password", "10.0.0.2"); err != nil {
+		t.Errorf("Expected resetting the password to lift the lockout, got %v", err)
+	}
+}
+
+func TestPasswordChangeLockout(t *testing.T) {
+	a := NewAuthService(state.NewMemoryStateStore())
+	a.SetLockoutPolicy(LockoutPolicy{Threshold: 3, Backoff: time.Hour, MaxBackoff: time.Hour})
+	alice, err := a.AddUser("alice", "alice-password", RoleDeployer)
+	if err != nil {
+		t.Fatalf("AddUser failed: %v", err)
+	}
+
+	for range 3 {
+		if err := a.ChangeOwnPassword(alice.ID, "wrong", "alices-new-password", ""); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("Expected ErrInvalidCredentials, got %v", err)
+		}
+	}
+	if err := a.ChangeOwnPassword(alice.ID, "alice-password", "alices-new-password", ""); !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("Expected guessing the current password to lock the account out, got %v", err)
+	}
+	if _, err := a.Authenticate("alice", "alice-password"); !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("Expected the lockout to cover logins too, got %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// argon2Params are the argon2id cost parameters of a password hash
+type argon2Params struct {
+	Memory  uint32 // KiB
+	Time    uint32
+	Threads uint8
+}
+
+// passwordHashParams are what new password hashes are made with. Hashes made
+// with other parameters are rehashed on the next successful login.
+var passwordHashParams = argon2Params{Memory: 19 * 1024, Time: 2, Threads: 1}
+
+const (
+	passwordSaltLength = 16
+	passwordKeyLength  = 32
+)
+
+// PasswordPolicy is what passwords must look like
+type PasswordPolicy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+// DefaultPasswordPolicy returns the password policy used unless configured otherwise
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{MinLength: 10}
+}
+
+// Check returns an error describing what a password is missing, if anything
+func (p PasswordPolicy) Check(password string) error {
+	var missing []string
+	if n := len([]rune(password)); n < p.MinLength {
+		missing = append(missing, fmt.Sprintf("at least %d characters", p.MinLength))
+	}
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		missing = append(missing, "an uppercase letter")
+	}
+	if p.RequireLower && !lower {
+		missing = append(missing, "a lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		missing = append(missing, "a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		missing = append(missing, "a symbol")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: it needs %s", ErrWeakPassword, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// hashPassword hashes a password with argon2id and a random salt, in the PHC
+// string format: $argon2id$v=19$m=...,t=...,p=...$<salt>$<hash>
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, passwordSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	p := passwordHashParams
+	key := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, passwordKeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, p.Memory, p.Time, p.Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// verifyPassword reports whether a password matches a hash, and whether the
+// hash should be replaced because it's a legacy SHA-256 hash or was made with
+// other parameters than passwordHashParams
+func verifyPassword(password, hash string) (ok, rehash bool) {
+	if !strings.HasPrefix(hash, "$") {
+		// Unsalted SHA-256 from before argon2id
+		sum := sha256.Sum256([]byte(password))
+		return subtle.ConstantTimeCompare([]byte(hex.EncodeToString(sum[:])), []byte(hash)) == 1, true
+	}
+
+	p, salt, key, err := parsePasswordHash(hash)
+	if err != nil {
+		return false, false
+	}
+	other := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return false, false
+	}
+	return true, p != passwordHashParams
+}
+
+// parsePasswordHash splits an argon2id hash into its parameters, salt and key
+func parsePasswordHash(hash string) (argon2Params, []byte, []byte, error) {
+	var p argon2Params
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, nil, nil, errors.New("unsupported password hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, errors.New("unsupported argon2 version")
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return p, nil, nil, fmt.Errorf("invalid argon2 parameters: %w", err)
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("invalid salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, errors.New("invalid password hash")
+	}
+	return p, salt, key, nil
+}
+
+// passwordAlphabet is what generated passwords are made of, without
+// characters that are easily confused. It has 64 characters so random bytes
+// map onto it evenly.
+const passwordAlphabet = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789-_.!@#%+"
+
+// generatePassword returns a random password that satisfies a policy
+func generatePassword(policy PasswordPolicy) (string, error) {
+	length := max(20, policy.MinLength)
+	for {
+		b := make([]byte, length)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		for i := range b {
+			b[i] = passwordAlphabet[int(b[i])%len(passwordAlphabet)]
+		}
+		if policy.Check(string(b)) == nil {
+			return string(b), nil
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jasonlovesdoggo/velo/internal/state"
+)
+
+func TestPasswordHashing(t *testing.T) {
+	hash, err := hashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("hashPassword failed: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=19456,t=2,p=1$") {
+		t.Errorf("Expected an argon2id hash, got %q", hash)
+	}
+	if other, _ := hashPassword("correct horse"); other == hash {
+		t.Error("Expected every hash to have its own salt")
+	}
+
+	for _, tt := range []struct {
+		password string
+		hash     string
+		ok       bool
+		rehash   bool
+	}{
+		{"correct horse", hash, true, false},
+		{"wrong horse", hash, false, false},
+		{"correct horse", legacyHash("correct horse"), true, true},
+		{"wrong horse", legacyHash("correct horse"), false, true},
+		{"correct horse", "$argon2id$v=19$garbage", false, false},
+		{"", "", false, true},
+	} {
+		ok, rehash := verifyPassword(tt.password, tt.hash)
+		if ok != tt.ok || (ok && rehash != tt.rehash) {
+			t.Errorf("Expected verifyPassword(%q, %q) to be %v, %v, got %v, %v", tt.password, tt.hash, tt.ok, tt.rehash, ok, rehash)
+		}
+	}
+}
+
+func TestRehashOnLogin(t *testing.T) {
+	a := NewAuthService(state.NewMemoryStateStore())
+	user, err := a.AddUser("alice", "alice-password", RoleViewer)
+	if err != nil {
+		t.Fatalf("AddUser failed: %v", err)
+	}
+
+	// A user from before argon2id
+	user.Password = legacyHash("alice-password")
+	a.UpdateUser(user)
+	if _, err := a.Authenticate("alice", "alice-password"); err != nil {
+		t.Fatalf("Expected a legacy hash to authenticate, got %v", err)
+	}
+	user, _ = a.GetUserByID(user.ID)
+	if !strings.HasPrefix(user.Password, "$argon2id$") {
+		t.Errorf("Expected the password to be rehashed, got %q", user.Password)
+	}
+
+	// Hashes made with weaker parameters are upgraded too
+	defer func(p argon2Params) { passwordHashParams = p }(passwordHashParams)
+	passwordHashParams.Time++
+	before := user.Password
+	if _, err := a.Authenticate("alice", "alice-password"); err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	user, _ = a.GetUserByID(user.ID)
+	if user.Password == before || !strings.Contains(user.Password, ",t=3,") {
+		t.Errorf("Expected the password to be rehashed with the new parameters, got %q", user.Password)
+	}
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	strict := PasswordPolicy{MinLength: 12, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true}
+	for _, tt := range []struct {
+		policy   PasswordPolicy
+		password string
+		valid    bool
+	}{
+		{DefaultPasswordPolicy(), "short", false},
+		{DefaultPasswordPolicy(), "long enough", true},
+		{DefaultPasswordPolicy(), "ünïcödé-pw", true},
+		{strict, "Tr0ub4dor&3xyz", true},
+		{strict, "tr0ub4dor&3xyz", false},
+		{strict, "TR0UB4DOR&3XYZ", false},
+		{strict, "Troubadour&xyz", false},
+		{strict, "Tr0ub4dor33xyz", false},
+		{strict, "Tr0ub4dor&3", false},
+	} {
+		err := tt.policy.Check(tt.password)
+		if (err == nil) != tt.valid {
+			t.Errorf("Expected %q to be valid: %v, got %v", tt.password, tt.valid, err)
+		}
+		if err != nil && !errors.Is(err, ErrWeakPassword) {
+			t.Errorf("Expected ErrWeakPassword, got %v", err)
+		}
+	}
+
+	a := NewAuthService(state.NewMemoryStateStore())
+	a.SetPasswordPolicy(strict)
+	if _, err := a.AddUser("alice", "alice-password", RoleViewer); !errors.Is(err, ErrWeakPassword) {
+		t.Errorf("Expected AddUser to apply the policy, got %v", err)
+	}
+	if password, err := generatePassword(strict); err != nil || strict.Check(password) != nil {
+		t.Errorf("Expected a generated password to satisfy the policy, got %q (%v)", password, err)
+	}
+}
+
+func TestInitialAdminPassword(t *testing.T) {
+	var out strings.Builder
+	initialPasswordOutput = &out
+	t.Cleanup(func() { initialPasswordOutput = os.Stderr })
+
+	a := NewAuthService(state.NewMemoryStateStore())
+	if err := a.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	printed := strings.Fields(strings.Split(out.String(), "\n")[3])
+	if len(printed) != 1 {
+		t.Fatalf("Expected the password to be printed, got %q", out.String())
+	}
+	if _, err := a.Authenticate(defaultAdmin, printed[0]); err != nil {
+		t.Errorf("Expected the printed password to log in, got %v", err)
+	}
+	admin, err := a.GetUserByUsername(defaultAdmin)
+	if err != nil {
+		t.Fatalf("Expected an admin user: %v", err)
+	}
+	if !admin.MustChangePassword || admin.Role != RoleAdmin {
+		t.Errorf("Expected an admin who must change their password, got %+v", admin)
+	}
+	if _, err := a.Authenticate(defaultAdmin, "admin"); err == nil {
+		t.Error("Expected admin/admin not to log in")
+	}
+
+	if err := a.ChangeOwnPassword(admin.ID, "wrong", "new-admin-password", ""); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected the current password to be checked, got %v", err)
+	}
+	if err := a.ChangeOwnPassword(admin.ID, printed[0], "short", ""); !errors.Is(err, ErrWeakPassword) {
+		t.Errorf("Expected the new password to be checked against the policy, got %v", err)
+	}
+	if err := a.ChangeOwnPassword(admin.ID, printed[0], "new-admin-password", ""); err != nil {
+		t.Fatalf("ChangeOwnPassword failed: %v", err)
+	}
+	if admin, _ = a.GetUserByID(admin.ID); admin.MustChangePassword {
+		t.Error("Expected changing the password to clear MustChangePassword")
+	}
+
+	if _, err := a.ResetPassword(defaultAdmin, "reset-password"); err != nil {
+		t.Fatalf("ResetPassword failed: %v", err)
+	}
+	if admin, _ = a.GetUserByID(admin.ID); !admin.MustChangePassword {
+		t.Error("Expected a reset password to have to be changed")
+	}
+
+	// Initialize leaves existing users alone
+	if err := a.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if users, _ := a.GetAllUsers(); len(users) != 1 {
+		t.Errorf("Expected one user, got %d", len(users))
+	}
+}
+
+func TestPasswordChangeRevokesSessions(t *testing.T) {
+	a := NewAuthService(state.NewMemoryStateStore())
+	alice, err := a.AddUser("alice", "alice-password", RoleDeployer)
+	if err != nil {
+		t.Fatalf("AddUser failed: %v", err)
+	}
+	if _, err := a.AddUser("bob", "bob-password", RoleDeployer); err != nil {
+		t.Fatalf("AddUser failed: %v", err)
+	}
+	login := func(username, password string) string {
+		token, err := a.Authenticate(username, password)
+		if err != nil {
+			t.Fatalf("Authenticate failed: %v", err)
+		}
+		return token.Value
+	}
+	current, stolen, bob := login("alice", "alice-password"), login("alice", "alice-password"), login("bob", "bob-password")
+
+	if err := a.ChangeOwnPassword(alice.ID, "alice-password", "alices-new-password", current); err != nil {
+		t.Fatalf("ChangeOwnPassword failed: %v", err)
+	}
+	if _, err := a.ValidateToken(current); err != nil {
+		t.Errorf("Expected the session the password was changed with to survive, got %v", err)
+	}
+	if _, err := a.ValidateToken(stolen); !errors.Is(err, ErrTokenInvalid) {
+		t.Errorf("Expected alice's other sessions to be revoked, got %v", err)
+	}
+	if _, err := a.ValidateToken(bob); err != nil {
+		t.Errorf("Expected other users' sessions to survive, got %v", err)
+	}
+
+	if _, err := a.ResetPassword("alice", "reset-password"); err != nil {
+		t.Fatalf("ResetPassword failed: %v", err)
+	}
+	if _, err := a.ValidateToken(current); !errors.Is(err, ErrTokenInvalid) {
+		t.Errorf("Expected resetting the password to revoke every session, got %v", err)
+	}
+}
+
+func legacyHash(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
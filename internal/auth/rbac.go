@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Roles
+const (
+	RoleViewer   = "viewer"   // reads services, nodes, metrics and alerts
+	RoleDeployer = "deployer" // and deploys, scales and rolls back services
+	RoleAdmin    = "admin"    // and manages nodes, users, tokens, alerts, webhooks and reads the audit log
+
+	// roleLegacyUser is the role users were created with before roles were checked
+	roleLegacyUser = "user"
+)
+
+// Roles lists the roles, from least to most privileged
+var Roles = []string{RoleViewer, RoleDeployer, RoleAdmin}
+
+// Permission allows a kind of operation
+type Permission string
+
+// Permissions
+const (
+	PermServicesRead   Permission = "services:read"   // service status and deployments
+	PermServicesDeploy Permission = "services:deploy" // deploy, scale and roll back
+	PermClusterRead    Permission = "cluster:read"    // clusters, nodes, capacity, metrics, alerts and the policy
+	PermNodesManage    Permission = "nodes:manage"    // drain, activate and rebalance, agent commands and join tokens
+	PermAlertsManage   Permission = "alerts:manage"   // alert rules and silences
+	PermWebhooksManage Permission = "webhooks:manage"
+	PermUsersManage    Permission = "users:manage" // users and their roles
+	PermAuditRead      Permission = "audit:read"
+)
+
+// Permissions lists every permission
+var Permissions = []Permission{
+	PermServicesRead, PermServicesDeploy, PermClusterRead, PermNodesManage,
+	PermAlertsManage, PermWebhooksManage, PermUsersManage, PermAuditRead,
+}
+
+// rolePermissions is the permission matrix: what each role may do
+var rolePermissions = map[string][]Permission{
+	RoleViewer:   {PermServicesRead, PermClusterRead},
+	RoleDeployer: {PermServicesRead, PermClusterRead, PermServicesDeploy},
+	RoleAdmin:    Permissions,
+}
+
+// scopedPermissions are the permissions a role bound to a project or service
+// grants on it. Everything else needs a role on the whole platform.
+var scopedPermissions = []Permission{PermServicesRead, PermServicesDeploy}
+
+// ErrPermissionDenied is returned when a user may not do something
+var ErrPermissionDenied = errors.New("permission denied")
+
+// Binding grants a role on the services of a project, or on a single service,
+// on top of the user's role
+type Binding struct {
+	Role    string `json:"role"`
+	Project string `json:"project,omitempty"`
+	Service string `json:"service,omitempty"`
+}
+
+func (b Binding) String() string {
+	if b.Service != "" {
+		return b.Role + " on service " + b.Service
+	}
+	return b.Role + " on project " + b.Project
+}
+
+// Resource is the service an operation acts on. The zero resource stands for
+// the whole platform.
+type Resource struct {
+	Project string
+	Service string
+}
+
+// ValidRole reports whether a role exists
+func ValidRole(role string) bool {
+	return slices.Contains(Roles, role)
+}
+
+// RolePermissions returns the permissions of a role
+func RolePermissions(role string) []Permission {
+	if role == roleLegacyUser {
+		role = RoleDeployer
+	}
+	return rolePermissions[role]
+}
+
+// Scoped reports whether a permission can be granted on a project or service
+func (p Permission) Scoped() bool {
+	return slices.Contains(scopedPermissions, p)
+}
+
+// Can reports whether the user may do something on a resource: with their
+// role, or a binding on the resource's project or service, and their API
+// token's scopes if they authenticated with one
+func (u *User) Can(perm Permission, res Resource) bool {
+	if u.Scopes != nil && !scopesAllow(u.Scopes, perm, res, false) {
+		return false
+	}
+	if slices.Contains(RolePermissions(u.Role), perm) {
+		return true
+	}
+	if !perm.Scoped() || res.Service == "" {
+		return false
+	}
+	for _, b := range u.Bindings {
+		if b.matches(res) && slices.Contains(RolePermissions(b.Role), perm) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanSome reports whether the user may do something on the whole platform or
+// on at least one project or service. Handlers that learn the resource later
+// check it with Authorize.
+func (u *User) CanSome(perm Permission) bool {
+	if u.Scopes != nil && !scopesAllow(u.Scopes, perm, Resource{}, true) {
+		return false
+	}
+	if slices.Contains(RolePermissions(u.Role), perm) {
+		return true
+	}
+	if !perm.Scoped() {
+		return false
+	}
+	for _, b := range u.Bindings {
+		if slices.Contains(RolePermissions(b.Role), perm) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b Binding) matches(res Resource) bool {
+	if b.Service != "" {
+		return b.Service == res.Service
+	}
+	return b.Project != "" && b.Project == res.Project
+}
+
+// Authorize returns an error wrapping ErrPermissionDenied unless the user may
+// do something on a resource
+func Authorize(u *User, perm Permission, res Resource) error {
+	if u.Can(perm, res) {
+		return nil
+	}
+	who := fmt.Sprintf("%s (%s)", u.Username, u.Role)
+	if u.Scopes != nil {
+		who += " with a token scoped to " + strings.Join(u.Scopes, ", ")
+	}
+	on := ""
+	if res.Service != "" {
+		on = " on " + res.Service
+	}
+	return fmt.Errorf("%w: %s lacks %s%s", ErrPermissionDenied, who, perm, on)
+}
+
+// validateBinding checks a binding names a role and exactly one project or service
+func validateBinding(b Binding) error {
+	if !ValidRole(b.Role) {
+		return fmt.Errorf("unknown role %q (expected %s)", b.Role, strings.Join(Roles, ", "))
+	}
+	if (b.Project == "") == (b.Service == "") {
+		return errors.New("a role binding needs either a project or a service")
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jasonlovesdoggo/velo/internal/state"
+)
+
+func TestCan(t *testing.T) {
+	viewer := &User{Username: "vera", Role: RoleViewer}
+	ci := &User{Username: "ci", Role: RoleViewer, Bindings: []Binding{{Role: RoleDeployer, Service: "web"}}}
+	team := &User{Username: "tess", Role: RoleViewer, Bindings: []Binding{{Role: RoleDeployer, Project: "shop"}}}
+	legacy := &User{Username: "lee", Role: "user"}
+	admin := &User{Username: "root", Role: RoleAdmin}
+
+	tests := []struct {
+		name string
+		user *User
+		perm Permission
+		res  Resource
+		want bool
+	}{
+		{"viewer reads", viewer, PermServicesRead, Resource{}, true},
+		{"viewer deploys", viewer, PermServicesDeploy, Resource{Service: "web"}, false},
+		{"binding on its service", ci, PermServicesDeploy, Resource{Service: "web"}, true},
+		{"binding on another service", ci, PermServicesDeploy, Resource{Service: "api"}, false},
+		{"binding on the platform", ci, PermServicesDeploy, Resource{}, false},
+		{"binding on its project", team, PermServicesDeploy, Resource{Project: "shop", Service: "cart"}, true},
+		{"binding on another project", team, PermServicesDeploy, Resource{Project: "blog", Service: "cart"}, false},
+		{"bindings don't grant unscoped permissions", &User{Role: RoleViewer, Bindings: []Binding{{Role: RoleAdmin, Service: "web"}}}, PermNodesManage, Resource{Service: "web"}, false},
+		{"legacy users deploy", legacy, PermServicesDeploy, Resource{}, true},
+		{"legacy users don't manage users", legacy, PermUsersManage, Resource{}, false},
+		{"admin manages users", admin, PermUsersManage, Resource{}, true},
+		{"unknown roles can't do anything", &User{Role: "root"}, PermServicesRead, Resource{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.user.Can(tt.perm, tt.res); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if !ci.CanSome(PermServicesDeploy) || viewer.CanSome(PermServicesDeploy) || ci.CanSome(PermAuditRead) {
+		t.Error("Expected CanSome to count bindings on scoped permissions only")
+	}
+	if err := Authorize(ci, PermServicesDeploy, Resource{Service: "api"}); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("Expected ErrPermissionDenied, got %v", err)
+	}
+}
+
+func TestRoleManagement(t *testing.T) {
+	a := NewAuthService(state.NewMemoryStateStore())
+
+	if _, err := a.AddUser("ci", "secret-password", "superuser"); err == nil {
+		t.Error("Expected an unknown role to be rejected")
+	}
+	if _, err := a.AddUser("ci", "secret-password", RoleViewer); err != nil {
+		t.Fatalf("AddUser failed: %v", err)
+	}
+	if _, err := a.AddUser("ci", "other-password", RoleViewer); err == nil {
+		t.Error("Expected a duplicate username to be rejected")
+	}
+
+	for _, b := range []Binding{
+		{Role: RoleDeployer},
+		{Role: RoleDeployer, Project: "shop", Service: "web"},
+		{Role: "owner", Service: "web"},
+	} {
+		if _, err := a.Grant("ci", b); err == nil {
+			t.Errorf("Expected binding %+v to be rejected", b)
+		}
+	}
+
+	web := Binding{Role: RoleDeployer, Service: "web"}
+	a.Grant("ci", web)
+	user, err := a.Grant("ci", web)
+	if err != nil || len(user.Bindings) != 1 {
+		t.Fatalf("Expected granting twice to keep one binding, got %v (%v)", user, err)
+	}
+	if user, _ := a.GetUserByUsername("ci"); !user.Can(PermServicesDeploy, Resource{Service: "web"}) {
+		t.Error("Expected the binding to be stored")
+	}
+
+	if _, err := a.Revoke("ci", Binding{Role: RoleDeployer, Service: "api"}); err == nil {
+		t.Error("Expected revoking a missing binding to fail")
+	}
+	if user, err := a.Revoke("ci", web); err != nil || len(user.Bindings) != 0 {
+		t.Errorf("Expected the binding to be revoked, got %v (%v)", user, err)
+	}
+
+	if _, err := a.SetRole("nobody", RoleAdmin); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+	if user, err := a.SetRole("ci", RoleAdmin); err != nil || !user.Can(PermUsersManage, Resource{}) {
+		t.Errorf("Expected ci to become an admin, got %v (%v)", user, err)
+	}
+}
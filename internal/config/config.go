@@ -23,6 +23,21 @@ func LoadConfigFromFile(directoryPath string) (*ServiceDefinition, error) {
 	return config, nil
 }
 
+// LoadServiceFile loads and validates the service definition in a velo.toml file
+func LoadServiceFile(path string) (*ServiceDefinition, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, ErrConfigNotFound
+	}
+	config, err := readServiceFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
 func validateConfig(config *ServiceDefinition) error {
 	// Validate the config fields
 	if config.Name == "" {
@@ -34,6 +49,17 @@ func validateConfig(config *ServiceDefinition) error {
 	if config.Replicas <= 0 {
 		return fmt.Errorf("service replicas must be greater than 0")
 	}
+	if err := validatePlacement(config); err != nil {
+		return err
+	}
+	switch config.Restart.Condition {
+	case "", RestartAny, RestartOnFailure, RestartNone:
+	default:
+		return fmt.Errorf("invalid restart condition %q (expected any, on-failure or none)", config.Restart.Condition)
+	}
+	if config.Hooks.Timeout < 0 {
+		return fmt.Errorf("hook timeout must not be negative")
+	}
 	return nil
 }
 
@@ -42,22 +68,7 @@ func loadConfig(directory string) (*ServiceDefinition, error) {
 		filePath := filepath.Join(directory, dir, FileName)
 		if _, err := os.Stat(filePath); err == nil {
 			// File exists, load it
-			v := viper.New()
-			v.SetConfigFile(filePath)
-			v.SetConfigType("toml") // Explicitly set config type to TOML as requested
-
-			if err := v.ReadInConfig(); err != nil {
-				log.Error("Failed to read config file", "file", filePath, "error", err)
-				return nil, ErrInvalidConfig
-			}
-
-			var config ServiceDefinition
-			if err := v.Unmarshal(&config); err != nil {
-				log.Error("Failed to unmarshal config", "file", filePath, "error", err)
-				return nil, ErrInvalidConfig
-			}
-
-			return &config, nil
+			return readServiceFile(filePath)
 		} else if os.IsNotExist(err) {
 			// File does not exist, continue to the next directory
 			continue
@@ -68,3 +79,23 @@ func loadConfig(directory string) (*ServiceDefinition, error) {
 	}
 	return nil, ErrConfigNotFound
 }
+
+// readServiceFile reads a service definition from a TOML file
+func readServiceFile(filePath string) (*ServiceDefinition, error) {
+	v := viper.New()
+	v.SetConfigFile(filePath)
+	v.SetConfigType("toml") // Explicitly set config type to TOML as requested
+
+	if err := v.ReadInConfig(); err != nil {
+		log.Error("Failed to read config file", "file", filePath, "error", err)
+		return nil, ErrInvalidConfig
+	}
+
+	var config ServiceDefinition
+	if err := v.Unmarshal(&config); err != nil {
+		log.Error("Failed to unmarshal config", "file", filePath, "error", err)
+		return nil, ErrInvalidConfig
+	}
+
+	return &config, nil
+}
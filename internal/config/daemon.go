@@ -0,0 +1,331 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/spf13/viper"
+)
+
+// Orchestration backends the velo daemon can run against
+const (
+	BackendSwarm      = "swarm"
+	BackendStandalone = "standalone"
+	BackendSim        = "sim" // in-memory simulated cluster, for development
+)
+
+// DefaultDaemonConfigPath is where the velo daemon looks for its config file
+const DefaultDaemonConfigPath = "/etc/velo/daemon.toml"
+
+// DaemonConfig holds the settings of the velo manager daemon
+type DaemonConfig struct {
+	Backend        string           `mapstructure:"backend"`
+	WebPort        string           `mapstructure:"web_port"`
+	CapacityPolicy string           `mapstructure:"capacity_policy"`
+	Standalone     StandaloneConfig `mapstructure:"standalone"`
+	DefaultCluster string           `mapstructure:"default_cluster"`
+	Clusters       []ClusterConfig  `mapstructure:"clusters"`
+	Metrics        MetricsConfig    `mapstructure:"metrics"`
+	Tracing        TracingConfig    `mapstructure:"tracing"`
+	Alerting       AlertingConfig   `mapstructure:"alerting"`
+	Webhooks       WebhooksConfig   `mapstructure:"webhooks"`
+	Auth           AuthConfig       `mapstructure:"auth"`
+	Admission      []AdmissionHook  `mapstructure:"admission"`
+	PolicyFile     string           `mapstructure:"policy_file"` // policy deploys are checked against; empty checks nothing
+}
+
+// AdmissionHook registers a webhook that is asked to allow, deny or change
+// every deploy before it's made
+type AdmissionHook struct {
+	Name     string `mapstructure:"name"`
+	URL      string `mapstructure:"url"`
+	Timeout  int    `mapstructure:"timeout"`   // seconds the hook has to respond; 0 uses DefaultAdmissionTimeout
+	FailOpen bool   `mapstructure:"fail_open"` // allow deploys when the hook can't be reached or answers garbage
+}
+
+// DefaultAdmissionTimeout is how long an admission webhook has to respond unless configured
+const DefaultAdmissionTimeout = 10
+
+// WebhooksConfig holds how outbound webhook deliveries are retried.
+// Subscriptions are kept in the state store.
+type WebhooksConfig struct {
+	MaxAttempts  int `mapstructure:"max_attempts"`  // attempts before a delivery is given up on
+	RetryBackoff int `mapstructure:"retry_backoff"` // seconds before the first retry, doubled on every further retry
+	MaxBackoff   int `mapstructure:"max_backoff"`   // minutes the wait between retries is capped at
+	Timeout      int `mapstructure:"timeout"`       // seconds an endpoint has to respond
+}
+
+// AuthConfig holds the password policy and how failed logins are throttled
+type AuthConfig struct {
+	PasswordMinLength     int  `mapstructure:"password_min_length"`
+	PasswordRequireUpper  bool `mapstructure:"password_require_upper"`
+	PasswordRequireLower  bool `mapstructure:"password_require_lower"`
+	PasswordRequireDigit  bool `mapstructure:"password_require_digit"`
+	PasswordRequireSymbol bool `mapstructure:"password_require_symbol"`
+
+	LockoutThreshold int `mapstructure:"lockout_threshold"` // failed logins in a row that lock out an account or IP address; 0 disables lockout
+	LockoutBackoff   int `mapstructure:"lockout_backoff"`   // seconds the first lockout lasts, doubled on every further failure
+	MaxLockout       int `mapstructure:"max_lockout"`       // minutes lockouts are capped at
+	LoginRate        int `mapstructure:"login_rate"`        // logins a minute an IP address may attempt on the web UI; 0 removes the limit
+}
+
+// Alert receiver types
+const (
+	ReceiverWebhook = "webhook"
+	ReceiverSlack   = "slack"
+	ReceiverEmail   = "email"
+)
+
+// AlertingConfig holds the settings of the alert evaluator and where it sends
+// notifications. Rules and silences are kept in the state store.
+type AlertingConfig struct {
+	Interval       int              `mapstructure:"interval"`        // seconds between rule evaluations
+	RepeatInterval int              `mapstructure:"repeat_interval"` // minutes before a still-firing group is notified again
+	Receivers      []ReceiverConfig `mapstructure:"receivers"`
+}
+
+// ReceiverConfig registers a destination for alert notifications
+type ReceiverConfig struct {
+	Name    string            `mapstructure:"name"`
+	Type    string            `mapstructure:"type"`    // webhook, slack or email
+	URL     string            `mapstructure:"url"`     // webhook and slack
+	Headers map[string]string `mapstructure:"headers"` // webhook, e.g. an Authorization header
+	Channel string            `mapstructure:"channel"` // slack, overrides the webhook's channel
+
+	SMTPAddr string   `mapstructure:"smtp_addr"` // email, host:port of the mail server
+	Username string   `mapstructure:"username"`  // email, for PLAIN auth; empty sends without auth
+	Password string   `mapstructure:"password"`
+	From     string   `mapstructure:"from"`
+	To       []string `mapstructure:"to"`
+}
+
+// TracingConfig holds where the manager exports its traces
+type TracingConfig struct {
+	Endpoint    string  `mapstructure:"endpoint"`     // OTLP/HTTP collector as host:port or URL; empty uses OTEL_EXPORTER_OTLP_ENDPOINT, if set
+	Insecure    bool    `mapstructure:"insecure"`     // plain HTTP to a host:port endpoint
+	SampleRatio float64 `mapstructure:"sample_ratio"` // fraction of new traces recorded
+}
+
+// MetricsConfig holds the settings of the metrics history, which keeps container
+// metrics past the hour the manager holds in memory, and of the Prometheus endpoint
+type MetricsConfig struct {
+	Path            string `mapstructure:"path"`             // SQLite database; empty disables the history
+	RawRetention    int    `mapstructure:"raw_retention"`    // hours raw samples are kept
+	MinuteRetention int    `mapstructure:"minute_retention"` // hours 1m rollups are kept
+	HourRetention   int    `mapstructure:"hour_retention"`   // hours 1h rollups are kept
+	ScrapeToken     string `mapstructure:"scrape_token"`     // bearer token required on /metrics; empty leaves it open
+}
+
+// ClusterConfig registers a Docker endpoint the manager deploys to. Without any
+// clusters the manager runs a single cluster named "default" against the local
+// Docker endpoint with the top-level backend.
+type ClusterConfig struct {
+	Name      string `mapstructure:"name"`
+	Backend   string `mapstructure:"backend"`    // swarm (default), standalone or sim
+	Host      string `mapstructure:"host"`       // unix:///var/run/docker.sock, tcp://host:2376 or ssh://user@host; empty uses DOCKER_HOST
+	TLSVerify bool   `mapstructure:"tls_verify"` // verify the daemon's certificate, for tcp hosts
+	TLSCACert string `mapstructure:"tls_ca_cert"`
+	TLSCert   string `mapstructure:"tls_cert"`
+	TLSKey    string `mapstructure:"tls_key"`
+}
+
+// LocalClusterName is the name of the cluster of a manager without cluster registrations
+const LocalClusterName = "default"
+
+// StandaloneConfig holds the settings of the standalone (non-swarm) backend
+type StandaloneConfig struct {
+	ReconcileInterval int `mapstructure:"reconcile_interval"` // seconds between reconcile passes
+	UpdateMonitor     int `mapstructure:"update_monitor"`     // seconds a new replica has to become ready during an update
+	StopTimeout       int `mapstructure:"stop_timeout"`       // seconds to wait for a container to stop
+}
+
+// DefaultDaemonConfig returns the daemon settings used when no config file exists
+func DefaultDaemonConfig() DaemonConfig {
+	return DaemonConfig{
+		Backend:        BackendSwarm,
+		WebPort:        "8080",
+		CapacityPolicy: "enforce",
+		Standalone: StandaloneConfig{
+			ReconcileInterval: 10,
+			UpdateMonitor:     30,
+			StopTimeout:       10,
+		},
+		Metrics: MetricsConfig{
+			Path:            "/tmp/velo-metrics.db",
+			RawRetention:    24,
+			MinuteRetention: 7 * 24,
+			HourRetention:   90 * 24,
+		},
+		Tracing: TracingConfig{
+			SampleRatio: 1,
+		},
+		Alerting: AlertingConfig{
+			Interval:       30,
+			RepeatInterval: 4 * 60,
+		},
+		Webhooks: WebhooksConfig{
+			MaxAttempts:  8,
+			RetryBackoff: 10,
+			MaxBackoff:   60,
+			Timeout:      10,
+		},
+		Auth: AuthConfig{
+			PasswordMinLength: 10,
+			LockoutThreshold:  5,
+			LockoutBackoff:    30,
+			MaxLockout:        60,
+			LoginRate:         10,
+		},
+	}
+}
+
+// LoadDaemonConfig reads the daemon config file at path on top of the defaults.
+// A missing file at the default path is not an error.
+func LoadDaemonConfig(path string) (DaemonConfig, error) {
+	cfg := DefaultDaemonConfig()
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) && path == DefaultDaemonConfigPath {
+		return cfg, nil
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("toml")
+	if err := v.ReadInConfig(); err != nil {
+		return cfg, fmt.Errorf("failed to read daemon config %s: %w", path, err)
+	}
+	if err := v.Unmarshal(&cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse daemon config %s: %w", path, err)
+	}
+	if err := validateClusters(cfg); err != nil {
+		return cfg, fmt.Errorf("invalid daemon config %s: %w", path, err)
+	}
+	if err := validateReceivers(cfg.Alerting.Receivers); err != nil {
+		return cfg, fmt.Errorf("invalid daemon config %s: %w", path, err)
+	}
+	if err := validateAdmission(cfg.Admission); err != nil {
+		return cfg, fmt.Errorf("invalid daemon config %s: %w", path, err)
+	}
+	if err := validateAuth(cfg.Auth); err != nil {
+		return cfg, fmt.Errorf("invalid daemon config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// ClusterConfigs returns the clusters the manager runs, falling back to a
+// single default cluster on the local Docker endpoint
+func (c DaemonConfig) ClusterConfigs() []ClusterConfig {
+	if len(c.Clusters) == 0 {
+		return []ClusterConfig{{Name: LocalClusterName, Backend: c.Backend}}
+	}
+
+	clusters := make([]ClusterConfig, len(c.Clusters))
+	for i, cluster := range c.Clusters {
+		if cluster.Backend == "" {
+			cluster.Backend = BackendSwarm
+		}
+		clusters[i] = cluster
+	}
+	return clusters
+}
+
+// DefaultClusterName returns the cluster used when a request doesn't select one
+func (c DaemonConfig) DefaultClusterName() string {
+	if c.DefaultCluster != "" {
+		return c.DefaultCluster
+	}
+	return c.ClusterConfigs()[0].Name
+}
+
+// validateClusters checks cluster registrations for missing and duplicate names
+func validateClusters(cfg DaemonConfig) error {
+	names := make(map[string]bool)
+	for i, cluster := range cfg.Clusters {
+		if cluster.Name == "" {
+			return fmt.Errorf("cluster %d has no name", i+1)
+		}
+		if names[cluster.Name] {
+			return fmt.Errorf("cluster %s is registered twice", cluster.Name)
+		}
+		names[cluster.Name] = true
+
+		switch cluster.Backend {
+		case "", BackendSwarm, BackendStandalone, BackendSim:
+		default:
+			return fmt.Errorf("cluster %s has unknown backend %q", cluster.Name, cluster.Backend)
+		}
+		if (cluster.TLSCert == "") != (cluster.TLSKey == "") {
+			return fmt.Errorf("cluster %s needs both tls_cert and tls_key", cluster.Name)
+		}
+	}
+
+	if cfg.DefaultCluster != "" && len(cfg.Clusters) > 0 && !names[cfg.DefaultCluster] {
+		return fmt.Errorf("default cluster %s is not registered", cfg.DefaultCluster)
+	}
+	return nil
+}
+
+// validateReceivers checks alert receivers for missing and duplicate names and
+// the settings their type needs
+func validateReceivers(receivers []ReceiverConfig) error {
+	names := make(map[string]bool)
+	for i, r := range receivers {
+		if r.Name == "" {
+			return fmt.Errorf("receiver %d has no name", i+1)
+		}
+		if names[r.Name] {
+			return fmt.Errorf("receiver %s is registered twice", r.Name)
+		}
+		names[r.Name] = true
+
+		switch r.Type {
+		case ReceiverWebhook, ReceiverSlack:
+			if r.URL == "" {
+				return fmt.Errorf("receiver %s needs a url", r.Name)
+			}
+		case ReceiverEmail:
+			if r.SMTPAddr == "" || r.From == "" || len(r.To) == 0 {
+				return fmt.Errorf("receiver %s needs smtp_addr, from and to", r.Name)
+			}
+		default:
+			return fmt.Errorf("receiver %s has unknown type %q (expected webhook, slack or email)", r.Name, r.Type)
+		}
+	}
+	return nil
+}
+
+// validateAdmission checks admission webhooks for missing and duplicate names,
+// URLs and negative timeouts
+func validateAdmission(hooks []AdmissionHook) error {
+	names := make(map[string]bool)
+	for i, h := range hooks {
+		if h.Name == "" {
+			return fmt.Errorf("admission webhook %d has no name", i+1)
+		}
+		if names[h.Name] {
+			return fmt.Errorf("admission webhook %s is registered twice", h.Name)
+		}
+		names[h.Name] = true
+		if u, err := url.Parse(h.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("admission webhook %s needs an http or https url", h.Name)
+		}
+		if h.Timeout < 0 {
+			return fmt.Errorf("admission webhook %s has a negative timeout", h.Name)
+		}
+	}
+	return nil
+}
+
+// validateAuth checks the password policy and lockout settings
+func validateAuth(a AuthConfig) error {
+	if a.PasswordMinLength < 1 {
+		return errors.New("auth.password_min_length must be at least 1")
+	}
+	if a.LockoutThreshold < 0 || a.LockoutBackoff < 0 || a.MaxLockout < 0 || a.LoginRate < 0 {
+		return errors.New("auth lockout and rate settings can't be negative")
+	}
+	return nil
+}
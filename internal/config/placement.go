@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Constraint is a parsed placement constraint such as node.labels.zone==eu
+type Constraint struct {
+	Key      string
+	Operator string // == or !=
+	Value    string
+}
+
+// String returns the constraint in swarm syntax
+func (c Constraint) String() string {
+	return c.Key + c.Operator + c.Value
+}
+
+// ParseConstraint parses a swarm placement constraint expression
+func ParseConstraint(expr string) (Constraint, error) {
+	for _, op := range []string{"==", "!="} {
+		if key, value, found := strings.Cut(expr, op); found {
+			key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+			if key == "" || value == "" {
+				break
+			}
+			if !strings.HasPrefix(key, "node.") && !strings.HasPrefix(key, "engine.labels.") {
+				return Constraint{}, fmt.Errorf("invalid constraint %q: key must start with node. or engine.labels.", expr)
+			}
+			return Constraint{Key: key, Operator: op, Value: value}, nil
+		}
+	}
+	return Constraint{}, fmt.Errorf("invalid constraint %q: expected <key>==<value> or <key>!=<value>", expr)
+}
+
+// ParseConstraints parses all constraints of a service definition
+func (s *ServiceDefinition) ParseConstraints() ([]Constraint, error) {
+	constraints := make([]Constraint, 0, len(s.Constraints))
+	for _, expr := range s.Constraints {
+		c, err := ParseConstraint(expr)
+		if err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, c)
+	}
+	return constraints, nil
+}
+
+// validatePlacement checks the syntax of constraints and placement preferences
+func validatePlacement(config *ServiceDefinition) error {
+	if _, err := config.ParseConstraints(); err != nil {
+		return err
+	}
+	for _, pref := range config.Placement.Preferences {
+		if !strings.HasPrefix(pref.Spread, "node.labels.") && !strings.HasPrefix(pref.Spread, "engine.labels.") {
+			return fmt.Errorf("invalid placement preference %q: spread must be a node.labels. or engine.labels. key", pref.Spread)
+		}
+	}
+	return nil
+}
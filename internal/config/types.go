@@ -1,43 +1,126 @@
 package config
 
+import "strings"
+
+// ServiceDefinition is a service to deploy, as declared in velo.toml. Its JSON
+// form, e.g. in admission webhook requests, uses the same field names.
 type ServiceDefinition struct {
-	Name         string            `mapstructure:"name"`
-	Image        string            `mapstructure:"image"`
-	Environment  map[string]string `mapstructure:"environment"`
-	Replicas     int               `mapstructure:"replicas"`
-	Labels       map[string]string `mapstructure:"labels"`
-	Networks     []string          `mapstructure:"networks"`
-	Volumes      []VolumeMount     `mapstructure:"volumes"`
-	Resources    ResourceConfig    `mapstructure:"resources"`
-	HealthCheck  HealthCheckConfig `mapstructure:"healthcheck"`
-	Constraints  []string          `mapstructure:"constraints"`
-	Dependencies []string          `mapstructure:"dependencies"`
+	Name         string            `mapstructure:"name" json:"name,omitempty"`
+	Image        string            `mapstructure:"image" json:"image,omitempty"`
+	Environment  map[string]string `mapstructure:"environment" json:"environment"`
+	Replicas     int               `mapstructure:"replicas" json:"replicas,omitempty"`
+	Labels       map[string]string `mapstructure:"labels" json:"labels"`
+	Networks     []string          `mapstructure:"networks" json:"networks,omitempty"`
+	Volumes      []VolumeMount     `mapstructure:"volumes" json:"volumes,omitempty"`
+	Resources    ResourceConfig    `mapstructure:"resources" json:"resources,omitempty"`
+	HealthCheck  HealthCheckConfig `mapstructure:"healthcheck" json:"healthcheck,omitempty"`
+	Constraints  []string          `mapstructure:"constraints" json:"constraints,omitempty"`
+	Placement    PlacementConfig   `mapstructure:"placement" json:"placement,omitempty"`
+	Restart      RestartPolicy     `mapstructure:"restart_policy" json:"restart_policy,omitempty"`
+	Dependencies []string          `mapstructure:"dependencies" json:"dependencies,omitempty"`
+	PreDeploy    []string          `mapstructure:"pre_deploy" json:"pre_deploy,omitempty"`   // command run as a one-off job before the rollout; a non-zero exit aborts the deploy
+	PostDeploy   []string          `mapstructure:"post_deploy" json:"post_deploy,omitempty"` // command run as a one-off job once the rollout is healthy
+	Hooks        HookConfig        `mapstructure:"hooks" json:"hooks,omitempty"`
+}
+
+// DefaultHookTimeout is how long a deploy hook may run unless configured
+const DefaultHookTimeout = 10 * 60
+
+// HookConfig holds how deploy hooks run
+type HookConfig struct {
+	Timeout           int  `mapstructure:"timeout" json:"timeout,omitempty"`                         // seconds each hook may run, and the rollout may take before post_deploy; 0 uses DefaultHookTimeout
+	RollbackOnFailure bool `mapstructure:"rollback_on_failure" json:"rollback_on_failure,omitempty"` // roll the deploy back when post_deploy fails
+}
+
+// ProjectLabel is the service label that names the project a service belongs to
+const ProjectLabel = "velo.project"
+
+// Project returns the project a service belongs to: its velo.project label, or
+// the service itself
+func (def ServiceDefinition) Project() string {
+	if project := def.Labels[ProjectLabel]; project != "" {
+		return project
+	}
+	return def.Name
+}
+
+// HasHooks reports whether the service declares deploy hooks
+func (def ServiceDefinition) HasHooks() bool {
+	return len(def.PreDeploy) > 0 || len(def.PostDeploy) > 0
 }
 
 type VolumeMount struct {
-	Source      string `mapstructure:"source"`
-	Destination string `mapstructure:"destination"`
-	ReadOnly    bool   `mapstructure:"readonly"`
+	Source      string `mapstructure:"source" json:"source,omitempty"`
+	Destination string `mapstructure:"destination" json:"destination,omitempty"`
+	ReadOnly    bool   `mapstructure:"readonly" json:"readonly,omitempty"`
+}
+
+// IsBind reports whether a volume mounts a host path rather than a named volume
+func (v VolumeMount) IsBind() bool {
+	return strings.HasPrefix(v.Source, "/") || strings.HasPrefix(v.Source, ".")
 }
 
 type ResourceConfig struct {
-	CPULimit      float64 `mapstructure:"cpu_limit"`
-	MemoryLimit   int64   `mapstructure:"memory_limit"`
-	CPUReserve    float64 `mapstructure:"cpu_reserve"`
-	MemoryReserve int64   `mapstructure:"memory_reserve"`
+	CPULimit      float64 `mapstructure:"cpu_limit" json:"cpu_limit,omitempty"`
+	MemoryLimit   int64   `mapstructure:"memory_limit" json:"memory_limit,omitempty"`
+	CPUReserve    float64 `mapstructure:"cpu_reserve" json:"cpu_reserve,omitempty"`
+	MemoryReserve int64   `mapstructure:"memory_reserve" json:"memory_reserve,omitempty"`
+}
+
+type PlacementConfig struct {
+	Preferences        []PlacementPreference `mapstructure:"preferences" json:"preferences,omitempty"`
+	MaxReplicasPerNode uint64                `mapstructure:"max_replicas_per_node" json:"max_replicas_per_node,omitempty"`
+}
+
+type PlacementPreference struct {
+	Spread string `mapstructure:"spread" json:"spread,omitempty"` // label to spread tasks over, e.g. node.labels.zone
+}
+
+// Restart conditions for RestartPolicy
+const (
+	RestartAny       = "any"
+	RestartOnFailure = "on-failure"
+	RestartNone      = "none"
+)
+
+type RestartPolicy struct {
+	Condition   string `mapstructure:"condition" json:"condition,omitempty"`       // any, on-failure or none
+	MaxAttempts uint64 `mapstructure:"max_attempts" json:"max_attempts,omitempty"` // 0 means unlimited
+	Delay       int    `mapstructure:"delay" json:"delay,omitempty"`               // seconds between restarts
 }
 
 type HealthCheckConfig struct {
-	Command     []string `mapstructure:"command"`
-	Interval    int      `mapstructure:"interval"`
-	Timeout     int      `mapstructure:"timeout"`
-	Retries     int      `mapstructure:"retries"`
-	StartPeriod int      `mapstructure:"start_period"`
+	Command     []string `mapstructure:"command" json:"command,omitempty"`
+	Interval    int      `mapstructure:"interval" json:"interval,omitempty"`
+	Timeout     int      `mapstructure:"timeout" json:"timeout,omitempty"`
+	Retries     int      `mapstructure:"retries" json:"retries,omitempty"`
+	StartPeriod int      `mapstructure:"start_period" json:"start_period,omitempty"`
+	OnFailure   string   `mapstructure:"on_failure" json:"on_failure,omitempty"` // restart, report or ignore; what node agents do about unhealthy or crash-looping containers
+}
+
+// Health policies for HealthCheckConfig.OnFailure
+const (
+	HealthPolicyRestart = "restart"
+	HealthPolicyReport  = "report"
+	HealthPolicyIgnore  = "ignore"
+)
+
+// HealthPolicyLabel is the container label that carries a service's health policy to node agents
+const HealthPolicyLabel = "velo.health.policy"
+
+// ValidHealthPolicy reports whether policy is a known health policy. Empty selects the agent's default.
+func ValidHealthPolicy(policy string) bool {
+	switch policy {
+	case "", HealthPolicyRestart, HealthPolicyReport, HealthPolicyIgnore:
+		return true
+	}
+	return false
 }
 
 type DeploymentStatus struct {
 	ID      string
 	Service ServiceDefinition
 	State   string // pending, running, failed
+	Running int    // replicas currently running
 	Logs    string
 }
@@ -3,14 +3,26 @@ package gateway
 import (
 	"crypto/subtle"
 	"fmt"
+	"github.com/jasonlovesdoggo/velo/internal/audit"
 	"github.com/jasonlovesdoggo/velo/internal/log"
+	"github.com/jasonlovesdoggo/velo/internal/metrics"
 	"github.com/jasonlovesdoggo/velo/pkg/core"
 	"net/http"
 )
 
 // an HTTP gateway for external access to the Velo system. TODO :)
 
-func Start(port string) error {
+var auditLog *audit.Log
+
+// SetAudit records the calls of the deploy hook in an audit log. It must be
+// called before Start.
+func SetAudit(l *audit.Log) {
+	auditLog = l
+}
+
+// Start serves the gateway on port. The gateway is the public entry point, so it
+// only serves metrics when scrapers must send metricsToken.
+func Start(port, metricsToken string) error {
 	// TODO: Hook into gRPC handlers or REST endpoints
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("OK"))
@@ -20,11 +32,15 @@ func Start(port string) error {
 		w.Write([]byte("Velo version: " + core.Version))
 	})
 
-	http.HandleFunc("/hooks/deploy", DeployHookHandler)
+	http.HandleFunc("/hooks/deploy", auditLog.Handler("DeployHook", DeployHookHandler))
 	// todo: ratelimit this endpoint
 
+	if metricsToken != "" {
+		http.Handle("/metrics", metrics.Handler(metricsToken))
+	}
+
 	log.Info("Gateway listening", "port", port)
-	return http.ListenAndServe(":"+port, nil)
+	return http.ListenAndServe(":"+port, metrics.InstrumentMux(http.DefaultServeMux))
 }
 
 func DeployHookHandler(w http.ResponseWriter, r *http.Request) {
@@ -35,6 +51,9 @@ func DeployHookHandler(w http.ResponseWriter, r *http.Request) {
 
 	secret := r.URL.Query().Get("velo_secret")
 	projectSlug := r.URL.Query().Get("project_slug")
+	entry := audit.FromContext(r.Context())
+	entry.Source = audit.SourceWebhook
+	entry.Target = projectSlug
 	if secret == "" || projectSlug == "" {
 		log.Warn("Deploy hook called with missing secret or project_slug")
 		http.Error(w, "Missing velo_secret or project_slug query parameter", http.StatusBadRequest)
@@ -0,0 +1,204 @@
+package metrics
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/cluster"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/manager"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// UsageWindow is how recent the last sample of a container must be for its
+// resource usage to be exported. Containers that stopped drop out after it.
+const UsageWindow = 2 * time.Minute
+
+// Caps on the services and nodes exported per cluster, so that a runaway
+// cluster can't blow up the number of series. What's left out is counted in
+// velo_metrics_truncated.
+const (
+	maxServices    = 1000
+	maxNodes       = 500
+	maxUsageSeries = 5000 // service and node pairs
+)
+
+// Node states and availabilities exported as one series per value, so they
+// can be graphed and alerted on like enums
+var (
+	nodeStates         = []string{"ready", "down", "disconnected", "unknown"}
+	nodeAvailabilities = []string{"active", "pause", "drain"}
+)
+
+var (
+	clusterUp = desc("cluster_up", "Whether the cluster's backend passed its last health check.", "cluster")
+
+	replicasDesired = desc("service_replicas_desired", "Replicas a service is meant to run.", "cluster", "service")
+	replicasRunning = desc("service_replicas_running", "Replicas of a service currently running.", "cluster", "service")
+
+	nodeState        = desc("node_state", "Set to 1 for the current state of a node (ready, down, disconnected or unknown).", "cluster", "node", "state")
+	nodeAvailability = desc("node_availability", "Set to 1 for the current availability of a node (active, pause or drain).", "cluster", "node", "availability")
+	nodeAgentUp      = desc("node_agent_up", "Whether the node's agent is sending heartbeats. Nodes that never ran an agent aren't exported.", "cluster", "node")
+
+	usageContainers = desc("service_containers", "Containers of a service on a node sampled by its agent.", "cluster", "service", "node")
+	usageCPU        = desc("service_cpu_percent", "CPU used by the containers of a service on a node, in percent of a core.", "cluster", "service", "node")
+	usageMemory     = desc("service_memory_usage_bytes", "Memory used by the containers of a service on a node, without the page cache.", "cluster", "service", "node")
+	usageMemLimit   = desc("service_memory_limit_bytes", "Memory limit of the containers of a service on a node.", "cluster", "service", "node")
+	usageNetRx      = desc("service_network_receive_bytes_per_second", "Network traffic received by the containers of a service on a node.", "cluster", "service", "node")
+	usageNetTx      = desc("service_network_transmit_bytes_per_second", "Network traffic sent by the containers of a service on a node.", "cluster", "service", "node")
+	usageBlockRead  = desc("service_block_read_bytes_per_second", "Block I/O read by the containers of a service on a node.", "cluster", "service", "node")
+	usageBlockWrite = desc("service_block_write_bytes_per_second", "Block I/O written by the containers of a service on a node.", "cluster", "service", "node")
+
+	truncated = desc("metrics_truncated", "Services, nodes or usage series of a cluster left out of the metrics because of the caps on series.", "cluster", "kind")
+)
+
+func desc(name, help string, labels ...string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, labels, nil)
+}
+
+// ClusterCollector exports the state of the registered clusters (services,
+// nodes and the resource usage sampled by agents) when scraped
+type ClusterCollector struct {
+	clusters *cluster.Registry
+}
+
+// NewClusterCollector creates a collector for the clusters of a registry
+func NewClusterCollector(clusters *cluster.Registry) *ClusterCollector {
+	return &ClusterCollector{clusters: clusters}
+}
+
+// Describe implements prometheus.Collector
+func (cc *ClusterCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		clusterUp, replicasDesired, replicasRunning, nodeState, nodeAvailability, nodeAgentUp,
+		usageContainers, usageCPU, usageMemory, usageMemLimit, usageNetRx, usageNetTx, usageBlockRead, usageBlockWrite,
+		truncated,
+	} {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector
+func (cc *ClusterCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range cc.clusters.Clusters() {
+		healthy := c.Healthy()
+		ch <- gauge(clusterUp, boolValue(healthy), c.Name)
+		if !healthy {
+			continue
+		}
+
+		nodes := collectNodes(ch, c)
+		collectServices(ch, c)
+		collectUsage(ch, c, nodes)
+	}
+}
+
+// collectNodes exports the nodes of a cluster and returns the node label of
+// every node ID: its hostname, or the ID if the hostname is missing or taken
+func collectNodes(ch chan<- prometheus.Metric, c *cluster.Cluster) map[string]string {
+	nodes := c.Nodes()
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+
+	labels := make(map[string]string, len(nodes))
+	taken := make(map[string]bool, len(nodes))
+	for i, n := range nodes {
+		if i >= maxNodes {
+			ch <- gauge(truncated, float64(len(nodes)-maxNodes), c.Name, "node")
+			break
+		}
+		label := n.Hostname
+		if label == "" || taken[label] {
+			label = n.ID
+		}
+		taken[label] = true
+		labels[n.ID] = label
+
+		state := "unknown"
+		if len(n.Conditions) > 0 && slices.Contains(nodeStates, n.Conditions[0]) {
+			state = n.Conditions[0]
+		}
+		for _, s := range nodeStates {
+			ch <- gauge(nodeState, boolValue(s == state), c.Name, label, s)
+		}
+		for _, a := range nodeAvailabilities {
+			ch <- gauge(nodeAvailability, boolValue(a == n.Availability), c.Name, label, a)
+		}
+		if n.Agent != nil {
+			ch <- gauge(nodeAgentUp, boolValue(!n.AgentMissing), c.Name, label)
+		}
+	}
+	return labels
+}
+
+// collectServices exports the desired and running replicas of a cluster's services
+func collectServices(ch chan<- prometheus.Metric, c *cluster.Cluster) {
+	sm, ok := c.Manager.(manager.ServiceManager)
+	if !ok {
+		return
+	}
+	services, err := sm.ListServices()
+	if err != nil {
+		return
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Service.Name < services[j].Service.Name })
+
+	seen := make(map[string]bool, len(services))
+	for _, s := range services {
+		if seen[s.Service.Name] {
+			continue
+		}
+		if len(seen) == maxServices {
+			ch <- gauge(truncated, float64(len(services)-maxServices), c.Name, "service")
+			break
+		}
+		seen[s.Service.Name] = true
+		ch <- gauge(replicasDesired, float64(s.Service.Replicas), c.Name, s.Service.Name)
+		ch <- gauge(replicasRunning, float64(s.Running), c.Name, s.Service.Name)
+	}
+}
+
+// collectUsage exports the recent resource usage of a cluster's services per node
+func collectUsage(ch chan<- prometheus.Metric, c *cluster.Cluster, nodes map[string]string) {
+	seen := make(map[[2]string]bool)
+	dropped := 0
+	for _, u := range c.CurrentUsage(time.Now().Add(-UsageWindow)) {
+		node, ok := nodes[u.Node]
+		if !ok {
+			// Only nodes that are still listed, which keeps the caps on nodes
+			continue
+		}
+		key := [2]string{u.Service, node}
+		if seen[key] {
+			continue
+		}
+		if len(seen) == maxUsageSeries {
+			dropped++
+			continue
+		}
+		seen[key] = true
+
+		labels := []string{c.Name, u.Service, node}
+		ch <- gauge(usageContainers, float64(u.Containers), labels...)
+		ch <- gauge(usageCPU, u.CPUPercent, labels...)
+		ch <- gauge(usageMemory, float64(u.MemoryUsage), labels...)
+		ch <- gauge(usageMemLimit, float64(u.MemoryLimit), labels...)
+		ch <- gauge(usageNetRx, u.NetRxRate, labels...)
+		ch <- gauge(usageNetTx, u.NetTxRate, labels...)
+		ch <- gauge(usageBlockRead, u.BlockReadRate, labels...)
+		ch <- gauge(usageBlockWrite, u.BlockWriteRate, labels...)
+	}
+	if dropped > 0 {
+		ch <- gauge(truncated, float64(dropped), c.Name, "usage")
+	}
+}
+
+func gauge(d *prometheus.Desc, value float64, labels ...string) prometheus.Metric {
+	return prometheus.MustNewConstMetric(d, prometheus.GaugeValue, value, labels...)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/manager"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Deploy outcomes
+const (
+	OutcomeSuccess  = "success"
+	OutcomeRejected = "rejected" // didn't fit on the cluster
+	OutcomeFailed   = "failed"
+)
+
+var (
+	deploys = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "deploys_total",
+		Help:      "Deploys handled, by cluster and outcome.",
+	}, []string{"cluster", "outcome"})
+
+	deployDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "deploy_duration_seconds",
+		Help:      "Time taken by deploys, by cluster and outcome.",
+		Buckets:   prometheus.ExponentialBuckets(0.25, 2, 12), // 250ms to ~8.5m
+	}, []string{"cluster", "outcome"})
+)
+
+func init() {
+	Registry.MustRegister(deploys, deployDuration)
+}
+
+// ObserveDeploy records a deploy to a cluster that started at start and ended with err
+func ObserveDeploy(cluster string, start time.Time, err error) {
+	outcome := OutcomeSuccess
+	switch {
+	case errors.Is(err, manager.ErrInsufficientCapacity), errors.Is(err, manager.ErrUnsatisfiablePlacement):
+		outcome = OutcomeRejected
+	case err != nil:
+		outcome = OutcomeFailed
+	}
+	deploys.WithLabelValues(cluster, outcome).Inc()
+	deployDuration.WithLabelValues(cluster, outcome).Observe(time.Since(start).Seconds())
+}
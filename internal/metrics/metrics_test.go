@@ -0,0 +1,207 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/config"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/cluster"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/manager"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/sim"
+	"github.com/jasonlovesdoggo/velo/internal/state"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// scrape returns the text exposition of a registry
+func scrape(t *testing.T, handler http.Handler, header http.Header) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	body, _ := io.ReadAll(rec.Body)
+	return rec.Code, string(body)
+}
+
+func TestClusterCollector(t *testing.T) {
+	nodes := sim.DefaultNodes()
+	nodes[1].Conditions = []string{"down"}
+	nodes[2].Availability = "drain"
+	o := sim.New(sim.Options{Nodes: nodes})
+	clusters := cluster.Single(o)
+	clusters.CheckHealth(context.Background())
+	defer clusters.Stop()
+
+	if _, err := o.DeployService(config.ServiceDefinition{Name: "web", Image: "nginx", Replicas: 2}); err != nil {
+		t.Fatalf("DeployService failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		o.Step()
+	}
+
+	c, _ := clusters.Get("")
+	now := time.Now()
+	c.Metrics.Add("node-1", []cluster.ContainerSample{
+		{Time: now, ContainerID: "a", Service: "web", CPUPercent: 10, MemoryUsage: 100},
+		{Time: now, ContainerID: "b", Service: "web", CPUPercent: 15, MemoryUsage: 50},
+		{Time: now.Add(-time.Hour), ContainerID: "old", Service: "web", CPUPercent: 99},
+	})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewClusterCollector(clusters))
+	_, body := scrape(t, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), nil)
+
+	for _, want := range []string{
+		`velo_cluster_up{cluster="default"} 1`,
+		`velo_service_replicas_desired{cluster="default",service="web"} 2`,
+		`velo_service_replicas_running{cluster="default",service="web"} 2`,
+		`velo_node_state{cluster="default",node="manager-1",state="ready"} 1`,
+		`velo_node_state{cluster="default",node="manager-1",state="down"} 0`,
+		`velo_node_state{cluster="default",node="worker-1",state="down"} 1`,
+		`velo_node_availability{availability="drain",cluster="default",node="worker-2"} 1`,
+		`velo_node_availability{availability="active",cluster="default",node="worker-2"} 0`,
+		// The stale container doesn't count
+		`velo_service_containers{cluster="default",node="manager-1",service="web"} 2`,
+		`velo_service_cpu_percent{cluster="default",node="manager-1",service="web"} 25`,
+		`velo_service_memory_usage_bytes{cluster="default",node="manager-1",service="web"} 150`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %s in the metrics, got:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "container=") || strings.Contains(body, "node-1") {
+		t.Error("Expected no container or node ID labels")
+	}
+}
+
+func TestHandlerToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		want   int
+	}{
+		{"Open", "", "", http.StatusOK},
+		{"Missing token", "secret", "", http.StatusUnauthorized},
+		{"Wrong token", "secret", "Bearer nope", http.StatusUnauthorized},
+		{"Right token", "secret", "Bearer secret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.header != "" {
+				header.Set("Authorization", tt.header)
+			}
+			code, body := scrape(t, Handler(tt.token), header)
+			if code != tt.want {
+				t.Fatalf("Expected status %d, got %d", tt.want, code)
+			}
+			if code == http.StatusOK && !strings.Contains(body, "go_goroutines") {
+				t.Errorf("Expected the runtime metrics, got:\n%s", body)
+			}
+		})
+	}
+}
+
+func TestInstrumentMux(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/things/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	handler := InstrumentMux(mux)
+
+	before := counterValue(t, httpRequests.WithLabelValues("/api/things/", "GET", "418"))
+	for _, path := range []string{"/api/things/1", "/api/things/2"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if got := counterValue(t, httpRequests.WithLabelValues("/api/things/", "GET", "418")) - before; got != 2 {
+		t.Errorf("Expected 2 requests counted against the route, got %v", got)
+	}
+
+	// Unknown paths and methods don't add label values
+	before = counterValue(t, httpRequests.WithLabelValues("unmatched", "other", "404"))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PROPFIND", "/random/path", nil))
+	if got := counterValue(t, httpRequests.WithLabelValues("unmatched", "other", "404")) - before; got != 1 {
+		t.Errorf("Expected the unknown request to count as unmatched, got %v", got)
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/velo.DeploymentService/Deploy"}
+	failing := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "no such cluster")
+	}
+
+	before := counterValue(t, grpcRequests.WithLabelValues(info.FullMethod, "NotFound"))
+	if _, err := UnaryServerInterceptor(context.Background(), nil, info, failing); status.Code(err) != codes.NotFound {
+		t.Fatalf("Expected the handler's error, got %v", err)
+	}
+	if got := counterValue(t, grpcRequests.WithLabelValues(info.FullMethod, "NotFound")) - before; got != 1 {
+		t.Errorf("Expected 1 failed request, got %v", got)
+	}
+}
+
+func TestObserveDeploy(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, OutcomeSuccess},
+		{fmt.Errorf("deploy: %w", manager.ErrInsufficientCapacity), OutcomeRejected},
+		{errors.New("image not found"), OutcomeFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			before := counterValue(t, deploys.WithLabelValues("test", tt.want))
+			ObserveDeploy("test", time.Now(), tt.err)
+			if got := counterValue(t, deploys.WithLabelValues("test", tt.want)) - before; got != 1 {
+				t.Errorf("Expected 1 deploy with outcome %s, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestInstrumentStateStore(t *testing.T) {
+	store := InstrumentStateStore(state.NewMemoryStateStore())
+	before := counterValue(t, stateStoreErrors.WithLabelValues("get"))
+
+	if err := store.Set("key", "value"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	var value string
+	if err := store.Get("key", &value); err != nil || value != "value" {
+		t.Fatalf("Expected to read back the value, got %q (%v)", value, err)
+	}
+	if err := store.Get("missing", &value); err == nil {
+		t.Fatal("Expected an error for a missing key")
+	}
+	if got := counterValue(t, stateStoreErrors.WithLabelValues("get")) - before; got != 1 {
+		t.Errorf("Expected 1 failed get, got %v", got)
+	}
+}
+
+// counterValue reads the current value of a counter
+func counterValue(t *testing.T, c prometheus.Counter) float64 {
+	t.Helper()
+	var m dto.Metric
+	if err := c.Write(&m); err != nil {
+		t.Fatalf("Failed to read the counter: %v", err)
+	}
+	return m.GetCounter().GetValue()
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/state"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	stateStoreDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "state_store_operation_duration_seconds",
+		Help:      "Time taken by state store operations, by operation.",
+		Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 9), // 100µs to ~6.5s
+	}, []string{"operation"})
+
+	stateStoreErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "state_store_errors_total",
+		Help:      "State store operations that failed, by operation. Reads of missing keys count as well.",
+	}, []string{"operation"})
+)
+
+func init() {
+	Registry.MustRegister(stateStoreDuration, stateStoreErrors)
+}
+
+// InstrumentStateStore times the operations of a state store
+func InstrumentStateStore(store state.StateStore) state.StateStore {
+	return &instrumentedStateStore{store: store}
+}
+
+type instrumentedStateStore struct {
+	store state.StateStore
+}
+
+func (s *instrumentedStateStore) Get(key string, value interface{}) error {
+	start := time.Now()
+	err := s.store.Get(key, value)
+	return observeStateStore("get", start, err)
+}
+
+func (s *instrumentedStateStore) Set(key string, value interface{}) error {
+	start := time.Now()
+	err := s.store.Set(key, value)
+	return observeStateStore("set", start, err)
+}
+
+func (s *instrumentedStateStore) Delete(key string) error {
+	start := time.Now()
+	err := s.store.Delete(key)
+	return observeStateStore("delete", start, err)
+}
+
+func (s *instrumentedStateStore) List(prefix string) ([]string, error) {
+	start := time.Now()
+	keys, err := s.store.List(prefix)
+	return keys, observeStateStore("list", start, err)
+}
+
+func (s *instrumentedStateStore) Close() error {
+	return s.store.Close()
+}
+
+func observeStateStore(operation string, start time.Time, err error) error {
+	stateStoreDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		stateStoreErrors.WithLabelValues(operation).Inc()
+	}
+	return err
+}
@@ -0,0 +1,409 @@
+package cluster
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/log"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/manager"
+	"github.com/jasonlovesdoggo/velo/pkg/core/node"
+)
+
+// DefaultHeartbeatInterval is how often agents send heartbeats
+const DefaultHeartbeatInterval = 15 * time.Second
+
+// missedHeartbeats is how many heartbeats an agent can miss before it's flagged as missing
+const missedHeartbeats = 3
+
+// MaxAgents caps how many agents a cluster tracks
+const MaxAgents = 1000
+
+// maxFinishedCommands caps how many finished commands are kept for status queries
+const maxFinishedCommands = 100
+
+// ErrUnknownAgent is returned for heartbeats from agents that aren't registered,
+// for example after the manager restarted. The agent should register again.
+var ErrUnknownAgent = errors.New("unknown agent")
+
+// ErrUnknownNode is returned when a command targets a node without an agent
+var ErrUnknownNode = errors.New("no agent registered for node")
+
+// ErrTooManyAgents is returned when a new agent registers with a cluster that
+// tracks as many agents as it may
+var ErrTooManyAgents = errors.New("too many agents registered")
+
+// Agent command types
+const (
+	CommandRestartContainer = "restart-container"
+	CommandStopContainer    = "stop-container"
+	CommandStartContainer   = "start-container"
+	CommandPruneImages      = "prune-images"
+)
+
+// Agent command states
+const (
+	CommandPending   = "pending"
+	CommandDelivered = "delivered"
+	CommandSucceeded = "succeeded"
+	CommandFailed    = "failed"
+)
+
+// AgentCommand is a command queued for the agent of a node
+type AgentCommand struct {
+	ID       string    `json:"id"`
+	NodeID   string    `json:"node_id"`
+	Type     string    `json:"type"`
+	Target   string    `json:"target,omitempty"`
+	State    string    `json:"state"`
+	Message  string    `json:"message,omitempty"`
+	Created  time.Time `json:"created"`
+	Finished time.Time `json:"finished,omitempty"`
+}
+
+// CommandResult is the outcome of a command, reported by the agent that ran it
+type CommandResult struct {
+	ID      string
+	Success bool
+	Message string
+}
+
+// AgentRegistration is what an agent reports when it registers
+type AgentRegistration struct {
+	NodeID        string
+	Hostname      string
+	Version       string
+	DockerVersion string
+	OS            string
+	Arch          string
+	KernelVersion string
+	Capacity      node.Resources
+}
+
+// AgentHeartbeat is what an agent reports periodically
+type AgentHeartbeat struct {
+	AgentID    string
+	NodeID     string
+	Health     string
+	Problems   []string
+	Containers []node.Container
+	Results    []CommandResult
+}
+
+// AgentTracker keeps the agents of a cluster and the commands queued for them
+type AgentTracker struct {
+	mu       sync.Mutex
+	interval time.Duration
+	max      int                      // agents tracked at most
+	agents   map[string]*trackedAgent // by node ID
+	commands map[string]*AgentCommand // by command ID
+	finished []string                 // IDs of finished commands, oldest first
+}
+
+type trackedAgent struct {
+	info     node.Agent
+	hostname string
+	capacity node.Resources
+	pending  []string // IDs of commands not delivered yet
+}
+
+// NewAgentTracker creates a tracker for agents sending heartbeats every interval
+func NewAgentTracker(interval time.Duration) *AgentTracker {
+	if interval <= 0 {
+		interval = DefaultHeartbeatInterval
+	}
+	return &AgentTracker{
+		interval: interval,
+		max:      MaxAgents,
+		agents:   make(map[string]*trackedAgent),
+		commands: make(map[string]*AgentCommand),
+	}
+}
+
+// Interval returns how often agents should send heartbeats
+func (t *AgentTracker) Interval() time.Duration {
+	return t.interval
+}
+
+// Register records an agent and returns its ID. An agent registering again
+// replaces its previous registration but keeps its queued commands. Callers
+// check the agent may speak for its node.
+func (t *AgentTracker) Register(reg AgentRegistration) (string, error) {
+	if reg.NodeID == "" {
+		return "", errors.New("node ID is required")
+	}
+	id, err := newID()
+	if err != nil {
+		return "", err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	agent := &trackedAgent{
+		info: node.Agent{
+			ID:            id,
+			Version:       reg.Version,
+			DockerVersion: reg.DockerVersion,
+			OS:            reg.OS,
+			Arch:          reg.Arch,
+			KernelVersion: reg.KernelVersion,
+			Registered:    now,
+			LastHeartbeat: now,
+			Health:        "healthy",
+		},
+		hostname: reg.Hostname,
+		capacity: reg.Capacity,
+	}
+	previous, ok := t.agents[reg.NodeID]
+	if !ok && len(t.agents) >= t.max {
+		return "", ErrTooManyAgents
+	}
+	if ok {
+		agent.pending = previous.pending
+	}
+	t.agents[reg.NodeID] = agent
+	return id, nil
+}
+
+// Heartbeat records an agent's heartbeat, applies the results it reports and
+// returns the commands it should run next
+func (t *AgentTracker) Heartbeat(hb AgentHeartbeat) ([]AgentCommand, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	agent, ok := t.agents[hb.NodeID]
+	if !ok || agent.info.ID != hb.AgentID {
+		return nil, ErrUnknownAgent
+	}
+	if t.missing(agent, time.Now()) {
+		log.Info("Agent is back", "node", hb.NodeID, "hostname", agent.hostname)
+	}
+
+	agent.info.LastHeartbeat = time.Now()
+	agent.info.Health = hb.Health
+	agent.info.Problems = hb.Problems
+	agent.info.Containers = hb.Containers
+
+	for _, result := range hb.Results {
+		cmd, ok := t.commands[result.ID]
+		if !ok || cmd.NodeID != hb.NodeID {
+			continue
+		}
+		cmd.State = CommandFailed
+		if result.Success {
+			cmd.State = CommandSucceeded
+		}
+		cmd.Message = result.Message
+		cmd.Finished = time.Now()
+		t.finish(cmd.ID)
+	}
+
+	commands := make([]AgentCommand, 0, len(agent.pending))
+	for _, id := range agent.pending {
+		cmd := t.commands[id]
+		cmd.State = CommandDelivered
+		commands = append(commands, *cmd)
+	}
+	agent.pending = nil
+	return commands, nil
+}
+
+// Enqueue queues a command for the agent of a node, found by ID or hostname
+func (t *AgentTracker) Enqueue(nodeRef, commandType, target string) (AgentCommand, error) {
+	switch commandType {
+	case CommandRestartContainer, CommandStopContainer, CommandStartContainer:
+		if target == "" {
+			return AgentCommand{}, fmt.Errorf("%s requires a container", commandType)
+		}
+	case CommandPruneImages:
+	default:
+		return AgentCommand{}, fmt.Errorf("unknown command %q", commandType)
+	}
+	id, err := newID()
+	if err != nil {
+		return AgentCommand{}, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	nodeID, agent := t.lookup(nodeRef)
+	if agent == nil {
+		return AgentCommand{}, fmt.Errorf("%w %s", ErrUnknownNode, nodeRef)
+	}
+	cmd := &AgentCommand{
+		ID:      id,
+		NodeID:  nodeID,
+		Type:    commandType,
+		Target:  target,
+		State:   CommandPending,
+		Created: time.Now(),
+	}
+	t.commands[id] = cmd
+	agent.pending = append(agent.pending, id)
+
+	log.Info("Agent command queued", "id", id, "node", nodeID, "type", commandType, "target", target)
+	return *cmd, nil
+}
+
+// Command returns a queued or finished command by ID
+func (t *AgentTracker) Command(id string) (AgentCommand, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	cmd, ok := t.commands[id]
+	if !ok {
+		return AgentCommand{}, false
+	}
+	return *cmd, true
+}
+
+// Merge adds what agents report to nodes listed by the backend: agent details,
+// disk and GPU capacity, which Swarm doesn't report, and whether the agent is
+// missing. Nodes that only have an agent, such as standalone hosts, are appended.
+func (t *AgentTracker) Merge(nodes []node.Info) []node.Info {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	merged := make([]node.Info, 0, len(nodes)+len(t.agents))
+	seen := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		seen[n.ID] = true
+		agent, ok := t.agents[n.ID]
+		if !ok {
+			n.AgentMissing = true
+			merged = append(merged, n)
+			continue
+		}
+		merged = append(merged, t.apply(n, agent, now))
+	}
+
+	var extra []node.Info
+	for id, agent := range t.agents {
+		if seen[id] {
+			continue
+		}
+		extra = append(extra, t.apply(node.Info{ID: id, Hostname: agent.hostname}, agent, now))
+	}
+	sort.Slice(extra, func(i, j int) bool { return extra[i].Hostname < extra[j].Hostname })
+	return append(merged, extra...)
+}
+
+// apply copies an agent's data into a node
+func (t *AgentTracker) apply(n node.Info, agent *trackedAgent, now time.Time) node.Info {
+	info := agent.info
+	n.Agent = &info
+	n.AgentMissing = t.missing(agent, now)
+	if n.Hostname == "" {
+		n.Hostname = agent.hostname
+	}
+	if n.Capacity.CPU == 0 {
+		n.Capacity.CPU = agent.capacity.CPU
+	}
+	if n.Capacity.Memory == 0 {
+		n.Capacity.Memory = agent.capacity.Memory
+	}
+	n.Capacity.Disk = agent.capacity.Disk
+	n.Capacity.GPU = agent.capacity.GPU
+	return n
+}
+
+// Missing returns the number of agents that stopped sending heartbeats
+func (t *AgentTracker) Missing() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	count := 0
+	now := time.Now()
+	for _, agent := range t.agents {
+		if t.missing(agent, now) {
+			count++
+		}
+	}
+	return count
+}
+
+func (t *AgentTracker) missing(agent *trackedAgent, now time.Time) bool {
+	return now.Sub(agent.info.LastHeartbeat) > missedHeartbeats*t.interval
+}
+
+// NodeID returns the ID of the node of an agent, found by node ID or hostname
+func (t *AgentTracker) NodeID(ref string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	id, agent := t.lookup(ref)
+	return id, agent != nil
+}
+
+// Hostname returns the hostname a node's agent registered with
+func (t *AgentTracker) Hostname(nodeID string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if agent, ok := t.agents[nodeID]; ok {
+		return agent.hostname
+	}
+	return ""
+}
+
+// lookup finds an agent by node ID or hostname
+func (t *AgentTracker) lookup(ref string) (string, *trackedAgent) {
+	if agent, ok := t.agents[ref]; ok {
+		return ref, agent
+	}
+	for id, agent := range t.agents {
+		if agent.hostname == ref {
+			return id, agent
+		}
+	}
+	return "", nil
+}
+
+// finish records a finished command, dropping the oldest ones past the limit
+func (t *AgentTracker) finish(id string) {
+	t.finished = append(t.finished, id)
+	for len(t.finished) > maxFinishedCommands {
+		delete(t.commands, t.finished[0])
+		t.finished = t.finished[1:]
+	}
+}
+
+func newID() (string, error) {
+	bytes := make([]byte, 8)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate ID: %w", err)
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// Nodes returns the cluster's nodes with what their agents report merged in
+func (c *Cluster) Nodes() []node.Info {
+	var nodes []node.Info
+	if nm, ok := c.Manager.(manager.NodeManager); ok {
+		nodes = nm.GetNodes()
+	}
+	if c.Agents == nil {
+		return nodes
+	}
+	return c.Agents.Merge(nodes)
+}
+
+// BackendNode looks a node up by ID or hostname in the nodes the cluster's
+// backend lists. listed is false for backends that don't list nodes, such as
+// standalone hosts.
+func (c *Cluster) BackendNode(ref string) (n node.Info, found, listed bool) {
+	nm, ok := c.Manager.(manager.NodeManager)
+	if !ok {
+		return node.Info{}, false, false
+	}
+	for _, n := range nm.GetNodes() {
+		if n.ID == ref || n.Hostname == ref {
+			return n, true, true
+		}
+	}
+	return node.Info{}, false, true
+}
@@ -0,0 +1,129 @@
+package cluster
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/pkg/core/node"
+)
+
+func TestAgentTrackerMerge(t *testing.T) {
+	tracker := NewAgentTracker(10 * time.Millisecond)
+	id, err := tracker.Register(AgentRegistration{
+		NodeID:   "node-1",
+		Hostname: "box-1",
+		Version:  "1.2.3",
+		Capacity: node.Resources{CPU: 8, Memory: 1 << 30, Disk: 1 << 40, GPU: 2},
+	})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if _, err := tracker.Register(AgentRegistration{NodeID: "standalone-1", Hostname: "edge"}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	// Swarm reports CPU and memory but not disk or GPUs
+	nodes := tracker.Merge([]node.Info{
+		{ID: "node-1", Hostname: "box-1", Capacity: node.Resources{CPU: 4, Memory: 1 << 29}},
+		{ID: "node-2", Hostname: "box-2"},
+	})
+	if len(nodes) != 3 {
+		t.Fatalf("Expected 3 nodes, got %d", len(nodes))
+	}
+	if n := nodes[0]; n.Agent == nil || n.Agent.ID != id || n.AgentMissing || n.Capacity.CPU != 4 || n.Capacity.Disk != 1<<40 || n.Capacity.GPU != 2 {
+		t.Errorf("Unexpected merged node %+v", n)
+	}
+	if n := nodes[1]; n.Agent != nil || !n.AgentMissing {
+		t.Errorf("Expected node without agent to be flagged, got %+v", n)
+	}
+	if n := nodes[2]; n.ID != "standalone-1" || n.Hostname != "edge" || n.AgentMissing {
+		t.Errorf("Expected agent-only node to be listed, got %+v", n)
+	}
+
+	// An agent that stops sending heartbeats is flagged as missing
+	time.Sleep(50 * time.Millisecond)
+	if missing := tracker.Missing(); missing != 2 {
+		t.Errorf("Expected 2 missing agents, got %d", missing)
+	}
+	if _, err := tracker.Heartbeat(AgentHeartbeat{AgentID: id, NodeID: "node-1", Health: "healthy"}); err != nil {
+		t.Fatalf("Heartbeat failed: %v", err)
+	}
+	if missing := tracker.Missing(); missing != 1 {
+		t.Errorf("Expected 1 missing agent after a heartbeat, got %d", missing)
+	}
+	if _, err := tracker.Heartbeat(AgentHeartbeat{AgentID: "stale", NodeID: "node-1"}); !errors.Is(err, ErrUnknownAgent) {
+		t.Errorf("Expected ErrUnknownAgent for a stale agent ID, got %v", err)
+	}
+}
+
+func TestAgentTrackerCommands(t *testing.T) {
+	tracker := NewAgentTracker(time.Minute)
+	if _, err := tracker.Enqueue("box-1", CommandPruneImages, ""); !errors.Is(err, ErrUnknownNode) {
+		t.Errorf("Expected ErrUnknownNode, got %v", err)
+	}
+	if _, err := tracker.Register(AgentRegistration{NodeID: "node-1", Hostname: "box-1"}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	tests := []struct {
+		commandType string
+		target      string
+		wantErr     bool
+	}{
+		{CommandRestartContainer, "web", false},
+		{CommandPruneImages, "", false},
+		{CommandStopContainer, "", true},
+		{"reboot", "", true},
+	}
+	for _, tt := range tests {
+		_, err := tracker.Enqueue("box-1", tt.commandType, tt.target)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Enqueue(%s, %q): expected error %v, got %v", tt.commandType, tt.target, tt.wantErr, err)
+		}
+	}
+
+	// Queued commands survive the agent registering again
+	id, _ := tracker.Register(AgentRegistration{NodeID: "node-1", Hostname: "box-1"})
+	commands, err := tracker.Heartbeat(AgentHeartbeat{AgentID: id, NodeID: "node-1"})
+	if err != nil {
+		t.Fatalf("Heartbeat failed: %v", err)
+	}
+	if len(commands) != 2 || commands[0].Type != CommandRestartContainer || commands[0].NodeID != "node-1" {
+		t.Fatalf("Expected the 2 queued commands, got %+v", commands)
+	}
+	if cmd, _ := tracker.Command(commands[0].ID); cmd.State != CommandDelivered {
+		t.Errorf("Expected a delivered command, got %s", cmd.State)
+	}
+
+	restart, prune := commands[0].ID, commands[1].ID
+	commands, err = tracker.Heartbeat(AgentHeartbeat{AgentID: id, NodeID: "node-1", Results: []CommandResult{
+		{ID: restart, Success: true, Message: "container restarted"},
+		{ID: prune, Success: false, Message: "daemon busy"},
+	}})
+	if err != nil || len(commands) != 0 {
+		t.Fatalf("Expected no more commands, got %v (%v)", commands, err)
+	}
+	if cmd, _ := tracker.Command(restart); cmd.State != CommandSucceeded || cmd.Message != "container restarted" {
+		t.Errorf("Expected the restart to have succeeded, got %+v", cmd)
+	}
+	if cmd, _ := tracker.Command(prune); cmd.State != CommandFailed || cmd.Message != "daemon busy" {
+		t.Errorf("Expected the prune to have failed, got %+v", cmd)
+	}
+}
+
+func TestAgentTrackerLimit(t *testing.T) {
+	tracker := NewAgentTracker(time.Minute)
+	tracker.max = 2
+	for _, id := range []string{"node-1", "node-2"} {
+		if _, err := tracker.Register(AgentRegistration{NodeID: id}); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+	if _, err := tracker.Register(AgentRegistration{NodeID: "node-3"}); !errors.Is(err, ErrTooManyAgents) {
+		t.Errorf("Expected ErrTooManyAgents, got %v", err)
+	}
+	if _, err := tracker.Register(AgentRegistration{NodeID: "node-1"}); err != nil {
+		t.Errorf("Expected known nodes to register again, got %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package cluster
+
+import (
+	"sort"
+	"time"
+)
+
+// FailedDeployRetention is how long a failed deploy is remembered unless the
+// service is deployed successfully in the meantime
+const FailedDeployRetention = 24 * time.Hour
+
+// FailedDeploy is the last deploy of a service, if it failed
+type FailedDeploy struct {
+	Service string
+	Time    time.Time
+	Error   string
+}
+
+// RecordDeploy remembers the outcome of a deploy of a service. A successful
+// deploy clears an earlier failure.
+func (c *Cluster) RecordDeploy(service string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == nil {
+		delete(c.failedDeploys, service)
+		return
+	}
+	if c.failedDeploys == nil {
+		c.failedDeploys = make(map[string]FailedDeploy)
+	}
+	c.failedDeploys[service] = FailedDeploy{Service: service, Time: time.Now(), Error: err.Error()}
+}
+
+// FailedDeploys returns the services whose last deploy failed, by service name
+func (c *Cluster) FailedDeploys() []FailedDeploy {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cutoff := time.Now().Add(-FailedDeployRetention)
+	failed := make([]FailedDeploy, 0, len(c.failedDeploys))
+	for service, f := range c.failedDeploys {
+		if f.Time.Before(cutoff) {
+			delete(c.failedDeploys, service)
+			continue
+		}
+		failed = append(failed, f)
+	}
+	sort.Slice(failed, func(i, j int) bool { return failed[i].Service < failed[j].Service })
+	return failed
+}
@@ -0,0 +1,156 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/tlsconfig"
+	"github.com/jasonlovesdoggo/velo/internal/config"
+)
+
+// NewDockerClient creates a Docker client for a cluster's endpoint. Unix and tcp
+// hosts are dialed directly, optionally with TLS; ssh hosts run
+// `docker system dial-stdio` on the remote host, like the docker CLI does.
+// An empty host uses the DOCKER_HOST environment.
+func NewDockerClient(cfg config.ClusterConfig) (*client.Client, error) {
+	if cfg.Host == "" {
+		return client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	}
+
+	u, err := url.Parse(cfg.Host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Docker host %q: %w", cfg.Host, err)
+	}
+
+	opts := []client.Opt{client.WithAPIVersionNegotiation()}
+	switch u.Scheme {
+	case "unix", "npipe":
+		opts = append(opts, client.WithHost(cfg.Host))
+	case "tcp":
+		if cfg.TLSVerify || cfg.TLSCACert != "" || cfg.TLSCert != "" {
+			tlsConfig, err := tlsconfig.Client(tlsconfig.Options{
+				CAFile:             cfg.TLSCACert,
+				CertFile:           cfg.TLSCert,
+				KeyFile:            cfg.TLSKey,
+				InsecureSkipVerify: !cfg.TLSVerify,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to load TLS config of cluster %s: %w", cfg.Name, err)
+			}
+			opts = append(opts, client.WithHTTPClient(&http.Client{
+				Transport:     &http.Transport{TLSClientConfig: tlsConfig},
+				CheckRedirect: client.CheckRedirect,
+			}))
+		}
+		opts = append(opts, client.WithHost(cfg.Host))
+	case "ssh":
+		args, err := sshArgs(u)
+		if err != nil {
+			return nil, err
+		}
+		// The host is only used to build request URLs, connections go through ssh
+		opts = append(opts,
+			client.WithHost("http://docker.example.com"),
+			client.WithDialContext(func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialCommand(ctx, "ssh", args...)
+			}),
+		)
+	default:
+		return nil, fmt.Errorf("unsupported Docker host %q (expected unix://, tcp:// or ssh://)", cfg.Host)
+	}
+
+	return client.NewClientWithOpts(opts...)
+}
+
+// sshArgs builds the ssh command line that connects to the Docker daemon of an ssh:// host
+func sshArgs(u *url.URL) ([]string, error) {
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("ssh host is required in %q", u.String())
+	}
+	if u.Path != "" && u.Path != "/" {
+		return nil, fmt.Errorf("ssh hosts can't have a path, got %q", u.Path)
+	}
+
+	args := []string{"-o", "ConnectTimeout=30", "-T"}
+	if u.User != nil {
+		args = append(args, "-l", u.User.Username())
+	}
+	if port := u.Port(); port != "" {
+		args = append(args, "-p", port)
+	}
+	return append(args, "--", u.Hostname(), "docker", "system", "dial-stdio"), nil
+}
+
+// dialCommand starts a command and returns a connection to its stdin and stdout
+func dialCommand(ctx context.Context, name string, args ...string) (net.Conn, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to run %s: %w", name, err)
+	}
+	return &commandConn{cmd: cmd, stdin: stdin, stdout: stdout, stderr: &stderr}, nil
+}
+
+// commandConn is a net.Conn over the stdin and stdout of a command
+type commandConn struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	stdout    io.ReadCloser
+	stderr    *strings.Builder
+	closeOnce sync.Once
+}
+
+func (c *commandConn) Read(p []byte) (int, error) {
+	n, err := c.stdout.Read(p)
+	if err == io.EOF && c.stderr.Len() > 0 {
+		return n, fmt.Errorf("%s: %s", c.cmd.Path, strings.TrimSpace(c.stderr.String()))
+	}
+	return n, err
+}
+
+func (c *commandConn) Write(p []byte) (int, error) {
+	return c.stdin.Write(p)
+}
+
+func (c *commandConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.stdin.Close()
+		if c.cmd.Process != nil {
+			_ = c.cmd.Process.Kill()
+		}
+		_ = c.cmd.Wait()
+	})
+	return nil
+}
+
+func (c *commandConn) LocalAddr() net.Addr  { return commandAddr{} }
+func (c *commandConn) RemoteAddr() net.Addr { return commandAddr{} }
+
+// Deadlines aren't supported on pipes; requests are bounded by their context instead
+func (c *commandConn) SetDeadline(time.Time) error      { return nil }
+func (c *commandConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *commandConn) SetWriteDeadline(time.Time) error { return nil }
+
+type commandAddr struct{}
+
+func (commandAddr) Network() string { return "command" }
+func (commandAddr) String() string  { return "command" }
@@ -0,0 +1,404 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/state/stores"
+)
+
+// DefaultMetricsRetention is how long container metrics are kept in memory
+const DefaultMetricsRetention = time.Hour
+
+// maxPointsPerContainer caps the points kept per container, whatever the retention
+const maxPointsPerContainer = 4000
+
+// Metrics grouping
+const (
+	GroupByService = "service"
+	GroupByNode    = "node"
+)
+
+// ContainerSample is a resource usage sample of a container, as sent by agents.
+// Network and block I/O are cumulative byte counters since the container started.
+type ContainerSample struct {
+	Time        time.Time
+	ContainerID string
+	Container   string
+	Service     string
+	TaskID      string
+	CPUPercent  float64
+	MemoryUsage uint64
+	MemoryLimit uint64
+	NetRx       uint64
+	NetTx       uint64
+	BlockRead   uint64
+	BlockWrite  uint64
+}
+
+// MetricsPoint is the resource usage of a container, or of a group of containers
+// over a step of a query. I/O is in bytes per second.
+type MetricsPoint struct {
+	Time           time.Time `json:"time"`
+	CPUPercent     float64   `json:"cpuPercent"`
+	MemoryUsage    uint64    `json:"memoryUsage"`
+	MemoryLimit    uint64    `json:"memoryLimit"`
+	NetRxRate      float64   `json:"netRxRate"`
+	NetTxRate      float64   `json:"netTxRate"`
+	BlockReadRate  float64   `json:"blockReadRate"`
+	BlockWriteRate float64   `json:"blockWriteRate"`
+	Containers     int       `json:"containers"`
+}
+
+// MetricsQuery selects and groups container metrics
+type MetricsQuery struct {
+	Service string        // only containers of this service
+	Node    string        // only containers on this node ID
+	GroupBy string        // service (default) or node
+	Since   time.Time     // defaults to the retention
+	Step    time.Duration // defaults to a minute
+}
+
+// MetricsSeries is the usage of a service or node over time. Every point
+// averages each container over the step and sums the containers.
+type MetricsSeries struct {
+	Service  string         `json:"service,omitempty"`
+	Node     string         `json:"node,omitempty"`
+	Hostname string         `json:"hostname,omitempty"`
+	Points   []MetricsPoint `json:"points"`
+}
+
+// Usage is the current resource usage of a service's containers on a node
+type Usage struct {
+	Service  string
+	Node     string // node ID
+	Hostname string
+	MetricsPoint
+}
+
+// MetricsHistory keeps container metrics past the in-memory retention, e.g. a
+// stores.TimeSeriesStore
+type MetricsHistory interface {
+	Append(samples ...stores.Sample)
+}
+
+// Metric names of container metrics in the history
+const (
+	MetricCPUPercent     = "container_cpu_percent"
+	MetricMemoryUsage    = "container_memory_usage_bytes"
+	MetricMemoryLimit    = "container_memory_limit_bytes"
+	MetricNetRxRate      = "container_network_receive_bytes_per_second"
+	MetricNetTxRate      = "container_network_transmit_bytes_per_second"
+	MetricBlockReadRate  = "container_block_read_bytes_per_second"
+	MetricBlockWriteRate = "container_block_write_bytes_per_second"
+)
+
+// MetricsStore keeps the recent resource usage of a cluster's containers
+type MetricsStore struct {
+	mu         sync.Mutex
+	retention  time.Duration
+	containers map[string]*containerMetrics // by container ID
+	history    MetricsHistory
+	cluster    string
+}
+
+type containerMetrics struct {
+	nodeID  string
+	service string
+	last    ContainerSample // to turn the I/O counters into rates
+	points  []MetricsPoint  // oldest first
+}
+
+// NewMetricsStore creates a store keeping metrics for the retention period
+func NewMetricsStore(retention time.Duration) *MetricsStore {
+	if retention <= 0 {
+		retention = DefaultMetricsRetention
+	}
+	return &MetricsStore{
+		retention:  retention,
+		containers: make(map[string]*containerMetrics),
+	}
+}
+
+// Add records the samples sent by the agent of a node. Samples that aren't newer
+// than the last one of their container are ignored.
+func (m *MetricsStore) Add(nodeID string, samples []ContainerSample) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, s := range samples {
+		if s.ContainerID == "" {
+			continue
+		}
+		c, ok := m.containers[s.ContainerID]
+		if !ok {
+			c = &containerMetrics{}
+			m.containers[s.ContainerID] = c
+		} else if !s.Time.After(c.last.Time) {
+			continue
+		}
+		c.nodeID = nodeID
+		c.service = s.Service
+
+		point := MetricsPoint{
+			Time:        s.Time,
+			CPUPercent:  s.CPUPercent,
+			MemoryUsage: s.MemoryUsage,
+			MemoryLimit: s.MemoryLimit,
+			Containers:  1,
+		}
+		if ok {
+			seconds := s.Time.Sub(c.last.Time).Seconds()
+			point.NetRxRate = rate(c.last.NetRx, s.NetRx, seconds)
+			point.NetTxRate = rate(c.last.NetTx, s.NetTx, seconds)
+			point.BlockReadRate = rate(c.last.BlockRead, s.BlockRead, seconds)
+			point.BlockWriteRate = rate(c.last.BlockWrite, s.BlockWrite, seconds)
+		}
+		c.last = s
+		c.points = append(c.points, point)
+		m.record(nodeID, s, point)
+		if len(c.points) > maxPointsPerContainer {
+			c.points = c.points[len(c.points)-maxPointsPerContainer:]
+		}
+	}
+	m.prune(time.Now())
+}
+
+// Record also writes the metrics of every container to h, labeled with the cluster name
+func (m *MetricsStore) Record(h MetricsHistory, clusterName string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.history = h
+	m.cluster = clusterName
+}
+
+// record writes a container's point to the history. Callers must hold m.mu.
+func (m *MetricsStore) record(nodeID string, s ContainerSample, p MetricsPoint) {
+	if m.history == nil {
+		return
+	}
+	labels := map[string]string{
+		"cluster":   m.cluster,
+		"node":      nodeID,
+		"service":   s.Service,
+		"container": s.Container,
+	}
+	values := map[string]float64{
+		MetricCPUPercent:     p.CPUPercent,
+		MetricMemoryUsage:    float64(p.MemoryUsage),
+		MetricMemoryLimit:    float64(p.MemoryLimit),
+		MetricNetRxRate:      p.NetRxRate,
+		MetricNetTxRate:      p.NetTxRate,
+		MetricBlockReadRate:  p.BlockReadRate,
+		MetricBlockWriteRate: p.BlockWriteRate,
+	}
+	samples := make([]stores.Sample, 0, len(values))
+	for metric, value := range values {
+		samples = append(samples, stores.Sample{Metric: metric, Labels: labels, Time: p.Time, Value: value})
+	}
+	m.history.Append(samples...)
+}
+
+// prune drops points past the retention and containers without points. Callers must hold m.mu.
+func (m *MetricsStore) prune(now time.Time) {
+	cutoff := now.Add(-m.retention)
+	for id, c := range m.containers {
+		i := sort.Search(len(c.points), func(i int) bool { return !c.points[i].Time.Before(cutoff) })
+		c.points = c.points[i:]
+		if len(c.points) == 0 {
+			delete(m.containers, id)
+		}
+	}
+}
+
+// Query aggregates the metrics matching q per service or per node
+func (m *MetricsStore) Query(q MetricsQuery) ([]MetricsSeries, error) {
+	switch q.GroupBy {
+	case "":
+		q.GroupBy = GroupByService
+	case GroupByService, GroupByNode:
+	default:
+		return nil, fmt.Errorf("invalid grouping %q (expected service or node)", q.GroupBy)
+	}
+	if q.Step <= 0 {
+		q.Step = time.Minute
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Sum the points of every container per step, per series
+	type bucket struct {
+		sums  map[string]*MetricsPoint // by container ID
+		count map[string]int
+	}
+	series := make(map[string]map[time.Time]*bucket)
+	for id, c := range m.containers {
+		if q.Service != "" && c.service != q.Service || q.Node != "" && c.nodeID != q.Node {
+			continue
+		}
+		key := c.service
+		if q.GroupBy == GroupByNode {
+			key = c.nodeID
+		}
+		if series[key] == nil {
+			series[key] = make(map[time.Time]*bucket)
+		}
+		for _, p := range c.points {
+			if p.Time.Before(q.Since) {
+				continue
+			}
+			t := p.Time.Truncate(q.Step)
+			b := series[key][t]
+			if b == nil {
+				b = &bucket{sums: make(map[string]*MetricsPoint), count: make(map[string]int)}
+				series[key][t] = b
+			}
+			sum := b.sums[id]
+			if sum == nil {
+				sum = &MetricsPoint{}
+				b.sums[id] = sum
+			}
+			sum.CPUPercent += p.CPUPercent
+			sum.MemoryUsage += p.MemoryUsage
+			sum.MemoryLimit += p.MemoryLimit
+			sum.NetRxRate += p.NetRxRate
+			sum.NetTxRate += p.NetTxRate
+			sum.BlockReadRate += p.BlockReadRate
+			sum.BlockWriteRate += p.BlockWriteRate
+			b.count[id]++
+		}
+	}
+
+	// Average every container over the step and add the containers up
+	result := make([]MetricsSeries, 0, len(series))
+	for key, buckets := range series {
+		s := MetricsSeries{Points: make([]MetricsPoint, 0, len(buckets))}
+		if q.GroupBy == GroupByNode {
+			s.Node = key
+		} else {
+			s.Service = key
+		}
+		for t, b := range buckets {
+			point := MetricsPoint{Time: t, Containers: len(b.sums)}
+			for id, sum := range b.sums {
+				n := b.count[id]
+				point.CPUPercent += sum.CPUPercent / float64(n)
+				point.MemoryUsage += sum.MemoryUsage / uint64(n)
+				point.MemoryLimit += sum.MemoryLimit / uint64(n)
+				point.NetRxRate += sum.NetRxRate / float64(n)
+				point.NetTxRate += sum.NetTxRate / float64(n)
+				point.BlockReadRate += sum.BlockReadRate / float64(n)
+				point.BlockWriteRate += sum.BlockWriteRate / float64(n)
+			}
+			s.Points = append(s.Points, point)
+		}
+		sort.Slice(s.Points, func(i, j int) bool { return s.Points[i].Time.Before(s.Points[j].Time) })
+		if len(s.Points) > 0 {
+			result = append(result, s)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Service+result[i].Node < result[j].Service+result[j].Node
+	})
+	return result, nil
+}
+
+// Current sums the latest point of every container sampled since the given
+// time, per service and node. Containers without a recent sample, e.g. ones
+// that were removed, don't count.
+func (m *MetricsStore) Current(since time.Time) []Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	type key struct{ service, node string }
+	usage := make(map[key]*Usage)
+	for _, c := range m.containers {
+		if len(c.points) == 0 {
+			continue
+		}
+		p := c.points[len(c.points)-1]
+		if p.Time.Before(since) {
+			continue
+		}
+		k := key{c.service, c.nodeID}
+		u := usage[k]
+		if u == nil {
+			u = &Usage{Service: c.service, Node: c.nodeID, MetricsPoint: MetricsPoint{Time: p.Time}}
+			usage[k] = u
+		}
+		if p.Time.After(u.Time) {
+			u.Time = p.Time
+		}
+		u.CPUPercent += p.CPUPercent
+		u.MemoryUsage += p.MemoryUsage
+		u.MemoryLimit += p.MemoryLimit
+		u.NetRxRate += p.NetRxRate
+		u.NetTxRate += p.NetTxRate
+		u.BlockReadRate += p.BlockReadRate
+		u.BlockWriteRate += p.BlockWriteRate
+		u.Containers++
+	}
+
+	result := make([]Usage, 0, len(usage))
+	for _, u := range usage {
+		result = append(result, *u)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Service != result[j].Service {
+			return result[i].Service < result[j].Service
+		}
+		return result[i].Node < result[j].Node
+	})
+	return result
+}
+
+// rate returns how fast a byte counter grew, in bytes per second. A counter that
+// went down was reset, e.g. by a container restart, and counts from zero.
+func rate(previous, current uint64, seconds float64) float64 {
+	if seconds <= 0 {
+		return 0
+	}
+	if current < previous {
+		return float64(current) / seconds
+	}
+	return float64(current-previous) / seconds
+}
+
+// QueryMetrics aggregates the container metrics of the cluster. Nodes can be
+// selected by ID or hostname, and node series carry the hostname.
+func (c *Cluster) QueryMetrics(q MetricsQuery) ([]MetricsSeries, error) {
+	if c.Metrics == nil {
+		return nil, nil
+	}
+	if q.Node != "" {
+		if id, ok := c.Agents.NodeID(q.Node); ok {
+			q.Node = id
+		}
+	}
+	series, err := c.Metrics.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	for i := range series {
+		if series[i].Node != "" {
+			series[i].Hostname = c.Agents.Hostname(series[i].Node)
+		}
+	}
+	return series, nil
+}
+
+// CurrentUsage returns the resource usage of the cluster's services per node,
+// from the containers sampled since the given time
+func (c *Cluster) CurrentUsage(since time.Time) []Usage {
+	if c.Metrics == nil {
+		return nil
+	}
+	usage := c.Metrics.Current(since)
+	for i := range usage {
+		usage[i].Hostname = c.Agents.Hostname(usage[i].Node)
+	}
+	return usage
+}
@@ -0,0 +1,115 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/state/stores"
+)
+
+func TestMetricsStoreQuery(t *testing.T) {
+	store := NewMetricsStore(time.Hour)
+	start := time.Now().Add(-10 * time.Minute).Truncate(time.Minute)
+
+	// Two replicas of web on two nodes and a worker on the first node, sampled every 30s
+	for i := 0; i < 4; i++ {
+		at := start.Add(time.Duration(i) * 30 * time.Second)
+		store.Add("node-1", []ContainerSample{
+			{Time: at, ContainerID: "web-1", Service: "web", CPUPercent: 10, MemoryUsage: 100, MemoryLimit: 1000, NetRx: uint64(i) * 3000},
+			{Time: at, ContainerID: "worker-1", Service: "worker", CPUPercent: 50, MemoryUsage: 400},
+		})
+		store.Add("node-2", []ContainerSample{
+			{Time: at, ContainerID: "web-2", Service: "web", CPUPercent: float64(20 + i*10), MemoryUsage: 200, MemoryLimit: 1000},
+		})
+	}
+
+	series, err := store.Query(MetricsQuery{Step: time.Minute})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(series) != 2 || series[0].Service != "web" || series[1].Service != "worker" {
+		t.Fatalf("Expected web and worker series, got %+v", series)
+	}
+	web := series[0]
+	if len(web.Points) != 2 {
+		t.Fatalf("Expected 2 one-minute points, got %+v", web.Points)
+	}
+	// Containers are averaged over the step and summed: web-1 at 10% and web-2 at 25%
+	first := web.Points[0]
+	if first.Containers != 2 || first.CPUPercent != 35 || first.MemoryUsage != 300 || first.MemoryLimit != 2000 {
+		t.Errorf("Unexpected first point %+v", first)
+	}
+	// The counter grows 3000 bytes every 30s; the very first sample has no rate yet
+	if first.NetRxRate != 50 || web.Points[1].NetRxRate != 100 {
+		t.Errorf("Expected network rates of 50 and 100 B/s, got %f and %f", first.NetRxRate, web.Points[1].NetRxRate)
+	}
+
+	// Grouped by node, filtered by service
+	series, err = store.Query(MetricsQuery{Service: "web", GroupBy: GroupByNode, Step: 2 * time.Minute})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(series) != 2 || series[0].Node != "node-1" || series[0].Points[0].MemoryUsage != 100 {
+		t.Errorf("Expected web usage per node, got %+v", series)
+	}
+
+	// The latest sample of every container, summed per service and node
+	usage := store.Current(start)
+	if len(usage) != 3 || usage[0].Service != "web" || usage[0].Node != "node-1" || usage[2].Service != "worker" {
+		t.Fatalf("Expected web on both nodes and worker, got %+v", usage)
+	}
+	if usage[1].CPUPercent != 50 || usage[1].Containers != 1 || usage[0].NetRxRate != 100 {
+		t.Errorf("Expected the latest usage of web, got %+v and %+v", usage[0], usage[1])
+	}
+	if usage := store.Current(time.Now()); len(usage) != 0 {
+		t.Errorf("Expected no usage without recent samples, got %+v", usage)
+	}
+
+	// Out of order samples are ignored and old points age out
+	store.Add("node-1", []ContainerSample{{Time: start, ContainerID: "web-1", Service: "web", CPUPercent: 99}})
+	series, _ = store.Query(MetricsQuery{Node: "node-1", Since: start.Add(time.Minute), Step: time.Minute})
+	if len(series) != 2 || series[0].Points[0].CPUPercent != 10 {
+		t.Errorf("Expected the late sample to be ignored, got %+v", series)
+	}
+	store.retention = time.Minute
+	store.Add("node-2", nil)
+	if series, _ := store.Query(MetricsQuery{}); len(series) != 0 {
+		t.Errorf("Expected old points to be pruned, got %+v", series)
+	}
+
+	if _, err := store.Query(MetricsQuery{GroupBy: "image"}); err == nil {
+		t.Error("Expected an error for an unknown grouping")
+	}
+}
+
+type fakeHistory struct {
+	samples []stores.Sample
+}
+
+func (h *fakeHistory) Append(samples ...stores.Sample) {
+	h.samples = append(h.samples, samples...)
+}
+
+func TestMetricsStoreHistory(t *testing.T) {
+	store := NewMetricsStore(time.Hour)
+	history := &fakeHistory{}
+	store.Record(history, "prod")
+
+	at := time.Now()
+	store.Add("node-1", []ContainerSample{
+		{Time: at, ContainerID: "c1", Container: "web.1", Service: "web", CPUPercent: 12.5, MemoryUsage: 64},
+		{Time: at, ContainerID: "c1", Container: "web.1", Service: "web", CPUPercent: 99}, // not newer, ignored
+	})
+
+	if len(history.samples) != 7 {
+		t.Fatalf("Expected one sample per container metric, got %d", len(history.samples))
+	}
+	for _, s := range history.samples {
+		if s.Labels["cluster"] != "prod" || s.Labels["node"] != "node-1" || s.Labels["service"] != "web" || s.Labels["container"] != "web.1" {
+			t.Errorf("Unexpected labels %v", s.Labels)
+		}
+		if s.Metric == MetricCPUPercent && s.Value != 12.5 {
+			t.Errorf("Expected CPU of 12.5%%, got %v", s.Value)
+		}
+	}
+}
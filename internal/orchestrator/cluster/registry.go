@@ -0,0 +1,286 @@
+// Package cluster keeps track of the clusters a Velo manager deploys to. Every
+// cluster is a named Docker endpoint with its own orchestration backend, node
+// cache and health state.
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/config"
+	"github.com/jasonlovesdoggo/velo/internal/log"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/manager"
+)
+
+// ErrUnknownCluster is returned when a request selects a cluster that isn't registered
+var ErrUnknownCluster = errors.New("unknown cluster")
+
+// DefaultHealthInterval is how often cluster health is checked
+const DefaultHealthInterval = 30 * time.Second
+
+// Cluster is a registered cluster
+type Cluster struct {
+	Name    string
+	Backend string // swarm, standalone or sim
+	Host    string // Docker endpoint, empty for the local one
+	Manager manager.Manager
+	Agents  *AgentTracker // created on Register if unset
+	Metrics *MetricsStore // created on Register if unset
+
+	mu        sync.RWMutex
+	started   bool
+	healthy   bool
+	lastError string
+	lastCheck time.Time
+
+	failedDeploys map[string]FailedDeploy // by service name
+}
+
+// Status describes a cluster and its health
+type Status struct {
+	Name      string    `json:"name"`
+	Backend   string    `json:"backend"`
+	Host      string    `json:"host,omitempty"`
+	Default   bool      `json:"default"`
+	Healthy   bool      `json:"healthy"`
+	Error     string    `json:"error,omitempty"`
+	LastCheck time.Time `json:"lastCheck"`
+	Nodes     int       `json:"nodes"`
+	// MissingAgents counts nodes whose agent isn't sending heartbeats
+	MissingAgents int `json:"missingAgents"`
+}
+
+// Registry holds the registered clusters
+type Registry struct {
+	mu          sync.RWMutex
+	clusters    map[string]*Cluster
+	defaultName string
+	ticker      *time.Ticker
+	ctx         context.Context
+	cancel      context.CancelFunc
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Registry{
+		clusters: make(map[string]*Cluster),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Single creates a registry with one cluster, named "default", managed by m
+func Single(m manager.Manager) *Registry {
+	r := NewRegistry()
+	_ = r.Register(&Cluster{Name: config.LocalClusterName, Manager: m})
+	return r
+}
+
+// Register adds a cluster. The first cluster registered is the default until SetDefault is called.
+func (r *Registry) Register(c *Cluster) error {
+	if c.Name == "" {
+		return fmt.Errorf("cluster name is required")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.clusters[c.Name]; exists {
+		return fmt.Errorf("cluster %s is already registered", c.Name)
+	}
+	if c.Agents == nil {
+		c.Agents = NewAgentTracker(DefaultHeartbeatInterval)
+	}
+	if c.Metrics == nil {
+		c.Metrics = NewMetricsStore(DefaultMetricsRetention)
+	}
+	r.clusters[c.Name] = c
+	if r.defaultName == "" {
+		r.defaultName = c.Name
+	}
+	return nil
+}
+
+// SetDefault selects the cluster used by requests that don't name one
+func (r *Registry) SetDefault(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.clusters[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownCluster, name)
+	}
+	r.defaultName = name
+	return nil
+}
+
+// Default returns the name of the default cluster
+func (r *Registry) Default() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.defaultName
+}
+
+// Get returns a cluster by name. An empty name selects the default cluster.
+func (r *Registry) Get(name string) (*Cluster, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if name == "" {
+		name = r.defaultName
+	}
+	c, ok := r.clusters[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCluster, name)
+	}
+	return c, nil
+}
+
+// Manager returns the manager of a cluster. An empty name selects the default cluster.
+func (r *Registry) Manager(name string) (manager.Manager, error) {
+	c, err := r.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.Manager, nil
+}
+
+// Clusters returns the registered clusters sorted by name
+func (r *Registry) Clusters() []*Cluster {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	clusters := make([]*Cluster, 0, len(r.clusters))
+	for _, c := range r.clusters {
+		clusters = append(clusters, c)
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Name < clusters[j].Name })
+	return clusters
+}
+
+// List returns the status of every cluster
+func (r *Registry) List() []Status {
+	defaultName := r.Default()
+	var statuses []Status
+	for _, c := range r.Clusters() {
+		status := c.Status()
+		status.Default = c.Name == defaultName
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Start starts the backends of all clusters and checks their health periodically.
+// A cluster that fails to start is marked unhealthy and retried on the next check,
+// so one unreachable cluster doesn't keep the manager from serving the others.
+func (r *Registry) Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultHealthInterval
+	}
+	r.CheckHealth(r.ctx)
+
+	r.ticker = time.NewTicker(interval)
+	go func() {
+		for {
+			select {
+			case <-r.ticker.C:
+				r.CheckHealth(r.ctx)
+			case <-r.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops health checks and the backends of all clusters
+func (r *Registry) Stop() {
+	if r.ticker != nil {
+		r.ticker.Stop()
+	}
+	r.cancel()
+
+	for _, c := range r.Clusters() {
+		c.mu.Lock()
+		if backend, ok := c.Manager.(manager.Backend); ok && c.started {
+			backend.Stop()
+			c.started = false
+		}
+		c.mu.Unlock()
+	}
+}
+
+// CheckHealth starts clusters that aren't running yet and checks the health of the others
+func (r *Registry) CheckHealth(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, c := range r.Clusters() {
+		wg.Add(1)
+		go func(c *Cluster) {
+			defer wg.Done()
+			c.check(ctx)
+		}(c)
+	}
+	wg.Wait()
+}
+
+// Status returns the cluster's status as of the last health check
+func (c *Cluster) Status() Status {
+	c.mu.RLock()
+	status := Status{
+		Name:      c.Name,
+		Backend:   c.Backend,
+		Host:      c.Host,
+		Healthy:   c.healthy,
+		Error:     c.lastError,
+		LastCheck: c.lastCheck,
+	}
+	c.mu.RUnlock()
+
+	nodes := c.Nodes()
+	status.Nodes = len(nodes)
+	for _, n := range nodes {
+		if n.AgentMissing {
+			status.MissingAgents++
+		}
+	}
+	return status
+}
+
+// Healthy reports whether the last health check succeeded
+func (c *Cluster) Healthy() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.healthy
+}
+
+// check starts the cluster's backend if needed and records its health
+func (c *Cluster) check(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var err error
+	if backend, ok := c.Manager.(manager.Backend); ok && !c.started {
+		if err = backend.Start(); err == nil {
+			c.started = true
+			log.Info("Cluster backend started", "cluster", c.Name, "backend", c.Backend)
+		}
+	} else if hc, ok := c.Manager.(manager.HealthChecker); ok {
+		checkCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		err = hc.HealthCheck(checkCtx)
+		cancel()
+	}
+
+	c.lastCheck = time.Now()
+	if err != nil {
+		if c.healthy || c.lastError == "" {
+			log.Warn("Cluster is unhealthy", "cluster", c.Name, "error", err)
+		}
+		c.healthy = false
+		c.lastError = err.Error()
+		return
+	}
+	if !c.healthy && c.lastError != "" {
+		log.Info("Cluster is healthy again", "cluster", c.Name)
+	}
+	c.healthy = true
+	c.lastError = ""
+}
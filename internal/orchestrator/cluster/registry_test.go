@@ -0,0 +1,148 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/jasonlovesdoggo/velo/internal/config"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/dockertest"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/manager"
+)
+
+// swarmCluster starts a fake swarm and returns a cluster managed by a SwarmManager connected to it
+func swarmCluster(t *testing.T, name string, workers int) (*dockertest.Server, *Cluster) {
+	t.Helper()
+
+	srv := dockertest.NewServer(dockertest.Options{Swarm: true, Hostname: name + "-manager"})
+	for i := 0; i < workers; i++ {
+		srv.AddNode(name+"-worker-"+string(rune('a'+i)), swarm.NodeRoleWorker, nil)
+	}
+	cli, err := NewDockerClient(config.ClusterConfig{Name: name, Host: srv.Host()})
+	if err != nil {
+		t.Fatalf("NewDockerClient failed: %v", err)
+	}
+	t.Cleanup(func() {
+		cli.Close()
+		srv.Close()
+	})
+	return srv, &Cluster{Name: name, Backend: "swarm", Host: srv.Host(), Manager: manager.NewSwarmManagerWithClient(cli)}
+}
+
+func TestRegistry(t *testing.T) {
+	_, prod := swarmCluster(t, "prod", 2)
+	staging, stagingCluster := swarmCluster(t, "staging", 0)
+
+	r := NewRegistry()
+	for _, c := range []*Cluster{prod, stagingCluster} {
+		if err := r.Register(c); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+	if err := r.Register(&Cluster{Name: "prod"}); err == nil {
+		t.Error("Expected registering a duplicate cluster to fail")
+	}
+	if err := r.SetDefault("staging"); err != nil {
+		t.Fatalf("SetDefault failed: %v", err)
+	}
+
+	r.Start(0)
+	defer r.Stop()
+
+	if m, err := r.Manager(""); err != nil || m != stagingCluster.Manager {
+		t.Errorf("Expected the default cluster's manager, got %v (%v)", m, err)
+	}
+	if _, err := r.Manager("dev"); !errors.Is(err, ErrUnknownCluster) {
+		t.Errorf("Expected ErrUnknownCluster, got %v", err)
+	}
+
+	statuses := r.List()
+	if len(statuses) != 2 {
+		t.Fatalf("Expected 2 clusters, got %d", len(statuses))
+	}
+	for _, status := range statuses {
+		if !status.Healthy {
+			t.Errorf("Expected cluster %s to be healthy, got error %q", status.Name, status.Error)
+		}
+	}
+	if statuses[0].Name != "prod" || statuses[0].Nodes != 3 || statuses[0].Default {
+		t.Errorf("Unexpected prod status %+v", statuses[0])
+	}
+	if statuses[1].Name != "staging" || statuses[1].Nodes != 1 || !statuses[1].Default {
+		t.Errorf("Unexpected staging status %+v", statuses[1])
+	}
+
+	// Each cluster keeps its own services
+	if _, err := prod.Manager.DeployService(config.ServiceDefinition{Name: "web", Image: "nginx:1", Replicas: 1}); err != nil {
+		t.Fatalf("DeployService failed: %v", err)
+	}
+	if services := staging.Services(); len(services) != 0 {
+		t.Errorf("Expected no services on staging, got %d", len(services))
+	}
+
+	staging.Close()
+	r.CheckHealth(context.Background())
+	if stagingCluster.Healthy() {
+		t.Error("Expected staging to be unhealthy once its endpoint is gone")
+	}
+	if !prod.Healthy() {
+		t.Error("Expected prod to stay healthy")
+	}
+}
+
+func TestRegistryStartFailure(t *testing.T) {
+	// A standalone engine isn't a swarm manager, so the swarm backend can't start
+	srv := dockertest.NewServer(dockertest.Options{})
+	defer srv.Close()
+	cli, err := NewDockerClient(config.ClusterConfig{Name: "edge", Host: srv.Host()})
+	if err != nil {
+		t.Fatalf("NewDockerClient failed: %v", err)
+	}
+	defer cli.Close()
+
+	c := &Cluster{Name: "edge", Backend: "swarm", Manager: manager.NewSwarmManagerWithClient(cli)}
+	r := NewRegistry()
+	_ = r.Register(c)
+	r.Start(0)
+	defer r.Stop()
+
+	status := r.List()[0]
+	if status.Healthy || status.Error == "" {
+		t.Errorf("Expected a cluster that failed to start to be unhealthy, got %+v", status)
+	}
+}
+
+func TestSSHArgs(t *testing.T) {
+	tests := []struct {
+		host     string
+		expected []string
+		wantErr  bool
+	}{
+		{"ssh://example.com", []string{"-o", "ConnectTimeout=30", "-T", "--", "example.com", "docker", "system", "dial-stdio"}, false},
+		{"ssh://deploy@example.com:2222", []string{"-o", "ConnectTimeout=30", "-T", "-l", "deploy", "-p", "2222", "--", "example.com", "docker", "system", "dial-stdio"}, false},
+		{"ssh://example.com/var/run/docker.sock", nil, true},
+		{"ssh://", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			u, _ := url.Parse(tt.host)
+			args, err := sshArgs(u)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(args, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, args)
+			}
+		})
+	}
+}
+
+func TestNewDockerClientRejectsUnknownScheme(t *testing.T) {
+	if _, err := NewDockerClient(config.ClusterConfig{Name: "x", Host: "ftp://example.com"}); err == nil {
+		t.Error("Expected an unsupported scheme to fail")
+	}
+}
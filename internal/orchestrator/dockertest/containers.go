@@ -0,0 +1,728 @@
+package dockertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// AddImage makes an image available locally, as if it had been pulled
+func (s *Server) AddImage(ref string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.images[normalizeImage(ref)] = true
+}
+
+// FailImage makes pulls of an image fail, and swarm tasks using it get rejected
+func (s *Server) FailImage(ref, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if reason == "" {
+		reason = fmt.Sprintf("manifest for %s not found: manifest unknown", normalizeImage(ref))
+	}
+	s.badImages[normalizeImage(ref)] = reason
+	delete(s.images, normalizeImage(ref))
+}
+
+// AddLogs appends log lines to a container or service
+func (s *Server) AddLogs(ref string, lines ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := ref
+	if c := s.findContainer(ref); c != nil {
+		id = c.ID
+	} else if svc := s.findService(ref); svc != nil {
+		id = svc.ID
+	}
+	s.logs[id] = append(s.logs[id], lines...)
+}
+
+// JobResult is what the task of a replicated job service prints and exits with
+type JobResult struct {
+	ExitCode int
+	Lines    []string
+}
+
+// SetJobResult makes the tasks of job services running command print lines
+// and exit with exitCode. Other jobs complete without output.
+func (s *Server) SetJobResult(command []string, exitCode int, lines ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobResults[strings.Join(command, " ")] = JobResult{ExitCode: exitCode, Lines: lines}
+}
+
+// CrashContainer stops a running container with an exit code, as if its process died
+func (s *Server) CrashContainer(ref string, exitCode int, oomKilled bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.findContainer(ref)
+	if c == nil || !c.State.Running {
+		return false
+	}
+	s.exitContainer(c, exitCode, oomKilled)
+
+	// Swarm replaces the task, standalone containers follow their restart policy
+	if t, ok := s.tasks[c.Config.Labels["com.docker.swarm.task.id"]]; ok {
+		s.stopTask(t, swarm.TaskStateFailed, fmt.Sprintf("task: non-zero exit (%d)", exitCode))
+		t.Status.ContainerStatus.ExitCode = exitCode
+		if svc, ok := s.services[t.ServiceID]; ok {
+			s.reconcileService(svc, false)
+		}
+		return true
+	}
+
+	policy := c.HostConfig.RestartPolicy
+	restart := policy.Name == container.RestartPolicyAlways ||
+		policy.Name == container.RestartPolicyUnlessStopped ||
+		policy.Name == container.RestartPolicyOnFailure && exitCode != 0 &&
+			(policy.MaximumRetryCount == 0 || c.RestartCount < policy.MaximumRetryCount)
+	if restart {
+		c.RestartCount++
+		s.startContainer(c)
+	}
+	return true
+}
+
+// SetContainerHealth changes the health status of a container with a healthcheck
+func (s *Server) SetContainerHealth(ref, status string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.findContainer(ref)
+	if c == nil || c.State.Health == nil {
+		return false
+	}
+	c.State.Health.Status = status
+	if status == container.Unhealthy {
+		c.State.Health.FailingStreak++
+	} else {
+		c.State.Health.FailingStreak = 0
+	}
+	s.emit(events.ContainerEventType, events.Action("health_status: "+status), c.ID, containerAttributes(c))
+	return true
+}
+
+// SetContainerStats sets the resource usage a container reports from its stats
+// endpoint. The read time is filled in when the stats are served.
+func (s *Server) SetContainerStats(ref string, stats container.StatsResponse) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.findContainer(ref)
+	if c == nil {
+		return false
+	}
+	s.stats[c.ID] = stats
+	return true
+}
+
+// Containers returns the containers on the local node, sorted by creation
+func (s *Server) Containers() []container.InspectResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var containers []container.InspectResponse
+	for _, c := range s.sortedContainers() {
+		containers = append(containers, *c)
+	}
+	return containers
+}
+
+func (s *Server) handleContainerList(w http.ResponseWriter, r *http.Request) {
+	args, ok := parseFilters(w, r)
+	if !ok {
+		return
+	}
+	all := r.URL.Query().Get("all") == "1" || r.URL.Query().Get("all") == "true"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	summaries := []container.Summary{}
+	for _, c := range s.sortedContainers() {
+		if !all && !c.State.Running {
+			continue
+		}
+		if args.Contains("label") && !args.MatchKVList("label", c.Config.Labels) {
+			continue
+		}
+		if args.Contains("name") && !args.Match("name", strings.TrimPrefix(c.Name, "/")) {
+			continue
+		}
+		if args.Contains("id") && !args.Match("id", c.ID) {
+			continue
+		}
+		if args.Contains("status") && !args.ExactMatch("status", c.State.Status) {
+			continue
+		}
+		summaries = append(summaries, summarize(c))
+	}
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+func (s *Server) handleContainerCreate(w http.ResponseWriter, r *http.Request) {
+	var req container.CreateRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Config == nil || req.Image == "" {
+		writeError(w, http.StatusBadRequest, "config cannot be empty in order to create a container")
+		return
+	}
+	if req.HostConfig == nil {
+		req.HostConfig = &container.HostConfig{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.images[normalizeImage(req.Image)] {
+		writeError(w, http.StatusNotFound, "No such image: %s", normalizeImage(req.Image))
+		return
+	}
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		name = fmt.Sprintf("container_%d", s.nextID+1)
+	}
+	if existing := s.findContainerByName(name); existing != nil {
+		writeError(w, http.StatusConflict, "Conflict. The container name \"/%s\" is already in use by container \"%s\". You have to remove (or rename) that container to be able to reuse that name.", name, existing.ID)
+		return
+	}
+
+	c := s.newContainer(s.newID(""), name, req.Config, req.HostConfig)
+	if req.NetworkingConfig != nil {
+		for net, endpoint := range req.NetworkingConfig.EndpointsConfig {
+			c.NetworkSettings.Networks[net] = endpoint
+		}
+	}
+	writeJSON(w, http.StatusCreated, container.CreateResponse{ID: c.ID, Warnings: []string{}})
+}
+
+func (s *Server) handleContainerInspect(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.findContainer(r.PathValue("id"))
+	if c == nil {
+		writeError(w, http.StatusNotFound, "No such container: %s", r.PathValue("id"))
+		return
+	}
+	writeJSON(w, http.StatusOK, c)
+}
+
+func (s *Server) handleContainerStart(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.findContainer(r.PathValue("id"))
+	if c == nil {
+		writeError(w, http.StatusNotFound, "No such container: %s", r.PathValue("id"))
+		return
+	}
+	if c.State.Running {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	s.startContainer(c)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleContainerStop(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.findContainer(r.PathValue("id"))
+	if c == nil {
+		writeError(w, http.StatusNotFound, "No such container: %s", r.PathValue("id"))
+		return
+	}
+	if !c.State.Running {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	s.exitContainer(c, 0, false)
+	s.emit(events.ContainerEventType, events.ActionStop, c.ID, containerAttributes(c))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleContainerRestart(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.findContainer(r.PathValue("id"))
+	if c == nil {
+		writeError(w, http.StatusNotFound, "No such container: %s", r.PathValue("id"))
+		return
+	}
+	if c.State.Running {
+		s.exitContainer(c, 0, false)
+	}
+	s.startContainer(c)
+	s.emit(events.ContainerEventType, events.ActionRestart, c.ID, containerAttributes(c))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleContainerRemove(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.findContainer(r.PathValue("id"))
+	if c == nil {
+		writeError(w, http.StatusNotFound, "No such container: %s", r.PathValue("id"))
+		return
+	}
+	force := r.URL.Query().Get("force") == "1" || r.URL.Query().Get("force") == "true"
+	if c.State.Running && !force {
+		writeError(w, http.StatusConflict, "cannot remove container \"%s\": container is running: stop the container before removing or force remove", c.Name)
+		return
+	}
+	if c.State.Running {
+		s.exitContainer(c, 137, false)
+	}
+	s.destroyContainer(c)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleLogs serves container and service logs as a multiplexed stream
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	ref := r.PathValue("id")
+	var lines []string
+	tty := false
+	if strings.HasPrefix(r.URL.Path, "/services/") {
+		svc := s.findService(ref)
+		if svc == nil {
+			s.mu.Unlock()
+			writeError(w, http.StatusNotFound, "service %s not found", ref)
+			return
+		}
+		lines = append(lines, s.logs[svc.ID]...)
+	} else {
+		c := s.findContainer(ref)
+		if c == nil {
+			s.mu.Unlock()
+			writeError(w, http.StatusNotFound, "No such container: %s", ref)
+			return
+		}
+		lines = append(lines, s.logs[c.ID]...)
+		tty = c.Config.Tty
+	}
+	s.mu.Unlock()
+
+	if tail, err := strconv.Atoi(r.URL.Query().Get("tail")); err == nil && tail >= 0 && tail < len(lines) {
+		lines = lines[len(lines)-tail:]
+	}
+
+	if tty {
+		w.Header().Set("Content-Type", "application/vnd.docker.raw-stream")
+		w.WriteHeader(http.StatusOK)
+		for _, line := range lines {
+			fmt.Fprintln(w, line)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.docker.multiplexed-stream")
+	w.WriteHeader(http.StatusOK)
+	stdout := stdcopy.NewStdWriter(w, stdcopy.Stdout)
+	for _, line := range lines {
+		fmt.Fprintln(stdout, line)
+	}
+}
+
+func (s *Server) handleImagePull(w http.ResponseWriter, r *http.Request) {
+	ref := r.URL.Query().Get("fromImage")
+	if tag := r.URL.Query().Get("tag"); tag != "" {
+		if strings.HasPrefix(tag, "sha256:") {
+			ref += "@" + tag
+		} else {
+			ref += ":" + tag
+		}
+	}
+	ref = normalizeImage(ref)
+
+	s.mu.Lock()
+	reason, bad := s.badImages[ref]
+	if !bad {
+		s.images[ref] = true
+		s.emit(events.ImageEventType, events.ActionPull, ref, map[string]string{"name": ref})
+	}
+	s.mu.Unlock()
+
+	if bad {
+		writeError(w, http.StatusNotFound, "%s", reason)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(w)
+	_ = enc.Encode(map[string]string{"status": "Pulling from " + ref})
+	_ = enc.Encode(map[string]string{"status": "Digest: sha256:" + strings.Repeat("0", 64)})
+	_ = enc.Encode(map[string]string{"status": "Status: Downloaded newer image for " + ref})
+}
+
+func (s *Server) handleImageInspect(w http.ResponseWriter, r *http.Request) {
+	name, ok := strings.CutSuffix(r.PathValue("name"), "/json")
+	if !ok {
+		writeError(w, http.StatusNotFound, "page not found")
+		return
+	}
+	ref := normalizeImage(name)
+
+	s.mu.Lock()
+	present := s.images[ref]
+	s.mu.Unlock()
+
+	if !present {
+		writeError(w, http.StatusNotFound, "No such image: %s", ref)
+		return
+	}
+	writeJSON(w, http.StatusOK, image.InspectResponse{
+		ID:           "sha256:" + fmt.Sprintf("%064x", len(ref)),
+		RepoTags:     []string{ref},
+		Os:           "linux",
+		Architecture: "amd64",
+	})
+}
+
+// handleContainerStats serves a single stats sample. Stopped containers report
+// no usage, like Docker does.
+func (s *Server) handleContainerStats(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	c := s.findContainer(r.PathValue("id"))
+	if c == nil {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, "No such container: %s", r.PathValue("id"))
+		return
+	}
+	var stats container.StatsResponse
+	if c.State.Running {
+		stats = s.stats[c.ID]
+	}
+	stats.ID = c.ID
+	stats.Name = c.Name
+	stats.Read = time.Now()
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// handleImagePrune removes the images no container uses
+func (s *Server) handleImagePrune(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	used := make(map[string]bool)
+	for _, c := range s.containers {
+		used[normalizeImage(c.Config.Image)] = true
+	}
+	var report image.PruneReport
+	for ref := range s.images {
+		if used[ref] {
+			continue
+		}
+		delete(s.images, ref)
+		report.ImagesDeleted = append(report.ImagesDeleted, image.DeleteResponse{Untagged: ref})
+		s.emit(events.ImageEventType, events.ActionDelete, ref, map[string]string{"name": ref})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(report.ImagesDeleted, func(i, j int) bool {
+		return report.ImagesDeleted[i].Untagged < report.ImagesDeleted[j].Untagged
+	})
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (s *Server) handleNetworkConnect(w http.ResponseWriter, r *http.Request) {
+	var req network.ConnectOptions
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.findContainer(req.Container)
+	if c == nil {
+		writeError(w, http.StatusNotFound, "No such container: %s", req.Container)
+		return
+	}
+	endpoint := req.EndpointConfig
+	if endpoint == nil {
+		endpoint = &network.EndpointSettings{}
+	}
+	c.NetworkSettings.Networks[r.PathValue("id")] = endpoint
+	s.emit(events.NetworkEventType, events.ActionConnect, r.PathValue("id"), map[string]string{"container": c.ID})
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleEvents streams events, replaying past ones when since is set
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	args, ok := parseFilters(w, r)
+	if !ok {
+		return
+	}
+	var since int64 = -1
+	if v := r.URL.Query().Get("since"); v != "" {
+		seconds, _, _ := strings.Cut(v, ".")
+		since, _ = strconv.ParseInt(seconds, 10, 64)
+	}
+
+	ch := make(chan events.Message, 64)
+	s.mu.Lock()
+	var replay []events.Message
+	if since >= 0 {
+		for _, msg := range s.events {
+			if msg.Time >= since {
+				replay = append(replay, msg)
+			}
+		}
+	}
+	s.subscribers[ch] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		if _, ok := s.subscribers[ch]; ok {
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	enc := json.NewEncoder(w)
+	send := func(msg events.Message) bool {
+		if !matchEvent(args, msg) {
+			return true
+		}
+		if err := enc.Encode(msg); err != nil {
+			return false
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return true
+	}
+
+	for _, msg := range replay {
+		if !send(msg) {
+			return
+		}
+	}
+	for {
+		select {
+		case msg, ok := <-ch:
+			if !ok || !send(msg) {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// matchEvent applies the type, event and container filters of an events request
+func matchEvent(args filters.Args, msg events.Message) bool {
+	if args.Contains("type") && !args.ExactMatch("type", string(msg.Type)) {
+		return false
+	}
+	if args.Contains("event") && !args.ExactMatch("event", string(msg.Action)) {
+		return false
+	}
+	if args.Contains("container") && (msg.Type != events.ContainerEventType ||
+		!args.ExactMatch("container", msg.Actor.ID) && !args.ExactMatch("container", msg.Actor.Attributes["name"])) {
+		return false
+	}
+	if args.Contains("label") && !args.MatchKVList("label", msg.Actor.Attributes) {
+		return false
+	}
+	return true
+}
+
+// newContainer adds a created container. Callers must hold s.mu.
+func (s *Server) newContainer(id, name string, cfg *container.Config, hostCfg *container.HostConfig) *container.InspectResponse {
+	if cfg.Labels == nil {
+		cfg.Labels = map[string]string{}
+	}
+	c := &container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			ID:         id,
+			Created:    time.Now().UTC().Format(time.RFC3339Nano),
+			Name:       "/" + name,
+			Image:      "sha256:" + fmt.Sprintf("%064x", len(cfg.Image)),
+			State:      &container.State{Status: "created"},
+			HostConfig: hostCfg,
+			Driver:     "overlay2",
+			Platform:   "linux",
+		},
+		Config:          cfg,
+		NetworkSettings: &container.NetworkSettings{Networks: map[string]*network.EndpointSettings{}},
+	}
+	s.containers[id] = c
+	s.emit(events.ContainerEventType, events.ActionCreate, id, containerAttributes(c))
+	return c
+}
+
+// startContainer marks a container running. Containers with a healthcheck
+// start healthy. Callers must hold s.mu.
+func (s *Server) startContainer(c *container.InspectResponse) {
+	c.State = &container.State{
+		Status:    "running",
+		Running:   true,
+		Pid:       1000 + s.nextID,
+		StartedAt: time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	if hc := c.Config.Healthcheck; hc != nil && len(hc.Test) > 0 && hc.Test[0] != "NONE" {
+		c.State.Health = &container.Health{Status: container.Healthy}
+	}
+	s.nextID++
+	s.emit(events.ContainerEventType, events.ActionStart, c.ID, containerAttributes(c))
+}
+
+// exitContainer marks a container exited. Callers must hold s.mu.
+func (s *Server) exitContainer(c *container.InspectResponse, exitCode int, oomKilled bool) {
+	health := c.State.Health
+	if health != nil {
+		health.Status = container.Unhealthy
+	}
+	c.State = &container.State{
+		Status:     "exited",
+		ExitCode:   exitCode,
+		OOMKilled:  oomKilled,
+		StartedAt:  c.State.StartedAt,
+		FinishedAt: time.Now().UTC().Format(time.RFC3339Nano),
+		Health:     health,
+	}
+
+	attributes := containerAttributes(c)
+	if oomKilled {
+		s.emit(events.ContainerEventType, events.ActionOOM, c.ID, attributes)
+	}
+	attributes["exitCode"] = strconv.Itoa(exitCode)
+	s.emit(events.ContainerEventType, events.ActionDie, c.ID, attributes)
+
+}
+
+// destroyContainer removes a container. Callers must hold s.mu.
+func (s *Server) destroyContainer(c *container.InspectResponse) {
+	delete(s.containers, c.ID)
+	delete(s.logs, c.ID)
+	s.emit(events.ContainerEventType, events.ActionDestroy, c.ID, containerAttributes(c))
+}
+
+// findContainer looks a container up by ID, name or ID prefix. Callers must hold s.mu.
+func (s *Server) findContainer(ref string) *container.InspectResponse {
+	if c, ok := s.containers[ref]; ok {
+		return c
+	}
+	if c := s.findContainerByName(ref); c != nil {
+		return c
+	}
+	for id, c := range s.containers {
+		if ref != "" && strings.HasPrefix(id, ref) {
+			return c
+		}
+	}
+	return nil
+}
+
+// findContainerByName looks a container up by name. Callers must hold s.mu.
+func (s *Server) findContainerByName(name string) *container.InspectResponse {
+	name = "/" + strings.TrimPrefix(name, "/")
+	for _, c := range s.containers {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// sortedContainers returns the containers sorted by creation. Callers must hold s.mu.
+func (s *Server) sortedContainers() []*container.InspectResponse {
+	containers := make([]*container.InspectResponse, 0, len(s.containers))
+	for _, c := range s.containers {
+		containers = append(containers, c)
+	}
+	sort.Slice(containers, func(i, j int) bool {
+		if containers[i].Created != containers[j].Created {
+			return containers[i].Created < containers[j].Created
+		}
+		return containers[i].ID < containers[j].ID
+	})
+	return containers
+}
+
+// badImage returns why pulls of an image fail, or "" if they don't. Callers must hold s.mu.
+func (s *Server) badImage(ref string) string {
+	return s.badImages[normalizeImage(ref)]
+}
+
+// summarize builds the container list entry of a container
+func summarize(c *container.InspectResponse) container.Summary {
+	created, _ := time.Parse(time.RFC3339Nano, c.Created)
+	summary := container.Summary{
+		ID:              c.ID,
+		Names:           []string{c.Name},
+		Image:           c.Config.Image,
+		ImageID:         c.Image,
+		Command:         strings.Join(c.Config.Cmd, " "),
+		Created:         created.Unix(),
+		Labels:          c.Config.Labels,
+		State:           c.State.Status,
+		Status:          statusText(c.State),
+		NetworkSettings: &container.NetworkSettingsSummary{Networks: c.NetworkSettings.Networks},
+	}
+	summary.HostConfig.NetworkMode = string(c.HostConfig.NetworkMode)
+	return summary
+}
+
+// statusText renders a container state the way `docker ps` does
+func statusText(state *container.State) string {
+	switch state.Status {
+	case "running":
+		status := "Up Less than a second"
+		if state.Health != nil {
+			status += " (" + state.Health.Status + ")"
+		}
+		return status
+	case "exited":
+		return fmt.Sprintf("Exited (%d) Less than a second ago", state.ExitCode)
+	default:
+		return "Created"
+	}
+}
+
+// containerAttributes returns the event attributes of a container
+func containerAttributes(c *container.InspectResponse) map[string]string {
+	attributes := map[string]string{
+		"name":  strings.TrimPrefix(c.Name, "/"),
+		"image": c.Config.Image,
+	}
+	for k, v := range c.Config.Labels {
+		attributes[k] = v
+	}
+	return attributes
+}
+
+// normalizeImage strips the default registry from an image reference and adds
+// the implicit latest tag, so "nginx" and "docker.io/library/nginx:latest" match
+func normalizeImage(ref string) string {
+	ref = strings.TrimPrefix(ref, "docker.io/")
+	ref = strings.TrimPrefix(ref, "library/")
+	if strings.Contains(ref, "@") {
+		return ref
+	}
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		return ref
+	}
+	return ref + ":latest"
+}
@@ -0,0 +1,284 @@
+// Package dockertest serves a fake Docker Engine API for tests. It implements the
+// subset of the API Velo uses (swarm, nodes, services, tasks, containers, images,
+// networks, events and logs) on top of in-memory state, so the real Docker client
+// and everything built on it can be tested without a daemon.
+//
+// The fake schedules swarm tasks immediately: creating or updating a service
+// replaces its tasks with running ones spread over the active, ready nodes.
+// Placement constraints and resource reservations are not simulated.
+package dockertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+)
+
+// APIVersion is the Engine API version the fake server reports
+const APIVersion = "1.47"
+
+// Options configures a fake Docker Engine
+type Options struct {
+	// Swarm initializes a swarm with the local node as its manager
+	Swarm bool
+	// Hostname is the hostname of the local node. Defaults to "docker-1".
+	Hostname string
+	// UnixSocket serves the API on a unix socket at this path instead of TCP
+	UnixSocket string
+}
+
+// Server is a fake Docker Engine API server
+type Server struct {
+	http *httptest.Server
+
+	mu           sync.Mutex
+	daemonID     string
+	hostname     string
+	swarm        *swarm.Swarm // nil outside a swarm, without an ID on workers
+	localNodeID  string
+	joinRequests []swarm.JoinRequest
+	nodes        map[string]*swarm.Node
+	services     map[string]*swarm.Service
+	tasks        map[string]*swarm.Task
+	containers   map[string]*container.InspectResponse
+	images       map[string]bool
+	badImages    map[string]string
+	logs         map[string][]string
+	stats        map[string]container.StatsResponse // by container ID
+	jobResults   map[string]JobResult               // by command
+	events       []events.Message
+	subscribers  map[chan events.Message]struct{}
+	calls        []string
+	version      uint64
+	nextID       int
+}
+
+// NewServer starts a fake Docker Engine. Close it when done.
+func NewServer(opts Options) *Server {
+	if opts.Hostname == "" {
+		opts.Hostname = "docker-1"
+	}
+
+	s := &Server{
+		hostname:    opts.Hostname,
+		nodes:       make(map[string]*swarm.Node),
+		services:    make(map[string]*swarm.Service),
+		tasks:       make(map[string]*swarm.Task),
+		containers:  make(map[string]*container.InspectResponse),
+		images:      make(map[string]bool),
+		badImages:   make(map[string]string),
+		logs:        make(map[string][]string),
+		stats:       make(map[string]container.StatsResponse),
+		jobResults:  make(map[string]JobResult),
+		subscribers: make(map[chan events.Message]struct{}),
+	}
+	s.daemonID = s.newID("daemon")
+
+	if opts.Swarm {
+		s.initSwarm("")
+	}
+
+	s.http = httptest.NewUnstartedServer(s.routes())
+	if opts.UnixSocket != "" {
+		lis, err := net.Listen("unix", opts.UnixSocket)
+		if err != nil {
+			panic(fmt.Sprintf("dockertest: failed to listen on %s: %v", opts.UnixSocket, err))
+		}
+		s.http.Listener.Close()
+		s.http.Listener = lis
+	}
+	s.http.Start()
+	return s
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.mu.Lock()
+	for ch := range s.subscribers {
+		close(ch)
+		delete(s.subscribers, ch)
+	}
+	s.mu.Unlock()
+	s.http.Close()
+}
+
+// Host returns the Docker host URL of the server, e.g. for DOCKER_HOST
+func (s *Server) Host() string {
+	addr := s.http.Listener.Addr()
+	if addr.Network() == "unix" {
+		return "unix://" + addr.String()
+	}
+	return "tcp://" + addr.String()
+}
+
+// Client returns a Docker client connected to the server
+func (s *Server) Client() (*client.Client, error) {
+	return client.NewClientWithOpts(client.WithHost(s.Host()), client.WithAPIVersionNegotiation())
+}
+
+// Calls returns the API calls made so far, as "METHOD /path" without the version prefix
+func (s *Server) Calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	calls := make([]string, len(s.calls))
+	copy(calls, s.calls)
+	return calls
+}
+
+var versionPrefix = regexp.MustCompile(`^/v[0-9.]+/`)
+
+// routes builds the request router
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /_ping", s.handlePing)
+	mux.HandleFunc("HEAD /_ping", s.handlePing)
+	mux.HandleFunc("GET /version", s.handleVersion)
+	mux.HandleFunc("GET /info", s.handleInfo)
+	mux.HandleFunc("GET /events", s.handleEvents)
+
+	mux.HandleFunc("GET /swarm", s.handleSwarmInspect)
+	mux.HandleFunc("POST /swarm/init", s.handleSwarmInit)
+	mux.HandleFunc("POST /swarm/join", s.handleSwarmJoin)
+	mux.HandleFunc("POST /swarm/leave", s.handleSwarmLeave)
+
+	mux.HandleFunc("GET /nodes", s.handleNodeList)
+	mux.HandleFunc("GET /nodes/{id}", s.handleNodeInspect)
+	mux.HandleFunc("POST /nodes/{id}/update", s.handleNodeUpdate)
+	mux.HandleFunc("DELETE /nodes/{id}", s.handleNodeRemove)
+
+	mux.HandleFunc("GET /services", s.handleServiceList)
+	mux.HandleFunc("POST /services/create", s.handleServiceCreate)
+	mux.HandleFunc("GET /services/{id}", s.handleServiceInspect)
+	mux.HandleFunc("POST /services/{id}/update", s.handleServiceUpdate)
+	mux.HandleFunc("DELETE /services/{id}", s.handleServiceRemove)
+	mux.HandleFunc("GET /services/{id}/logs", s.handleLogs)
+	mux.HandleFunc("GET /tasks", s.handleTaskList)
+	mux.HandleFunc("GET /tasks/{id}", s.handleTaskInspect)
+
+	mux.HandleFunc("GET /containers/json", s.handleContainerList)
+	mux.HandleFunc("POST /containers/create", s.handleContainerCreate)
+	mux.HandleFunc("GET /containers/{id}/json", s.handleContainerInspect)
+	mux.HandleFunc("POST /containers/{id}/start", s.handleContainerStart)
+	mux.HandleFunc("POST /containers/{id}/stop", s.handleContainerStop)
+	mux.HandleFunc("POST /containers/{id}/restart", s.handleContainerRestart)
+	mux.HandleFunc("DELETE /containers/{id}", s.handleContainerRemove)
+	mux.HandleFunc("GET /containers/{id}/logs", s.handleLogs)
+	mux.HandleFunc("GET /containers/{id}/stats", s.handleContainerStats)
+
+	mux.HandleFunc("POST /images/create", s.handleImagePull)
+	mux.HandleFunc("GET /images/{name...}", s.handleImageInspect)
+	mux.HandleFunc("POST /images/prune", s.handleImagePrune)
+	mux.HandleFunc("POST /networks/{id}/connect", s.handleNetworkConnect)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if loc := versionPrefix.FindStringIndex(r.URL.Path); loc != nil {
+			r.URL.Path = r.URL.Path[loc[1]-1:]
+		}
+		r.URL.RawPath = ""
+
+		s.mu.Lock()
+		s.calls = append(s.calls, r.Method+" "+r.URL.Path)
+		s.mu.Unlock()
+
+		w.Header().Set("Api-Version", APIVersion)
+		w.Header().Set("Server", "Docker/dockertest")
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handlePing(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	if s.inSwarm() {
+		w.Header().Set("Swarm", "active/manager")
+	} else {
+		w.Header().Set("Swarm", "inactive")
+	}
+	if r.Method == http.MethodGet {
+		_, _ = w.Write([]byte("OK"))
+	}
+}
+
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"Version":       "28.1.1",
+		"ApiVersion":    APIVersion,
+		"MinAPIVersion": "1.24",
+		"Os":            "linux",
+		"Arch":          "amd64",
+	})
+}
+
+func (s *Server) inSwarm() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.swarm != nil
+}
+
+// newID returns a new unique ID. Callers must hold s.mu or be in the constructor.
+func (s *Server) newID(prefix string) string {
+	s.nextID++
+	return fmt.Sprintf("%s%020d", prefix, s.nextID)
+}
+
+// nextVersion returns a new object version. Callers must hold s.mu.
+func (s *Server) nextVersion() swarm.Version {
+	s.version++
+	return swarm.Version{Index: s.version}
+}
+
+// emit records an event and sends it to subscribers. Callers must hold s.mu.
+func (s *Server) emit(eventType events.Type, action events.Action, id string, attributes map[string]string) {
+	now := time.Now()
+	msg := events.Message{
+		Type:     eventType,
+		Action:   action,
+		Actor:    events.Actor{ID: id, Attributes: attributes},
+		Scope:    "local",
+		Time:     now.Unix(),
+		TimeNano: now.UnixNano(),
+	}
+	if eventType == events.ServiceEventType || eventType == events.NodeEventType {
+		msg.Scope = "swarm"
+	}
+	s.events = append(s.events, msg)
+
+	for ch := range s.subscribers {
+		select {
+		case ch <- msg:
+		default:
+			// Slow subscribers miss events rather than block the server
+		}
+	}
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error in the Engine API's format
+func writeError(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	writeJSON(w, status, map[string]string{"message": fmt.Sprintf(format, args...)})
+}
+
+// decodeBody decodes a JSON request body, writing an error response on failure
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: %v", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,133 @@
+package dockertest
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+func TestEventsAndLogs(t *testing.T) {
+	srv := NewServer(Options{})
+	defer srv.Close()
+	srv.AddImage("busybox")
+
+	cli, err := srv.Client()
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	messages, errs := cli.Events(ctx, events.ListOptions{Filters: filters.NewArgs(filters.Arg("type", "container"))})
+
+	created, err := cli.ContainerCreate(ctx, &container.Config{Image: "busybox"},
+		&container.HostConfig{RestartPolicy: container.RestartPolicy{Name: container.RestartPolicyOnFailure, MaximumRetryCount: 1}}, nil, nil, "job")
+	if err != nil {
+		t.Fatalf("ContainerCreate failed: %v", err)
+	}
+	if err := cli.ContainerStart(ctx, created.ID, container.StartOptions{}); err != nil {
+		t.Fatalf("ContainerStart failed: %v", err)
+	}
+
+	// The first crash is restarted by the restart policy, the second isn't
+	srv.CrashContainer(created.ID, 1, false)
+	srv.CrashContainer(created.ID, 137, true)
+	inspect, err := cli.ContainerInspect(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("ContainerInspect failed: %v", err)
+	}
+	if inspect.State.Running || !inspect.State.OOMKilled || inspect.State.ExitCode != 137 || inspect.RestartCount != 1 {
+		t.Errorf("Unexpected state %+v after %d restart(s)", inspect.State, inspect.RestartCount)
+	}
+
+	var actions []string
+	for len(actions) < 6 {
+		select {
+		case msg := <-messages:
+			actions = append(actions, string(msg.Action))
+		case err := <-errs:
+			t.Fatalf("Events failed: %v", err)
+		}
+	}
+	if got := strings.Join(actions, ","); got != "create,start,die,start,oom,die" {
+		t.Errorf("Unexpected events %s", got)
+	}
+
+	srv.AddLogs("job", "hello", "world")
+	reader, err := cli.ContainerLogs(ctx, created.ID, container.LogsOptions{ShowStdout: true, Tail: "1"})
+	if err != nil {
+		t.Fatalf("ContainerLogs failed: %v", err)
+	}
+	defer reader.Close()
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, reader); err != nil || stdout.String() != "world\n" {
+		t.Errorf("Expected last log line, got %q (%v)", stdout.String(), err)
+	}
+}
+
+func TestUnixSocket(t *testing.T) {
+	srv := NewServer(Options{UnixSocket: filepath.Join(t.TempDir(), "docker.sock")})
+	defer srv.Close()
+	cli, _ := srv.Client()
+	defer cli.Close()
+
+	info, err := cli.Info(context.Background())
+	if err != nil || info.Swarm.LocalNodeState != swarm.LocalNodeStateInactive {
+		t.Errorf("Unexpected info %+v (%v)", info.Swarm, err)
+	}
+}
+
+func TestServiceVersioning(t *testing.T) {
+	srv := NewServer(Options{Swarm: true})
+	defer srv.Close()
+	cli, _ := srv.Client()
+	defer cli.Close()
+	ctx := context.Background()
+
+	replicas := uint64(2)
+	spec := swarm.ServiceSpec{
+		Annotations:  swarm.Annotations{Name: "web"},
+		TaskTemplate: swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{Image: "nginx:1"}},
+		Mode:         swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: &replicas}},
+	}
+	created, err := cli.ServiceCreate(ctx, spec, types.ServiceCreateOptions{})
+	if err != nil {
+		t.Fatalf("ServiceCreate failed: %v", err)
+	}
+	service, _, _ := cli.ServiceInspectWithRaw(ctx, created.ID, types.ServiceInspectOptions{})
+
+	spec.TaskTemplate.ContainerSpec.Image = "nginx:2"
+	if _, err := cli.ServiceUpdate(ctx, created.ID, service.Version, spec, types.ServiceUpdateOptions{}); err != nil {
+		t.Fatalf("ServiceUpdate failed: %v", err)
+	}
+	if _, err := cli.ServiceUpdate(ctx, created.ID, service.Version, spec, types.ServiceUpdateOptions{}); err == nil ||
+		!strings.Contains(err.Error(), "out of sequence") {
+		t.Errorf("Expected stale update to fail, got %v", err)
+	}
+
+	// Both tasks of the first version were replaced, and their containers stopped
+	tasks := srv.Tasks("web")
+	if len(tasks) != 4 || tasks[0].DesiredState != swarm.TaskStateShutdown || tasks[3].Spec.ContainerSpec.Image != "nginx:2" {
+		t.Errorf("Unexpected tasks %+v", tasks)
+	}
+	running := 0
+	for _, c := range srv.Containers() {
+		if c.State.Running {
+			running++
+		}
+	}
+	if running != 2 {
+		t.Errorf("Expected 2 running task containers, got %d", running)
+	}
+}
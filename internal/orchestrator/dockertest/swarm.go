@@ -0,0 +1,922 @@
+package dockertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/api/types/system"
+)
+
+// Resources of every fake node
+const (
+	nodeCPUs   = 4
+	nodeMemory = 8 << 30
+)
+
+// initSwarm creates a swarm managed by the local node. Callers must hold s.mu or be in the constructor.
+func (s *Server) initSwarm(advertiseAddr string) {
+	if advertiseAddr == "" {
+		advertiseAddr = "10.0.0.1"
+	}
+
+	now := time.Now()
+	s.swarm = &swarm.Swarm{
+		ClusterInfo: swarm.ClusterInfo{
+			ID:                     s.newID("swarm"),
+			Meta:                   swarm.Meta{Version: s.nextVersion(), CreatedAt: now, UpdatedAt: now},
+			Spec:                   swarm.Spec{Annotations: swarm.Annotations{Name: "default"}},
+			RootRotationInProgress: false,
+		},
+		JoinTokens: swarm.JoinTokens{
+			Worker:  "SWMTKN-1-" + s.newID("worker"),
+			Manager: "SWMTKN-1-" + s.newID("manager"),
+		},
+	}
+
+	local := s.addNode(s.hostname, swarm.NodeRoleManager, nil, advertiseAddr)
+	local.ManagerStatus = &swarm.ManagerStatus{Leader: true, Reachability: swarm.ReachabilityReachable, Addr: advertiseAddr + ":2377"}
+	s.localNodeID = local.ID
+}
+
+// addNode adds a ready, active node to the swarm. Callers must hold s.mu.
+func (s *Server) addNode(hostname string, role swarm.NodeRole, labels map[string]string, addr string) *swarm.Node {
+	now := time.Now()
+	n := &swarm.Node{
+		ID:   s.newID("node"),
+		Meta: swarm.Meta{Version: s.nextVersion(), CreatedAt: now, UpdatedAt: now},
+		Spec: swarm.NodeSpec{
+			Annotations:  swarm.Annotations{Labels: labels},
+			Role:         role,
+			Availability: swarm.NodeAvailabilityActive,
+		},
+		Description: swarm.NodeDescription{
+			Hostname:  hostname,
+			Platform:  swarm.Platform{Architecture: "x86_64", OS: "linux"},
+			Resources: swarm.Resources{NanoCPUs: nodeCPUs * 1e9, MemoryBytes: nodeMemory},
+			Engine:    swarm.EngineDescription{EngineVersion: "28.1.1"},
+		},
+		Status: swarm.NodeStatus{State: swarm.NodeStateReady, Addr: addr},
+	}
+	if role == swarm.NodeRoleManager && s.localNodeID != "" {
+		n.ManagerStatus = &swarm.ManagerStatus{Reachability: swarm.ReachabilityReachable, Addr: addr + ":2377"}
+	}
+	s.nodes[n.ID] = n
+	s.emit(events.NodeEventType, events.ActionCreate, n.ID, map[string]string{"name": hostname})
+	return n
+}
+
+// AddNode adds a ready, active node to the swarm and returns its ID. Tasks are
+// not rebalanced onto it, like in a real swarm.
+func (s *Server) AddNode(hostname string, role swarm.NodeRole, labels map[string]string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	addr := "10.0.0." + strconv.Itoa(len(s.nodes)+1)
+	return s.addNode(hostname, role, labels, addr).ID
+}
+
+// SetNodeState changes a node's status, e.g. to swarm.NodeStateDown. Tasks on
+// nodes that are no longer ready are failed and rescheduled.
+func (s *Server) SetNodeState(ref string, state swarm.NodeState) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := s.findNode(ref)
+	if n == nil {
+		return false
+	}
+	n.Status.State = state
+	n.Meta.Version = s.nextVersion()
+	s.emit(events.NodeEventType, events.ActionUpdate, n.ID, map[string]string{"name": n.Description.Hostname})
+	s.reconcileAll()
+	return true
+}
+
+// Nodes returns the swarm's nodes
+func (s *Server) Nodes() []swarm.Node {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.nodeList()
+}
+
+// Services returns the swarm's services
+func (s *Server) Services() []swarm.Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.serviceList()
+}
+
+// Tasks returns the tasks of a service, or of every service if ref is empty
+func (s *Server) Tasks(ref string) []swarm.Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var serviceID string
+	if ref != "" {
+		svc := s.findService(ref)
+		if svc == nil {
+			return nil
+		}
+		serviceID = svc.ID
+	}
+
+	var tasks []swarm.Task
+	for _, t := range s.sortedTasks() {
+		if serviceID == "" || t.ServiceID == serviceID {
+			tasks = append(tasks, *t)
+		}
+	}
+	return tasks
+}
+
+func (s *Server) handleInfo(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info := system.Info{
+		ID:              s.daemonID,
+		Name:            s.hostname,
+		NCPU:            nodeCPUs,
+		MemTotal:        nodeMemory,
+		ServerVersion:   "28.1.1",
+		OperatingSystem: "dockertest",
+		OSType:          "linux",
+		Architecture:    "x86_64",
+		Containers:      len(s.containers),
+		Images:          len(s.images),
+		Swarm:           swarm.Info{LocalNodeState: swarm.LocalNodeStateInactive},
+	}
+	for _, c := range s.containers {
+		switch c.State.Status {
+		case "running":
+			info.ContainersRunning++
+		case "paused":
+			info.ContainersPaused++
+		default:
+			info.ContainersStopped++
+		}
+	}
+
+	if s.swarm != nil {
+		info.Swarm = swarm.Info{
+			NodeID:           s.localNodeID,
+			LocalNodeState:   swarm.LocalNodeStateActive,
+			ControlAvailable: s.swarm.ID != "",
+			Nodes:            len(s.nodes),
+		}
+		if local := s.nodes[s.localNodeID]; local != nil {
+			info.Swarm.NodeAddr = local.Status.Addr
+		}
+		for _, n := range s.nodes {
+			if n.ManagerStatus != nil {
+				info.Swarm.Managers++
+			}
+		}
+		if info.Swarm.ControlAvailable {
+			cluster := s.swarm.ClusterInfo
+			info.Swarm.Cluster = &cluster
+		}
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (s *Server) handleSwarmInspect(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isManager() {
+		writeNotManager(w)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.swarm)
+}
+
+func (s *Server) handleSwarmInit(w http.ResponseWriter, r *http.Request) {
+	var req swarm.InitRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.swarm != nil {
+		writeError(w, http.StatusServiceUnavailable, "This node is already part of a swarm. Use \"docker swarm leave\" to leave this swarm and join another one.")
+		return
+	}
+
+	addr := req.AdvertiseAddr
+	if host, _, found := strings.Cut(addr, ":"); found {
+		addr = host
+	}
+	s.initSwarm(addr)
+	writeJSON(w, http.StatusOK, s.localNodeID)
+}
+
+func (s *Server) handleSwarmJoin(w http.ResponseWriter, r *http.Request) {
+	var req swarm.JoinRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.swarm != nil {
+		writeError(w, http.StatusServiceUnavailable, "This node is already part of a swarm. Use \"docker swarm leave\" to leave this swarm and join another one.")
+		return
+	}
+	if len(req.RemoteAddrs) == 0 {
+		writeError(w, http.StatusBadRequest, "at least 1 RemoteAddr is required to join")
+		return
+	}
+	if !strings.HasPrefix(req.JoinToken, "SWMTKN-1-") {
+		writeError(w, http.StatusBadRequest, "invalid join token")
+		return
+	}
+
+	// The local node joins a remote swarm: it becomes a node of its own,
+	// without access to the swarm's control plane
+	role := swarm.NodeRoleWorker
+	if strings.Contains(req.JoinToken, "manager") {
+		role = swarm.NodeRoleManager
+	}
+	s.swarm = &swarm.Swarm{}
+	s.joinRequests = append(s.joinRequests, req)
+	local := s.addNode(s.hostname, role, nil, req.AdvertiseAddr)
+	s.localNodeID = local.ID
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleSwarmLeave(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.swarm == nil {
+		writeError(w, http.StatusServiceUnavailable, "This node is not part of a swarm")
+		return
+	}
+	if s.isManager() && r.URL.Query().Get("force") != "1" && r.URL.Query().Get("force") != "true" {
+		writeError(w, http.StatusServiceUnavailable, "You are attempting to leave the swarm on a node that is participating as a manager. Use `--force` to ignore this message.")
+		return
+	}
+
+	s.swarm = nil
+	s.localNodeID = ""
+	s.nodes = make(map[string]*swarm.Node)
+	s.services = make(map[string]*swarm.Service)
+	for id := range s.tasks {
+		s.removeTask(id)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// JoinRequests returns the swarm join requests the server has accepted
+func (s *Server) JoinRequests() []swarm.JoinRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reqs := make([]swarm.JoinRequest, len(s.joinRequests))
+	copy(reqs, s.joinRequests)
+	return reqs
+}
+
+// isManager reports whether the local node manages a swarm. Callers must hold s.mu.
+func (s *Server) isManager() bool {
+	return s.swarm != nil && s.swarm.ID != ""
+}
+
+func writeNotManager(w http.ResponseWriter) {
+	writeError(w, http.StatusServiceUnavailable, "This node is not a swarm manager. Use \"docker swarm init\" or \"docker swarm join\" to connect this node to swarm and try again.")
+}
+
+// Nodes
+
+func (s *Server) handleNodeList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isManager() {
+		writeNotManager(w)
+		return
+	}
+	args, ok := parseFilters(w, r)
+	if !ok {
+		return
+	}
+
+	nodes := []swarm.Node{}
+	for _, n := range s.nodeList() {
+		if args.Contains("role") && !args.ExactMatch("role", string(n.Spec.Role)) {
+			continue
+		}
+		if args.Contains("name") && !args.ExactMatch("name", n.Description.Hostname) {
+			continue
+		}
+		nodes = append(nodes, n)
+	}
+	writeJSON(w, http.StatusOK, nodes)
+}
+
+func (s *Server) handleNodeInspect(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isManager() {
+		writeNotManager(w)
+		return
+	}
+	n := s.findNode(r.PathValue("id"))
+	if n == nil {
+		writeError(w, http.StatusNotFound, "node %s not found", r.PathValue("id"))
+		return
+	}
+	writeJSON(w, http.StatusOK, n)
+}
+
+func (s *Server) handleNodeUpdate(w http.ResponseWriter, r *http.Request) {
+	var spec swarm.NodeSpec
+	if !decodeBody(w, r, &spec) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isManager() {
+		writeNotManager(w)
+		return
+	}
+	n := s.findNode(r.PathValue("id"))
+	if n == nil {
+		writeError(w, http.StatusNotFound, "node %s not found", r.PathValue("id"))
+		return
+	}
+	if !checkVersion(w, r, n.Version) {
+		return
+	}
+
+	n.Spec = spec
+	n.Meta.Version = s.nextVersion()
+	n.UpdatedAt = time.Now()
+	s.emit(events.NodeEventType, events.ActionUpdate, n.ID, map[string]string{"name": n.Description.Hostname})
+	s.reconcileAll()
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleNodeRemove(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isManager() {
+		writeNotManager(w)
+		return
+	}
+	n := s.findNode(r.PathValue("id"))
+	if n == nil {
+		writeError(w, http.StatusNotFound, "node %s not found", r.PathValue("id"))
+		return
+	}
+	force := r.URL.Query().Get("force") == "1" || r.URL.Query().Get("force") == "true"
+	if n.Status.State == swarm.NodeStateReady && !force {
+		writeError(w, http.StatusBadRequest, "rpc error: code = FailedPrecondition desc = node %s is not down and can't be removed", n.ID)
+		return
+	}
+	if n.ID == s.localNodeID {
+		writeError(w, http.StatusBadRequest, "rpc error: code = FailedPrecondition desc = node %s is a cluster manager and is a member of the raft cluster", n.ID)
+		return
+	}
+
+	delete(s.nodes, n.ID)
+	s.emit(events.NodeEventType, events.ActionRemove, n.ID, map[string]string{"name": n.Description.Hostname})
+	s.reconcileAll()
+	w.WriteHeader(http.StatusOK)
+}
+
+// findNode looks a node up by ID or hostname. Callers must hold s.mu.
+func (s *Server) findNode(ref string) *swarm.Node {
+	if n, ok := s.nodes[ref]; ok {
+		return n
+	}
+	for _, n := range s.nodeList() {
+		if n.Description.Hostname == ref {
+			return s.nodes[n.ID]
+		}
+	}
+	return nil
+}
+
+// nodeList returns copies of the nodes sorted by hostname. Callers must hold s.mu.
+func (s *Server) nodeList() []swarm.Node {
+	nodes := make([]swarm.Node, 0, len(s.nodes))
+	for _, n := range s.nodes {
+		nodes = append(nodes, *n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Description.Hostname < nodes[j].Description.Hostname })
+	return nodes
+}
+
+// Services
+
+func (s *Server) handleServiceList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isManager() {
+		writeNotManager(w)
+		return
+	}
+	args, ok := parseFilters(w, r)
+	if !ok {
+		return
+	}
+
+	services := []swarm.Service{}
+	for _, svc := range s.serviceList() {
+		if args.Contains("name") && !args.ExactMatch("name", svc.Spec.Name) {
+			continue
+		}
+		if args.Contains("id") && !args.ExactMatch("id", svc.ID) {
+			continue
+		}
+		if args.Contains("label") && !args.MatchKVList("label", svc.Spec.Labels) {
+			continue
+		}
+		services = append(services, svc)
+	}
+	writeJSON(w, http.StatusOK, services)
+}
+
+func (s *Server) handleServiceCreate(w http.ResponseWriter, r *http.Request) {
+	var spec swarm.ServiceSpec
+	if !decodeBody(w, r, &spec) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isManager() {
+		writeNotManager(w)
+		return
+	}
+	if spec.Name == "" {
+		writeError(w, http.StatusBadRequest, "rpc error: code = InvalidArgument desc = name must be valid as a DNS name component")
+		return
+	}
+	if spec.TaskTemplate.ContainerSpec == nil || spec.TaskTemplate.ContainerSpec.Image == "" {
+		writeError(w, http.StatusBadRequest, "rpc error: code = InvalidArgument desc = ContainerSpec: image reference must be provided")
+		return
+	}
+	if s.findService(spec.Name) != nil {
+		writeError(w, http.StatusConflict, "rpc error: code = AlreadyExists desc = name conflicts with an existing object: service %s already exists", spec.Name)
+		return
+	}
+
+	now := time.Now()
+	svc := &swarm.Service{
+		ID:   s.newID("service"),
+		Meta: swarm.Meta{Version: s.nextVersion(), CreatedAt: now, UpdatedAt: now},
+		Spec: spec,
+	}
+	s.services[svc.ID] = svc
+	s.emit(events.ServiceEventType, events.ActionCreate, svc.ID, map[string]string{"name": spec.Name})
+	s.reconcileService(svc, false)
+
+	writeJSON(w, http.StatusCreated, swarm.ServiceCreateResponse{ID: svc.ID})
+}
+
+func (s *Server) handleServiceInspect(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isManager() {
+		writeNotManager(w)
+		return
+	}
+	svc := s.findService(r.PathValue("id"))
+	if svc == nil {
+		writeError(w, http.StatusNotFound, "service %s not found", r.PathValue("id"))
+		return
+	}
+	writeJSON(w, http.StatusOK, svc)
+}
+
+func (s *Server) handleServiceUpdate(w http.ResponseWriter, r *http.Request) {
+	var spec swarm.ServiceSpec
+	if !decodeBody(w, r, &spec) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isManager() {
+		writeNotManager(w)
+		return
+	}
+	svc := s.findService(r.PathValue("id"))
+	if svc == nil {
+		writeError(w, http.StatusNotFound, "service %s not found", r.PathValue("id"))
+		return
+	}
+	if !checkVersion(w, r, svc.Version) {
+		return
+	}
+
+	previous := svc.Spec
+	state := swarm.UpdateStateCompleted
+	message := "update completed"
+	if r.URL.Query().Get("rollback") == "previous" {
+		if svc.PreviousSpec == nil {
+			writeError(w, http.StatusBadRequest, "rpc error: code = FailedPrecondition desc = service %s does not have a previous spec", svc.ID)
+			return
+		}
+		spec = *svc.PreviousSpec
+		state = swarm.UpdateStateRollbackCompleted
+		message = "rollback completed"
+	}
+	if spec.Name != previous.Name {
+		writeError(w, http.StatusBadRequest, "rpc error: code = Unimplemented desc = renaming services is not supported")
+		return
+	}
+
+	now := time.Now()
+	svc.PreviousSpec = &previous
+	svc.Spec = spec
+	svc.Meta.Version = s.nextVersion()
+	svc.UpdatedAt = now
+	svc.UpdateStatus = &swarm.UpdateStatus{State: state, StartedAt: &now, CompletedAt: &now, Message: message}
+	s.emit(events.ServiceEventType, events.ActionUpdate, svc.ID, map[string]string{"name": spec.Name})
+	s.reconcileService(svc, !sameJSON(previous.TaskTemplate, spec.TaskTemplate))
+
+	writeJSON(w, http.StatusOK, swarm.ServiceUpdateResponse{})
+}
+
+func (s *Server) handleServiceRemove(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isManager() {
+		writeNotManager(w)
+		return
+	}
+	svc := s.findService(r.PathValue("id"))
+	if svc == nil {
+		writeError(w, http.StatusNotFound, "service %s not found", r.PathValue("id"))
+		return
+	}
+
+	delete(s.services, svc.ID)
+	for id, t := range s.tasks {
+		if t.ServiceID == svc.ID {
+			s.removeTask(id)
+		}
+	}
+	s.emit(events.ServiceEventType, events.ActionRemove, svc.ID, map[string]string{"name": svc.Spec.Name})
+	w.WriteHeader(http.StatusOK)
+}
+
+// findService looks a service up by ID, name or ID prefix. Callers must hold s.mu.
+func (s *Server) findService(ref string) *swarm.Service {
+	if svc, ok := s.services[ref]; ok {
+		return svc
+	}
+	for _, svc := range s.services {
+		if svc.Spec.Name == ref {
+			return svc
+		}
+	}
+	for id, svc := range s.services {
+		if ref != "" && strings.HasPrefix(id, ref) {
+			return svc
+		}
+	}
+	return nil
+}
+
+// serviceList returns copies of the services sorted by name. Callers must hold s.mu.
+func (s *Server) serviceList() []swarm.Service {
+	services := make([]swarm.Service, 0, len(s.services))
+	for _, svc := range s.services {
+		services = append(services, *svc)
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Spec.Name < services[j].Spec.Name })
+	return services
+}
+
+// Tasks
+
+func (s *Server) handleTaskList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isManager() {
+		writeNotManager(w)
+		return
+	}
+	args, ok := parseFilters(w, r)
+	if !ok {
+		return
+	}
+
+	serviceIDs := make(map[string]bool)
+	for _, ref := range args.Get("service") {
+		if svc := s.findService(ref); svc != nil {
+			serviceIDs[svc.ID] = true
+		}
+	}
+	nodeIDs := make(map[string]bool)
+	for _, ref := range args.Get("node") {
+		if n := s.findNode(ref); n != nil {
+			nodeIDs[n.ID] = true
+		}
+	}
+
+	tasks := []swarm.Task{}
+	for _, t := range s.sortedTasks() {
+		if args.Contains("service") && !serviceIDs[t.ServiceID] {
+			continue
+		}
+		if args.Contains("node") && !nodeIDs[t.NodeID] {
+			continue
+		}
+		if args.Contains("desired-state") && !args.ExactMatch("desired-state", string(t.DesiredState)) {
+			continue
+		}
+		if args.Contains("id") && !args.ExactMatch("id", t.ID) {
+			continue
+		}
+		tasks = append(tasks, *t)
+	}
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+func (s *Server) handleTaskInspect(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isManager() {
+		writeNotManager(w)
+		return
+	}
+	t, ok := s.tasks[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "task %s not found", r.PathValue("id"))
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+// sortedTasks returns the tasks sorted by creation. Callers must hold s.mu.
+func (s *Server) sortedTasks() []*swarm.Task {
+	tasks := make([]*swarm.Task, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		tasks = append(tasks, t)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Version.Index < tasks[j].Version.Index })
+	return tasks
+}
+
+// Scheduling
+
+// reconcileAll reconciles every service after a node change. Callers must hold s.mu.
+func (s *Server) reconcileAll() {
+	for _, svc := range s.serviceList() {
+		s.reconcileService(s.services[svc.ID], false)
+	}
+}
+
+// reconcileService converges a service's tasks on its spec. With replace set,
+// every running task is replaced by one running the current spec. Callers must hold s.mu.
+func (s *Server) reconcileService(svc *swarm.Service, replace bool) {
+	var live []*swarm.Task
+	for _, t := range s.sortedTasks() {
+		if t.ServiceID != svc.ID || t.DesiredState != swarm.TaskStateRunning {
+			continue
+		}
+		switch n := s.nodes[t.NodeID]; {
+		case n == nil || n.Status.State != swarm.NodeStateReady:
+			s.stopTask(t, swarm.TaskStateFailed, "node is down")
+		case n.Spec.Availability == swarm.NodeAvailabilityDrain, replace:
+			s.stopTask(t, swarm.TaskStateShutdown, "")
+		case t.Status.State == swarm.TaskStateRunning || t.Status.State == swarm.TaskStatePending:
+			live = append(live, t)
+		}
+	}
+
+	if svc.Spec.Mode.ReplicatedJob != nil {
+		s.runJob(svc)
+		return
+	}
+
+	if svc.Spec.Mode.Global != nil {
+		covered := make(map[string]bool)
+		for _, t := range live {
+			covered[t.NodeID] = true
+		}
+		for _, n := range s.eligibleNodes() {
+			if !covered[n.ID] {
+				s.startTask(svc, 0, n.ID)
+			}
+		}
+		return
+	}
+
+	replicas := 1
+	if svc.Spec.Mode.Replicated != nil && svc.Spec.Mode.Replicated.Replicas != nil {
+		replicas = int(*svc.Spec.Mode.Replicated.Replicas)
+	}
+
+	slots := make(map[int]bool)
+	for _, t := range live {
+		if t.Slot > replicas || slots[t.Slot] {
+			s.stopTask(t, swarm.TaskStateShutdown, "")
+			continue
+		}
+		slots[t.Slot] = true
+	}
+	for slot := 1; slot <= replicas; slot++ {
+		if !slots[slot] {
+			s.startTask(svc, slot, s.pickNode(svc.ID))
+		}
+	}
+}
+
+// runJob runs the single task of a replicated job service once; the task
+// finishes right away with the result set for its command. Callers must hold s.mu.
+func (s *Server) runJob(svc *swarm.Service) {
+	for _, t := range s.tasks {
+		if t.ServiceID == svc.ID {
+			return
+		}
+	}
+
+	now := time.Now()
+	t := &swarm.Task{
+		ID:           s.newID("task"),
+		Meta:         swarm.Meta{Version: s.nextVersion(), CreatedAt: now, UpdatedAt: now},
+		Spec:         svc.Spec.TaskTemplate,
+		ServiceID:    svc.ID,
+		Slot:         1,
+		NodeID:       s.pickNode(svc.ID),
+		DesiredState: swarm.TaskStateComplete,
+		Status:       swarm.TaskStatus{Timestamp: now},
+	}
+	s.tasks[t.ID] = t
+
+	spec := t.Spec.ContainerSpec
+	result := s.jobResults[strings.Join(spec.Command, " ")]
+	switch {
+	case t.NodeID == "":
+		t.Status.State = swarm.TaskStatePending
+		t.Status.Err = "no suitable node (scheduling constraints not satisfied on 0 nodes)"
+	case s.badImage(spec.Image) != "":
+		t.Status.State = swarm.TaskStateRejected
+		t.Status.Err = "No such image: " + spec.Image
+	case result.ExitCode != 0:
+		t.Status.State = swarm.TaskStateFailed
+		t.Status.Err = fmt.Sprintf("task: non-zero exit (%d)", result.ExitCode)
+		t.Status.ContainerStatus = &swarm.ContainerStatus{ContainerID: s.newID(""), ExitCode: result.ExitCode}
+	default:
+		t.Status.State = swarm.TaskStateComplete
+		t.Status.ContainerStatus = &swarm.ContainerStatus{ContainerID: s.newID("")}
+	}
+	s.logs[svc.ID] = append(s.logs[svc.ID], result.Lines...)
+}
+
+// eligibleNodes returns the nodes tasks can be scheduled on. Callers must hold s.mu.
+func (s *Server) eligibleNodes() []swarm.Node {
+	var nodes []swarm.Node
+	for _, n := range s.nodeList() {
+		if n.Status.State == swarm.NodeStateReady && n.Spec.Availability == swarm.NodeAvailabilityActive {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// pickNode returns the eligible node running the fewest tasks of a service,
+// or "" if there is none. Callers must hold s.mu.
+func (s *Server) pickNode(serviceID string) string {
+	best, bestCount := "", 0
+	for _, n := range s.eligibleNodes() {
+		count := 0
+		for _, t := range s.tasks {
+			if t.ServiceID == serviceID && t.NodeID == n.ID && t.DesiredState == swarm.TaskStateRunning {
+				count++
+			}
+		}
+		if best == "" || count < bestCount {
+			best, bestCount = n.ID, count
+		}
+	}
+	return best
+}
+
+// startTask creates a task for a slot of a service. Callers must hold s.mu.
+func (s *Server) startTask(svc *swarm.Service, slot int, nodeID string) {
+	now := time.Now()
+	t := &swarm.Task{
+		ID:           s.newID("task"),
+		Meta:         swarm.Meta{Version: s.nextVersion(), CreatedAt: now, UpdatedAt: now},
+		Spec:         svc.Spec.TaskTemplate,
+		ServiceID:    svc.ID,
+		Slot:         slot,
+		NodeID:       nodeID,
+		DesiredState: swarm.TaskStateRunning,
+		Status:       swarm.TaskStatus{Timestamp: now},
+	}
+	s.tasks[t.ID] = t
+
+	image := t.Spec.ContainerSpec.Image
+	switch {
+	case nodeID == "":
+		t.Status.State = swarm.TaskStatePending
+		t.Status.Err = "no suitable node (scheduling constraints not satisfied on 0 nodes)"
+		t.Status.Message = "pending task scheduling"
+	case s.badImage(image) != "":
+		t.Status.State = swarm.TaskStateRejected
+		t.Status.Err = "No such image: " + image
+		t.Status.Message = "preparing"
+		t.DesiredState = swarm.TaskStateShutdown
+	default:
+		t.Status.State = swarm.TaskStateRunning
+		t.Status.Message = "started"
+		containerID := s.newID("")
+		t.Status.ContainerStatus = &swarm.ContainerStatus{ContainerID: containerID, PID: 1000 + int(t.Version.Index)}
+		if nodeID == s.localNodeID {
+			s.createTaskContainer(svc, t, containerID)
+		}
+	}
+}
+
+// stopTask moves a task to a terminal state. Callers must hold s.mu.
+func (s *Server) stopTask(t *swarm.Task, state swarm.TaskState, errMsg string) {
+	t.DesiredState = swarm.TaskStateShutdown
+	t.Status.State = state
+	t.Status.Err = errMsg
+	t.Status.Timestamp = time.Now()
+	t.Meta.Version = s.nextVersion()
+	if t.Status.ContainerStatus != nil {
+		if c, ok := s.containers[t.Status.ContainerStatus.ContainerID]; ok && c.State.Running {
+			s.exitContainer(c, 0, false)
+		}
+	}
+}
+
+// removeTask deletes a task and its container. Callers must hold s.mu.
+func (s *Server) removeTask(id string) {
+	t := s.tasks[id]
+	delete(s.tasks, id)
+	if t != nil && t.Status.ContainerStatus != nil {
+		if c, ok := s.containers[t.Status.ContainerStatus.ContainerID]; ok {
+			s.destroyContainer(c)
+		}
+	}
+}
+
+// createTaskContainer creates the running container of a task on the local node. Callers must hold s.mu.
+func (s *Server) createTaskContainer(svc *swarm.Service, t *swarm.Task, containerID string) {
+	spec := t.Spec.ContainerSpec
+	labels := map[string]string{
+		"com.docker.swarm.service.id":   svc.ID,
+		"com.docker.swarm.service.name": svc.Spec.Name,
+		"com.docker.swarm.task.id":      t.ID,
+		"com.docker.swarm.task.name":    svc.Spec.Name + "." + strconv.Itoa(t.Slot) + "." + t.ID,
+		"com.docker.swarm.node.id":      t.NodeID,
+	}
+	for k, v := range spec.Labels {
+		labels[k] = v
+	}
+
+	cfg := &container.Config{Image: spec.Image, Env: spec.Env, Labels: labels, Cmd: spec.Args}
+	if hc := spec.Healthcheck; hc != nil {
+		cfg.Healthcheck = hc
+	}
+	c := s.newContainer(containerID, labels["com.docker.swarm.task.name"], cfg, &container.HostConfig{})
+	s.startContainer(c)
+}
+
+// Helpers
+
+// parseFilters parses the filters query parameter
+func parseFilters(w http.ResponseWriter, r *http.Request) (filters.Args, bool) {
+	args, err := filters.FromJSON(r.URL.Query().Get("filters"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid filters: %v", err)
+		return args, false
+	}
+	return args, true
+}
+
+// checkVersion rejects updates based on a stale object version
+func checkVersion(w http.ResponseWriter, r *http.Request, current swarm.Version) bool {
+	version, err := strconv.ParseUint(r.URL.Query().Get("version"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid version: %v", err)
+		return false
+	}
+	if version != current.Index {
+		writeError(w, http.StatusBadRequest, "rpc error: code = Unknown desc = update out of sequence")
+		return false
+	}
+	return true
+}
+
+// sameJSON reports whether two values have the same JSON encoding
+func sameJSON(a, b interface{}) bool {
+	aj, _ := json.Marshal(a)
+	bj, _ := json.Marshal(b)
+	return string(aj) == string(bj)
+}
@@ -11,12 +11,17 @@ func GetClient() *docker.Client {
 	if client != nil {
 		return client
 	}
-	client, err := docker.NewClientWithOpts(docker.FromEnv, docker.WithAPIVersionNegotiation())
+	cli, err := docker.NewClientWithOpts(docker.FromEnv, docker.WithAPIVersionNegotiation())
 	if err != nil {
 		// todo: handle error properly once i learn what the errors can be...
 		panic(err)
 		return nil
 	}
+	client = cli
 	return client
+}
 
+// SetClient replaces the shared client, e.g. to point it at a test server
+func SetClient(c *docker.Client) {
+	client = c
 }
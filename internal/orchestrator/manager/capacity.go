@@ -0,0 +1,281 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/jasonlovesdoggo/velo/internal/config"
+	"github.com/jasonlovesdoggo/velo/internal/log"
+	"github.com/jasonlovesdoggo/velo/pkg/core/node"
+)
+
+// CapacityPolicy controls what happens when a deploy doesn't fit on the cluster
+type CapacityPolicy string
+
+const (
+	// CapacityEnforce rejects deploys whose reservations don't fit
+	CapacityEnforce CapacityPolicy = "enforce"
+	// CapacityWarn allows the deploy but reports a warning
+	CapacityWarn CapacityPolicy = "warn"
+	// CapacityOff disables admission checks
+	CapacityOff CapacityPolicy = "off"
+)
+
+// ParseCapacityPolicy validates a capacity policy name
+func ParseCapacityPolicy(name string) (CapacityPolicy, error) {
+	switch policy := CapacityPolicy(name); policy {
+	case CapacityEnforce, CapacityWarn, CapacityOff:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown capacity policy %q (expected enforce, warn or off)", name)
+	}
+}
+
+// NodeCapacity describes the reserved resources and headroom of a node
+type NodeCapacity struct {
+	NodeID         string
+	Hostname       string
+	Availability   string
+	Eligible       bool // whether the node can receive new tasks
+	NanoCPUs       int64
+	MemoryBytes    int64
+	ReservedCPUs   int64
+	ReservedMemory int64
+	Tasks          int
+}
+
+// FreeCPUs returns the unreserved CPU of the node in nano CPUs
+func (c NodeCapacity) FreeCPUs() int64 {
+	return c.NanoCPUs - c.ReservedCPUs
+}
+
+// FreeMemory returns the unreserved memory of the node in bytes
+func (c NodeCapacity) FreeMemory() int64 {
+	return c.MemoryBytes - c.ReservedMemory
+}
+
+// CapacityCheck is the result of checking a deploy against the cluster's capacity
+type CapacityCheck struct {
+	Fits     bool
+	Message  string
+	Headroom []NodeCapacity
+}
+
+// CapacityManager defines capacity reporting and admission checks
+type CapacityManager interface {
+	// CapacityReport returns the reserved resources and headroom of every node
+	CapacityReport(ctx context.Context) ([]NodeCapacity, error)
+
+	// CheckCapacity reports whether a service's reservations fit on its eligible nodes
+	CheckCapacity(ctx context.Context, def config.ServiceDefinition) (CapacityCheck, error)
+}
+
+// SetCapacityPolicy sets how deploys that don't fit on the cluster are handled
+func (m *SwarmManager) SetCapacityPolicy(policy CapacityPolicy) {
+	m.capacityPolicy = policy
+}
+
+// CapacityReport returns the reserved resources and headroom of every node
+func (m *SwarmManager) CapacityReport(ctx context.Context) ([]NodeCapacity, error) {
+	tasks, err := m.runningTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NodeCapacities(m.GetNodes(), tasks, ""), nil
+}
+
+// CheckCapacity reports whether a service's reservations fit on its eligible nodes.
+// Tasks of an existing service with the same name are not counted as reserved, so
+// the check covers both new deploys and updates that scale up or grow reservations.
+func (m *SwarmManager) CheckCapacity(ctx context.Context, def config.ServiceDefinition) (CapacityCheck, error) {
+	tasks, err := m.runningTasks(ctx)
+	if err != nil {
+		return CapacityCheck{}, err
+	}
+
+	excludeServiceID := ""
+	if service, _, err := m.client.ServiceInspectWithRaw(ctx, def.Name, types.ServiceInspectOptions{}); err == nil {
+		excludeServiceID = service.ID
+	}
+
+	return SimulatePlacement(def, m.GetNodes(), tasks, excludeServiceID)
+}
+
+type warningsKey struct{}
+
+// WithWarnings returns a copy of ctx under which deploys append the capacity
+// shortfalls the capacity policy lets through to warnings, so callers learn of
+// them without checking the capacity a second time
+func WithWarnings(ctx context.Context, warnings *[]string) context.Context {
+	return context.WithValue(ctx, warningsKey{}, warnings)
+}
+
+// AddWarning reports a warning to the caller of a deploy made under ctx, if
+// they collect warnings with WithWarnings
+func AddWarning(ctx context.Context, warning string) {
+	if warnings, ok := ctx.Value(warningsKey{}).(*[]string); ok && warnings != nil {
+		*warnings = append(*warnings, warning)
+	}
+}
+
+// admit applies the capacity policy to a deploy
+func (m *SwarmManager) admit(ctx context.Context, def config.ServiceDefinition) error {
+	if m.capacityPolicy == CapacityOff {
+		return nil
+	}
+
+	check, err := m.CheckCapacity(ctx, def)
+	if err != nil {
+		return err
+	}
+	if check.Fits {
+		return nil
+	}
+	if m.capacityPolicy == CapacityWarn {
+		log.Warn("Service reservations exceed cluster capacity", "service", def.Name, "details", check.Message)
+		AddWarning(ctx, check.Message)
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrInsufficientCapacity, check.Message)
+}
+
+// runningTasks lists all tasks that are meant to be running
+func (m *SwarmManager) runningTasks(ctx context.Context) ([]swarm.Task, error) {
+	tasks, err := m.client.TaskList(ctx, types.TaskListOptions{
+		Filters: filters.NewArgs(filters.Arg("desired-state", string(swarm.TaskStateRunning))),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	return tasks, nil
+}
+
+// NodeCapacities sums task reservations per node, ignoring tasks of excludeServiceID
+func NodeCapacities(nodes []node.Info, tasks []swarm.Task, excludeServiceID string) []NodeCapacity {
+	byID := make(map[string]*NodeCapacity, len(nodes))
+	result := make([]NodeCapacity, len(nodes))
+	for i, n := range nodes {
+		result[i] = NodeCapacity{
+			NodeID:       n.ID,
+			Hostname:     n.Hostname,
+			Availability: n.Availability,
+			Eligible:     n.Availability == string(swarm.NodeAvailabilityActive) && nodeReady(n),
+			NanoCPUs:     int64(n.Capacity.CPU) * 1e9,
+			MemoryBytes:  n.Capacity.Memory,
+		}
+		byID[n.ID] = &result[i]
+	}
+
+	for _, task := range tasks {
+		if task.DesiredState != swarm.TaskStateRunning || task.ServiceID == excludeServiceID {
+			continue
+		}
+		if c, ok := byID[task.NodeID]; ok {
+			cpu, memory := taskReservation(task)
+			c.ReservedCPUs += cpu
+			c.ReservedMemory += memory
+			c.Tasks++
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Hostname < result[j].Hostname })
+	return result
+}
+
+// SimulatePlacement simulates placing every replica of a service on its eligible nodes
+func SimulatePlacement(def config.ServiceDefinition, nodes []node.Info, tasks []swarm.Task, excludeServiceID string) (CapacityCheck, error) {
+	eligible, err := EligibleNodes(def, nodes)
+	if err != nil {
+		return CapacityCheck{}, err
+	}
+
+	capacities := NodeCapacities(eligible, tasks, excludeServiceID)
+	check := CapacityCheck{Fits: true, Headroom: capacities}
+
+	cpu := int64(def.Resources.CPUReserve * 1e9)
+	memory := def.Resources.MemoryReserve
+	if cpu == 0 && memory == 0 {
+		return check, nil
+	}
+
+	free := make([]NodeCapacity, 0, len(capacities))
+	for _, c := range capacities {
+		if c.Eligible {
+			free = append(free, c)
+		}
+	}
+	placed := make([]uint64, len(free))
+
+	for replica := 0; replica < def.Replicas; replica++ {
+		best := -1
+		for i, c := range free {
+			if c.FreeCPUs() < cpu || c.FreeMemory() < memory {
+				continue
+			}
+			if max := def.Placement.MaxReplicasPerNode; max > 0 && placed[i] >= max {
+				continue
+			}
+			if best == -1 || c.FreeMemory() > free[best].FreeMemory() {
+				best = i
+			}
+		}
+		if best == -1 {
+			check.Fits = false
+			check.Message = fmt.Sprintf("only %d of %d replica(s) of %s fit (each reserves %s); node headroom: %s",
+				replica, def.Replicas, def.Name, formatResources(cpu, memory), describeHeadroom(capacities))
+			return check, nil
+		}
+		free[best].ReservedCPUs += cpu
+		free[best].ReservedMemory += memory
+		placed[best]++
+	}
+
+	return check, nil
+}
+
+// describeHeadroom formats the free resources of each node for error messages
+func describeHeadroom(capacities []NodeCapacity) string {
+	if len(capacities) == 0 {
+		return "no eligible nodes"
+	}
+	parts := make([]string, 0, len(capacities))
+	for _, c := range capacities {
+		part := fmt.Sprintf("%s %s free", c.Hostname, formatResources(c.FreeCPUs(), c.FreeMemory()))
+		if !c.Eligible {
+			part += " (" + c.Availability + ")"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatResources(nanoCPUs, memory int64) string {
+	return fmt.Sprintf("%.2f CPU / %d MiB", float64(nanoCPUs)/1e9, memory/(1<<20))
+}
+
+// buildResources maps a service's resource config to swarm resource requirements
+func buildResources(res config.ResourceConfig) *swarm.ResourceRequirements {
+	if res == (config.ResourceConfig{}) {
+		return nil
+	}
+
+	requirements := &swarm.ResourceRequirements{}
+	if res.CPULimit > 0 || res.MemoryLimit > 0 {
+		requirements.Limits = &swarm.Limit{
+			NanoCPUs:    int64(res.CPULimit * 1e9),
+			MemoryBytes: res.MemoryLimit,
+		}
+	}
+	if res.CPUReserve > 0 || res.MemoryReserve > 0 {
+		requirements.Reservations = &swarm.Resources{
+			NanoCPUs:    int64(res.CPUReserve * 1e9),
+			MemoryBytes: res.MemoryReserve,
+		}
+	}
+	return requirements
+}
@@ -0,0 +1,120 @@
+package manager
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/jasonlovesdoggo/velo/internal/config"
+	"github.com/jasonlovesdoggo/velo/pkg/core/node"
+)
+
+func TestSimulatePlacement(t *testing.T) {
+	const gib = int64(1 << 30)
+
+	nodes := []node.Info{
+		testNode("a", 2, 4*gib, swarm.NodeAvailabilityActive),
+		testNode("b", 2, 4*gib, swarm.NodeAvailabilityActive),
+		testNode("c", 8, 32*gib, swarm.NodeAvailabilityDrain),
+	}
+	tasks := []swarm.Task{
+		testTask("t1", "a", 1e9, 3*gib),
+		testTask("t2", "b", 5e8, 1*gib),
+	}
+
+	tests := []struct {
+		name        string
+		def         config.ServiceDefinition
+		exclude     string
+		wantFits    bool
+		msgContains string
+	}{
+		{
+			name:     "No reservations always fit",
+			def:      config.ServiceDefinition{Name: "web", Replicas: 10},
+			wantFits: true,
+		},
+		{
+			name: "Fits in remaining headroom",
+			def: config.ServiceDefinition{Name: "web", Replicas: 3,
+				Resources: config.ResourceConfig{CPUReserve: 0.25, MemoryReserve: gib / 2}},
+			wantFits: true,
+		},
+		{
+			name: "Overcommit is reported with per-node headroom",
+			def: config.ServiceDefinition{Name: "web", Replicas: 2,
+				Resources: config.ResourceConfig{MemoryReserve: 2 * gib}},
+			wantFits:    false,
+			msgContains: "only 1 of 2 replica(s) of web fit",
+		},
+		{
+			name: "Service's own tasks are not counted",
+			def: config.ServiceDefinition{Name: "web", Replicas: 1,
+				Resources: config.ResourceConfig{CPUReserve: 1, MemoryReserve: 3 * gib}},
+			exclude:  "svc-t1",
+			wantFits: true,
+		},
+		{
+			name: "Max replicas per node limits packing",
+			def: config.ServiceDefinition{Name: "web", Replicas: 2,
+				Resources:   config.ResourceConfig{MemoryReserve: gib / 4},
+				Placement:   config.PlacementConfig{MaxReplicasPerNode: 1},
+				Constraints: []string{"node.hostname==b"}},
+			wantFits: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check, err := SimulatePlacement(tt.def, nodes, tasks, tt.exclude)
+			if err != nil && tt.wantFits {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if err != nil {
+				// Placement errors also mean the deploy can't fit
+				return
+			}
+			if check.Fits != tt.wantFits {
+				t.Fatalf("Expected fits=%v, got %v (%s)", tt.wantFits, check.Fits, check.Message)
+			}
+			if tt.msgContains != "" && !strings.Contains(check.Message, tt.msgContains) {
+				t.Errorf("Expected message to contain %q, got %q", tt.msgContains, check.Message)
+			}
+		})
+	}
+}
+
+func TestNodeCapacities(t *testing.T) {
+	nodes := []node.Info{
+		testNode("b", 4, 8<<30, swarm.NodeAvailabilityDrain),
+		testNode("a", 2, 4<<30, swarm.NodeAvailabilityActive),
+	}
+	tasks := []swarm.Task{
+		testTask("t1", "a", 1e9, 1<<30),
+		testTask("t2", "a", 5e8, 0),
+	}
+
+	capacities := NodeCapacities(nodes, tasks, "")
+	if len(capacities) != 2 || capacities[0].NodeID != "a" {
+		t.Fatalf("Expected capacities sorted by hostname, got %+v", capacities)
+	}
+
+	a := capacities[0]
+	if a.Tasks != 2 || a.FreeCPUs() != 5e8 || a.FreeMemory() != 3<<30 || !a.Eligible {
+		t.Errorf("Unexpected capacity for node a: %+v", a)
+	}
+	if capacities[1].Eligible {
+		t.Errorf("Expected drained node to be ineligible")
+	}
+}
+
+func TestParseCapacityPolicy(t *testing.T) {
+	for _, name := range []string{"enforce", "warn", "off"} {
+		if _, err := ParseCapacityPolicy(name); err != nil {
+			t.Errorf("ParseCapacityPolicy(%q) failed: %v", name, err)
+		}
+	}
+	if _, err := ParseCapacityPolicy("strict"); err == nil {
+		t.Error("Expected an error for unknown policy")
+	}
+}
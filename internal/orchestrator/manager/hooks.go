@@ -0,0 +1,222 @@
+package manager
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/config"
+	"github.com/jasonlovesdoggo/velo/internal/log"
+)
+
+// Deploy hook phases
+const (
+	HookPreDeploy  = "pre_deploy"
+	HookPostDeploy = "post_deploy"
+)
+
+// Labels of the jobs that run deploy hooks
+const (
+	HookLabel        = "velo.hook"
+	HookServiceLabel = "velo.hook.service"
+)
+
+// rolloutPollInterval is how often a rollout is checked before post-deploy hooks run
+var rolloutPollInterval = 500 * time.Millisecond
+
+// ErrHooksUnsupported is returned when deploying a service with hooks to a backend that can't run jobs
+var ErrHooksUnsupported = errors.New("deploy hooks are not supported by this backend")
+
+// Job is a one-off container run to completion, e.g. a deploy hook
+type Job struct {
+	Name        string
+	Image       string
+	Command     []string
+	Env         map[string]string
+	Labels      map[string]string
+	Networks    []string
+	Volumes     []config.VolumeMount
+	Constraints []string
+}
+
+// JobRunner is implemented by backends that run one-off jobs
+type JobRunner interface {
+	// RunJob runs a job to completion, writing its output to output, and
+	// returns its exit code. The job is removed afterwards.
+	RunJob(ctx context.Context, job Job, output io.Writer) (int, error)
+}
+
+// HookError is returned when a deploy hook exits non-zero
+type HookError struct {
+	Phase      string
+	ExitCode   int
+	RolledBack bool // the deploy was rolled back after a failed post-deploy hook
+}
+
+func (e *HookError) Error() string {
+	msg := fmt.Sprintf("%s hook exited with code %d", e.Phase, e.ExitCode)
+	if e.RolledBack {
+		msg += "; the deploy was rolled back"
+	}
+	return msg
+}
+
+// DeployWithHooks deploys a service like Deploy, running its pre-deploy hook
+// before and its post-deploy hook once the rollout is healthy. The hooks'
+// output is written to output. A failing pre-deploy hook aborts the deploy; a
+// failing post-deploy hook rolls it back if the service asks for it.
+func DeployWithHooks(ctx context.Context, m Manager, def config.ServiceDefinition, output io.Writer) (string, error) {
+	if !def.HasHooks() {
+		return Deploy(ctx, m, def)
+	}
+	jr, ok := m.(JobRunner)
+	if !ok {
+		return "", ErrHooksUnsupported
+	}
+	if output == nil {
+		output = io.Discard
+	}
+	// Job output may still be arriving when the next hook starts
+	output = &syncWriter{w: output}
+	timeout := time.Duration(def.Hooks.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = config.DefaultHookTimeout * time.Second
+	}
+
+	if len(def.PreDeploy) > 0 {
+		if err := runHook(ctx, jr, def, HookPreDeploy, def.PreDeploy, timeout, output); err != nil {
+			return "", err
+		}
+	}
+
+	id, err := Deploy(ctx, m, def)
+	if err != nil || len(def.PostDeploy) == 0 {
+		return id, err
+	}
+
+	err = waitRollout(ctx, m, id, timeout)
+	if err == nil {
+		err = runHook(ctx, jr, def, HookPostDeploy, def.PostDeploy, timeout, output)
+	}
+	if err == nil {
+		return id, nil
+	}
+	if !def.Hooks.RollbackOnFailure {
+		return id, err
+	}
+
+	log.Warn("Post-deploy hook failed, rolling back", "service", def.Name, "error", err)
+	if rbErr := rollbackDeploy(m, id); rbErr != nil {
+		return id, fmt.Errorf("%w; rolling back failed: %v", err, rbErr)
+	}
+	if hookErr, ok := err.(*HookError); ok {
+		hookErr.RolledBack = true
+		return "", hookErr
+	}
+	return "", fmt.Errorf("%w; the deploy was rolled back", err)
+}
+
+// runHook runs one hook of a service as a job with the service's image,
+// environment, networks and volumes
+func runHook(ctx context.Context, jr JobRunner, def config.ServiceDefinition, phase string, command []string, timeout time.Duration, output io.Writer) error {
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Errorf("failed to generate job name: %w", err)
+	}
+	env := maps.Clone(def.Environment)
+	if env == nil {
+		env = make(map[string]string)
+	}
+	env["VELO_HOOK"] = phase
+	env["VELO_SERVICE"] = def.Name
+
+	job := Job{
+		Name:        fmt.Sprintf("%s-%s-%s", def.Name, phase, hex.EncodeToString(suffix)),
+		Image:       def.Image,
+		Command:     command,
+		Env:         env,
+		Labels:      map[string]string{HookLabel: phase, HookServiceLabel: def.Name},
+		Networks:    def.Networks,
+		Volumes:     def.Volumes,
+		Constraints: def.Constraints,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	log.Info("Running deploy hook", "service", def.Name, "hook", phase, "command", command)
+	fmt.Fprintf(output, "==> Running %s hook: %s\n", phase, strings.Join(command, " "))
+	start := time.Now()
+	code, err := jr.RunJob(ctx, job, output)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%s hook did not finish within %s", phase, timeout)
+		}
+		return fmt.Errorf("%s hook failed: %w", phase, err)
+	}
+	if code != 0 {
+		log.Warn("Deploy hook failed", "service", def.Name, "hook", phase, "exitCode", code, "duration", time.Since(start))
+		return &HookError{Phase: phase, ExitCode: code}
+	}
+
+	log.Info("Deploy hook succeeded", "service", def.Name, "hook", phase, "duration", time.Since(start))
+	return nil
+}
+
+// syncWriter serializes writes to a writer
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// waitRollout waits until every replica of a new deployment is running
+func waitRollout(ctx context.Context, m Manager, id string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(rolloutPollInterval)
+	defer ticker.Stop()
+
+	for {
+		status, err := m.GetServiceStatus(id)
+		if err == nil {
+			if status.State == "failed" {
+				return errors.New("rollout failed: no replica is running")
+			}
+			if status.Running >= status.Service.Replicas {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("rollout did not become healthy within %s", timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// rollbackDeploy undoes a deploy: restores the service's previous version when
+// the backend keeps one, otherwise removes the service
+func rollbackDeploy(m Manager, id string) error {
+	if rm, ok := m.(RollbackManager); ok {
+		err := rm.RollbackService(id)
+		if !errors.Is(err, ErrNoPreviousVersion) {
+			return err
+		}
+	}
+	return m.RemoveService(id)
+}
@@ -0,0 +1,81 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jasonlovesdoggo/velo/internal/config"
+)
+
+func TestDeployWithHooks(t *testing.T) {
+	migrate := []string{"./manage.py", "migrate"}
+	warm := []string{"./manage.py", "warm_cache"}
+
+	tests := []struct {
+		name        string
+		hooks       config.HookConfig
+		preExit     int
+		postExit    int
+		wantErr     string
+		wantService bool
+		wantOutput  string
+	}{
+		{name: "hooks succeed", wantService: true, wantOutput: "==> Running pre_deploy hook: ./manage.py migrate\nApplying migrations... OK\n" +
+			"==> Running post_deploy hook: ./manage.py warm_cache\nCache warmed\n"},
+		{name: "pre-deploy fails", preExit: 3, wantErr: "pre_deploy hook exited with code 3", wantOutput: "==> Running pre_deploy hook: ./manage.py migrate\nApplying migrations... OK\n"},
+		{name: "post-deploy fails", postExit: 1, wantErr: "post_deploy hook exited with code 1", wantService: true},
+		{name: "post-deploy fails and rolls back", hooks: config.HookConfig{RollbackOnFailure: true}, postExit: 1,
+			wantErr: "post_deploy hook exited with code 1; the deploy was rolled back"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, m := newTestSwarm(t)
+			srv.SetJobResult(migrate, tt.preExit, "Applying migrations... OK")
+			srv.SetJobResult(warm, tt.postExit, "Cache warmed")
+
+			var output strings.Builder
+			_, err := DeployWithHooks(context.Background(), m, config.ServiceDefinition{
+				Name: "web", Image: "web:2", Replicas: 2, PreDeploy: migrate, PostDeploy: warm, Hooks: tt.hooks,
+			}, &output)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("DeployWithHooks failed: %v", err)
+			}
+			if tt.wantErr != "" {
+				var hookErr *HookError
+				if err == nil || err.Error() != tt.wantErr || !errors.As(err, &hookErr) {
+					t.Fatalf("Expected %q, got %v", tt.wantErr, err)
+				}
+			}
+			if tt.wantOutput != "" && output.String() != tt.wantOutput {
+				t.Errorf("Expected output %q, got %q", tt.wantOutput, output.String())
+			}
+
+			// Hook jobs are removed once they finish
+			services := srv.Services()
+			if tt.wantService != (len(services) == 1) {
+				t.Fatalf("Expected service deployed %v, got %d services", tt.wantService, len(services))
+			}
+			if tt.wantService && services[0].Spec.Name != "web" {
+				t.Errorf("Expected only the web service, got %s", services[0].Spec.Name)
+			}
+		})
+	}
+}
+
+func TestDeployWithoutHooks(t *testing.T) {
+	srv, m := newTestSwarm(t)
+	if _, err := DeployWithHooks(context.Background(), m, config.ServiceDefinition{Name: "web", Image: "web:1", Replicas: 1}, nil); err != nil {
+		t.Fatalf("DeployWithHooks failed: %v", err)
+	}
+	creates := 0
+	for _, call := range srv.Calls() {
+		if call == "POST /services/create" {
+			creates++
+		}
+	}
+	if creates != 1 {
+		t.Errorf("Expected only the service to be created, got %d creates", creates)
+	}
+}
@@ -1,7 +1,11 @@
 package manager
 
 import (
+	"context"
+	"errors"
+
 	"github.com/jasonlovesdoggo/velo/internal/config"
+	"github.com/jasonlovesdoggo/velo/pkg/core/node"
 )
 
 // Manager defines the interface for orchestration managers
@@ -15,3 +19,114 @@ type Manager interface {
 	// GetServiceStatus returns the status of a service
 	GetServiceStatus(serviceID string) (config.DeploymentStatus, error)
 }
+
+// ContextDeployer is implemented by managers that deploy under the caller's
+// context, so that the orchestrator calls of a deploy join the caller's trace
+// and its warnings reach the caller
+type ContextDeployer interface {
+	// DeployServiceContext deploys a service like DeployService
+	DeployServiceContext(ctx context.Context, def config.ServiceDefinition) (string, error)
+}
+
+// Deploy deploys a service under ctx if the manager supports it
+func Deploy(ctx context.Context, m Manager, def config.ServiceDefinition) (string, error) {
+	if cd, ok := m.(ContextDeployer); ok {
+		return cd.DeployServiceContext(ctx, def)
+	}
+	return m.DeployService(def)
+}
+
+// Backend is a Manager with background operations, as run by the velo daemon
+type Backend interface {
+	Manager
+
+	// Start begins the backend's background operations
+	Start() error
+
+	// Stop stops the backend's background operations
+	Stop()
+}
+
+// ServiceManager defines service lifecycle operations beyond deploy and remove
+type ServiceManager interface {
+	// UpdateService updates the image, environment and settings of a service
+	UpdateService(serviceID string, def config.ServiceDefinition) error
+
+	// ScaleService changes the number of replicas of a service
+	ScaleService(serviceID string, replicas int) error
+
+	// ListServices returns the status of all services
+	ListServices() ([]config.DeploymentStatus, error)
+}
+
+// ErrNoPreviousVersion is returned when rolling back a service that was never updated
+var ErrNoPreviousVersion = errors.New("service has no previous version")
+
+// RollbackManager is implemented by managers that can roll a service back to its previous version
+type RollbackManager interface {
+	// RollbackService restores the service's previous definition
+	RollbackService(serviceID string) error
+}
+
+// Reconciler is implemented by backends that change services on their own to
+// bring them back in line with their definitions
+type Reconciler interface {
+	// OnReconcile sets a function called with every such change. It must be
+	// called before the backend starts.
+	OnReconcile(fn func(ReconcileAction))
+}
+
+// ReconcileAction is a change a backend made to a service on its own
+type ReconcileAction struct {
+	Action  string // e.g. RecreateReplica
+	Service string
+	Params  map[string]string
+}
+
+// HealthChecker is implemented by backends that can check their connection to the orchestrator
+type HealthChecker interface {
+	// HealthCheck returns an error if the orchestrator can't be reached or isn't usable
+	HealthCheck(ctx context.Context) error
+}
+
+// NodeManager defines node maintenance operations for managers that run on a cluster
+type NodeManager interface {
+	// GetNodes returns all nodes in the cluster
+	GetNodes() []node.Info
+
+	// DrainNodeAndWait drains a node and waits until its tasks are running elsewhere
+	DrainNodeAndWait(ctx context.Context, nodeID string, opts DrainOptions) (DrainReport, error)
+
+	// ActivateNode makes a node available for scheduling again
+	ActivateNode(nodeID string) error
+
+	// RebalanceServices spreads service tasks back out over all active nodes
+	RebalanceServices(ctx context.Context) ([]string, error)
+}
+
+// JoinManager is implemented by backends that new nodes can join
+type JoinManager interface {
+	// GetJoinToken returns the token a worker or manager node joins the cluster with
+	GetJoinToken(isManager bool) (string, error)
+
+	// ManagerAddresses returns the addresses of the managers nodes join through
+	ManagerAddresses() ([]string, error)
+}
+
+// Ensure the backends implement the optional interfaces they support
+var (
+	_ Backend         = (*SwarmManager)(nil)
+	_ ServiceManager  = (*SwarmManager)(nil)
+	_ RollbackManager = (*SwarmManager)(nil)
+	_ NodeManager     = (*SwarmManager)(nil)
+	_ CapacityManager = (*SwarmManager)(nil)
+	_ HealthChecker   = (*SwarmManager)(nil)
+	_ JoinManager     = (*SwarmManager)(nil)
+	_ JobRunner       = (*SwarmManager)(nil)
+
+	_ Backend        = (*StandaloneManager)(nil)
+	_ ServiceManager = (*StandaloneManager)(nil)
+	_ HealthChecker  = (*StandaloneManager)(nil)
+	_ JobRunner      = (*StandaloneManager)(nil)
+	_ Reconciler     = (*StandaloneManager)(nil)
+)
@@ -0,0 +1,179 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/jasonlovesdoggo/velo/internal/log"
+	"github.com/jasonlovesdoggo/velo/internal/utils"
+)
+
+// logDrainTimeout is how long a finished job's remaining output is waited for
+const logDrainTimeout = 2 * time.Second
+
+// jobEnv converts a job's environment to KEY=VALUE format
+func jobEnv(job Job) []string {
+	env := make([]string, 0, len(job.Env))
+	for key, value := range job.Env {
+		env = append(env, key+"="+value)
+	}
+	return env
+}
+
+// RunJob runs a job as a swarm replicated job with a single task
+func (m *SwarmManager) RunJob(ctx context.Context, job Job, output io.Writer) (int, error) {
+	spec := swarm.ServiceSpec{
+		Annotations: swarm.Annotations{
+			Name:   job.Name,
+			Labels: job.Labels,
+		},
+		TaskTemplate: swarm.TaskSpec{
+			ContainerSpec: &swarm.ContainerSpec{
+				Image:   job.Image,
+				Command: job.Command,
+				Env:     jobEnv(job),
+				Labels:  job.Labels,
+			},
+			Placement:     &swarm.Placement{Constraints: job.Constraints},
+			RestartPolicy: &swarm.RestartPolicy{Condition: swarm.RestartPolicyConditionNone},
+		},
+		Mode: swarm.ServiceMode{
+			ReplicatedJob: &swarm.ReplicatedJob{
+				MaxConcurrent:    utils.Uint64Ptr(1),
+				TotalCompletions: utils.Uint64Ptr(1),
+			},
+		},
+	}
+	for _, network := range job.Networks {
+		spec.TaskTemplate.Networks = append(spec.TaskTemplate.Networks, swarm.NetworkAttachmentConfig{Target: network})
+	}
+
+	resp, err := m.client.ServiceCreate(ctx, spec, types.ServiceCreateOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create job: %w", err)
+	}
+	defer func() {
+		if err := m.client.ServiceRemove(context.WithoutCancel(ctx), resp.ID); err != nil {
+			log.Warn("Failed to remove job", "job", job.Name, "error", err)
+		}
+	}()
+
+	// Follow the job's output until its task finishes
+	logCtx, stopLogs := context.WithCancel(ctx)
+	defer stopLogs()
+	logsDone := make(chan struct{})
+	go func() {
+		defer close(logsDone)
+		logs, err := m.client.ServiceLogs(logCtx, resp.ID, container.LogsOptions{ShowStdout: true, ShowStderr: true, Follow: true})
+		if err != nil {
+			log.Warn("Failed to follow job logs", "job", job.Name, "error", err)
+			return
+		}
+		defer logs.Close()
+		stdcopy.StdCopy(output, output, logs)
+	}()
+
+	code, err := m.waitJob(ctx, resp.ID)
+	select {
+	case <-logsDone:
+	case <-time.After(logDrainTimeout):
+	}
+	return code, err
+}
+
+// waitJob waits for the task of a job to finish and returns its exit code
+func (m *SwarmManager) waitJob(ctx context.Context, serviceID string) (int, error) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		tasks, err := m.client.TaskList(ctx, types.TaskListOptions{Filters: filters.NewArgs(filters.Arg("service", serviceID))})
+		if err == nil {
+			for _, task := range tasks {
+				switch task.Status.State {
+				case swarm.TaskStateComplete:
+					return 0, nil
+				case swarm.TaskStateFailed:
+					if task.Status.ContainerStatus != nil && task.Status.ContainerStatus.ExitCode != 0 {
+						return task.Status.ContainerStatus.ExitCode, nil
+					}
+					return 0, fmt.Errorf("job task failed: %s", task.Status.Err)
+				case swarm.TaskStateRejected:
+					return 0, fmt.Errorf("job task was rejected: %s", task.Status.Err)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunJob runs a job as a container on the host
+func (m *StandaloneManager) RunJob(ctx context.Context, job Job, output io.Writer) (int, error) {
+	if err := m.pullImage(ctx, job.Image); err != nil {
+		return 0, err
+	}
+
+	cfg := &container.Config{
+		Image:  job.Image,
+		Cmd:    job.Command,
+		Env:    jobEnv(job),
+		Labels: job.Labels,
+	}
+	hostCfg := &container.HostConfig{Mounts: buildMounts(job.Volumes)}
+	var netCfg *network.NetworkingConfig
+	if len(job.Networks) > 0 {
+		netCfg = &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{job.Networks[0]: {}}}
+	}
+
+	resp, err := m.client.ContainerCreate(ctx, cfg, hostCfg, netCfg, nil, job.Name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create job container: %w", err)
+	}
+	defer func() {
+		if err := m.client.ContainerRemove(context.WithoutCancel(ctx), resp.ID, container.RemoveOptions{Force: true}); err != nil {
+			log.Warn("Failed to remove job container", "job", job.Name, "error", err)
+		}
+	}()
+	for _, net := range job.Networks[min(1, len(job.Networks)):] {
+		if err := m.client.NetworkConnect(ctx, net, resp.ID, nil); err != nil {
+			return 0, fmt.Errorf("failed to connect job container to network %s: %w", net, err)
+		}
+	}
+
+	// Wait before starting so a fast exit isn't missed
+	waitCh, errCh := m.client.ContainerWait(ctx, resp.ID, container.WaitConditionNextExit)
+	if err := m.client.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return 0, fmt.Errorf("failed to start job container: %w", err)
+	}
+
+	logs, err := m.client.ContainerLogs(ctx, resp.ID, container.LogsOptions{ShowStdout: true, ShowStderr: true, Follow: true})
+	if err != nil {
+		log.Warn("Failed to follow job logs", "job", job.Name, "error", err)
+	} else {
+		defer logs.Close()
+		stdcopy.StdCopy(output, output, logs)
+	}
+
+	select {
+	case result := <-waitCh:
+		if result.Error != nil {
+			return 0, fmt.Errorf("failed to wait for job container: %s", result.Error.Message)
+		}
+		return int(result.StatusCode), nil
+	case err := <-errCh:
+		return 0, fmt.Errorf("failed to wait for job container: %w", err)
+	}
+}
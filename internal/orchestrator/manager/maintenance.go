@@ -0,0 +1,314 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/jasonlovesdoggo/velo/internal/log"
+	"github.com/jasonlovesdoggo/velo/pkg/core/node"
+)
+
+// Drain phases reported through DrainProgress
+const (
+	DrainPhaseChecking = "checking"
+	DrainPhaseDraining = "draining"
+	DrainPhaseWaiting  = "waiting"
+	DrainPhaseComplete = "complete"
+)
+
+// ErrInsufficientCapacity is returned when tasks' reservations don't fit on the available nodes
+var ErrInsufficientCapacity = errors.New("insufficient node capacity")
+
+// DrainOptions controls how a node is drained
+type DrainOptions struct {
+	// Timeout bounds how long to wait for tasks to be rescheduled. Zero means no limit.
+	Timeout time.Duration
+	// Force drains the node even if the capacity check fails
+	Force bool
+	// PollInterval is how often task state is checked while waiting
+	PollInterval time.Duration
+	// Progress is called whenever the drain makes progress
+	Progress func(DrainProgress)
+}
+
+// DrainProgress describes the current state of a drain operation
+type DrainProgress struct {
+	NodeID    string
+	Phase     string
+	Total     int // tasks that were running on the node when the drain started
+	Remaining int // tasks still scheduled on the node
+	Pending   int // replicas not yet running elsewhere
+	Message   string
+	Warnings  []string
+}
+
+// DrainReport summarizes a completed drain
+type DrainReport struct {
+	NodeID   string
+	Moved    int
+	Services []string
+	Duration time.Duration
+	Warnings []string
+}
+
+// DrainNodeAndWait checks capacity, drains a node and waits until every task
+// that was running on it is running on another node
+func (m *SwarmManager) DrainNodeAndWait(ctx context.Context, nodeRef string, opts DrainOptions) (DrainReport, error) {
+	start := time.Now()
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 2 * time.Second
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	progress := func(p DrainProgress) {
+		if opts.Progress != nil {
+			opts.Progress(p)
+		}
+	}
+
+	swarmNode, _, err := m.client.NodeInspectWithRaw(ctx, nodeRef)
+	if err != nil {
+		return DrainReport{}, fmt.Errorf("failed to inspect node: %w", err)
+	}
+	nodeID := swarmNode.ID
+	report := DrainReport{NodeID: nodeID}
+
+	progress(DrainProgress{NodeID: nodeID, Phase: DrainPhaseChecking, Message: "Checking capacity on remaining nodes"})
+
+	if err := m.RefreshNodes(); err != nil {
+		return report, err
+	}
+	tasks, err := m.runningTasks(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	moving := tasksOnNode(tasks, nodeID)
+	if err := CheckDrainCapacity(nodeID, m.GetNodes(), tasks); err != nil {
+		if !opts.Force {
+			return report, err
+		}
+		warning := fmt.Sprintf("capacity check failed, draining anyway: %v", err)
+		log.Warn("Forcing node drain", "node", nodeID, "error", err)
+		report.Warnings = append(report.Warnings, warning)
+	}
+
+	services := make(map[string]struct{})
+	for _, task := range moving {
+		services[task.ServiceID] = struct{}{}
+	}
+	for serviceID := range services {
+		report.Services = append(report.Services, serviceID)
+	}
+	sort.Strings(report.Services)
+
+	progress(DrainProgress{NodeID: nodeID, Phase: DrainPhaseDraining, Total: len(moving), Remaining: len(moving),
+		Message: fmt.Sprintf("Draining node %s", swarmNode.Description.Hostname), Warnings: report.Warnings})
+
+	if err := m.DrainNode(nodeID); err != nil {
+		return report, err
+	}
+
+	ticker := time.NewTicker(opts.PollInterval)
+	defer ticker.Stop()
+
+	last := DrainProgress{Remaining: -1, Pending: -1}
+	for {
+		remaining, pending, err := m.drainState(ctx, nodeID, report.Services)
+		if err != nil {
+			return report, err
+		}
+
+		current := DrainProgress{
+			NodeID:    nodeID,
+			Phase:     DrainPhaseWaiting,
+			Total:     len(moving),
+			Remaining: remaining,
+			Pending:   pending,
+			Message:   fmt.Sprintf("%d task(s) left on node, %d replica(s) not yet running elsewhere", remaining, pending),
+		}
+		if current.Remaining != last.Remaining || current.Pending != last.Pending {
+			progress(current)
+			last = current
+		}
+
+		if remaining == 0 && pending == 0 {
+			report.Moved = len(moving)
+			report.Duration = time.Since(start)
+			progress(DrainProgress{NodeID: nodeID, Phase: DrainPhaseComplete, Total: len(moving),
+				Message: fmt.Sprintf("Node drained, %d task(s) moved in %s", report.Moved, report.Duration.Round(time.Second))})
+			return report, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return report, fmt.Errorf("timed out waiting for node to drain (%d task(s) left on node, %d replica(s) pending): %w",
+				remaining, pending, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// drainState returns how many tasks are still on the node and how many replicas
+// of the affected services are not yet running
+func (m *SwarmManager) drainState(ctx context.Context, nodeID string, serviceIDs []string) (int, int, error) {
+	tasks, err := m.client.TaskList(ctx, types.TaskListOptions{})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	remaining := 0
+	running := make(map[string]int)
+	for _, task := range tasks {
+		if task.NodeID == nodeID && task.Status.State == swarm.TaskStateRunning {
+			remaining++
+			continue
+		}
+		if task.DesiredState == swarm.TaskStateRunning && task.Status.State == swarm.TaskStateRunning {
+			running[task.ServiceID]++
+		}
+	}
+
+	pending := 0
+	for _, serviceID := range serviceIDs {
+		service, _, err := m.client.ServiceInspectWithRaw(ctx, serviceID, types.ServiceInspectOptions{})
+		if err != nil {
+			// The service may have been removed while we were waiting
+			continue
+		}
+		if desired := getReplicaCount(service.Spec); running[serviceID] < desired {
+			pending += desired - running[serviceID]
+		}
+	}
+
+	return remaining, pending, nil
+}
+
+// RebalanceServices force-updates every replicated service so swarm spreads
+// its tasks over all active nodes again
+func (m *SwarmManager) RebalanceServices(ctx context.Context) ([]string, error) {
+	services, err := m.client.ServiceList(ctx, types.ServiceListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+
+	var updated []string
+	for _, service := range services {
+		if getReplicaCount(service.Spec) < 2 {
+			continue
+		}
+
+		spec := service.Spec
+		spec.TaskTemplate.ForceUpdate++
+		if _, err := m.client.ServiceUpdate(ctx, service.ID, service.Version, spec, types.ServiceUpdateOptions{}); err != nil {
+			return updated, fmt.Errorf("failed to rebalance service %s: %w", service.Spec.Name, err)
+		}
+		updated = append(updated, service.Spec.Name)
+	}
+
+	return updated, nil
+}
+
+// CheckDrainCapacity verifies that the tasks running on nodeID fit on the other
+// active nodes, taking their existing reservations into account
+func CheckDrainCapacity(nodeID string, nodes []node.Info, tasks []swarm.Task) error {
+	moving := tasksOnNode(tasks, nodeID)
+	if len(moving) == 0 {
+		return nil
+	}
+
+	type headroom struct {
+		hostname string
+		cpu      int64
+		memory   int64
+	}
+
+	free := make(map[string]*headroom)
+	for _, n := range nodes {
+		if n.ID == nodeID || n.Availability != string(swarm.NodeAvailabilityActive) || !nodeReady(n) {
+			continue
+		}
+		free[n.ID] = &headroom{
+			hostname: n.Hostname,
+			cpu:      int64(n.Capacity.CPU) * 1e9,
+			memory:   n.Capacity.Memory,
+		}
+	}
+	if len(free) == 0 {
+		return fmt.Errorf("%w: no other active nodes to move %d task(s) to", ErrInsufficientCapacity, len(moving))
+	}
+
+	for _, task := range tasks {
+		if h, ok := free[task.NodeID]; ok {
+			cpu, memory := taskReservation(task)
+			h.cpu -= cpu
+			h.memory -= memory
+		}
+	}
+
+	// Place the biggest tasks first so a fragmented cluster is reported accurately
+	sort.Slice(moving, func(i, j int) bool {
+		ci, mi := taskReservation(moving[i])
+		cj, mj := taskReservation(moving[j])
+		if mi != mj {
+			return mi > mj
+		}
+		return ci > cj
+	})
+
+	for _, task := range moving {
+		cpu, memory := taskReservation(task)
+		if cpu == 0 && memory == 0 {
+			continue
+		}
+
+		var best *headroom
+		for _, h := range free {
+			if h.cpu >= cpu && h.memory >= memory && (best == nil || h.memory > best.memory) {
+				best = h
+			}
+		}
+		if best == nil {
+			return fmt.Errorf("%w: task %s needs %.2f CPU and %d MiB memory",
+				ErrInsufficientCapacity, task.ID, float64(cpu)/1e9, memory/(1<<20))
+		}
+		best.cpu -= cpu
+		best.memory -= memory
+	}
+
+	return nil
+}
+
+func tasksOnNode(tasks []swarm.Task, nodeID string) []swarm.Task {
+	var result []swarm.Task
+	for _, task := range tasks {
+		if task.NodeID == nodeID && task.DesiredState == swarm.TaskStateRunning {
+			result = append(result, task)
+		}
+	}
+	return result
+}
+
+func taskReservation(task swarm.Task) (int64, int64) {
+	if task.Spec.Resources == nil || task.Spec.Resources.Reservations == nil {
+		return 0, 0
+	}
+	return task.Spec.Resources.Reservations.NanoCPUs, task.Spec.Resources.Reservations.MemoryBytes
+}
+
+func nodeReady(n node.Info) bool {
+	for _, condition := range n.Conditions {
+		if condition == string(swarm.NodeStateReady) {
+			return true
+		}
+	}
+	return false
+}
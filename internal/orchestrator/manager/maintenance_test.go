@@ -0,0 +1,117 @@
+package manager
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/jasonlovesdoggo/velo/pkg/core/node"
+)
+
+func testNode(id string, cpus int, memory int64, availability swarm.NodeAvailability) node.Info {
+	return node.Info{
+		ID:           id,
+		Hostname:     id,
+		Availability: string(availability),
+		Conditions:   []string{string(swarm.NodeStateReady)},
+		Capacity:     node.Resources{CPU: cpus, Memory: memory},
+	}
+}
+
+func testTask(id, nodeID string, nanoCPUs, memory int64) swarm.Task {
+	task := swarm.Task{
+		ID:           id,
+		ServiceID:    "svc-" + id,
+		NodeID:       nodeID,
+		DesiredState: swarm.TaskStateRunning,
+		Status:       swarm.TaskStatus{State: swarm.TaskStateRunning},
+	}
+	if nanoCPUs > 0 || memory > 0 {
+		task.Spec.Resources = &swarm.ResourceRequirements{
+			Reservations: &swarm.Resources{NanoCPUs: nanoCPUs, MemoryBytes: memory},
+		}
+	}
+	return task
+}
+
+func TestCheckDrainCapacity(t *testing.T) {
+	const gib = int64(1 << 30)
+
+	tests := []struct {
+		name    string
+		nodes   []node.Info
+		tasks   []swarm.Task
+		wantErr bool
+	}{
+		{
+			name: "Empty node",
+			nodes: []node.Info{
+				testNode("a", 2, 4*gib, swarm.NodeAvailabilityActive),
+			},
+			tasks:   nil,
+			wantErr: false,
+		},
+		{
+			name: "Tasks fit on remaining node",
+			nodes: []node.Info{
+				testNode("a", 2, 4*gib, swarm.NodeAvailabilityActive),
+				testNode("b", 2, 4*gib, swarm.NodeAvailabilityActive),
+			},
+			tasks: []swarm.Task{
+				testTask("t1", "a", 1e9, 2*gib),
+				testTask("t2", "b", 5e8, 1*gib),
+			},
+			wantErr: false,
+		},
+		{
+			name: "Existing reservations leave no room",
+			nodes: []node.Info{
+				testNode("a", 2, 4*gib, swarm.NodeAvailabilityActive),
+				testNode("b", 2, 4*gib, swarm.NodeAvailabilityActive),
+			},
+			tasks: []swarm.Task{
+				testTask("t1", "a", 1e9, 2*gib),
+				testTask("t2", "b", 5e8, 3*gib),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Drained nodes are not eligible",
+			nodes: []node.Info{
+				testNode("a", 2, 4*gib, swarm.NodeAvailabilityActive),
+				testNode("b", 8, 16*gib, swarm.NodeAvailabilityDrain),
+			},
+			tasks: []swarm.Task{
+				testTask("t1", "a", 0, 0),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Free capacity is fragmented",
+			nodes: []node.Info{
+				testNode("a", 4, 4*gib, swarm.NodeAvailabilityActive),
+				testNode("b", 4, 2*gib, swarm.NodeAvailabilityActive),
+				testNode("c", 4, 2*gib, swarm.NodeAvailabilityActive),
+			},
+			tasks: []swarm.Task{
+				testTask("t1", "a", 1e9, 3*gib),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckDrainCapacity("a", tt.nodes, tt.tasks)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInsufficientCapacity) {
+					t.Errorf("Expected ErrInsufficientCapacity, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -9,21 +9,27 @@ import (
 	"time"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/swarm"
 	"github.com/docker/docker/client"
 	"github.com/jasonlovesdoggo/velo/internal/config"
 	"github.com/jasonlovesdoggo/velo/internal/log"
+	"github.com/jasonlovesdoggo/velo/internal/tracing"
 	"github.com/jasonlovesdoggo/velo/pkg/core/node"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // SwarmManager handles Docker Swarm cluster management operations
 type SwarmManager struct {
-	client        *client.Client
-	nodeCache     map[string]node.Info
-	nodeCacheMu   sync.RWMutex
-	refreshTicker *time.Ticker
-	ctx           context.Context
-	cancel        context.CancelFunc
+	client         *client.Client
+	nodeCache      map[string]node.Info
+	nodeCacheMu    sync.RWMutex
+	refreshTicker  *time.Ticker
+	capacityPolicy CapacityPolicy
+	ctx            context.Context
+	cancel         context.CancelFunc
 }
 
 // NewSwarmManager creates a new SwarmManager instance
@@ -33,16 +39,19 @@ func NewSwarmManager() (*SwarmManager, error) {
 		return nil, fmt.Errorf("failed to create Docker client: %w", err)
 	}
 
+	return NewSwarmManagerWithClient(cli), nil
+}
+
+// NewSwarmManagerWithClient creates a SwarmManager that uses an existing Docker client
+func NewSwarmManagerWithClient(cli *client.Client) *SwarmManager {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	manager := &SwarmManager{
+	return &SwarmManager{
 		client:    cli,
 		nodeCache: make(map[string]node.Info),
 		ctx:       ctx,
 		cancel:    cancel,
 	}
-
-	return manager, nil
 }
 
 // Start begins the manager's background operations
@@ -78,6 +87,18 @@ func (m *SwarmManager) Stop() {
 	m.cancel()
 }
 
+// HealthCheck verifies that the Docker endpoint is reachable and is a swarm manager
+func (m *SwarmManager) HealthCheck(ctx context.Context) error {
+	info, err := m.client.Info(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to reach Docker: %w", err)
+	}
+	if !info.Swarm.ControlAvailable {
+		return fmt.Errorf("host %s is not a swarm manager", info.Name)
+	}
+	return m.RefreshNodes()
+}
+
 // InitSwarm initializes a new Swarm cluster
 func (m *SwarmManager) InitSwarm(advertiseAddr string) (string, error) {
 	req := swarm.InitRequest{
@@ -111,6 +132,32 @@ func (m *SwarmManager) GetJoinToken(isManager bool) (string, error) {
 	return swarmInfo.JoinTokens.Worker, nil
 }
 
+// ManagerAddresses returns the swarm addresses of the reachable managers, leader first
+func (m *SwarmManager) ManagerAddresses() ([]string, error) {
+	nodes, err := m.client.NodeList(context.Background(), types.NodeListOptions{
+		Filters: filters.NewArgs(filters.Arg("role", string(swarm.NodeRoleManager))),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list managers: %w", err)
+	}
+
+	var addrs []string
+	for _, n := range nodes {
+		if n.ManagerStatus == nil || n.ManagerStatus.Addr == "" || n.ManagerStatus.Reachability == swarm.ReachabilityUnreachable {
+			continue
+		}
+		if n.ManagerStatus.Leader {
+			addrs = append([]string{n.ManagerStatus.Addr}, addrs...)
+		} else {
+			addrs = append(addrs, n.ManagerStatus.Addr)
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, errors.New("no reachable swarm managers")
+	}
+	return addrs, nil
+}
+
 // RefreshNodes updates the node cache with the current state of the swarm
 func (m *SwarmManager) RefreshNodes() error {
 	nodes, err := m.client.NodeList(context.Background(), types.NodeListOptions{})
@@ -253,27 +300,63 @@ func (m *SwarmManager) RemoveNode(nodeID string, force bool) error {
 
 // DeployService deploys a service to the swarm
 func (m *SwarmManager) DeployService(def config.ServiceDefinition) (string, error) {
+	return m.DeployServiceContext(context.Background(), def)
+}
+
+// DeployServiceContext deploys a service to the swarm, tracing the Docker calls under ctx
+func (m *SwarmManager) DeployServiceContext(ctx context.Context, def config.ServiceDefinition) (id string, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "SwarmManager.DeployService", trace.WithAttributes(
+		attribute.String("velo.service", def.Name),
+		attribute.String("velo.image", def.Image),
+		attribute.Int("velo.replicas", def.Replicas),
+	))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	if err := m.ValidatePlacement(def); err != nil {
+		return "", err
+	}
+	if err := m.admit(ctx, def); err != nil {
+		return "", err
+	}
+
+	resp, err := m.client.ServiceCreate(ctx, buildServiceSpec(def), types.ServiceCreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to create service: %w", err)
+	}
+	span.SetAttributes(attribute.String("velo.service_id", resp.ID))
+
+	return resp.ID, nil
+}
+
+// buildServiceSpec converts a service definition into a swarm service spec
+func buildServiceSpec(def config.ServiceDefinition) swarm.ServiceSpec {
 	spec := swarm.ServiceSpec{
 		Annotations: swarm.Annotations{
-			Name: def.Name,
+			Name:   def.Name,
+			Labels: def.Labels, // velo.project decides who may change the service
 		},
 		TaskTemplate: swarm.TaskSpec{
 			ContainerSpec: &swarm.ContainerSpec{
-				Image: def.Image,
-				Env:   def.ToEnv(),
+				Image:  def.Image,
+				Env:    def.ToEnv(),
+				Labels: containerLabels(def),
 			},
+			Placement:     buildPlacement(def),
+			Resources:     buildResources(def.Resources),
+			RestartPolicy: buildRestartPolicy(def.Restart),
 		},
 		Mode: swarm.ServiceMode{
 			Replicated: &swarm.ReplicatedService{Replicas: utils.Uint64Ptr(uint64(def.Replicas))},
 		},
 	}
 
-	resp, err := m.client.ServiceCreate(context.Background(), spec, types.ServiceCreateOptions{})
-	if err != nil {
-		return "", fmt.Errorf("failed to create service: %w", err)
-	}
-
-	return resp.ID, nil
+	return spec
 }
 
 // UpdateService updates an existing service
@@ -284,10 +367,26 @@ func (m *SwarmManager) UpdateService(serviceID string, def config.ServiceDefinit
 		return fmt.Errorf("failed to inspect service: %w", err)
 	}
 
+	if def.Replicas <= 0 {
+		def.Replicas = getReplicaCount(service.Spec)
+	}
+	if err := m.ValidatePlacement(def); err != nil {
+		return err
+	}
+	if err := m.admit(context.Background(), def); err != nil {
+		return err
+	}
+
 	// Update spec
 	spec := service.Spec
 	spec.TaskTemplate.ContainerSpec.Image = def.Image
 	spec.TaskTemplate.ContainerSpec.Env = def.ToEnv()
+	// Definitions without placement keep the service's, which may be set outside Velo
+	if placement := buildPlacement(def); placement != nil {
+		spec.TaskTemplate.Placement = placement
+	}
+	spec.TaskTemplate.Resources = buildResources(def.Resources)
+	spec.TaskTemplate.RestartPolicy = buildRestartPolicy(def.Restart)
 
 	if def.Replicas > 0 {
 		replicas := uint64(def.Replicas)
@@ -314,6 +413,53 @@ func (m *SwarmManager) UpdateService(serviceID string, def config.ServiceDefinit
 	return nil
 }
 
+// ScaleService changes the number of replicas of a service
+func (m *SwarmManager) ScaleService(serviceID string, replicas int) error {
+	service, _, err := m.client.ServiceInspectWithRaw(context.Background(), serviceID, types.ServiceInspectOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to inspect service: %w", err)
+	}
+	if service.Spec.Mode.Replicated == nil {
+		return fmt.Errorf("service %s is not replicated", service.Spec.Name)
+	}
+
+	// Only scale-ups need new capacity
+	if replicas > getReplicaCount(service.Spec) {
+		def := serviceDefinitionFromSpec(service.Spec)
+		def.Replicas = replicas
+		if err := m.admit(context.Background(), def); err != nil {
+			return err
+		}
+	}
+
+	spec := service.Spec
+	spec.Mode.Replicated.Replicas = utils.Uint64Ptr(uint64(replicas))
+	if _, err := m.client.ServiceUpdate(context.Background(), service.ID, service.Version, spec, types.ServiceUpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to scale service: %w", err)
+	}
+
+	return nil
+}
+
+// RollbackService rolls a service back to the spec it had before its last update
+func (m *SwarmManager) RollbackService(serviceID string) error {
+	service, _, err := m.client.ServiceInspectWithRaw(context.Background(), serviceID, types.ServiceInspectOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to inspect service: %w", err)
+	}
+	if service.PreviousSpec == nil {
+		return ErrNoPreviousVersion
+	}
+
+	if _, err := m.client.ServiceUpdate(context.Background(), service.ID, service.Version, service.Spec,
+		types.ServiceUpdateOptions{Rollback: "previous"}); err != nil {
+		return fmt.Errorf("failed to roll back service: %w", err)
+	}
+
+	log.Info("Rolled back service", "serviceID", service.ID, "name", service.Spec.Name)
+	return nil
+}
+
 // RemoveService removes a service from the swarm
 func (m *SwarmManager) RemoveService(serviceID string) error {
 	err := m.client.ServiceRemove(context.Background(), serviceID)
@@ -347,12 +493,18 @@ func (m *SwarmManager) GetServiceStatus(serviceID string) (config.DeploymentStat
 
 	// Determine overall state
 	state := "running"
+	running := 0
+	for _, task := range serviceTasks {
+		if task.DesiredState == swarm.TaskStateRunning && task.Status.State == swarm.TaskStateRunning {
+			running++
+		}
+	}
 	if len(serviceTasks) == 0 {
 		state = "pending"
 	} else {
 		failedTasks := 0
 		for _, task := range serviceTasks {
-			if task.Status.State == swarm.TaskStateFailed {
+			if task.Status.State == swarm.TaskStateFailed || task.Status.State == swarm.TaskStateRejected {
 				failedTasks++
 			}
 		}
@@ -364,14 +516,10 @@ func (m *SwarmManager) GetServiceStatus(serviceID string) (config.DeploymentStat
 	}
 
 	return config.DeploymentStatus{
-		ID:    serviceID,
-		State: state,
-		Service: config.ServiceDefinition{
-			Name:  service.Spec.Annotations.Name,
-			Image: service.Spec.TaskTemplate.ContainerSpec.Image,
-			// Environment would need parsing from env strings back to map
-			Replicas: getReplicaCount(service.Spec),
-		},
+		ID:      serviceID,
+		State:   state,
+		Running: running,
+		Service: serviceDefinitionFromSpec(service.Spec),
 	}, nil
 }
 
@@ -397,6 +545,73 @@ func (m *SwarmManager) ListServices() ([]config.DeploymentStatus, error) {
 
 // Helper functions
 
+// serviceDefinitionFromSpec converts a swarm service spec back into a service definition
+func serviceDefinitionFromSpec(spec swarm.ServiceSpec) config.ServiceDefinition {
+	def := config.ServiceDefinition{
+		Name:   spec.Annotations.Name,
+		Labels: spec.Annotations.Labels,
+		// Environment would need parsing from env strings back to map
+		Replicas: getReplicaCount(spec),
+	}
+	if spec.TaskTemplate.ContainerSpec != nil {
+		def.Image = spec.TaskTemplate.ContainerSpec.Image
+	}
+	if placement := spec.TaskTemplate.Placement; placement != nil {
+		def.Constraints = placement.Constraints
+		def.Placement.MaxReplicasPerNode = placement.MaxReplicas
+		for _, pref := range placement.Preferences {
+			if pref.Spread != nil {
+				def.Placement.Preferences = append(def.Placement.Preferences, config.PlacementPreference{Spread: pref.Spread.SpreadDescriptor})
+			}
+		}
+	}
+	if resources := spec.TaskTemplate.Resources; resources != nil {
+		if resources.Limits != nil {
+			def.Resources.CPULimit = float64(resources.Limits.NanoCPUs) / 1e9
+			def.Resources.MemoryLimit = resources.Limits.MemoryBytes
+		}
+		if resources.Reservations != nil {
+			def.Resources.CPUReserve = float64(resources.Reservations.NanoCPUs) / 1e9
+			def.Resources.MemoryReserve = resources.Reservations.MemoryBytes
+		}
+	}
+	if restart := spec.TaskTemplate.RestartPolicy; restart != nil {
+		def.Restart.Condition = string(restart.Condition)
+		if restart.MaxAttempts != nil {
+			def.Restart.MaxAttempts = *restart.MaxAttempts
+		}
+		if restart.Delay != nil {
+			def.Restart.Delay = int(restart.Delay.Seconds())
+		}
+	}
+	return def
+}
+
+// buildRestartPolicy maps a service's restart policy to a swarm restart policy
+// containerLabels returns the labels node agents read from a service's containers, nil if there are none
+func containerLabels(def config.ServiceDefinition) map[string]string {
+	if def.HealthCheck.OnFailure == "" {
+		return nil
+	}
+	return map[string]string{config.HealthPolicyLabel: def.HealthCheck.OnFailure}
+}
+
+func buildRestartPolicy(policy config.RestartPolicy) *swarm.RestartPolicy {
+	if policy.Condition == "" {
+		return nil
+	}
+
+	restart := &swarm.RestartPolicy{Condition: swarm.RestartPolicyCondition(policy.Condition)}
+	if policy.MaxAttempts > 0 {
+		restart.MaxAttempts = utils.Uint64Ptr(policy.MaxAttempts)
+	}
+	if policy.Delay > 0 {
+		delay := time.Duration(policy.Delay) * time.Second
+		restart.Delay = &delay
+	}
+	return restart
+}
+
 func getReplicaCount(spec swarm.ServiceSpec) int {
 	if spec.Mode.Replicated != nil && spec.Mode.Replicated.Replicas != nil {
 		return int(*spec.Mode.Replicated.Replicas)
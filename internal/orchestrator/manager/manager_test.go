@@ -0,0 +1,257 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/jasonlovesdoggo/velo/internal/config"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/dockertest"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// newTestSwarm starts a fake three node swarm and a SwarmManager connected to it
+func newTestSwarm(t *testing.T) (*dockertest.Server, *SwarmManager) {
+	t.Helper()
+
+	srv := dockertest.NewServer(dockertest.Options{Swarm: true, Hostname: "manager-1"})
+	srv.AddNode("worker-1", swarm.NodeRoleWorker, nil)
+	srv.AddNode("worker-2", swarm.NodeRoleWorker, nil)
+
+	cli, err := srv.Client()
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	m := NewSwarmManagerWithClient(cli)
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	t.Cleanup(func() {
+		m.Stop()
+		cli.Close()
+		srv.Close()
+	})
+	return srv, m
+}
+
+// runningTasks returns the running tasks of a service on the fake server
+func runningTasks(srv *dockertest.Server, serviceID string) []swarm.Task {
+	var running []swarm.Task
+	for _, task := range srv.Tasks(serviceID) {
+		if task.DesiredState == swarm.TaskStateRunning && task.Status.State == swarm.TaskStateRunning {
+			running = append(running, task)
+		}
+	}
+	return running
+}
+
+func TestSwarmDeployUpdateRollback(t *testing.T) {
+	srv, m := newTestSwarm(t)
+
+	id, err := m.DeployService(config.ServiceDefinition{Name: "web", Image: "nginx:1", Replicas: 3,
+		Environment: map[string]string{"PORT": "80"}})
+	if err != nil {
+		t.Fatalf("DeployService failed: %v", err)
+	}
+
+	status, err := m.GetServiceStatus(id)
+	if err != nil {
+		t.Fatalf("GetServiceStatus failed: %v", err)
+	}
+	if status.State != "running" || status.Service.Image != "nginx:1" || status.Service.Replicas != 3 {
+		t.Fatalf("Unexpected status %+v", status)
+	}
+	if n := len(runningTasks(srv, id)); n != 3 {
+		t.Fatalf("Expected 3 running tasks, got %d", n)
+	}
+
+	if err := m.RollbackService(id); !errors.Is(err, ErrNoPreviousVersion) {
+		t.Fatalf("Expected ErrNoPreviousVersion before any update, got %v", err)
+	}
+
+	if err := m.UpdateService(id, config.ServiceDefinition{Name: "web", Image: "nginx:2"}); err != nil {
+		t.Fatalf("UpdateService failed: %v", err)
+	}
+	for _, task := range runningTasks(srv, id) {
+		if task.Spec.ContainerSpec.Image != "nginx:2" {
+			t.Errorf("Expected updated tasks, found one running %s", task.Spec.ContainerSpec.Image)
+		}
+	}
+
+	if err := m.RollbackService(id); err != nil {
+		t.Fatalf("RollbackService failed: %v", err)
+	}
+	status, _ = m.GetServiceStatus(id)
+	if status.Service.Image != "nginx:1" {
+		t.Errorf("Expected rollback to restore nginx:1, got %s", status.Service.Image)
+	}
+	if services := srv.Services(); services[0].UpdateStatus == nil || services[0].UpdateStatus.State != swarm.UpdateStateRollbackCompleted {
+		t.Errorf("Expected a completed rollback, got %+v", services[0].UpdateStatus)
+	}
+
+	if err := m.RemoveService(id); err != nil {
+		t.Fatalf("RemoveService failed: %v", err)
+	}
+	if _, err := m.GetServiceStatus(id); err == nil {
+		t.Error("Expected status of removed service to fail")
+	}
+}
+
+func TestSwarmServiceProject(t *testing.T) {
+	_, m := newTestSwarm(t)
+
+	id, err := m.DeployService(config.ServiceDefinition{Name: "web", Image: "nginx:1", Replicas: 1,
+		Labels: map[string]string{config.ProjectLabel: "shop"}})
+	if err != nil {
+		t.Fatalf("DeployService failed: %v", err)
+	}
+	status, err := m.GetServiceStatus(id)
+	if err != nil {
+		t.Fatalf("GetServiceStatus failed: %v", err)
+	}
+	if project := status.Service.Project(); project != "shop" {
+		t.Errorf("Expected the service to belong to shop, got %s", project)
+	}
+}
+
+func TestSwarmUpdateKeepsPlacement(t *testing.T) {
+	srv, m := newTestSwarm(t)
+
+	id, err := m.DeployService(config.ServiceDefinition{Name: "web", Image: "nginx:1", Replicas: 1,
+		Constraints: []string{"node.role==worker"}})
+	if err != nil {
+		t.Fatalf("DeployService failed: %v", err)
+	}
+
+	if err := m.UpdateService(id, config.ServiceDefinition{Name: "web", Image: "nginx:2"}); err != nil {
+		t.Fatalf("UpdateService failed: %v", err)
+	}
+	if placement := srv.Services()[0].Spec.TaskTemplate.Placement; placement == nil || len(placement.Constraints) != 1 {
+		t.Errorf("Expected an update without placement to keep the service's, got %+v", placement)
+	}
+
+	if err := m.UpdateService(id, config.ServiceDefinition{Name: "web", Image: "nginx:3",
+		Constraints: []string{"node.role==manager"}}); err != nil {
+		t.Fatalf("UpdateService failed: %v", err)
+	}
+	if placement := srv.Services()[0].Spec.TaskTemplate.Placement; placement == nil || len(placement.Constraints) != 1 || placement.Constraints[0] != "node.role==manager" {
+		t.Errorf("Expected an update with placement to replace the service's, got %+v", placement)
+	}
+}
+
+func TestSwarmScaleAndFailedImage(t *testing.T) {
+	srv, m := newTestSwarm(t)
+
+	id, err := m.DeployService(config.ServiceDefinition{Name: "api", Image: "api:1", Replicas: 1})
+	if err != nil {
+		t.Fatalf("DeployService failed: %v", err)
+	}
+	if err := m.ScaleService(id, 4); err != nil {
+		t.Fatalf("ScaleService failed: %v", err)
+	}
+	if n := len(runningTasks(srv, id)); n != 4 {
+		t.Errorf("Expected 4 running tasks after scale up, got %d", n)
+	}
+	if err := m.ScaleService(id, 2); err != nil {
+		t.Fatalf("ScaleService failed: %v", err)
+	}
+	if n := len(runningTasks(srv, id)); n != 2 {
+		t.Errorf("Expected 2 running tasks after scale down, got %d", n)
+	}
+
+	srv.FailImage("broken:1", "")
+	broken, err := m.DeployService(config.ServiceDefinition{Name: "broken", Image: "broken:1", Replicas: 2})
+	if err != nil {
+		t.Fatalf("DeployService failed: %v", err)
+	}
+	if status, _ := m.GetServiceStatus(broken); status.State != "failed" {
+		t.Errorf("Expected service with a missing image to fail, got %s", status.State)
+	}
+}
+
+func TestSwarmDrainNodeAndWait(t *testing.T) {
+	srv, m := newTestSwarm(t)
+
+	id, err := m.DeployService(config.ServiceDefinition{Name: "web", Image: "nginx:1", Replicas: 3})
+	if err != nil {
+		t.Fatalf("DeployService failed: %v", err)
+	}
+
+	var phases []string
+	report, err := m.DrainNodeAndWait(context.Background(), "worker-1", DrainOptions{
+		Timeout:      5 * time.Second,
+		PollInterval: 10 * time.Millisecond,
+		Progress:     func(p DrainProgress) { phases = append(phases, p.Phase) },
+	})
+	if err != nil {
+		t.Fatalf("DrainNodeAndWait failed: %v", err)
+	}
+	if report.Moved != 1 || len(report.Services) != 1 || phases[len(phases)-1] != DrainPhaseComplete {
+		t.Errorf("Unexpected drain report %+v, phases %v", report, phases)
+	}
+
+	running := runningTasks(srv, id)
+	if len(running) != 3 {
+		t.Fatalf("Expected 3 running tasks after drain, got %d", len(running))
+	}
+	for _, task := range running {
+		if task.NodeID == report.NodeID {
+			t.Errorf("Task %s still running on drained node", task.ID)
+		}
+	}
+
+	if err := m.ActivateNode(report.NodeID); err != nil {
+		t.Fatalf("ActivateNode failed: %v", err)
+	}
+	updated, err := m.RebalanceServices(context.Background())
+	if err != nil || len(updated) != 1 {
+		t.Fatalf("Expected 1 rebalanced service, got %v (%v)", updated, err)
+	}
+	nodes := make(map[string]bool)
+	for _, task := range runningTasks(srv, id) {
+		nodes[task.NodeID] = true
+	}
+	if len(nodes) != 3 {
+		t.Errorf("Expected tasks spread over 3 nodes after rebalance, got %d", len(nodes))
+	}
+}
+
+func TestSwarmDeployTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	_, m := newTestSwarm(t)
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "Deploy")
+	if _, err := m.DeployServiceContext(ctx, config.ServiceDefinition{Name: "web", Image: "nginx", Replicas: 1}); err != nil {
+		t.Fatalf("DeployServiceContext failed: %v", err)
+	}
+	parent.End()
+
+	// The deploy span sits under the caller's span and the Docker calls under the deploy
+	var deploy sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "SwarmManager.DeployService" {
+			deploy = span
+		}
+	}
+	if deploy == nil || deploy.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatalf("Expected a deploy span under the caller's span, got %v", deploy)
+	}
+	docker := map[string]bool{}
+	for _, span := range recorder.Ended() {
+		if span.Parent().SpanID() == deploy.SpanContext().SpanID() {
+			docker[span.Name()] = true
+		}
+	}
+	if !docker["POST /v"+m.client.ClientVersion()+"/services/create"] {
+		t.Errorf("Expected the service creation under the deploy span, got %v", docker)
+	}
+}
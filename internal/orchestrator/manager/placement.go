@@ -0,0 +1,129 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/jasonlovesdoggo/velo/internal/config"
+	"github.com/jasonlovesdoggo/velo/pkg/core/node"
+)
+
+// ErrUnsatisfiablePlacement is returned when no node can run a service's tasks
+var ErrUnsatisfiablePlacement = errors.New("placement cannot be satisfied")
+
+// buildPlacement maps a service definition's constraints and preferences to a swarm placement
+func buildPlacement(def config.ServiceDefinition) *swarm.Placement {
+	if len(def.Constraints) == 0 && len(def.Placement.Preferences) == 0 && def.Placement.MaxReplicasPerNode == 0 {
+		return nil
+	}
+
+	placement := &swarm.Placement{
+		Constraints: def.Constraints,
+		MaxReplicas: def.Placement.MaxReplicasPerNode,
+	}
+	for _, pref := range def.Placement.Preferences {
+		placement.Preferences = append(placement.Preferences, swarm.PlacementPreference{
+			Spread: &swarm.SpreadOver{SpreadDescriptor: pref.Spread},
+		})
+	}
+	return placement
+}
+
+// ValidatePlacement checks a service definition's placement against the node cache
+func (m *SwarmManager) ValidatePlacement(def config.ServiceDefinition) error {
+	nodes := m.GetNodes()
+	if len(nodes) == 0 {
+		if err := m.RefreshNodes(); err != nil {
+			return err
+		}
+		nodes = m.GetNodes()
+	}
+	_, err := EligibleNodes(def, nodes)
+	return err
+}
+
+// EligibleNodes returns the active nodes that satisfy all of a service's constraints
+func EligibleNodes(def config.ServiceDefinition, nodes []node.Info) ([]node.Info, error) {
+	constraints, err := def.ParseConstraints()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsatisfiablePlacement, err)
+	}
+
+	var eligible []node.Info
+	for _, n := range nodes {
+		if n.Availability != string(swarm.NodeAvailabilityActive) {
+			continue
+		}
+		if matchesConstraints(n, constraints) {
+			eligible = append(eligible, n)
+		}
+	}
+
+	if len(eligible) == 0 {
+		return nil, fmt.Errorf("%w: no active node satisfies %s; available node labels: %s",
+			ErrUnsatisfiablePlacement, strings.Join(def.Constraints, ", "), describeLabels(nodes))
+	}
+
+	if max := def.Placement.MaxReplicasPerNode; max > 0 && uint64(def.Replicas) > max*uint64(len(eligible)) {
+		return nil, fmt.Errorf("%w: %d replicas with max_replicas_per_node=%d need at least %d eligible nodes, only %d available",
+			ErrUnsatisfiablePlacement, def.Replicas, max, (uint64(def.Replicas)+max-1)/max, len(eligible))
+	}
+
+	return eligible, nil
+}
+
+// matchesConstraints reports whether a node satisfies every constraint
+func matchesConstraints(n node.Info, constraints []config.Constraint) bool {
+	for _, c := range constraints {
+		actual, known := nodeAttribute(n, c.Key)
+		if !known {
+			// Attributes we don't cache (engine labels, platform) are left to the scheduler
+			continue
+		}
+		// Swarm compares constraint values case-insensitively
+		if (c.Operator == "==") != strings.EqualFold(actual, c.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// nodeAttribute looks up a constraint key on a cached node
+func nodeAttribute(n node.Info, key string) (string, bool) {
+	switch key {
+	case "node.id":
+		return n.ID, true
+	case "node.hostname":
+		return n.Hostname, true
+	case "node.role":
+		return n.Role, true
+	}
+	if label, ok := strings.CutPrefix(key, "node.labels."); ok {
+		// A missing label compares as empty, matching swarm's behaviour
+		return n.Labels[label], true
+	}
+	return "", false
+}
+
+// describeLabels lists the distinct node labels in the cluster for error messages
+func describeLabels(nodes []node.Info) string {
+	seen := make(map[string]struct{})
+	for _, n := range nodes {
+		for key, value := range n.Labels {
+			seen[key+"="+value] = struct{}{}
+		}
+	}
+	if len(seen) == 0 {
+		return "none"
+	}
+
+	labels := make([]string, 0, len(seen))
+	for label := range seen {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return strings.Join(labels, ", ")
+}
@@ -0,0 +1,116 @@
+package manager
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/jasonlovesdoggo/velo/internal/config"
+	"github.com/jasonlovesdoggo/velo/pkg/core/node"
+)
+
+func labeledNode(id string, labels map[string]string) node.Info {
+	n := testNode(id, 2, 1<<30, swarm.NodeAvailabilityActive)
+	n.Role = "worker"
+	n.Labels = labels
+	return n
+}
+
+func TestEligibleNodes(t *testing.T) {
+	nodes := []node.Info{
+		labeledNode("a", map[string]string{"zone": "us", "disk": "ssd"}),
+		labeledNode("b", map[string]string{"zone": "us"}),
+		labeledNode("c", map[string]string{"zone": "ap"}),
+	}
+
+	tests := []struct {
+		name        string
+		def         config.ServiceDefinition
+		wantNodes   int
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name:      "No constraints",
+			def:       config.ServiceDefinition{Replicas: 3},
+			wantNodes: 3,
+		},
+		{
+			name:      "Label equality",
+			def:       config.ServiceDefinition{Replicas: 1, Constraints: []string{"node.labels.zone==us"}},
+			wantNodes: 2,
+		},
+		{
+			name:      "Label inequality and role",
+			def:       config.ServiceDefinition{Replicas: 1, Constraints: []string{"node.labels.zone!=us", "node.role==worker"}},
+			wantNodes: 1,
+		},
+		{
+			name:        "Unknown label value lists available labels",
+			def:         config.ServiceDefinition{Replicas: 1, Constraints: []string{"node.labels.zone==eu"}},
+			wantErr:     true,
+			errContains: "available node labels: disk=ssd, zone=ap, zone=us",
+		},
+		{
+			name: "Too many replicas per node",
+			def: config.ServiceDefinition{
+				Replicas:    3,
+				Constraints: []string{"node.labels.zone==us"},
+				Placement:   config.PlacementConfig{MaxReplicasPerNode: 1},
+			},
+			wantErr:     true,
+			errContains: "max_replicas_per_node=1",
+		},
+		{
+			name:    "Malformed constraint",
+			def:     config.ServiceDefinition{Replicas: 1, Constraints: []string{"zone=eu"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eligible, err := EligibleNodes(tt.def, nodes)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnsatisfiablePlacement) {
+					t.Fatalf("Expected ErrUnsatisfiablePlacement, got %v", err)
+				}
+				if tt.errContains != "" && !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("Expected error to contain %q, got %q", tt.errContains, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(eligible) != tt.wantNodes {
+				t.Errorf("Expected %d eligible nodes, got %d", tt.wantNodes, len(eligible))
+			}
+		})
+	}
+}
+
+func TestBuildPlacement(t *testing.T) {
+	if p := buildPlacement(config.ServiceDefinition{}); p != nil {
+		t.Errorf("Expected nil placement for empty definition, got %+v", p)
+	}
+
+	def := config.ServiceDefinition{
+		Constraints: []string{"node.labels.zone==eu"},
+		Placement: config.PlacementConfig{
+			Preferences:        []config.PlacementPreference{{Spread: "node.labels.rack"}},
+			MaxReplicasPerNode: 2,
+		},
+	}
+	p := buildPlacement(def)
+	if p == nil {
+		t.Fatal("Expected placement, got nil")
+	}
+	if len(p.Constraints) != 1 || p.MaxReplicas != 2 {
+		t.Errorf("Unexpected placement %+v", p)
+	}
+	if len(p.Preferences) != 1 || p.Preferences[0].Spread.SpreadDescriptor != "node.labels.rack" {
+		t.Errorf("Unexpected placement preferences %+v", p.Preferences)
+	}
+}
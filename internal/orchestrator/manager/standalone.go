@@ -0,0 +1,866 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/jasonlovesdoggo/velo/internal/config"
+	"github.com/jasonlovesdoggo/velo/internal/log"
+)
+
+// Labels the standalone backend puts on the containers it manages
+const (
+	labelServiceID   = "velo.service.id"
+	labelServiceName = "velo.service.name"
+	labelReplica     = "velo.replica"
+	labelRevision    = "velo.revision"
+	labelSpec        = "velo.spec"
+	labelEnv         = "velo.env" // names of the service's environment variables, whose values aren't in velo.spec
+)
+
+// replicaSettleTime is how long a replica without a healthcheck has to stay
+// running before an update moves on to the next one
+const replicaSettleTime = 3 * time.Second
+
+// ErrServiceNotFound is returned when a service isn't known to the backend
+var ErrServiceNotFound = errors.New("service not found")
+
+// StandaloneManager runs services as plain containers on a single Docker host,
+// for installs that don't use swarm mode. Every replica is a container labelled
+// with its service, slot and definition, so services are picked back up from
+// the containers when the manager restarts. Services scaled to zero replicas
+// have no containers left and are forgotten on restart.
+type StandaloneManager struct {
+	client          *client.Client
+	settings        config.StandaloneConfig
+	services        map[string]*standaloneService
+	deploying       map[string]bool // names of services being deployed, reserved so they're deployed once
+	servicesMu      sync.RWMutex
+	reconcileTicker *time.Ticker
+	ctx             context.Context
+	cancel          context.CancelFunc
+	onReconcile     func(ReconcileAction)
+}
+
+// standaloneService is a service run by the standalone backend
+type standaloneService struct {
+	id       string
+	def      config.ServiceDefinition
+	revision int        // bumped on every update, replicas of older revisions get replaced
+	opMu     sync.Mutex // serializes operations that change the service's containers
+}
+
+// replica is a container running one slot of a service
+type replica struct {
+	slot      int
+	revision  int
+	container container.Summary
+}
+
+// NewStandaloneManager creates a new StandaloneManager instance
+func NewStandaloneManager(settings config.StandaloneConfig) (*StandaloneManager, error) {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Docker client: %w", err)
+	}
+
+	return NewStandaloneManagerWithClient(cli, settings), nil
+}
+
+// NewStandaloneManagerWithClient creates a StandaloneManager that uses an existing Docker client
+func NewStandaloneManagerWithClient(cli *client.Client, settings config.StandaloneConfig) *StandaloneManager {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &StandaloneManager{
+		client:    cli,
+		settings:  settings,
+		services:  make(map[string]*standaloneService),
+		deploying: make(map[string]bool),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Start loads existing services from their containers and begins reconciling them
+func (m *StandaloneManager) Start() error {
+	if err := m.loadServices(); err != nil {
+		return fmt.Errorf("failed to load services: %w", err)
+	}
+	m.reconcile()
+
+	interval := time.Duration(m.settings.ReconcileInterval) * time.Second
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	m.reconcileTicker = time.NewTicker(interval)
+	go func() {
+		for {
+			select {
+			case <-m.reconcileTicker.C:
+				m.reconcile()
+			case <-m.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	log.Info("Standalone manager started", "services", len(m.services))
+	return nil
+}
+
+// Stop stops the manager's background operations. Containers keep running.
+func (m *StandaloneManager) Stop() {
+	if m.reconcileTicker != nil {
+		m.reconcileTicker.Stop()
+	}
+	m.cancel()
+}
+
+// HealthCheck verifies that the Docker endpoint is reachable
+func (m *StandaloneManager) HealthCheck(ctx context.Context) error {
+	if _, err := m.client.Ping(ctx); err != nil {
+		return fmt.Errorf("failed to reach Docker: %w", err)
+	}
+	return nil
+}
+
+// DeployService creates the replicas of a new service
+func (m *StandaloneManager) DeployService(def config.ServiceDefinition) (string, error) {
+	ctx := context.Background()
+
+	if err := m.reserve(def.Name); err != nil {
+		return "", err
+	}
+	defer m.release(def.Name)
+	if len(def.Constraints) > 0 || len(def.Placement.Preferences) > 0 {
+		log.Warn("Placement settings are ignored by the standalone backend", "service", def.Name)
+	}
+
+	if err := m.pullImage(ctx, def.Image); err != nil {
+		return "", err
+	}
+
+	svc := &standaloneService{
+		id:       stringid.TruncateID(stringid.GenerateRandomID()),
+		def:      def,
+		revision: 1,
+	}
+	svc.opMu.Lock()
+	defer svc.opMu.Unlock()
+
+	var created []string
+	for slot := 1; slot <= def.Replicas; slot++ {
+		id, err := m.startReplica(ctx, svc.id, svc.def, svc.revision, slot)
+		if err != nil {
+			for _, containerID := range created {
+				m.removeContainer(ctx, containerID)
+			}
+			return "", fmt.Errorf("failed to start replica %d of %s: %w", slot, def.Name, err)
+		}
+		created = append(created, id)
+	}
+
+	m.servicesMu.Lock()
+	m.services[svc.id] = svc
+	m.servicesMu.Unlock()
+
+	return svc.id, nil
+}
+
+// UpdateService replaces the replicas of a service one at a time. Each new replica
+// has to become ready (healthy, or running for a few seconds when the service has
+// no healthcheck) before the old one is removed. If a replica fails, the service
+// is rolled back to its previous definition.
+func (m *StandaloneManager) UpdateService(serviceID string, def config.ServiceDefinition) error {
+	ctx := context.Background()
+
+	svc, err := m.lookup(serviceID)
+	if err != nil {
+		return err
+	}
+	svc.opMu.Lock()
+	defer svc.opMu.Unlock()
+
+	previous, _ := m.snapshot(svc)
+	if def.Replicas <= 0 {
+		def.Replicas = previous.Replicas
+	}
+	def.Name = previous.Name
+
+	if err := m.pullImage(ctx, def.Image); err != nil {
+		return err
+	}
+
+	current, err := m.replicas(ctx, svc.id)
+	if err != nil {
+		return err
+	}
+	bySlot := newestBySlot(current)
+
+	revision := m.setDefinition(svc, def, true)
+	for slot := 1; slot <= def.Replicas; slot++ {
+		id, err := m.startReplica(ctx, svc.id, def, revision, slot)
+		if err == nil {
+			if err = m.waitReady(ctx, id); err != nil {
+				m.removeContainer(ctx, id)
+			}
+		}
+		if err != nil {
+			log.Error("Replica failed during update, rolling back", "service", def.Name, "replica", slot, "error", err)
+			// A newer revision with the old definition replaces the replicas that were already updated
+			m.setDefinition(svc, previous, true)
+			if _, rollbackErr := m.reconcileService(ctx, svc); rollbackErr != nil {
+				log.Error("Failed to roll back service", "service", def.Name, "error", rollbackErr)
+			}
+			return fmt.Errorf("update of %s failed on replica %d and was rolled back: %w", def.Name, slot, err)
+		}
+
+		if old, ok := bySlot[slot]; ok {
+			m.removeContainer(ctx, old.container.ID)
+		}
+	}
+
+	// Remove replicas beyond the new count
+	for slot, old := range bySlot {
+		if slot > def.Replicas {
+			m.removeContainer(ctx, old.container.ID)
+		}
+	}
+
+	return nil
+}
+
+// ScaleService starts or removes replicas so the service has the given count
+func (m *StandaloneManager) ScaleService(serviceID string, replicas int) error {
+	if replicas < 0 {
+		return fmt.Errorf("invalid replica count %d", replicas)
+	}
+
+	svc, err := m.lookup(serviceID)
+	if err != nil {
+		return err
+	}
+	svc.opMu.Lock()
+	defer svc.opMu.Unlock()
+
+	def, _ := m.snapshot(svc)
+	def.Replicas = replicas
+	m.setDefinition(svc, def, false)
+
+	_, err = m.reconcileService(context.Background(), svc)
+	return err
+}
+
+// RemoveService removes a service and all of its containers
+func (m *StandaloneManager) RemoveService(serviceID string) error {
+	ctx := context.Background()
+
+	svc, err := m.lookup(serviceID)
+	if err != nil {
+		return err
+	}
+	svc.opMu.Lock()
+	defer svc.opMu.Unlock()
+
+	current, err := m.replicas(ctx, svc.id)
+	if err != nil {
+		return err
+	}
+	for _, r := range current {
+		if err := m.client.ContainerRemove(ctx, r.container.ID, container.RemoveOptions{Force: true}); err != nil {
+			return fmt.Errorf("failed to remove container %s: %w", r.container.ID, err)
+		}
+	}
+
+	m.servicesMu.Lock()
+	delete(m.services, svc.id)
+	m.servicesMu.Unlock()
+
+	return nil
+}
+
+// GetServiceStatus returns the status of a service from the state of its containers
+func (m *StandaloneManager) GetServiceStatus(serviceID string) (config.DeploymentStatus, error) {
+	svc, err := m.lookup(serviceID)
+	if err != nil {
+		return config.DeploymentStatus{}, err
+	}
+
+	current, err := m.replicas(context.Background(), svc.id)
+	if err != nil {
+		return config.DeploymentStatus{}, err
+	}
+
+	def, _ := m.snapshot(svc)
+	return config.DeploymentStatus{
+		ID:      svc.id,
+		State:   serviceState(def, current),
+		Running: runningReplicas(def, current),
+		Service: def,
+	}, nil
+}
+
+// ListServices returns the status of all services
+func (m *StandaloneManager) ListServices() ([]config.DeploymentStatus, error) {
+	m.servicesMu.RLock()
+	ids := make([]string, 0, len(m.services))
+	for id := range m.services {
+		ids = append(ids, id)
+	}
+	m.servicesMu.RUnlock()
+
+	result := make([]config.DeploymentStatus, 0, len(ids))
+	for _, id := range ids {
+		status, err := m.GetServiceStatus(id)
+		if err != nil {
+			log.Warn("Failed to get status for service", "serviceID", id, "error", err)
+			continue
+		}
+		result = append(result, status)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Service.Name < result[j].Service.Name })
+	return result, nil
+}
+
+// reserve claims a service name for a deploy, failing if the service exists
+// or is being deployed already
+func (m *StandaloneManager) reserve(name string) error {
+	m.servicesMu.Lock()
+	defer m.servicesMu.Unlock()
+
+	if m.deploying[name] {
+		return fmt.Errorf("service %s is already being deployed", name)
+	}
+	for _, svc := range m.services {
+		if svc.def.Name == name {
+			return fmt.Errorf("service %s already exists", name)
+		}
+	}
+	m.deploying[name] = true
+	return nil
+}
+
+// release gives up the claim on a service name, once the deploy added the
+// service or failed
+func (m *StandaloneManager) release(name string) {
+	m.servicesMu.Lock()
+	defer m.servicesMu.Unlock()
+	delete(m.deploying, name)
+}
+
+// lookup finds a service by ID or name
+func (m *StandaloneManager) lookup(ref string) (*standaloneService, error) {
+	m.servicesMu.RLock()
+	defer m.servicesMu.RUnlock()
+
+	if svc, ok := m.services[ref]; ok {
+		return svc, nil
+	}
+	for _, svc := range m.services {
+		if svc.def.Name == ref {
+			return svc, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrServiceNotFound, ref)
+}
+
+// snapshot returns a service's current definition and revision
+func (m *StandaloneManager) snapshot(svc *standaloneService) (config.ServiceDefinition, int) {
+	m.servicesMu.RLock()
+	defer m.servicesMu.RUnlock()
+	return svc.def, svc.revision
+}
+
+// setDefinition changes a service's definition, optionally starting a new revision
+func (m *StandaloneManager) setDefinition(svc *standaloneService, def config.ServiceDefinition, newRevision bool) int {
+	m.servicesMu.Lock()
+	defer m.servicesMu.Unlock()
+	svc.def = def
+	if newRevision {
+		svc.revision++
+	}
+	return svc.revision
+}
+
+// loadServices rebuilds the service list from the labels of existing containers
+func (m *StandaloneManager) loadServices() error {
+	containers, err := m.client.ContainerList(m.ctx, container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", labelServiceID)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	slots := make(map[string]map[string]struct{})
+	m.servicesMu.Lock()
+	defer m.servicesMu.Unlock()
+
+	for _, c := range containers {
+		id := c.Labels[labelServiceID]
+		revision, _ := strconv.Atoi(c.Labels[labelRevision])
+
+		if slots[id] == nil {
+			slots[id] = make(map[string]struct{})
+		}
+		slots[id][c.Labels[labelReplica]] = struct{}{}
+
+		if svc, ok := m.services[id]; ok && svc.revision >= revision {
+			continue
+		}
+		var def config.ServiceDefinition
+		if err := json.Unmarshal([]byte(c.Labels[labelSpec]), &def); err != nil {
+			log.Warn("Ignoring container with unreadable service definition", "container", c.ID, "error", err)
+			continue
+		}
+		if def.Environment, err = m.containerEnvironment(c.ID, c.Labels[labelEnv]); err != nil {
+			log.Warn("Ignoring container with unreadable environment", "container", c.ID, "error", err)
+			continue
+		}
+		m.services[id] = &standaloneService{id: id, def: def, revision: revision}
+	}
+
+	// Scaling doesn't rewrite existing containers, so the replica count comes
+	// from the slots that are still around
+	for id, svc := range m.services {
+		svc.def.Replicas = len(slots[id])
+	}
+
+	return nil
+}
+
+// containerEnvironment reads the service environment variables named by a
+// velo.env label back from a container's Env, leaving out those of the image
+func (m *StandaloneManager) containerEnvironment(containerID, names string) (map[string]string, error) {
+	var keys []string
+	if names != "" {
+		if err := json.Unmarshal([]byte(names), &keys); err != nil {
+			return nil, err
+		}
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	inspect, err := m.client.ContainerInspect(m.ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+	env := make(map[string]string, len(keys))
+	for _, kv := range inspect.Config.Env {
+		key, value, _ := strings.Cut(kv, "=")
+		if slices.Contains(keys, key) {
+			env[key] = value
+		}
+	}
+	return env, nil
+}
+
+// reconcile brings the containers of every service in line with its definition
+func (m *StandaloneManager) reconcile() {
+	m.servicesMu.RLock()
+	services := make([]*standaloneService, 0, len(m.services))
+	for _, svc := range m.services {
+		services = append(services, svc)
+	}
+	m.servicesMu.RUnlock()
+
+	for _, svc := range services {
+		// Skip services that are being changed right now
+		if !svc.opMu.TryLock() {
+			continue
+		}
+		recreated, err := m.reconcileService(m.ctx, svc)
+		svc.opMu.Unlock()
+
+		def, _ := m.snapshot(svc)
+		if err != nil {
+			log.Error("Failed to reconcile service", "service", def.Name, "error", err)
+		}
+		for _, slot := range recreated {
+			log.Info("Recreated missing replica", "service", def.Name, "replica", slot)
+			if m.onReconcile != nil {
+				m.onReconcile(ReconcileAction{
+					Action:  "RecreateReplica",
+					Service: def.Name,
+					Params:  map[string]string{"replica": strconv.Itoa(slot), "image": def.Image},
+				})
+			}
+		}
+	}
+}
+
+// OnReconcile sets a function called for every replica the reconciler
+// recreates. It must be called before the manager starts.
+func (m *StandaloneManager) OnReconcile(fn func(ReconcileAction)) {
+	m.onReconcile = fn
+}
+
+// reconcileService recreates missing replicas, replaces replicas of older
+// revisions and removes replicas beyond the desired count. Replicas that exited
+// are left to Docker's restart policy. It returns the slots of the missing
+// replicas it recreated. Callers must hold svc.opMu.
+func (m *StandaloneManager) reconcileService(ctx context.Context, svc *standaloneService) ([]int, error) {
+	def, revision := m.snapshot(svc)
+
+	current, err := m.replicas(ctx, svc.id)
+	if err != nil {
+		return nil, err
+	}
+
+	newest := newestBySlot(current)
+	for _, r := range current {
+		if r.container.ID != newest[r.slot].container.ID || r.slot > def.Replicas {
+			m.removeContainer(ctx, r.container.ID)
+		}
+	}
+
+	var recreated []int
+	for slot := 1; slot <= def.Replicas; slot++ {
+		existing, ok := newest[slot]
+		if ok && existing.revision >= revision {
+			continue
+		}
+		if _, err := m.startReplica(ctx, svc.id, def, revision, slot); err != nil {
+			return recreated, fmt.Errorf("failed to start replica %d: %w", slot, err)
+		}
+		if ok {
+			m.removeContainer(ctx, existing.container.ID)
+		} else {
+			recreated = append(recreated, slot)
+		}
+	}
+
+	return recreated, nil
+}
+
+// replicas lists the containers of a service, sorted by slot and newest revision first
+func (m *StandaloneManager) replicas(ctx context.Context, serviceID string) ([]replica, error) {
+	containers, err := m.client.ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", labelServiceID+"="+serviceID)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	result := make([]replica, 0, len(containers))
+	for _, c := range containers {
+		slot, _ := strconv.Atoi(c.Labels[labelReplica])
+		revision, _ := strconv.Atoi(c.Labels[labelRevision])
+		result = append(result, replica{slot: slot, revision: revision, container: c})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].slot != result[j].slot {
+			return result[i].slot < result[j].slot
+		}
+		return result[i].revision > result[j].revision
+	})
+	return result, nil
+}
+
+// startReplica creates and starts the container for one slot of a service
+func (m *StandaloneManager) startReplica(ctx context.Context, serviceID string, def config.ServiceDefinition, revision, slot int) (string, error) {
+	cfg, hostCfg, netCfg, err := containerSpec(serviceID, def, revision, slot)
+	if err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("%s.%d.%s", def.Name, slot, stringid.TruncateID(stringid.GenerateRandomID()))
+	resp, err := m.client.ContainerCreate(ctx, cfg, hostCfg, netCfg, nil, name)
+	if err != nil {
+		return "", fmt.Errorf("failed to create container: %w", err)
+	}
+	for _, warning := range resp.Warnings {
+		log.Warn("Warning during container create", "container", name, "warning", warning)
+	}
+
+	if len(def.Networks) > 1 {
+		for _, net := range def.Networks[1:] {
+			if err := m.client.NetworkConnect(ctx, net, resp.ID, &network.EndpointSettings{Aliases: []string{def.Name}}); err != nil {
+				m.removeContainer(ctx, resp.ID)
+				return "", fmt.Errorf("failed to connect container to network %s: %w", net, err)
+			}
+		}
+	}
+
+	if err := m.client.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		m.removeContainer(ctx, resp.ID)
+		return "", fmt.Errorf("failed to start container: %w", err)
+	}
+
+	return resp.ID, nil
+}
+
+// waitReady waits until a new replica is ready or the update monitor period runs out
+func (m *StandaloneManager) waitReady(ctx context.Context, containerID string) error {
+	monitor := time.Duration(m.settings.UpdateMonitor) * time.Second
+	if monitor <= 0 {
+		monitor = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, monitor)
+	defer cancel()
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		inspect, err := m.client.ContainerInspect(ctx, containerID)
+		if err == nil {
+			ready, err := replicaReady(inspect.State, time.Now())
+			if err != nil {
+				return err
+			}
+			if ready {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("replica did not become ready within %s", monitor)
+		case <-ticker.C:
+		}
+	}
+}
+
+// removeContainer stops and removes a container, logging failures
+func (m *StandaloneManager) removeContainer(ctx context.Context, containerID string) {
+	timeout := m.settings.StopTimeout
+	if err := m.client.ContainerStop(ctx, containerID, container.StopOptions{Timeout: &timeout}); err != nil {
+		log.Warn("Failed to stop container", "container", containerID, "error", err)
+	}
+	if err := m.client.ContainerRemove(ctx, containerID, container.RemoveOptions{Force: true}); err != nil {
+		log.Warn("Failed to remove container", "container", containerID, "error", err)
+	}
+}
+
+// pullImage pulls an image, falling back to a local copy if the pull fails
+func (m *StandaloneManager) pullImage(ctx context.Context, ref string) error {
+	reader, err := m.client.ImagePull(ctx, ref, image.PullOptions{})
+	if err == nil {
+		defer reader.Close()
+		err = jsonmessage.DisplayJSONMessagesStream(reader, io.Discard, 0, false, nil)
+	}
+	if err == nil {
+		return nil
+	}
+
+	// Images built on this host can't be pulled
+	if _, inspectErr := m.client.ImageInspect(ctx, ref); inspectErr == nil {
+		log.Warn("Failed to pull image, using local copy", "image", ref, "error", err)
+		return nil
+	}
+	return fmt.Errorf("failed to pull image %s: %w", ref, err)
+}
+
+// containerSpec builds the container configuration for one slot of a service
+func containerSpec(serviceID string, def config.ServiceDefinition, revision, slot int) (*container.Config, *container.HostConfig, *network.NetworkingConfig, error) {
+	// Labels can be read by anyone who can inspect the container, so the
+	// environment, which often holds secrets, only goes into its Env
+	labelled := def
+	labelled.Environment = nil
+	spec, err := json.Marshal(labelled)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to encode service definition: %w", err)
+	}
+	envNames, err := json.Marshal(slices.Sorted(maps.Keys(def.Environment)))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to encode environment names: %w", err)
+	}
+
+	labels := make(map[string]string, len(def.Labels)+6)
+	for key, value := range def.Labels {
+		labels[key] = value
+	}
+	for key, value := range containerLabels(def) {
+		labels[key] = value
+	}
+	labels[labelServiceID] = serviceID
+	labels[labelServiceName] = def.Name
+	labels[labelReplica] = strconv.Itoa(slot)
+	labels[labelRevision] = strconv.Itoa(revision)
+	labels[labelSpec] = string(spec)
+	labels[labelEnv] = string(envNames)
+
+	cfg := &container.Config{
+		Image:       def.Image,
+		Env:         def.ToEnv(),
+		Labels:      labels,
+		Healthcheck: buildHealthcheck(def.HealthCheck),
+	}
+
+	hostCfg := &container.HostConfig{
+		RestartPolicy: containerRestartPolicy(def.Restart),
+		Resources: container.Resources{
+			NanoCPUs:          int64(def.Resources.CPULimit * 1e9),
+			Memory:            def.Resources.MemoryLimit,
+			MemoryReservation: def.Resources.MemoryReserve,
+		},
+		Mounts: buildMounts(def.Volumes),
+	}
+
+	var netCfg *network.NetworkingConfig
+	if len(def.Networks) > 0 {
+		netCfg = &network.NetworkingConfig{
+			EndpointsConfig: map[string]*network.EndpointSettings{
+				def.Networks[0]: {Aliases: []string{def.Name}},
+			},
+		}
+	}
+
+	return cfg, hostCfg, netCfg, nil
+}
+
+// containerRestartPolicy maps a service's restart policy to a Docker restart
+// policy. Docker only limits attempts for on-failure and has no restart delay.
+func containerRestartPolicy(policy config.RestartPolicy) container.RestartPolicy {
+	switch policy.Condition {
+	case config.RestartNone:
+		return container.RestartPolicy{Name: container.RestartPolicyDisabled}
+	case config.RestartOnFailure:
+		return container.RestartPolicy{Name: container.RestartPolicyOnFailure, MaximumRetryCount: int(policy.MaxAttempts)}
+	default:
+		// Same default as swarm: restart on any exit
+		return container.RestartPolicy{Name: container.RestartPolicyAlways}
+	}
+}
+
+// buildHealthcheck maps a service's healthcheck to a container healthcheck
+func buildHealthcheck(hc config.HealthCheckConfig) *container.HealthConfig {
+	if len(hc.Command) == 0 {
+		return nil
+	}
+	return &container.HealthConfig{
+		Test:        hc.Command,
+		Interval:    time.Duration(hc.Interval) * time.Second,
+		Timeout:     time.Duration(hc.Timeout) * time.Second,
+		StartPeriod: time.Duration(hc.StartPeriod) * time.Second,
+		Retries:     hc.Retries,
+	}
+}
+
+// buildMounts maps volume mounts to container mounts. Absolute and relative
+// paths are bind mounts, anything else is a named volume.
+func buildMounts(volumes []config.VolumeMount) []mount.Mount {
+	mounts := make([]mount.Mount, 0, len(volumes))
+	for _, v := range volumes {
+		mountType := mount.TypeVolume
+		if v.IsBind() {
+			mountType = mount.TypeBind
+		}
+		mounts = append(mounts, mount.Mount{
+			Type:     mountType,
+			Source:   v.Source,
+			Target:   v.Destination,
+			ReadOnly: v.ReadOnly,
+		})
+	}
+	return mounts
+}
+
+// replicaReady reports whether a new replica is ready to take over, or failed
+func replicaReady(state *container.State, now time.Time) (bool, error) {
+	if state == nil {
+		return false, nil
+	}
+	if state.Status == "exited" || state.Dead {
+		if state.OOMKilled {
+			return false, fmt.Errorf("replica was OOM killed")
+		}
+		return false, fmt.Errorf("replica exited with code %d", state.ExitCode)
+	}
+	if !state.Running || state.Restarting {
+		return false, nil
+	}
+
+	if state.Health != nil {
+		switch state.Health.Status {
+		case container.Healthy:
+			return true, nil
+		case container.Unhealthy:
+			return false, fmt.Errorf("replica is unhealthy")
+		default:
+			return false, nil
+		}
+	}
+
+	started, err := time.Parse(time.RFC3339Nano, state.StartedAt)
+	if err != nil {
+		return false, nil
+	}
+	return now.Sub(started) >= replicaSettleTime, nil
+}
+
+// newestBySlot returns the newest replica of every slot
+func newestBySlot(replicas []replica) map[int]replica {
+	result := make(map[int]replica)
+	for _, r := range replicas {
+		if existing, ok := result[r.slot]; !ok || r.revision > existing.revision {
+			result[r.slot] = r
+		}
+	}
+	return result
+}
+
+// runningReplicas counts the slots of a service whose newest replica is running
+// and not unhealthy
+func runningReplicas(def config.ServiceDefinition, replicas []replica) int {
+	running := 0
+	for slot, r := range newestBySlot(replicas) {
+		if slot <= def.Replicas && r.container.State == "running" && !strings.Contains(r.container.Status, "(unhealthy)") {
+			running++
+		}
+	}
+	return running
+}
+
+// serviceState derives a service's state from its containers: running once
+// every replica runs, failed when replicas have exited with an error and none
+// are running, and pending otherwise
+func serviceState(def config.ServiceDefinition, replicas []replica) string {
+	running, failed := 0, 0
+	for slot, r := range newestBySlot(replicas) {
+		if slot > def.Replicas {
+			continue
+		}
+		switch r.container.State {
+		case "running":
+			if !strings.Contains(r.container.Status, "(unhealthy)") {
+				running++
+			}
+		case "exited", "dead":
+			if !strings.HasPrefix(r.container.Status, "Exited (0)") {
+				failed++
+			}
+		}
+	}
+
+	switch {
+	case def.Replicas > 0 && running >= def.Replicas:
+		return "running"
+	case running == 0 && failed > 0:
+		return "failed"
+	default:
+		return "pending"
+	}
+}
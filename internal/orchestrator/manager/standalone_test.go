@@ -0,0 +1,279 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/jasonlovesdoggo/velo/internal/config"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/dockertest"
+)
+
+func TestContainerSpec(t *testing.T) {
+	def := config.ServiceDefinition{
+		Name:        "web",
+		Image:       "nginx:1.27",
+		Replicas:    2,
+		Labels:      map[string]string{"app": "web"},
+		Environment: map[string]string{"PORT": "80"},
+		Networks:    []string{"frontend", "backend"},
+		Volumes: []config.VolumeMount{
+			{Source: "/srv/web", Destination: "/usr/share/nginx/html", ReadOnly: true},
+			{Source: "web-cache", Destination: "/cache"},
+		},
+		Resources:   config.ResourceConfig{CPULimit: 0.5, MemoryLimit: 256 << 20},
+		HealthCheck: config.HealthCheckConfig{Command: []string{"CMD", "true"}, Interval: 5, Retries: 3, OnFailure: config.HealthPolicyRestart},
+		Restart:     config.RestartPolicy{Condition: config.RestartOnFailure, MaxAttempts: 4},
+	}
+
+	cfg, hostCfg, netCfg, err := containerSpec("svc1", def, 3, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if cfg.Labels[labelServiceID] != "svc1" || cfg.Labels[labelReplica] != "2" || cfg.Labels[labelRevision] != "3" || cfg.Labels["app"] != "web" ||
+		cfg.Labels[config.HealthPolicyLabel] != config.HealthPolicyRestart {
+		t.Errorf("Unexpected labels %v", cfg.Labels)
+	}
+	var decoded config.ServiceDefinition
+	if err := json.Unmarshal([]byte(cfg.Labels[labelSpec]), &decoded); err != nil || decoded.Image != def.Image {
+		t.Errorf("Expected service definition in labels, got %q (%v)", cfg.Labels[labelSpec], err)
+	}
+	if decoded.Environment != nil || cfg.Labels[labelEnv] != `["PORT"]` || len(cfg.Env) != 1 || cfg.Env[0] != "PORT=80" {
+		t.Errorf("Expected the environment only in the container's Env, got labels %v and Env %v", cfg.Labels, cfg.Env)
+	}
+	if cfg.Healthcheck == nil || cfg.Healthcheck.Interval != 5*time.Second {
+		t.Errorf("Unexpected healthcheck %+v", cfg.Healthcheck)
+	}
+
+	if hostCfg.RestartPolicy.Name != container.RestartPolicyOnFailure || hostCfg.RestartPolicy.MaximumRetryCount != 4 {
+		t.Errorf("Unexpected restart policy %+v", hostCfg.RestartPolicy)
+	}
+	if hostCfg.NanoCPUs != 5e8 || hostCfg.Memory != 256<<20 {
+		t.Errorf("Unexpected resources %+v", hostCfg.Resources)
+	}
+	if len(hostCfg.Mounts) != 2 || hostCfg.Mounts[0].Type != mount.TypeBind || hostCfg.Mounts[1].Type != mount.TypeVolume {
+		t.Errorf("Unexpected mounts %+v", hostCfg.Mounts)
+	}
+
+	if netCfg == nil || len(netCfg.EndpointsConfig) != 1 || netCfg.EndpointsConfig["frontend"] == nil {
+		t.Errorf("Expected only the first network at create time, got %+v", netCfg)
+	}
+}
+
+func TestContainerRestartPolicy(t *testing.T) {
+	tests := []struct {
+		condition string
+		want      container.RestartPolicyMode
+	}{
+		{"", container.RestartPolicyAlways},
+		{config.RestartAny, container.RestartPolicyAlways},
+		{config.RestartOnFailure, container.RestartPolicyOnFailure},
+		{config.RestartNone, container.RestartPolicyDisabled},
+	}
+
+	for _, tt := range tests {
+		if got := containerRestartPolicy(config.RestartPolicy{Condition: tt.condition}); got.Name != tt.want {
+			t.Errorf("containerRestartPolicy(%q) = %q, want %q", tt.condition, got.Name, tt.want)
+		}
+	}
+}
+
+func TestReplicaReady(t *testing.T) {
+	now := time.Now()
+	startedAt := func(ago time.Duration) string { return now.Add(-ago).Format(time.RFC3339Nano) }
+
+	tests := []struct {
+		name      string
+		state     *container.State
+		wantReady bool
+		wantErr   bool
+	}{
+		{
+			name:  "Just started",
+			state: &container.State{Status: "running", Running: true, StartedAt: startedAt(time.Second)},
+		},
+		{
+			name:      "Running long enough",
+			state:     &container.State{Status: "running", Running: true, StartedAt: startedAt(10 * time.Second)},
+			wantReady: true,
+		},
+		{
+			name:    "Exited",
+			state:   &container.State{Status: "exited", ExitCode: 1},
+			wantErr: true,
+		},
+		{
+			name: "Health starting",
+			state: &container.State{Status: "running", Running: true, StartedAt: startedAt(time.Minute),
+				Health: &container.Health{Status: container.Starting}},
+		},
+		{
+			name: "Healthy",
+			state: &container.State{Status: "running", Running: true, StartedAt: startedAt(time.Second),
+				Health: &container.Health{Status: container.Healthy}},
+			wantReady: true,
+		},
+		{
+			name: "Unhealthy",
+			state: &container.State{Status: "running", Running: true,
+				Health: &container.Health{Status: container.Unhealthy}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ready, err := replicaReady(tt.state, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error=%v, got %v", tt.wantErr, err)
+			}
+			if ready != tt.wantReady {
+				t.Errorf("Expected ready=%v, got %v", tt.wantReady, ready)
+			}
+		})
+	}
+}
+
+func TestServiceState(t *testing.T) {
+	rep := func(slot, revision int, state, status string) replica {
+		return replica{slot: slot, revision: revision, container: container.Summary{
+			ID: state + status, State: state, Status: status,
+		}}
+	}
+	def := config.ServiceDefinition{Name: "web", Replicas: 2}
+
+	tests := []struct {
+		name     string
+		replicas []replica
+		want     string
+		running  int
+	}{
+		{"No containers", nil, "pending", 0},
+		{"All running", []replica{rep(1, 1, "running", "Up 1 minute"), rep(2, 1, "running", "Up 1 minute")}, "running", 2},
+		{"One restarting", []replica{rep(1, 1, "running", "Up 1 minute"), rep(2, 1, "restarting", "Restarting (1)")}, "pending", 1},
+		{"Unhealthy replicas don't count", []replica{rep(1, 1, "running", "Up (unhealthy)"), rep(2, 1, "running", "Up")}, "pending", 1},
+		{"All failed", []replica{rep(1, 1, "exited", "Exited (1) 5 seconds ago"), rep(2, 1, "exited", "Exited (137)")}, "failed", 0},
+		{"Newest revision wins", []replica{rep(1, 2, "running", "Up"), rep(1, 1, "exited", "Exited (1)"), rep(2, 1, "running", "Up")}, "running", 2},
+		{"Slots past the replica count", []replica{rep(1, 1, "running", "Up"), rep(2, 1, "running", "Up"), rep(3, 1, "running", "Up")}, "running", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serviceState(def, tt.replicas); got != tt.want {
+				t.Errorf("serviceState() = %q, want %q", got, tt.want)
+			}
+			if got := runningReplicas(def, tt.replicas); got != tt.running {
+				t.Errorf("runningReplicas() = %d, want %d", got, tt.running)
+			}
+		})
+	}
+}
+
+func TestReserveServiceName(t *testing.T) {
+	m := NewStandaloneManagerWithClient(nil, config.StandaloneConfig{})
+	m.services["abc"] = &standaloneService{id: "abc", def: config.ServiceDefinition{Name: "db"}}
+
+	if err := m.reserve("db"); err == nil {
+		t.Error("Expected an existing service's name not to be reserved")
+	}
+	if err := m.reserve("web"); err != nil {
+		t.Fatalf("reserve failed: %v", err)
+	}
+	if err := m.reserve("web"); err == nil {
+		t.Error("Expected a concurrent deploy of the same name to fail")
+	}
+	m.release("web")
+	if err := m.reserve("web"); err != nil {
+		t.Errorf("Expected a released name to be reserved again, got %v", err)
+	}
+}
+
+func TestStandaloneLifecycle(t *testing.T) {
+	srv := dockertest.NewServer(dockertest.Options{})
+	defer srv.Close()
+	cli, err := srv.Client()
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer cli.Close()
+
+	settings := config.StandaloneConfig{ReconcileInterval: 3600, UpdateMonitor: 5, StopTimeout: 1}
+	m := NewStandaloneManagerWithClient(cli, settings)
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer m.Stop()
+
+	healthCheck := config.HealthCheckConfig{Command: []string{"CMD", "true"}, Interval: 1}
+	id, err := m.DeployService(config.ServiceDefinition{Name: "web", Image: "nginx:1", Replicas: 2, HealthCheck: healthCheck})
+	if err != nil {
+		t.Fatalf("DeployService failed: %v", err)
+	}
+	if status, _ := m.GetServiceStatus(id); status.State != "running" {
+		t.Fatalf("Expected running service, got %s", status.State)
+	}
+
+	env := map[string]string{"DB_PASSWORD": "hunter2"}
+	if err := m.UpdateService(id, config.ServiceDefinition{Image: "nginx:2", Environment: env, HealthCheck: healthCheck}); err != nil {
+		t.Fatalf("UpdateService failed: %v", err)
+	}
+	containers := srv.Containers()
+	if len(containers) != 2 {
+		t.Fatalf("Expected 2 containers after update, got %d", len(containers))
+	}
+	for _, c := range containers {
+		if c.Config.Image != "nginx:2" || c.Config.Labels[labelRevision] != "2" {
+			t.Errorf("Expected updated replica, got %s at revision %s", c.Config.Image, c.Config.Labels[labelRevision])
+		}
+	}
+
+	// An image that can't be pulled leaves the service untouched
+	srv.FailImage("nginx:broken", "")
+	if err := m.UpdateService(id, config.ServiceDefinition{Image: "nginx:broken", Environment: env}); err == nil {
+		t.Error("Expected update to an unknown image to fail")
+	}
+	if status, _ := m.GetServiceStatus(id); status.Service.Image != "nginx:2" {
+		t.Errorf("Expected service to keep nginx:2, got %s", status.Service.Image)
+	}
+
+	// Reconciling recreates a replica that was removed behind the manager's back
+	if err := cli.ContainerRemove(context.Background(), containers[0].ID, container.RemoveOptions{Force: true}); err != nil {
+		t.Fatalf("ContainerRemove failed: %v", err)
+	}
+	m.reconcile()
+	if n := len(srv.Containers()); n != 2 {
+		t.Errorf("Expected reconcile to recreate the missing replica, got %d container(s)", n)
+	}
+
+	// A new manager picks the service up from its containers
+	restarted := NewStandaloneManagerWithClient(cli, settings)
+	if err := restarted.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer restarted.Stop()
+	if status, err := restarted.GetServiceStatus("web"); err != nil || status.Service.Replicas != 2 {
+		t.Errorf("Expected restarted manager to load the service, got %+v (%v)", status, err)
+	}
+	if status, _ := restarted.GetServiceStatus("web"); status.Service.Environment["DB_PASSWORD"] != "hunter2" {
+		t.Errorf("Expected the environment to be read back from the containers, got %v", status.Service.Environment)
+	}
+	for _, c := range srv.Containers() {
+		for key, value := range c.Config.Labels {
+			if strings.Contains(value, "hunter2") {
+				t.Errorf("Expected no secrets in labels, found one in %s", key)
+			}
+		}
+	}
+
+	if err := m.RemoveService(id); err != nil {
+		t.Fatalf("RemoveService failed: %v", err)
+	}
+	if n := len(srv.Containers()); n != 0 {
+		t.Errorf("Expected no containers after remove, got %d", n)
+	}
+}
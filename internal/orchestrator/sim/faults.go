@@ -0,0 +1,89 @@
+package sim
+
+import "github.com/docker/docker/api/types/swarm"
+
+// Operations that can be made to fail with InjectError
+const (
+	OpDeploy = "DeployService"
+	OpUpdate = "UpdateService"
+	OpScale  = "ScaleService"
+	OpRemove = "RemoveService"
+	OpStatus = "GetServiceStatus"
+	OpDrain  = "DrainNodeAndWait"
+	OpRunJob = "RunJob"
+)
+
+// faults holds the failures injected into the simulation
+type faults struct {
+	imagePulls map[string]string    // image -> failure reason
+	errors     map[string][]error   // operation -> errors to return, in order
+	jobs       map[string]JobResult // command -> what jobs running it print and exit with
+}
+
+func newFaults() faults {
+	return faults{
+		imagePulls: make(map[string]string),
+		errors:     make(map[string][]error),
+		jobs:       make(map[string]JobResult),
+	}
+}
+
+// take returns the next injected error for an operation, if any
+func (f *faults) take(op string) error {
+	queued := f.errors[op]
+	if len(queued) == 0 {
+		return nil
+	}
+	f.errors[op] = queued[1:]
+	return queued[0]
+}
+
+// InjectError makes the next call of op return err. Errors queue up when
+// injected more than once.
+func (o *Orchestrator) InjectError(op string, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.faults.errors[op] = append(o.faults.errors[op], err)
+}
+
+// FailImagePulls makes every task using image fail while preparing, until
+// the failure is cleared
+func (o *Orchestrator) FailImagePulls(image, reason string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if reason == "" {
+		reason = "manifest unknown"
+	}
+	o.faults.imagePulls[image] = reason
+}
+
+// ClearFaults removes all injected failures
+func (o *Orchestrator) ClearFaults() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.faults = newFaults()
+}
+
+// CrashTasks fails up to n running tasks of a service, as if their containers
+// exited with an error. It returns how many tasks were crashed.
+func (o *Orchestrator) CrashTasks(serviceRef string, n int) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	svc, err := o.lookup(serviceRef)
+	if err != nil {
+		return 0, err
+	}
+
+	crashed := 0
+	for _, t := range o.tasks {
+		if crashed == n {
+			break
+		}
+		if t.ServiceID == svc.id && !terminal(t) && t.Status.State == swarm.TaskStateRunning {
+			o.setState(svc, t, swarm.TaskStateFailed, "task: non-zero exit (1)", true)
+			crashed++
+		}
+	}
+	return crashed, nil
+}
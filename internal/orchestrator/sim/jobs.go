@@ -0,0 +1,54 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/manager"
+)
+
+// JobResult is what a simulated job prints and exits with
+type JobResult struct {
+	ExitCode int
+	Output   string
+}
+
+// SetJobResult makes jobs running command print output and exit with the
+// result's code, until the faults are cleared. Other jobs succeed silently.
+func (o *Orchestrator) SetJobResult(command []string, result JobResult) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.faults.jobs[strings.Join(command, " ")] = result
+}
+
+// Jobs returns the jobs that were run, oldest first
+func (o *Orchestrator) Jobs() []manager.Job {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]manager.Job(nil), o.jobs...)
+}
+
+// RunJob runs a simulated job, which finishes immediately
+func (o *Orchestrator) RunJob(ctx context.Context, job manager.Job, output io.Writer) (int, error) {
+	o.mu.Lock()
+	if err := o.faults.take(OpRunJob); err != nil {
+		o.mu.Unlock()
+		return 0, err
+	}
+	if reason, ok := o.faults.imagePulls[job.Image]; ok {
+		o.mu.Unlock()
+		return 0, fmt.Errorf("failed to pull image %s: %s", job.Image, reason)
+	}
+	o.jobs = append(o.jobs, job)
+	result := o.faults.jobs[strings.Join(job.Command, " ")]
+	o.mu.Unlock()
+
+	if result.Output != "" {
+		if _, err := io.WriteString(output, result.Output); err != nil {
+			return 0, err
+		}
+	}
+	return result.ExitCode, ctx.Err()
+}
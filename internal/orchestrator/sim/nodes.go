@@ -0,0 +1,288 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/jasonlovesdoggo/velo/internal/config"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/manager"
+	"github.com/jasonlovesdoggo/velo/pkg/core/node"
+)
+
+// GetNodes returns all nodes in the simulated cluster
+func (o *Orchestrator) GetNodes() []node.Info {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	nodes := make([]node.Info, len(o.nodes))
+	copy(nodes, o.nodes)
+	return nodes
+}
+
+// AddNode adds a node to the simulated cluster
+func (o *Orchestrator) AddNode(n node.Info) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if n.Availability == "" {
+		n.Availability = string(swarm.NodeAvailabilityActive)
+	}
+	if len(n.Conditions) == 0 {
+		n.Conditions = []string{string(swarm.NodeStateReady)}
+	}
+	o.nodes = append(o.nodes, n)
+}
+
+// GetJoinToken returns a fake swarm join token; nodes can't actually join the simulation
+func (o *Orchestrator) GetJoinToken(isManager bool) (string, error) {
+	if isManager {
+		return "SWMTKN-1-sim-manager", nil
+	}
+	return "SWMTKN-1-sim-worker", nil
+}
+
+// ManagerAddresses returns the addresses of the simulated manager nodes
+func (o *Orchestrator) ManagerAddresses() ([]string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var addrs []string
+	for _, n := range o.nodes {
+		if n.Manager {
+			addrs = append(addrs, n.Address+":2377")
+		}
+	}
+	return addrs, nil
+}
+
+// SetNodeDown marks a node as down or ready again. Tasks on a node that goes
+// down fail on the next step and are rescheduled elsewhere.
+func (o *Orchestrator) SetNodeDown(nodeRef string, down bool) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	i, err := o.resolveNode(nodeRef)
+	if err != nil {
+		return err
+	}
+	state := swarm.NodeStateReady
+	if down {
+		state = swarm.NodeStateDown
+	}
+	o.nodes[i].Conditions = []string{string(state)}
+	return nil
+}
+
+// ActivateNode makes a node available for scheduling again
+func (o *Orchestrator) ActivateNode(nodeRef string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	i, err := o.resolveNode(nodeRef)
+	if err != nil {
+		return fmt.Errorf("failed to inspect node: %w", err)
+	}
+	o.nodes[i].Availability = string(swarm.NodeAvailabilityActive)
+	return nil
+}
+
+// DrainNodeAndWait drains a node and steps the simulation until its tasks run elsewhere
+func (o *Orchestrator) DrainNodeAndWait(ctx context.Context, nodeRef string, opts manager.DrainOptions) (manager.DrainReport, error) {
+	start := o.clock.Now()
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 100 * time.Millisecond
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	progress := func(p manager.DrainProgress) {
+		if opts.Progress != nil {
+			opts.Progress(p)
+		}
+	}
+
+	o.mu.Lock()
+	if err := o.faults.take(OpDrain); err != nil {
+		o.mu.Unlock()
+		return manager.DrainReport{}, err
+	}
+	i, err := o.resolveNode(nodeRef)
+	if err != nil {
+		o.mu.Unlock()
+		return manager.DrainReport{}, fmt.Errorf("failed to inspect node: %w", err)
+	}
+	nodeID, hostname := o.nodes[i].ID, o.nodes[i].Hostname
+	report := manager.DrainReport{NodeID: nodeID}
+
+	progress(manager.DrainProgress{NodeID: nodeID, Phase: manager.DrainPhaseChecking, Message: "Checking capacity on remaining nodes"})
+
+	tasks := o.liveTasks()
+	if err := manager.CheckDrainCapacity(nodeID, o.nodes, tasks); err != nil {
+		if !opts.Force {
+			o.mu.Unlock()
+			return report, err
+		}
+		report.Warnings = append(report.Warnings, fmt.Sprintf("capacity check failed, draining anyway: %v", err))
+	}
+
+	moving := 0
+	services := make(map[string]struct{})
+	for _, t := range tasks {
+		if t.NodeID == nodeID {
+			moving++
+			services[t.ServiceID] = struct{}{}
+		}
+	}
+	for id := range services {
+		report.Services = append(report.Services, id)
+	}
+	sort.Strings(report.Services)
+
+	progress(manager.DrainProgress{NodeID: nodeID, Phase: manager.DrainPhaseDraining, Total: moving, Remaining: moving,
+		Message: fmt.Sprintf("Draining node %s", hostname), Warnings: report.Warnings})
+
+	o.nodes[i].Availability = string(swarm.NodeAvailabilityDrain)
+	o.mu.Unlock()
+
+	last := manager.DrainProgress{Remaining: -1, Pending: -1}
+	for {
+		o.Step()
+		remaining, pending := o.drainState(nodeID, report.Services)
+
+		if remaining != last.Remaining || pending != last.Pending {
+			last = manager.DrainProgress{
+				NodeID:    nodeID,
+				Phase:     manager.DrainPhaseWaiting,
+				Total:     moving,
+				Remaining: remaining,
+				Pending:   pending,
+				Message:   fmt.Sprintf("%d task(s) left on node, %d replica(s) not yet running elsewhere", remaining, pending),
+			}
+			progress(last)
+		}
+
+		if remaining == 0 && pending == 0 {
+			report.Moved = moving
+			report.Duration = o.clock.Now().Sub(start)
+			progress(manager.DrainProgress{NodeID: nodeID, Phase: manager.DrainPhaseComplete, Total: moving,
+				Message: fmt.Sprintf("Node drained, %d task(s) moved", moving)})
+			return report, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return report, fmt.Errorf("timed out waiting for node to drain (%d task(s) left on node, %d replica(s) pending): %w",
+				remaining, pending, ctx.Err())
+		case <-time.After(opts.PollInterval):
+		}
+	}
+}
+
+// drainState returns how many tasks are still on the node and how many replicas
+// of the affected services are not yet running
+func (o *Orchestrator) drainState(nodeID string, serviceIDs []string) (int, int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	remaining := 0
+	running := make(map[string]int)
+	for _, t := range o.tasks {
+		if terminal(t) {
+			continue
+		}
+		if t.NodeID == nodeID {
+			remaining++
+		} else if t.Status.State == swarm.TaskStateRunning {
+			running[t.ServiceID]++
+		}
+	}
+
+	pending := 0
+	for _, id := range serviceIDs {
+		if svc, ok := o.services[id]; ok && running[id] < svc.def.Replicas {
+			pending += svc.def.Replicas - running[id]
+		}
+	}
+	return remaining, pending
+}
+
+// RebalanceServices rolls every replicated service so its tasks spread over all active nodes
+func (o *Orchestrator) RebalanceServices(ctx context.Context) ([]string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var updated []string
+	for _, svc := range o.services {
+		if svc.def.Replicas < 2 {
+			continue
+		}
+		svc.version++
+		o.event(svc, "service rebalanced (version %d)", svc.version)
+		o.reconcileService(svc)
+		updated = append(updated, svc.def.Name)
+	}
+	sort.Strings(updated)
+	return updated, nil
+}
+
+// CapacityReport returns the reserved resources and headroom of every node
+func (o *Orchestrator) CapacityReport(ctx context.Context) ([]manager.NodeCapacity, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return manager.NodeCapacities(o.nodes, o.liveTasks(), ""), nil
+}
+
+// CheckCapacity reports whether a service's reservations fit on its eligible nodes
+func (o *Orchestrator) CheckCapacity(ctx context.Context, def config.ServiceDefinition) (manager.CapacityCheck, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	excludeServiceID := ""
+	if svc, err := o.lookup(def.Name); err == nil {
+		excludeServiceID = svc.id
+	}
+	return manager.SimulatePlacement(def, o.nodes, o.liveTasks(), excludeServiceID)
+}
+
+// resolveNode finds a node by ID or hostname
+func (o *Orchestrator) resolveNode(ref string) (int, error) {
+	for i, n := range o.nodes {
+		if n.ID == ref || n.Hostname == ref {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("node %s not found", ref)
+}
+
+func (o *Orchestrator) findNode(nodeID string) (node.Info, bool) {
+	for _, n := range o.nodes {
+		if n.ID == nodeID {
+			return n, true
+		}
+	}
+	return node.Info{}, false
+}
+
+func (o *Orchestrator) nodeReady(nodeID string) bool {
+	n, ok := o.findNode(nodeID)
+	return ok && nodeIsReady(n)
+}
+
+func (o *Orchestrator) nodeDrained(nodeID string) bool {
+	n, ok := o.findNode(nodeID)
+	return ok && n.Availability == string(swarm.NodeAvailabilityDrain)
+}
+
+func nodeIsReady(n node.Info) bool {
+	for _, condition := range n.Conditions {
+		if condition == string(swarm.NodeStateReady) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,740 @@
+// Package sim provides an in-memory orchestrator that simulates a swarm cluster.
+// It implements the same manager interfaces as the Docker backends, so the web
+// UI, the gRPC API and integration tests can run without Docker.
+package sim
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/jasonlovesdoggo/velo/internal/config"
+	"github.com/jasonlovesdoggo/velo/internal/log"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/manager"
+	"github.com/jasonlovesdoggo/velo/pkg/core/node"
+)
+
+// defaultRestartDelay matches swarm's default delay between task restarts
+const defaultRestartDelay = 5 * time.Second
+
+// maxServiceEvents bounds the event log kept for each service
+const maxServiceEvents = 100
+
+// taskHistoryLimit is how many terminal tasks are kept per slot, as with
+// swarm's default task history limit
+const taskHistoryLimit = 5
+
+// Options configures a simulated cluster
+type Options struct {
+	// Clock drives the simulation. Defaults to the wall clock.
+	Clock Clock
+	// Nodes are the nodes of the cluster. Defaults to DefaultNodes().
+	Nodes []node.Info
+	// StartDelay is how long a task spends preparing (pulling its image) before it runs
+	StartDelay time.Duration
+	// TickInterval is how often Start advances the simulation. Defaults to 500ms.
+	TickInterval time.Duration
+	// CapacityPolicy controls admission of deploys that don't fit. Defaults to enforce.
+	CapacityPolicy manager.CapacityPolicy
+}
+
+// Orchestrator is an in-memory manager.Backend that simulates services, tasks and nodes
+type Orchestrator struct {
+	opts     Options
+	clock    Clock
+	mu       sync.Mutex
+	nodes    []node.Info
+	services map[string]*service
+	tasks    []*task
+	faults   faults
+	jobs     []manager.Job
+	nextID   int
+	ticker   *time.Ticker
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// service is a simulated service
+type service struct {
+	id      string
+	def     config.ServiceDefinition
+	version int // bumped on every update, tasks of older versions are replaced
+	events  []string
+
+	// crashes counts the crashed tasks of crashVersion per slot, which outlive
+	// the task history
+	crashes      map[int]uint64
+	crashVersion int
+}
+
+// recordCrash counts a crashed task of the service's current version
+func (svc *service) recordCrash(t *task) {
+	if t.version != svc.version {
+		return
+	}
+	if svc.crashVersion != svc.version {
+		svc.crashes = make(map[int]uint64)
+		svc.crashVersion = svc.version
+	}
+	svc.crashes[t.Slot]++
+}
+
+// crashCount returns how often tasks of the service's current version crashed in a slot
+func (svc *service) crashCount(slot int) uint64 {
+	if svc.crashVersion != svc.version {
+		return 0
+	}
+	return svc.crashes[slot]
+}
+
+// task is a simulated task. The last taskHistoryLimit terminal tasks of each
+// slot are kept as history.
+type task struct {
+	swarm.Task
+	version int
+	readyAt time.Time // when a preparing task starts running
+	crashed bool      // failed on its own rather than because its node went away
+}
+
+// Ensure Orchestrator implements the manager interfaces
+var (
+	_ manager.Backend         = (*Orchestrator)(nil)
+	_ manager.ServiceManager  = (*Orchestrator)(nil)
+	_ manager.NodeManager     = (*Orchestrator)(nil)
+	_ manager.CapacityManager = (*Orchestrator)(nil)
+	_ manager.ContextDeployer = (*Orchestrator)(nil)
+	_ manager.JoinManager     = (*Orchestrator)(nil)
+	_ manager.JobRunner       = (*Orchestrator)(nil)
+)
+
+// DefaultNodes returns a small cluster of one manager and two workers
+func DefaultNodes() []node.Info {
+	return []node.Info{
+		simNode("node-1", "manager-1", "manager", 4, 8<<30, map[string]string{"zone": "a"}),
+		simNode("node-2", "worker-1", "worker", 2, 4<<30, map[string]string{"zone": "a"}),
+		simNode("node-3", "worker-2", "worker", 2, 4<<30, map[string]string{"zone": "b"}),
+	}
+}
+
+func simNode(id, hostname, role string, cpus int, memory int64, labels map[string]string) node.Info {
+	return node.Info{
+		ID:           id,
+		Hostname:     hostname,
+		Address:      "10.0.0." + strings.TrimPrefix(id, "node-"),
+		Labels:       labels,
+		Role:         role,
+		Manager:      role == "manager",
+		Availability: string(swarm.NodeAvailabilityActive),
+		Conditions:   []string{string(swarm.NodeStateReady)},
+		Capacity:     node.Resources{CPU: cpus, Memory: memory},
+	}
+}
+
+// New creates a simulated cluster
+func New(opts Options) *Orchestrator {
+	if opts.Clock == nil {
+		opts.Clock = realClock{}
+	}
+	if opts.Nodes == nil {
+		opts.Nodes = DefaultNodes()
+	}
+	if opts.TickInterval <= 0 {
+		opts.TickInterval = 500 * time.Millisecond
+	}
+	if opts.CapacityPolicy == "" {
+		opts.CapacityPolicy = manager.CapacityEnforce
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	nodes := make([]node.Info, len(opts.Nodes))
+	copy(nodes, opts.Nodes)
+
+	return &Orchestrator{
+		opts:     opts,
+		clock:    opts.Clock,
+		nodes:    nodes,
+		services: make(map[string]*service),
+		faults:   newFaults(),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start advances the simulation in the background every TickInterval
+func (o *Orchestrator) Start() error {
+	o.ticker = time.NewTicker(o.opts.TickInterval)
+	go func() {
+		for {
+			select {
+			case <-o.ticker.C:
+				o.Step()
+			case <-o.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	log.Info("Simulated cluster started", "nodes", len(o.nodes))
+	return nil
+}
+
+// Stop stops advancing the simulation
+func (o *Orchestrator) Stop() {
+	if o.ticker != nil {
+		o.ticker.Stop()
+	}
+	o.cancel()
+}
+
+// Step advances every task and service to the current time of the clock
+func (o *Orchestrator) Step() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.step()
+}
+
+// SetCapacityPolicy sets how deploys that don't fit on the cluster are handled
+func (o *Orchestrator) SetCapacityPolicy(policy manager.CapacityPolicy) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.opts.CapacityPolicy = policy
+}
+
+// DeployService creates a service and schedules its tasks
+func (o *Orchestrator) DeployService(def config.ServiceDefinition) (string, error) {
+	return o.DeployServiceContext(context.Background(), def)
+}
+
+// DeployServiceContext deploys a service like DeployService, reporting capacity
+// warnings to ctx
+func (o *Orchestrator) DeployServiceContext(ctx context.Context, def config.ServiceDefinition) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err := o.faults.take(OpDeploy); err != nil {
+		return "", err
+	}
+	if _, err := o.lookup(def.Name); err == nil {
+		return "", fmt.Errorf("failed to create service: service %s already exists", def.Name)
+	}
+	if _, err := manager.EligibleNodes(def, o.nodes); err != nil {
+		return "", err
+	}
+	if err := o.admit(ctx, def, ""); err != nil {
+		return "", err
+	}
+
+	svc := &service{id: o.newID("svc"), def: def, version: 1}
+	o.services[svc.id] = svc
+	o.event(svc, "service created with image %s and %d replica(s)", def.Image, def.Replicas)
+	o.reconcileService(svc)
+
+	return svc.id, nil
+}
+
+// UpdateService changes a service's definition and rolls its tasks over one at a time
+func (o *Orchestrator) UpdateService(serviceID string, def config.ServiceDefinition) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err := o.faults.take(OpUpdate); err != nil {
+		return err
+	}
+	svc, err := o.lookup(serviceID)
+	if err != nil {
+		return err
+	}
+
+	if def.Replicas <= 0 {
+		def.Replicas = svc.def.Replicas
+	}
+	def.Name = svc.def.Name
+	if _, err := manager.EligibleNodes(def, o.nodes); err != nil {
+		return err
+	}
+	if err := o.admit(context.Background(), def, svc.id); err != nil {
+		return err
+	}
+
+	svc.def = def
+	svc.version++
+	o.event(svc, "service updated to image %s (version %d)", def.Image, svc.version)
+	o.reconcileService(svc)
+
+	return nil
+}
+
+// ScaleService changes the number of replicas of a service
+func (o *Orchestrator) ScaleService(serviceID string, replicas int) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err := o.faults.take(OpScale); err != nil {
+		return err
+	}
+	svc, err := o.lookup(serviceID)
+	if err != nil {
+		return err
+	}
+
+	if replicas > svc.def.Replicas {
+		def := svc.def
+		def.Replicas = replicas
+		if err := o.admit(context.Background(), def, svc.id); err != nil {
+			return err
+		}
+	}
+
+	svc.def.Replicas = replicas
+	o.event(svc, "service scaled to %d replica(s)", replicas)
+	o.reconcileService(svc)
+
+	return nil
+}
+
+// RemoveService shuts down a service's tasks and forgets it
+func (o *Orchestrator) RemoveService(serviceID string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err := o.faults.take(OpRemove); err != nil {
+		return err
+	}
+	svc, err := o.lookup(serviceID)
+	if err != nil {
+		return fmt.Errorf("failed to remove service: %w", err)
+	}
+
+	kept := o.tasks[:0]
+	for _, t := range o.tasks {
+		if t.ServiceID != svc.id {
+			kept = append(kept, t)
+		}
+	}
+	o.tasks = kept
+	delete(o.services, svc.id)
+
+	return nil
+}
+
+// GetServiceStatus returns the status of a service. Logs holds the service's event log.
+func (o *Orchestrator) GetServiceStatus(serviceID string) (config.DeploymentStatus, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err := o.faults.take(OpStatus); err != nil {
+		return config.DeploymentStatus{}, err
+	}
+	svc, err := o.lookup(serviceID)
+	if err != nil {
+		return config.DeploymentStatus{}, fmt.Errorf("failed to inspect service: %w", err)
+	}
+
+	return o.status(svc), nil
+}
+
+// ListServices returns the status of all services
+func (o *Orchestrator) ListServices() ([]config.DeploymentStatus, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	result := make([]config.DeploymentStatus, 0, len(o.services))
+	for _, svc := range o.services {
+		result = append(result, o.status(svc))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Service.Name < result[j].Service.Name })
+	return result, nil
+}
+
+// Tasks returns all tasks of a service, including finished ones, oldest first
+func (o *Orchestrator) Tasks(serviceID string) ([]swarm.Task, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	svc, err := o.lookup(serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []swarm.Task
+	for _, t := range o.tasks {
+		if t.ServiceID == svc.id {
+			result = append(result, t.Task)
+		}
+	}
+	return result, nil
+}
+
+// status derives a service's state from its tasks the same way SwarmManager does,
+// except that a service whose newest tasks all failed counts as failed even while
+// a restart is pending
+func (o *Orchestrator) status(svc *service) config.DeploymentStatus {
+	running := 0
+	latest := make(map[int]*task)
+	for _, t := range o.tasks {
+		if t.ServiceID != svc.id {
+			continue
+		}
+		if t.Status.State == swarm.TaskStateRunning && t.version == svc.version {
+			running++
+		}
+		latest[t.Slot] = t
+	}
+
+	state := "pending"
+	if svc.def.Replicas > 0 && running >= svc.def.Replicas {
+		state = "running"
+	} else if svc.def.Replicas > 0 && running == 0 && len(latest) > 0 {
+		state = "failed"
+		for slot := 1; slot <= svc.def.Replicas; slot++ {
+			if t, ok := latest[slot]; !ok || t.Status.State != swarm.TaskStateFailed {
+				state = "pending"
+				break
+			}
+		}
+	}
+
+	return config.DeploymentStatus{
+		ID:      svc.id,
+		Service: svc.def,
+		State:   state,
+		Running: running,
+		Logs:    strings.Join(svc.events, "\n"),
+	}
+}
+
+// lookup finds a service by ID or name
+func (o *Orchestrator) lookup(ref string) (*service, error) {
+	if svc, ok := o.services[ref]; ok {
+		return svc, nil
+	}
+	for _, svc := range o.services {
+		if svc.def.Name == ref {
+			return svc, nil
+		}
+	}
+	return nil, fmt.Errorf("service %s not found", ref)
+}
+
+// admit applies the capacity policy to a deploy
+func (o *Orchestrator) admit(ctx context.Context, def config.ServiceDefinition, excludeServiceID string) error {
+	if o.opts.CapacityPolicy == manager.CapacityOff {
+		return nil
+	}
+
+	check, err := manager.SimulatePlacement(def, o.nodes, o.liveTasks(), excludeServiceID)
+	if err != nil {
+		return err
+	}
+	if check.Fits {
+		return nil
+	}
+	if o.opts.CapacityPolicy == manager.CapacityWarn {
+		log.Warn("Service reservations exceed cluster capacity", "service", def.Name, "details", check.Message)
+		manager.AddWarning(ctx, check.Message)
+		return nil
+	}
+	return fmt.Errorf("%w: %s", manager.ErrInsufficientCapacity, check.Message)
+}
+
+// step moves preparing tasks along, fails tasks on nodes that went down and
+// reconciles every service
+func (o *Orchestrator) step() {
+	now := o.clock.Now()
+
+	for _, t := range o.tasks {
+		if terminal(t) {
+			continue
+		}
+		svc := o.services[t.ServiceID]
+
+		switch {
+		case t.NodeID != "" && !o.nodeReady(t.NodeID):
+			o.setState(svc, t, swarm.TaskStateFailed, "node is down", false)
+		case t.NodeID != "" && o.nodeDrained(t.NodeID):
+			o.setState(svc, t, swarm.TaskStateShutdown, "node is draining", false)
+		case t.Status.State == swarm.TaskStatePreparing && !now.Before(t.readyAt):
+			image := t.Spec.ContainerSpec.Image
+			if reason, failing := o.faults.imagePulls[image]; failing {
+				o.setState(svc, t, swarm.TaskStateFailed, "image pull failed: "+reason, true)
+			} else {
+				o.setState(svc, t, swarm.TaskStateRunning, "started", false)
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(o.services))
+	for id := range o.services {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		o.reconcileService(o.services[id])
+	}
+	o.pruneTasks()
+}
+
+// pruneTasks drops all but the newest taskHistoryLimit terminal tasks of every slot
+func (o *Orchestrator) pruneTasks() {
+	type slotKey struct {
+		service string
+		slot    int
+	}
+	history := make(map[slotKey]int)
+	keep := make([]bool, len(o.tasks))
+	for i := len(o.tasks) - 1; i >= 0; i-- {
+		t := o.tasks[i]
+		if !terminal(t) {
+			keep[i] = true
+			continue
+		}
+		key := slotKey{t.ServiceID, t.Slot}
+		history[key]++
+		keep[i] = history[key] <= taskHistoryLimit
+	}
+
+	kept := o.tasks[:0]
+	for i, t := range o.tasks {
+		if keep[i] {
+			kept = append(kept, t)
+		}
+	}
+	clear(o.tasks[len(kept):])
+	o.tasks = kept
+}
+
+// reconcileService scales a service's tasks to its replica count, replaces
+// tasks of older versions one at a time and restarts failed tasks
+func (o *Orchestrator) reconcileService(svc *service) {
+	now := o.clock.Now()
+
+	var live []*task
+	for _, t := range o.tasks {
+		if t.ServiceID == svc.id && !terminal(t) {
+			live = append(live, t)
+		}
+	}
+
+	// Retry placement of tasks that are waiting for a node
+	for _, t := range live {
+		if t.NodeID == "" {
+			o.schedule(svc, t)
+		}
+	}
+
+	// Scale down, removing tasks of older versions and higher slots first
+	sort.Slice(live, func(i, j int) bool {
+		if live[i].version != live[j].version {
+			return live[i].version > live[j].version
+		}
+		return live[i].Slot < live[j].Slot
+	})
+	for len(live) > svc.def.Replicas {
+		victim := live[len(live)-1]
+		o.setState(svc, victim, swarm.TaskStateShutdown, "service scaled down", false)
+		live = live[:len(live)-1]
+	}
+
+	occupied := make(map[int]bool, len(live))
+	for _, t := range live {
+		occupied[t.Slot] = true
+	}
+
+	// Rolling update: replace one old task at a time once the new ones are
+	// running. The update pauses when a task of the new version crashes.
+	updating, settled := false, true
+	for _, t := range live {
+		if t.version < svc.version {
+			updating = true
+		} else if t.Status.State != swarm.TaskStateRunning {
+			settled = false
+		}
+	}
+	if updating && settled && !o.versionCrashed(svc) {
+		for i := len(live) - 1; i >= 0; i-- {
+			if live[i].version < svc.version {
+				o.setState(svc, live[i], swarm.TaskStateShutdown, "replaced by update", false)
+				o.createTask(svc, live[i].Slot)
+				break
+			}
+		}
+	}
+
+	for slot := 1; slot <= svc.def.Replicas; slot++ {
+		if !occupied[slot] && o.shouldRestart(svc, slot, now) {
+			o.createTask(svc, slot)
+		}
+	}
+}
+
+// versionCrashed reports whether a task of the service's current version has crashed
+func (o *Orchestrator) versionCrashed(svc *service) bool {
+	return svc.crashVersion == svc.version && len(svc.crashes) > 0
+}
+
+// shouldRestart applies the service's restart policy to an empty slot
+func (o *Orchestrator) shouldRestart(svc *service, slot int, now time.Time) bool {
+	var last *task
+	for _, t := range o.tasks {
+		if t.ServiceID == svc.id && t.Slot == slot {
+			last = t
+		}
+	}
+	attempts := svc.crashCount(slot)
+	if last == nil || !last.crashed {
+		return true
+	}
+
+	policy := svc.def.Restart
+	if policy.Condition == config.RestartNone {
+		return false
+	}
+	if policy.MaxAttempts > 0 && attempts >= policy.MaxAttempts {
+		return false
+	}
+	delay := defaultRestartDelay
+	if policy.Delay > 0 {
+		delay = time.Duration(policy.Delay) * time.Second
+	}
+	return !now.Before(last.Status.Timestamp.Add(delay))
+}
+
+// createTask adds a task for a slot of a service and tries to place it
+func (o *Orchestrator) createTask(svc *service, slot int) {
+	now := o.clock.Now()
+	t := &task{
+		Task: swarm.Task{
+			ID:           o.newID("task"),
+			ServiceID:    svc.id,
+			Slot:         slot,
+			DesiredState: swarm.TaskStateRunning,
+			Spec: swarm.TaskSpec{
+				ContainerSpec: &swarm.ContainerSpec{Image: svc.def.Image, Env: svc.def.ToEnv()},
+				Resources:     resourceRequirements(svc.def.Resources),
+			},
+			Status: swarm.TaskStatus{Timestamp: now, State: swarm.TaskStatePending, Message: "pending task scheduling"},
+		},
+		version: svc.version,
+	}
+	t.CreatedAt = now
+	o.tasks = append(o.tasks, t)
+	o.schedule(svc, t)
+}
+
+// schedule places a pending task on the eligible node with the fewest tasks
+// of its service that has room for its reservations
+func (o *Orchestrator) schedule(svc *service, t *task) {
+	var ready []node.Info
+	for _, n := range o.nodes {
+		if nodeIsReady(n) {
+			ready = append(ready, n)
+		}
+	}
+	eligible, err := manager.EligibleNodes(svc.def, ready)
+	if err != nil {
+		t.Status.Err = err.Error()
+		return
+	}
+
+	perNode := make(map[string]uint64)
+	for _, other := range o.tasks {
+		if other.ServiceID == svc.id && !terminal(other) && other.NodeID != "" {
+			perNode[other.NodeID]++
+		}
+	}
+
+	cpu, memory := int64(svc.def.Resources.CPUReserve*1e9), svc.def.Resources.MemoryReserve
+	var best *manager.NodeCapacity
+	for _, c := range manager.NodeCapacities(eligible, o.liveTasks(), "") {
+		if c.FreeCPUs() < cpu || c.FreeMemory() < memory {
+			continue
+		}
+		if max := svc.def.Placement.MaxReplicasPerNode; max > 0 && perNode[c.NodeID] >= max {
+			continue
+		}
+		if best == nil || perNode[c.NodeID] < perNode[best.NodeID] ||
+			(perNode[c.NodeID] == perNode[best.NodeID] && c.Tasks < best.Tasks) {
+			candidate := c
+			best = &candidate
+		}
+	}
+	if best == nil {
+		t.Status.Err = fmt.Sprintf("no suitable node (insufficient resources on %d node(s))", len(eligible))
+		return
+	}
+
+	t.NodeID = best.NodeID
+	t.Status.Err = ""
+	t.readyAt = o.clock.Now().Add(o.opts.StartDelay)
+	o.setState(svc, t, swarm.TaskStatePreparing, "pulling image", false)
+}
+
+// setState moves a task to a new state and records it in the service's event log
+func (o *Orchestrator) setState(svc *service, t *task, state swarm.TaskState, message string, crashed bool) {
+	t.Status.State = state
+	t.Status.Message = message
+	t.Status.Timestamp = o.clock.Now()
+	t.UpdatedAt = t.Status.Timestamp
+	t.crashed = crashed
+	if terminal(t) {
+		t.DesiredState = swarm.TaskStateShutdown
+	}
+	if crashed && svc != nil {
+		svc.recordCrash(t)
+	}
+
+	if svc != nil {
+		where := ""
+		if n, ok := o.findNode(t.NodeID); ok {
+			where = " on " + n.Hostname
+		}
+		o.event(svc, "task %s (replica %d)%s: %s: %s", t.ID, t.Slot, where, state, message)
+	}
+}
+
+// event appends a line to a service's event log
+func (o *Orchestrator) event(svc *service, format string, args ...interface{}) {
+	line := o.clock.Now().UTC().Format(time.RFC3339) + " " + fmt.Sprintf(format, args...)
+	svc.events = append(svc.events, line)
+	if len(svc.events) > maxServiceEvents {
+		svc.events = svc.events[len(svc.events)-maxServiceEvents:]
+	}
+}
+
+// liveTasks returns the tasks that are meant to be running, as swarm tasks
+func (o *Orchestrator) liveTasks() []swarm.Task {
+	var result []swarm.Task
+	for _, t := range o.tasks {
+		if !terminal(t) {
+			result = append(result, t.Task)
+		}
+	}
+	return result
+}
+
+func (o *Orchestrator) newID(prefix string) string {
+	o.nextID++
+	return fmt.Sprintf("%s-%d", prefix, o.nextID)
+}
+
+func terminal(t *task) bool {
+	switch t.Status.State {
+	case swarm.TaskStateFailed, swarm.TaskStateShutdown, swarm.TaskStateComplete, swarm.TaskStateRejected:
+		return true
+	}
+	return false
+}
+
+func resourceRequirements(res config.ResourceConfig) *swarm.ResourceRequirements {
+	if res.CPUReserve == 0 && res.MemoryReserve == 0 {
+		return nil
+	}
+	return &swarm.ResourceRequirements{
+		Reservations: &swarm.Resources{NanoCPUs: int64(res.CPUReserve * 1e9), MemoryBytes: res.MemoryReserve},
+	}
+}
@@ -0,0 +1,290 @@
+package sim
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/jasonlovesdoggo/velo/internal/config"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/manager"
+)
+
+const startDelay = 2 * time.Second
+
+// newTestCluster creates a simulated cluster on a fake clock
+func newTestCluster() (*Orchestrator, *FakeClock) {
+	clock := NewFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	return New(Options{Clock: clock, StartDelay: startDelay}), clock
+}
+
+// advance moves the clock forward and steps the simulation
+func advance(o *Orchestrator, clock *FakeClock, d time.Duration) {
+	clock.Advance(d)
+	o.Step()
+}
+
+func mustDeploy(t *testing.T, o *Orchestrator, def config.ServiceDefinition) string {
+	t.Helper()
+	id, err := o.DeployService(def)
+	if err != nil {
+		t.Fatalf("DeployService failed: %v", err)
+	}
+	return id
+}
+
+func expectState(t *testing.T, o *Orchestrator, id, want string) {
+	t.Helper()
+	status, err := o.GetServiceStatus(id)
+	if err != nil {
+		t.Fatalf("GetServiceStatus failed: %v", err)
+	}
+	if status.State != want {
+		t.Fatalf("Expected state %q, got %q\n%s", want, status.State, status.Logs)
+	}
+}
+
+func liveTasks(t *testing.T, o *Orchestrator, id string) []swarm.Task {
+	t.Helper()
+	tasks, err := o.Tasks(id)
+	if err != nil {
+		t.Fatalf("Tasks failed: %v", err)
+	}
+	var live []swarm.Task
+	for _, task := range tasks {
+		if task.DesiredState == swarm.TaskStateRunning {
+			live = append(live, task)
+		}
+	}
+	return live
+}
+
+func TestDeployLifecycle(t *testing.T) {
+	o, clock := newTestCluster()
+	id := mustDeploy(t, o, config.ServiceDefinition{Name: "web", Image: "nginx:1", Replicas: 3})
+
+	expectState(t, o, id, "pending")
+	for _, task := range liveTasks(t, o, id) {
+		if task.Status.State != swarm.TaskStatePreparing || task.NodeID == "" {
+			t.Fatalf("Expected task to be preparing on a node, got %s on %q", task.Status.State, task.NodeID)
+		}
+	}
+
+	advance(o, clock, startDelay)
+	expectState(t, o, id, "running")
+
+	nodes := make(map[string]bool)
+	for _, task := range liveTasks(t, o, id) {
+		nodes[task.NodeID] = true
+	}
+	if len(nodes) != 3 {
+		t.Errorf("Expected replicas spread over 3 nodes, got %d", len(nodes))
+	}
+
+	if err := o.ScaleService("web", 1); err != nil {
+		t.Fatalf("ScaleService failed: %v", err)
+	}
+	if n := len(liveTasks(t, o, id)); n != 1 {
+		t.Errorf("Expected 1 task after scale down, got %d", n)
+	}
+
+	if err := o.RemoveService(id); err != nil {
+		t.Fatalf("RemoveService failed: %v", err)
+	}
+	if _, err := o.GetServiceStatus(id); err == nil {
+		t.Error("Expected removed service to be gone")
+	}
+}
+
+func TestImagePullFailure(t *testing.T) {
+	o, clock := newTestCluster()
+	o.FailImagePulls("nginx:missing", "")
+	id := mustDeploy(t, o, config.ServiceDefinition{Name: "web", Image: "nginx:missing", Replicas: 1})
+
+	advance(o, clock, startDelay)
+	expectState(t, o, id, "failed")
+
+	// The task is restarted after the restart delay and succeeds once the image exists
+	o.ClearFaults()
+	advance(o, clock, defaultRestartDelay)
+	advance(o, clock, startDelay)
+	expectState(t, o, id, "running")
+}
+
+func TestRestartPolicy(t *testing.T) {
+	o, clock := newTestCluster()
+	id := mustDeploy(t, o, config.ServiceDefinition{Name: "job", Image: "busybox", Replicas: 1,
+		Restart: config.RestartPolicy{Condition: config.RestartOnFailure, MaxAttempts: 2, Delay: 1}})
+	advance(o, clock, startDelay)
+
+	for attempt := 0; attempt < 2; attempt++ {
+		if n, _ := o.CrashTasks(id, 1); n != 1 {
+			t.Fatalf("Expected to crash 1 task on attempt %d, crashed %d", attempt, n)
+		}
+		advance(o, clock, time.Second)
+		advance(o, clock, startDelay)
+	}
+
+	expectState(t, o, id, "failed")
+	if n := len(liveTasks(t, o, id)); n != 0 {
+		t.Errorf("Expected no restarts after max attempts, got %d live task(s)", n)
+	}
+}
+
+func TestTaskHistoryLimit(t *testing.T) {
+	o, clock := newTestCluster()
+	const maxAttempts = 2 * taskHistoryLimit
+	id := mustDeploy(t, o, config.ServiceDefinition{Name: "loop", Image: "busybox", Replicas: 1,
+		Restart: config.RestartPolicy{Condition: config.RestartOnFailure, MaxAttempts: maxAttempts, Delay: 1}})
+	advance(o, clock, startDelay)
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if n, _ := o.CrashTasks(id, 1); n != 1 {
+			t.Fatalf("Expected to crash 1 task on attempt %d, crashed %d", attempt, n)
+		}
+		advance(o, clock, time.Second)
+		advance(o, clock, startDelay)
+
+		tasks, err := o.Tasks(id)
+		if err != nil {
+			t.Fatalf("Tasks failed: %v", err)
+		}
+		if len(tasks) > taskHistoryLimit+1 {
+			t.Fatalf("Expected at most %d terminal tasks and a live one, got %d tasks", taskHistoryLimit, len(tasks))
+		}
+	}
+
+	// Restart attempts are counted beyond the history that's kept
+	expectState(t, o, id, "failed")
+	if n := len(liveTasks(t, o, id)); n != 0 {
+		t.Errorf("Expected no restarts after max attempts, got %d live task(s)", n)
+	}
+}
+
+func TestRollingUpdate(t *testing.T) {
+	o, clock := newTestCluster()
+	id := mustDeploy(t, o, config.ServiceDefinition{Name: "web", Image: "nginx:1", Replicas: 3})
+	advance(o, clock, startDelay)
+
+	if err := o.UpdateService(id, config.ServiceDefinition{Image: "nginx:2"}); err != nil {
+		t.Fatalf("UpdateService failed: %v", err)
+	}
+
+	// Only one task is replaced at a time
+	old := 0
+	for _, task := range liveTasks(t, o, id) {
+		if task.Spec.ContainerSpec.Image == "nginx:1" {
+			old++
+		}
+	}
+	if old != 2 {
+		t.Fatalf("Expected 2 old tasks while the first is replaced, got %d", old)
+	}
+
+	for i := 0; i < 3; i++ {
+		advance(o, clock, startDelay)
+	}
+	expectState(t, o, id, "running")
+	for _, task := range liveTasks(t, o, id) {
+		if task.Spec.ContainerSpec.Image != "nginx:2" {
+			t.Errorf("Expected all tasks updated, found %s", task.Spec.ContainerSpec.Image)
+		}
+	}
+}
+
+func TestFailedUpdatePauses(t *testing.T) {
+	o, clock := newTestCluster()
+	id := mustDeploy(t, o, config.ServiceDefinition{Name: "web", Image: "nginx:1", Replicas: 3})
+	advance(o, clock, startDelay)
+
+	o.FailImagePulls("nginx:broken", "")
+	if err := o.UpdateService(id, config.ServiceDefinition{Image: "nginx:broken"}); err != nil {
+		t.Fatalf("UpdateService failed: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		advance(o, clock, startDelay)
+	}
+
+	running := 0
+	for _, task := range liveTasks(t, o, id) {
+		if task.Spec.ContainerSpec.Image == "nginx:1" && task.Status.State == swarm.TaskStateRunning {
+			running++
+		}
+	}
+	if running != 2 {
+		t.Errorf("Expected the update to pause with 2 old tasks running, got %d", running)
+	}
+}
+
+func TestDrainAndNodeFailure(t *testing.T) {
+	o, clock := newTestCluster()
+	id := mustDeploy(t, o, config.ServiceDefinition{Name: "web", Image: "nginx:1", Replicas: 3})
+	advance(o, clock, startDelay)
+
+	// Tasks start immediately from here on so the drain can complete
+	o.opts.StartDelay = 0
+
+	var phases []string
+	report, err := o.DrainNodeAndWait(context.Background(), "worker-1", manager.DrainOptions{
+		PollInterval: time.Millisecond,
+		Progress:     func(p manager.DrainProgress) { phases = append(phases, p.Phase) },
+	})
+	if err != nil {
+		t.Fatalf("DrainNodeAndWait failed: %v", err)
+	}
+	if report.Moved != 1 || phases[len(phases)-1] != manager.DrainPhaseComplete {
+		t.Errorf("Unexpected drain report %+v, phases %v", report, phases)
+	}
+	for _, task := range liveTasks(t, o, id) {
+		if task.NodeID == report.NodeID {
+			t.Errorf("Task %s still on drained node", task.ID)
+		}
+	}
+
+	if err := o.SetNodeDown("worker-2", true); err != nil {
+		t.Fatalf("SetNodeDown failed: %v", err)
+	}
+	advance(o, clock, 0)
+	advance(o, clock, 0)
+	expectState(t, o, id, "running")
+	for _, task := range liveTasks(t, o, id) {
+		if task.NodeID != "node-1" {
+			t.Errorf("Expected all tasks on the only healthy node, found one on %s", task.NodeID)
+		}
+	}
+}
+
+func TestFaultsAndCapacity(t *testing.T) {
+	o, _ := newTestCluster()
+
+	injected := errors.New("docker daemon unavailable")
+	o.InjectError(OpDeploy, injected)
+	if _, err := o.DeployService(config.ServiceDefinition{Name: "web", Image: "nginx", Replicas: 1}); !errors.Is(err, injected) {
+		t.Fatalf("Expected injected error, got %v", err)
+	}
+	mustDeploy(t, o, config.ServiceDefinition{Name: "web", Image: "nginx", Replicas: 1})
+
+	_, err := o.DeployService(config.ServiceDefinition{Name: "big", Image: "nginx", Replicas: 2,
+		Resources: config.ResourceConfig{MemoryReserve: 6 << 30}})
+	if !errors.Is(err, manager.ErrInsufficientCapacity) {
+		t.Fatalf("Expected ErrInsufficientCapacity, got %v", err)
+	}
+
+	// Deploys the policy lets through report the shortfall to their caller
+	o.SetCapacityPolicy(manager.CapacityWarn)
+	var warnings []string
+	ctx := manager.WithWarnings(context.Background(), &warnings)
+	if _, err := o.DeployServiceContext(ctx, config.ServiceDefinition{Name: "big", Image: "nginx", Replicas: 2,
+		Resources: config.ResourceConfig{MemoryReserve: 6 << 30}}); err != nil {
+		t.Fatalf("Expected the deploy to be let through, got %v", err)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("Expected a capacity warning, got %q", warnings)
+	}
+
+	capacities, err := o.CapacityReport(context.Background())
+	if err != nil || len(capacities) != 3 {
+		t.Fatalf("Unexpected capacity report %+v (%v)", capacities, err)
+	}
+}
@@ -0,0 +1,47 @@
+package orchestrator
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/jasonlovesdoggo/velo/internal/config"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/dockertest"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/gocker"
+)
+
+func TestDeployToSwarm(t *testing.T) {
+	srv := dockertest.NewServer(dockertest.Options{Swarm: true, Hostname: "manager-1"})
+	defer srv.Close()
+	srv.AddNode("worker-1", swarm.NodeRoleWorker, map[string]string{"zone": "b"})
+
+	cli, err := srv.Client()
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer cli.Close()
+	gocker.SetClient(cli)
+	defer gocker.SetClient(nil)
+
+	id, err := DeployToSwarm(config.ServiceDefinition{Name: "web", Image: "nginx:1", Replicas: 2,
+		Labels: map[string]string{"app": "web"}, Networks: []string{"frontend"}})
+	if err != nil {
+		t.Fatalf("DeployToSwarm failed: %v", err)
+	}
+
+	status, err := GetDeploymentStatus(id)
+	if err != nil || status.ID != id {
+		t.Fatalf("Unexpected status %+v (%v)", status, err)
+	}
+	services := srv.Services()
+	if len(services) != 1 || services[0].Spec.Labels["app"] != "web" || len(services[0].Spec.TaskTemplate.Networks) != 1 {
+		t.Errorf("Unexpected services %+v", services)
+	}
+
+	nodes, err := ListNodes()
+	if err != nil {
+		t.Fatalf("ListNodes failed: %v", err)
+	}
+	if len(nodes) != 2 || !nodes[0].Manager || nodes[1].Labels["zone"] != "b" || nodes[1].Capacity.CPU == 0 {
+		t.Errorf("Unexpected nodes %+v", nodes)
+	}
+}
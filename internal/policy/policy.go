@@ -0,0 +1,271 @@
+// Package policy checks deploys against a declarative policy file. Each rule
+// either enforces itself, rejecting deploys that break it, or only warns about
+// them. The manager evaluates the policy on every deploy and veloctl evaluates
+// the same policy locally with `veloctl policy test`.
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jasonlovesdoggo/velo/internal/config"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Rule modes
+const (
+	ModeEnforce = "enforce"
+	ModeWarn    = "warn"
+	ModeOff     = "off"
+)
+
+// Rules
+const (
+	RuleNoLatestTag        = "no_latest_tag"
+	RuleRequireLimits      = "require_limits"
+	RuleRequireHealthcheck = "require_healthcheck"
+	RuleAllowedRegistries  = "allowed_registries"
+	RuleMaxReplicas        = "max_replicas"
+	RuleNoBindMounts       = "no_bind_mounts"
+	RuleRequiredLabels     = "required_labels"
+)
+
+// Rule configures one rule of a policy
+type Rule struct {
+	Mode       string   `toml:"mode"`       // enforce (default), warn or off
+	Registries []string `toml:"registries"` // allowed_registries: registry hosts or image prefixes, e.g. ghcr.io/acme
+	Max        int      `toml:"max"`        // max_replicas
+	Labels     []string `toml:"labels"`     // required_labels
+}
+
+// Policy is a set of rules deploys are checked against. The zero policy, and a
+// nil one, allows everything.
+type Policy struct {
+	Rules  map[string]Rule `toml:"rules"`
+	Source string          `toml:"-"` // the policy file the rules were read from
+}
+
+// Violation is a rule a service breaks
+type Violation struct {
+	Rule    string
+	Mode    string
+	Message string
+}
+
+func (v Violation) String() string {
+	return v.Rule + ": " + v.Message
+}
+
+// ViolationError is returned for deploys that break enforced rules
+type ViolationError struct {
+	Violations []Violation
+}
+
+func (e *ViolationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.String()
+	}
+	return "deploy violates policy: " + strings.Join(msgs, "; ")
+}
+
+// checks holds how each rule is checked. A check returns a message for every
+// way the service breaks the rule.
+var checks = map[string]func(def config.ServiceDefinition, rule Rule) []string{
+	RuleNoLatestTag:        checkNoLatestTag,
+	RuleRequireLimits:      checkRequireLimits,
+	RuleRequireHealthcheck: checkRequireHealthcheck,
+	RuleAllowedRegistries:  checkAllowedRegistries,
+	RuleMaxReplicas:        checkMaxReplicas,
+	RuleNoBindMounts:       checkNoBindMounts,
+	RuleRequiredLabels:     checkRequiredLabels,
+}
+
+// Load reads a policy file
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy %s: %w", path, err)
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Parse parses and validates a policy in TOML. Unlike the other config files
+// it's decoded without viper, which drops empty tables like [rules.no_bind_mounts].
+func Parse(data []byte) (*Policy, error) {
+	var p Policy
+	dec := toml.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		var strict *toml.StrictMissingError
+		if errors.As(err, &strict) && len(strict.Errors) > 0 {
+			return nil, fmt.Errorf("unknown setting %s", strings.Join(strict.Errors[0].Key(), "."))
+		}
+		return nil, err
+	}
+	p.Source = string(data)
+
+	for name, rule := range p.Rules {
+		if _, ok := checks[name]; !ok {
+			return nil, fmt.Errorf("unknown rule %q", name)
+		}
+		switch rule.Mode {
+		case "":
+			rule.Mode = ModeEnforce
+			p.Rules[name] = rule
+		case ModeEnforce, ModeWarn, ModeOff:
+		default:
+			return nil, fmt.Errorf("rule %s has unknown mode %q (expected enforce, warn or off)", name, rule.Mode)
+		}
+		switch {
+		case name == RuleAllowedRegistries && len(rule.Registries) == 0:
+			return nil, fmt.Errorf("rule %s needs registries", name)
+		case name == RuleMaxReplicas && rule.Max <= 0:
+			return nil, fmt.Errorf("rule %s needs a max above 0", name)
+		case name == RuleRequiredLabels && len(rule.Labels) == 0:
+			return nil, fmt.Errorf("rule %s needs labels", name)
+		}
+	}
+	return &p, nil
+}
+
+// Evaluate checks a service against every rule of the policy and returns what
+// it breaks, ordered by rule
+func (p *Policy) Evaluate(def config.ServiceDefinition) []Violation {
+	if p == nil {
+		return nil
+	}
+	names := make([]string, 0, len(p.Rules))
+	for name := range p.Rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var violations []Violation
+	for _, name := range names {
+		rule := p.Rules[name]
+		if rule.Mode == ModeOff {
+			continue
+		}
+		for _, msg := range checks[name](def, rule) {
+			violations = append(violations, Violation{Rule: name, Mode: rule.Mode, Message: msg})
+		}
+	}
+	return violations
+}
+
+// Check evaluates a service and returns the violations of rules that only warn
+// as warnings. Violations of enforced rules are returned as a *ViolationError.
+func (p *Policy) Check(def config.ServiceDefinition) ([]string, error) {
+	var warnings []string
+	var enforced []Violation
+	for _, v := range p.Evaluate(def) {
+		if v.Mode == ModeEnforce {
+			enforced = append(enforced, v)
+		} else {
+			warnings = append(warnings, "policy: "+v.String())
+		}
+	}
+	if len(enforced) > 0 {
+		return warnings, &ViolationError{Violations: enforced}
+	}
+	return warnings, nil
+}
+
+// splitImage splits an image reference into its repository, with the registry
+// host, and its tag. Digests are dropped.
+func splitImage(image string) (registry, repository, tag string) {
+	image, _, _ = strings.Cut(image, "@")
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image, tag = image[:i], image[i+1:]
+	}
+
+	registry = "docker.io"
+	first, rest, found := strings.Cut(image, "/")
+	if found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		registry, image = first, rest
+	} else if !found {
+		image = "library/" + image
+	}
+	return registry, registry + "/" + image, tag
+}
+
+func checkNoLatestTag(def config.ServiceDefinition, rule Rule) []string {
+	if strings.Contains(def.Image, "@") {
+		return nil // pinned by digest
+	}
+	_, _, tag := splitImage(def.Image)
+	if tag == "" || tag == "latest" {
+		return []string{fmt.Sprintf("image %s must be pinned to a tag other than latest", def.Image)}
+	}
+	return nil
+}
+
+func checkRequireLimits(def config.ServiceDefinition, rule Rule) []string {
+	var msgs []string
+	if def.Resources.CPULimit <= 0 {
+		msgs = append(msgs, "a CPU limit is required")
+	}
+	if def.Resources.MemoryLimit <= 0 {
+		msgs = append(msgs, "a memory limit is required")
+	}
+	return msgs
+}
+
+func checkRequireHealthcheck(def config.ServiceDefinition, rule Rule) []string {
+	if len(def.HealthCheck.Command) == 0 {
+		return []string{"a healthcheck command is required"}
+	}
+	return nil
+}
+
+func checkAllowedRegistries(def config.ServiceDefinition, rule Rule) []string {
+	registry, repository, _ := splitImage(def.Image)
+	for _, allowed := range rule.Registries {
+		allowed = strings.TrimSuffix(allowed, "/")
+		if allowed == registry || strings.HasPrefix(repository, allowed+"/") {
+			return nil
+		}
+	}
+	return []string{fmt.Sprintf("image %s is not from an allowed registry (%s)", def.Image, strings.Join(rule.Registries, ", "))}
+}
+
+func checkMaxReplicas(def config.ServiceDefinition, rule Rule) []string {
+	if def.Replicas > rule.Max {
+		return []string{fmt.Sprintf("%d replicas is more than the maximum of %d", def.Replicas, rule.Max)}
+	}
+	return nil
+}
+
+func checkNoBindMounts(def config.ServiceDefinition, rule Rule) []string {
+	var msgs []string
+	for _, v := range def.Volumes {
+		if v.IsBind() {
+			msgs = append(msgs, fmt.Sprintf("host path %s must not be mounted", v.Source))
+		}
+	}
+	return msgs
+}
+
+func checkRequiredLabels(def config.ServiceDefinition, rule Rule) []string {
+	var missing []string
+	for _, label := range rule.Labels {
+		if def.Labels[label] == "" {
+			missing = append(missing, label)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	slices.Sort(missing)
+	return []string{"missing required labels: " + strings.Join(missing, ", ")}
+}
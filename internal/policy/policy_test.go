@@ -0,0 +1,141 @@
+package policy
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jasonlovesdoggo/velo/internal/config"
+)
+
+const testPolicy = `
+[rules.no_latest_tag]
+
+[rules.require_limits]
+mode = "warn"
+
+[rules.require_healthcheck]
+mode = "off"
+
+[rules.allowed_registries]
+registries = ["registry.example.com", "ghcr.io/acme"]
+
+[rules.max_replicas]
+max = 5
+
+[rules.no_bind_mounts]
+
+[rules.required_labels]
+mode = "warn"
+labels = ["team", "velo.project"]
+`
+
+func TestEvaluate(t *testing.T) {
+	p, err := Parse([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	compliant := config.ServiceDefinition{
+		Name:      "web",
+		Image:     "registry.example.com/shop/web:1.4.2",
+		Replicas:  3,
+		Resources: config.ResourceConfig{CPULimit: 1, MemoryLimit: 1 << 30},
+		Labels:    map[string]string{"team": "payments", "velo.project": "shop"},
+		Volumes:   []config.VolumeMount{{Source: "web-data", Destination: "/data"}},
+	}
+
+	tests := []struct {
+		name   string
+		change func(def *config.ServiceDefinition)
+		want   []string
+	}{
+		{name: "compliant", change: func(def *config.ServiceDefinition) {}},
+		{name: "pinned by digest", change: func(def *config.ServiceDefinition) {
+			def.Image = "ghcr.io/acme/web@sha256:0123456789abcdef"
+		}},
+		{name: "latest tag", change: func(def *config.ServiceDefinition) { def.Image = "registry.example.com/shop/web" },
+			want: []string{"no_latest_tag: image registry.example.com/shop/web must be pinned to a tag other than latest"}},
+		{name: "registry with port", change: func(def *config.ServiceDefinition) { def.Image = "localhost:5000/web:1" },
+			want: []string{"allowed_registries: image localhost:5000/web:1 is not from an allowed registry (registry.example.com, ghcr.io/acme)"}},
+		{name: "docker hub", change: func(def *config.ServiceDefinition) { def.Image = "nginx:latest" }, want: []string{
+			"allowed_registries: image nginx:latest is not from an allowed registry (registry.example.com, ghcr.io/acme)",
+			"no_latest_tag: image nginx:latest must be pinned to a tag other than latest",
+		}},
+		{name: "other organisation", change: func(def *config.ServiceDefinition) { def.Image = "ghcr.io/acme-evil/web:1" },
+			want: []string{"allowed_registries: image ghcr.io/acme-evil/web:1 is not from an allowed registry (registry.example.com, ghcr.io/acme)"}},
+		{name: "too many replicas", change: func(def *config.ServiceDefinition) { def.Replicas = 6 },
+			want: []string{"max_replicas: 6 replicas is more than the maximum of 5"}},
+		{name: "bind mount", change: func(def *config.ServiceDefinition) {
+			def.Volumes = append(def.Volumes, config.VolumeMount{Source: "/var/run/docker.sock", Destination: "/var/run/docker.sock"})
+		}, want: []string{"no_bind_mounts: host path /var/run/docker.sock must not be mounted"}},
+		{name: "warnings", change: func(def *config.ServiceDefinition) {
+			def.Resources = config.ResourceConfig{}
+			def.Labels = nil
+		}, want: []string{
+			"require_limits: a CPU limit is required",
+			"require_limits: a memory limit is required",
+			"required_labels: missing required labels: team, velo.project",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := compliant
+			tt.change(&def)
+
+			var got []string
+			for _, v := range p.Evaluate(def) {
+				got = append(got, v.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected violations %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	p, err := Parse([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	warnings, err := p.Check(config.ServiceDefinition{Name: "web", Image: "ghcr.io/acme/web:1", Replicas: 1})
+	if err != nil {
+		t.Fatalf("Expected only warnings, got %v", err)
+	}
+	if len(warnings) != 3 || !strings.HasPrefix(warnings[0], "policy: require_limits") {
+		t.Errorf("Expected three warnings, got %q", warnings)
+	}
+
+	_, err = p.Check(config.ServiceDefinition{Name: "web", Image: "nginx", Replicas: 1})
+	var violation *ViolationError
+	if !errors.As(err, &violation) || len(violation.Violations) != 2 {
+		t.Fatalf("Expected two enforced violations, got %v", err)
+	}
+
+	var none *Policy
+	if warnings, err := none.Check(config.ServiceDefinition{Image: "nginx"}); err != nil || warnings != nil {
+		t.Errorf("Expected a nil policy to allow everything, got %v, %v", warnings, err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		policy  string
+		wantErr string
+	}{
+		{policy: "[rules.no_root]", wantErr: `unknown rule "no_root"`},
+		{policy: "[rules.no_latest_tag]\nmode = \"audit\"", wantErr: `unknown mode "audit"`},
+		{policy: "[rules.allowed_registries]", wantErr: "needs registries"},
+		{policy: "[rules.max_replicas]\nmax = 0", wantErr: "needs a max above 0"},
+		{policy: "[rules.required_labels]\nmode = \"warn\"", wantErr: "needs labels"},
+		{policy: "[rules.max_replicas]\nmaximum = 3", wantErr: "maximum"},
+	}
+	for _, tt := range tests {
+		if _, err := Parse([]byte(tt.policy)); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Expected %q to fail with %q, got %v", tt.policy, tt.wantErr, err)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/api/proto"
+	"github.com/jasonlovesdoggo/velo/internal/auth"
+	"github.com/jasonlovesdoggo/velo/internal/log"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/cluster"
+	"github.com/jasonlovesdoggo/velo/pkg/core/node"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AgentServer implements the proto.AgentServiceServer interface. It's called by
+// the agents running on the nodes of every cluster, each authenticated by the
+// agent token of its node.
+type AgentServer struct {
+	proto.UnimplementedAgentServiceServer
+	clusters    *cluster.Registry
+	authService *auth.AuthService
+}
+
+// NewAgentServer creates a new AgentServer for the registered clusters
+func NewAgentServer(clusters *cluster.Registry, authService *auth.AuthService) *AgentServer {
+	return &AgentServer{clusters: clusters, authService: authService}
+}
+
+// agentToken returns the agent token a call is authenticated with and the
+// cluster it's for, which must be the cluster the agent names, if any
+func (s *AgentServer) agentToken(ctx context.Context, clusterName string) (*auth.AgentToken, *cluster.Cluster, error) {
+	token, ok := auth.AgentFromContext(ctx)
+	if !ok {
+		return nil, nil, status.Error(codes.Unauthenticated, "agent token required")
+	}
+	c, err := s.clusters.Get(token.Cluster)
+	if err != nil {
+		return nil, nil, status.Error(codes.NotFound, err.Error())
+	}
+	if clusterName != "" && clusterName != c.Name {
+		return nil, nil, status.Errorf(codes.PermissionDenied, "agent token is for cluster %s", c.Name)
+	}
+	return token, c, nil
+}
+
+// registering checks the agent token of a call may register the agent of a
+// node and returns the ID of the node, binding tokens that only know the
+// hostname their node joined with. Nodes must exist in backends that list
+// nodes; backends that don't can't vouch for the ID an agent reports, so there
+// the node is the one its token was created for.
+func (s *AgentServer) registering(ctx context.Context, req *proto.RegisterAgentRequest) (*cluster.Cluster, string, error) {
+	token, c, err := s.agentToken(ctx, req.Cluster)
+	if err != nil {
+		return nil, "", err
+	}
+	nodeID := req.NodeId
+	n, found, listed := c.BackendNode(req.NodeId)
+	switch {
+	case !listed:
+		nodeID = cmp.Or(token.NodeID, token.Hostname)
+	case !found || n.ID != req.NodeId:
+		return nil, "", status.Errorf(codes.PermissionDenied, "unknown node %s in cluster %s", req.NodeId, c.Name)
+	case token.NodeID == "" && token.Hostname != n.Hostname:
+		return nil, "", status.Errorf(codes.PermissionDenied, "agent token is for node %s", token.Hostname)
+	}
+
+	if _, err := s.authService.BindAgentToken(token.ID, nodeID); err != nil {
+		if errors.Is(err, auth.ErrAgentTokenBound) {
+			return nil, "", status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, "", err
+	}
+	return c, nodeID, nil
+}
+
+// Register handles the Register RPC call
+func (s *AgentServer) Register(ctx context.Context, req *proto.RegisterAgentRequest) (*proto.RegisterAgentResponse, error) {
+	log.Info("Received agent Register request", "node", req.NodeId, "hostname", req.Hostname, "version", req.AgentVersion, "cluster", req.Cluster)
+
+	c, nodeID, err := s.registering(ctx, req)
+	if err != nil {
+		log.Warn("Rejected agent registration", "node", req.NodeId, "hostname", req.Hostname, "error", err)
+		return nil, err
+	}
+
+	reg := cluster.AgentRegistration{
+		NodeID:        nodeID,
+		Hostname:      req.Hostname,
+		Version:       req.AgentVersion,
+		DockerVersion: req.DockerVersion,
+		OS:            req.Os,
+		Arch:          req.Arch,
+		KernelVersion: req.KernelVersion,
+	}
+	if capacity := req.Capacity; capacity != nil {
+		reg.Capacity = node.Resources{
+			CPU:    int(capacity.CpuCores),
+			Memory: capacity.MemoryBytes,
+			Disk:   capacity.DiskBytes,
+			GPU:    int(capacity.Gpus),
+		}
+	}
+	id, err := c.Agents.Register(reg)
+	if err != nil {
+		if errors.Is(err, cluster.ErrTooManyAgents) {
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		}
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	log.Info("Agent registered", "node", nodeID, "hostname", req.Hostname, "agentID", id, "cluster", c.Name)
+	return &proto.RegisterAgentResponse{
+		AgentId:             id,
+		Cluster:             c.Name,
+		HeartbeatIntervalMs: c.Agents.Interval().Milliseconds(),
+		NodeId:              nodeID,
+	}, nil
+}
+
+// Heartbeat handles the Heartbeat RPC call. Agents the manager doesn't know get
+// NotFound, which tells them to register again.
+func (s *AgentServer) Heartbeat(ctx context.Context, req *proto.HeartbeatRequest) (*proto.HeartbeatResponse, error) {
+	token, c, err := s.agentToken(ctx, req.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	if token.NodeID != req.NodeId {
+		return nil, status.Error(codes.PermissionDenied, auth.ErrAgentTokenBound.Error())
+	}
+
+	hb := cluster.AgentHeartbeat{
+		AgentID:  req.AgentId,
+		NodeID:   req.NodeId,
+		Health:   req.Health,
+		Problems: req.Problems,
+	}
+	for _, container := range req.Containers {
+		hb.Containers = append(hb.Containers, node.Container{
+			ID:           container.Id,
+			Name:         container.Name,
+			Image:        container.Image,
+			State:        container.State,
+			Health:       container.Health,
+			Service:      container.Service,
+			TaskID:       container.TaskId,
+			RestartCount: int(container.RestartCount),
+			ExitCode:     int(container.ExitCode),
+			OOMKilled:    container.OomKilled,
+		})
+	}
+	for _, result := range req.Results {
+		hb.Results = append(hb.Results, cluster.CommandResult{ID: result.Id, Success: result.Success, Message: result.Message})
+	}
+
+	commands, err := c.Agents.Heartbeat(hb)
+	if err != nil {
+		if errors.Is(err, cluster.ErrUnknownAgent) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, err
+	}
+	if len(req.Metrics) > 0 {
+		c.Metrics.Add(req.NodeId, samplesFromProto(req.Metrics))
+	}
+
+	resp := &proto.HeartbeatResponse{}
+	for _, cmd := range commands {
+		resp.Commands = append(resp.Commands, &proto.AgentCommand{Id: cmd.ID, Type: cmd.Type, Target: cmd.Target})
+	}
+	return resp, nil
+}
+
+// CreateAgentToken handles the CreateAgentToken RPC call. It's for the agents of
+// nodes that didn't join with a bootstrap token, such as standalone hosts or
+// nodes that joined the swarm directly. Nodes the backend lists get a token
+// bound to their ID; in backends that don't list nodes, the node is identified
+// by the name the token is created for.
+func (s *ClusterServer) CreateAgentToken(ctx context.Context, req *proto.CreateAgentTokenRequest) (*proto.AgentToken, error) {
+	log.Info("Received CreateAgentToken request", "node", req.Node, "cluster", req.Cluster)
+
+	if req.Node == "" {
+		return nil, status.Error(codes.InvalidArgument, "a node is required")
+	}
+	c, err := s.clusters.Get(req.Cluster)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	nodeID := req.Node
+	if n, found, listed := c.BackendNode(req.Node); listed {
+		if !found {
+			return nil, status.Errorf(codes.NotFound, "unknown node %s in cluster %s", req.Node, c.Name)
+		}
+		nodeID = n.ID
+	}
+
+	value, token, err := s.authService.CreateAgentToken(c.Name, nodeID, "")
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	resp := agentTokenToProto(*token)
+	resp.Token = value
+	return resp, nil
+}
+
+// ListAgentTokens handles the ListAgentTokens RPC call
+func (s *ClusterServer) ListAgentTokens(ctx context.Context, req *proto.ListAgentTokensRequest) (*proto.ListAgentTokensResponse, error) {
+	log.Info("Received ListAgentTokens request", "cluster", req.Cluster)
+
+	c, err := s.clusters.Get(req.Cluster)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	tokens, err := s.authService.ListAgentTokens(c.Name)
+	if err != nil {
+		log.Error("Failed to list agent tokens", "error", err)
+		return nil, fmt.Errorf("failed to list agent tokens: %w", err)
+	}
+
+	resp := &proto.ListAgentTokensResponse{}
+	for _, token := range tokens {
+		resp.Tokens = append(resp.Tokens, agentTokenToProto(token))
+	}
+	return resp, nil
+}
+
+// RevokeAgentToken handles the RevokeAgentToken RPC call
+func (s *ClusterServer) RevokeAgentToken(ctx context.Context, req *proto.RevokeAgentTokenRequest) (*proto.GenericResponse, error) {
+	log.Info("Received RevokeAgentToken request", "id", req.Id)
+
+	if err := s.authService.RevokeAgentToken(req.Id); err != nil {
+		return &proto.GenericResponse{
+			Message: fmt.Sprintf("Failed to revoke agent token %s: %v", req.Id, err),
+			Success: false,
+		}, nil
+	}
+
+	return &proto.GenericResponse{
+		Message: fmt.Sprintf("Agent token %s revoked", req.Id),
+		Success: true,
+	}, nil
+}
+
+// SendAgentCommand handles the SendAgentCommand RPC call. The command is
+// delivered with the next heartbeat of the node's agent.
+func (s *ClusterServer) SendAgentCommand(ctx context.Context, req *proto.AgentCommandRequest) (*proto.AgentCommandStatus, error) {
+	log.Info("Received SendAgentCommand request", "node", req.Node, "type", req.Type, "target", req.Target, "cluster", req.Cluster)
+
+	c, err := s.clusters.Get(req.Cluster)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	cmd, err := c.Agents.Enqueue(req.Node, req.Type, req.Target)
+	if err != nil {
+		if errors.Is(err, cluster.ErrUnknownNode) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return agentCommandToProto(cmd), nil
+}
+
+// GetAgentCommand handles the GetAgentCommand RPC call
+func (s *ClusterServer) GetAgentCommand(ctx context.Context, req *proto.GetAgentCommandRequest) (*proto.AgentCommandStatus, error) {
+	c, err := s.clusters.Get(req.Cluster)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	cmd, ok := c.Agents.Command(req.Id)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown command %s", req.Id)
+	}
+	return agentCommandToProto(cmd), nil
+}
+
+// samplesFromProto converts the metrics sent with a heartbeat
+func samplesFromProto(metrics []*proto.ContainerMetrics) []cluster.ContainerSample {
+	samples := make([]cluster.ContainerSample, 0, len(metrics))
+	for _, m := range metrics {
+		samples = append(samples, cluster.ContainerSample{
+			Time:        time.UnixMilli(m.TimestampUnixMs),
+			ContainerID: m.ContainerId,
+			Container:   m.Container,
+			Service:     m.Service,
+			TaskID:      m.TaskId,
+			CPUPercent:  m.CpuPercent,
+			MemoryUsage: m.MemoryUsage,
+			MemoryLimit: m.MemoryLimit,
+			NetRx:       m.NetRxBytes,
+			NetTx:       m.NetTxBytes,
+			BlockRead:   m.BlockReadBytes,
+			BlockWrite:  m.BlockWriteBytes,
+		})
+	}
+	return samples
+}
+
+func agentCommandToProto(cmd cluster.AgentCommand) *proto.AgentCommandStatus {
+	return &proto.AgentCommandStatus{
+		Id:          cmd.ID,
+		NodeId:      cmd.NodeID,
+		Type:        cmd.Type,
+		Target:      cmd.Target,
+		State:       cmd.State,
+		Message:     cmd.Message,
+		CreatedUnix: cmd.Created.Unix(),
+	}
+}
+
+// agentToProto converts what a node's agent reports for the cluster APIs
+func agentToProto(agent *node.Agent) *proto.AgentInfo {
+	info := &proto.AgentInfo{
+		Id:                agent.ID,
+		Version:           agent.Version,
+		DockerVersion:     agent.DockerVersion,
+		Os:                agent.OS,
+		Arch:              agent.Arch,
+		KernelVersion:     agent.KernelVersion,
+		RegisteredUnix:    agent.Registered.Unix(),
+		LastHeartbeatUnix: agent.LastHeartbeat.Unix(),
+		Health:            agent.Health,
+		Problems:          agent.Problems,
+	}
+	for _, c := range agent.Containers {
+		info.Containers = append(info.Containers, &proto.ContainerStatus{
+			Id:           c.ID,
+			Name:         c.Name,
+			Image:        c.Image,
+			State:        c.State,
+			Health:       c.Health,
+			Service:      c.Service,
+			TaskId:       c.TaskID,
+			RestartCount: int32(c.RestartCount),
+			ExitCode:     int32(c.ExitCode),
+			OomKilled:    c.OOMKilled,
+		})
+	}
+	return info
+}
+
+func agentTokenToProto(token auth.AgentToken) *proto.AgentToken {
+	return &proto.AgentToken{
+		Id:          token.ID,
+		Cluster:     token.Cluster,
+		NodeId:      token.NodeID,
+		Hostname:    token.Hostname,
+		CreatedUnix: token.Created.Unix(),
+	}
+}
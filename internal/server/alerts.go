@@ -0,0 +1,195 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/api/proto"
+	"github.com/jasonlovesdoggo/velo/internal/alerting"
+	"github.com/jasonlovesdoggo/velo/internal/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AlertServer implements the proto.AlertServiceServer interface
+type AlertServer struct {
+	proto.UnimplementedAlertServiceServer
+	engine *alerting.Engine
+}
+
+// NewAlertServer creates a new AlertServer for an alerting engine
+func NewAlertServer(engine *alerting.Engine) *AlertServer {
+	return &AlertServer{engine: engine}
+}
+
+// ListAlerts handles the ListAlerts RPC call
+func (s *AlertServer) ListAlerts(ctx context.Context, req *proto.ListAlertsRequest) (*proto.ListAlertsResponse, error) {
+	log.Info("Received ListAlerts request")
+
+	resp := &proto.ListAlertsResponse{}
+	for _, a := range s.engine.Alerts() {
+		alert := &proto.Alert{
+			Fingerprint:     a.Fingerprint,
+			Rule:            a.Rule,
+			State:           a.State,
+			Labels:          a.Labels,
+			Summary:         a.Summary,
+			Value:           a.Value,
+			ActiveSinceUnix: a.ActiveSince.Unix(),
+			SilencedBy:      a.SilencedBy,
+		}
+		if !a.FiredAt.IsZero() {
+			alert.FiredAtUnix = a.FiredAt.Unix()
+		}
+		resp.Alerts = append(resp.Alerts, alert)
+	}
+	return resp, nil
+}
+
+// ListAlertRules handles the ListAlertRules RPC call
+func (s *AlertServer) ListAlertRules(ctx context.Context, req *proto.ListAlertRulesRequest) (*proto.ListAlertRulesResponse, error) {
+	log.Info("Received ListAlertRules request")
+
+	rules, err := s.engine.Rules()
+	if err != nil {
+		log.Error("Failed to list alert rules", "error", err)
+		return nil, fmt.Errorf("failed to list alert rules: %w", err)
+	}
+
+	resp := &proto.ListAlertRulesResponse{Receivers: s.engine.Receivers()}
+	for _, rule := range rules {
+		resp.Rules = append(resp.Rules, alertRuleToProto(rule))
+	}
+	return resp, nil
+}
+
+// SetAlertRule handles the SetAlertRule RPC call, creating or replacing a rule
+func (s *AlertServer) SetAlertRule(ctx context.Context, req *proto.AlertRule) (*proto.AlertRule, error) {
+	log.Info("Received SetAlertRule request", "name", req.Name, "condition", req.Condition)
+
+	rule, err := s.engine.SetRule(alerting.Rule{
+		Name:           req.Name,
+		Condition:      req.Condition,
+		Cluster:        req.Cluster,
+		Service:        req.Service,
+		Threshold:      req.Threshold,
+		Target:         req.Target,
+		Within:         time.Duration(req.WithinSeconds) * time.Second,
+		For:            time.Duration(req.ForSeconds) * time.Second,
+		Severity:       req.Severity,
+		Labels:         req.Labels,
+		GroupBy:        req.GroupBy,
+		RepeatInterval: time.Duration(req.RepeatIntervalSeconds) * time.Second,
+		Receivers:      req.Receivers,
+	})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return alertRuleToProto(rule), nil
+}
+
+// DeleteAlertRule handles the DeleteAlertRule RPC call
+func (s *AlertServer) DeleteAlertRule(ctx context.Context, req *proto.DeleteAlertRuleRequest) (*proto.GenericResponse, error) {
+	log.Info("Received DeleteAlertRule request", "name", req.Name)
+
+	if err := s.engine.DeleteRule(req.Name); err != nil {
+		return &proto.GenericResponse{
+			Message: fmt.Sprintf("Failed to delete alert rule %s: %v", req.Name, err),
+			Success: false,
+		}, nil
+	}
+
+	return &proto.GenericResponse{
+		Message: fmt.Sprintf("Alert rule %s deleted", req.Name),
+		Success: true,
+	}, nil
+}
+
+// ListSilences handles the ListSilences RPC call
+func (s *AlertServer) ListSilences(ctx context.Context, req *proto.ListSilencesRequest) (*proto.ListSilencesResponse, error) {
+	log.Info("Received ListSilences request")
+
+	silences, err := s.engine.Silences()
+	if err != nil {
+		log.Error("Failed to list silences", "error", err)
+		return nil, fmt.Errorf("failed to list silences: %w", err)
+	}
+
+	resp := &proto.ListSilencesResponse{}
+	for _, silence := range silences {
+		resp.Silences = append(resp.Silences, silenceToProto(silence))
+	}
+	return resp, nil
+}
+
+// CreateSilence handles the CreateSilence RPC call
+func (s *AlertServer) CreateSilence(ctx context.Context, req *proto.CreateSilenceRequest) (*proto.Silence, error) {
+	log.Info("Received CreateSilence request", "matchers", req.Matchers, "duration", req.DurationSeconds, "by", req.CreatedBy)
+
+	matchers := make([]alerting.Matcher, 0, len(req.Matchers))
+	for _, m := range req.Matchers {
+		matcher, err := alerting.ParseMatcher(m)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		matchers = append(matchers, matcher)
+	}
+
+	silence, err := s.engine.CreateSilence(matchers, time.Duration(req.DurationSeconds)*time.Second, req.Comment, req.CreatedBy)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return silenceToProto(silence), nil
+}
+
+// ExpireSilence handles the ExpireSilence RPC call
+func (s *AlertServer) ExpireSilence(ctx context.Context, req *proto.ExpireSilenceRequest) (*proto.GenericResponse, error) {
+	log.Info("Received ExpireSilence request", "id", req.Id)
+
+	if err := s.engine.ExpireSilence(req.Id); err != nil {
+		return &proto.GenericResponse{
+			Message: fmt.Sprintf("Failed to expire silence %s: %v", req.Id, err),
+			Success: false,
+		}, nil
+	}
+
+	return &proto.GenericResponse{
+		Message: fmt.Sprintf("Silence %s expired", req.Id),
+		Success: true,
+	}, nil
+}
+
+func alertRuleToProto(rule alerting.Rule) *proto.AlertRule {
+	return &proto.AlertRule{
+		Name:                  rule.Name,
+		Condition:             rule.Condition,
+		Cluster:               rule.Cluster,
+		Service:               rule.Service,
+		Threshold:             rule.Threshold,
+		Target:                rule.Target,
+		WithinSeconds:         int64(rule.Within.Seconds()),
+		ForSeconds:            int64(rule.For.Seconds()),
+		Severity:              rule.Severity,
+		Labels:                rule.Labels,
+		GroupBy:               rule.GroupBy,
+		RepeatIntervalSeconds: int64(rule.RepeatInterval.Seconds()),
+		Receivers:             rule.Receivers,
+		CreatedUnix:           rule.Created.Unix(),
+		UpdatedUnix:           rule.Updated.Unix(),
+	}
+}
+
+func silenceToProto(silence alerting.Silence) *proto.Silence {
+	resp := &proto.Silence{
+		Id:         silence.ID,
+		Comment:    silence.Comment,
+		CreatedBy:  silence.CreatedBy,
+		StartsUnix: silence.StartsAt.Unix(),
+		EndsUnix:   silence.EndsAt.Unix(),
+	}
+	for _, m := range silence.Matchers {
+		resp.Matchers = append(resp.Matchers, m.String())
+	}
+	return resp
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/api/proto"
+	"github.com/jasonlovesdoggo/velo/internal/audit"
+	"github.com/jasonlovesdoggo/velo/internal/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AuditServer implements the proto.AuditServiceServer interface
+type AuditServer struct {
+	proto.UnimplementedAuditServiceServer
+	log *audit.Log
+}
+
+// NewAuditServer creates a new AuditServer for an audit log
+func NewAuditServer(l *audit.Log) *AuditServer {
+	return &AuditServer{log: l}
+}
+
+// ListAuditEntries handles the ListAuditEntries RPC call
+func (s *AuditServer) ListAuditEntries(ctx context.Context, req *proto.ListAuditEntriesRequest) (*proto.ListAuditEntriesResponse, error) {
+	log.Info("Received ListAuditEntries request", "actor", req.Actor, "action", req.Action, "target", req.Target)
+
+	filter := audit.Filter{
+		Actor:  req.Actor,
+		Source: req.Source,
+		Action: req.Action,
+		Target: req.Target,
+		Result: req.Result,
+		Limit:  int(req.Limit),
+	}
+	if req.SinceUnix > 0 {
+		filter.Since = time.Unix(req.SinceUnix, 0)
+	}
+	if req.UntilUnix > 0 {
+		filter.Until = time.Unix(req.UntilUnix, 0)
+	}
+
+	entries, err := s.log.Query(filter)
+	if err != nil {
+		log.Error("Failed to query the audit log", "error", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &proto.ListAuditEntriesResponse{}
+	for _, e := range entries {
+		resp.Entries = append(resp.Entries, &proto.AuditEntry{
+			Id:           e.ID,
+			TimeUnixNano: e.Time.UnixNano(),
+			Actor:        e.Actor,
+			Source:       e.Source,
+			Ip:           e.IP,
+			Action:       e.Action,
+			Target:       e.Target,
+			Cluster:      e.Cluster,
+			Params:       e.Params,
+			Result:       e.Result,
+			Error:        e.Error,
+		})
+	}
+	return resp, nil
+}
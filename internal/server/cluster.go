@@ -0,0 +1,210 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/api/proto"
+	"github.com/jasonlovesdoggo/velo/internal/auth"
+	"github.com/jasonlovesdoggo/velo/internal/log"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/cluster"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/manager"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ClusterServer implements the proto.ClusterServiceServer interface
+type ClusterServer struct {
+	proto.UnimplementedClusterServiceServer
+	clusters    *cluster.Registry
+	authService *auth.AuthService
+}
+
+// NewClusterServer creates a new ClusterServer for the registered clusters
+func NewClusterServer(clusters *cluster.Registry, authService *auth.AuthService) *ClusterServer {
+	return &ClusterServer{clusters: clusters, authService: authService}
+}
+
+// nodeManager returns the node operations of a cluster, if its backend supports them
+func (s *ClusterServer) nodeManager(clusterName string) (manager.NodeManager, error) {
+	m, err := managerFor(s.clusters, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	nm, ok := m.(manager.NodeManager)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "node operations are not supported by this backend")
+	}
+	return nm, nil
+}
+
+// DrainNode handles the DrainNode RPC call, streaming progress until the node is drained
+func (s *ClusterServer) DrainNode(req *proto.DrainNodeRequest, stream proto.ClusterService_DrainNodeServer) error {
+	log.Info("Received DrainNode request", "node", req.NodeId, "force", req.Force, "cluster", req.Cluster)
+
+	nm, err := s.nodeManager(req.Cluster)
+	if err != nil {
+		return err
+	}
+
+	opts := manager.DrainOptions{
+		Timeout: time.Duration(req.TimeoutSeconds) * time.Second,
+		Force:   req.Force,
+		Progress: func(p manager.DrainProgress) {
+			if err := stream.Send(&proto.DrainNodeProgress{
+				Phase:     p.Phase,
+				Total:     int32(p.Total),
+				Remaining: int32(p.Remaining),
+				Pending:   int32(p.Pending),
+				Message:   p.Message,
+				Warnings:  p.Warnings,
+			}); err != nil {
+				log.Warn("Failed to send drain progress", "error", err)
+			}
+		},
+	}
+
+	report, err := nm.DrainNodeAndWait(stream.Context(), req.NodeId, opts)
+	if err != nil {
+		log.Error("Failed to drain node", "node", req.NodeId, "error", err)
+		return status.Errorf(codes.FailedPrecondition, "failed to drain node: %v", err)
+	}
+
+	log.Info("Node drained", "node", report.NodeID, "moved", report.Moved, "duration", report.Duration)
+	return nil
+}
+
+// ActivateNode handles the ActivateNode RPC call
+func (s *ClusterServer) ActivateNode(ctx context.Context, req *proto.NodeRequest) (*proto.GenericResponse, error) {
+	log.Info("Received ActivateNode request", "node", req.NodeId, "cluster", req.Cluster)
+
+	nm, err := s.nodeManager(req.Cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := nm.ActivateNode(req.NodeId); err != nil {
+		log.Error("Failed to activate node", "node", req.NodeId, "error", err)
+		return &proto.GenericResponse{
+			Message: fmt.Sprintf("Failed to activate node: %v", err),
+			Success: false,
+		}, nil
+	}
+
+	return &proto.GenericResponse{
+		Message: "Node activated successfully",
+		Success: true,
+	}, nil
+}
+
+// Rebalance handles the Rebalance RPC call
+func (s *ClusterServer) Rebalance(ctx context.Context, req *proto.RebalanceRequest) (*proto.RebalanceResponse, error) {
+	log.Info("Received Rebalance request", "cluster", req.Cluster)
+
+	nm, err := s.nodeManager(req.Cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	services, err := nm.RebalanceServices(ctx)
+	if err != nil {
+		log.Error("Failed to rebalance services", "error", err)
+		return nil, fmt.Errorf("failed to rebalance services: %w", err)
+	}
+
+	return &proto.RebalanceResponse{Services: services}, nil
+}
+
+// GetCapacity handles the GetCapacity RPC call
+func (s *ClusterServer) GetCapacity(ctx context.Context, req *proto.CapacityRequest) (*proto.CapacityResponse, error) {
+	m, err := managerFor(s.clusters, req.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	cm, ok := m.(manager.CapacityManager)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "capacity reporting is not supported by this backend")
+	}
+
+	capacities, err := cm.CapacityReport(ctx)
+	if err != nil {
+		log.Error("Failed to build capacity report", "error", err)
+		return nil, fmt.Errorf("failed to build capacity report: %w", err)
+	}
+
+	resp := &proto.CapacityResponse{}
+	for _, c := range capacities {
+		resp.Nodes = append(resp.Nodes, &proto.NodeCapacity{
+			NodeId:              c.NodeID,
+			Hostname:            c.Hostname,
+			Availability:        c.Availability,
+			Eligible:            c.Eligible,
+			NanoCpus:            c.NanoCPUs,
+			MemoryBytes:         c.MemoryBytes,
+			ReservedNanoCpus:    c.ReservedCPUs,
+			ReservedMemoryBytes: c.ReservedMemory,
+			Tasks:               int32(c.Tasks),
+		})
+	}
+	return resp, nil
+}
+
+// ListNodes handles the ListNodes RPC call. Nodes include what their agents
+// report; hosts that only have an agent, such as standalone ones, are listed too.
+func (s *ClusterServer) ListNodes(ctx context.Context, req *proto.ListNodesRequest) (*proto.ListNodesResponse, error) {
+	log.Info("Received ListNodes request", "cluster", req.Cluster)
+
+	c, err := s.clusters.Get(req.Cluster)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	resp := &proto.ListNodesResponse{}
+	for _, n := range c.Nodes() {
+		info := &proto.NodeInfo{
+			Id:           n.ID,
+			Hostname:     n.Hostname,
+			Address:      n.Address,
+			Role:         n.Role,
+			Availability: n.Availability,
+			Conditions:   n.Conditions,
+			Manager:      n.Manager,
+			Labels:       n.Labels,
+			CpuCores:     int32(n.Capacity.CPU),
+			MemoryBytes:  n.Capacity.Memory,
+			DiskBytes:    n.Capacity.Disk,
+			Gpus:         int32(n.Capacity.GPU),
+			AgentMissing: n.AgentMissing,
+		}
+		if n.Agent != nil {
+			info.Agent = agentToProto(n.Agent)
+		}
+		resp.Nodes = append(resp.Nodes, info)
+	}
+	return resp, nil
+}
+
+// ListClusters handles the ListClusters RPC call
+func (s *ClusterServer) ListClusters(ctx context.Context, req *proto.ListClustersRequest) (*proto.ListClustersResponse, error) {
+	log.Info("Received ListClusters request")
+
+	resp := &proto.ListClustersResponse{}
+	for _, c := range s.clusters.List() {
+		info := &proto.ClusterInfo{
+			Name:          c.Name,
+			Backend:       c.Backend,
+			Host:          c.Host,
+			Default:       c.Default,
+			Healthy:       c.Healthy,
+			Error:         c.Error,
+			Nodes:         int32(c.Nodes),
+			MissingAgents: int32(c.MissingAgents),
+		}
+		if !c.LastCheck.IsZero() {
+			info.LastCheckUnix = c.LastCheck.Unix()
+		}
+		resp.Clusters = append(resp.Clusters, info)
+	}
+	return resp, nil
+}
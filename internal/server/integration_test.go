@@ -0,0 +1,1546 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/jasonlovesdoggo/velo/api/proto"
+	"github.com/jasonlovesdoggo/velo/internal/admission"
+	"github.com/jasonlovesdoggo/velo/internal/agent"
+	"github.com/jasonlovesdoggo/velo/internal/alerting"
+	"github.com/jasonlovesdoggo/velo/internal/audit"
+	"github.com/jasonlovesdoggo/velo/internal/auth"
+	"github.com/jasonlovesdoggo/velo/internal/config"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/cluster"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/dockertest"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/manager"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/sim"
+	"github.com/jasonlovesdoggo/velo/internal/policy"
+	"github.com/jasonlovesdoggo/velo/internal/state"
+	"github.com/jasonlovesdoggo/velo/internal/webhooks"
+	"github.com/jasonlovesdoggo/velo/pkg/client"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// startSimCluster serves the gRPC API against a simulated cluster and returns a connected client
+func startSimCluster(t *testing.T) (*sim.Orchestrator, *client.Client) {
+	t.Helper()
+
+	orchestrator := sim.New(sim.Options{TickInterval: 10 * time.Millisecond})
+	return orchestrator, serveBackend(t, orchestrator)
+}
+
+// startDockerSwarm serves the gRPC API against a SwarmManager connected to a fake
+// three node Docker swarm and returns a connected client
+func startDockerSwarm(t *testing.T) (*dockertest.Server, *manager.SwarmManager, *client.Client) {
+	t.Helper()
+
+	docker := dockertest.NewServer(dockertest.Options{Swarm: true, Hostname: "manager-1"})
+	docker.AddNode("worker-1", swarm.NodeRoleWorker, nil)
+	docker.AddNode("worker-2", swarm.NodeRoleWorker, nil)
+	t.Cleanup(docker.Close)
+
+	cli, err := docker.Client()
+	if err != nil {
+		t.Fatalf("Failed to create Docker client: %v", err)
+	}
+	t.Cleanup(func() { cli.Close() })
+
+	swarmManager := manager.NewSwarmManagerWithClient(cli)
+	return docker, swarmManager, serveBackend(t, swarmManager)
+}
+
+// serveBackend starts a backend, serves the gRPC API against it and returns a connected client
+func serveBackend(t *testing.T, backend manager.Backend) *client.Client {
+	t.Helper()
+
+	if err := backend.Start(); err != nil {
+		t.Fatalf("Failed to start backend: %v", err)
+	}
+	t.Cleanup(backend.Stop)
+
+	return serveClusters(t, cluster.Single(backend))
+}
+
+// serveClusters serves the gRPC API against registered clusters and returns a connected client
+func serveClusters(t *testing.T, clusters *cluster.Registry) *client.Client {
+	t.Helper()
+	return serve(t, NewDeploymentServer(clusters, auth.NewAuthService(state.NewMemoryStateStore())))
+}
+
+// serveAgent serves the gRPC API of clusters and returns a client connected as
+// an admin and one authenticated with the agent token of a node
+func serveAgent(t *testing.T, clusters *cluster.Registry, node string) (*client.Client, *client.Client) {
+	t.Helper()
+
+	srv := NewDeploymentServer(clusters, auth.NewAuthService(state.NewMemoryStateStore()))
+	lis := bufconn.Listen(1 << 20)
+	srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	admin := connect(t, lis, login(t, srv.authService, "test-admin", auth.RoleAdmin))
+	token, err := admin.CreateAgentToken(context.Background(), node)
+	if err != nil {
+		t.Fatalf("CreateAgentToken failed: %v", err)
+	}
+	return admin, connect(t, lis, token.Token)
+}
+
+// serve serves the gRPC API of srv and returns a client connected as an admin
+func serve(t *testing.T, srv *DeploymentServer) *client.Client {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	return connect(t, lis, login(t, srv.authService, "test-admin", auth.RoleAdmin))
+}
+
+// login creates a user with a role and returns a token of theirs
+func login(t *testing.T, authService *auth.AuthService, username, role string) string {
+	t.Helper()
+
+	if _, err := authService.AddUser(username, username+"-password", role); err != nil {
+		t.Fatalf("Failed to create user %s: %v", username, err)
+	}
+	token, err := authService.Authenticate(username, username+"-password")
+	if err != nil {
+		t.Fatalf("Failed to log in as %s: %v", username, err)
+	}
+	return token.Value
+}
+
+// connect returns a client connected to a served listener, authenticated with
+// a token unless it's empty
+func connect(t *testing.T, lis *bufconn.Listener, token string) *client.Client {
+	t.Helper()
+
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+	}
+	if token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(client.BearerToken(token)))
+	}
+	conn, err := grpc.NewClient("passthrough:///bufnet", opts...)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return client.NewClientWithConn(conn)
+}
+
+// waitForStatus polls a deployment until it reaches the wanted state
+func waitForStatus(t *testing.T, c *client.Client, id, want string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := c.GetStatus(context.Background(), id)
+		if err != nil {
+			t.Fatalf("GetStatus failed: %v", err)
+		}
+		if resp.Status == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s to be %s, last status %s\n%s", id, want, resp.Status, resp.Logs)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestIntegrationDeployScaleRollback(t *testing.T) {
+	_, c := startSimCluster(t)
+	ctx := context.Background()
+
+	resp, err := c.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "web", Image: "nginx:1", Replicas: 2})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	waitForStatus(t, c, resp.DeploymentId, "running")
+
+	scaled, err := c.Scale(ctx, resp.DeploymentId, 3)
+	if err != nil || !scaled.Success {
+		t.Fatalf("Scale failed: %v %v", err, scaled)
+	}
+	waitForStatus(t, c, resp.DeploymentId, "running")
+
+	// Deploying the same service name again is rejected
+	if _, err := c.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "web", Image: "nginx:2"}); err == nil {
+		t.Error("Expected duplicate deploy to fail")
+	}
+
+	rolledBack, err := c.Rollback(ctx, resp.DeploymentId)
+	if err != nil || !rolledBack.Success {
+		t.Fatalf("Rollback failed: %v %v", err, rolledBack)
+	}
+	if _, err := c.GetStatus(ctx, resp.DeploymentId); err == nil {
+		t.Error("Expected status of rolled back deployment to fail")
+	}
+}
+
+func TestIntegrationImagePullFailure(t *testing.T) {
+	cluster, c := startSimCluster(t)
+	cluster.FailImagePulls("nginx:does-not-exist", "")
+
+	resp, err := c.DeployWithRequest(context.Background(), &proto.DeployRequest{ServiceName: "web", Image: "nginx:does-not-exist", Replicas: 1})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	waitForStatus(t, c, resp.DeploymentId, "failed")
+}
+
+func TestIntegrationDrainNode(t *testing.T) {
+	_, c := startSimCluster(t)
+	ctx := context.Background()
+
+	resp, err := c.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "web", Image: "nginx:1", Replicas: 3})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	waitForStatus(t, c, resp.DeploymentId, "running")
+
+	var last *proto.DrainNodeProgress
+	if err := c.DrainNode(ctx, "worker-2", 5*time.Second, false, func(p *proto.DrainNodeProgress) { last = p }); err != nil {
+		t.Fatalf("DrainNode failed: %v", err)
+	}
+	if last == nil || last.Phase != "complete" {
+		t.Fatalf("Expected drain to complete, last progress %v", last)
+	}
+	waitForStatus(t, c, resp.DeploymentId, "running")
+
+	capacity, err := c.GetCapacity(ctx)
+	if err != nil {
+		t.Fatalf("GetCapacity failed: %v", err)
+	}
+	for _, n := range capacity.Nodes {
+		if n.Hostname == "worker-2" && (n.Eligible || n.Tasks != 0) {
+			t.Errorf("Expected drained node to be empty and ineligible, got %+v", n)
+		}
+	}
+
+	if activated, err := c.ActivateNode(ctx, "worker-2"); err != nil || !activated.Success {
+		t.Fatalf("ActivateNode failed: %v %v", err, activated)
+	}
+}
+
+func TestIntegrationDockerSwarm(t *testing.T) {
+	docker, swarmManager, c := startDockerSwarm(t)
+	ctx := context.Background()
+
+	resp, err := c.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "web", Image: "nginx:1", Replicas: 3})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	waitForStatus(t, c, resp.DeploymentId, "running")
+
+	var last *proto.DrainNodeProgress
+	if err := c.DrainNode(ctx, "worker-1", 5*time.Second, false, func(p *proto.DrainNodeProgress) { last = p }); err != nil {
+		t.Fatalf("DrainNode failed: %v", err)
+	}
+	if last == nil || last.Phase != "complete" {
+		t.Fatalf("Expected drain to complete, last progress %v", last)
+	}
+
+	// Rolling back an updated service restores its previous spec
+	if err := swarmManager.UpdateService(resp.DeploymentId, config.ServiceDefinition{Image: "nginx:2"}); err != nil {
+		t.Fatalf("UpdateService failed: %v", err)
+	}
+	rolledBack, err := c.Rollback(ctx, resp.DeploymentId)
+	if err != nil || !rolledBack.Success {
+		t.Fatalf("Rollback failed: %v %v", err, rolledBack)
+	}
+	for _, task := range docker.Tasks(resp.DeploymentId) {
+		if task.DesiredState == swarm.TaskStateRunning && task.Spec.ContainerSpec.Image != "nginx:1" {
+			t.Errorf("Expected rolled back tasks to run nginx:1, found %s", task.Spec.ContainerSpec.Image)
+		}
+	}
+
+	// Without a previous version the deployment is removed
+	other, err := c.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "api", Image: "api:1", Replicas: 1})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	if rolledBack, err := c.Rollback(ctx, other.DeploymentId); err != nil || !rolledBack.Success {
+		t.Fatalf("Rollback failed: %v %v", err, rolledBack)
+	}
+	if len(docker.Services()) != 1 {
+		t.Errorf("Expected only the web service to remain, got %d service(s)", len(docker.Services()))
+	}
+}
+
+func TestIntegrationMultipleClusters(t *testing.T) {
+	clusters := cluster.NewRegistry()
+	docker := make(map[string]*dockertest.Server)
+	for _, name := range []string{"prod", "staging"} {
+		srv := dockertest.NewServer(dockertest.Options{Swarm: true, Hostname: name + "-manager"})
+		srv.AddNode(name+"-worker", swarm.NodeRoleWorker, nil)
+		t.Cleanup(srv.Close)
+		docker[name] = srv
+
+		cli, err := cluster.NewDockerClient(config.ClusterConfig{Name: name, Host: srv.Host()})
+		if err != nil {
+			t.Fatalf("Failed to create Docker client: %v", err)
+		}
+		t.Cleanup(func() { cli.Close() })
+		if err := clusters.Register(&cluster.Cluster{Name: name, Backend: config.BackendSwarm, Host: srv.Host(), Manager: manager.NewSwarmManagerWithClient(cli)}); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+	clusters.Start(time.Minute)
+	t.Cleanup(clusters.Stop)
+
+	c := serveClusters(t, clusters)
+	ctx := context.Background()
+
+	list, err := c.ListClusters(ctx)
+	if err != nil {
+		t.Fatalf("ListClusters failed: %v", err)
+	}
+	if len(list.Clusters) != 2 || !list.Clusters[0].Default || !list.Clusters[0].Healthy || list.Clusters[1].Nodes != 2 {
+		t.Fatalf("Unexpected clusters %v", list.Clusters)
+	}
+
+	// Requests without a cluster go to the default one
+	if _, err := c.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "web", Image: "nginx:1"}); err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	c.UseCluster("staging")
+	resp, err := c.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "api", Image: "api:1", Replicas: 2})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	waitForStatus(t, c, resp.DeploymentId, "running")
+
+	if services := docker["prod"].Services(); len(services) != 1 || services[0].Spec.Name != "web" {
+		t.Errorf("Expected only web on prod, got %d service(s)", len(services))
+	}
+	if services := docker["staging"].Services(); len(services) != 1 || services[0].Spec.Name != "api" {
+		t.Errorf("Expected only api on staging, got %d service(s)", len(services))
+	}
+
+	nodes, err := c.ListNodes(ctx)
+	if err != nil {
+		t.Fatalf("ListNodes failed: %v", err)
+	}
+	for _, n := range nodes.Nodes {
+		if n.Hostname != "staging-manager" && n.Hostname != "staging-worker" {
+			t.Errorf("Expected staging nodes, got %s", n.Hostname)
+		}
+	}
+
+	c.UseCluster("dev")
+	if _, err := c.GetStatus(ctx, resp.DeploymentId); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for an unknown cluster, got %v", err)
+	}
+}
+
+func TestIntegrationJoinWithBootstrapToken(t *testing.T) {
+	_, _, c := startDockerSwarm(t)
+	ctx := context.Background()
+
+	token, err := c.CreateBootstrapToken(ctx, "worker", time.Hour, "new box")
+	if err != nil {
+		t.Fatalf("CreateBootstrapToken failed: %v", err)
+	}
+	tokens, err := c.ListBootstrapTokens(ctx)
+	if err != nil || len(tokens.Tokens) != 1 || tokens.Tokens[0].Token != "" {
+		t.Fatalf("Expected one listed token without its secret, got %v (%v)", tokens, err)
+	}
+
+	resp, err := c.Join(ctx, token.Token, "worker-3")
+	if err != nil {
+		t.Fatalf("Join failed: %v", err)
+	}
+	if resp.Role != "worker" || len(resp.ManagerAddrs) == 0 || resp.SwarmJoinToken == "" || resp.AgentToken == "" {
+		t.Fatalf("Unexpected join response %v", resp)
+	}
+
+	// The new host joins the swarm with what the manager handed out
+	host := dockertest.NewServer(dockertest.Options{Hostname: "worker-3"})
+	t.Cleanup(host.Close)
+	cli, err := host.Client()
+	if err != nil {
+		t.Fatalf("Failed to create Docker client: %v", err)
+	}
+	t.Cleanup(func() { cli.Close() })
+	if _, err := agent.JoinSwarm(ctx, cli, agent.JoinOptions{JoinToken: resp.SwarmJoinToken, ManagerAddrs: resp.ManagerAddrs}); err != nil {
+		t.Fatalf("JoinSwarm failed: %v", err)
+	}
+	joins := host.JoinRequests()
+	if len(joins) != 1 || joins[0].JoinToken != resp.SwarmJoinToken || joins[0].RemoteAddrs[0] != resp.ManagerAddrs[0] {
+		t.Errorf("Unexpected swarm join requests %v", joins)
+	}
+	if _, err := agent.JoinSwarm(ctx, cli, agent.JoinOptions{JoinToken: resp.SwarmJoinToken, ManagerAddrs: resp.ManagerAddrs}); !errors.Is(err, agent.ErrAlreadyInSwarm) {
+		t.Errorf("Expected joining twice to fail with ErrAlreadyInSwarm, got %v", err)
+	}
+
+	// Bootstrap tokens are single-use
+	if _, err := c.Join(ctx, token.Token, "worker-4"); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected a used token to be rejected, got %v", err)
+	}
+
+	revoked, err := c.CreateBootstrapToken(ctx, "manager", time.Hour, "")
+	if err != nil {
+		t.Fatalf("CreateBootstrapToken failed: %v", err)
+	}
+	if resp, err := c.RevokeBootstrapToken(ctx, revoked.Id); err != nil || !resp.Success {
+		t.Fatalf("RevokeBootstrapToken failed: %v %v", err, resp)
+	}
+	if _, err := c.Join(ctx, revoked.Token, "worker-4"); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected a revoked token to be rejected, got %v", err)
+	}
+}
+
+func TestIntegrationAgentHeartbeatsAndCommands(t *testing.T) {
+	docker := dockertest.NewServer(dockertest.Options{Swarm: true, Hostname: "manager-1"})
+	docker.AddNode("worker-1", swarm.NodeRoleWorker, nil)
+	docker.AddImage("nginx")
+	t.Cleanup(docker.Close)
+
+	cli, err := docker.Client()
+	if err != nil {
+		t.Fatalf("Failed to create Docker client: %v", err)
+	}
+	t.Cleanup(func() { cli.Close() })
+	ctx := context.Background()
+	created, err := cli.ContainerCreate(ctx, &container.Config{Image: "nginx"}, nil, nil, nil, "web")
+	if err != nil {
+		t.Fatalf("ContainerCreate failed: %v", err)
+	}
+	if err := cli.ContainerStart(ctx, created.ID, container.StartOptions{}); err != nil {
+		t.Fatalf("ContainerStart failed: %v", err)
+	}
+
+	swarmManager := manager.NewSwarmManagerWithClient(cli)
+	if err := swarmManager.Start(); err != nil {
+		t.Fatalf("Failed to start backend: %v", err)
+	}
+	t.Cleanup(swarmManager.Stop)
+	clusters := cluster.NewRegistry()
+	_ = clusters.Register(&cluster.Cluster{Name: "default", Manager: swarmManager, Agents: cluster.NewAgentTracker(20 * time.Millisecond)})
+	c, agentClient := serveAgent(t, clusters, "manager-1")
+
+	// The agent runs on the manager node; the worker has none
+	a, err := agent.NewContainerAgentWithClient(cli)
+	if err != nil {
+		t.Fatalf("NewContainerAgentWithClient failed: %v", err)
+	}
+	if err := a.Start(); err != nil {
+		t.Fatalf("Failed to start agent: %v", err)
+	}
+	t.Cleanup(a.Stop)
+	a.ReportTo(agentClient.Conn(), "")
+
+	var managerNode *proto.NodeInfo
+	deadline := time.Now().Add(5 * time.Second)
+	for managerNode == nil || managerNode.Agent == nil || len(managerNode.Agent.Containers) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for the agent to report, last node %v", managerNode)
+		}
+		time.Sleep(10 * time.Millisecond)
+		nodes, err := c.ListNodes(ctx)
+		if err != nil {
+			t.Fatalf("ListNodes failed: %v", err)
+		}
+		for _, n := range nodes.Nodes {
+			switch n.Hostname {
+			case "manager-1":
+				managerNode = n
+			case "worker-1":
+				if !n.AgentMissing || n.Agent != nil {
+					t.Errorf("Expected the worker's agent to be flagged missing, got %v", n)
+				}
+			}
+		}
+	}
+	if managerNode.AgentMissing || managerNode.Agent.DockerVersion == "" || managerNode.Agent.Containers[0].Name != "web" {
+		t.Errorf("Unexpected agent report %v", managerNode.Agent)
+	}
+
+	clusterList, err := c.ListClusters(ctx)
+	if err != nil || clusterList.Clusters[0].MissingAgents != 1 {
+		t.Errorf("Expected one node without agent, got %v (%v)", clusterList, err)
+	}
+
+	// Commands are delivered with the next heartbeat and their results reported back
+	cmd, err := c.SendAgentCommand(ctx, managerNode.Id, cluster.CommandStopContainer, "web")
+	if err != nil {
+		t.Fatalf("SendAgentCommand failed: %v", err)
+	}
+	deadline = time.Now().Add(5 * time.Second)
+	for cmd.State != cluster.CommandSucceeded {
+		if cmd.State == cluster.CommandFailed || time.Now().After(deadline) {
+			t.Fatalf("Expected the command to succeed, got %v", cmd)
+		}
+		time.Sleep(10 * time.Millisecond)
+		if cmd, err = c.GetAgentCommand(ctx, cmd.Id); err != nil {
+			t.Fatalf("GetAgentCommand failed: %v", err)
+		}
+	}
+	if inspect, err := cli.ContainerInspect(ctx, created.ID); err != nil || inspect.State.Running {
+		t.Errorf("Expected the agent to stop the container, got %v", err)
+	}
+
+	if _, err := c.SendAgentCommand(ctx, "worker-1", cluster.CommandPruneImages, ""); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected a node without agent to be rejected, got %v", err)
+	}
+	if _, err := c.SendAgentCommand(ctx, managerNode.Id, "reboot", ""); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected an unknown command to be rejected, got %v", err)
+	}
+}
+
+func TestIntegrationAgentAuthentication(t *testing.T) {
+	clusters := cluster.Single(sim.New(sim.Options{}))
+	srv := NewDeploymentServer(clusters, auth.NewAuthService(state.NewMemoryStateStore()))
+	lis := bufconn.Listen(1 << 20)
+	srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	admin := connect(t, lis, login(t, srv.authService, "root", auth.RoleAdmin))
+	ctx := context.Background()
+
+	register := func(c *client.Client, nodeID, hostname string) (*proto.RegisterAgentResponse, error) {
+		return proto.NewAgentServiceClient(c.Conn()).Register(ctx, &proto.RegisterAgentRequest{NodeId: nodeID, Hostname: hostname})
+	}
+	heartbeat := func(c *client.Client, reg *proto.RegisterAgentResponse, nodeID string) error {
+		_, err := proto.NewAgentServiceClient(c.Conn()).Heartbeat(ctx, &proto.HeartbeatRequest{AgentId: reg.GetAgentId(), NodeId: nodeID, Health: "healthy"})
+		return err
+	}
+
+	// Neither anonymous callers nor users can speak for a node
+	for _, c := range []*client.Client{connect(t, lis, ""), admin} {
+		if _, err := register(c, "node-1", "manager-1"); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("Expected Register without an agent token to be rejected, got %v", err)
+		}
+		if err := heartbeat(c, nil, "node-1"); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("Expected Heartbeat without an agent token to be rejected, got %v", err)
+		}
+	}
+
+	// A node joining gets a token that binds to it when its agent registers
+	bootstrap, err := admin.CreateBootstrapToken(ctx, "worker", time.Hour, "")
+	if err != nil {
+		t.Fatalf("CreateBootstrapToken failed: %v", err)
+	}
+	joined, err := admin.Join(ctx, bootstrap.Token, "worker-1")
+	if err != nil {
+		t.Fatalf("Join failed: %v", err)
+	}
+	worker := connect(t, lis, joined.AgentToken)
+	if _, err := register(worker, "node-1", "worker-1"); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected a joined node not to register as another node, got %v", err)
+	}
+	if _, err := register(worker, "node-9", "worker-1"); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected a node the backend doesn't list to be rejected, got %v", err)
+	}
+	reg, err := register(worker, "node-2", "worker-1")
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := heartbeat(worker, reg, "node-2"); err != nil {
+		t.Errorf("Heartbeat failed: %v", err)
+	}
+	if err := heartbeat(worker, reg, "node-1"); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected heartbeats for another node to be rejected, got %v", err)
+	}
+	if _, err := worker.ListNodes(ctx); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected agent tokens to only call the agent API, got %v", err)
+	}
+
+	// Tokens created for a node are bound to it, and replace its previous one
+	first, err := admin.CreateAgentToken(ctx, "manager-1")
+	if err != nil || first.NodeId != "node-1" {
+		t.Fatalf("Expected a token for node-1, got %v (%v)", first, err)
+	}
+	second, err := admin.CreateAgentToken(ctx, "node-1")
+	if err != nil {
+		t.Fatalf("CreateAgentToken failed: %v", err)
+	}
+	if _, err := register(connect(t, lis, first.Token), "node-1", "manager-1"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected the replaced token to be rejected, got %v", err)
+	}
+	if _, err := register(connect(t, lis, second.Token), "node-1", "manager-1"); err != nil {
+		t.Errorf("Register failed: %v", err)
+	}
+	if _, err := admin.CreateAgentToken(ctx, "worker-9"); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected a token for an unknown node to be rejected, got %v", err)
+	}
+
+	// Revoked tokens are turned away
+	tokens, err := admin.ListAgentTokens(ctx)
+	if err != nil {
+		t.Fatalf("ListAgentTokens failed: %v", err)
+	}
+	if len(tokens.Tokens) != 2 || tokens.Tokens[1].Id != second.Id || tokens.Tokens[1].Token != "" {
+		t.Fatalf("Expected the tokens of node-2 and node-1 without their secrets, got %v", tokens.Tokens)
+	}
+	if resp, err := admin.RevokeAgentToken(ctx, second.Id); err != nil || !resp.Success {
+		t.Fatalf("RevokeAgentToken failed: %v %v", err, resp)
+	}
+	if _, err := register(connect(t, lis, second.Token), "node-1", "manager-1"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected the revoked token to be rejected, got %v", err)
+	}
+}
+
+func TestIntegrationStandaloneAgentTokens(t *testing.T) {
+	// The backend doesn't list nodes, like standalone Docker hosts
+	clusters := cluster.Single(struct{ manager.Manager }{sim.New(sim.Options{})})
+	srv := NewDeploymentServer(clusters, auth.NewAuthService(state.NewMemoryStateStore()))
+	lis := bufconn.Listen(1 << 20)
+	srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	admin := connect(t, lis, login(t, srv.authService, "root", auth.RoleAdmin))
+	ctx := context.Background()
+
+	register := func(token, nodeID string) (*proto.RegisterAgentResponse, error) {
+		return proto.NewAgentServiceClient(connect(t, lis, token).Conn()).Register(ctx, &proto.RegisterAgentRequest{NodeId: nodeID, Hostname: "box"})
+	}
+	first, err := admin.CreateAgentToken(ctx, "box-1")
+	if err != nil || first.NodeId != "box-1" {
+		t.Fatalf("Expected a token for box-1, got %v (%v)", first, err)
+	}
+	second, _ := admin.CreateAgentToken(ctx, "box-2")
+
+	// Agents are known by the node their token is for, whatever ID they report
+	reg, err := register(first.Token, "daemon-2")
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if reg.NodeId != "box-1" {
+		t.Errorf("Expected the agent to be registered as box-1, got %s", reg.NodeId)
+	}
+	if reg, err := register(second.Token, "daemon-2"); err != nil || reg.NodeId != "box-2" {
+		t.Errorf("Expected box-2 to register despite the ID box-1 reported, got %v (%v)", reg, err)
+	}
+	agent := proto.NewAgentServiceClient(connect(t, lis, first.Token).Conn())
+	if _, err := agent.Heartbeat(ctx, &proto.HeartbeatRequest{AgentId: reg.AgentId, NodeId: "box-2"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected heartbeats for another node to be rejected, got %v", err)
+	}
+	if _, err := agent.Heartbeat(ctx, &proto.HeartbeatRequest{AgentId: reg.AgentId, NodeId: reg.NodeId}); err != nil {
+		t.Errorf("Heartbeat failed: %v", err)
+	}
+
+	// A new token for the node replaces its old one
+	if _, err := admin.CreateAgentToken(ctx, "box-1"); err != nil {
+		t.Fatalf("CreateAgentToken failed: %v", err)
+	}
+	if _, err := register(first.Token, "box-1"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected the replaced token to be rejected, got %v", err)
+	}
+}
+
+func TestIntegrationAgentMetrics(t *testing.T) {
+	docker := dockertest.NewServer(dockertest.Options{})
+	docker.AddImage("nginx")
+	t.Cleanup(docker.Close)
+
+	cli, err := docker.Client()
+	if err != nil {
+		t.Fatalf("Failed to create Docker client: %v", err)
+	}
+	t.Cleanup(func() { cli.Close() })
+	ctx := context.Background()
+	created, err := cli.ContainerCreate(ctx, &container.Config{Image: "nginx", Labels: map[string]string{"velo.service.name": "web"}}, nil, nil, nil, "web-1")
+	if err != nil {
+		t.Fatalf("ContainerCreate failed: %v", err)
+	}
+	if err := cli.ContainerStart(ctx, created.ID, container.StartOptions{}); err != nil {
+		t.Fatalf("ContainerStart failed: %v", err)
+	}
+	docker.SetContainerStats("web-1", container.StatsResponse{
+		MemoryStats: container.MemoryStats{Usage: 64 << 20, Limit: 512 << 20},
+	})
+
+	clusters := cluster.NewRegistry()
+	_ = clusters.Register(&cluster.Cluster{Name: "default", Manager: manager.NewStandaloneManagerWithClient(cli, config.StandaloneConfig{}), Agents: cluster.NewAgentTracker(20 * time.Millisecond)})
+	hostname, _ := os.Hostname()
+	c, agentClient := serveAgent(t, clusters, hostname)
+
+	opts := agent.DefaultOptions()
+	opts.MetricsInterval = 10 * time.Millisecond
+	a, err := agent.NewContainerAgentWithOptions(cli, opts)
+	if err != nil {
+		t.Fatalf("NewContainerAgentWithOptions failed: %v", err)
+	}
+	if err := a.Start(); err != nil {
+		t.Fatalf("Failed to start agent: %v", err)
+	}
+	t.Cleanup(a.Stop)
+	a.ReportTo(agentClient.Conn(), "")
+
+	// Samples are shipped with heartbeats and aggregated per service and node
+	var resp *proto.MetricsResponse
+	deadline := time.Now().Add(5 * time.Second)
+	for resp == nil || len(resp.Series) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the agent to ship metrics")
+		}
+		time.Sleep(10 * time.Millisecond)
+		if resp, err = c.GetMetrics(ctx, "", "", "", time.Minute, time.Second); err != nil {
+			t.Fatalf("GetMetrics failed: %v", err)
+		}
+	}
+	point := resp.Series[0].Points[0]
+	if resp.Series[0].Service != "web" || point.Containers != 1 || point.MemoryUsage != 64<<20 || point.MemoryLimit != 512<<20 {
+		t.Errorf("Unexpected metrics %v", resp.Series)
+	}
+
+	// Agents register with the hostname of the machine they run on
+	resp, err = c.GetMetrics(ctx, "", hostname, cluster.GroupByNode, time.Minute, time.Second)
+	if err != nil || len(resp.Series) != 1 || resp.Series[0].Hostname != hostname {
+		t.Errorf("Expected the metrics of %s, got %v (%v)", hostname, resp, err)
+	}
+	if _, err := c.GetMetrics(ctx, "", "", "image", time.Minute, time.Second); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected an unknown grouping to be rejected, got %v", err)
+	}
+}
+
+func TestIntegrationAlerts(t *testing.T) {
+	orchestrator := sim.New(sim.Options{TickInterval: 10 * time.Millisecond})
+	if err := orchestrator.Start(); err != nil {
+		t.Fatalf("Failed to start backend: %v", err)
+	}
+	t.Cleanup(orchestrator.Stop)
+	clusters := cluster.Single(orchestrator)
+	clusters.CheckHealth(context.Background())
+
+	store := state.NewMemoryStateStore()
+	engine := alerting.NewEngine(store, clusters, alerting.Options{})
+	srv := NewDeploymentServer(clusters, auth.NewAuthService(store))
+	srv.SetAlerting(engine)
+	c := serve(t, srv)
+	ctx := context.Background()
+
+	if _, err := c.SetAlertRule(ctx, &proto.AlertRule{Name: "bad rule", Condition: "deploy_failed"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected an invalid rule to be rejected, got %v", err)
+	}
+	rule, err := c.SetAlertRule(ctx, &proto.AlertRule{Name: "deploys", Condition: "deploy_failed", Severity: "critical"})
+	if err != nil || rule.CreatedUnix == 0 {
+		t.Fatalf("SetAlertRule failed: %v (%+v)", err, rule)
+	}
+
+	// A failed deploy fires the rule on the next evaluation
+	orchestrator.InjectError(sim.OpDeploy, errors.New("registry unreachable"))
+	if _, err := c.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "web", Image: "nginx:1"}); err == nil {
+		t.Fatal("Expected the deploy to fail")
+	}
+	engine.Evaluate(ctx)
+
+	alerts, err := c.ListAlerts(ctx)
+	if err != nil {
+		t.Fatalf("ListAlerts failed: %v", err)
+	}
+	if len(alerts.Alerts) != 1 || alerts.Alerts[0].State != "firing" || alerts.Alerts[0].Labels["service"] != "web" {
+		t.Fatalf("Expected a firing deploy alert for web, got %v", alerts.Alerts)
+	}
+
+	silence, err := c.CreateSilence(ctx, []string{"service=web"}, time.Hour, "known issue", "alice")
+	if err != nil {
+		t.Fatalf("CreateSilence failed: %v", err)
+	}
+	engine.Evaluate(ctx)
+	alerts, _ = c.ListAlerts(ctx)
+	if alerts.Alerts[0].SilencedBy != silence.Id {
+		t.Errorf("Expected the alert to be silenced by %s, got %q", silence.Id, alerts.Alerts[0].SilencedBy)
+	}
+	if _, err := c.CreateSilence(ctx, []string{"service"}, time.Hour, "", "alice"); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected an invalid matcher to be rejected, got %v", err)
+	}
+
+	// A successful deploy resolves it
+	if _, err := c.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "web", Image: "nginx:1"}); err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	engine.Evaluate(ctx)
+	if alerts, _ := c.ListAlerts(ctx); len(alerts.Alerts) != 0 {
+		t.Errorf("Expected no alerts after a successful deploy, got %v", alerts.Alerts)
+	}
+
+	expired, err := c.ExpireSilence(ctx, silence.Id)
+	if err != nil || !expired.Success {
+		t.Errorf("ExpireSilence failed: %v %v", err, expired)
+	}
+	deleted, err := c.DeleteAlertRule(ctx, "deploys")
+	if err != nil || !deleted.Success {
+		t.Errorf("DeleteAlertRule failed: %v %v", err, deleted)
+	}
+	if rules, _ := c.ListAlertRules(ctx); len(rules.Rules) != 0 {
+		t.Errorf("Expected no rules after deleting, got %v", rules.Rules)
+	}
+}
+
+func TestIntegrationWebhooks(t *testing.T) {
+	orchestrator := sim.New(sim.Options{TickInterval: 10 * time.Millisecond})
+	if err := orchestrator.Start(); err != nil {
+		t.Fatalf("Failed to start backend: %v", err)
+	}
+	t.Cleanup(orchestrator.Stop)
+	clusters := cluster.Single(orchestrator)
+	clusters.CheckHealth(context.Background())
+
+	var mu sync.Mutex
+	var events []webhooks.Event
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e webhooks.Event
+		json.NewDecoder(r.Body).Decode(&e)
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	}))
+	t.Cleanup(receiver.Close)
+
+	store := state.NewMemoryStateStore()
+	dispatcher := webhooks.NewDispatcher(store, clusters, webhooks.Options{})
+	srv := NewDeploymentServer(clusters, auth.NewAuthService(store))
+	srv.SetWebhooks(dispatcher)
+	c := serve(t, srv)
+	ctx := context.Background()
+
+	if _, err := c.CreateWebhook(ctx, &proto.CreateWebhookRequest{Url: "not a url"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected an invalid URL to be rejected, got %v", err)
+	}
+	hook, err := c.CreateWebhook(ctx, &proto.CreateWebhookRequest{Url: receiver.URL, Service: "web"})
+	if err != nil || hook.Secret == "" {
+		t.Fatalf("CreateWebhook failed: %v (%+v)", err, hook)
+	}
+	if _, err := c.CreateWebhook(ctx, &proto.CreateWebhookRequest{Url: receiver.URL, Service: "api"}); err != nil {
+		t.Fatalf("CreateWebhook failed: %v", err)
+	}
+	list, err := c.ListWebhooks(ctx)
+	if err != nil || len(list.Webhooks) != 2 || list.Webhooks[0].Secret != "" {
+		t.Fatalf("Expected two webhooks without their secrets, got %v (%v)", list, err)
+	}
+
+	orchestrator.InjectError(sim.OpDeploy, errors.New("registry unreachable"))
+	if _, err := c.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "web", Image: "nginx:1"}); err == nil {
+		t.Fatal("Expected the deploy to fail")
+	}
+	deployed, err := c.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "web", Image: "nginx:1"})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	if resp, err := c.Scale(ctx, deployed.DeploymentId, 3); err != nil || !resp.Success {
+		t.Fatalf("Scale failed: %v %v", err, resp)
+	}
+	if resp, err := c.Rollback(ctx, deployed.DeploymentId); err != nil || !resp.Success {
+		t.Fatalf("Rollback failed: %v %v", err, resp)
+	}
+	dispatcher.Flush()
+
+	want := []string{
+		webhooks.EventDeployStarted, webhooks.EventDeployFailed,
+		webhooks.EventDeployStarted, webhooks.EventDeploySucceeded,
+		webhooks.EventScale, webhooks.EventRollback,
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != len(want) {
+		t.Fatalf("Expected %d events for web, got %+v", len(want), events)
+	}
+	for i, e := range events {
+		if e.Type != want[i] || e.Service != "web" || e.Cluster != "default" {
+			t.Errorf("Expected event %d to be %s for web, got %+v", i, want[i], e)
+		}
+	}
+	if events[1].Data["error"] == "" || events[4].Data["replicas"] != "3" {
+		t.Errorf("Expected the error and replicas in the events, got %+v and %+v", events[1], events[4])
+	}
+
+	deliveries, err := c.ListWebhookDeliveries(ctx, hook.Id, 0)
+	if err != nil || len(deliveries.Deliveries) != len(want) || deliveries.Deliveries[0].State != "succeeded" {
+		t.Errorf("Expected %d succeeded deliveries, got %v (%v)", len(want), deliveries, err)
+	}
+	if resp, err := c.DeleteWebhook(ctx, hook.Id); err != nil || !resp.Success {
+		t.Errorf("DeleteWebhook failed: %v %v", err, resp)
+	}
+	if resp, _ := c.DeleteWebhook(ctx, hook.Id); resp.Success {
+		t.Error("Expected deleting an unknown webhook to fail")
+	}
+}
+
+func TestIntegrationDeployHooks(t *testing.T) {
+	orchestrator, c := startSimCluster(t)
+	ctx := context.Background()
+
+	migrate := []string{"./manage.py", "migrate"}
+	orchestrator.SetJobResult(migrate, sim.JobResult{Output: "Applying migrations... OK\n"})
+	var output strings.Builder
+	resp, err := c.DeployStream(ctx, &proto.DeployRequest{ServiceName: "web", Image: "web:1", PreDeploy: migrate}, &output)
+	if err != nil {
+		t.Fatalf("DeployStream failed: %v", err)
+	}
+	if want := "==> Running pre_deploy hook: ./manage.py migrate\nApplying migrations... OK\n"; output.String() != want {
+		t.Errorf("Expected hook output %q, got %q", want, output.String())
+	}
+	jobs := orchestrator.Jobs()
+	if len(jobs) != 1 || jobs[0].Image != "web:1" || jobs[0].Env["VELO_HOOK"] != "pre_deploy" {
+		t.Errorf("Expected the hook to run with the new image, got %+v", jobs)
+	}
+	waitForStatus(t, c, resp.DeploymentId, "running")
+
+	// A failing pre-deploy hook aborts the deploy
+	orchestrator.SetJobResult(migrate, sim.JobResult{ExitCode: 2, Output: "relation already exists\n"})
+	_, err = c.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "api", Image: "api:1", PreDeploy: migrate})
+	if status.Code(err) != codes.Aborted {
+		t.Fatalf("Expected the deploy to be aborted, got %v", err)
+	}
+	if _, err := c.GetStatus(ctx, "api"); err == nil {
+		t.Error("Expected the api service not to be deployed")
+	}
+
+	// A failing post-deploy hook rolls the deploy back when asked to
+	check := []string{"./manage.py", "check"}
+	orchestrator.SetJobResult(check, sim.JobResult{ExitCode: 1})
+	_, err = c.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "worker", Image: "worker:1", PostDeploy: check, RollbackOnHookFailure: true})
+	if err == nil || !strings.Contains(err.Error(), "the deploy was rolled back") {
+		t.Fatalf("Expected the deploy to be rolled back, got %v", err)
+	}
+	if _, err := c.GetStatus(ctx, "worker"); err == nil {
+		t.Error("Expected the worker service to be removed")
+	}
+}
+
+func TestIntegrationAdmission(t *testing.T) {
+	orchestrator := sim.New(sim.Options{TickInterval: 10 * time.Millisecond})
+	if err := orchestrator.Start(); err != nil {
+		t.Fatalf("Failed to start backend: %v", err)
+	}
+	t.Cleanup(orchestrator.Stop)
+	clusters := cluster.Single(orchestrator)
+	clusters.CheckHealth(context.Background())
+
+	// Only allow images from the internal registry, and label everything
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req admission.Request
+		json.NewDecoder(r.Body).Decode(&req)
+		resp := admission.Response{UID: req.UID, Allowed: strings.HasPrefix(req.Service.Image, "registry.example.com/")}
+		if !resp.Allowed {
+			resp.Reason = "images must come from registry.example.com"
+		} else {
+			resp.Patch = json.RawMessage(`[{"op":"add","path":"/labels/team","value":"payments"}]`)
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(hook.Close)
+
+	srv := NewDeploymentServer(clusters, auth.NewAuthService(state.NewMemoryStateStore()))
+	srv.SetAdmission(admission.New([]config.AdmissionHook{{Name: "registries", URL: hook.URL}}))
+	c := serve(t, srv)
+	ctx := context.Background()
+
+	_, err := c.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "web", Image: "nginx:1"})
+	if status.Code(err) != codes.PermissionDenied || !strings.Contains(err.Error(), "images must come from registry.example.com") {
+		t.Fatalf("Expected the deploy to be denied, got %v", err)
+	}
+
+	resp, err := c.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "web", Image: "registry.example.com/web:1"})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	deployed, err := orchestrator.GetServiceStatus(resp.DeploymentId)
+	if err != nil {
+		t.Fatalf("GetServiceStatus failed: %v", err)
+	}
+	if deployed.Service.Labels["team"] != "payments" {
+		t.Errorf("Expected the admission webhook's label, got %v", deployed.Service.Labels)
+	}
+
+	// Unreachable webhooks deny deploys unless they fail open
+	hook.Close()
+	if _, err := c.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "api", Image: "registry.example.com/api:1"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected the deploy to be denied, got %v", err)
+	}
+}
+
+func TestIntegrationPolicy(t *testing.T) {
+	orchestrator := sim.New(sim.Options{TickInterval: 10 * time.Millisecond})
+	if err := orchestrator.Start(); err != nil {
+		t.Fatalf("Failed to start backend: %v", err)
+	}
+	t.Cleanup(orchestrator.Stop)
+	clusters := cluster.Single(orchestrator)
+	clusters.CheckHealth(context.Background())
+
+	source := `
+[rules.no_latest_tag]
+
+[rules.required_labels]
+mode = "warn"
+labels = ["team"]
+`
+	p, err := policy.Parse([]byte(source))
+	if err != nil {
+		t.Fatalf("Failed to parse policy: %v", err)
+	}
+	srv := NewDeploymentServer(clusters, auth.NewAuthService(state.NewMemoryStateStore()))
+	srv.SetPolicy(p)
+	c := serve(t, srv)
+	ctx := context.Background()
+
+	_, err = c.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "web", Image: "nginx:latest"})
+	if status.Code(err) != codes.FailedPrecondition || !strings.Contains(err.Error(), "no_latest_tag") {
+		t.Fatalf("Expected the deploy to violate the policy, got %v", err)
+	}
+
+	resp, err := c.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "web", Image: "nginx:1.27"})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	if len(resp.Warnings) != 1 || !strings.Contains(resp.Warnings[0], "missing required labels: team") {
+		t.Errorf("Expected a policy warning, got %q", resp.Warnings)
+	}
+	if _, err := c.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "api", Image: "api:2", Labels: map[string]string{"team": "payments"}}); err != nil {
+		t.Errorf("Deploy failed: %v", err)
+	}
+
+	// veloctl checks services locally against the same policy
+	got, err := c.GetPolicy(ctx)
+	if err != nil || got != source {
+		t.Errorf("Expected the server's policy, got %q (%v)", got, err)
+	}
+}
+
+func TestIntegrationPolicyServiceRules(t *testing.T) {
+	orchestrator := sim.New(sim.Options{TickInterval: 10 * time.Millisecond})
+	if err := orchestrator.Start(); err != nil {
+		t.Fatalf("Failed to start backend: %v", err)
+	}
+	t.Cleanup(orchestrator.Stop)
+	clusters := cluster.Single(orchestrator)
+	clusters.CheckHealth(context.Background())
+
+	p, err := policy.Parse([]byte(`
+[rules.require_limits]
+
+[rules.require_healthcheck]
+
+[rules.no_bind_mounts]
+`))
+	if err != nil {
+		t.Fatalf("Failed to parse policy: %v", err)
+	}
+	srv := NewDeploymentServer(clusters, auth.NewAuthService(state.NewMemoryStateStore()))
+	srv.SetPolicy(p)
+	c := serve(t, srv)
+	ctx := context.Background()
+
+	healthcheck := &proto.HealthCheck{Command: []string{"CMD-SHELL", "curl -f localhost"}, IntervalSeconds: 10}
+	tests := []struct {
+		name    string
+		req     *proto.DeployRequest
+		wantErr string
+	}{
+		{
+			name:    "no limits",
+			req:     &proto.DeployRequest{Image: "web:2", Healthcheck: healthcheck},
+			wantErr: "a CPU limit is required",
+		},
+		{
+			name:    "no memory limit",
+			req:     &proto.DeployRequest{Image: "web:2", CpuLimit: 1, Healthcheck: healthcheck},
+			wantErr: "a memory limit is required",
+		},
+		{
+			name:    "no healthcheck",
+			req:     &proto.DeployRequest{Image: "web:2", CpuLimit: 1, MemoryLimit: 1 << 28},
+			wantErr: "a healthcheck command is required",
+		},
+		{
+			name: "bind mount",
+			req: &proto.DeployRequest{Image: "web:2", CpuLimit: 1, MemoryLimit: 1 << 28, Healthcheck: healthcheck,
+				Volumes: []*proto.VolumeMount{{Source: "/var/run/docker.sock", Destination: "/var/run/docker.sock"}}},
+			wantErr: "host path /var/run/docker.sock must not be mounted",
+		},
+		{
+			name: "compliant",
+			req: &proto.DeployRequest{Image: "web:2", CpuLimit: 1, MemoryLimit: 1 << 28, Healthcheck: healthcheck,
+				Volumes: []*proto.VolumeMount{{Source: "uploads", Destination: "/app/uploads", ReadOnly: true}}},
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.ServiceName = fmt.Sprintf("web-%d", i)
+			_, err := c.DeployWithRequest(ctx, tt.req)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Deploy failed: %v", err)
+				}
+				return
+			}
+			if status.Code(err) != codes.FailedPrecondition || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	// The deployed service keeps what the policy was checked against
+	services, err := orchestrator.ListServices()
+	if err != nil {
+		t.Fatalf("ListServices failed: %v", err)
+	}
+	if len(services) != 1 {
+		t.Fatalf("Expected only the compliant service to be deployed, got %d services", len(services))
+	}
+	for _, svc := range services {
+		def := svc.Service
+		if len(def.HealthCheck.Command) != 2 || def.HealthCheck.Interval != 10 || len(def.Volumes) != 1 || !def.Volumes[0].ReadOnly || def.Resources.MemoryLimit != 1<<28 {
+			t.Errorf("Expected the healthcheck, volume and limits to be deployed, got %+v", def)
+		}
+	}
+
+	if _, err := c.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "api", Image: "api:2",
+		Volumes: []*proto.VolumeMount{{Source: "data"}}}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected a volume without a destination to be invalid, got %v", err)
+	}
+}
+
+func TestIntegrationAudit(t *testing.T) {
+	orchestrator := sim.New(sim.Options{TickInterval: 10 * time.Millisecond})
+	if err := orchestrator.Start(); err != nil {
+		t.Fatalf("Failed to start backend: %v", err)
+	}
+	t.Cleanup(orchestrator.Stop)
+	clusters := cluster.Single(orchestrator)
+	clusters.CheckHealth(context.Background())
+
+	srv := NewDeploymentServer(clusters, auth.NewAuthService(state.NewMemoryStateStore()))
+	srv.SetAudit(audit.New(state.NewMemoryStateStore()))
+	c := serve(t, srv)
+	ctx := context.Background()
+
+	resp, err := c.DeployWithRequest(ctx, &proto.DeployRequest{
+		ServiceName: "web",
+		Image:       "nginx:1",
+		Replicas:    2,
+		Env:         map[string]string{"LOG_LEVEL": "debug", "DB_PASSWORD": "hunter2", "DATABASE_URL": "postgres://app:hunter2@db/app"},
+	})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	waitForStatus(t, c, resp.DeploymentId, "running")
+	if _, err := c.Scale(ctx, resp.DeploymentId, 3); err != nil {
+		t.Fatalf("Scale failed: %v", err)
+	}
+	if err := c.DrainNode(ctx, "worker-2", 5*time.Second, false, func(*proto.DrainNodeProgress) {}); err != nil {
+		t.Fatalf("DrainNode failed: %v", err)
+	}
+	if _, err := c.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "web", Image: "nginx:2"}); err == nil {
+		t.Fatal("Expected duplicate deploy to fail")
+	}
+
+	// Reads aren't recorded
+	list, err := c.ListAuditEntries(ctx, &proto.ListAuditEntriesRequest{})
+	if err != nil {
+		t.Fatalf("ListAuditEntries failed: %v", err)
+	}
+	var actions []string
+	for _, e := range list.Entries {
+		actions = append(actions, e.Action+"/"+e.Result)
+	}
+	want := []string{"Deploy/failure", "DrainNode/success", "Scale/success", "Deploy/success"}
+	if strings.Join(actions, " ") != strings.Join(want, " ") {
+		t.Fatalf("Expected entries %q, newest first, got %q", want, actions)
+	}
+
+	deploy := list.Entries[3]
+	if deploy.Source != "grpc" || deploy.Target != "web" || deploy.Params["image"] != "nginx:1" {
+		t.Errorf("Expected the deploy of web over gRPC, got %+v", deploy)
+	}
+	if env := deploy.Params["env"]; strings.Contains(env, "hunter2") || strings.Contains(env, "debug") || !strings.Contains(env, "DATABASE_URL") {
+		t.Errorf("Expected only the names of the environment variables, got %s", env)
+	}
+	if drain := list.Entries[1]; drain.Target != "worker-2" {
+		t.Errorf("Expected the drain of worker-2, got %+v", drain)
+	}
+
+	filtered, err := c.ListAuditEntries(ctx, &proto.ListAuditEntriesRequest{Action: "deploy", Result: "failure"})
+	if err != nil || len(filtered.Entries) != 1 || filtered.Entries[0].Error == "" {
+		t.Errorf("Expected the failed deploy with its error, got %v (%v)", filtered, err)
+	}
+}
+
+func TestIntegrationRBAC(t *testing.T) {
+	orchestrator := sim.New(sim.Options{TickInterval: 10 * time.Millisecond})
+	if err := orchestrator.Start(); err != nil {
+		t.Fatalf("Failed to start backend: %v", err)
+	}
+	t.Cleanup(orchestrator.Stop)
+	clusters := cluster.Single(orchestrator)
+	clusters.CheckHealth(context.Background())
+
+	authService := auth.NewAuthService(state.NewMemoryStateStore())
+	srv := NewDeploymentServer(clusters, authService)
+	lis := bufconn.Listen(1 << 20)
+	srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	ctx := context.Background()
+
+	admin := connect(t, lis, login(t, authService, "root", auth.RoleAdmin))
+	viewer := connect(t, lis, login(t, authService, "vera", auth.RoleViewer))
+	ci := connect(t, lis, login(t, authService, "ci", auth.RoleViewer))
+	anonymous := connect(t, lis, "")
+	forged := connect(t, lis, "not-a-token")
+
+	if _, err := admin.GrantRole(ctx, "ci", &proto.RoleBinding{Role: auth.RoleDeployer, Service: "web"}); err != nil {
+		t.Fatalf("GrantRole failed: %v", err)
+	}
+
+	web, err := ci.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "web", Image: "nginx:1", Replicas: 1})
+	if err != nil {
+		t.Fatalf("Expected a deployer on web to deploy it, got %v", err)
+	}
+	waitForStatus(t, admin, web.DeploymentId, "running")
+	if _, err := ci.Scale(ctx, web.DeploymentId, 2); err != nil {
+		t.Errorf("Expected a deployer on web to scale it, got %v", err)
+	}
+	if _, err := ci.DeployStream(ctx, &proto.DeployRequest{ServiceName: "web", Image: "nginx:2", Replicas: 1}, io.Discard); status.Code(err) == codes.PermissionDenied {
+		t.Errorf("Expected a deployer on web to stream a deploy of it, got %v", err)
+	}
+
+	api, err := admin.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "api", Image: "api:1", Replicas: 1})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+
+	denied := []struct {
+		name string
+		call func() error
+	}{
+		{"viewer deploy", func() error {
+			_, err := viewer.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "db", Image: "postgres:16"})
+			return err
+		}},
+		{"viewer scale", func() error { _, err := viewer.Scale(ctx, web.DeploymentId, 3); return err }},
+		{"deployer deploy elsewhere", func() error {
+			_, err := ci.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "db", Image: "postgres:16"})
+			return err
+		}},
+		{"deployer stream elsewhere", func() error {
+			_, err := ci.DeployStream(ctx, &proto.DeployRequest{ServiceName: "db", Image: "postgres:16"}, io.Discard)
+			return err
+		}},
+		{"deployer scale elsewhere", func() error { _, err := ci.Scale(ctx, api.DeploymentId, 3); return err }},
+		{"deployer drain", func() error {
+			return ci.DrainNode(ctx, "worker-1", time.Second, false, func(*proto.DrainNodeProgress) {})
+		}},
+		{"viewer list users", func() error { _, err := viewer.ListUsers(ctx); return err }},
+	}
+	for _, tt := range denied {
+		if err := tt.call(); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("%s: expected PermissionDenied, got %v", tt.name, err)
+		}
+	}
+
+	if _, err := viewer.GetStatus(ctx, web.DeploymentId); err != nil {
+		t.Errorf("Expected a viewer to read status, got %v", err)
+	}
+
+	// A user whose only role is bound to a service reads the status of just that service
+	if _, err := authService.AddUser("sam", "sam-password", auth.RoleViewer); err != nil {
+		t.Fatalf("AddUser failed: %v", err)
+	}
+	sam, _ := authService.GetUserByUsername("sam")
+	sam.Role = ""
+	sam.Bindings = []auth.Binding{{Role: auth.RoleViewer, Service: "web"}}
+	if err := authService.UpdateUser(sam); err != nil {
+		t.Fatalf("UpdateUser failed: %v", err)
+	}
+	token, err := authService.Authenticate("sam", "sam-password")
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	scoped := connect(t, lis, token.Value)
+	if _, err := scoped.GetStatus(ctx, web.DeploymentId); err != nil {
+		t.Errorf("Expected a viewer on web to read its status, got %v", err)
+	}
+	if _, err := scoped.GetStatus(ctx, api.DeploymentId); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected a viewer on web not to read the status of api, got %v", err)
+	}
+	if _, err := anonymous.GetStatus(ctx, web.DeploymentId); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected an anonymous call to be unauthenticated, got %v", err)
+	}
+	if _, err := forged.ListNodes(ctx); status.Code(err) != codes.Unauthenticated || !strings.Contains(err.Error(), "log in again") {
+		t.Errorf("Expected an invalid token to be turned away, got %v", err)
+	}
+
+	// Promoting a user takes effect on their next call
+	if _, err := admin.SetUserRole(ctx, "vera", auth.RoleDeployer); err != nil {
+		t.Fatalf("SetUserRole failed: %v", err)
+	}
+	if _, err := viewer.Scale(ctx, api.DeploymentId, 2); err != nil {
+		t.Errorf("Expected a promoted user to scale, got %v", err)
+	}
+	if _, err := admin.SetUserRole(ctx, "root", auth.RoleViewer); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected admins not to demote themselves, got %v", err)
+	}
+	if _, err := admin.GrantRole(ctx, "ci", &proto.RoleBinding{Role: auth.RoleAdmin}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected a binding without a project or service to be rejected, got %v", err)
+	}
+	if _, err := admin.RevokeRole(ctx, "ci", &proto.RoleBinding{Role: auth.RoleDeployer, Service: "web"}); err != nil {
+		t.Fatalf("RevokeRole failed: %v", err)
+	}
+	if _, err := ci.Scale(ctx, web.DeploymentId, 1); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected a revoked binding to stop applying, got %v", err)
+	}
+
+	if _, err := admin.CreateUser(ctx, "olga", "olga-password", auth.RoleViewer); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	if _, err := admin.DeleteUser(ctx, "olga"); err != nil {
+		t.Fatalf("DeleteUser failed: %v", err)
+	}
+	users, err := admin.ListUsers(ctx)
+	if err != nil {
+		t.Fatalf("ListUsers failed: %v", err)
+	}
+	for _, u := range users.Users {
+		if u.Username == "olga" && u.Active {
+			t.Errorf("Expected a deleted user to be deactivated, got %+v", u)
+		}
+	}
+}
+
+func TestAccessCoversEveryMethod(t *testing.T) {
+	access := (&DeploymentServer{}).access()
+	services := proto.File_velo_proto.Services()
+	for i := 0; i < services.Len(); i++ {
+		service := services.Get(i)
+		for j := 0; j < service.Methods().Len(); j++ {
+			method := "/" + string(service.FullName()) + "/" + string(service.Methods().Get(j).Name())
+			if _, ok := access[method]; !ok {
+				t.Errorf("Expected %s to have an access rule", method)
+			}
+		}
+	}
+}
+
+func TestIntegrationLogin(t *testing.T) {
+	authService := auth.NewAuthService(state.NewMemoryStateStore())
+	srv := NewDeploymentServer(cluster.Single(sim.New(sim.Options{})), authService)
+	srv.SetAudit(audit.New(state.NewMemoryStateStore()))
+	lis := bufconn.Listen(1 << 20)
+	srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	ctx := context.Background()
+
+	if _, err := authService.AddUser("alice", "alice-password", auth.RoleDeployer); err != nil {
+		t.Fatalf("AddUser failed: %v", err)
+	}
+	anonymous := connect(t, lis, "")
+	if _, err := anonymous.Login(ctx, "alice", "wrong"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected a wrong password to be rejected, got %v", err)
+	}
+	resp, err := anonymous.Login(ctx, "alice", "alice-password")
+	if err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	if resp.Token == "" || resp.User.Role != auth.RoleDeployer || resp.ExpiresUnix <= time.Now().Unix() {
+		t.Fatalf("Expected a token for a deployer, got %+v", resp)
+	}
+
+	alice := connect(t, lis, resp.Token)
+	if user, err := alice.GetCurrentUser(ctx); err != nil || user.Username != "alice" {
+		t.Errorf("Expected to be alice, got %v (%v)", user, err)
+	}
+	if _, err := anonymous.GetCurrentUser(ctx); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected GetCurrentUser to need a login, got %v", err)
+	}
+
+	if _, err := alice.Logout(ctx); err != nil {
+		t.Fatalf("Logout failed: %v", err)
+	}
+	if _, err := alice.GetCurrentUser(ctx); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected the token to be revoked, got %v", err)
+	}
+
+	admin := connect(t, lis, login(t, authService, "root", auth.RoleAdmin))
+	entries, err := admin.ListAuditEntries(ctx, &proto.ListAuditEntriesRequest{Actor: "alice"})
+	if err != nil {
+		t.Fatalf("ListAuditEntries failed: %v", err)
+	}
+	var actions []string
+	for _, e := range entries.Entries {
+		actions = append(actions, e.Action+"/"+e.Result)
+		if e.Params["password"] != "" && e.Params["password"] != "[REDACTED]" {
+			t.Errorf("Expected passwords to be redacted, got %v", e.Params)
+		}
+	}
+	if want := "Logout/success Login/success Login/failure"; strings.Join(actions, " ") != want {
+		t.Errorf("Expected entries %q, got %q", want, actions)
+	}
+}
+
+func TestIntegrationAPITokens(t *testing.T) {
+	orchestrator := sim.New(sim.Options{TickInterval: 10 * time.Millisecond})
+	if err := orchestrator.Start(); err != nil {
+		t.Fatalf("Failed to start backend: %v", err)
+	}
+	t.Cleanup(orchestrator.Stop)
+	clusters := cluster.Single(orchestrator)
+	clusters.CheckHealth(context.Background())
+
+	authService := auth.NewAuthService(state.NewMemoryStateStore())
+	srv := NewDeploymentServer(clusters, authService)
+	lis := bufconn.Listen(1 << 20)
+	srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	ctx := context.Background()
+
+	admin := connect(t, lis, login(t, authService, "root", auth.RoleAdmin))
+	if _, err := admin.CreateUserWithRequest(ctx, &proto.CreateUserRequest{Username: "ci", Role: auth.RoleDeployer, ServiceAccount: true}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	created, err := admin.CreateAPIToken(ctx, &proto.CreateAPITokenRequest{Name: "deploy-web", Scopes: []string{"deploy:web"}, Username: "ci"})
+	if err != nil {
+		t.Fatalf("CreateAPIToken failed: %v", err)
+	}
+	if created.Token == "" || created.ApiToken.Username != "ci" || created.ApiToken.ExpiresUnix != 0 {
+		t.Fatalf("Expected a token for ci that doesn't expire, got %+v", created)
+	}
+
+	ci := connect(t, lis, created.Token)
+	web, err := ci.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "web", Image: "nginx:1", Replicas: 1})
+	if err != nil {
+		t.Fatalf("Expected the CI token to deploy web, got %v", err)
+	}
+	waitForStatus(t, ci, web.DeploymentId, "running")
+	if _, err := ci.DeployWithRequest(ctx, &proto.DeployRequest{ServiceName: "api", Image: "api:1"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected the CI token not to deploy api, got %v", err)
+	}
+	if _, err := ci.CreateAPIToken(ctx, &proto.CreateAPITokenRequest{Name: "more", Scopes: []string{"admin"}}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected tokens not to create tokens, got %v", err)
+	}
+	if _, err := ci.Logout(ctx); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected logging out with an API token to point to revoking it, got %v", err)
+	}
+	if _, err := ci.GetCurrentUser(ctx); err != nil {
+		t.Errorf("Expected the API token to still work after a logout, got %v", err)
+	}
+	if _, err := ci.ListUsers(ctx); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected the CI token not to manage users, got %v", err)
+	}
+
+	list, err := admin.ListAPITokens(ctx, true)
+	if err != nil || len(list.Tokens) != 1 || list.Tokens[0].LastUsedUnix == 0 {
+		t.Fatalf("Expected the used token to be listed, got %v (%v)", list, err)
+	}
+	if own, err := admin.ListAPITokens(ctx, false); err != nil || len(own.Tokens) != 0 {
+		t.Errorf("Expected admin to have no tokens of their own, got %v (%v)", own, err)
+	}
+
+	if _, err := admin.RevokeAPIToken(ctx, created.ApiToken.Id); err != nil {
+		t.Fatalf("RevokeAPIToken failed: %v", err)
+	}
+	if _, err := ci.GetStatus(ctx, web.DeploymentId); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected a revoked token to be turned away, got %v", err)
+	}
+	if _, err := admin.RevokeAPIToken(ctx, created.ApiToken.Id); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected revoking twice to fail, got %v", err)
+	}
+}
+
+func TestIntegrationChangePassword(t *testing.T) {
+	authService := auth.NewAuthService(state.NewMemoryStateStore())
+	authService.SetLockoutPolicy(auth.LockoutPolicy{Threshold: 3, Backoff: time.Hour, MaxBackoff: time.Hour})
+	srv := NewDeploymentServer(cluster.Single(sim.New(sim.Options{})), authService)
+	lis := bufconn.Listen(1 << 20)
+	srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	ctx := context.Background()
+
+	admin := connect(t, lis, login(t, authService, "root", auth.RoleAdmin))
+	bob := connect(t, lis, login(t, authService, "bob", auth.RoleDeployer))
+	if _, err := admin.CreateUser(ctx, "alice", "alice-password", auth.RoleDeployer); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	if _, err := bob.ChangePassword(ctx, "", "bobs-choice-1", "alice"); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected deployers not to set others' passwords, got %v", err)
+	}
+	if _, err := admin.ChangePassword(ctx, "", "short", "alice"); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected a weak password to be rejected, got %v", err)
+	}
+	if _, err := admin.ChangePassword(ctx, "", "temporary-password", "alice"); err != nil {
+		t.Fatalf("ChangePassword failed: %v", err)
+	}
+
+	anonymous := connect(t, lis, "")
+	resp, err := anonymous.Login(ctx, "alice", "temporary-password")
+	if err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	if !resp.User.MustChangePassword {
+		t.Fatalf("Expected alice to have to change her password, got %+v", resp.User)
+	}
+	alice := connect(t, lis, resp.Token)
+	if _, err := alice.ListAPITokens(ctx, false); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected alice to be turned away until she changes her password, got %v", err)
+	}
+	if _, err := alice.GetCurrentUser(ctx); err != nil {
+		t.Errorf("Expected GetCurrentUser to work before changing the password, got %v", err)
+	}
+	if _, err := alice.ChangePassword(ctx, "wrong", "alices-own-password", ""); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected the current password to be checked, got %v", err)
+	}
+	if _, err := alice.ChangePassword(ctx, "temporary-password", "alices-own-password", ""); err != nil {
+		t.Fatalf("ChangePassword failed: %v", err)
+	}
+	if _, err := alice.ListAPITokens(ctx, false); err != nil {
+		t.Errorf("Expected alice to get in after changing her password, got %v", err)
+	}
+
+	// Admins who still have to change their own password can't set others'
+	if _, err := authService.AddUser("carol", "carol-password", auth.RoleAdmin); err != nil {
+		t.Fatalf("AddUser failed: %v", err)
+	}
+	if _, err := authService.ResetPassword("carol", "carols-temporary-password"); err != nil {
+		t.Fatalf("ResetPassword failed: %v", err)
+	}
+	resp, err = anonymous.Login(ctx, "carol", "carols-temporary-password")
+	if err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	carol := connect(t, lis, resp.Token)
+	if _, err := carol.ChangePassword(ctx, "", "carols-choice-for-alice", "alice"); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected carol to have to change her own password first, got %v", err)
+	}
+
+	// Setting alice's password logs her out
+	if _, err := admin.ChangePassword(ctx, "", "another-temporary-password", "alice"); err != nil {
+		t.Fatalf("ChangePassword failed: %v", err)
+	}
+	if _, err := alice.GetCurrentUser(ctx); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected alice's session to be revoked, got %v", err)
+	}
+	if _, err := admin.ChangePassword(ctx, "", "alices-own-password", "alice"); err != nil {
+		t.Fatalf("ChangePassword failed: %v", err)
+	}
+
+	// Repeated failures lock the account out, even with the right password
+	for range 3 {
+		anonymous.Login(ctx, "alice", "wrong")
+	}
+	if _, err := anonymous.Login(ctx, "alice", "alices-own-password"); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected alice to be locked out, got %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/api/proto"
+	"github.com/jasonlovesdoggo/velo/internal/auth"
+	"github.com/jasonlovesdoggo/velo/internal/log"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/manager"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// joinManager returns the join operations of a cluster, if its backend supports them
+func (s *ClusterServer) joinManager(clusterName string) (manager.JoinManager, error) {
+	m, err := managerFor(s.clusters, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	jm, ok := m.(manager.JoinManager)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "joining nodes is not supported by this backend")
+	}
+	return jm, nil
+}
+
+// GetJoinToken handles the GetJoinToken RPC call
+func (s *ClusterServer) GetJoinToken(ctx context.Context, req *proto.JoinTokenRequest) (*proto.JoinTokenResponse, error) {
+	log.Info("Received GetJoinToken request", "manager", req.Manager, "cluster", req.Cluster)
+
+	jm, err := s.joinManager(req.Cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := jm.GetJoinToken(req.Manager)
+	if err != nil {
+		log.Error("Failed to get join token", "error", err)
+		return nil, fmt.Errorf("failed to get join token: %w", err)
+	}
+	addrs, err := jm.ManagerAddresses()
+	if err != nil {
+		log.Error("Failed to get manager addresses", "error", err)
+		return nil, fmt.Errorf("failed to get manager addresses: %w", err)
+	}
+
+	return &proto.JoinTokenResponse{Token: token, ManagerAddrs: addrs}, nil
+}
+
+// CreateBootstrapToken handles the CreateBootstrapToken RPC call
+func (s *ClusterServer) CreateBootstrapToken(ctx context.Context, req *proto.CreateBootstrapTokenRequest) (*proto.BootstrapToken, error) {
+	log.Info("Received CreateBootstrapToken request", "role", req.Role, "cluster", req.Cluster)
+
+	// Only mint tokens nodes can actually use
+	if _, err := s.joinManager(req.Cluster); err != nil {
+		return nil, err
+	}
+
+	value, token, err := s.authService.CreateBootstrapToken(req.Role, req.Cluster, req.Description, time.Duration(req.TtlSeconds)*time.Second)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	resp := bootstrapTokenToProto(*token)
+	resp.Token = value
+	return resp, nil
+}
+
+// ListBootstrapTokens handles the ListBootstrapTokens RPC call
+func (s *ClusterServer) ListBootstrapTokens(ctx context.Context, req *proto.ListBootstrapTokensRequest) (*proto.ListBootstrapTokensResponse, error) {
+	log.Info("Received ListBootstrapTokens request")
+
+	tokens, err := s.authService.ListBootstrapTokens()
+	if err != nil {
+		log.Error("Failed to list bootstrap tokens", "error", err)
+		return nil, fmt.Errorf("failed to list bootstrap tokens: %w", err)
+	}
+
+	resp := &proto.ListBootstrapTokensResponse{}
+	for _, token := range tokens {
+		resp.Tokens = append(resp.Tokens, bootstrapTokenToProto(token))
+	}
+	return resp, nil
+}
+
+// RevokeBootstrapToken handles the RevokeBootstrapToken RPC call
+func (s *ClusterServer) RevokeBootstrapToken(ctx context.Context, req *proto.RevokeBootstrapTokenRequest) (*proto.GenericResponse, error) {
+	log.Info("Received RevokeBootstrapToken request", "id", req.Id)
+
+	if err := s.authService.RevokeBootstrapToken(req.Id); err != nil {
+		return &proto.GenericResponse{
+			Message: fmt.Sprintf("Failed to revoke bootstrap token %s: %v", req.Id, err),
+			Success: false,
+		}, nil
+	}
+
+	return &proto.GenericResponse{
+		Message: fmt.Sprintf("Bootstrap token %s revoked", req.Id),
+		Success: true,
+	}, nil
+}
+
+// Join handles the Join RPC call. The caller is authenticated by its bootstrap
+// token, which is used up, and receives what it needs to join the swarm and
+// the agent token its agent authenticates with.
+func (s *ClusterServer) Join(ctx context.Context, req *proto.JoinRequest) (*proto.JoinResponse, error) {
+	addr := "unknown"
+	if p, ok := peer.FromContext(ctx); ok {
+		addr = p.Addr.String()
+	}
+	log.Info("Received Join request", "hostname", req.Hostname, "address", addr)
+	if req.Hostname == "" {
+		return nil, status.Error(codes.InvalidArgument, "a hostname is required")
+	}
+
+	token, err := s.authService.ConsumeBootstrapToken(req.Token, req.Hostname+"@"+addr)
+	if err != nil {
+		if errors.Is(err, auth.ErrTokenInvalid) || errors.Is(err, auth.ErrTokenExpired) {
+			// Don't tell the caller whether the token exists
+			return nil, status.Error(codes.PermissionDenied, "invalid or expired bootstrap token")
+		}
+		return nil, err
+	}
+
+	c, err := s.clusters.Get(token.Cluster)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	jm, err := s.joinManager(c.Name)
+	if err != nil {
+		return nil, err
+	}
+	swarmToken, err := jm.GetJoinToken(token.Role == auth.BootstrapRoleManager)
+	if err != nil {
+		log.Error("Failed to get join token", "error", err)
+		return nil, status.Errorf(codes.Unavailable, "failed to get join token: %v", err)
+	}
+	addrs, err := jm.ManagerAddresses()
+	if err != nil {
+		log.Error("Failed to get manager addresses", "error", err)
+		return nil, status.Errorf(codes.Unavailable, "failed to get manager addresses: %v", err)
+	}
+
+	agentToken, _, err := s.authService.CreateAgentToken(c.Name, "", req.Hostname)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	log.Info("Node authorized to join", "hostname", req.Hostname, "role", token.Role, "cluster", token.Cluster)
+	return &proto.JoinResponse{
+		SwarmJoinToken: swarmToken,
+		ManagerAddrs:   addrs,
+		Role:           token.Role,
+		Cluster:        token.Cluster,
+		AgentToken:     agentToken,
+	}, nil
+}
+
+func bootstrapTokenToProto(token auth.BootstrapToken) *proto.BootstrapToken {
+	return &proto.BootstrapToken{
+		Id:          token.ID,
+		Role:        token.Role,
+		Cluster:     token.Cluster,
+		Description: token.Description,
+		CreatedUnix: token.Created.Unix(),
+		ExpiresUnix: token.ExpiresAt.Unix(),
+	}
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/api/proto"
+	"github.com/jasonlovesdoggo/velo/internal/log"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/cluster"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Default range and resolution of metrics queries
+const (
+	defaultMetricsSince = 15 * time.Minute
+	defaultMetricsStep  = time.Minute
+)
+
+// GetMetrics handles the GetMetrics RPC call. It returns the resource usage the
+// agents reported, aggregated per service or per node.
+func (s *ClusterServer) GetMetrics(ctx context.Context, req *proto.MetricsRequest) (*proto.MetricsResponse, error) {
+	log.Info("Received GetMetrics request", "service", req.Service, "node", req.Node, "groupBy", req.GroupBy, "cluster", req.Cluster)
+
+	c, err := s.clusters.Get(req.Cluster)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if req.SinceSeconds < 0 || req.StepSeconds < 0 {
+		return nil, status.Error(codes.InvalidArgument, "since and step must not be negative")
+	}
+	since := time.Duration(req.SinceSeconds) * time.Second
+	if since == 0 {
+		since = defaultMetricsSince
+	}
+	step := time.Duration(req.StepSeconds) * time.Second
+	if step == 0 {
+		step = defaultMetricsStep
+	}
+
+	series, err := c.QueryMetrics(cluster.MetricsQuery{
+		Service: req.Service,
+		Node:    req.Node,
+		GroupBy: req.GroupBy,
+		Since:   time.Now().Add(-since),
+		Step:    step,
+	})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	resp := &proto.MetricsResponse{}
+	for _, ms := range series {
+		ps := &proto.MetricSeries{Service: ms.Service, Node: ms.Node, Hostname: ms.Hostname}
+		for _, p := range ms.Points {
+			ps.Points = append(ps.Points, &proto.MetricPoint{
+				TimestampUnix:  p.Time.Unix(),
+				CpuPercent:     p.CPUPercent,
+				MemoryUsage:    p.MemoryUsage,
+				MemoryLimit:    p.MemoryLimit,
+				NetRxRate:      p.NetRxRate,
+				NetTxRate:      p.NetTxRate,
+				BlockReadRate:  p.BlockReadRate,
+				BlockWriteRate: p.BlockWriteRate,
+				Containers:     int32(p.Containers),
+			})
+		}
+		resp.Series = append(resp.Series, ps)
+	}
+	return resp, nil
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+
+	"github.com/jasonlovesdoggo/velo/api/proto"
+	"github.com/jasonlovesdoggo/velo/internal/auth"
+	"github.com/jasonlovesdoggo/velo/internal/config"
+)
+
+// access is the permission every gRPC method needs. Methods missing from it
+// can't be called.
+func (s *DeploymentServer) access() map[string]auth.Access {
+	read := auth.Access{Permission: auth.PermServicesRead, Resource: s.deploymentResource}
+	deploy := auth.Access{Permission: auth.PermServicesDeploy, Resource: deployResource}
+	change := auth.Access{Permission: auth.PermServicesDeploy, Resource: s.deploymentResource}
+	cluster := auth.Access{Permission: auth.PermClusterRead}
+	nodes := auth.Access{Permission: auth.PermNodesManage}
+	alerts := auth.Access{Permission: auth.PermAlertsManage}
+	webhooks := auth.Access{Permission: auth.PermWebhooksManage}
+	users := auth.Access{Permission: auth.PermUsersManage}
+	public := auth.Access{Public: true}
+	agent := auth.Access{Agent: true}
+
+	return map[string]auth.Access{
+		proto.DeploymentService_Deploy_FullMethodName:       deploy,
+		proto.DeploymentService_DeployStream_FullMethodName: deploy,
+		proto.DeploymentService_Rollback_FullMethodName:     change,
+		proto.DeploymentService_Scale_FullMethodName:        change,
+		proto.DeploymentService_GetStatus_FullMethodName:    read,
+		proto.DeploymentService_GetPolicy_FullMethodName:    cluster,
+
+		proto.ClusterService_DrainNode_FullMethodName:            nodes,
+		proto.ClusterService_ActivateNode_FullMethodName:         nodes,
+		proto.ClusterService_Rebalance_FullMethodName:            nodes,
+		proto.ClusterService_GetCapacity_FullMethodName:          cluster,
+		proto.ClusterService_ListNodes_FullMethodName:            cluster,
+		proto.ClusterService_ListClusters_FullMethodName:         cluster,
+		proto.ClusterService_GetJoinToken_FullMethodName:         nodes,
+		proto.ClusterService_CreateBootstrapToken_FullMethodName: nodes,
+		proto.ClusterService_ListBootstrapTokens_FullMethodName:  nodes,
+		proto.ClusterService_RevokeBootstrapToken_FullMethodName: nodes,
+		proto.ClusterService_Join_FullMethodName:                 public, // authenticated by the bootstrap token
+		proto.ClusterService_CreateAgentToken_FullMethodName:     nodes,
+		proto.ClusterService_ListAgentTokens_FullMethodName:      nodes,
+		proto.ClusterService_RevokeAgentToken_FullMethodName:     nodes,
+		proto.ClusterService_SendAgentCommand_FullMethodName:     nodes,
+		proto.ClusterService_GetAgentCommand_FullMethodName:      nodes,
+		proto.ClusterService_GetMetrics_FullMethodName:           cluster,
+
+		// Agents don't have users; they're authenticated by their node's agent token
+		proto.AgentService_Register_FullMethodName:  agent,
+		proto.AgentService_Heartbeat_FullMethodName: agent,
+
+		proto.AlertService_ListAlerts_FullMethodName:      cluster,
+		proto.AlertService_ListAlertRules_FullMethodName:  cluster,
+		proto.AlertService_SetAlertRule_FullMethodName:    alerts,
+		proto.AlertService_DeleteAlertRule_FullMethodName: alerts,
+		proto.AlertService_ListSilences_FullMethodName:    cluster,
+		proto.AlertService_CreateSilence_FullMethodName:   alerts,
+		proto.AlertService_ExpireSilence_FullMethodName:   alerts,
+
+		proto.WebhookService_CreateWebhook_FullMethodName:         webhooks,
+		proto.WebhookService_ListWebhooks_FullMethodName:          webhooks,
+		proto.WebhookService_DeleteWebhook_FullMethodName:         webhooks,
+		proto.WebhookService_ListWebhookDeliveries_FullMethodName: webhooks,
+
+		proto.UserService_ListUsers_FullMethodName:   users,
+		proto.UserService_CreateUser_FullMethodName:  users,
+		proto.UserService_DeleteUser_FullMethodName:  users,
+		proto.UserService_SetUserRole_FullMethodName: users,
+		proto.UserService_GrantRole_FullMethodName:   users,
+		proto.UserService_RevokeRole_FullMethodName:  users,
+
+		proto.AuthService_Login_FullMethodName:          public,
+		proto.AuthService_Logout_FullMethodName:         public, // revokes the token it's called with
+		proto.AuthService_GetCurrentUser_FullMethodName: {BeforePasswordChange: true},
+		proto.AuthService_ChangePassword_FullMethodName: {BeforePasswordChange: true}, // setting others' passwords needs users:manage and a changed password
+
+		// Users manage their own tokens; admins those of service accounts
+		proto.TokenService_CreateAPIToken_FullMethodName: {},
+		proto.TokenService_ListAPITokens_FullMethodName:  {},
+		proto.TokenService_RevokeAPIToken_FullMethodName: {},
+
+		proto.AuditService_ListAuditEntries_FullMethodName: {Permission: auth.PermAuditRead},
+	}
+}
+
+// deployResource returns the service a deploy request deploys
+func deployResource(ctx context.Context, req interface{}) (auth.Resource, error) {
+	r, ok := req.(*proto.DeployRequest)
+	if !ok {
+		return auth.Resource{}, nil
+	}
+	def := config.ServiceDefinition{Name: r.ServiceName, Labels: r.Labels}
+	return auth.Resource{Service: def.Name, Project: def.Project()}, nil
+}
+
+// deploymentResource returns the service of the deployment a request changes.
+// Deployments that can't be found belong to no service, so only users with a
+// role on the whole platform get to hear that they don't exist.
+func (s *DeploymentServer) deploymentResource(ctx context.Context, req interface{}) (auth.Resource, error) {
+	r, ok := req.(interface {
+		GetDeploymentId() string
+		GetCluster() string
+	})
+	if !ok {
+		return auth.Resource{}, nil
+	}
+	m, err := s.clusters.Manager(r.GetCluster())
+	if err != nil {
+		return auth.Resource{}, nil
+	}
+	st, err := m.GetServiceStatus(r.GetDeploymentId())
+	if err != nil {
+		return auth.Resource{}, nil
+	}
+	return auth.Resource{Service: st.Service.Name, Project: st.Service.Project()}, nil
+}
@@ -1,37 +1,110 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jasonlovesdoggo/velo/api/proto"
+	"github.com/jasonlovesdoggo/velo/internal/admission"
+	"github.com/jasonlovesdoggo/velo/internal/alerting"
+	"github.com/jasonlovesdoggo/velo/internal/audit"
 	"github.com/jasonlovesdoggo/velo/internal/auth"
 	"github.com/jasonlovesdoggo/velo/internal/config"
 	"github.com/jasonlovesdoggo/velo/internal/log"
+	"github.com/jasonlovesdoggo/velo/internal/metrics"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/cluster"
 	"github.com/jasonlovesdoggo/velo/internal/orchestrator/manager"
+	"github.com/jasonlovesdoggo/velo/internal/policy"
+	"github.com/jasonlovesdoggo/velo/internal/tracing"
+	"github.com/jasonlovesdoggo/velo/internal/webhooks"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // DeploymentServer implements the proto.DeploymentServiceServer interface
 type DeploymentServer struct {
 	proto.UnimplementedDeploymentServiceServer
-	manager     manager.Manager
+	clusters    *cluster.Registry
 	authService *auth.AuthService
+	cluster     *ClusterServer
+	alerts      *AlertServer
+	webhooks    *webhooks.Dispatcher
+	admission   *admission.Controller
+	policy      *policy.Policy
+	audit       *audit.Log
 	server      *grpc.Server
 }
 
-// NewDeploymentServer creates a new DeploymentServer
-func NewDeploymentServer(manager manager.Manager, authService *auth.AuthService) *DeploymentServer {
-	server := grpc.NewServer(
-		grpc.UnaryInterceptor(authService.AuthInterceptor),
+// NewDeploymentServer creates a new DeploymentServer for the registered clusters
+func NewDeploymentServer(clusters *cluster.Registry, authService *auth.AuthService) *DeploymentServer {
+	s := &DeploymentServer{
+		clusters:    clusters,
+		authService: authService,
+		cluster:     NewClusterServer(clusters, authService),
+	}
+	// Calls are authenticated, recorded in the audit log, denied included, and
+	// authorized in that order. The audit log is set after the server is
+	// created, so the interceptors look it up on every call.
+	access := s.access()
+	s.server = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(metrics.UnaryServerInterceptor, tracing.UnaryServerInterceptor, authService.UnaryAuthenticator,
+			func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				return s.audit.UnaryServerInterceptor(ctx, req, info, handler)
+			},
+			authService.UnaryAuthorizer(access)),
+		grpc.ChainStreamInterceptor(metrics.StreamServerInterceptor, tracing.StreamServerInterceptor, authService.StreamAuthenticator,
+			func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+				return s.audit.StreamServerInterceptor(srv, ss, info, handler)
+			},
+			authService.StreamAuthorizer(access)),
 	)
+	return s
+}
 
-	return &DeploymentServer{
-		manager:     manager,
-		authService: authService,
-		server:      server,
+// SetAlerting serves the rules, silences and alerts of an alerting engine. It
+// must be called before the server starts.
+func (s *DeploymentServer) SetAlerting(engine *alerting.Engine) {
+	s.alerts = NewAlertServer(engine)
+}
+
+// SetWebhooks publishes deployment events to a webhook dispatcher and serves
+// its subscriptions. It must be called before the server starts.
+func (s *DeploymentServer) SetWebhooks(d *webhooks.Dispatcher) {
+	s.webhooks = d
+}
+
+// SetAdmission asks admission webhooks about every deploy. It must be called
+// before the server starts.
+func (s *DeploymentServer) SetAdmission(c *admission.Controller) {
+	s.admission = c
+}
+
+// SetPolicy checks every deploy against a policy and serves it to veloctl. It
+// must be called before the server starts.
+func (s *DeploymentServer) SetPolicy(p *policy.Policy) {
+	s.policy = p
+}
+
+// SetAudit records the calls that change something in an audit log and serves
+// the log to veloctl. It must be called before the server starts.
+func (s *DeploymentServer) SetAudit(l *audit.Log) {
+	s.audit = l
+}
+
+// GetPolicy handles the GetPolicy RPC call
+func (s *DeploymentServer) GetPolicy(ctx context.Context, req *proto.GetPolicyRequest) (*proto.GetPolicyResponse, error) {
+	if s.policy == nil {
+		return &proto.GetPolicyResponse{}, nil
 	}
+	return &proto.GetPolicyResponse{Policy: s.policy.Source}, nil
 }
 
 // Start starts the gRPC server
@@ -41,17 +114,35 @@ func (s *DeploymentServer) Start(address string) error {
 		return fmt.Errorf("failed to listen: %w", err)
 	}
 
-	// Register the deployment service
+	log.Info("Starting gRPC server", "address", address)
+	s.Serve(lis)
+
+	return nil
+}
+
+// Serve registers the services and serves gRPC on an existing listener in the background
+func (s *DeploymentServer) Serve(lis net.Listener) {
 	proto.RegisterDeploymentServiceServer(s.server, s)
+	proto.RegisterClusterServiceServer(s.server, s.cluster)
+	proto.RegisterAgentServiceServer(s.server, NewAgentServer(s.clusters, s.authService))
+	proto.RegisterUserServiceServer(s.server, NewUserServer(s.authService))
+	proto.RegisterAuthServiceServer(s.server, NewAuthServer(s.authService))
+	proto.RegisterTokenServiceServer(s.server, NewTokenServer(s.authService))
+	if s.alerts != nil {
+		proto.RegisterAlertServiceServer(s.server, s.alerts)
+	}
+	if s.webhooks != nil {
+		proto.RegisterWebhookServiceServer(s.server, NewWebhookServer(s.webhooks))
+	}
+	if s.audit != nil {
+		proto.RegisterAuditServiceServer(s.server, NewAuditServer(s.audit))
+	}
 
-	log.Info("Starting gRPC server", "address", address)
 	go func() {
 		if err := s.server.Serve(lis); err != nil {
 			log.Error("Failed to serve gRPC", "error", err)
 		}
 	}()
-
-	return nil
 }
 
 // Stop stops the gRPC server
@@ -62,37 +153,218 @@ func (s *DeploymentServer) Stop() {
 	}
 }
 
-// Deploy handles the Deploy RPC call
+// Deploy handles the Deploy RPC call. The output of deploy hooks is returned
+// with the response.
 func (s *DeploymentServer) Deploy(ctx context.Context, req *proto.DeployRequest) (*proto.DeployResponse, error) {
-	log.Info("Received Deploy request", "service", req.ServiceName, "image", req.Image)
+	log.Info("Received Deploy request", "service", req.ServiceName, "image", req.Image, "cluster", req.Cluster)
+
+	var output strings.Builder
+	resp, err := s.deploy(ctx, req, &output)
+	if err != nil {
+		return nil, err
+	}
+	resp.HookOutput = output.String()
+	return resp, nil
+}
+
+// DeployStream handles the DeployStream RPC call, streaming the output of
+// deploy hooks as they run
+func (s *DeploymentServer) DeployStream(req *proto.DeployRequest, stream proto.DeploymentService_DeployStreamServer) error {
+	log.Info("Received DeployStream request", "service", req.ServiceName, "image", req.Image, "cluster", req.Cluster)
+
+	resp, err := s.deploy(stream.Context(), req, progressWriter{stream})
+	if err != nil {
+		return err
+	}
+	return stream.Send(&proto.DeployProgress{Result: resp})
+}
+
+// progressWriter streams what's written to it as deploy progress
+type progressWriter struct {
+	stream proto.DeploymentService_DeployStreamServer
+}
+
+func (w progressWriter) Write(p []byte) (int, error) {
+	if err := w.stream.Send(&proto.DeployProgress{Output: bytes.Clone(p)}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// deploy deploys the service of a request, writing the output of its hooks to output
+func (s *DeploymentServer) deploy(ctx context.Context, req *proto.DeployRequest, output io.Writer) (*proto.DeployResponse, error) {
+	m, err := managerFor(s.clusters, req.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	if !config.ValidHealthPolicy(req.HealthPolicy) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid health policy %q (expected restart, report or ignore)", req.HealthPolicy)
+	}
 
 	// Convert the request to a ServiceDefinition
 	serviceDef := config.ServiceDefinition{
 		Name:        req.ServiceName,
 		Image:       req.Image,
 		Environment: req.Env,
-		Replicas:    1, // Default to 1 replica
+		Labels:      req.Labels,
+		Replicas:    int(req.Replicas),
+		Constraints: req.Constraints,
+		Resources: config.ResourceConfig{
+			CPULimit:      req.CpuLimit,
+			MemoryLimit:   req.MemoryLimit,
+			CPUReserve:    req.CpuReserve,
+			MemoryReserve: req.MemoryReserve,
+		},
+		Placement: config.PlacementConfig{
+			MaxReplicasPerNode: req.MaxReplicasPerNode,
+		},
+		HealthCheck: config.HealthCheckConfig{
+			OnFailure: req.HealthPolicy,
+		},
+		PreDeploy:  req.PreDeploy,
+		PostDeploy: req.PostDeploy,
+		Hooks: config.HookConfig{
+			Timeout:           int(req.HookTimeoutSeconds),
+			RollbackOnFailure: req.RollbackOnHookFailure,
+		},
+	}
+	for _, spread := range req.PlacementPreferences {
+		serviceDef.Placement.Preferences = append(serviceDef.Placement.Preferences, config.PlacementPreference{Spread: spread})
+	}
+	if hc := req.Healthcheck; hc != nil {
+		serviceDef.HealthCheck.Command = hc.Command
+		serviceDef.HealthCheck.Interval = int(hc.IntervalSeconds)
+		serviceDef.HealthCheck.Timeout = int(hc.TimeoutSeconds)
+		serviceDef.HealthCheck.Retries = int(hc.Retries)
+		serviceDef.HealthCheck.StartPeriod = int(hc.StartPeriodSeconds)
+	}
+	for _, v := range req.Volumes {
+		if v.Destination == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "volume %q has no destination", v.Source)
+		}
+		serviceDef.Volumes = append(serviceDef.Volumes, config.VolumeMount{Source: v.Source, Destination: v.Destination, ReadOnly: v.ReadOnly})
+	}
+	if serviceDef.Replicas <= 0 {
+		serviceDef.Replicas = 1 // Default to 1 replica
+	}
+
+	// Admission webhooks may deny the deploy or change the service
+	serviceDef, warnings, err := s.admission.Admit(ctx, admission.Request{
+		Cluster: clusterName(s.clusters, req.Cluster),
+		User:    auth.Username(ctx),
+		Service: serviceDef,
+	})
+	if err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	policyWarnings, err := s.policy.Check(serviceDef)
+	warnings = append(warnings, policyWarnings...)
+	if err != nil {
+		log.Info("Deploy violates policy", "service", serviceDef.Name, "error", err)
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if _, ok := m.(manager.JobRunner); serviceDef.HasHooks() && !ok {
+		return nil, status.Error(codes.Unimplemented, manager.ErrHooksUnsupported.Error())
+	}
+	if req.HookTimeoutSeconds < 0 {
+		return nil, status.Error(codes.InvalidArgument, "hook timeout must not be negative")
 	}
 
 	// Deploy the service
-	deploymentID, err := s.manager.DeployService(serviceDef)
+	start := time.Now()
+	event := webhooks.Event{
+		Cluster: clusterName(s.clusters, req.Cluster),
+		Service: serviceDef.Name,
+		Data:    map[string]string{"image": serviceDef.Image},
+	}
+	s.webhooks.Publish(event.As(webhooks.EventDeployStarted))
+	// Deploys run to completion even if the client gives up; the context only
+	// carries the trace and collects capacity warnings
+	deployCtx := manager.WithWarnings(context.WithoutCancel(ctx), &warnings)
+	deploymentID, err := manager.DeployWithHooks(deployCtx, m, serviceDef, output)
+	metrics.ObserveDeploy(clusterName(s.clusters, req.Cluster), start, err)
+	if c, cerr := s.clusters.Get(req.Cluster); cerr == nil {
+		c.RecordDeploy(serviceDef.Name, err)
+	}
 	if err != nil {
 		log.Error("Failed to deploy service", "error", err)
+		s.webhooks.Publish(event.Failed(err))
+		var hookErr *manager.HookError
+		if errors.As(err, &hookErr) {
+			return nil, status.Error(codes.Aborted, err.Error())
+		}
 		return nil, fmt.Errorf("failed to deploy service: %w", err)
 	}
+	s.webhooks.Publish(event.As(webhooks.EventDeploySucceeded))
 
 	return &proto.DeployResponse{
 		DeploymentId: deploymentID,
 		Status:       "deployed",
+		Warnings:     warnings,
+	}, nil
+}
+
+// Scale handles the Scale RPC call
+func (s *DeploymentServer) Scale(ctx context.Context, req *proto.ScaleRequest) (*proto.GenericResponse, error) {
+	log.Info("Received Scale request", "deploymentID", req.DeploymentId, "replicas", req.Replicas, "cluster", req.Cluster)
+
+	m, err := managerFor(s.clusters, req.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	sm, ok := m.(manager.ServiceManager)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "scaling is not supported by this backend")
+	}
+	if req.Replicas < 0 {
+		return nil, status.Error(codes.InvalidArgument, "replicas must not be negative")
+	}
+
+	if err := sm.ScaleService(req.DeploymentId, int(req.Replicas)); err != nil {
+		log.Error("Failed to scale service", "error", err)
+		return &proto.GenericResponse{
+			Message: fmt.Sprintf("Failed to scale service: %v", err),
+			Success: false,
+		}, nil
+	}
+	s.webhooks.Publish(webhooks.Event{
+		Type:    webhooks.EventScale,
+		Cluster: clusterName(s.clusters, req.Cluster),
+		Service: serviceName(m, req.DeploymentId),
+		Data:    map[string]string{"replicas": strconv.Itoa(int(req.Replicas))},
+	})
+
+	return &proto.GenericResponse{
+		Message: fmt.Sprintf("Service scaled to %d replica(s)", req.Replicas),
+		Success: true,
 	}, nil
 }
 
 // Rollback handles the Rollback RPC call
 func (s *DeploymentServer) Rollback(ctx context.Context, req *proto.RollbackRequest) (*proto.GenericResponse, error) {
-	log.Info("Received Rollback request", "deploymentID", req.DeploymentId)
+	log.Info("Received Rollback request", "deploymentID", req.DeploymentId, "cluster", req.Cluster)
+
+	m, err := managerFor(s.clusters, req.Cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	// Look the name up first: rolling back may remove the service
+	service := serviceName(m, req.DeploymentId)
 
-	// For now, just remove the service as a simple rollback
-	err := s.manager.RemoveService(req.DeploymentId)
+	// Restore the previous version when the backend keeps one, otherwise
+	// rolling back a deployment removes it
+	rolledBack := false
+	if rm, ok := m.(manager.RollbackManager); ok {
+		err = rm.RollbackService(req.DeploymentId)
+		rolledBack = err == nil
+		if errors.Is(err, manager.ErrNoPreviousVersion) {
+			err = nil
+		}
+	}
+	if err == nil && !rolledBack {
+		err = m.RemoveService(req.DeploymentId)
+	}
 	if err != nil {
 		log.Error("Failed to rollback deployment", "error", err)
 		return &proto.GenericResponse{
@@ -100,6 +372,16 @@ func (s *DeploymentServer) Rollback(ctx context.Context, req *proto.RollbackRequ
 			Success: false,
 		}, nil
 	}
+	action := "removed"
+	if rolledBack {
+		action = "restored previous version"
+	}
+	s.webhooks.Publish(webhooks.Event{
+		Type:    webhooks.EventRollback,
+		Cluster: clusterName(s.clusters, req.Cluster),
+		Service: service,
+		Data:    map[string]string{"action": action},
+	})
 
 	return &proto.GenericResponse{
 		Message: "Deployment rolled back successfully",
@@ -109,10 +391,15 @@ func (s *DeploymentServer) Rollback(ctx context.Context, req *proto.RollbackRequ
 
 // GetStatus handles the GetStatus RPC call
 func (s *DeploymentServer) GetStatus(ctx context.Context, req *proto.StatusRequest) (*proto.StatusResponse, error) {
-	log.Info("Received GetStatus request", "deploymentID", req.DeploymentId)
+	log.Info("Received GetStatus request", "deploymentID", req.DeploymentId, "cluster", req.Cluster)
+
+	m, err := managerFor(s.clusters, req.Cluster)
+	if err != nil {
+		return nil, err
+	}
 
 	// Get the status of the service
-	status, err := s.manager.GetServiceStatus(req.DeploymentId)
+	status, err := m.GetServiceStatus(req.DeploymentId)
 	if err != nil {
 		log.Error("Failed to get service status", "error", err)
 		return nil, fmt.Errorf("failed to get service status: %w", err)
@@ -123,3 +410,29 @@ func (s *DeploymentServer) GetStatus(ctx context.Context, req *proto.StatusReque
 		Logs:   status.Logs,
 	}, nil
 }
+
+// clusterName resolves the cluster a request selects, for labels
+func clusterName(clusters *cluster.Registry, name string) string {
+	if name == "" {
+		return clusters.Default()
+	}
+	return name
+}
+
+// serviceName returns the name of a deployed service, or the ID it was
+// requested by if the backend doesn't know it
+func serviceName(m manager.Manager, deploymentID string) string {
+	if status, err := m.GetServiceStatus(deploymentID); err == nil && status.Service.Name != "" {
+		return status.Service.Name
+	}
+	return deploymentID
+}
+
+// managerFor returns the manager of the cluster a request selects
+func managerFor(clusters *cluster.Registry, name string) (manager.Manager, error) {
+	m, err := clusters.Manager(name)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return m, nil
+}
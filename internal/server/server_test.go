@@ -6,8 +6,11 @@ import (
 	"testing"
 
 	"github.com/jasonlovesdoggo/velo/api/proto"
+	"github.com/jasonlovesdoggo/velo/internal/auth"
 	"github.com/jasonlovesdoggo/velo/internal/config"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/cluster"
 	"github.com/jasonlovesdoggo/velo/internal/orchestrator/manager"
+	"github.com/jasonlovesdoggo/velo/internal/state"
 )
 
 // MockManager is a mock implementation of the Manager interface for testing
@@ -38,6 +41,11 @@ func (m *MockManager) GetServiceStatus(serviceID string) (config.DeploymentStatu
 	return m.ServiceStatus, m.ServiceStatusErr
 }
 
+// newTestServer creates a DeploymentServer backed by an in-memory auth service
+func newTestServer(m manager.Manager) *DeploymentServer {
+	return NewDeploymentServer(cluster.Single(m), auth.NewAuthService(state.NewMemoryStateStore()))
+}
+
 func TestDeploy(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -83,7 +91,7 @@ func TestDeploy(t *testing.T) {
 			}
 
 			// Create a server with the mock manager
-			server := NewDeploymentServer(mockManager)
+			server := newTestServer(mockManager)
 
 			// Call the Deploy method
 			resp, err := server.Deploy(context.Background(), tt.req)
@@ -142,7 +150,7 @@ func TestRollback(t *testing.T) {
 			}
 
 			// Create a server with the mock manager
-			server := NewDeploymentServer(mockManager)
+			server := newTestServer(mockManager)
 
 			// Call the Rollback method
 			resp, err := server.Rollback(context.Background(), tt.req)
@@ -202,7 +210,7 @@ func TestGetStatus(t *testing.T) {
 			}
 
 			// Create a server with the mock manager
-			server := NewDeploymentServer(mockManager)
+			server := newTestServer(mockManager)
 
 			// Call the GetStatus method
 			resp, err := server.GetStatus(context.Background(), tt.req)
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/api/proto"
+	"github.com/jasonlovesdoggo/velo/internal/auth"
+	"github.com/jasonlovesdoggo/velo/internal/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TokenServer implements the proto.TokenServiceServer interface
+type TokenServer struct {
+	proto.UnimplementedTokenServiceServer
+	authService *auth.AuthService
+}
+
+// NewTokenServer creates a new TokenServer for an authentication service
+func NewTokenServer(authService *auth.AuthService) *TokenServer {
+	return &TokenServer{authService: authService}
+}
+
+// CreateAPIToken handles the CreateAPIToken RPC call
+func (s *TokenServer) CreateAPIToken(ctx context.Context, req *proto.CreateAPITokenRequest) (*proto.CreateAPITokenResponse, error) {
+	log.Info("Received CreateAPIToken request", "name", req.Name, "username", req.Username, "scopes", req.Scopes)
+
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "not logged in")
+	}
+	value, token, err := s.authService.CreateAPIToken(user, req.Username, req.Name, req.Scopes, time.Duration(req.TtlSeconds)*time.Second)
+	if err != nil {
+		return nil, tokenError(err)
+	}
+	return &proto.CreateAPITokenResponse{
+		Token:    value,
+		ApiToken: s.tokenToProto(token),
+	}, nil
+}
+
+// ListAPITokens handles the ListAPITokens RPC call
+func (s *TokenServer) ListAPITokens(ctx context.Context, req *proto.ListAPITokensRequest) (*proto.ListAPITokensResponse, error) {
+	log.Info("Received ListAPITokens request", "all", req.All)
+
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "not logged in")
+	}
+	userID := user.ID
+	if req.All {
+		if err := auth.Authorize(user, auth.PermUsersManage, auth.Resource{}); err != nil {
+			return nil, tokenError(err)
+		}
+		userID = ""
+	}
+
+	tokens, err := s.authService.ListAPITokens(userID)
+	if err != nil {
+		log.Error("Failed to list API tokens", "error", err)
+		return nil, fmt.Errorf("failed to list API tokens: %w", err)
+	}
+	resp := &proto.ListAPITokensResponse{}
+	for _, token := range tokens {
+		resp.Tokens = append(resp.Tokens, s.tokenToProto(&token))
+	}
+	return resp, nil
+}
+
+// RevokeAPIToken handles the RevokeAPIToken RPC call
+func (s *TokenServer) RevokeAPIToken(ctx context.Context, req *proto.RevokeAPITokenRequest) (*proto.GenericResponse, error) {
+	log.Info("Received RevokeAPIToken request", "id", req.Id)
+
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "not logged in")
+	}
+	if err := s.authService.RevokeAPIToken(user, req.Id); err != nil {
+		return nil, tokenError(err)
+	}
+	return &proto.GenericResponse{
+		Message: fmt.Sprintf("API token %s revoked", req.Id),
+		Success: true,
+	}, nil
+}
+
+// tokenError maps the errors of API token management to gRPC status errors
+func tokenError(err error) error {
+	switch {
+	case errors.Is(err, auth.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, auth.ErrTokenInvalid):
+		return status.Error(codes.NotFound, "API token not found")
+	}
+	return userError(err)
+}
+
+func (s *TokenServer) tokenToProto(token *auth.APIToken) *proto.APIToken {
+	resp := &proto.APIToken{
+		Id:          token.ID,
+		Name:        token.Name,
+		Scopes:      token.Scopes,
+		CreatedUnix: token.Created.Unix(),
+	}
+	if user, err := s.authService.GetUserByID(token.UserID); err == nil {
+		resp.Username = user.Username
+	}
+	if !token.ExpiresAt.IsZero() {
+		resp.ExpiresUnix = token.ExpiresAt.Unix()
+	}
+	if !token.LastUsed.IsZero() {
+		resp.LastUsedUnix = token.LastUsed.Unix()
+	}
+	return resp
+}
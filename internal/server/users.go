@@ -0,0 +1,267 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/jasonlovesdoggo/velo/api/proto"
+	"github.com/jasonlovesdoggo/velo/internal/auth"
+	"github.com/jasonlovesdoggo/velo/internal/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// UserServer implements the proto.UserServiceServer interface
+type UserServer struct {
+	proto.UnimplementedUserServiceServer
+	authService *auth.AuthService
+}
+
+// NewUserServer creates a new UserServer for an authentication service
+func NewUserServer(authService *auth.AuthService) *UserServer {
+	return &UserServer{authService: authService}
+}
+
+// ListUsers handles the ListUsers RPC call
+func (s *UserServer) ListUsers(ctx context.Context, req *proto.ListUsersRequest) (*proto.ListUsersResponse, error) {
+	log.Info("Received ListUsers request")
+
+	users, err := s.authService.GetAllUsers()
+	if err != nil {
+		log.Error("Failed to list users", "error", err)
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	resp := &proto.ListUsersResponse{}
+	for _, user := range users {
+		resp.Users = append(resp.Users, userToProto(&user))
+	}
+	return resp, nil
+}
+
+// CreateUser handles the CreateUser RPC call
+func (s *UserServer) CreateUser(ctx context.Context, req *proto.CreateUserRequest) (*proto.User, error) {
+	log.Info("Received CreateUser request", "username", req.Username, "role", req.Role)
+
+	var user *auth.User
+	var err error
+	if req.ServiceAccount {
+		user, err = s.authService.AddServiceAccount(req.Username, req.Role)
+	} else {
+		user, err = s.authService.AddUser(req.Username, req.Password, req.Role)
+	}
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return userToProto(user), nil
+}
+
+// DeleteUser handles the DeleteUser RPC call. Users are deactivated rather
+// than deleted, so the audit log keeps making sense.
+func (s *UserServer) DeleteUser(ctx context.Context, req *proto.DeleteUserRequest) (*proto.GenericResponse, error) {
+	log.Info("Received DeleteUser request", "username", req.Username)
+
+	if req.Username == auth.Username(ctx) {
+		return nil, status.Error(codes.FailedPrecondition, "you can't delete yourself")
+	}
+	user, err := s.authService.GetUserByUsername(req.Username)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err := s.authService.DeleteUser(user.ID); err != nil {
+		log.Error("Failed to delete user", "username", req.Username, "error", err)
+		return nil, fmt.Errorf("failed to delete user: %w", err)
+	}
+	return &proto.GenericResponse{
+		Message: fmt.Sprintf("User %s deleted", req.Username),
+		Success: true,
+	}, nil
+}
+
+// SetUserRole handles the SetUserRole RPC call
+func (s *UserServer) SetUserRole(ctx context.Context, req *proto.SetUserRoleRequest) (*proto.User, error) {
+	log.Info("Received SetUserRole request", "username", req.Username, "role", req.Role)
+
+	if req.Username == auth.Username(ctx) && req.Role != auth.RoleAdmin {
+		return nil, status.Error(codes.FailedPrecondition, "you can't take away your own admin role")
+	}
+	user, err := s.authService.SetRole(req.Username, req.Role)
+	if err != nil {
+		return nil, userError(err)
+	}
+	return userToProto(user), nil
+}
+
+// GrantRole handles the GrantRole RPC call
+func (s *UserServer) GrantRole(ctx context.Context, req *proto.RoleBindingRequest) (*proto.User, error) {
+	log.Info("Received GrantRole request", "username", req.Username, "binding", req.Binding.String())
+
+	user, err := s.authService.Grant(req.Username, bindingFromProto(req.Binding))
+	if err != nil {
+		return nil, userError(err)
+	}
+	return userToProto(user), nil
+}
+
+// RevokeRole handles the RevokeRole RPC call
+func (s *UserServer) RevokeRole(ctx context.Context, req *proto.RoleBindingRequest) (*proto.User, error) {
+	log.Info("Received RevokeRole request", "username", req.Username, "binding", req.Binding.String())
+
+	user, err := s.authService.Revoke(req.Username, bindingFromProto(req.Binding))
+	if err != nil {
+		return nil, userError(err)
+	}
+	return userToProto(user), nil
+}
+
+// AuthServer implements the proto.AuthServiceServer interface
+type AuthServer struct {
+	proto.UnimplementedAuthServiceServer
+	authService *auth.AuthService
+}
+
+// NewAuthServer creates a new AuthServer for an authentication service
+func NewAuthServer(authService *auth.AuthService) *AuthServer {
+	return &AuthServer{authService: authService}
+}
+
+// Login handles the Login RPC call
+func (s *AuthServer) Login(ctx context.Context, req *proto.LoginRequest) (*proto.LoginResponse, error) {
+	log.Info("Received Login request", "username", req.Username)
+
+	token, err := s.authService.AuthenticateFrom(req.Username, req.Password, peerIP(ctx))
+	if errors.Is(err, auth.ErrTooManyAttempts) {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+	user, err := s.authService.ValidateToken(token.Value)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return &proto.LoginResponse{
+		Token:       token.Value,
+		ExpiresUnix: token.ExpiresAt.Unix(),
+		User:        userToProto(user),
+	}, nil
+}
+
+// Logout handles the Logout RPC call
+func (s *AuthServer) Logout(ctx context.Context, req *proto.LogoutRequest) (*proto.GenericResponse, error) {
+	log.Info("Received Logout request", "username", auth.Username(ctx))
+
+	token := auth.BearerToken(ctx)
+	if token == "" {
+		return nil, status.Error(codes.Unauthenticated, "not logged in")
+	}
+	if err := s.authService.RevokeToken(token); errors.Is(err, auth.ErrNotSessionToken) {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	} else if err != nil {
+		log.Error("Failed to revoke token", "error", err)
+		return nil, fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return &proto.GenericResponse{
+		Message: "Logged out",
+		Success: true,
+	}, nil
+}
+
+// GetCurrentUser handles the GetCurrentUser RPC call
+func (s *AuthServer) GetCurrentUser(ctx context.Context, req *proto.GetCurrentUserRequest) (*proto.User, error) {
+	log.Info("Received GetCurrentUser request", "username", auth.Username(ctx))
+
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "not logged in")
+	}
+	return userToProto(user), nil
+}
+
+// ChangePassword handles the ChangePassword RPC call. Users change their own
+// password; admins set others', who must then change it on their next login.
+// Admins who still have to change their own password can't set others'.
+func (s *AuthServer) ChangePassword(ctx context.Context, req *proto.ChangePasswordRequest) (*proto.GenericResponse, error) {
+	log.Info("Received ChangePassword request", "username", auth.Username(ctx), "for", req.Username)
+
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "not logged in")
+	}
+	if req.Username != "" && req.Username != user.Username {
+		if err := auth.Authorize(user, auth.PermUsersManage, auth.Resource{}); err != nil {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		if user.MustChangePassword {
+			return nil, status.Error(codes.FailedPrecondition, "change your own password before setting others'")
+		}
+		if _, err := s.authService.ResetPassword(req.Username, req.NewPassword); err != nil {
+			return nil, userError(err)
+		}
+		return &proto.GenericResponse{
+			Message: fmt.Sprintf("Password of %s set; they must change it on their next login", req.Username),
+			Success: true,
+		}, nil
+	}
+
+	if user.Scopes != nil || user.ServiceAccount {
+		return nil, status.Error(codes.PermissionDenied, "API tokens can't change passwords, log in instead")
+	}
+	if err := s.authService.ChangeOwnPassword(user.ID, req.CurrentPassword, req.NewPassword, auth.BearerToken(ctx)); err != nil {
+		if errors.Is(err, auth.ErrTooManyAttempts) {
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		}
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, userError(err)
+	}
+	return &proto.GenericResponse{
+		Message: "Password changed",
+		Success: true,
+	}, nil
+}
+
+// peerIP returns the IP address a call comes from
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// userError maps the errors of user management to gRPC status errors
+func userError(err error) error {
+	if errors.Is(err, auth.ErrUserNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.InvalidArgument, err.Error())
+}
+
+func bindingFromProto(b *proto.RoleBinding) auth.Binding {
+	return auth.Binding{Role: b.GetRole(), Project: b.GetProject(), Service: b.GetService()}
+}
+
+func userToProto(user *auth.User) *proto.User {
+	resp := &proto.User{
+		Id:                 user.ID,
+		Username:           user.Username,
+		Role:               user.Role,
+		Active:             user.Active,
+		CreatedUnix:        user.Created.Unix(),
+		ServiceAccount:     user.ServiceAccount,
+		MustChangePassword: user.MustChangePassword,
+	}
+	for _, b := range user.Bindings {
+		resp.Bindings = append(resp.Bindings, &proto.RoleBinding{Role: b.Role, Project: b.Project, Service: b.Service})
+	}
+	return resp
+}
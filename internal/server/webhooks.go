@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jasonlovesdoggo/velo/api/proto"
+	"github.com/jasonlovesdoggo/velo/internal/log"
+	"github.com/jasonlovesdoggo/velo/internal/webhooks"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// WebhookServer implements the proto.WebhookServiceServer interface
+type WebhookServer struct {
+	proto.UnimplementedWebhookServiceServer
+	dispatcher *webhooks.Dispatcher
+}
+
+// NewWebhookServer creates a new WebhookServer for a webhook dispatcher
+func NewWebhookServer(dispatcher *webhooks.Dispatcher) *WebhookServer {
+	return &WebhookServer{dispatcher: dispatcher}
+}
+
+// CreateWebhook handles the CreateWebhook RPC call. The response is the only
+// place the webhook's secret is returned.
+func (s *WebhookServer) CreateWebhook(ctx context.Context, req *proto.CreateWebhookRequest) (*proto.Webhook, error) {
+	log.Info("Received CreateWebhook request", "events", req.Events, "cluster", req.Cluster, "service", req.Service)
+
+	sub, err := s.dispatcher.CreateSubscription(webhooks.Subscription{
+		URL:         req.Url,
+		Secret:      req.Secret,
+		Events:      req.Events,
+		Cluster:     req.Cluster,
+		Service:     req.Service,
+		Description: req.Description,
+	})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	resp := webhookToProto(sub)
+	resp.Secret = sub.Secret
+	return resp, nil
+}
+
+// ListWebhooks handles the ListWebhooks RPC call
+func (s *WebhookServer) ListWebhooks(ctx context.Context, req *proto.ListWebhooksRequest) (*proto.ListWebhooksResponse, error) {
+	log.Info("Received ListWebhooks request")
+
+	subscriptions, err := s.dispatcher.Subscriptions()
+	if err != nil {
+		log.Error("Failed to list webhooks", "error", err)
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	resp := &proto.ListWebhooksResponse{EventTypes: webhooks.EventTypes}
+	for _, sub := range subscriptions {
+		resp.Webhooks = append(resp.Webhooks, webhookToProto(sub))
+	}
+	return resp, nil
+}
+
+// DeleteWebhook handles the DeleteWebhook RPC call
+func (s *WebhookServer) DeleteWebhook(ctx context.Context, req *proto.DeleteWebhookRequest) (*proto.GenericResponse, error) {
+	log.Info("Received DeleteWebhook request", "id", req.Id)
+
+	if err := s.dispatcher.DeleteSubscription(req.Id); err != nil {
+		return &proto.GenericResponse{
+			Message: fmt.Sprintf("Failed to delete webhook %s: %v", req.Id, err),
+			Success: false,
+		}, nil
+	}
+
+	return &proto.GenericResponse{
+		Message: fmt.Sprintf("Webhook %s deleted", req.Id),
+		Success: true,
+	}, nil
+}
+
+// ListWebhookDeliveries handles the ListWebhookDeliveries RPC call
+func (s *WebhookServer) ListWebhookDeliveries(ctx context.Context, req *proto.ListWebhookDeliveriesRequest) (*proto.ListWebhookDeliveriesResponse, error) {
+	log.Info("Received ListWebhookDeliveries request", "webhook", req.Webhook, "limit", req.Limit)
+
+	deliveries, err := s.dispatcher.Deliveries(req.Webhook, int(req.Limit))
+	if err != nil {
+		log.Error("Failed to list webhook deliveries", "error", err)
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	resp := &proto.ListWebhookDeliveriesResponse{}
+	for _, d := range deliveries {
+		delivery := &proto.WebhookDelivery{
+			Id:          d.ID,
+			Webhook:     d.Subscription,
+			Event:       d.Event,
+			EventId:     d.EventID,
+			State:       d.State,
+			Attempts:    int32(d.Attempts),
+			LastStatus:  int32(d.LastStatus),
+			LastError:   d.LastError,
+			CreatedUnix: d.Created.Unix(),
+		}
+		if d.State == webhooks.DeliveryPending {
+			delivery.NextAttemptUnix = d.NextAttempt.Unix()
+		} else {
+			delivery.FinishedUnix = d.Finished.Unix()
+		}
+		resp.Deliveries = append(resp.Deliveries, delivery)
+	}
+	return resp, nil
+}
+
+func webhookToProto(sub webhooks.Subscription) *proto.Webhook {
+	return &proto.Webhook{
+		Id:          sub.ID,
+		Url:         sub.URL,
+		Events:      sub.Events,
+		Cluster:     sub.Cluster,
+		Service:     sub.Service,
+		Description: sub.Description,
+		CreatedUnix: sub.Created.Unix(),
+	}
+}
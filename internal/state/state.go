@@ -22,6 +22,9 @@ type Store interface {
 	// It's not an error to delete a non-existent key.
 	Delete(key string) error
 
+	// List returns all keys with a given prefix.
+	List(prefix string) ([]string, error)
+
 	// Close releases any resources used by the store (like database connections).
 	Close() error
 }
@@ -100,6 +100,21 @@ func runStoreImplementationTests(t *testing.T, store Store) {
 			t.Errorf("Delete() of non-existent key failed: %v", err)
 		}
 	})
+
+	t.Run("ListByPrefix", func(t *testing.T) {
+		for _, key := range []string{"list:b", "list:a", "lister"} {
+			if err := store.Set(key, "value"); err != nil {
+				t.Fatalf("Set(%q) failed: %v", key, err)
+			}
+		}
+		keys, err := store.List("list:")
+		if err != nil {
+			t.Fatalf("List() failed: %v", err)
+		}
+		if strings.Join(keys, ",") != "list:a,list:b" {
+			t.Errorf("List(%q) returned %v, want [list:a list:b]", "list:", keys)
+		}
+	})
 }
 
 func runStorePersistenceTest(t *testing.T, cfg Config) {
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 )
 
 // JSONStateStore implements StateStore using a JSON-based Store backend
@@ -49,10 +50,7 @@ func (s *JSONStateStore) Delete(key string) error {
 
 // List returns all keys with a given prefix
 func (s *JSONStateStore) List(prefix string) ([]string, error) {
-	// This is a simplified implementation - a real implementation would
-	// need to be backed by a store that supports key listing
-	// For now, return empty list
-	return []string{}, nil
+	return s.store.List(prefix)
 }
 
 // Close releases any resources
@@ -77,6 +75,7 @@ func NewDefaultStateStore() (StateStore, error) {
 
 // MemoryStateStore is an in-memory implementation for testing
 type MemoryStateStore struct {
+	mu   sync.RWMutex
 	data map[string]string
 }
 
@@ -88,7 +87,9 @@ func NewMemoryStateStore() StateStore {
 }
 
 func (m *MemoryStateStore) Get(key string, value interface{}) error {
+	m.mu.RLock()
 	data, exists := m.data[key]
+	m.mu.RUnlock()
 	if !exists {
 		return fmt.Errorf("key not found: %s", key)
 	}
@@ -102,16 +103,22 @@ func (m *MemoryStateStore) Set(key string, value interface{}) error {
 		return err
 	}
 
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.data[key] = string(data)
 	return nil
 }
 
 func (m *MemoryStateStore) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	delete(m.data, key)
 	return nil
 }
 
 func (m *MemoryStateStore) List(prefix string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	var keys []string
 	for key := range m.data {
 		if strings.HasPrefix(key, prefix) {
@@ -122,6 +129,8 @@ func (m *MemoryStateStore) List(prefix string) ([]string, error) {
 }
 
 func (m *MemoryStateStore) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.data = nil
 	return nil
 }
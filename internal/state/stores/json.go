@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 	"sync"
 )
 
@@ -123,6 +125,20 @@ func (s *JsonStore) Delete(key string) error {
 	return nil
 }
 
+func (s *JsonStore) List(prefix string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var keys []string
+	for key := range s.data {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
 func (s *JsonStore) Close() error {
 	// No explicit resources to close for the JSON store (file handles are managed per operation).
 	// Could potentially force a save here if needed, but current design saves on modify.
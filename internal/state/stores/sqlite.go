@@ -23,6 +23,7 @@ const (
 	getSQL    = `SELECT value FROM config WHERE key = ?;`
 	setSQL    = `INSERT INTO config (key, value, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP) ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = CURRENT_TIMESTAMP;`
 	deleteSQL = `DELETE FROM config WHERE key = ?;`
+	listSQL   = `SELECT key FROM config WHERE substr(key, 1, length(?)) = ? ORDER BY key;`
 )
 
 type SqliteStore struct {
@@ -31,6 +32,29 @@ type SqliteStore struct {
 
 // NewSQLiteStore creates and initializes a new SQLite-backed store.
 func NewSQLiteStore(dbPath string) (*SqliteStore, error) {
+	db, err := openSQLite(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	// Ensure table and index exist
+	if _, err := db.ExecContext(ctx, createTableSQL); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create config table: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, createKeyIndexSQL); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create config key index: %w", err)
+	}
+
+	return &SqliteStore{db: db}, nil
+}
+
+// openSQLite opens a SQLite database, creating the file if needed
+func openSQLite(dbPath string) (*sql.DB, error) {
 	// Add necessary parameters for better performance and WAL mode (good for Litestream if we use that down the line)
 	// _busy_timeout helps with concurrent writes.
 	// _journal_mode=WAL allows concurrent reads and writes.
@@ -54,18 +78,7 @@ func NewSQLiteStore(dbPath string) (*SqliteStore, error) {
 		db.Close() // Close the potentially invalid DB handle
 		return nil, fmt.Errorf("failed to ping sqlite database %q: %w", dbPath, err)
 	}
-
-	// Ensure table and index exist
-	if _, err := db.ExecContext(ctx, createTableSQL); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("failed to create config table: %w", err)
-	}
-	if _, err := db.ExecContext(ctx, createKeyIndexSQL); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("failed to create config key index: %w", err)
-	}
-
-	return &SqliteStore{db: db}, nil
+	return db, nil
 }
 
 func (s *SqliteStore) Get(key string) (string, error) {
@@ -106,6 +119,27 @@ func (s *SqliteStore) Delete(key string) error {
 	return nil
 }
 
+func (s *SqliteStore) List(prefix string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, listSQL, prefix, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite List failed for prefix %q: %w", prefix, err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("sqlite List failed for prefix %q: %w", prefix, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
 func (s *SqliteStore) Close() error {
 	if s.db != nil {
 		return s.db.Close()
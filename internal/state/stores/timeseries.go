@@ -0,0 +1,730 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/log"
+)
+
+// Resolution is the granularity samples are kept at
+type Resolution string
+
+// Resolutions of the time-series store. Raw samples are rolled up into one row
+// per minute and per hour as they're written.
+const (
+	ResolutionRaw    Resolution = "raw"
+	ResolutionMinute Resolution = "1m"
+	ResolutionHour   Resolution = "1h"
+)
+
+// Aggregations supported by range queries
+const (
+	AggregateAvg = "avg"
+	AggregateMax = "max"
+	AggregateP95 = "p95"
+)
+
+const (
+	createSeriesTableSQL = `
+	CREATE TABLE IF NOT EXISTS ts_series (
+		id INTEGER PRIMARY KEY,
+		metric TEXT NOT NULL,
+		labels TEXT NOT NULL,
+		UNIQUE(metric, labels)
+	);`
+	createRawTableSQL = `
+	CREATE TABLE IF NOT EXISTS ts_raw (
+		series_id INTEGER NOT NULL,
+		ts INTEGER NOT NULL,
+		value REAL NOT NULL,
+		PRIMARY KEY (series_id, ts)
+	) WITHOUT ROWID;`
+	// Rollup tables keep enough per bucket to aggregate buckets further
+	createRollupTableSQL = `
+	CREATE TABLE IF NOT EXISTS %s (
+		series_id INTEGER NOT NULL,
+		ts INTEGER NOT NULL,
+		count INTEGER NOT NULL,
+		sum REAL NOT NULL,
+		min REAL NOT NULL,
+		max REAL NOT NULL,
+		p95 REAL NOT NULL,
+		PRIMARY KEY (series_id, ts)
+	) WITHOUT ROWID;`
+	// Retention deletes scan by time
+	createTimeIndexSQL = `CREATE INDEX IF NOT EXISTS idx_%[1]s_ts ON %[1]s(ts);`
+
+	insertSeriesSQL  = `INSERT OR IGNORE INTO ts_series (metric, labels) VALUES (?, ?);`
+	selectSeriesSQL  = `SELECT id FROM ts_series WHERE metric = ? AND labels = ?;`
+	metricSeriesSQL  = `SELECT id, labels FROM ts_series WHERE metric = ?;`
+	insertRawSQL     = `INSERT OR REPLACE INTO ts_raw (series_id, ts, value) VALUES (?, ?, ?);`
+	bucketRawSQL     = `SELECT value FROM ts_raw WHERE series_id = ? AND ts >= ? AND ts < ?;`
+	bucketRollupSQL  = `SELECT count, sum, min, max, p95 FROM %s WHERE series_id = ? AND ts >= ? AND ts < ?;`
+	upsertRollupSQL  = `INSERT OR REPLACE INTO %s (series_id, ts, count, sum, min, max, p95) VALUES (?, ?, ?, ?, ?, ?, ?);`
+	expireSQL        = `DELETE FROM %s WHERE ts < ?;`
+	deleteOrphansSQL = `
+	DELETE FROM ts_series WHERE
+		id NOT IN (SELECT series_id FROM ts_raw) AND
+		id NOT IN (SELECT series_id FROM ts_1m) AND
+		id NOT IN (SELECT series_id FROM ts_1h);`
+)
+
+// tables maps every resolution to its table
+var tables = map[Resolution]string{
+	ResolutionRaw:    "ts_raw",
+	ResolutionMinute: "ts_1m",
+	ResolutionHour:   "ts_1h",
+}
+
+// Sample is the value of a metric for a label set at a point in time
+type Sample struct {
+	Metric string
+	Labels map[string]string
+	Time   time.Time
+	Value  float64
+}
+
+// Retention is how long samples are kept at every resolution
+type Retention struct {
+	Raw    time.Duration
+	Minute time.Duration
+	Hour   time.Duration
+}
+
+// TimeSeriesOptions configures a TimeSeriesStore
+type TimeSeriesOptions struct {
+	BatchSize       int           // buffered samples that trigger a write
+	FlushInterval   time.Duration // how often buffered samples are written anyway
+	CompactInterval time.Duration // how often expired samples are deleted
+	Retention       Retention
+}
+
+// DefaultTimeSeriesOptions returns the options the store runs with unless configured otherwise
+func DefaultTimeSeriesOptions() TimeSeriesOptions {
+	return TimeSeriesOptions{
+		BatchSize:       1000,
+		FlushInterval:   10 * time.Second,
+		CompactInterval: 10 * time.Minute,
+		Retention: Retention{
+			Raw:    24 * time.Hour,
+			Minute: 7 * 24 * time.Hour,
+			Hour:   90 * 24 * time.Hour,
+		},
+	}
+}
+
+// RangeQuery selects the series of a metric and aggregates them over time
+type RangeQuery struct {
+	Metric string
+	// Labels the series must have; other labels are ignored
+	Labels map[string]string
+	// GroupBy merges the series that share these labels. Without it every label
+	// set is its own series.
+	GroupBy     []string
+	Start       time.Time
+	End         time.Time     // defaults to now
+	Step        time.Duration // width of the aggregated points, defaults to the resolution
+	Aggregation string        // avg (default), max or p95
+	// Resolution to read; empty picks the coarsest one that fits the step and
+	// still covers the start of the range
+	Resolution Resolution
+}
+
+// Series is an aggregated label set over time
+type Series struct {
+	Metric string            `json:"metric"`
+	Labels map[string]string `json:"labels"`
+	Points []Point           `json:"points"`
+}
+
+// Point is an aggregated value at the start of a step
+type Point struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// TimeSeriesStore stores metrics in SQLite. Writes are buffered and written in
+// batches; every batch also updates the 1m and 1h rollups of the buckets it
+// touches, so late samples are rolled up as long as their raw bucket is kept.
+type TimeSeriesStore struct {
+	db     *sql.DB
+	ownsDB bool
+	opts   TimeSeriesOptions
+
+	mu      sync.Mutex
+	pending []Sample
+	series  map[string]int64 // series IDs by metric and labels
+
+	writeMu sync.Mutex // serializes batches
+	flush   chan struct{}
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewTimeSeriesStore opens a time-series store in its own SQLite database
+func NewTimeSeriesStore(dbPath string, opts TimeSeriesOptions) (*TimeSeriesStore, error) {
+	db, err := openSQLite(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := newTimeSeriesStore(db, opts)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	ts.ownsDB = true
+	return ts, nil
+}
+
+// TimeSeries opens a time-series store in the same database as the key-value
+// store. Closing it leaves the database open.
+func (s *SqliteStore) TimeSeries(opts TimeSeriesOptions) (*TimeSeriesStore, error) {
+	return newTimeSeriesStore(s.db, opts)
+}
+
+func newTimeSeriesStore(db *sql.DB, opts TimeSeriesOptions) (*TimeSeriesStore, error) {
+	defaults := DefaultTimeSeriesOptions()
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaults.BatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaults.FlushInterval
+	}
+	if opts.CompactInterval <= 0 {
+		opts.CompactInterval = defaults.CompactInterval
+	}
+	if opts.Retention.Raw <= 0 {
+		opts.Retention.Raw = defaults.Retention.Raw
+	}
+	if opts.Retention.Minute <= 0 {
+		opts.Retention.Minute = defaults.Retention.Minute
+	}
+	if opts.Retention.Hour <= 0 {
+		opts.Retention.Hour = defaults.Retention.Hour
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	statements := []string{
+		createSeriesTableSQL,
+		createRawTableSQL,
+		fmt.Sprintf(createRollupTableSQL, tables[ResolutionMinute]),
+		fmt.Sprintf(createRollupTableSQL, tables[ResolutionHour]),
+	}
+	for _, table := range tables {
+		statements = append(statements, fmt.Sprintf(createTimeIndexSQL, table))
+	}
+	for _, stmt := range statements {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return nil, fmt.Errorf("failed to create time-series tables: %w", err)
+		}
+	}
+
+	ts := &TimeSeriesStore{
+		db:     db,
+		opts:   opts,
+		series: make(map[string]int64),
+		flush:  make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	ts.wg.Add(1)
+	go ts.run()
+	return ts, nil
+}
+
+// Append buffers samples. They're written once the batch is full or the flush
+// interval passes, whichever comes first.
+func (ts *TimeSeriesStore) Append(samples ...Sample) {
+	ts.mu.Lock()
+	ts.pending = append(ts.pending, samples...)
+	full := len(ts.pending) >= ts.opts.BatchSize
+	ts.mu.Unlock()
+
+	if full {
+		select {
+		case ts.flush <- struct{}{}:
+		default: // a flush is already due
+		}
+	}
+}
+
+// Flush writes the buffered samples and updates their rollups
+func (ts *TimeSeriesStore) Flush() error {
+	ts.writeMu.Lock()
+	defer ts.writeMu.Unlock()
+
+	ts.mu.Lock()
+	batch := ts.pending
+	ts.pending = nil
+	ts.mu.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+	if err := ts.write(batch); err != nil {
+		return fmt.Errorf("failed to write %d samples: %w", len(batch), err)
+	}
+	return nil
+}
+
+// run flushes and compacts the store in the background until it's closed
+func (ts *TimeSeriesStore) run() {
+	defer ts.wg.Done()
+	flushTicker := time.NewTicker(ts.opts.FlushInterval)
+	defer flushTicker.Stop()
+	compactTicker := time.NewTicker(ts.opts.CompactInterval)
+	defer compactTicker.Stop()
+
+	for {
+		select {
+		case <-flushTicker.C:
+		case <-ts.flush:
+		case <-compactTicker.C:
+			if err := ts.Compact(); err != nil {
+				log.Error("Failed to compact time-series store", "error", err)
+			}
+			continue
+		case <-ts.done:
+			return
+		}
+		if err := ts.Flush(); err != nil {
+			log.Error("Failed to flush time-series samples", "error", err)
+		}
+	}
+}
+
+// write inserts a batch of raw samples and recomputes the rollups of the
+// buckets they fall into, in one transaction
+func (ts *TimeSeriesStore) write(batch []Sample) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tx, err := ts.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	type bucket struct {
+		series int64
+		start  int64
+	}
+	created := make(map[string]int64)
+	minutes := make(map[bucket]bool)
+	for _, s := range batch {
+		id, err := ts.seriesID(ctx, tx, s.Metric, s.Labels, created)
+		if err != nil {
+			return err
+		}
+		at := s.Time.UnixMilli()
+		if _, err := tx.ExecContext(ctx, insertRawSQL, id, at, s.Value); err != nil {
+			return err
+		}
+		minutes[bucket{id, floorMillis(at, time.Minute)}] = true
+	}
+
+	hours := make(map[bucket]bool)
+	for b := range minutes {
+		if err := ts.rollup(ctx, tx, ResolutionMinute, b.series, b.start); err != nil {
+			return err
+		}
+		hours[bucket{b.series, floorMillis(b.start, time.Hour)}] = true
+	}
+	for b := range hours {
+		if err := ts.rollup(ctx, tx, ResolutionHour, b.series, b.start); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	ts.mu.Lock()
+	for key, id := range created {
+		ts.series[key] = id
+	}
+	ts.mu.Unlock()
+	return nil
+}
+
+// seriesID returns the ID of a series, creating it in tx if needed. IDs created
+// in tx are only cached once it commits.
+func (ts *TimeSeriesStore) seriesID(ctx context.Context, tx *sql.Tx, metric string, labels map[string]string, created map[string]int64) (int64, error) {
+	encoded, err := encodeLabels(labels)
+	if err != nil {
+		return 0, err
+	}
+	key := metric + encoded
+	ts.mu.Lock()
+	id, ok := ts.series[key]
+	ts.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+	if id, ok := created[key]; ok {
+		return id, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, insertSeriesSQL, metric, encoded); err != nil {
+		return 0, err
+	}
+	if err := tx.QueryRowContext(ctx, selectSeriesSQL, metric, encoded).Scan(&id); err != nil {
+		return 0, err
+	}
+	created[key] = id
+	return id, nil
+}
+
+// rollup recomputes one bucket of a rollup table from the next finer resolution
+func (ts *TimeSeriesStore) rollup(ctx context.Context, tx *sql.Tx, res Resolution, series, start int64) error {
+	var rows *sql.Rows
+	var err error
+	switch res {
+	case ResolutionMinute:
+		rows, err = tx.QueryContext(ctx, bucketRawSQL, series, start, start+time.Minute.Milliseconds())
+	case ResolutionHour:
+		rows, err = tx.QueryContext(ctx, fmt.Sprintf(bucketRollupSQL, tables[ResolutionMinute]), series, start, start+time.Hour.Milliseconds())
+	default:
+		return fmt.Errorf("can't roll up to %s", res)
+	}
+	if err != nil {
+		return err
+	}
+	var parts []aggregate
+	for rows.Next() {
+		var a aggregate
+		if res == ResolutionMinute {
+			var value float64
+			err = rows.Scan(&value)
+			a = single(value)
+		} else {
+			err = rows.Scan(&a.count, &a.sum, &a.min, &a.max, &a.p95)
+		}
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		parts = append(parts, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+
+	a := combine(parts)
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(upsertRollupSQL, tables[res]), series, start, a.count, a.sum, a.min, a.max, a.p95)
+	return err
+}
+
+// Compact deletes the samples past the retention of their resolution and the
+// series left without samples
+func (ts *TimeSeriesStore) Compact() error {
+	// Hold off writes so no batch uses a series that's being deleted
+	ts.writeMu.Lock()
+	defer ts.writeMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	now := time.Now()
+	for res, retention := range ts.retentions() {
+		cutoff := now.Add(-retention).UnixMilli()
+		if _, err := ts.db.ExecContext(ctx, fmt.Sprintf(expireSQL, tables[res]), cutoff); err != nil {
+			return fmt.Errorf("failed to expire %s samples: %w", res, err)
+		}
+	}
+
+	result, err := ts.db.ExecContext(ctx, deleteOrphansSQL)
+	if err != nil {
+		return fmt.Errorf("failed to delete empty series: %w", err)
+	}
+	if deleted, _ := result.RowsAffected(); deleted > 0 {
+		ts.mu.Lock()
+		ts.series = make(map[string]int64)
+		ts.mu.Unlock()
+	}
+	// Give the space of deleted rows back to the main database file
+	if _, err := ts.db.ExecContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE);`); err != nil {
+		return fmt.Errorf("failed to checkpoint: %w", err)
+	}
+	return nil
+}
+
+func (ts *TimeSeriesStore) retentions() map[Resolution]time.Duration {
+	return map[Resolution]time.Duration{
+		ResolutionRaw:    ts.opts.Retention.Raw,
+		ResolutionMinute: ts.opts.Retention.Minute,
+		ResolutionHour:   ts.opts.Retention.Hour,
+	}
+}
+
+// Query aggregates the series of a metric over a time range. Every point
+// aggregates the samples of its step: avg and max are exact at every resolution,
+// p95 is exact on raw samples and estimated from the per-bucket p95 of rollups.
+func (ts *TimeSeriesStore) Query(q RangeQuery) ([]Series, error) {
+	if q.Metric == "" {
+		return nil, errors.New("metric is required")
+	}
+	switch q.Aggregation {
+	case "":
+		q.Aggregation = AggregateAvg
+	case AggregateAvg, AggregateMax, AggregateP95:
+	default:
+		return nil, fmt.Errorf("invalid aggregation %q (expected avg, max or p95)", q.Aggregation)
+	}
+	if q.End.IsZero() {
+		q.End = time.Now()
+	}
+	res := q.Resolution
+	if res == "" {
+		res = ts.resolutionFor(q.Start, q.Step)
+	}
+	table, ok := tables[res]
+	if !ok {
+		return nil, fmt.Errorf("invalid resolution %q (expected raw, 1m or 1h)", res)
+	}
+	if q.Step <= 0 {
+		q.Step = time.Minute
+		if res == ResolutionHour {
+			q.Step = time.Hour
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Find the matching series and the group each belongs to
+	rows, err := ts.db.QueryContext(ctx, metricSeriesSQL, q.Metric)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list series: %w", err)
+	}
+	groups := make(map[int64]string)
+	groupLabels := make(map[string]map[string]string)
+	var ids []any
+	for rows.Next() {
+		var id int64
+		var encoded string
+		if err := rows.Scan(&id, &encoded); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to list series: %w", err)
+		}
+		labels := make(map[string]string)
+		if err := json.Unmarshal([]byte(encoded), &labels); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("invalid labels of series %d: %w", id, err)
+		}
+		if !matchLabels(labels, q.Labels) {
+			continue
+		}
+		if len(q.GroupBy) > 0 {
+			grouped := make(map[string]string, len(q.GroupBy))
+			for _, name := range q.GroupBy {
+				if value, ok := labels[name]; ok {
+					grouped[name] = value
+				}
+			}
+			labels = grouped
+		}
+		key, _ := encodeLabels(labels)
+		groups[id] = key
+		groupLabels[key] = labels
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list series: %w", err)
+	}
+	if len(ids) == 0 {
+		return []Series{}, nil
+	}
+
+	// Collect the samples or rollups of every group per step
+	columns := "value"
+	if res != ResolutionRaw {
+		columns = "count, sum, min, max, p95"
+	}
+	query := fmt.Sprintf("SELECT series_id, ts, %s FROM %s WHERE series_id IN (%s) AND ts >= ? AND ts < ?;",
+		columns, table, strings.TrimSuffix(strings.Repeat("?,", len(ids)), ","))
+	args := append(ids, q.Start.UnixMilli(), q.End.UnixMilli())
+	rows, err = ts.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s samples: %w", res, err)
+	}
+	defer rows.Close()
+
+	buckets := make(map[string]map[int64][]aggregate)
+	for rows.Next() {
+		var id, at int64
+		var a aggregate
+		if res == ResolutionRaw {
+			var value float64
+			err = rows.Scan(&id, &at, &value)
+			a = single(value)
+		} else {
+			err = rows.Scan(&id, &at, &a.count, &a.sum, &a.min, &a.max, &a.p95)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to query %s samples: %w", res, err)
+		}
+		key := groups[id]
+		if buckets[key] == nil {
+			buckets[key] = make(map[int64][]aggregate)
+		}
+		step := floorMillis(at, q.Step)
+		buckets[key][step] = append(buckets[key][step], a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query %s samples: %w", res, err)
+	}
+
+	result := make([]Series, 0, len(buckets))
+	for key, steps := range buckets {
+		s := Series{Metric: q.Metric, Labels: groupLabels[key], Points: make([]Point, 0, len(steps))}
+		for at, parts := range steps {
+			s.Points = append(s.Points, Point{Time: time.UnixMilli(at), Value: combine(parts).value(q.Aggregation)})
+		}
+		sort.Slice(s.Points, func(i, j int) bool { return s.Points[i].Time.Before(s.Points[j].Time) })
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, _ := encodeLabels(result[i].Labels)
+		b, _ := encodeLabels(result[j].Labels)
+		return a < b
+	})
+	return result, nil
+}
+
+// resolutionFor picks the coarsest resolution no wider than the step whose
+// retention still covers start, falling back to coarser ones for old ranges
+func (ts *TimeSeriesStore) resolutionFor(start time.Time, step time.Duration) Resolution {
+	retentions := ts.retentions()
+	age := time.Since(start)
+	candidates := []struct {
+		res   Resolution
+		width time.Duration
+	}{{ResolutionRaw, 0}, {ResolutionMinute, time.Minute}, {ResolutionHour, time.Hour}}
+
+	best := 0
+	for i, c := range candidates {
+		if c.width <= step {
+			best = i
+		}
+	}
+	for i := best; i < len(candidates); i++ {
+		if age <= retentions[candidates[i].res] {
+			return candidates[i].res
+		}
+	}
+	return ResolutionHour
+}
+
+// Close writes the buffered samples and stops the background work
+func (ts *TimeSeriesStore) Close() error {
+	close(ts.done)
+	ts.wg.Wait()
+	err := ts.Flush()
+	if ts.ownsDB {
+		if closeErr := ts.db.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// aggregate summarizes the samples of a bucket
+type aggregate struct {
+	count int64
+	sum   float64
+	min   float64
+	max   float64
+	p95   float64
+}
+
+func single(value float64) aggregate {
+	return aggregate{count: 1, sum: value, min: value, max: value, p95: value}
+}
+
+// combine merges the aggregates of smaller buckets. The p95 is the 95th
+// percentile of the parts' p95, weighted by their sample counts, which is exact
+// when every part is a single sample.
+func combine(parts []aggregate) aggregate {
+	result := aggregate{min: math.Inf(1), max: math.Inf(-1)}
+	for _, p := range parts {
+		result.count += p.count
+		result.sum += p.sum
+		result.min = math.Min(result.min, p.min)
+		result.max = math.Max(result.max, p.max)
+	}
+	sorted := make([]aggregate, len(parts))
+	copy(sorted, parts)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].p95 < sorted[j].p95 })
+	rank := int64(math.Ceil(0.95 * float64(result.count)))
+	var seen int64
+	for _, p := range sorted {
+		seen += p.count
+		if seen >= rank {
+			result.p95 = p.p95
+			break
+		}
+	}
+	return result
+}
+
+func (a aggregate) value(aggregation string) float64 {
+	switch aggregation {
+	case AggregateMax:
+		return a.max
+	case AggregateP95:
+		return a.p95
+	default:
+		if a.count == 0 {
+			return 0
+		}
+		return a.sum / float64(a.count)
+	}
+}
+
+// encodeLabels encodes labels canonically; encoding/json sorts map keys
+func encodeLabels(labels map[string]string) (string, error) {
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	data, err := json.Marshal(labels)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode labels: %w", err)
+	}
+	return string(data), nil
+}
+
+// matchLabels reports whether labels contains every label of want
+func matchLabels(labels, want map[string]string) bool {
+	for name, value := range want {
+		if labels[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// floorMillis truncates a Unix millisecond timestamp to a multiple of d
+func floorMillis(at int64, d time.Duration) int64 {
+	width := d.Milliseconds()
+	if width <= 0 {
+		return at
+	}
+	if at < 0 {
+		return (at - width + 1) / width * width
+	}
+	return at / width * width
+}
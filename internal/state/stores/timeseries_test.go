@@ -0,0 +1,194 @@
+package stores
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestTimeSeries(t *testing.T, opts TimeSeriesOptions) *TimeSeriesStore {
+	t.Helper()
+	ts, err := NewTimeSeriesStore(filepath.Join(t.TempDir(), "metrics.db"), opts)
+	if err != nil {
+		t.Fatalf("NewTimeSeriesStore failed: %v", err)
+	}
+	t.Cleanup(func() { ts.Close() })
+	return ts
+}
+
+func TestTimeSeriesRollups(t *testing.T) {
+	ts := newTestTimeSeries(t, TimeSeriesOptions{BatchSize: 10000, FlushInterval: time.Hour})
+	start := time.Now().Add(-3 * time.Hour).Truncate(time.Hour)
+
+	// Two containers of web sampled every 10s for two hours: web-1 counts
+	// 0..59 every 10 minutes, web-2 stays at 1000
+	for i := 0; i < 720; i++ {
+		at := start.Add(time.Duration(i) * 10 * time.Second)
+		ts.Append(
+			Sample{Metric: "cpu", Labels: map[string]string{"service": "web", "container": "web-1"}, Time: at, Value: float64(i % 60)},
+			Sample{Metric: "cpu", Labels: map[string]string{"service": "web", "container": "web-2"}, Time: at, Value: 1000},
+			Sample{Metric: "memory", Labels: map[string]string{"service": "web", "container": "web-1"}, Time: at, Value: 1},
+		)
+	}
+	if err := ts.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		query      RangeQuery
+		wantSeries int
+		wantPoints int
+		wantValue  float64 // of the first point of the first series
+	}{
+		{
+			name:       "raw avg per label set",
+			query:      RangeQuery{Metric: "cpu", Labels: map[string]string{"container": "web-1"}, Start: start, Step: time.Hour, Resolution: ResolutionRaw},
+			wantSeries: 1, wantPoints: 2, wantValue: 29.5,
+		},
+		{
+			name:       "raw p95",
+			query:      RangeQuery{Metric: "cpu", Labels: map[string]string{"container": "web-1"}, Start: start, Step: time.Hour, Aggregation: AggregateP95, Resolution: ResolutionRaw},
+			wantSeries: 1, wantPoints: 2, wantValue: 56,
+		},
+		{
+			name:       "minute rollups match raw averages",
+			query:      RangeQuery{Metric: "cpu", Labels: map[string]string{"container": "web-1"}, Start: start, Step: time.Hour, Resolution: ResolutionMinute},
+			wantSeries: 1, wantPoints: 2, wantValue: 29.5,
+		},
+		{
+			name:       "hour rollups keep the max",
+			query:      RangeQuery{Metric: "cpu", Start: start, Step: time.Hour, Aggregation: AggregateMax, Resolution: ResolutionHour},
+			wantSeries: 2, wantPoints: 2, wantValue: 59,
+		},
+		{
+			name:       "grouped by service",
+			query:      RangeQuery{Metric: "cpu", GroupBy: []string{"service"}, Start: start, Step: time.Hour, Aggregation: AggregateMax},
+			wantSeries: 1, wantPoints: 2, wantValue: 1000,
+		},
+		{
+			name:       "minute steps",
+			query:      RangeQuery{Metric: "memory", Start: start, End: start.Add(10 * time.Minute), Step: time.Minute},
+			wantSeries: 1, wantPoints: 10, wantValue: 1,
+		},
+		{
+			name:       "no match",
+			query:      RangeQuery{Metric: "cpu", Labels: map[string]string{"service": "api"}, Start: start},
+			wantSeries: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			series, err := ts.Query(tt.query)
+			if err != nil {
+				t.Fatalf("Query failed: %v", err)
+			}
+			if len(series) != tt.wantSeries {
+				t.Fatalf("Expected %d series, got %d: %+v", tt.wantSeries, len(series), series)
+			}
+			if tt.wantSeries == 0 {
+				return
+			}
+			if len(series[0].Points) != tt.wantPoints {
+				t.Fatalf("Expected %d points, got %d", tt.wantPoints, len(series[0].Points))
+			}
+			if got := series[0].Points[0].Value; math.Abs(got-tt.wantValue) > 0.001 {
+				t.Errorf("Expected %v, got %v", tt.wantValue, got)
+			}
+			if !series[0].Points[0].Time.Equal(start) {
+				t.Errorf("Expected the first point at %v, got %v", start, series[0].Points[0].Time)
+			}
+		})
+	}
+
+	if _, err := ts.Query(RangeQuery{Metric: "cpu", Aggregation: "median"}); err == nil {
+		t.Error("Expected an error for an unknown aggregation")
+	}
+}
+
+func TestTimeSeriesLateSamples(t *testing.T) {
+	ts := newTestTimeSeries(t, TimeSeriesOptions{BatchSize: 2, FlushInterval: time.Hour})
+	minute := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	labels := map[string]string{"node": "n1"}
+
+	ts.Append(Sample{Metric: "load", Labels: labels, Time: minute, Value: 1})
+	if err := ts.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	// A sample arriving in a later batch updates the rollup of its minute
+	ts.Append(Sample{Metric: "load", Labels: labels, Time: minute.Add(30 * time.Second), Value: 3})
+	if err := ts.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	series, err := ts.Query(RangeQuery{Metric: "load", Start: minute, Step: time.Minute, Resolution: ResolutionMinute})
+	if err != nil || len(series) != 1 || series[0].Points[0].Value != 2 {
+		t.Fatalf("Expected the minute to average both samples, got %+v (%v)", series, err)
+	}
+
+	// A full batch is written without an explicit flush
+	ts.Append(
+		Sample{Metric: "load", Labels: labels, Time: minute.Add(time.Minute), Value: 5},
+		Sample{Metric: "load", Labels: labels, Time: minute.Add(time.Minute + time.Second), Value: 5},
+	)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		series, _ = ts.Query(RangeQuery{Metric: "load", Start: minute, Step: time.Minute, Resolution: ResolutionRaw})
+		if len(series) == 1 && len(series[0].Points) == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for the batch to be written, got %+v", series)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestTimeSeriesRetention(t *testing.T) {
+	ts := newTestTimeSeries(t, TimeSeriesOptions{
+		FlushInterval: time.Hour,
+		Retention:     Retention{Raw: time.Hour, Minute: 48 * time.Hour, Hour: 30 * 24 * time.Hour},
+	})
+	now := time.Now()
+	ts.Append(
+		Sample{Metric: "disk", Labels: map[string]string{"node": "old"}, Time: now.Add(-72 * time.Hour), Value: 1},
+		Sample{Metric: "disk", Labels: map[string]string{"node": "recent"}, Time: now.Add(-3 * time.Hour), Value: 2},
+		Sample{Metric: "disk", Labels: map[string]string{"node": "new"}, Time: now.Add(-time.Minute), Value: 3},
+	)
+	if err := ts.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if err := ts.Compact(); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+
+	count := func(res Resolution) int {
+		series, err := ts.Query(RangeQuery{Metric: "disk", Start: now.Add(-100 * 24 * time.Hour), Step: time.Hour, Resolution: res})
+		if err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		return len(series)
+	}
+	if got := count(ResolutionRaw); got != 1 {
+		t.Errorf("Expected only the newest raw sample to be kept, got %d series", got)
+	}
+	if got := count(ResolutionMinute); got != 2 {
+		t.Errorf("Expected 2 series at minute resolution, got %d", got)
+	}
+	if got := count(ResolutionHour); got != 3 {
+		t.Errorf("Expected all 3 series at hour resolution, got %d", got)
+	}
+
+	// Ranges past the raw retention are read from rollups
+	if res := ts.resolutionFor(now.Add(-3*time.Hour), 10*time.Second); res != ResolutionMinute {
+		t.Errorf("Expected the minute resolution for a range past the raw retention, got %s", res)
+	}
+	if res := ts.resolutionFor(now.Add(-10*time.Minute), 10*time.Second); res != ResolutionRaw {
+		t.Errorf("Expected raw samples for a recent range, got %s", res)
+	}
+	if res := ts.resolutionFor(now.Add(-10*time.Minute), 2*time.Hour); res != ResolutionHour {
+		t.Errorf("Expected hourly rollups for wide steps, got %s", res)
+	}
+}
@@ -0,0 +1,26 @@
+package tracing
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// Middleware records a server span per request served by mux, continuing the
+// trace propagated by the caller. Spans are named after the route the request
+// matched, e.g. "POST /api/deploy".
+func Middleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "http",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			_, pattern := mux.Handler(r)
+			if pattern == "" {
+				pattern = "unmatched"
+			}
+			return r.Method + " " + pattern
+		}),
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			// Scrapes would drown everything else
+			return r.URL.Path != "/metrics"
+		}),
+	)
+}
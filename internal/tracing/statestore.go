@@ -0,0 +1,72 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jasonlovesdoggo/velo/internal/state"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentStateStore records a span per state store operation. The state
+// store doesn't take contexts, so its spans start traces of their own. Keys are
+// only recorded by kind.
+func InstrumentStateStore(store state.StateStore) state.StateStore {
+	return &tracedStateStore{store: store}
+}
+
+type tracedStateStore struct {
+	store state.StateStore
+}
+
+func (s *tracedStateStore) Get(key string, value interface{}) error {
+	span := startStateSpan("get", keyAttribute(key))
+	return endStateSpan(span, s.store.Get(key, value))
+}
+
+func (s *tracedStateStore) Set(key string, value interface{}) error {
+	span := startStateSpan("set", keyAttribute(key))
+	return endStateSpan(span, s.store.Set(key, value))
+}
+
+func (s *tracedStateStore) Delete(key string) error {
+	span := startStateSpan("delete", keyAttribute(key))
+	return endStateSpan(span, s.store.Delete(key))
+}
+
+func (s *tracedStateStore) List(prefix string) ([]string, error) {
+	span := startStateSpan("list", keyAttribute(prefix))
+	keys, err := s.store.List(prefix)
+	span.SetAttributes(attribute.Int("velo.state.keys", len(keys)))
+	return keys, endStateSpan(span, err)
+}
+
+func (s *tracedStateStore) Close() error {
+	return s.store.Close()
+}
+
+// keyAttribute records the kind of a key, e.g. "token" for "token:abc": keys
+// can embed secrets such as session tokens
+func keyAttribute(key string) attribute.KeyValue {
+	kind, _, _ := strings.Cut(key, ":")
+	return attribute.String("velo.state.key_kind", kind)
+}
+
+func startStateSpan(operation string, attrs ...attribute.KeyValue) trace.Span {
+	_, span := Tracer().Start(context.Background(), "state."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(append(attrs, attribute.String("db.operation.name", operation))...),
+	)
+	return span
+}
+
+func endStateSpan(span trace.Span, err error) error {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+	return err
+}
@@ -0,0 +1,101 @@
+// Package tracing records OpenTelemetry spans for the web handlers, the gRPC
+// API, Docker calls and the state store, and exports them over OTLP.
+//
+// Docker calls are traced by the Docker client itself once a tracer provider is
+// installed; they join a request's trace when the request's context reaches them.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jasonlovesdoggo/velo/pkg/core"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName names the tracer of Velo's own spans
+const instrumentationName = "github.com/jasonlovesdoggo/velo"
+
+// Config selects where traces are exported
+type Config struct {
+	// Endpoint is the OTLP/HTTP collector, as host:port or a URL such as
+	// http://collector:4318. Empty falls back to the standard OTEL_EXPORTER_OTLP_*
+	// environment variables, and disables exporting if they aren't set either.
+	Endpoint string
+	// Insecure sends traces over plain HTTP to a host:port endpoint
+	Insecure bool
+	// SampleRatio is the fraction of new traces recorded, from 0 to 1. Traces
+	// started by a caller follow the caller's decision.
+	SampleRatio float64
+	// ServiceName is reported as the service.name of every span
+	ServiceName string
+}
+
+// Tracer returns the tracer of Velo's own spans
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Setup installs a tracer provider exporting to the configured endpoint and the
+// W3C trace context propagator. The returned function flushes pending spans and
+// must be called before exiting.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.ServiceVersion(core.Version),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe the service: %w", err)
+	}
+
+	ratio := cfg.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	}
+
+	if Enabled(cfg) {
+		exporter, err := otlptracehttp.New(ctx, exporterOptions(cfg)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the OTLP exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+
+	// Without an exporter spans are still created, so trace IDs reach the servers
+	// a client talks to
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Enabled reports whether traces are exported, by the config or the environment
+func Enabled(cfg Config) bool {
+	return cfg.Endpoint != "" || os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+func exporterOptions(cfg Config) []otlptracehttp.Option {
+	switch {
+	case cfg.Endpoint == "":
+		return nil
+	case strings.Contains(cfg.Endpoint, "://"):
+		return []otlptracehttp.Option{otlptracehttp.WithEndpointURL(cfg.Endpoint)}
+	case cfg.Insecure:
+		return []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint), otlptracehttp.WithInsecure()}
+	default:
+		return []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+	}
+}
@@ -0,0 +1,141 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jasonlovesdoggo/velo/internal/state"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// record installs a tracer provider that keeps spans in memory
+func record(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+	return recorder
+}
+
+func spanNamed(recorder *tracetest.SpanRecorder, name string) sdktrace.ReadOnlySpan {
+	for _, span := range recorder.Ended() {
+		if span.Name() == name {
+			return span
+		}
+	}
+	return nil
+}
+
+func TestGRPCPropagation(t *testing.T) {
+	recorder := record(t)
+	method := "/velo.DeploymentService/Deploy"
+
+	// The client interceptor sends its span's context in the request metadata...
+	var sent metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		sent, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+	if err := UnaryClientInterceptor(context.Background(), method, nil, nil, nil, invoker); err != nil {
+		t.Fatalf("UnaryClientInterceptor failed: %v", err)
+	}
+	if len(sent.Get("traceparent")) != 1 {
+		t.Fatalf("Expected a traceparent header, got %v", sent)
+	}
+
+	// ...which the server interceptor continues
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(grpccodes.InvalidArgument, "bad image")
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), sent)
+	UnaryServerInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+
+	client := spanNamed(recorder, "velo.DeploymentService/Deploy")
+	var server sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.SpanKind() == trace.SpanKindServer {
+			server = span
+		}
+	}
+	if client == nil || server == nil {
+		t.Fatalf("Expected a client and a server span, got %v", recorder.Ended())
+	}
+	if server.Parent().SpanID() != client.SpanContext().SpanID() || server.SpanContext().TraceID() != client.SpanContext().TraceID() {
+		t.Error("Expected the server span to continue the client's trace")
+	}
+	if server.Status().Code != codes.Error || server.Status().Description != "bad image" {
+		t.Errorf("Expected the server span to record the error, got %+v", server.Status())
+	}
+}
+
+func TestContextFromEnv(t *testing.T) {
+	recorder := record(t)
+	t.Setenv("TRACEPARENT", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return errors.New("unavailable")
+	}
+	UnaryClientInterceptor(context.Background(), "/velo.ClusterService/ListNodes", nil, nil, nil, invoker)
+
+	span := spanNamed(recorder, "velo.ClusterService/ListNodes")
+	if span == nil {
+		t.Fatal("Expected a client span")
+	}
+	if span.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || span.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("Expected the span to continue TRACEPARENT, got trace %s parent %s", span.SpanContext().TraceID(), span.Parent().SpanID())
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	recorder := record(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/services", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {})
+	handler := Middleware(mux, mux)
+
+	for _, path := range []string{"/api/services?cluster=prod", "/metrics"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "GET /api/services" {
+		t.Errorf("Expected one span named after the route, got %v", spans)
+	}
+}
+
+func TestInstrumentStateStore(t *testing.T) {
+	recorder := record(t)
+	store := InstrumentStateStore(state.NewMemoryStateStore())
+
+	store.Set("token:secret-session", "alice")
+	var value string
+	if err := store.Get("token:missing", &value); err == nil {
+		t.Fatal("Expected an error for a missing key")
+	}
+
+	get := spanNamed(recorder, "state.get")
+	if get == nil || get.Status().Code != codes.Error {
+		t.Fatalf("Expected a failed get span, got %v", get)
+	}
+	for _, span := range recorder.Ended() {
+		for _, attr := range span.Attributes() {
+			if attr.Value.AsString() == "token:secret-session" {
+				t.Errorf("Expected keys to be recorded by kind only, got %s=%s", attr.Key, attr.Value.AsString())
+			}
+		}
+	}
+}
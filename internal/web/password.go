@@ -0,0 +1,167 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+
+	"github.com/jasonlovesdoggo/velo/internal/audit"
+	"github.com/jasonlovesdoggo/velo/internal/auth"
+)
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+// Change password page handler. Users who must change their password are sent
+// here; it's the only page they can open until they do.
+func (ws *WebServer) handleChangePassword(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie("velo_session")
+	if err != nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+	user, err := ws.authService.ValidateToken(cookie.Value)
+	if err != nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	notice := ""
+	if user.MustChangePassword {
+		notice = `<p class="notice">You must change your password before using Velo.</p>`
+	}
+
+	tmpl := `<!DOCTYPE html>
+<html>
+<head>
+    <title>Change Password - Velo</title>
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', system-ui, sans-serif; margin: 0; padding: 0; background: linear-gradient(135deg, #667eea 0%%, #764ba2 100%%); min-height: 100vh; display: flex; align-items: center; justify-content: center; }
+        .login-container { background: white; padding: 40px; border-radius: 12px; box-shadow: 0 10px 25px rgba(0,0,0,0.2); width: 100%%; max-width: 400px; }
+        h1 { color: #333; font-size: 1.8em; margin: 0 0 20px; font-weight: 300; text-align: center; }
+        .form-group { margin-bottom: 20px; }
+        label { display: block; margin-bottom: 8px; font-weight: 600; color: #333; }
+        input[type="password"] { width: 100%%; padding: 12px; border: 2px solid #e1e5e9; border-radius: 6px; font-size: 16px; }
+        input[type="password"]:focus { outline: none; border-color: #667eea; }
+        button { width: 100%%; padding: 12px; background: #667eea; color: white; border: none; border-radius: 6px; font-size: 16px; cursor: pointer; }
+        button:hover { background: #5a67d8; }
+        .error { color: #e53e3e; margin-top: 10px; display: none; }
+        .notice { color: #975a16; background: #fefcbf; padding: 10px; border-radius: 6px; }
+    </style>
+</head>
+<body>
+    <div class="login-container">
+        <h1>Change password for %s</h1>
+        %s
+        <form id="passwordForm">
+            <div class="form-group">
+                <label for="currentPassword">Current password:</label>
+                <input type="password" id="currentPassword" required>
+            </div>
+            <div class="form-group">
+                <label for="newPassword">New password:</label>
+                <input type="password" id="newPassword" required>
+            </div>
+            <div class="form-group">
+                <label for="confirmPassword">Confirm new password:</label>
+                <input type="password" id="confirmPassword" required>
+            </div>
+            <button type="submit">Change Password</button>
+            <div id="error" class="error"></div>
+        </form>
+    </div>
+
+    <script>
+        document.getElementById('passwordForm').addEventListener('submit', async function(e) {
+            e.preventDefault();
+
+            const currentPassword = document.getElementById('currentPassword').value;
+            const newPassword = document.getElementById('newPassword').value;
+            const errorDiv = document.getElementById('error');
+            if (newPassword !== document.getElementById('confirmPassword').value) {
+                errorDiv.textContent = 'Passwords do not match';
+                errorDiv.style.display = 'block';
+                return;
+            }
+
+            try {
+                const response = await fetch('/api/auth/password', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ currentPassword, newPassword })
+                });
+                if (response.ok) {
+                    window.location.href = '/deployments';
+                } else {
+                    const result = await response.json();
+                    errorDiv.textContent = result.error || 'Failed to change password';
+                    errorDiv.style.display = 'block';
+                }
+            } catch (error) {
+                errorDiv.textContent = 'Failed to change password: ' + error.message;
+                errorDiv.style.display = 'block';
+            }
+        });
+    </script>
+</body>
+</html>`
+
+	w.Header().Set("Content-Type", "text/html")
+	fmt.Fprintf(w, tmpl, html.EscapeString(user.Username), notice)
+}
+
+// API change password handler. It authenticates on its own, since users who
+// must change their password are turned away by authRequiredAPI.
+func (ws *WebServer) handleAPIChangePassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+		return
+	}
+
+	session := requestToken(r)
+	user, err := ws.authService.ValidateToken(session)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	audit.FromContext(r.Context()).Actor = user.Username
+	if user.Scopes != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "API tokens can't change passwords, log in instead"})
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("Invalid JSON: %v", err)})
+		return
+	}
+
+	if err := ws.authService.ChangeOwnPassword(user.ID, req.CurrentPassword, req.NewPassword, session); err != nil {
+		code := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, auth.ErrTooManyAttempts):
+			code = http.StatusTooManyRequests
+		case errors.Is(err, auth.ErrInvalidCredentials):
+			code = http.StatusForbidden
+		case errors.Is(err, auth.ErrWeakPassword):
+			code = http.StatusBadRequest
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "changed"})
+}
@@ -0,0 +1,88 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/audit"
+	"github.com/jasonlovesdoggo/velo/internal/log"
+	"golang.org/x/time/rate"
+)
+
+// DefaultLoginRate is how many logins a minute an IP address may attempt
+// unless set otherwise
+const DefaultLoginRate = 10
+
+// rateLimiter limits the requests of every client IP address to perMinute a
+// minute, in bursts of up to perMinute
+type rateLimiter struct {
+	mu        sync.Mutex
+	perMinute int
+	clients   map[string]*rateClient
+}
+
+type rateClient struct {
+	limiter *rate.Limiter
+	seen    time.Time
+}
+
+func newRateLimiter(perMinute int) *rateLimiter {
+	return &rateLimiter{perMinute: perMinute, clients: map[string]*rateClient{}}
+}
+
+// allow reports whether a client may make a request now and, if not, about
+// how long until it may. Without a rate, everyone may.
+func (l *rateLimiter) allow(ip string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.perMinute <= 0 {
+		return true, 0
+	}
+
+	now := time.Now()
+	// A client idle for a minute has a full bucket again, like a new one
+	for key, c := range l.clients {
+		if now.Sub(c.seen) > time.Minute {
+			delete(l.clients, key)
+		}
+	}
+
+	c, ok := l.clients[ip]
+	if !ok {
+		c = &rateClient{limiter: rate.NewLimiter(rate.Limit(float64(l.perMinute)/60), l.perMinute)}
+		l.clients[ip] = c
+	}
+	c.seen = now
+	if c.limiter.AllowN(now, 1) {
+		return true, 0
+	}
+	return false, time.Minute / time.Duration(l.perMinute)
+}
+
+// SetLoginRate sets how many logins a minute an IP address may attempt; 0
+// removes the limit
+func (ws *WebServer) SetLoginRate(perMinute int) {
+	ws.loginLimiter.mu.Lock()
+	defer ws.loginLimiter.mu.Unlock()
+	ws.loginLimiter.perMinute = perMinute
+	clear(ws.loginLimiter.clients)
+}
+
+// rateLimited turns away the requests of clients over a limiter's rate
+func rateLimited(l *rateLimiter, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ip := audit.ClientIP(r)
+		if ok, wait := l.allow(ip); !ok {
+			log.Warn("Request rate limited", "path", r.URL.Path, "ip", ip)
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			w.WriteHeader(http.StatusTooManyRequests)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Too many requests, try again later"})
+			return
+		}
+		handler(w, r)
+	}
+}
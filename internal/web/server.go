@@ -1,15 +1,27 @@
 package web
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/jasonlovesdoggo/velo/internal/admission"
+	"github.com/jasonlovesdoggo/velo/internal/audit"
 	"github.com/jasonlovesdoggo/velo/internal/auth"
 	"github.com/jasonlovesdoggo/velo/internal/config"
 	"github.com/jasonlovesdoggo/velo/internal/log"
+	"github.com/jasonlovesdoggo/velo/internal/metrics"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/cluster"
 	"github.com/jasonlovesdoggo/velo/internal/orchestrator/manager"
+	"github.com/jasonlovesdoggo/velo/internal/policy"
+	"github.com/jasonlovesdoggo/velo/internal/tracing"
+	"github.com/jasonlovesdoggo/velo/internal/webhooks"
 )
 
 // Request/Response structs
@@ -19,38 +31,97 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	Status string `json:"status"`
-	Token  string `json:"token"`
+	Status             string `json:"status"`
+	Token              string `json:"token"`
+	MustChangePassword bool   `json:"mustChangePassword,omitempty"`
 }
 
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error      string `json:"error"`
+	HookOutput string `json:"hookOutput,omitempty"` // output of the deploy hooks of a failed deploy
 }
 
 type DeployRequest struct {
-	ServiceName string            `json:"serviceName"`
-	Image       string            `json:"image"`
-	Replicas    int               `json:"replicas"`
-	Environment map[string]string `json:"environment"`
+	ServiceName        string            `json:"serviceName"`
+	Image              string            `json:"image"`
+	Replicas           int               `json:"replicas"`
+	Environment        map[string]string `json:"environment"`
+	Labels             map[string]string `json:"labels"`
+	Constraints        []string          `json:"constraints"`
+	SpreadOver         []string          `json:"spreadOver"`
+	MaxReplicasPerNode uint64            `json:"maxReplicasPerNode"`
+	CPUReserve         float64           `json:"cpuReserve"`
+	MemoryReserve      int64             `json:"memoryReserve"`
+	CPULimit           float64           `json:"cpuLimit"`
+	MemoryLimit        int64             `json:"memoryLimit"`
+	HealthPolicy       string            `json:"healthPolicy"` // restart, report or ignore
+	Healthcheck        *HealthCheck      `json:"healthcheck"`
+	Volumes            []VolumeMount     `json:"volumes"`
+	Cluster            string            `json:"cluster"`
+	PreDeploy          []string          `json:"preDeploy"`
+	PostDeploy         []string          `json:"postDeploy"`
+	HookTimeout        int               `json:"hookTimeout"` // seconds
+	RollbackOnHookFail bool              `json:"rollbackOnHookFailure"`
+}
+
+// HealthCheck is the container healthcheck of a deploy. Durations are in seconds.
+type HealthCheck struct {
+	Command     []string `json:"command"`
+	Interval    int      `json:"interval"`
+	Timeout     int      `json:"timeout"`
+	Retries     int      `json:"retries"`
+	StartPeriod int      `json:"startPeriod"`
+}
+
+type VolumeMount struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	ReadOnly    bool   `json:"readOnly"`
 }
 
 type DeployResponse struct {
-	DeploymentID string `json:"deploymentId"`
-	Status       string `json:"status"`
+	DeploymentID string   `json:"deploymentId"`
+	Status       string   `json:"status"`
+	Warnings     []string `json:"warnings,omitempty"`
+	HookOutput   string   `json:"hookOutput,omitempty"`
+}
+
+type DeploymentSummary struct {
+	ID      string         `json:"id"`
+	Service ServiceSummary `json:"service"`
+	State   string         `json:"state"`
+}
+
+type ServiceSummary struct {
+	Name     string `json:"name"`
+	Image    string `json:"image"`
+	Replicas int    `json:"replicas"`
 }
 
+// clusterCookie remembers the cluster selected with the cluster switcher
+const clusterCookie = "velo_cluster"
+
 // WebServer provides a web interface for Velo
 type WebServer struct {
-	manager     manager.Manager
+	clusters    *cluster.Registry
 	authService *auth.AuthService
+	mux         *http.ServeMux
 	server      *http.Server
+	webhooks    *webhooks.Dispatcher
+	admission   *admission.Controller
+	policy      *policy.Policy
+	audit       *audit.Log
+
+	// loginLimiter limits the login attempts of every IP address
+	loginLimiter *rateLimiter
 }
 
-// NewWebServer creates a new web server
-func NewWebServer(mgr manager.Manager, authService *auth.AuthService, port string) *WebServer {
+// NewWebServer creates a new web server for the registered clusters
+func NewWebServer(clusters *cluster.Registry, authService *auth.AuthService, port string) *WebServer {
 	ws := &WebServer{
-		manager:     mgr,
-		authService: authService,
+		clusters:     clusters,
+		authService:  authService,
+		loginLimiter: newRateLimiter(DefaultLoginRate),
 	}
 
 	mux := http.NewServeMux()
@@ -61,25 +132,49 @@ func NewWebServer(mgr manager.Manager, authService *auth.AuthService, port strin
 	// Pages
 	mux.HandleFunc("/", ws.handleHome)
 	mux.HandleFunc("/login", ws.handleLogin)
-	mux.HandleFunc("/deployments", ws.authRequired(ws.handleDeployments))
-	mux.HandleFunc("/deploy", ws.authRequired(ws.handleDeploy))
-	mux.HandleFunc("/services", ws.authRequired(ws.handleServices))
+	mux.HandleFunc("/deployments", ws.authRequired(auth.PermServicesRead, ws.handleDeployments))
+	mux.HandleFunc("/deploy", ws.authRequired(auth.PermServicesDeploy, ws.handleDeploy))
+	mux.HandleFunc("/services", ws.authRequired(auth.PermServicesRead, ws.handleServices))
+	mux.HandleFunc("/audit", ws.authRequired(auth.PermAuditRead, ws.handleAudit))
+	mux.HandleFunc("/tokens", ws.authRequired(auth.PermServicesRead, ws.handleTokens))
+	mux.HandleFunc("/change-password", ws.handleChangePassword)
 
 	// API endpoints
-	mux.HandleFunc("/api/auth/login", ws.handleAPILogin)
-	mux.HandleFunc("/api/auth/logout", ws.handleAPILogout)
-	mux.HandleFunc("/api/deployments", ws.authRequiredAPI(ws.handleAPIDeployments))
-	mux.HandleFunc("/api/deploy", ws.authRequiredAPI(ws.handleAPIDeploy))
-	mux.HandleFunc("/api/services", ws.authRequiredAPI(ws.handleAPIServices))
+	mux.HandleFunc("/api/auth/login", rateLimited(ws.loginLimiter, ws.audited("Login", ws.handleAPILogin)))
+	mux.HandleFunc("/api/auth/logout", ws.audited("Logout", ws.handleAPILogout))
+	mux.HandleFunc("/api/auth/password", ws.audited("ChangePassword", ws.handleAPIChangePassword))
+	mux.HandleFunc("/api/deployments", ws.authRequiredAPI(auth.PermServicesRead, ws.handleAPIDeployments))
+	mux.HandleFunc("/api/deploy", ws.authRequiredAPI(auth.PermServicesDeploy, ws.audited("Deploy", ws.handleAPIDeploy)))
+	mux.HandleFunc("/api/services", ws.authRequiredAPI(auth.PermServicesRead, ws.handleAPIServices))
+	mux.HandleFunc("/api/clusters", ws.authRequiredAPI(auth.PermClusterRead, ws.handleAPIClusters))
+	mux.HandleFunc("/api/nodes", ws.authRequiredAPI(auth.PermClusterRead, ws.handleAPINodes))
+	mux.HandleFunc("/api/metrics", ws.authRequiredAPI(auth.PermClusterRead, ws.handleAPIMetrics))
+	mux.HandleFunc("/api/audit", ws.authRequiredAPI(auth.PermAuditRead, ws.handleAPIAudit))
+	mux.HandleFunc("/api/tokens", ws.authRequiredAPI(auth.PermServicesRead, ws.handleAPITokens))
+	mux.HandleFunc("/api/tokens/create", ws.authRequiredAPI(auth.PermServicesRead, ws.audited("CreateAPIToken", ws.handleAPICreateToken)))
+	mux.HandleFunc("/api/tokens/revoke", ws.authRequiredAPI(auth.PermServicesRead, ws.audited("RevokeAPIToken", ws.handleAPIRevokeToken)))
 
+	ws.mux = mux
 	ws.server = &http.Server{
 		Addr:    ":" + port,
-		Handler: mux,
+		Handler: tracing.Middleware(mux, metrics.InstrumentMux(mux)),
 	}
 
 	return ws
 }
 
+// ServeMetrics serves Prometheus metrics on /metrics. With a token, scrapers
+// must send it as a bearer token; session logins don't apply.
+func (ws *WebServer) ServeMetrics(token string) {
+	ws.mux.Handle("/metrics", metrics.Handler(token))
+}
+
+// SetWebhooks publishes the events of deploys made through the web UI to a
+// webhook dispatcher
+func (ws *WebServer) SetWebhooks(d *webhooks.Dispatcher) {
+	ws.webhooks = d
+}
+
 // Start starts the web server
 func (ws *WebServer) Start() error {
 	log.Info("Starting web server", "address", ws.server.Addr)
@@ -123,6 +218,8 @@ func (ws *WebServer) handleHome(w http.ResponseWriter, r *http.Request) {
             <a href="/deployments">View Deployments</a>
             <a href="/deploy">Deploy Service</a>
             <a href="/services">Manage Services</a>
+            <a href="/audit">Audit Log</a>
+            <a href="/tokens">API Tokens</a>` + clusterSwitcher + `
         </div>
         <h2>Welcome to Velo</h2>
         <p>Velo is a lightweight, self-hostable deployment and operations platform built on Docker Swarm.</p>
@@ -162,7 +259,7 @@ func (ws *WebServer) handleDeployments(w http.ResponseWriter, r *http.Request) {
         <div class="nav">
             <a href="/">Home</a>
             <a href="/deploy">Deploy Service</a>
-            <a href="/services">Manage Services</a>
+            <a href="/services">Manage Services</a>` + clusterSwitcher + `
         </div>
         <div id="deployments">
             <p>Loading deployments...</p>
@@ -243,7 +340,7 @@ func (ws *WebServer) handleDeploy(w http.ResponseWriter, r *http.Request) {
         <div class="nav">
             <a href="/">Home</a>
             <a href="/deployments">View Deployments</a>
-            <a href="/services">Manage Services</a>
+            <a href="/services">Manage Services</a>` + clusterSwitcher + `
         </div>
         
         <form id="deployForm">
@@ -267,6 +364,31 @@ func (ws *WebServer) handleDeploy(w http.ResponseWriter, r *http.Request) {
                 <textarea id="environment" name="environment" rows="4" placeholder="NODE_ENV=production\nPORT=3000"></textarea>
             </div>
             
+            <div class="form-group">
+                <label for="constraints">Placement Constraints (one per line):</label>
+                <textarea id="constraints" name="constraints" rows="2" placeholder="node.labels.zone==eu"></textarea>
+            </div>
+            
+            <div class="form-group">
+                <label for="cpuLimit">CPU Limit (cores):</label>
+                <input type="number" id="cpuLimit" name="cpuLimit" min="0" step="0.1" placeholder="0.5">
+            </div>
+            
+            <div class="form-group">
+                <label for="memoryLimit">Memory Limit (MB):</label>
+                <input type="number" id="memoryLimit" name="memoryLimit" min="0" placeholder="512">
+            </div>
+            
+            <div class="form-group">
+                <label for="healthcheck">Healthcheck Command:</label>
+                <input type="text" id="healthcheck" name="healthcheck" placeholder="curl -f http://localhost/ || exit 1">
+            </div>
+            
+            <div class="form-group">
+                <label for="volumes">Volumes (one per line, SOURCE:DESTINATION[:ro]):</label>
+                <textarea id="volumes" name="volumes" rows="2" placeholder="data:/var/lib/app"></textarea>
+            </div>
+            
             <button type="submit">Deploy Service</button>
         </form>
         
@@ -281,6 +403,8 @@ func (ws *WebServer) handleDeploy(w http.ResponseWriter, r *http.Request) {
             const image = document.getElementById('image').value;
             const replicas = parseInt(document.getElementById('replicas').value);
             const envText = document.getElementById('environment').value;
+            const constraints = document.getElementById('constraints').value
+                .split('\n').map(line => line.trim()).filter(line => line);
             
             // Parse environment variables
             const environment = {};
@@ -293,11 +417,29 @@ func (ws *WebServer) handleDeploy(w http.ResponseWriter, r *http.Request) {
                 });
             }
             
+            const cpuLimit = parseFloat(document.getElementById('cpuLimit').value) || 0;
+            const memoryLimit = (parseInt(document.getElementById('memoryLimit').value) || 0) * 1024 * 1024;
+            const healthcheckCmd = document.getElementById('healthcheck').value.trim();
+            const healthcheck = healthcheckCmd ? { command: ['CMD-SHELL', healthcheckCmd] } : null;
+            
+            // Parse volumes
+            const volumes = document.getElementById('volumes').value
+                .split('\n').map(line => line.trim()).filter(line => line)
+                .map(line => {
+                    const [source, destination, mode] = line.split(':');
+                    return { source, destination, readOnly: mode === 'ro' };
+                });
+            
             const deployment = {
                 serviceName,
                 image,
                 replicas,
-                environment
+                environment,
+                constraints,
+                cpuLimit,
+                memoryLimit,
+                healthcheck,
+                volumes
             };
             
             try {
@@ -361,7 +503,7 @@ func (ws *WebServer) handleServices(w http.ResponseWriter, r *http.Request) {
         <div class="nav">
             <a href="/">Home</a>
             <a href="/deployments">View Deployments</a>
-            <a href="/deploy">Deploy Service</a>
+            <a href="/deploy">Deploy Service</a>` + clusterSwitcher + `
         </div>
         <p>Service management features will be available here.</p>
         <p>This will include service scaling, updates, and monitoring capabilities.</p>
@@ -373,10 +515,167 @@ func (ws *WebServer) handleServices(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprint(w, tmpl)
 }
 
+// handleAudit shows the audit log with filters and a JSON lines export
+func (ws *WebServer) handleAudit(w http.ResponseWriter, r *http.Request) {
+	tmpl := `
+<!DOCTYPE html>
+<html>
+<head>
+    <title>Audit Log - Velo</title>
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', system-ui, sans-serif; margin: 0; padding: 20px; background: #f5f5f5; }
+        .container { max-width: 1200px; margin: 0 auto; background: white; padding: 20px; border-radius: 8px; box-shadow: 0 2px 4px rgba(0,0,0,0.1); }
+        h1 { color: #333; border-bottom: 2px solid #007acc; padding-bottom: 10px; }
+        .nav { margin: 20px 0; }
+        .nav a { margin-right: 20px; padding: 8px 16px; background: #007acc; color: white; text-decoration: none; border-radius: 4px; }
+        .nav a:hover { background: #005a9e; }
+        .filters input, .filters select { padding: 6px; margin-right: 8px; border: 1px solid #ddd; border-radius: 4px; }
+        .filters button { padding: 6px 12px; background: #007acc; color: white; border: none; border-radius: 4px; cursor: pointer; }
+        table { width: 100%; border-collapse: collapse; margin-top: 20px; font-size: 14px; }
+        th, td { padding: 8px; text-align: left; border-bottom: 1px solid #ddd; vertical-align: top; }
+        th { background-color: #f8f9fa; font-weight: 600; }
+        .result-success { color: #4caf50; font-weight: bold; }
+        .result-failure { color: #f44336; font-weight: bold; }
+        .result-denied { color: #ff9800; font-weight: bold; }
+        .params { color: #666; font-family: monospace; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <h1>📜 Audit Log</h1>
+        <div class="nav">
+            <a href="/">Home</a>
+            <a href="/deployments">View Deployments</a>
+            <a href="/deploy">Deploy Service</a>` + clusterSwitcher + `
+        </div>
+        <form id="filters" class="filters">
+            <input name="actor" placeholder="Actor">
+            <input name="action" placeholder="Action">
+            <input name="target" placeholder="Target">
+            <select name="source">
+                <option value="">Any source</option>
+                <option>web</option>
+                <option>grpc</option>
+                <option>webhook</option>
+                <option>reconciler</option>
+            </select>
+            <select name="result">
+                <option value="">Any result</option>
+                <option>success</option>
+                <option>failure</option>
+                <option>denied</option>
+            </select>
+            <input name="since" placeholder="Since, e.g. 24h" size="12">
+            <button type="submit">Filter</button>
+            <a id="export" href="/api/audit?format=jsonl">Export JSON lines</a>
+        </form>
+        <div id="entries">
+            <p>Loading audit log...</p>
+        </div>
+    </div>
+
+    <script>
+        function escapeHTML(value) {
+            return String(value).replace(/[&<>"']/g, c => ({'&': '&amp;', '<': '&lt;', '>': '&gt;', '"': '&quot;', "'": '&#39;'}[c]));
+        }
+
+        async function loadEntries() {
+            const params = new URLSearchParams();
+            new FormData(document.getElementById('filters')).forEach((value, key) => { if (value) params.set(key, value); });
+            params.set('limit', '200');
+            document.getElementById('export').href = '/api/audit?format=jsonl&' + params.toString();
+            try {
+                const response = await fetch('/api/audit?' + params.toString());
+                const result = await response.json();
+                if (!response.ok) throw new Error(result.error);
+
+                let html = '<table><thead><tr><th>Time</th><th>Actor</th><th>Source</th><th>IP</th><th>Action</th><th>Target</th><th>Result</th><th>Parameters</th></tr></thead><tbody>';
+                if (result.length === 0) {
+                    html += '<tr><td colspan="8" style="text-align: center; padding: 40px; color: #666;">No entries found.</td></tr>';
+                }
+                result.forEach(entry => {
+                    const params = Object.entries(entry.params || {}).map(([k, v]) => escapeHTML(k + '=' + v)).join('<br>');
+                    const target = (entry.cluster ? entry.cluster + '/' : '') + (entry.target || '');
+                    html += ` + "`" + `<tr>
+                        <td>${new Date(entry.time).toLocaleString()}</td>
+                        <td>${escapeHTML(entry.actor || '-')}</td>
+                        <td>${escapeHTML(entry.source)}</td>
+                        <td>${escapeHTML(entry.ip || '')}</td>
+                        <td>${escapeHTML(entry.action)}</td>
+                        <td>${escapeHTML(target)}</td>
+                        <td class="result-${escapeHTML(entry.result)}" title="${escapeHTML(entry.error || '')}">${escapeHTML(entry.result)}</td>
+                        <td class="params">${params}</td>
+                    </tr>` + "`" + `;
+                });
+                html += '</tbody></table>';
+                document.getElementById('entries').innerHTML = html;
+            } catch (error) {
+                document.getElementById('entries').innerHTML = '<p style="color: red;">Error loading the audit log: ' + escapeHTML(error.message) + '</p>';
+            }
+        }
+
+        document.getElementById('filters').addEventListener('submit', e => { e.preventDefault(); loadEntries(); });
+        loadEntries();
+    </script>
+</body>
+</html>`
+
+	w.Header().Set("Content-Type", "text/html")
+	fmt.Fprint(w, tmpl)
+}
+
+// SetAdmission asks admission webhooks about the deploys made through the web UI
+func (ws *WebServer) SetAdmission(c *admission.Controller) {
+	ws.admission = c
+}
+
+// SetPolicy checks the deploys made through the web UI against a policy
+func (ws *WebServer) SetPolicy(p *policy.Policy) {
+	ws.policy = p
+}
+
+// SetAudit records deploys and logins made through the web UI in an audit log
+// and serves the log on /audit
+func (ws *WebServer) SetAudit(l *audit.Log) {
+	ws.audit = l
+}
+
+// audited records the requests a handler serves in the audit log. The log is
+// looked up on every request as it's set after the routes.
+func (ws *WebServer) audited(action string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ws.audit.Handler(action, handler)(w, r)
+	}
+}
+
 // API handlers
 func (ws *WebServer) handleAPIDeployments(w http.ResponseWriter, r *http.Request) {
-	// For now, return empty list - this will be implemented when the manager interface is extended
-	deployments := []config.DeploymentStatus{}
+	mgr, ok := ws.managerFor(w, r, "")
+	if !ok {
+		return
+	}
+
+	deployments := []DeploymentSummary{}
+	if sm, ok := mgr.(manager.ServiceManager); ok {
+		statuses, err := sm.ListServices()
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadGateway)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("Failed to list services: %v", err)})
+			return
+		}
+		for _, status := range statuses {
+			deployments = append(deployments, DeploymentSummary{
+				ID:    status.ID,
+				State: status.State,
+				Service: ServiceSummary{
+					Name:     status.Service.Name,
+					Image:    status.Service.Image,
+					Replicas: status.Service.Replicas,
+				},
+			})
+		}
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(deployments)
@@ -406,31 +705,149 @@ func (ws *WebServer) handleAPIDeploy(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !config.ValidHealthPolicy(req.HealthPolicy) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("Invalid health policy %q (expected restart, report or ignore)", req.HealthPolicy)})
+		return
+	}
+	for _, v := range req.Volumes {
+		if v.Destination == "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("Volume %q has no destination", v.Source)})
+			return
+		}
+	}
+
 	if req.Replicas <= 0 {
 		req.Replicas = 1
 	}
 
+	entry := audit.FromContext(r.Context())
+	entry.Target = req.ServiceName
+	entry.Params = map[string]string{"image": req.Image, "replicas": strconv.Itoa(req.Replicas)}
+	if len(req.Environment) > 0 {
+		env, _ := json.Marshal(req.Environment)
+		entry.Params["environment"] = string(env)
+	}
+
+	// Users with a role on projects or services may only deploy those
+	user, _ := auth.UserFromContext(r.Context())
+	project := config.ServiceDefinition{Name: req.ServiceName, Labels: req.Labels}.Project()
+	if err := auth.Authorize(user, auth.PermServicesDeploy, auth.Resource{Project: project, Service: req.ServiceName}); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c, ok := ws.clusterFor(w, r, req.Cluster)
+	if !ok {
+		return
+	}
+	mgr := c.Manager
+	entry.Cluster = c.Name
+
 	// Create ServiceDefinition from request
 	serviceDef := config.ServiceDefinition{
 		Name:        req.ServiceName,
 		Image:       req.Image,
 		Environment: req.Environment,
+		Labels:      req.Labels,
 		Replicas:    req.Replicas,
+		Constraints: req.Constraints,
+		Placement: config.PlacementConfig{
+			MaxReplicasPerNode: req.MaxReplicasPerNode,
+		},
+		Resources: config.ResourceConfig{
+			CPULimit:      req.CPULimit,
+			MemoryLimit:   req.MemoryLimit,
+			CPUReserve:    req.CPUReserve,
+			MemoryReserve: req.MemoryReserve,
+		},
+		HealthCheck: config.HealthCheckConfig{
+			OnFailure: req.HealthPolicy,
+		},
+		PreDeploy:  req.PreDeploy,
+		PostDeploy: req.PostDeploy,
+		Hooks: config.HookConfig{
+			Timeout:           req.HookTimeout,
+			RollbackOnFailure: req.RollbackOnHookFail,
+		},
+	}
+	for _, spread := range req.SpreadOver {
+		serviceDef.Placement.Preferences = append(serviceDef.Placement.Preferences, config.PlacementPreference{Spread: spread})
+	}
+	if hc := req.Healthcheck; hc != nil {
+		serviceDef.HealthCheck.Command = hc.Command
+		serviceDef.HealthCheck.Interval = hc.Interval
+		serviceDef.HealthCheck.Timeout = hc.Timeout
+		serviceDef.HealthCheck.Retries = hc.Retries
+		serviceDef.HealthCheck.StartPeriod = hc.StartPeriod
+	}
+	for _, v := range req.Volumes {
+		serviceDef.Volumes = append(serviceDef.Volumes, config.VolumeMount{Source: v.Source, Destination: v.Destination, ReadOnly: v.ReadOnly})
+	}
+
+	// Admission webhooks may deny the deploy or change the service
+	serviceDef, warnings, err := ws.admission.Admit(r.Context(), admission.Request{
+		Cluster: c.Name,
+		User:    auth.Username(r.Context()),
+		Service: serviceDef,
+	})
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+	policyWarnings, err := ws.policy.Check(serviceDef)
+	warnings = append(warnings, policyWarnings...)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
 	}
 
 	// Deploy the service using the manager
-	deploymentID, err := ws.manager.DeployService(serviceDef)
+	start := time.Now()
+	event := webhooks.Event{
+		Cluster: c.Name,
+		Service: serviceDef.Name,
+		Data:    map[string]string{"image": serviceDef.Image},
+	}
+	ws.webhooks.Publish(event.As(webhooks.EventDeployStarted))
+	var hookOutput strings.Builder
+	// The manager reports capacity shortfalls it lets through as warnings
+	deployCtx := manager.WithWarnings(context.WithoutCancel(r.Context()), &warnings)
+	deploymentID, err := manager.DeployWithHooks(deployCtx, mgr, serviceDef, &hookOutput)
+	metrics.ObserveDeploy(c.Name, start, err)
+	c.RecordDeploy(serviceDef.Name, err)
 	if err != nil {
+		ws.webhooks.Publish(event.Failed(err))
+		status := http.StatusInternalServerError
+		var hookErr *manager.HookError
+		if errors.Is(err, manager.ErrUnsatisfiablePlacement) || errors.Is(err, manager.ErrInsufficientCapacity) || errors.As(err, &hookErr) {
+			status = http.StatusUnprocessableEntity
+		} else if errors.Is(err, manager.ErrHooksUnsupported) {
+			status = http.StatusNotImplemented
+		}
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("Deployment failed: %v", err)})
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("Deployment failed: %v", err), HookOutput: hookOutput.String()})
 		return
 	}
 
+	ws.webhooks.Publish(event.As(webhooks.EventDeploySucceeded))
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(DeployResponse{
 		DeploymentID: deploymentID,
 		Status:       "deployed",
+		Warnings:     warnings,
+		HookOutput:   hookOutput.String(),
 	})
 }
 
@@ -449,6 +866,168 @@ func (ws *WebServer) handleAPIServices(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(services)
 }
 
+func (ws *WebServer) handleAPIClusters(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Selected string           `json:"selected"`
+		Clusters []cluster.Status `json:"clusters"`
+	}{
+		Selected: ws.selectedCluster(r, ""),
+		Clusters: ws.clusters.List(),
+	})
+}
+
+// handleAPINodes lists the nodes of the selected cluster with what their agents report
+func (ws *WebServer) handleAPINodes(w http.ResponseWriter, r *http.Request) {
+	c, ok := ws.clusterFor(w, r, "")
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c.Nodes())
+}
+
+// handleAPIMetrics returns container metrics for charts, grouped by service or
+// node. The range and resolution are durations: ?since=1h&step=1m.
+func (ws *WebServer) handleAPIMetrics(w http.ResponseWriter, r *http.Request) {
+	c, ok := ws.clusterFor(w, r, "")
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	since, step := 15*time.Minute, time.Minute
+	for name, value := range map[string]*time.Duration{"since": &since, "step": &step} {
+		raw := query.Get(name)
+		if raw == "" {
+			continue
+		}
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("Invalid %s: %q", name, raw)})
+			return
+		}
+		*value = d
+	}
+
+	series, err := c.QueryMetrics(cluster.MetricsQuery{
+		Service: query.Get("service"),
+		Node:    query.Get("node"),
+		GroupBy: query.Get("group_by"),
+		Since:   time.Now().Add(-since),
+		Step:    step,
+	})
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+	if series == nil {
+		series = []cluster.MetricsSeries{}
+	}
+	json.NewEncoder(w).Encode(series)
+}
+
+// handleAPIAudit lists audit log entries, newest first. Query parameters filter
+// them: actor, source, action, target, result, since and until (durations like
+// 24h, dates or RFC 3339 times) and limit. ?format=jsonl exports them as JSON lines.
+func (ws *WebServer) handleAPIAudit(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := audit.Filter{
+		Actor:  query.Get("actor"),
+		Source: query.Get("source"),
+		Action: query.Get("action"),
+		Target: query.Get("target"),
+		Result: query.Get("result"),
+	}
+	now := time.Now()
+	for name, value := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		raw := query.Get(name)
+		if raw == "" {
+			continue
+		}
+		t, err := audit.ParseTime(raw, now)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("Invalid %s: %v", name, err)})
+			return
+		}
+		*value = t
+	}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("Invalid limit: %q", raw)})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	entries, err := ws.audit.Query(filter)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+	if entries == nil {
+		entries = []audit.Entry{}
+	}
+
+	if query.Get("format") == "jsonl" {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", `attachment; filename="velo-audit.jsonl"`)
+		audit.WriteJSONL(w, entries)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// selectedCluster returns the cluster a request operates on: the one named in the
+// request itself, the ?cluster= query parameter or the switcher cookie, in that order.
+// An empty result selects the default cluster.
+func (ws *WebServer) selectedCluster(r *http.Request, requested string) string {
+	if requested != "" {
+		return requested
+	}
+	if name := r.URL.Query().Get("cluster"); name != "" {
+		return name
+	}
+	if cookie, err := r.Cookie(clusterCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// clusterFor returns the cluster a request operates on, writing an error response if it's unknown
+func (ws *WebServer) clusterFor(w http.ResponseWriter, r *http.Request, requested string) (*cluster.Cluster, bool) {
+	c, err := ws.clusters.Get(ws.selectedCluster(r, requested))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return nil, false
+	}
+	return c, true
+}
+
+// managerFor returns the manager of the cluster a request operates on, writing an error response if it's unknown
+func (ws *WebServer) managerFor(w http.ResponseWriter, r *http.Request, requested string) (manager.Manager, bool) {
+	c, ok := ws.clusterFor(w, r, requested)
+	if !ok {
+		return nil, false
+	}
+	return c.Manager, true
+}
+
 func (ws *WebServer) handleStatic(w http.ResponseWriter, r *http.Request) {
 	// Simple static file serving
 	file := filepath.Base(r.URL.Path)
@@ -461,8 +1040,9 @@ func (ws *WebServer) handleStatic(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// Authentication middleware
-func (ws *WebServer) authRequired(handler http.HandlerFunc) http.HandlerFunc {
+// Authentication middleware. Users whose role doesn't grant perm, on the whole
+// platform or some project or service, are turned away.
+func (ws *WebServer) authRequired(perm auth.Permission, handler http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Check for session cookie
 		cookie, err := r.Cookie("velo_session")
@@ -472,53 +1052,72 @@ func (ws *WebServer) authRequired(handler http.HandlerFunc) http.HandlerFunc {
 		}
 
 		// Validate token
-		_, err = ws.authService.ValidateToken(cookie.Value)
+		user, err := ws.authService.ValidateToken(cookie.Value)
 		if err != nil {
 			http.Redirect(w, r, "/login", http.StatusSeeOther)
 			return
 		}
+		if user.MustChangePassword {
+			http.Redirect(w, r, "/change-password", http.StatusSeeOther)
+			return
+		}
+		if !user.CanSome(perm) {
+			http.Error(w, "Forbidden: "+auth.Authorize(user, perm, auth.Resource{}).Error(), http.StatusForbidden)
+			return
+		}
 
-		handler(w, r)
+		handler(w, r.WithContext(auth.WithUser(r.Context(), user)))
 	}
 }
 
-// API authentication middleware
-func (ws *WebServer) authRequiredAPI(handler http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		// Check for session cookie or Authorization header
-		var token string
-
-		// Try cookie first
-		if cookie, err := r.Cookie("velo_session"); err == nil {
-			token = cookie.Value
-		} else {
-			// Try Authorization header
-			auth := r.Header.Get("Authorization")
-			if auth != "" && len(auth) > 7 && auth[:7] == "Bearer " {
-				token = auth[7:]
-			}
-		}
+// requestToken returns the token of a request's session cookie or, failing
+// that, its Authorization bearer header
+func requestToken(r *http.Request) string {
+	if cookie, err := r.Cookie("velo_session"); err == nil {
+		return cookie.Value
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return token
+	}
+	return ""
+}
 
+// API authentication middleware, checking perm like authRequired
+func (ws *WebServer) authRequiredAPI(perm auth.Permission, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := requestToken(r)
 		if token == "" {
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
 		// Validate token
-		_, err := ws.authService.ValidateToken(token)
+		user, err := ws.authService.ValidateToken(token)
 		if err != nil {
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
+		if user.MustChangePassword {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "You must change your password first"})
+			return
+		}
+		if !user.CanSome(perm) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: auth.Authorize(user, perm, auth.Resource{}).Error()})
+			return
+		}
 
-		handler(w, r)
+		handler(w, r.WithContext(auth.WithUser(r.Context(), user)))
 	}
 }
 
 // Login page handler
 func (ws *WebServer) handleLogin(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "POST" {
-		ws.handleAPILogin(w, r)
+		rateLimited(ws.loginLimiter, ws.audited("Login", ws.handleAPILogin))(w, r)
 		return
 	}
 
@@ -559,7 +1158,7 @@ func (ws *WebServer) handleLogin(w http.ResponseWriter, r *http.Request) {
             <div id="error" class="error"></div>
         </form>
         <div class="note">
-            Default credentials: admin / admin
+            On the first run, the admin password is printed once to the manager's stderr
         </div>
     </div>
     
@@ -581,7 +1180,8 @@ func (ws *WebServer) handleLogin(w http.ResponseWriter, r *http.Request) {
                 });
                 
                 if (response.ok) {
-                    window.location.href = '/deployments';
+                    const result = await response.json();
+                    window.location.href = result.mustChangePassword ? '/change-password' : '/deployments';
                 } else {
                     const result = await response.json();
                     errorDiv.textContent = result.error || 'Login failed';
@@ -610,9 +1210,16 @@ func (ws *WebServer) handleAPILogin(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON"})
 		return
 	}
+	audit.FromContext(r.Context()).Actor = req.Username
 
 	// Authenticate user
-	token, err := ws.authService.Authenticate(req.Username, req.Password)
+	token, err := ws.authService.AuthenticateFrom(req.Username, req.Password, audit.ClientIP(r))
+	if errors.Is(err, auth.ErrTooManyAttempts) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusUnauthorized)
@@ -630,20 +1237,35 @@ func (ws *WebServer) handleAPILogin(w http.ResponseWriter, r *http.Request) {
 		SameSite: http.SameSiteLaxMode,
 	})
 
+	user, err := ws.authService.ValidateToken(token.Value)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid credentials"})
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(LoginResponse{
-		Status: "success",
-		Token:  token.Value,
+		Status:             "success",
+		Token:              token.Value,
+		MustChangePassword: user.MustChangePassword,
 	})
 }
 
 // API logout handler
 func (ws *WebServer) handleAPILogout(w http.ResponseWriter, r *http.Request) {
-	// Get token from cookie
-	cookie, err := r.Cookie("velo_session")
-	if err == nil {
+	if token := requestToken(r); token != "" {
+		if user, err := ws.authService.ValidateToken(token); err == nil {
+			audit.FromContext(r.Context()).Actor = user.Username
+		}
 		// Revoke token
-		ws.authService.RevokeToken(cookie.Value)
+		if err := ws.authService.RevokeToken(token); errors.Is(err, auth.ErrNotSessionToken) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+			return
+		}
 	}
 
 	// Clear cookie
@@ -658,3 +1280,31 @@ func (ws *WebServer) handleAPILogout(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"status": "logged out"})
 }
+
+// clusterSwitcher is added to the navigation of every page. It lists the clusters
+// and remembers the selected one in a cookie, which the API handlers fall back to.
+const clusterSwitcher = `
+            <select id="cluster-switcher" style="display: none; padding: 8px; border-radius: 4px;" title="Cluster"></select>
+            <script>
+                (async function() {
+                    const select = document.getElementById('cluster-switcher');
+                    try {
+                        const response = await fetch('/api/clusters');
+                        if (!response.ok) return;
+                        const result = await response.json();
+                        if (result.clusters.length < 2) return;
+                        result.clusters.forEach(cluster => {
+                            const option = document.createElement('option');
+                            option.value = cluster.name;
+                            option.textContent = cluster.name + (cluster.healthy ? '' : ' (unhealthy)');
+                            option.selected = result.selected ? cluster.name === result.selected : cluster.default;
+                            select.appendChild(option);
+                        });
+                        select.style.display = 'inline-block';
+                        select.addEventListener('change', () => {
+                            document.cookie = '` + clusterCookie + `=' + encodeURIComponent(select.value) + '; path=/; SameSite=Lax';
+                            window.location.reload();
+                        });
+                    } catch (error) {}
+                })();
+            </script>`
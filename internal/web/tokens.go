@@ -0,0 +1,303 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/audit"
+	"github.com/jasonlovesdoggo/velo/internal/auth"
+)
+
+type APITokenSummary struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Username  string     `json:"username"`
+	Scopes    []string   `json:"scopes"`
+	Created   time.Time  `json:"created"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	LastUsed  *time.Time `json:"lastUsed,omitempty"`
+}
+
+type CreateAPITokenRequest struct {
+	Name     string   `json:"name"`
+	Scopes   []string `json:"scopes"`
+	TTLHours int      `json:"ttlHours"` // 0 for a token that doesn't expire
+	Username string   `json:"username"` // a service account, empty for your own token
+}
+
+type CreateAPITokenResponse struct {
+	Token    string          `json:"token"` // only ever shown here
+	APIToken APITokenSummary `json:"apiToken"`
+}
+
+type RevokeAPITokenRequest struct {
+	ID string `json:"id"`
+}
+
+func (ws *WebServer) handleTokens(w http.ResponseWriter, r *http.Request) {
+	tmpl := `
+<!DOCTYPE html>
+<html>
+<head>
+    <title>API Tokens - Velo</title>
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', system-ui, sans-serif; margin: 0; padding: 20px; background: #f5f5f5; }
+        .container { max-width: 1200px; margin: 0 auto; background: white; padding: 20px; border-radius: 8px; box-shadow: 0 2px 4px rgba(0,0,0,0.1); }
+        h1 { color: #333; border-bottom: 2px solid #007acc; padding-bottom: 10px; }
+        .nav { margin: 20px 0; }
+        .nav a { margin-right: 20px; padding: 8px 16px; background: #007acc; color: white; text-decoration: none; border-radius: 4px; }
+        .nav a:hover { background: #005a9e; }
+        .create input, .create select { padding: 6px; margin-right: 8px; border: 1px solid #ddd; border-radius: 4px; }
+        button { padding: 6px 12px; background: #007acc; color: white; border: none; border-radius: 4px; cursor: pointer; }
+        button.revoke { background: #f44336; }
+        .secret { display: none; margin-top: 20px; padding: 15px; background: #e8f5e9; border: 1px solid #4caf50; border-radius: 4px; }
+        .secret code { display: block; margin-top: 10px; font-size: 14px; word-break: break-all; }
+        table { width: 100%; border-collapse: collapse; margin-top: 20px; font-size: 14px; }
+        th, td { padding: 8px; text-align: left; border-bottom: 1px solid #ddd; }
+        th { background-color: #f8f9fa; font-weight: 600; }
+        .hint { color: #666; font-size: 13px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <h1>🔑 API Tokens</h1>
+        <div class="nav">
+            <a href="/">Home</a>
+            <a href="/deployments">View Deployments</a>
+            <a href="/deploy">Deploy Service</a>` + clusterSwitcher + `
+        </div>
+        <p class="hint">Tokens for CI and scripts. A token can do what its scopes allow and its owner's role allows, whichever is less: <code>read-only</code>, <code>deploy</code> (any service), <code>deploy:&lt;service&gt;</code> (one service) or <code>admin</code>. Separate scopes with commas.</p>
+        <form id="create" class="create">
+            <input name="name" placeholder="Name, e.g. ci-web" required>
+            <input name="scopes" placeholder="Scopes, e.g. deploy:web" required>
+            <input name="ttlHours" type="number" min="0" placeholder="Expires after hours (empty for never)" size="28">
+            <input name="username" placeholder="Service account (admins)">
+            <button type="submit">Create Token</button>
+        </form>
+        <div id="secret" class="secret">
+            <strong>Store this token now; it won't be shown again.</strong>
+            <code id="secretValue"></code>
+        </div>
+        <div id="error" style="color: red;"></div>
+        <div id="tokens">
+            <p>Loading tokens...</p>
+        </div>
+    </div>
+
+    <script>
+        function escapeHTML(value) {
+            return String(value).replace(/[&<>"']/g, c => ({'&': '&amp;', '<': '&lt;', '>': '&gt;', '"': '&quot;', "'": '&#39;'}[c]));
+        }
+
+        function formatTime(value, unset) {
+            return value ? new Date(value).toLocaleString() : unset;
+        }
+
+        async function loadTokens() {
+            try {
+                const response = await fetch('/api/tokens');
+                const result = await response.json();
+                if (!response.ok) throw new Error(result.error);
+
+                let html = '<table><thead><tr><th>ID</th><th>Name</th><th>User</th><th>Scopes</th><th>Created</th><th>Expires</th><th>Last Used</th><th></th></tr></thead><tbody>';
+                if (result.length === 0) {
+                    html += '<tr><td colspan="8" style="text-align: center; padding: 40px; color: #666;">No API tokens.</td></tr>';
+                }
+                result.forEach(token => {
+                    html += ` + "`" + `<tr>
+                        <td>${escapeHTML(token.id)}</td>
+                        <td>${escapeHTML(token.name)}</td>
+                        <td>${escapeHTML(token.username)}</td>
+                        <td>${escapeHTML(token.scopes.join(', '))}</td>
+                        <td>${formatTime(token.created, '-')}</td>
+                        <td>${formatTime(token.expiresAt, 'never')}</td>
+                        <td>${formatTime(token.lastUsed, 'never')}</td>
+                        <td><button class="revoke" onclick="revokeToken('${escapeHTML(token.id)}')">Revoke</button></td>
+                    </tr>` + "`" + `;
+                });
+                html += '</tbody></table>';
+                document.getElementById('tokens').innerHTML = html;
+            } catch (error) {
+                document.getElementById('tokens').innerHTML = '<p style="color: red;">Error loading tokens: ' + escapeHTML(error.message) + '</p>';
+            }
+        }
+
+        async function revokeToken(id) {
+            if (!confirm('Revoke token ' + id + '? Anything using it stops working.')) return;
+            const response = await fetch('/api/tokens/revoke', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({id: id})
+            });
+            if (!response.ok) {
+                const result = await response.json();
+                document.getElementById('error').textContent = result.error;
+            }
+            loadTokens();
+        }
+
+        document.getElementById('create').addEventListener('submit', async e => {
+            e.preventDefault();
+            const form = new FormData(e.target);
+            document.getElementById('error').textContent = '';
+            const response = await fetch('/api/tokens/create', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({
+                    name: form.get('name'),
+                    scopes: form.get('scopes').split(',').map(s => s.trim()).filter(s => s),
+                    ttlHours: parseInt(form.get('ttlHours')) || 0,
+                    username: form.get('username')
+                })
+            });
+            const result = await response.json();
+            if (!response.ok) {
+                document.getElementById('error').textContent = result.error;
+                return;
+            }
+            document.getElementById('secretValue').textContent = result.token;
+            document.getElementById('secret').style.display = 'block';
+            e.target.reset();
+            loadTokens();
+        });
+
+        loadTokens();
+    </script>
+</body>
+</html>`
+
+	w.Header().Set("Content-Type", "text/html")
+	fmt.Fprint(w, tmpl)
+}
+
+// handleAPITokens lists the user's API tokens, or everyone's with ?all=true for admins
+func (ws *WebServer) handleAPITokens(w http.ResponseWriter, r *http.Request) {
+	user, _ := auth.UserFromContext(r.Context())
+	userID := user.ID
+	if r.URL.Query().Get("all") == "true" {
+		if err := auth.Authorize(user, auth.PermUsersManage, auth.Resource{}); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+			return
+		}
+		userID = ""
+	}
+
+	tokens, err := ws.authService.ListAPITokens(userID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("Failed to list API tokens: %v", err)})
+		return
+	}
+
+	summaries := []APITokenSummary{}
+	for _, token := range tokens {
+		summaries = append(summaries, ws.apiTokenSummary(&token))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summaries)
+}
+
+func (ws *WebServer) handleAPICreateToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+		return
+	}
+
+	var req CreateAPITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("Invalid JSON: %v", err)})
+		return
+	}
+
+	entry := audit.FromContext(r.Context())
+	entry.Target = req.Name
+	if req.Username != "" {
+		entry.Target = req.Username
+	}
+	scopes, _ := json.Marshal(req.Scopes)
+	entry.Params = map[string]string{"name": req.Name, "scopes": string(scopes), "ttl_hours": fmt.Sprint(req.TTLHours)}
+
+	user, _ := auth.UserFromContext(r.Context())
+	value, token, err := ws.authService.CreateAPIToken(user, req.Username, req.Name, req.Scopes, time.Duration(req.TTLHours)*time.Hour)
+	if err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, auth.ErrPermissionDenied) {
+			code = http.StatusForbidden
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(CreateAPITokenResponse{
+		Token:    value,
+		APIToken: ws.apiTokenSummary(token),
+	})
+}
+
+func (ws *WebServer) handleAPIRevokeToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+		return
+	}
+
+	var req RevokeAPITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("Invalid JSON: %v", err)})
+		return
+	}
+	audit.FromContext(r.Context()).Target = req.ID
+
+	user, _ := auth.UserFromContext(r.Context())
+	if err := ws.authService.RevokeAPIToken(user, req.ID); err != nil {
+		code, msg := http.StatusInternalServerError, err.Error()
+		switch {
+		case errors.Is(err, auth.ErrPermissionDenied):
+			code = http.StatusForbidden
+		case errors.Is(err, auth.ErrTokenInvalid):
+			code, msg = http.StatusNotFound, "API token not found"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: msg})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "revoked"})
+}
+
+func (ws *WebServer) apiTokenSummary(token *auth.APIToken) APITokenSummary {
+	summary := APITokenSummary{
+		ID:      token.ID,
+		Name:    token.Name,
+		Scopes:  token.Scopes,
+		Created: token.Created,
+	}
+	if user, err := ws.authService.GetUserByID(token.UserID); err == nil {
+		summary.Username = user.Username
+	}
+	if !token.ExpiresAt.IsZero() {
+		summary.ExpiresAt = &token.ExpiresAt
+	}
+	if !token.LastUsed.IsZero() {
+		summary.LastUsed = &token.LastUsed
+	}
+	return summary
+}
@@ -0,0 +1,381 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/log"
+)
+
+// Headers of every delivery
+const (
+	HeaderEvent     = "X-Velo-Event"
+	HeaderDelivery  = "X-Velo-Delivery"
+	HeaderTimestamp = "X-Velo-Timestamp"
+	HeaderSignature = "X-Velo-Signature"
+)
+
+// Delivery states
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+const (
+	deliveryKeyPrefix = "webhook-delivery:"
+	pendingKeyPrefix  = "webhook-pending:" // indexes the pending deliveries by ID
+)
+
+// maxResponseBody caps how much of a failed response is kept as its error
+const maxResponseBody = 512
+
+// ErrBadSignature is returned by Verify for payloads that weren't signed with the secret
+var ErrBadSignature = errors.New("invalid webhook signature")
+
+// Delivery is one event on its way to one subscription
+type Delivery struct {
+	ID           string          `json:"id"`
+	Subscription string          `json:"subscription"`
+	Event        string          `json:"event"`
+	EventID      string          `json:"event_id"`
+	Payload      json.RawMessage `json:"payload"`
+	State        string          `json:"state"`
+	Attempts     int             `json:"attempts"`
+	NextAttempt  time.Time       `json:"next_attempt"`
+	LastStatus   int             `json:"last_status,omitempty"` // HTTP status of the last attempt, 0 if there was no response
+	LastError    string          `json:"last_error,omitempty"`
+	Created      time.Time       `json:"created"`
+	Finished     time.Time       `json:"finished,omitempty"`
+}
+
+// pendingDelivery is the pending index's entry of a delivery, so finding the
+// deliveries that are due doesn't load every delivery kept for the retention
+type pendingDelivery struct {
+	Subscription string    `json:"subscription"`
+	NextAttempt  time.Time `json:"next_attempt"`
+}
+
+type httpDoer interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+func newHTTPClient(timeout time.Duration) httpDoer {
+	return &http.Client{Timeout: timeout}
+}
+
+// Sign returns the signature of a payload sent at timestamp (in unix seconds):
+// "sha256=" and the hex HMAC-SHA256 of "<timestamp>.<payload>" keyed with the
+// secret. Signing the timestamp lets receivers reject replayed deliveries.
+func Sign(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and timestamp headers of a delivery received at
+// now. Timestamps further than tolerance from now are rejected, unless
+// tolerance is 0.
+func Verify(secret, timestamp, signature string, payload []byte, tolerance time.Duration, now time.Time) error {
+	if tolerance > 0 {
+		unix, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid webhook timestamp %q", timestamp)
+		}
+		if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+			return fmt.Errorf("webhook timestamp %s is outside the tolerance of %s", timestamp, tolerance)
+		}
+	}
+	if !hmac.Equal([]byte(Sign(secret, timestamp, payload)), []byte(signature)) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// enqueue adds a delivery of an event to the outbox
+func (d *Dispatcher) enqueue(s Subscription, e Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	id, err := randomHex(8)
+	if err != nil {
+		return err
+	}
+
+	now := d.opts.Now()
+	delivery := Delivery{
+		ID:           id,
+		Subscription: s.ID,
+		Event:        e.Type,
+		EventID:      e.ID,
+		Payload:      payload,
+		State:        DeliveryPending,
+		NextAttempt:  now,
+		Created:      now,
+	}
+
+	// The index entry goes first, so a pending delivery is never missing from it
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.store.Set(pendingKeyPrefix+id, pendingDelivery{Subscription: s.ID, NextAttempt: now}); err != nil {
+		return err
+	}
+	return d.store.Set(deliveryKeyPrefix+id, delivery)
+}
+
+// Flush attempts the pending deliveries that are due and waits for them to be
+// sent
+func (d *Dispatcher) Flush() {
+	d.dispatch().Wait()
+}
+
+// dispatch starts sending the pending deliveries that are due, at most Workers
+// at a time. A subscription's deliveries are sent one after another, oldest
+// first, so events reach each endpoint in the order they happened; a slow
+// endpoint only holds up its own deliveries.
+func (d *Dispatcher) dispatch() *sync.WaitGroup {
+	var wg sync.WaitGroup
+	for subscription, deliveries := range d.due() {
+		wg.Add(1)
+		d.sending.Add(1)
+		go func() {
+			defer d.sending.Done()
+			defer wg.Done()
+			defer d.release(subscription)
+			for _, delivery := range deliveries {
+				d.workers <- struct{}{}
+				d.attempt(delivery)
+				<-d.workers
+			}
+		}()
+	}
+	return &wg
+}
+
+// due returns the pending deliveries that are due by subscription, oldest
+// first, and marks their subscriptions busy until released. Subscriptions that
+// are still busy from an earlier dispatch are left for the next one.
+func (d *Dispatcher) due() map[string][]Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	keys, err := d.store.List(pendingKeyPrefix)
+	if err != nil {
+		log.Error("Failed to load pending webhook deliveries", "error", err)
+		return nil
+	}
+
+	now := d.opts.Now()
+	var deliveries []Delivery
+	for _, key := range keys {
+		var pending pendingDelivery
+		if err := d.store.Get(key, &pending); err != nil {
+			continue // Skip invalid entries
+		}
+		if d.busy[pending.Subscription] || pending.NextAttempt.After(now) {
+			continue
+		}
+		var delivery Delivery
+		if err := d.store.Get(deliveryKeyPrefix+strings.TrimPrefix(key, pendingKeyPrefix), &delivery); err != nil || delivery.State != DeliveryPending {
+			// Left behind by a delivery that was dropped or finished
+			d.store.Delete(key)
+			continue
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].Created.Before(deliveries[j].Created) })
+
+	due := make(map[string][]Delivery)
+	for _, delivery := range deliveries {
+		due[delivery.Subscription] = append(due[delivery.Subscription], delivery)
+		d.busy[delivery.Subscription] = true
+	}
+	return due
+}
+
+// release lets the next dispatch send a subscription's deliveries again
+func (d *Dispatcher) release(subscription string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.busy, subscription)
+}
+
+// attempt sends a delivery once and records the outcome, scheduling a retry
+// with exponential backoff if it failed
+func (d *Dispatcher) attempt(delivery Delivery) {
+	var s Subscription
+	if err := d.store.Get(subscriptionKeyPrefix+delivery.Subscription, &s); err != nil {
+		// The subscription was deleted; nobody is waiting for this anymore
+		if err := d.store.Delete(deliveryKeyPrefix + delivery.ID); err != nil {
+			log.Warn("Failed to drop webhook delivery", "id", delivery.ID, "error", err)
+		}
+		d.unindex(delivery)
+		return
+	}
+
+	status, err := d.send(s, delivery)
+	now := d.opts.Now()
+	delivery.Attempts++
+	delivery.LastStatus = status
+	delivery.LastError = ""
+
+	switch {
+	case err == nil:
+		delivery.State = DeliverySucceeded
+		delivery.Finished = now
+	case delivery.Attempts >= d.opts.MaxAttempts:
+		delivery.State = DeliveryFailed
+		delivery.LastError = err.Error()
+		delivery.Finished = now
+		log.Warn("Webhook delivery failed, giving up", "webhook", s.ID, "event", delivery.Event, "attempts", delivery.Attempts, "error", err)
+	default:
+		delivery.LastError = err.Error()
+		delivery.NextAttempt = now.Add(d.backoff(delivery.Attempts))
+		log.Warn("Webhook delivery failed, retrying", "webhook", s.ID, "event", delivery.Event, "attempt", delivery.Attempts, "next", delivery.NextAttempt, "error", err)
+	}
+
+	if err := d.store.Set(deliveryKeyPrefix+delivery.ID, delivery); err != nil {
+		log.Error("Failed to record webhook delivery", "id", delivery.ID, "error", err)
+		return
+	}
+	if delivery.State != DeliveryPending {
+		d.unindex(delivery)
+		return
+	}
+	if err := d.store.Set(pendingKeyPrefix+delivery.ID, pendingDelivery{Subscription: delivery.Subscription, NextAttempt: delivery.NextAttempt}); err != nil {
+		log.Warn("Failed to reschedule webhook delivery", "id", delivery.ID, "error", err)
+	}
+}
+
+// unindex removes a delivery that's no longer pending from the pending index
+func (d *Dispatcher) unindex(delivery Delivery) {
+	if err := d.store.Delete(pendingKeyPrefix + delivery.ID); err != nil {
+		log.Warn("Failed to unindex webhook delivery", "id", delivery.ID, "error", err)
+	}
+}
+
+// Prune deletes finished deliveries past their retention. Pending deliveries
+// missing from the pending index, like those queued before it existed, are
+// added to it.
+func (d *Dispatcher) Prune() {
+	deliveries, err := d.loadDeliveries()
+	if err != nil {
+		log.Error("Failed to load webhook deliveries", "error", err)
+		return
+	}
+
+	now := d.opts.Now()
+	for _, delivery := range deliveries {
+		if delivery.State == DeliveryPending {
+			var pending pendingDelivery
+			if d.store.Get(pendingKeyPrefix+delivery.ID, &pending) != nil {
+				d.store.Set(pendingKeyPrefix+delivery.ID, pendingDelivery{Subscription: delivery.Subscription, NextAttempt: delivery.NextAttempt})
+			}
+			continue
+		}
+		if now.Sub(delivery.Finished) > d.opts.Retention {
+			if err := d.store.Delete(deliveryKeyPrefix + delivery.ID); err != nil {
+				log.Warn("Failed to prune webhook delivery", "id", delivery.ID, "error", err)
+			}
+		}
+	}
+}
+
+// backoff returns how long to wait before the retry following attempt
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	wait := d.opts.Backoff
+	for i := 1; i < attempt && wait < d.opts.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, d.opts.MaxBackoff)
+}
+
+// send posts a delivery to its subscription's URL, returning the response's
+// status. Anything but a 2xx response is an error.
+func (d *Dispatcher) send(s Subscription, delivery Delivery) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.opts.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(d.opts.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Velo-Webhooks")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(s.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+		if msg := strings.TrimSpace(string(body)); msg != "" {
+			return resp.StatusCode, fmt.Errorf("%s: %s", resp.Status, msg)
+		}
+		return resp.StatusCode, errors.New(resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Deliveries returns the delivery log, newest first, optionally only for one
+// subscription. A limit of 0 returns every delivery.
+func (d *Dispatcher) Deliveries(subscription string, limit int) ([]Delivery, error) {
+	deliveries, err := d.loadDeliveries()
+	if err != nil {
+		return nil, err
+	}
+
+	var filtered []Delivery
+	for _, delivery := range deliveries {
+		if subscription != "" && delivery.Subscription != subscription {
+			continue
+		}
+		filtered = append(filtered, delivery)
+	}
+	sort.Slice(filtered, func(i, j int) bool { return filtered[i].Created.After(filtered[j].Created) })
+	if limit > 0 && len(filtered) > limit {
+		filtered = filtered[:limit]
+	}
+	return filtered, nil
+}
+
+func (d *Dispatcher) loadDeliveries() ([]Delivery, error) {
+	keys, err := d.store.List(deliveryKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]Delivery, 0, len(keys))
+	for _, key := range keys {
+		var delivery Delivery
+		if err := d.store.Get(key, &delivery); err != nil {
+			continue // Skip invalid entries
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].Created.Before(deliveries[j].Created) })
+	return deliveries, nil
+}
@@ -0,0 +1,56 @@
+package webhooks
+
+import (
+	"github.com/jasonlovesdoggo/velo/pkg/core/node"
+)
+
+// CheckNodes publishes node.down and node.up for nodes whose readiness changed
+// since the last check. The first time a node is seen only records its state,
+// so restarting the manager doesn't announce every node. Clusters that are
+// down are skipped: what they last reported can't be trusted.
+func (d *Dispatcher) CheckNodes() {
+	var events []Event
+	d.mu.Lock()
+	for _, c := range d.clusters.Clusters() {
+		if !c.Healthy() {
+			continue
+		}
+		for _, n := range c.Nodes() {
+			name := n.Hostname
+			if name == "" {
+				name = n.ID
+			}
+			key := c.Name + "/" + n.ID
+			ready, reason := nodeReady(n)
+			was, seen := d.nodes[key]
+			d.nodes[key] = ready
+			if !seen || was == ready {
+				continue
+			}
+
+			e := Event{Type: EventNodeUp, Cluster: c.Name, Node: name}
+			if !ready {
+				e.Type = EventNodeDown
+				e.Data = map[string]string{"reason": reason}
+			}
+			events = append(events, e)
+		}
+	}
+	d.mu.Unlock()
+
+	for _, e := range events {
+		d.Publish(e)
+	}
+}
+
+// nodeReady reports whether a node is ready and, if it isn't, why. Nodes whose
+// agent went missing count as down; nodes that never ran an agent don't.
+func nodeReady(n node.Info) (bool, string) {
+	switch {
+	case len(n.Conditions) > 0 && n.Conditions[0] != "ready":
+		return false, n.Conditions[0]
+	case n.Agent != nil && n.AgentMissing:
+		return false, "agent stopped sending heartbeats"
+	}
+	return true, ""
+}
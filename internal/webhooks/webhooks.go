@@ -0,0 +1,370 @@
+// Package webhooks sends deployment lifecycle events to subscribed HTTP
+// endpoints. Every event is written to an outbox in the state store, one
+// delivery per matching subscription, and delivered from there with retries,
+// so deliveries survive manager restarts. Payloads are signed with the
+// subscription's secret.
+package webhooks
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/log"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/cluster"
+	"github.com/jasonlovesdoggo/velo/internal/state"
+)
+
+// Event types
+const (
+	EventDeployStarted   = "deploy.started"
+	EventDeploySucceeded = "deploy.succeeded"
+	EventDeployFailed    = "deploy.failed"
+	EventRollback        = "service.rolled_back"
+	EventScale           = "service.scaled"
+	EventNodeDown        = "node.down"
+	EventNodeUp          = "node.up"
+)
+
+// EventTypes lists the event types subscriptions can select
+var EventTypes = []string{
+	EventDeployStarted,
+	EventDeploySucceeded,
+	EventDeployFailed,
+	EventRollback,
+	EventScale,
+	EventNodeDown,
+	EventNodeUp,
+}
+
+const (
+	subscriptionKeyPrefix = "webhook:"
+	secretPrefix          = "whsec_"
+)
+
+// ErrUnknownSubscription is returned for subscriptions that don't exist
+var ErrUnknownSubscription = errors.New("unknown webhook")
+
+// Event is something that happened to a service or node. It's the JSON payload
+// of every delivery.
+type Event struct {
+	ID      string            `json:"id"`
+	Type    string            `json:"type"`
+	Time    time.Time         `json:"timestamp"`
+	Cluster string            `json:"cluster,omitempty"`
+	Service string            `json:"service,omitempty"`
+	Node    string            `json:"node,omitempty"`
+	Data    map[string]string `json:"data,omitempty"` // e.g. image, replicas or error
+}
+
+// As returns a copy of the event with another type, for the events of one
+// operation, e.g. a deploy starting and then succeeding
+func (e Event) As(eventType string) Event {
+	e.Type = eventType
+	e.Data = maps.Clone(e.Data)
+	return e
+}
+
+// Failed returns a copy of a deploy event as deploy.failed with the error
+func (e Event) Failed(err error) Event {
+	e = e.As(EventDeployFailed)
+	if e.Data == nil {
+		e.Data = make(map[string]string)
+	}
+	e.Data["error"] = err.Error()
+	return e
+}
+
+// Subscription sends the events it selects to a URL. Subscriptions scoped to
+// a cluster or service only get the events of that cluster or service; node
+// events have no service and only reach subscriptions without one.
+type Subscription struct {
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	Secret      string    `json:"secret"`           // signs payloads; kept so deliveries can be signed
+	Events      []string  `json:"events,omitempty"` // empty selects all events
+	Cluster     string    `json:"cluster,omitempty"`
+	Service     string    `json:"service,omitempty"`
+	Description string    `json:"description"`
+	Created     time.Time `json:"created"`
+}
+
+// Matches reports whether the subscription selects an event
+func (s Subscription) Matches(e Event) bool {
+	if len(s.Events) > 0 && !slices.Contains(s.Events, e.Type) {
+		return false
+	}
+	if s.Cluster != "" && s.Cluster != e.Cluster {
+		return false
+	}
+	if s.Service != "" && s.Service != e.Service {
+		return false
+	}
+	return true
+}
+
+// Options configure a Dispatcher
+type Options struct {
+	MaxAttempts  int           // before a delivery is given up on
+	Backoff      time.Duration // before the first retry, doubled on every further retry
+	MaxBackoff   time.Duration // caps the wait between retries
+	Timeout      time.Duration // for the receiving endpoint to respond
+	Retention    time.Duration // finished deliveries are kept this long
+	NodeInterval time.Duration // between checks for nodes going down or coming up
+	Workers      int           // deliveries sent at the same time
+	Now          func() time.Time
+}
+
+// Defaults of the dispatcher's options
+const (
+	DefaultMaxAttempts  = 8
+	DefaultBackoff      = 10 * time.Second
+	DefaultMaxBackoff   = time.Hour
+	DefaultTimeout      = 10 * time.Second
+	DefaultRetention    = 7 * 24 * time.Hour
+	DefaultNodeInterval = 15 * time.Second
+	DefaultWorkers      = 8
+)
+
+// pruneInterval is how often finished deliveries past their retention are deleted
+const pruneInterval = time.Hour
+
+// Dispatcher records events in the outbox and delivers them
+type Dispatcher struct {
+	store    state.StateStore
+	clusters *cluster.Registry
+	opts     Options
+	client   httpDoer
+
+	mu      sync.Mutex      // guards the pending index and busy
+	busy    map[string]bool // subscriptions with deliveries being sent
+	workers chan struct{}   // one token per delivery being sent
+	sending sync.WaitGroup  // deliveries being sent, waited for by Stop
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	nodes   map[string]bool // cluster/node -> ready, for node events
+}
+
+// NewDispatcher creates a dispatcher with its outbox in store. Node events are
+// watched in clusters, if set.
+func NewDispatcher(store state.StateStore, clusters *cluster.Registry, opts Options) *Dispatcher {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = DefaultBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultMaxBackoff
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.Retention <= 0 {
+		opts.Retention = DefaultRetention
+	}
+	if opts.NodeInterval <= 0 {
+		opts.NodeInterval = DefaultNodeInterval
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = DefaultWorkers
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Dispatcher{
+		store:    store,
+		clusters: clusters,
+		opts:     opts,
+		client:   newHTTPClient(opts.Timeout),
+		busy:     make(map[string]bool),
+		workers:  make(chan struct{}, opts.Workers),
+		wake:     make(chan struct{}, 1),
+		nodes:    make(map[string]bool),
+	}
+}
+
+// Start delivers the outbox in the background, including deliveries left over
+// from before a restart, and watches the clusters' nodes
+func (d *Dispatcher) Start() {
+	d.stop = make(chan struct{})
+	d.done = make(chan struct{})
+
+	go func() {
+		defer close(d.done)
+		retry := time.NewTicker(time.Second)
+		defer retry.Stop()
+		nodes := time.NewTicker(d.opts.NodeInterval)
+		defer nodes.Stop()
+		prune := time.NewTicker(pruneInterval)
+		defer prune.Stop()
+		d.Prune()
+		if d.clusters != nil {
+			d.CheckNodes()
+		}
+
+		for {
+			select {
+			case <-d.stop:
+				return
+			case <-d.wake:
+				d.dispatch()
+			case <-retry.C:
+				d.dispatch()
+			case <-prune.C:
+				d.Prune()
+			case <-nodes.C:
+				if d.clusters != nil {
+					d.CheckNodes()
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops delivering, waiting for the deliveries being sent. Pending
+// deliveries stay in the outbox.
+func (d *Dispatcher) Stop() {
+	if d.stop == nil {
+		return
+	}
+	close(d.stop)
+	<-d.done
+	d.sending.Wait()
+}
+
+// Publish records an event in the outbox for every subscription it matches.
+// Publishing on a nil dispatcher does nothing, so callers needn't check
+// whether webhooks are enabled.
+func (d *Dispatcher) Publish(e Event) {
+	if d == nil {
+		return
+	}
+	if e.ID == "" {
+		id, err := randomHex(8)
+		if err != nil {
+			log.Error("Failed to generate event ID", "error", err)
+			return
+		}
+		e.ID = "evt_" + id
+	}
+	if e.Time.IsZero() {
+		e.Time = d.opts.Now()
+	}
+
+	subscriptions, err := d.Subscriptions()
+	if err != nil {
+		log.Error("Failed to load webhooks", "event", e.Type, "error", err)
+		return
+	}
+	queued := 0
+	for _, s := range subscriptions {
+		if !s.Matches(e) {
+			continue
+		}
+		if err := d.enqueue(s, e); err != nil {
+			log.Error("Failed to queue webhook delivery", "webhook", s.ID, "event", e.Type, "error", err)
+			continue
+		}
+		queued++
+	}
+	if queued == 0 {
+		return
+	}
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// CreateSubscription subscribes url to events. A secret is generated unless
+// given; it's returned with the subscription and isn't listed again.
+func (d *Dispatcher) CreateSubscription(s Subscription) (Subscription, error) {
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Subscription{}, fmt.Errorf("invalid webhook URL %q (expected http or https)", s.URL)
+	}
+	for _, event := range s.Events {
+		if !slices.Contains(EventTypes, event) {
+			return Subscription{}, fmt.Errorf("unknown event %q (expected one of %v)", event, EventTypes)
+		}
+	}
+	if s.Secret == "" {
+		secret, err := randomHex(24)
+		if err != nil {
+			return Subscription{}, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+		s.Secret = secretPrefix + secret
+	}
+
+	// IDs are short so they're easy to delete; regenerate the rare duplicate
+	for {
+		id, err := randomHex(3)
+		if err != nil {
+			return Subscription{}, fmt.Errorf("failed to generate webhook ID: %w", err)
+		}
+		var existing Subscription
+		if d.store.Get(subscriptionKeyPrefix+id, &existing) != nil {
+			s.ID = id
+			break
+		}
+	}
+	s.Created = d.opts.Now()
+	if err := d.store.Set(subscriptionKeyPrefix+s.ID, s); err != nil {
+		return Subscription{}, fmt.Errorf("failed to store webhook: %w", err)
+	}
+
+	log.Info("Webhook created", "id", s.ID, "url", u.Redacted(), "events", s.Events, "cluster", s.Cluster, "service", s.Service)
+	return s, nil
+}
+
+// Subscriptions returns the subscriptions, oldest first
+func (d *Dispatcher) Subscriptions() ([]Subscription, error) {
+	keys, err := d.store.List(subscriptionKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions := make([]Subscription, 0, len(keys))
+	for _, key := range keys {
+		var s Subscription
+		if err := d.store.Get(key, &s); err != nil {
+			continue // Skip invalid entries
+		}
+		subscriptions = append(subscriptions, s)
+	}
+	sort.Slice(subscriptions, func(i, j int) bool { return subscriptions[i].Created.Before(subscriptions[j].Created) })
+	return subscriptions, nil
+}
+
+// DeleteSubscription removes a subscription. Its pending deliveries are dropped
+// when they're next attempted.
+func (d *Dispatcher) DeleteSubscription(id string) error {
+	var s Subscription
+	if err := d.store.Get(subscriptionKeyPrefix+id, &s); err != nil {
+		return fmt.Errorf("%w: %s", ErrUnknownSubscription, id)
+	}
+	if err := d.store.Delete(subscriptionKeyPrefix + id); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	log.Info("Webhook deleted", "id", id)
+	return nil
+}
+
+func randomHex(n int) (string, error) {
+	bytes := make([]byte, n)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
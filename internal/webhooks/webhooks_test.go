@@ -0,0 +1,363 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/cluster"
+	"github.com/jasonlovesdoggo/velo/internal/orchestrator/sim"
+	"github.com/jasonlovesdoggo/velo/internal/state"
+)
+
+// clock is a settable time source
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// receiver is an endpoint that records what it's sent and answers with the
+// statuses it's given, then 200
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	if len(r.statuses) > 0 {
+		status := r.statuses[0]
+		r.statuses = r.statuses[1:]
+		http.Error(w, "try again later", status)
+	}
+}
+
+func (r *receiver) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.requests)
+}
+
+func newReceiver(t *testing.T, statuses ...int) (*receiver, string) {
+	t.Helper()
+	r := &receiver{statuses: statuses}
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return r, srv.URL
+}
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	payload := []byte(`{"type":"deploy.succeeded"}`)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	signature := Sign("secret", timestamp, payload)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		payload   []byte
+		tolerance time.Duration
+		now       time.Time
+		wantErr   bool
+	}{
+		{"valid", "secret", timestamp, payload, 5 * time.Minute, now, false},
+		{"wrong secret", "other", timestamp, payload, 5 * time.Minute, now, true},
+		{"tampered payload", "secret", timestamp, []byte(`{"type":"deploy.failed"}`), 5 * time.Minute, now, true},
+		{"tampered timestamp", "secret", strconv.FormatInt(now.Unix()+1, 10), payload, 5 * time.Minute, now, true},
+		{"too old", "secret", timestamp, payload, 5 * time.Minute, now.Add(10 * time.Minute), true},
+		{"no tolerance", "secret", timestamp, payload, 0, now.Add(10 * time.Minute), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.timestamp, signature, tt.payload, tt.tolerance, tt.now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSubscriptionMatches(t *testing.T) {
+	event := Event{Type: EventDeployFailed, Cluster: "prod", Service: "web"}
+	tests := []struct {
+		name string
+		sub  Subscription
+		want bool
+	}{
+		{"global", Subscription{}, true},
+		{"selected event", Subscription{Events: []string{EventDeployFailed, EventRollback}}, true},
+		{"other event", Subscription{Events: []string{EventDeploySucceeded}}, false},
+		{"same cluster", Subscription{Cluster: "prod"}, true},
+		{"other cluster", Subscription{Cluster: "staging"}, false},
+		{"same service", Subscription{Cluster: "prod", Service: "web"}, true},
+		{"other service", Subscription{Service: "api"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sub.Matches(event); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	node := Event{Type: EventNodeDown, Cluster: "prod", Node: "worker-1"}
+	if (Subscription{Service: "web"}).Matches(node) {
+		t.Error("Expected node events not to reach service webhooks")
+	}
+}
+
+func TestDeliveryRetries(t *testing.T) {
+	clk := &clock{now: time.Now()}
+	rec, url := newReceiver(t, http.StatusInternalServerError, http.StatusBadGateway)
+	d := NewDispatcher(state.NewMemoryStateStore(), nil, Options{Backoff: time.Minute, Now: clk.Now})
+
+	sub, err := d.CreateSubscription(Subscription{URL: url})
+	if err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+	d.Publish(Event{Type: EventDeploySucceeded, Cluster: "default", Service: "web"})
+
+	// The first attempt fails and is retried after the backoff, then after twice the backoff
+	d.Flush()
+	deliveries, _ := d.Deliveries("", 0)
+	if len(deliveries) != 1 || deliveries[0].State != DeliveryPending || deliveries[0].Attempts != 1 || deliveries[0].LastStatus != 500 {
+		t.Fatalf("Expected a pending delivery after one failed attempt, got %+v", deliveries)
+	}
+	if want := clk.Now().Add(time.Minute); !deliveries[0].NextAttempt.Equal(want) {
+		t.Errorf("Expected the retry at %s, got %s", want, deliveries[0].NextAttempt)
+	}
+	d.Flush()
+	if rec.count() != 1 {
+		t.Fatalf("Expected no attempt before the backoff, got %d attempts", rec.count())
+	}
+
+	clk.Advance(time.Minute)
+	d.Flush()
+	deliveries, _ = d.Deliveries("", 0)
+	if want := clk.Now().Add(2 * time.Minute); !deliveries[0].NextAttempt.Equal(want) {
+		t.Errorf("Expected the second retry at %s, got %s", want, deliveries[0].NextAttempt)
+	}
+
+	clk.Advance(2 * time.Minute)
+	d.Flush()
+	deliveries, _ = d.Deliveries(sub.ID, 0)
+	if len(deliveries) != 1 || deliveries[0].State != DeliverySucceeded || deliveries[0].Attempts != 3 || deliveries[0].LastError != "" {
+		t.Fatalf("Expected a succeeded delivery after three attempts, got %+v", deliveries)
+	}
+
+	// Every attempt is signed and carries the same delivery ID
+	for i, req := range rec.requests {
+		if req.Header.Get(HeaderEvent) != EventDeploySucceeded || req.Header.Get(HeaderDelivery) != deliveries[0].ID {
+			t.Errorf("Expected the event and delivery headers on attempt %d, got %v", i+1, req.Header)
+		}
+		if err := Verify(sub.Secret, req.Header.Get(HeaderTimestamp), req.Header.Get(HeaderSignature), rec.bodies[i], 0, clk.Now()); err != nil {
+			t.Errorf("Expected attempt %d to be signed, got %v", i+1, err)
+		}
+	}
+	var event Event
+	if err := json.Unmarshal(rec.bodies[2], &event); err != nil {
+		t.Fatalf("Failed to decode payload: %v", err)
+	}
+	if event.Type != EventDeploySucceeded || event.Service != "web" || event.ID == "" {
+		t.Errorf("Expected the event as payload, got %+v", event)
+	}
+}
+
+func TestDeliveryGivesUp(t *testing.T) {
+	clk := &clock{now: time.Now()}
+	_, url := newReceiver(t, 500, 500, 500, 500)
+	d := NewDispatcher(state.NewMemoryStateStore(), nil, Options{MaxAttempts: 3, Backoff: time.Second, Now: clk.Now})
+
+	if _, err := d.CreateSubscription(Subscription{URL: url}); err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+	d.Publish(Event{Type: EventScale, Service: "web"})
+	for range 3 {
+		d.Flush()
+		clk.Advance(time.Hour)
+	}
+
+	deliveries, _ := d.Deliveries("", 0)
+	if len(deliveries) != 1 || deliveries[0].State != DeliveryFailed || deliveries[0].Attempts != 3 {
+		t.Fatalf("Expected a failed delivery after three attempts, got %+v", deliveries)
+	}
+	if deliveries[0].LastError != "500 Internal Server Error: try again later" {
+		t.Errorf("Expected the response in the error, got %q", deliveries[0].LastError)
+	}
+
+	// Finished deliveries are pruned after the retention
+	clk.Advance(DefaultRetention)
+	d.Prune()
+	if deliveries, _ := d.Deliveries("", 0); len(deliveries) != 0 {
+		t.Errorf("Expected the delivery to be pruned, got %+v", deliveries)
+	}
+}
+
+func TestOutboxSurvivesRestart(t *testing.T) {
+	rec, url := newReceiver(t)
+	store := state.NewMemoryStateStore()
+	d := NewDispatcher(store, nil, Options{})
+	kept, err := d.CreateSubscription(Subscription{URL: url, Events: []string{EventDeployFailed}})
+	if err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+	deleted, err := d.CreateSubscription(Subscription{URL: url})
+	if err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+
+	// Queued, but the manager stops before delivering
+	event := Event{Type: EventDeployStarted, Service: "web"}
+	d.Publish(event)
+	d.Publish(event.Failed(errors.New("image not found")))
+	if err := d.DeleteSubscription(deleted.ID); err != nil {
+		t.Fatalf("Failed to delete webhook: %v", err)
+	}
+
+	restarted := NewDispatcher(store, nil, Options{})
+	restarted.Flush()
+	if rec.count() != 1 {
+		t.Fatalf("Expected one delivery, got %d", rec.count())
+	}
+	var got Event
+	json.Unmarshal(rec.bodies[0], &got)
+	if got.Type != EventDeployFailed || got.Data["error"] != "image not found" || got.Data["image"] != "" {
+		t.Errorf("Expected the failed deploy, got %+v", got)
+	}
+	if deliveries, _ := restarted.Deliveries("", 0); len(deliveries) != 1 || deliveries[0].Subscription != kept.ID {
+		t.Errorf("Expected the deleted webhook's delivery to be dropped, got %+v", deliveries)
+	}
+	if err := restarted.DeleteSubscription(deleted.ID); !errors.Is(err, ErrUnknownSubscription) {
+		t.Errorf("Expected ErrUnknownSubscription, got %v", err)
+	}
+}
+
+func TestSlowEndpoint(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { <-release }))
+	t.Cleanup(slow.Close)
+	t.Cleanup(func() { close(release) })
+	rec, url := newReceiver(t)
+	store := state.NewMemoryStateStore()
+	d := NewDispatcher(store, nil, Options{})
+
+	if _, err := d.CreateSubscription(Subscription{URL: slow.URL}); err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+	if _, err := d.CreateSubscription(Subscription{URL: url}); err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+	d.Publish(Event{Type: EventScale, Service: "web"})
+	d.dispatch()
+
+	// The fast endpoint is sent to while the slow one is still waited for,
+	// and events can be published meanwhile
+	deadline := time.Now().Add(5 * time.Second)
+	for rec.count() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if rec.count() != 1 {
+		t.Fatalf("Expected the fast endpoint to get its delivery, got %d", rec.count())
+	}
+	d.Publish(Event{Type: EventScale, Service: "api"})
+	d.Flush()
+	if rec.count() != 2 {
+		t.Errorf("Expected the fast endpoint to get the second delivery, got %d", rec.count())
+	}
+
+	// Deliveries queued before the pending index existed are picked up again
+	keys, _ := store.List(pendingKeyPrefix)
+	for _, key := range keys {
+		store.Delete(key)
+	}
+	d.Prune()
+	if keys, _ := store.List(pendingKeyPrefix); len(keys) != 2 {
+		t.Errorf("Expected the slow endpoint's deliveries to be indexed, got %v", keys)
+	}
+}
+
+func TestCreateSubscriptionValidation(t *testing.T) {
+	d := NewDispatcher(state.NewMemoryStateStore(), nil, Options{})
+	tests := []struct {
+		name string
+		sub  Subscription
+	}{
+		{"no URL", Subscription{}},
+		{"not http", Subscription{URL: "ftp://example.com"}},
+		{"no host", Subscription{URL: "https://"}},
+		{"unknown event", Subscription{URL: "https://example.com", Events: []string{"deploy.exploded"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := d.CreateSubscription(tt.sub); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+
+	sub, err := d.CreateSubscription(Subscription{URL: "https://example.com/hook", Secret: "mine"})
+	if err != nil || sub.Secret != "mine" || sub.ID == "" {
+		t.Errorf("Expected the given secret to be kept, got %+v, %v", sub, err)
+	}
+}
+
+func TestNodeEvents(t *testing.T) {
+	o := sim.New(sim.Options{})
+	clusters := cluster.Single(o)
+	clusters.CheckHealth(context.Background())
+	t.Cleanup(clusters.Stop)
+
+	rec, url := newReceiver(t)
+	d := NewDispatcher(state.NewMemoryStateStore(), clusters, Options{})
+	if _, err := d.CreateSubscription(Subscription{URL: url, Events: []string{EventNodeDown, EventNodeUp}}); err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+
+	// The first check only learns the nodes' states
+	d.CheckNodes()
+	o.SetNodeDown("worker-1", true)
+	d.CheckNodes()
+	d.CheckNodes()
+	o.SetNodeDown("worker-1", false)
+	d.CheckNodes()
+	d.Flush()
+
+	if rec.count() != 2 {
+		t.Fatalf("Expected two node events, got %d", rec.count())
+	}
+	var down, up Event
+	json.Unmarshal(rec.bodies[0], &down)
+	json.Unmarshal(rec.bodies[1], &up)
+	if down.Type != EventNodeDown || down.Node != "worker-1" || down.Cluster != "default" || down.Data["reason"] != "down" {
+		t.Errorf("Expected worker-1 to go down, got %+v", down)
+	}
+	if up.Type != EventNodeUp || up.Node != "worker-1" {
+		t.Errorf("Expected worker-1 to come up, got %+v", up)
+	}
+}
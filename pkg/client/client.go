@@ -2,39 +2,100 @@ package client
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net"
 	"time"
 
 	"github.com/jasonlovesdoggo/velo/api/proto"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
 // Client represents a client for the Velo API
 type Client struct {
-	conn   *grpc.ClientConn
-	client proto.DeploymentServiceClient
+	conn    *grpc.ClientConn
+	client  proto.DeploymentServiceClient
+	cluster proto.ClusterServiceClient
+	alerts  proto.AlertServiceClient
+	hooks   proto.WebhookServiceClient
+	audit   proto.AuditServiceClient
+	users   proto.UserServiceClient
+	auth    proto.AuthServiceClient
+	tokens  proto.TokenServiceClient
+
+	// clusterName selects the cluster requests are sent to, empty for the server's default
+	clusterName string
 }
 
 // NewClientWithConn creates a new client with an existing connection (for testing)
 func NewClientWithConn(conn *grpc.ClientConn) *Client {
 	return &Client{
-		conn:   conn,
-		client: proto.NewDeploymentServiceClient(conn),
+		conn:    conn,
+		client:  proto.NewDeploymentServiceClient(conn),
+		cluster: proto.NewClusterServiceClient(conn),
+		alerts:  proto.NewAlertServiceClient(conn),
+		hooks:   proto.NewWebhookServiceClient(conn),
+		audit:   proto.NewAuditServiceClient(conn),
+		users:   proto.NewUserServiceClient(conn),
+		auth:    proto.NewAuthServiceClient(conn),
+		tokens:  proto.NewTokenServiceClient(conn),
+	}
+}
+
+// Option configures a client
+type Option func(*[]grpc.DialOption)
+
+// WithToken authenticates every request with an API token
+func WithToken(token string) Option {
+	return func(opts *[]grpc.DialOption) {
+		if token != "" {
+			*opts = append(*opts, grpc.WithPerRPCCredentials(BearerToken(token)))
+		}
 	}
 }
 
+// WithDialOptions adds gRPC dial options, e.g. interceptors
+func WithDialOptions(dialOpts ...grpc.DialOption) Option {
+	return func(opts *[]grpc.DialOption) {
+		*opts = append(*opts, dialOpts...)
+	}
+}
+
+// bearerToken sends a token in the authorization metadata of every request
+type bearerToken string
+
+// BearerToken returns credentials that send a token as "authorization: Bearer <token>"
+func BearerToken(token string) credentials.PerRPCCredentials {
+	return bearerToken(token)
+}
+
+func (t bearerToken) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+// RequireTransportSecurity allows tokens over plaintext connections, as the
+// manager doesn't serve TLS yet
+func (t bearerToken) RequireTransportSecurity() bool {
+	return false
+}
+
 // NewClient creates a new client for the Velo API
-func NewClient(serverAddr string) (*Client, error) {
-	// Set up a connection to the server
-	conn, err := grpc.NewClient(
-		serverAddr,
+func NewClient(serverAddr string, opts ...Option) (*Client, error) {
+	dialOpts := []grpc.DialOption{
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
 			d := &net.Dialer{}
 			return d.DialContext(ctx, "tcp", addr)
 		}),
-	)
+	}
+	for _, opt := range opts {
+		opt(&dialOpts)
+	}
+
+	// Set up a connection to the server
+	conn, err := grpc.NewClient(serverAddr, dialOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -43,11 +104,28 @@ func NewClient(serverAddr string) (*Client, error) {
 	client := proto.NewDeploymentServiceClient(conn)
 
 	return &Client{
-		conn:   conn,
-		client: client,
+		conn:    conn,
+		client:  client,
+		cluster: proto.NewClusterServiceClient(conn),
+		alerts:  proto.NewAlertServiceClient(conn),
+		hooks:   proto.NewWebhookServiceClient(conn),
+		audit:   proto.NewAuditServiceClient(conn),
+		users:   proto.NewUserServiceClient(conn),
+		auth:    proto.NewAuthServiceClient(conn),
+		tokens:  proto.NewTokenServiceClient(conn),
 	}, nil
 }
 
+// UseCluster sends subsequent requests to the named cluster. An empty name selects the server's default.
+func (c *Client) UseCluster(name string) {
+	c.clusterName = name
+}
+
+// Conn returns the client's connection, e.g. for other services served by the manager
+func (c *Client) Conn() *grpc.ClientConn {
+	return c.conn
+}
+
 // Close closes the client connection
 func (c *Client) Close() error {
 	if c.conn != nil {
@@ -63,17 +141,66 @@ func (c *Client) Deploy(ctx context.Context, serviceName, image string, env map[
 		ServiceName: serviceName,
 		Image:       image,
 		Env:         env,
+		Cluster:     c.clusterName,
 	}
 
 	// Call the Deploy method
 	return c.client.Deploy(ctx, req)
 }
 
+// DeployWithRequest deploys a service using a fully populated request
+func (c *Client) DeployWithRequest(ctx context.Context, req *proto.DeployRequest) (*proto.DeployResponse, error) {
+	if req.Cluster == "" {
+		req.Cluster = c.clusterName
+	}
+	return c.client.Deploy(ctx, req)
+}
+
+// DeployStream deploys a service like DeployWithRequest, writing the output of
+// its deploy hooks to output as they run
+func (c *Client) DeployStream(ctx context.Context, req *proto.DeployRequest, output io.Writer) (*proto.DeployResponse, error) {
+	if req.Cluster == "" {
+		req.Cluster = c.clusterName
+	}
+	stream, err := c.client.DeployStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		update, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("deploy stream ended without a result")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(update.Output) > 0 {
+			if _, err := output.Write(update.Output); err != nil {
+				return nil, err
+			}
+		}
+		if update.Result != nil {
+			return update.Result, nil
+		}
+	}
+}
+
+// GetPolicy returns the policy file deploys are checked against, empty if there's none
+func (c *Client) GetPolicy(ctx context.Context) (string, error) {
+	resp, err := c.client.GetPolicy(ctx, &proto.GetPolicyRequest{})
+	if err != nil {
+		return "", err
+	}
+	return resp.Policy, nil
+}
+
 // GetStatus gets the status of a deployment
 func (c *Client) GetStatus(ctx context.Context, deploymentID string) (*proto.StatusResponse, error) {
 	// Create a status request
 	req := &proto.StatusRequest{
 		DeploymentId: deploymentID,
+		Cluster:      c.clusterName,
 	}
 
 	// Call the GetStatus method
@@ -85,13 +212,293 @@ func (c *Client) Rollback(ctx context.Context, deploymentID string) (*proto.Gene
 	// Create a rollback request
 	req := &proto.RollbackRequest{
 		DeploymentId: deploymentID,
+		Cluster:      c.clusterName,
 	}
 
 	// Call the Rollback method
 	return c.client.Rollback(ctx, req)
 }
 
+// Scale changes the number of replicas of a deployment
+func (c *Client) Scale(ctx context.Context, deploymentID string, replicas int) (*proto.GenericResponse, error) {
+	return c.client.Scale(ctx, &proto.ScaleRequest{
+		DeploymentId: deploymentID,
+		Replicas:     int32(replicas),
+		Cluster:      c.clusterName,
+	})
+}
+
+// GetCapacity returns the reserved resources and headroom of every node
+func (c *Client) GetCapacity(ctx context.Context) (*proto.CapacityResponse, error) {
+	return c.cluster.GetCapacity(ctx, &proto.CapacityRequest{Cluster: c.clusterName})
+}
+
+// DrainNode drains a node and calls progress for every update until the drain completes
+func (c *Client) DrainNode(ctx context.Context, nodeID string, timeout time.Duration, force bool, progress func(*proto.DrainNodeProgress)) error {
+	// Create a drain request
+	req := &proto.DrainNodeRequest{
+		NodeId:         nodeID,
+		TimeoutSeconds: int64(timeout.Seconds()),
+		Force:          force,
+		Cluster:        c.clusterName,
+	}
+
+	stream, err := c.cluster.DrainNode(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	for {
+		update, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if progress != nil {
+			progress(update)
+		}
+	}
+}
+
+// ActivateNode makes a node available for scheduling again
+func (c *Client) ActivateNode(ctx context.Context, nodeID string) (*proto.GenericResponse, error) {
+	return c.cluster.ActivateNode(ctx, &proto.NodeRequest{NodeId: nodeID, Cluster: c.clusterName})
+}
+
+// Rebalance force-updates services so their tasks spread over all active nodes
+func (c *Client) Rebalance(ctx context.Context) (*proto.RebalanceResponse, error) {
+	return c.cluster.Rebalance(ctx, &proto.RebalanceRequest{Cluster: c.clusterName})
+}
+
+// ListNodes returns the nodes of the selected cluster
+func (c *Client) ListNodes(ctx context.Context) (*proto.ListNodesResponse, error) {
+	return c.cluster.ListNodes(ctx, &proto.ListNodesRequest{Cluster: c.clusterName})
+}
+
+// ListClusters returns the clusters registered with the server and their health
+func (c *Client) ListClusters(ctx context.Context) (*proto.ListClustersResponse, error) {
+	return c.cluster.ListClusters(ctx, &proto.ListClustersRequest{})
+}
+
+// SendAgentCommand queues a command for the agent of a node, found by ID or hostname
+func (c *Client) SendAgentCommand(ctx context.Context, node, commandType, target string) (*proto.AgentCommandStatus, error) {
+	return c.cluster.SendAgentCommand(ctx, &proto.AgentCommandRequest{
+		Cluster: c.clusterName,
+		Node:    node,
+		Type:    commandType,
+		Target:  target,
+	})
+}
+
+// GetAgentCommand returns the state of a command sent to an agent
+func (c *Client) GetAgentCommand(ctx context.Context, id string) (*proto.AgentCommandStatus, error) {
+	return c.cluster.GetAgentCommand(ctx, &proto.GetAgentCommandRequest{Cluster: c.clusterName, Id: id})
+}
+
+// GetMetrics returns the resource usage of the selected cluster's containers over
+// the last since, aggregated per step and grouped by service or node
+func (c *Client) GetMetrics(ctx context.Context, service, node, groupBy string, since, step time.Duration) (*proto.MetricsResponse, error) {
+	return c.cluster.GetMetrics(ctx, &proto.MetricsRequest{
+		Cluster:      c.clusterName,
+		Service:      service,
+		Node:         node,
+		GroupBy:      groupBy,
+		SinceSeconds: int64(since.Seconds()),
+		StepSeconds:  int64(step.Seconds()),
+	})
+}
+
+// GetJoinToken returns the swarm join token of the selected cluster and its manager addresses
+func (c *Client) GetJoinToken(ctx context.Context, manager bool) (*proto.JoinTokenResponse, error) {
+	return c.cluster.GetJoinToken(ctx, &proto.JoinTokenRequest{Manager: manager, Cluster: c.clusterName})
+}
+
+// CreateBootstrapToken mints a single-use token a new node joins the selected cluster with
+func (c *Client) CreateBootstrapToken(ctx context.Context, role string, ttl time.Duration, description string) (*proto.BootstrapToken, error) {
+	return c.cluster.CreateBootstrapToken(ctx, &proto.CreateBootstrapTokenRequest{
+		Role:        role,
+		TtlSeconds:  int64(ttl.Seconds()),
+		Description: description,
+		Cluster:     c.clusterName,
+	})
+}
+
+// ListBootstrapTokens returns the bootstrap tokens that are still valid
+func (c *Client) ListBootstrapTokens(ctx context.Context) (*proto.ListBootstrapTokensResponse, error) {
+	return c.cluster.ListBootstrapTokens(ctx, &proto.ListBootstrapTokensRequest{})
+}
+
+// RevokeBootstrapToken invalidates a bootstrap token by its ID
+func (c *Client) RevokeBootstrapToken(ctx context.Context, id string) (*proto.GenericResponse, error) {
+	return c.cluster.RevokeBootstrapToken(ctx, &proto.RevokeBootstrapTokenRequest{Id: id})
+}
+
+// Join exchanges a bootstrap token for what a node needs to join the swarm
+func (c *Client) Join(ctx context.Context, token, hostname string) (*proto.JoinResponse, error) {
+	return c.cluster.Join(ctx, &proto.JoinRequest{Token: token, Hostname: hostname})
+}
+
+// CreateAgentToken creates the token the agent of a node, found by ID or
+// hostname, authenticates with. It replaces the node's previous token.
+func (c *Client) CreateAgentToken(ctx context.Context, node string) (*proto.AgentToken, error) {
+	return c.cluster.CreateAgentToken(ctx, &proto.CreateAgentTokenRequest{Cluster: c.clusterName, Node: node})
+}
+
+// ListAgentTokens returns the agent tokens of the selected cluster
+func (c *Client) ListAgentTokens(ctx context.Context) (*proto.ListAgentTokensResponse, error) {
+	return c.cluster.ListAgentTokens(ctx, &proto.ListAgentTokensRequest{Cluster: c.clusterName})
+}
+
+// RevokeAgentToken invalidates an agent token by its ID
+func (c *Client) RevokeAgentToken(ctx context.Context, id string) (*proto.GenericResponse, error) {
+	return c.cluster.RevokeAgentToken(ctx, &proto.RevokeAgentTokenRequest{Id: id})
+}
+
+// ListAlerts returns the pending and firing alerts
+func (c *Client) ListAlerts(ctx context.Context) (*proto.ListAlertsResponse, error) {
+	return c.alerts.ListAlerts(ctx, &proto.ListAlertsRequest{})
+}
+
+// ListAlertRules returns the alert rules and the receivers configured on the manager
+func (c *Client) ListAlertRules(ctx context.Context) (*proto.ListAlertRulesResponse, error) {
+	return c.alerts.ListAlertRules(ctx, &proto.ListAlertRulesRequest{})
+}
+
+// SetAlertRule creates or replaces an alert rule
+func (c *Client) SetAlertRule(ctx context.Context, rule *proto.AlertRule) (*proto.AlertRule, error) {
+	return c.alerts.SetAlertRule(ctx, rule)
+}
+
+// DeleteAlertRule removes an alert rule by name
+func (c *Client) DeleteAlertRule(ctx context.Context, name string) (*proto.GenericResponse, error) {
+	return c.alerts.DeleteAlertRule(ctx, &proto.DeleteAlertRuleRequest{Name: name})
+}
+
+// ListSilences returns the active silences and the ones that ended recently
+func (c *Client) ListSilences(ctx context.Context) (*proto.ListSilencesResponse, error) {
+	return c.alerts.ListSilences(ctx, &proto.ListSilencesRequest{})
+}
+
+// CreateSilence mutes the alerts matching all matchers for the given duration
+func (c *Client) CreateSilence(ctx context.Context, matchers []string, duration time.Duration, comment, createdBy string) (*proto.Silence, error) {
+	return c.alerts.CreateSilence(ctx, &proto.CreateSilenceRequest{
+		Matchers:        matchers,
+		DurationSeconds: int64(duration.Seconds()),
+		Comment:         comment,
+		CreatedBy:       createdBy,
+	})
+}
+
+// ExpireSilence ends a silence by its ID
+func (c *Client) ExpireSilence(ctx context.Context, id string) (*proto.GenericResponse, error) {
+	return c.alerts.ExpireSilence(ctx, &proto.ExpireSilenceRequest{Id: id})
+}
+
+// CreateWebhook subscribes a URL to deployment events. The response holds the
+// webhook's signing secret, which isn't returned again.
+func (c *Client) CreateWebhook(ctx context.Context, req *proto.CreateWebhookRequest) (*proto.Webhook, error) {
+	return c.hooks.CreateWebhook(ctx, req)
+}
+
+// ListWebhooks returns the webhook subscriptions and the events they can select
+func (c *Client) ListWebhooks(ctx context.Context) (*proto.ListWebhooksResponse, error) {
+	return c.hooks.ListWebhooks(ctx, &proto.ListWebhooksRequest{})
+}
+
+// DeleteWebhook removes a webhook subscription by its ID
+func (c *Client) DeleteWebhook(ctx context.Context, id string) (*proto.GenericResponse, error) {
+	return c.hooks.DeleteWebhook(ctx, &proto.DeleteWebhookRequest{Id: id})
+}
+
+// ListWebhookDeliveries returns the delivery log, newest first, optionally of
+// one webhook. A limit of 0 returns every delivery.
+func (c *Client) ListWebhookDeliveries(ctx context.Context, webhook string, limit int) (*proto.ListWebhookDeliveriesResponse, error) {
+	return c.hooks.ListWebhookDeliveries(ctx, &proto.ListWebhookDeliveriesRequest{Webhook: webhook, Limit: int32(limit)})
+}
+
+// ListAuditEntries lists the audit log entries a request selects, newest first
+func (c *Client) ListAuditEntries(ctx context.Context, req *proto.ListAuditEntriesRequest) (*proto.ListAuditEntriesResponse, error) {
+	return c.audit.ListAuditEntries(ctx, req)
+}
+
 // WithTimeout creates a new context with a timeout
 func WithTimeout(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
 	return context.WithTimeout(parent, timeout)
 }
+
+// ListUsers lists the users and their roles
+func (c *Client) ListUsers(ctx context.Context) (*proto.ListUsersResponse, error) {
+	return c.users.ListUsers(ctx, &proto.ListUsersRequest{})
+}
+
+// CreateUser creates a user with a role
+func (c *Client) CreateUser(ctx context.Context, username, password, role string) (*proto.User, error) {
+	return c.users.CreateUser(ctx, &proto.CreateUserRequest{Username: username, Password: password, Role: role})
+}
+
+// CreateUserWithRequest creates a user, or a service account, from a full request
+func (c *Client) CreateUserWithRequest(ctx context.Context, req *proto.CreateUserRequest) (*proto.User, error) {
+	return c.users.CreateUser(ctx, req)
+}
+
+// DeleteUser deletes a user
+func (c *Client) DeleteUser(ctx context.Context, username string) (*proto.GenericResponse, error) {
+	return c.users.DeleteUser(ctx, &proto.DeleteUserRequest{Username: username})
+}
+
+// SetUserRole changes the role of a user
+func (c *Client) SetUserRole(ctx context.Context, username, role string) (*proto.User, error) {
+	return c.users.SetUserRole(ctx, &proto.SetUserRoleRequest{Username: username, Role: role})
+}
+
+// GrantRole grants a user a role on a project or service
+func (c *Client) GrantRole(ctx context.Context, username string, binding *proto.RoleBinding) (*proto.User, error) {
+	return c.users.GrantRole(ctx, &proto.RoleBindingRequest{Username: username, Binding: binding})
+}
+
+// RevokeRole revokes a role a user was granted on a project or service
+func (c *Client) RevokeRole(ctx context.Context, username string, binding *proto.RoleBinding) (*proto.User, error) {
+	return c.users.RevokeRole(ctx, &proto.RoleBindingRequest{Username: username, Binding: binding})
+}
+
+// Login logs a user in and returns their token
+func (c *Client) Login(ctx context.Context, username, password string) (*proto.LoginResponse, error) {
+	return c.auth.Login(ctx, &proto.LoginRequest{Username: username, Password: password})
+}
+
+// Logout revokes the token the client authenticates with
+func (c *Client) Logout(ctx context.Context) (*proto.GenericResponse, error) {
+	return c.auth.Logout(ctx, &proto.LogoutRequest{})
+}
+
+// GetCurrentUser returns the user the client authenticates as
+func (c *Client) GetCurrentUser(ctx context.Context) (*proto.User, error) {
+	return c.auth.GetCurrentUser(ctx, &proto.GetCurrentUserRequest{})
+}
+
+// ChangePassword changes the password of the user the client authenticates
+// as, or sets another user's password when username is set
+func (c *Client) ChangePassword(ctx context.Context, currentPassword, newPassword, username string) (*proto.GenericResponse, error) {
+	return c.auth.ChangePassword(ctx, &proto.ChangePasswordRequest{
+		CurrentPassword: currentPassword,
+		NewPassword:     newPassword,
+		Username:        username,
+	})
+}
+
+// CreateAPIToken creates an API token. Its secret is only returned here.
+func (c *Client) CreateAPIToken(ctx context.Context, req *proto.CreateAPITokenRequest) (*proto.CreateAPITokenResponse, error) {
+	return c.tokens.CreateAPIToken(ctx, req)
+}
+
+// ListAPITokens lists your API tokens, or everyone's with all
+func (c *Client) ListAPITokens(ctx context.Context, all bool) (*proto.ListAPITokensResponse, error) {
+	return c.tokens.ListAPITokens(ctx, &proto.ListAPITokensRequest{All: all})
+}
+
+// RevokeAPIToken revokes an API token by its ID
+func (c *Client) RevokeAPIToken(ctx context.Context, id string) (*proto.GenericResponse, error) {
+	return c.tokens.RevokeAPIToken(ctx, &proto.RevokeAPITokenRequest{Id: id})
+}
@@ -1,5 +1,7 @@
 package node
 
+import "time"
+
 type Info struct {
 	ID           string            `json:"id"`
 	Hostname     string            `json:"hostname"`
@@ -10,6 +12,8 @@ type Info struct {
 	Role         string            `json:"role"`
 	Manager      bool              `json:"is_manager"`
 	Availability string            `json:"availability"`
+	Agent        *Agent            `json:"agent,omitempty"` // nil if no agent ever registered for the node
+	AgentMissing bool              `json:"agent_missing"`   // the node's agent isn't sending heartbeats
 }
 
 type Resources struct {
@@ -18,3 +22,32 @@ type Resources struct {
 	Disk   int64 `json:"disk_bytes"`
 	GPU    int   `json:"gpu_count"`
 }
+
+// Agent is what the Velo agent running on a node reports about it
+type Agent struct {
+	ID            string      `json:"id"`
+	Version       string      `json:"version"`
+	DockerVersion string      `json:"docker_version"`
+	OS            string      `json:"os"`
+	Arch          string      `json:"arch"`
+	KernelVersion string      `json:"kernel_version"`
+	Registered    time.Time   `json:"registered"`
+	LastHeartbeat time.Time   `json:"last_heartbeat"`
+	Health        string      `json:"health"` // healthy or degraded
+	Problems      []string    `json:"problems,omitempty"`
+	Containers    []Container `json:"containers"`
+}
+
+// Container is a container on a node, as reported by its agent
+type Container struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Image        string `json:"image"`
+	State        string `json:"state"`
+	Health       string `json:"health,omitempty"`
+	Service      string `json:"service,omitempty"`
+	TaskID       string `json:"task_id,omitempty"`
+	RestartCount int    `json:"restart_count"`
+	ExitCode     int    `json:"exit_code"`
+	OOMKilled    bool   `json:"oom_killed"`
+}